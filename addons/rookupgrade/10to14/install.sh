@@ -78,7 +78,7 @@ function rookupgrade_10to14_upgrade() {
             bail "Failed to verify the updated cluster, Ceph is not healthy"
         fi
 
-        "$DIR"/bin/kurl rook hostpath-to-block
+        "$DIR"/bin/kurl rook hostpath-to-block --yes
 
         logStep "Upgrading to Rook 1.1.9"
         if ! "$DIR"/bin/kurl rook wait-for-health 300 ; then
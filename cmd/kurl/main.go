@@ -23,6 +23,9 @@ func main() {
 		if errors.Is(err, cli.ErrWarn) {
 			os.Exit(3)
 		}
+		if errors.Is(err, cli.ErrTimeout) {
+			os.Exit(4)
+		}
 		os.Exit(1)
 	}
 }
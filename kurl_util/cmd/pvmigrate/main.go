@@ -4,12 +4,15 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
 	"strings"
+	"text/tabwriter"
 	"time"
 
+	"code.cloudfoundry.org/bytefmt"
 	clusterspace "github.com/replicatedhq/kurl/pkg/cluster/space"
 	"github.com/replicatedhq/kurl/pkg/version"
 	"github.com/replicatedhq/pvmigrate/pkg/migrate"
@@ -22,6 +25,9 @@ import (
 
 const openEBSLocalProvisioner = "openebs.io/local"
 
+// openEBSSpaceCheckConcurrency is how many nodes have their free space measured at the same time.
+const openEBSSpaceCheckConcurrency = 5
+
 func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer stop()
@@ -30,6 +36,7 @@ func main() {
 	var skipPreflightValidation bool
 	var preflightValidationOnly bool
 	var printVersion bool
+	var recordEvents bool
 	var podReadyTimeout int
 	var deletePVTimeout int
 	var opts migrate.Options
@@ -42,6 +49,7 @@ func main() {
 	flag.BoolVar(&opts.VerboseCopy, "verbose-copy", false, "show output from the rsync command used to copy data between PVCs")
 	flag.BoolVar(&opts.SkipSourceValidation, "skip-source-validation", false, "migrate from PVCs using a particular StorageClass name, even if that StorageClass does not exist")
 	flag.BoolVar(&skipFreeSpaceCheck, "skip-free-space-check", false, "skips the check for storage free space prior to running the migrations")
+	flag.BoolVar(&recordEvents, "events", false, "record kubernetes events describing the progress of the storage free space check")
 	flag.BoolVar(&printVersion, "version", false, "Print the version of the client")
 	flag.IntVar(&podReadyTimeout, "pod-ready-timeout", 60, "length of time to wait (in seconds) for volume validation pod(s) to go into Ready phase")
 	flag.IntVar(&deletePVTimeout, "delete-pv-timeout", 300, "length of time to wait (in seconds) for backing PV to be removed when temporary PVC is deleted")
@@ -76,7 +84,7 @@ func main() {
 	}
 
 	if !skipFreeSpaceCheck {
-		if err := checkFreeSpace(ctx, logger, cfg, cli, opts); err != nil {
+		if err := checkFreeSpace(ctx, logger, cfg, cli, opts, recordEvents); err != nil {
 			logger.Fatalf("failed to check cluster free space: %s", err)
 		}
 	}
@@ -101,7 +109,7 @@ func main() {
 	}
 }
 
-func checkFreeSpace(ctx context.Context, logger *log.Logger, cfg *rest.Config, cli kubernetes.Interface, opts migrate.Options) error {
+func checkFreeSpace(ctx context.Context, logger *log.Logger, cfg *rest.Config, cli kubernetes.Interface, opts migrate.Options, recordEvents bool) error {
 	logger.Printf("Checking if there is enough space to complete the storage migration")
 	sclasses, err := cli.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
 	if err != nil {
@@ -125,21 +133,41 @@ func checkFreeSpace(ctx context.Context, logger *log.Logger, cfg *rest.Config, c
 	}
 
 	if dstProvisioner == openEBSLocalProvisioner {
-		dfchecker, err := clusterspace.NewOpenEBSDiskSpaceValidator(cfg, logger, opts.RsyncImage, opts.SourceSCName, opts.DestSCName)
+		options := []clusterspace.OpenEBSOption{
+			clusterspace.WithDeletePVTimeout(opts.DeletePVTimeout),
+			clusterspace.WithConcurrency(openEBSSpaceCheckConcurrency),
+		}
+		if recordEvents {
+			recorder, flush := clusterspace.NewEventRecorder(cli)
+			defer flush()
+			options = append(options, clusterspace.WithEventRecorder(recorder))
+		}
+
+		dfchecker, err := clusterspace.NewOpenEBSDiskSpaceValidator(
+			cfg, logger, opts.RsyncImage, opts.SourceSCName, opts.DestSCName, options...,
+		)
 		if err != nil {
 			return fmt.Errorf("failed to create openebs free space checker: %w", err)
 		}
 
-		nodes, err := dfchecker.NodesWithoutSpace(ctx)
+		results, err := dfchecker.NodesSpace(ctx)
 		if err != nil {
 			return fmt.Errorf("failed to check nodes free space: %w", err)
 		}
+		printNodesSpace(logger.Writer(), results)
+
+		var nodes []string
+		for _, result := range results {
+			if result.Status == clusterspace.NodeSpaceFail {
+				nodes = append(nodes, fmt.Sprintf("%s (needs %s more)", result.NodeName, bytefmt.ByteSize(uint64(result.Shortfall()))))
+			}
+		}
 
 		if len(nodes) == 0 {
 			return nil
 		}
 
-		return fmt.Errorf("some nodes do not have enough disk space for the migration: %s", strings.Join(nodes, ","))
+		return fmt.Errorf("some nodes do not have enough disk space for the migration: %s", strings.Join(nodes, ", "))
 	}
 
 	rookProvisioners := map[string]bool{
@@ -167,3 +195,21 @@ func checkFreeSpace(ctx context.Context, logger *log.Logger, cfg *rest.Config, c
 	logger.Printf("Skipping disk space check, provisioner %s not supported.", dstProvisioner)
 	return nil
 }
+
+// printNodesSpace prints a table with the free, used and required space of each node.
+func printNodesSpace(w io.Writer, results []clusterspace.NodeSpaceResult) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NODE\tFREE\tUSED\tREQUIRED\tROOT VOLUME\tSTATUS")
+	for _, result := range results {
+		fmt.Fprintf(
+			tw, "%s\t%s\t%s\t%s\t%t\t%s\n",
+			result.NodeName,
+			bytefmt.ByteSize(uint64(max(result.FreeBytes, 0))),
+			bytefmt.ByteSize(uint64(result.UsedBytes)),
+			bytefmt.ByteSize(uint64(result.RequiredBytes)),
+			result.RootVolume,
+			result.Status,
+		)
+	}
+	_ = tw.Flush()
+}
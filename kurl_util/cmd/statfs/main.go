@@ -0,0 +1,34 @@
+//go:build linux
+
+// statfs prints, for each provided path, the total, free and available space of the filesystem
+// holding it in the fixed format parsed by the openebs free disk space checker. it is meant to be
+// built statically and shipped in the checker image as a replacement for df. the kurl packages
+// are not imported so the binary stays small, the line format must match the one parsed by
+// pkg/cluster/space:
+//
+// KURL_STATFS v1 <total> <free> <available> <path>
+package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s PATH...\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	for _, path := range os.Args[1:] {
+		var st syscall.Statfs_t
+		if err := syscall.Statfs(path, &st); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to statfs %s: %s\n", path, err)
+			os.Exit(1)
+		}
+
+		bsize := uint64(st.Bsize)
+		fmt.Printf("KURL_STATFS v1 %d %d %d %s\n", st.Blocks*bsize, st.Bfree*bsize, st.Bavail*bsize, path)
+	}
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"code.cloudfoundry.org/bytefmt"
+)
+
+const (
+	// bytesFormatShort prints byte amounts using single letter units (K, M, G, etc), this is the
+	// default format.
+	bytesFormatShort = "short"
+	// bytesFormatRaw prints byte amounts as plain integers.
+	bytesFormatRaw = "raw"
+	// bytesFormatHuman prints byte amounts using binary units (KiB, MiB, GiB, etc).
+	bytesFormatHuman = "human"
+)
+
+// binaryUnits are the units used when formatting bytes in a human readable way, each one is 1024 times
+// bigger than the previous one.
+var binaryUnits = []string{"KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+
+// humanBytes returns the provided amount of bytes in a human readable format (e.g. 512B, 1.5KiB, 6.8GiB).
+// amounts smaller than 1KiB are returned as bytes, bigger amounts are rounded to one decimal place.
+func humanBytes(b int64) string {
+	sign := ""
+	abs := uint64(b)
+	if b < 0 {
+		sign = "-"
+		abs = uint64(-(b + 1)) + 1
+	}
+
+	if abs < 1024 {
+		return fmt.Sprintf("%s%dB", sign, abs)
+	}
+
+	value := float64(abs) / 1024
+	unit := 0
+	for value >= 1024 && unit < len(binaryUnits)-1 {
+		value /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%s%.1f%s", sign, value, binaryUnits[unit])
+}
+
+// formatBytes formats the provided amount of bytes according to format (bytesFormatShort, bytesFormatRaw or
+// bytesFormatHuman). an empty format is taken as bytesFormatShort.
+func formatBytes(b int64, format string) string {
+	switch format {
+	case bytesFormatRaw:
+		return strconv.FormatInt(b, 10)
+	case bytesFormatHuman:
+		return humanBytes(b)
+	}
+
+	if b < 0 {
+		return "-" + bytefmt.ByteSize(uint64(-(b+1))+1)
+	}
+	return bytefmt.ByteSize(uint64(b))
+}
+
+// validateBytesFormat returns an error if the provided bytes format is not supported.
+func validateBytesFormat(format string) error {
+	if format != bytesFormatShort && format != bytesFormatRaw && format != bytesFormatHuman {
+		return fmt.Errorf("invalid bytes format %q, valid formats: %s, %s, %s", format, bytesFormatShort, bytesFormatRaw, bytesFormatHuman)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_humanBytes(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		bytes    int64
+		expected string
+	}{
+		{name: "zero", bytes: 0, expected: "0B"},
+		{name: "one byte", bytes: 1, expected: "1B"},
+		{name: "right below one kibibyte", bytes: 1023, expected: "1023B"},
+		{name: "one kibibyte", bytes: 1024, expected: "1.0KiB"},
+		{name: "one and a half kibibyte", bytes: 1536, expected: "1.5KiB"},
+		{name: "right below one mebibyte", bytes: 1024*1024 - 1, expected: "1024.0KiB"},
+		{name: "one mebibyte", bytes: 1024 * 1024, expected: "1.0MiB"},
+		{name: "df available output", bytes: 7327760384, expected: "6.8GiB"},
+		{name: "one tebibyte", bytes: 1 << 40, expected: "1.0TiB"},
+		{name: "one pebibyte", bytes: 1 << 50, expected: "1.0PiB"},
+		{name: "max int64", bytes: math.MaxInt64, expected: "8.0EiB"},
+		{name: "negative", bytes: -1536, expected: "-1.5KiB"},
+		{name: "min int64", bytes: math.MinInt64, expected: "-8.0EiB"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := humanBytes(tt.bytes); result != tt.expected {
+				t.Errorf("expected %q, %q received", tt.expected, result)
+			}
+		})
+	}
+}
+
+func Test_formatBytes(t *testing.T) {
+	for _, format := range []string{"", bytesFormatShort} {
+		if result := formatBytes(7327760384, format); result != "6.8G" {
+			t.Errorf("expected short bytes for format %q, %q received", format, result)
+		}
+	}
+
+	if result := formatBytes(-1073741824, bytesFormatShort); result != "-1G" {
+		t.Errorf("expected negative short bytes, %q received", result)
+	}
+
+	if result := formatBytes(7327760384, bytesFormatRaw); result != "7327760384" {
+		t.Errorf("expected raw bytes, %q received", result)
+	}
+
+	if result := formatBytes(7327760384, bytesFormatHuman); result != "6.8GiB" {
+		t.Errorf("expected human readable bytes, %q received", result)
+	}
+
+	if err := validateBytesFormat("invalid"); err == nil {
+		t.Errorf("expected error for invalid format")
+	}
+}
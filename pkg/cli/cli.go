@@ -10,6 +10,7 @@ import (
 	"github.com/replicatedhq/kurl/pkg/preflight"
 	"github.com/spf13/afero"
 	"github.com/spf13/viper"
+	"k8s.io/client-go/rest"
 )
 
 // CLI contains the required methods for a kurl CLI
@@ -23,6 +24,8 @@ type CLI interface {
 	Stderr() io.Writer
 	Logger() *log.Logger
 	DebugLogger() *log.Logger
+	Namespace() string
+	KubeConfig() (*rest.Config, error)
 }
 
 // KurlCLI is the real implementation of the kurl CLI
@@ -99,6 +102,8 @@ func (cli *KurlCLI) SetStderr(w io.Writer) {
 const (
 	logDebugFlag   = "debug"
 	logDebugPrefix = "DEBUG: "
+	namespaceFlag  = "namespace"
+	kubeconfigFlag = "kubeconfig"
 )
 
 // Logger returns the logger that should be used for standard log output.
@@ -115,3 +120,17 @@ func (cli *KurlCLI) DebugLogger() *log.Logger {
 	}
 	return log.New(io.Discard, "", 0)
 }
+
+// Namespace returns the namespace provided through the persistent --namespace flag. an empty
+// string is returned when the flag has not been set, commands then use their own default.
+func (cli *KurlCLI) Namespace() string {
+	return cli.GetViper().GetString(namespaceFlag)
+}
+
+// KubeConfig returns the kubernetes client configuration. the kubeconfig provided through the
+// persistent --kubeconfig flag is used if set, otherwise the KUBECONFIG environment variable, the
+// in-cluster configuration when running inside a pod, and the kubeconfig in the user home
+// directory are tried in this order.
+func (cli *KurlCLI) KubeConfig() (*rest.Config, error) {
+	return restConfig(cli.GetViper().GetString(kubeconfigFlag))
+}
@@ -3,24 +3,29 @@ package cli
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
 	"syscall"
+	"time"
 
 	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
 
-	"code.cloudfoundry.org/bytefmt"
 	rookcli "github.com/rook/rook/pkg/client/clientset/versioned"
 	"github.com/spf13/cobra"
 
 	clusterspace "github.com/replicatedhq/kurl/pkg/cluster/space"
+	"github.com/replicatedhq/kurl/pkg/k8sutil"
 )
 
 const (
@@ -28,7 +33,7 @@ const (
 	// pvmigrate project, it may be any image containing 'df' and 'cat' commands.
 	defaultOpenEBSPodImage          = "eeacms/rsync:2.3"
 	isDefaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
-	openEBSLocalProvisioner         = "openebs.io/local"
+	openEBSLocalProvisioner         = clusterspace.OpenEBSLocalProvisioner
 	rookRBDProvisioner              = "rook-ceph.rbd.csi.ceph.com"
 	rookCephFSProvisioner           = "rook-ceph.cephfs.csi.ceph.com"
 )
@@ -58,86 +63,663 @@ func getStorageClassByName(ctx context.Context, kubeCli kubernetes.Interface, st
 	return nil, fmt.Errorf("failed to find storage class %q", storageClassName)
 }
 
+// requiredSpacePresets maps kURL add-ons to the minimum amount of free space they need in the storage. these are the
+// default sizes for the volumes created by the add-ons. new add-ons can be supported by adding them to this map.
+var requiredSpacePresets = map[string]resource.Quantity{
+	"registry":   resource.MustParse("50Gi"),
+	"minio":      resource.MustParse("10Gi"),
+	"prometheus": resource.MustParse("10Gi"),
+	"kotsadm":    resource.MustParse("4Gi"),
+}
+
+// requiredSpacePresetNames returns the sorted list of preset names.
+func requiredSpacePresetNames() []string {
+	var names []string
+	for name := range requiredSpacePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// requiredSpace returns the amount of bytes that must be available in the storage. the value is read either from biggerThan,
+// a quantity as used when defining storage requests in Kubernetes, or from the required space preset with the provided name.
+// returns zero if none has been provided.
+func requiredSpace(biggerThan, preset string) (int64, error) {
+	if biggerThan != "" && preset != "" {
+		return 0, fmt.Errorf("bigger than and required space preset can't be used together")
+	}
+
+	if preset != "" {
+		quantity, ok := requiredSpacePresets[preset]
+		if !ok {
+			return 0, fmt.Errorf("unknown required space preset %q, valid presets: %s", preset, strings.Join(requiredSpacePresetNames(), ", "))
+		}
+		return quantity.Value(), nil
+	}
+
+	if biggerThan == "" {
+		return 0, nil
+	}
+
+	parsed, err := resource.ParseQuantity(biggerThan)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s as a quantity: %w", biggerThan, err)
+	}
+	return parsed.Value(), nil
+}
+
 // hasEnoughSpace compares if free space is bigger than the requested space. returns a user friendly string representing the output
-// and a bool indicating if there is or not enough room. this function is an auxiliar function so we don't need to keep concatenating
-// the output strings in the evaluateOpenEBSFreeSpace function.
-func hasEnoughSpace(node string, free, requested int64) (string, bool) {
-	requestedString := bytefmt.ByteSize(uint64(requested))
-	freeString := bytefmt.ByteSize(uint64(free))
-	if free >= requested {
-		message := fmt.Sprintf("Node %s has %s available", node, freeString)
-		if requested > 0 {
-			message = fmt.Sprintf("%s (requested %s)", message, requestedString)
+// and the node result classifying the free space as OK, WARN (less than grace percent above the requested space) or FAIL. this
+// function is an auxiliar function so we don't need to keep concatenating the output strings in the evaluateOpenEBSFreeSpace
+// function. byte amounts are printed according to format. when the storage keeps more than one replica of each byte the free
+// space is compared against the effective requirement, the requested space multiplied by the number of replicas.
+func hasEnoughSpace(node string, free, requested int64, replicas int, grace float64, format string) (string, clusterspace.NodeSpaceResult) {
+	effective := clusterspace.ReplicatedRequirement(requested, replicas)
+	result := clusterspace.NewNodeSpaceResult(node, free, effective, grace)
+	requestedString := requirementString(requested, replicas, format)
+	freeString := formatBytes(free, format)
+	switch result.Status {
+	case clusterspace.NodeSpaceFail:
+		return fmt.Sprintf("Not enough space on node %s (requested %s, available %s)", node, requestedString, freeString), result
+	case clusterspace.NodeSpaceWarn:
+		return fmt.Sprintf("Node %s has %s available (requested %s), less than %v%% above the requested space", node, freeString, requestedString, grace), result
+	}
+
+	message := fmt.Sprintf("Node %s has %s available", node, freeString)
+	if requested > 0 {
+		message = fmt.Sprintf("%s (requested %s)", message, requestedString)
+	}
+	return message, result
+}
+
+// requirementString returns the requested space formatted according to format. if more than one replica is kept the effective
+// requirement is included as well.
+func requirementString(requested int64, replicas int, format string) string {
+	if replicas <= 1 {
+		return formatBytes(requested, format)
+	}
+	return fmt.Sprintf(
+		"%s, effective %s with %d replicas",
+		formatBytes(requested, format), formatBytes(clusterspace.ReplicatedRequirement(requested, replicas), format), replicas,
+	)
+}
+
+// openEBSFreeSpaceOpts holds the options used when evaluating the free space in a storage class backed by openEBSLocalProvisioner.
+// getterOpts are passed as they are to the openebs free space getter, see openEBSGetterOptions. onNode is the node name (empty
+// for all nodes) while the biggerThan is expressed in bytes.
+type openEBSFreeSpaceOpts struct {
+	getterOpts     clusterspace.OpenEBSOptions
+	onNode         string
+	biggerThan     int64
+	debug          bool
+	noCache        bool
+	cacheTTL       time.Duration
+	junitOutput    string
+	pendingPVCs    bool
+	bytesFormat    string
+	byTopology     bool
+	overcommit     float64
+	nodeExporter   clusterspace.NodeExporterSource
+	gracePercent   float64
+	strict         bool
+	reserves       clusterspace.ReservePolicies
+	quiet          bool
+	maxVolume      bool
+	replicas       int
+	followLogs     bool
+	bundle         string
+	imageConfigMap string
+}
+
+// resolveOpenEBSImage returns the image to be used by the openebs checker. when the image has not been explicitly provided and
+// a config map reference in the [namespace/]name format has, the image is read from the config map key instead. the config map
+// namespace defaults to the provided namespace or to "default" if empty.
+func resolveOpenEBSImage(ctx context.Context, kubeCli kubernetes.Interface, image string, explicit bool, ref, key, namespace string) (string, error) {
+	if explicit || ref == "" {
+		return image, nil
+	}
+
+	namespace, name := parseConfigMapRef(ref, namespace)
+	resolved, err := k8sutil.ConfigMapValue(ctx, kubeCli, namespace, name, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to read openebs image from config map: %w", err)
+	}
+	return resolved, nil
+}
+
+// parseConfigMapRef splits a config map reference in the [namespace/]name format. the namespace defaults to the provided
+// namespace or to "default" if empty.
+func parseConfigMapRef(ref, namespace string) (string, string) {
+	name := ref
+	if idx := strings.Index(ref, "/"); idx != -1 {
+		namespace, name = ref[:idx], ref[idx+1:]
+	}
+	if namespace == "" {
+		namespace = "default"
+	}
+	return namespace, name
+}
+
+// openEBSCachePath returns the path for the file where openebs volume measurements are cached.
+func openEBSCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user cache directory: %w", err)
+	}
+	return filepath.Join(dir, "kurl", "openebs-volumes.json"), nil
+}
+
+// openEBSGetterOptions returns the openebs free space getter options held by the command options, along with the logger,
+// the node-exporter source and the followed logs destination. the local cache is not configured here, see
+// newOpenEBSFreeSpaceGetter.
+func openEBSGetterOptions(logger *log.Logger, opts openEBSFreeSpaceOpts) clusterspace.OpenEBSOptions {
+	getterOpts := opts.getterOpts
+	getterOpts.Log = logger
+
+	if opts.nodeExporter.Selector != "" {
+		getterOpts.NodeExporter = &opts.nodeExporter
+	}
+
+	if opts.followLogs {
+		getterOpts.Job.FollowLogs = os.Stderr
+	}
+	return getterOpts
+}
+
+// newOpenEBSFreeSpaceGetter returns an openebs free space getter configured according to the provided options.
+func newOpenEBSFreeSpaceGetter(kubeCli kubernetes.Interface, logger *log.Logger, opts openEBSFreeSpaceOpts) (*clusterspace.OpenEBSFreeDiskSpaceGetter, error) {
+	getterOpts := openEBSGetterOptions(logger, opts)
+	if !opts.noCache && opts.cacheTTL > 0 {
+		path, err := openEBSCachePath()
+		if err != nil {
+			return nil, err
 		}
-		return message, true
+
+		if getterOpts.Cache, err = clusterspace.NewOpenEBSVolumeCache(path, opts.cacheTTL); err != nil {
+			return nil, fmt.Errorf("failed to create openebs volume cache: %w", err)
+		}
+	}
+
+	freeSpaceGetter, err := clusterspace.NewOpenEBSFreeDiskSpaceGetterWithOptions(kubeCli, getterOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start openebs free space getter: %w", err)
 	}
-	return fmt.Sprintf("Not enough space on node %s (requested %s, available %s)", node, requestedString, freeString), false
+	return freeSpaceGetter, nil
 }
 
-// evaluateOpenEBSFreeSpace checks how much space is available in a storage class backed by openEBSLocalProvisioner. biggerThan is
-// used to check if there is enough room in one node (if onNode != "") or in all nodes (onNode == ""). onNode is the node name, image
-// is the image to be used by the openebs disk free checker pod while the biggerThan is expressed in bytes.
-func evaluateOpenEBSFreeSpace(ctx context.Context, kubeCli kubernetes.Interface, image, scname, onNode string, biggerThan int64, debug bool) error {
+// evaluateOpenEBSFreeSpace checks how much space is available in a storage class backed by openEBSLocalProvisioner. opts.biggerThan
+// is used to check if there is enough room in one node (if opts.onNode != "") or in all nodes (opts.onNode == ""). if a support
+// bundle has been requested it is written once the evaluation finishes, whether it passed or not.
+func evaluateOpenEBSFreeSpace(ctx context.Context, kubeCli kubernetes.Interface, opts openEBSFreeSpaceOpts) (err error) {
 	logger := log.New(io.Discard, "", 0)
-	if debug {
+	if opts.debug {
 		logger = log.New(os.Stderr, "", 0)
 	}
 
-	freeSpaceGetter, err := clusterspace.NewOpenEBSFreeDiskSpaceGetter(kubeCli, logger, image, scname)
+	freeSpaceGetter, err := newOpenEBSFreeSpaceGetter(kubeCli, logger, opts)
 	if err != nil {
-		return fmt.Errorf("failed to start openebs free space getter: %w", err)
+		return err
+	}
+
+	var volumes map[string]clusterspace.OpenEBSVolume
+	var checks []nodeSpaceCheck
+	if opts.bundle != "" {
+		defer func() {
+			results := newSupportBundleResults(freeSpaceGetter, opts.getterOpts.DstSC, volumes, checks, err)
+			files := collectSupportBundle(context.Background(), kubeCli, freeSpaceGetter, results)
+			if berr := writeSupportBundle(opts.bundle, files, time.Now()); berr != nil {
+				fmt.Fprintf(os.Stderr, "Failed to write support bundle: %s\n", berr)
+				return
+			}
+			fmt.Fprintf(os.Stderr, "Support bundle written to %s\n", opts.bundle)
+		}()
 	}
 
-	volumes, err := freeSpaceGetter.OpenEBSVolumes(ctx)
+	if err := freeSpaceGetter.Validate(ctx); err != nil {
+		return fmt.Errorf("unsupported cluster: %w", err)
+	}
+
+	volumes, err = freeSpaceGetter.OpenEBSVolumes(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get openebs free space: %w", err)
 	}
 
-	successOutput := bytes.NewBuffer(nil)
-	for node, volume := range volumes {
-		var msg string
-		var hasSpace bool
-		if node == onNode {
-			if msg, hasSpace = hasEnoughSpace(node, volume.Free, biggerThan); !hasSpace {
-				return fmt.Errorf(msg)
-			}
-			fmt.Println(msg)
+	// in quiet mode everything printed while evaluating the nodes is held back and only emitted,
+	// along with the per node details, if any of the nodes fails.
+	out := io.Writer(os.Stdout)
+	diagnostics := bytes.NewBuffer(nil)
+	if opts.quiet {
+		out = diagnostics
+	}
+
+	if opts.debug {
+		fmt.Fprintf(out, "Run ID: %s\n", freeSpaceGetter.RunID())
+	}
+
+	if opts.pendingPVCs {
+		if volumes, err = subtractOpenEBSPendingDemand(ctx, out, kubeCli, volumes, opts.getterOpts.DstSC, opts.bytesFormat); err != nil {
+			return err
+		}
+	}
+
+	checks, err = checkOpenEBSNodesSpace(volumes, opts)
+	if err != nil {
+		return err
+	}
+
+	reportSkippedNodes(out, freeSpaceGetter.SkippedNodes())
+
+	if opts.getterOpts.Output.ResolvePath != "" {
+		reportResolvedPaths(out, volumes, opts.getterOpts.Output.ResolvePath)
+	}
+
+	if opts.getterOpts.Output.MountSource != "" || opts.getterOpts.Output.MountMatch == clusterspace.MountMatchBlockDevice {
+		reportMountSources(out, volumes)
+	}
+	reportBindMounts(out, volumes)
+
+	if opts.getterOpts.DetectThinPools {
+		reportThinPools(out, volumes, opts)
+	}
+
+	if opts.maxVolume {
+		reportMaxProvisionable(out, volumes, opts)
+	}
+
+	if opts.byTopology {
+		if err := reportTopologySegments(ctx, out, freeSpaceGetter, volumes, opts); err != nil {
+			return err
+		}
+	}
+
+	if opts.junitOutput != "" {
+		if err := writeJUnitReport(opts.junitOutput, opts.getterOpts.DstSC, checks); err != nil {
+			return err
+		}
+	}
+
+	return reportOpenEBSChecks(os.Stdout, diagnostics.Bytes(), checks, opts)
+}
+
+// reportTopologySegments prints the free space of the provided volumes grouped by the allowed topologies of
+// the storage class, nothing is printed unless the storage class uses the WaitForFirstConsumer binding mode.
+func reportTopologySegments(ctx context.Context, out io.Writer, freeSpaceGetter *clusterspace.OpenEBSFreeDiskSpaceGetter, volumes map[string]clusterspace.OpenEBSVolume, opts openEBSFreeSpaceOpts) error {
+	segments, err := freeSpaceGetter.TopologySegments(ctx, volumes)
+	if err != nil {
+		return fmt.Errorf("failed to group openebs free space by topology: %w", err)
+	}
+
+	for _, segment := range segments {
+		fmt.Fprintf(
+			out, "Topology %s (nodes: %s) has %s available, at most %s in a single node\n",
+			segment.Name,
+			strings.Join(segment.Nodes, ", "),
+			formatBytes(segment.Free, opts.bytesFormat),
+			formatBytes(segment.MaxNodeFree, opts.bytesFormat),
+		)
+	}
+	return nil
+}
+
+// evaluateLocalVolumesFreeSpace checks how much space is available in a storage class backed by statically defined local
+// persistent volumes (clusterspace.LocalVolumeProvisioner). the free space of every available local volume is printed and,
+// as a claim is bound to a single volume, opts.biggerThan is compared against the largest available volume in each node.
+func evaluateLocalVolumesFreeSpace(ctx context.Context, kubeCli kubernetes.Interface, opts openEBSFreeSpaceOpts) error {
+	logger := log.New(io.Discard, "", 0)
+	if opts.debug {
+		logger = log.New(os.Stderr, "", 0)
+	}
+
+	freeSpaceGetter, err := newOpenEBSFreeSpaceGetter(kubeCli, logger, opts)
+	if err != nil {
+		return err
+	}
+
+	space, err := freeSpaceGetter.LocalVolumesSpace(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get local volumes free space: %w", err)
+	}
+
+	out := io.Writer(os.Stdout)
+	diagnostics := bytes.NewBuffer(nil)
+	if opts.quiet {
+		out = diagnostics
+	}
+
+	if opts.debug {
+		fmt.Fprintf(out, "Run ID: %s\n", freeSpaceGetter.RunID())
+	}
+	reportSkippedNodes(out, freeSpaceGetter.SkippedNodes())
+
+	var nodes []string
+	for node := range space {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	for _, node := range nodes {
+		var names []string
+		for name := range space[node] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			fmt.Fprintf(
+				out, "Local volume %s on node %s has %s available\n",
+				name, node, formatBytes(space[node][name].Free, opts.bytesFormat),
+			)
+		}
+	}
+
+	checks, err := checkOpenEBSNodesSpace(clusterspace.LargestLocalVolumes(space), opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.junitOutput != "" {
+		if err := writeJUnitReport(opts.junitOutput, opts.getterOpts.DstSC, checks); err != nil {
+			return err
+		}
+	}
+
+	return reportOpenEBSChecks(os.Stdout, diagnostics.Bytes(), checks, opts)
+}
+
+// reportOpenEBSChecks prints the outcome of the node checks into out and returns an error with the
+// message of the first failed check. by default the failure is only reported through the returned
+// error. in quiet mode (opts.quiet) a single summary line is printed when all nodes pass
+// while on failure the held back diagnostics and the details for all nodes are printed.
+func reportOpenEBSChecks(out io.Writer, diagnostics []byte, checks []nodeSpaceCheck, opts openEBSFreeSpaceOpts) error {
+	var failed *nodeSpaceCheck
+	var warnings int
+	for i, check := range checks {
+		if !check.passed && failed == nil {
+			failed = &checks[i]
+		}
+		if check.result.Status == clusterspace.NodeSpaceWarn {
+			warnings++
+		}
+	}
+
+	if opts.quiet {
+		if failed == nil {
+			fmt.Fprintf(
+				out, "All %d node(s) have enough space (requested %s, %d warning(s))\n",
+				len(checks), formatBytes(opts.biggerThan, opts.bytesFormat), warnings,
+			)
 			return nil
 		}
 
-		if onNode == "" {
-			if msg, hasSpace = hasEnoughSpace(node, volume.Free, biggerThan); !hasSpace {
-				return fmt.Errorf(msg)
+		out.Write(diagnostics)
+		for _, check := range checks {
+			status := "OK"
+			if !check.passed {
+				status = "FAIL"
+			} else if check.result.Status == clusterspace.NodeSpaceWarn {
+				status = "WARN"
 			}
-			fmt.Fprintf(successOutput, "%s\n", msg)
-			continue
+			fmt.Fprintf(out, "%s: %s\n", status, check.message)
 		}
+		return errors.New(failed.message)
 	}
 
-	if onNode != "" {
-		return fmt.Errorf("failed to collect openebs free space: node %q not found", onNode)
+	if failed != nil {
+		return errors.New(failed.message)
 	}
 
-	fmt.Print(successOutput.String())
+	for _, check := range checks {
+		if check.result.Status == clusterspace.NodeSpaceWarn {
+			fmt.Fprintf(out, "WARN: %s\n", check.message)
+			continue
+		}
+		fmt.Fprintf(out, "%s\n", check.message)
+	}
+
+	if opts.getterOpts.PVC.SkipPVWait {
+		fmt.Fprintln(out, "Temporary PVs cleanup deferred to the storage provisioner")
+	}
 	return nil
 }
 
-// evaluateOpenEBSFreeSpace checks how much space is available in a storage class backed by rookRBDProvisioner or rookCephFSProvisioner. biggerThan
-// is used to compare if there is enough room.
-func evaluateRookFreeSpace(ctx context.Context, kubeCli kubernetes.Interface, rookCli rookcli.Interface, scname string, requested int64) error {
+// subtractOpenEBSPendingDemand decreases the free space of the provided volumes by the amount of storage requested by pending
+// pvcs in the storage class. the pending demand is reported separately. pending pvcs not yet scheduled to a node are subtracted
+// from all nodes.
+func subtractOpenEBSPendingDemand(ctx context.Context, out io.Writer, kubeCli kubernetes.Interface, volumes map[string]clusterspace.OpenEBSVolume, scname, format string) (map[string]clusterspace.OpenEBSVolume, error) {
+	perNode, detached, err := k8sutil.PendingPVCSReservationPerNode(ctx, kubeCli, scname)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate pending pvcs demand: %w", err)
+	}
+
+	if detached > 0 {
+		fmt.Fprintf(out, "Pending PVCs not yet scheduled to a node request %s\n", formatBytes(detached, format))
+	}
+
+	result := map[string]clusterspace.OpenEBSVolume{}
+	for node, volume := range volumes {
+		if perNode[node] > 0 {
+			fmt.Fprintf(out, "Pending PVCs on node %s request %s\n", node, formatBytes(perNode[node], format))
+		}
+		volume.Free -= perNode[node] + detached
+		result[node] = volume
+	}
+	return result, nil
+}
+
+// reportSkippedNodes prints the nodes whose free space could not be measured along with the reason.
+func reportSkippedNodes(out io.Writer, skipped map[string]string) {
+	var nodes []string
+	for node := range skipped {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	for _, node := range nodes {
+		fmt.Fprintf(out, "Node %s skipped: %s\n", node, skipped[node])
+	}
+}
+
+// reportResolvedPaths prints, for each node, the path the provided node path resolves to. this is the
+// path whose filesystem has been measured.
+func reportResolvedPaths(out io.Writer, volumes map[string]clusterspace.OpenEBSVolume, path string) {
+	var nodes []string
+	for node := range volumes {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	for _, node := range nodes {
+		if resolved := volumes[node].Resolved; resolved != "" {
+			fmt.Fprintf(out, "Path %s resolves to %s on node %s\n", path, resolved, node)
+		}
+	}
+}
+
+// reportMountSources prints, for each node, the source of the filesystem selected for the base path
+// in the df output.
+func reportMountSources(out io.Writer, volumes map[string]clusterspace.OpenEBSVolume) {
+	var nodes []string
+	for node := range volumes {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	for _, node := range nodes {
+		if source := volumes[node].Source; source != "" {
+			fmt.Fprintf(out, "Base path measured on %s on node %s\n", source, node)
+		}
+	}
+}
+
+// reportBindMounts prints, for each node whose base path is a bind mount, the directory bound to it.
+func reportBindMounts(out io.Writer, volumes map[string]clusterspace.OpenEBSVolume) {
+	var nodes []string
+	for node := range volumes {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	for _, node := range nodes {
+		if source := volumes[node].BindSource; source != "" {
+			fmt.Fprintf(out, "Base path bind mounted from %s on node %s\n", source, node)
+		}
+	}
+}
+
+// reportMaxProvisionable prints the largest volume that could be provisioned in each node and in the
+// cluster. the storage class reserve policy, if any, is applied.
+func reportMaxProvisionable(out io.Writer, volumes map[string]clusterspace.OpenEBSVolume, opts openEBSFreeSpaceOpts) {
+	reserve, _ := opts.reserves.For(opts.getterOpts.DstSC)
+	capacity := clusterspace.MaxProvisionable(volumes, reserve)
+
+	var nodes []string
+	for node := range capacity.PerNode {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	for _, node := range nodes {
+		fmt.Fprintf(out, "Largest volume provisionable on node %s: %s\n", node, formatBytes(capacity.PerNode[node], opts.bytesFormat))
+	}
+	if capacity.Node != "" {
+		fmt.Fprintf(out, "Largest volume provisionable in the cluster: %s (node %s)\n", formatBytes(capacity.Largest, opts.bytesFormat), capacity.Node)
+	}
+}
+
+// reportThinPools prints the physical utilization of the thin pools found in the nodes. a warning is printed for each
+// pool whose overcommit ratio is bigger than opts.overcommit as df reports logical free space for thin provisioned volumes.
+func reportThinPools(out io.Writer, volumes map[string]clusterspace.OpenEBSVolume, opts openEBSFreeSpaceOpts) {
+	var nodes []string
+	for node := range volumes {
+		if opts.onNode == "" || opts.onNode == node {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.Strings(nodes)
+
+	for _, node := range nodes {
+		for _, pool := range volumes[node].ThinPools {
+			fmt.Fprintf(
+				out, "Thin pool %s on node %s: size %s, %.2f%% used, %s allocated to thin volumes\n",
+				pool, node, formatBytes(pool.Size, opts.bytesFormat), pool.DataPercent, formatBytes(pool.VirtualSize, opts.bytesFormat),
+			)
+			if ratio := pool.Overcommit(); ratio > opts.overcommit {
+				fmt.Fprintf(
+					out, "Warning: thin pool %s on node %s is overcommitted %.2f times (threshold %.2f), free space reported by df may not be available\n",
+					pool, node, ratio, opts.overcommit,
+				)
+			}
+		}
+	}
+}
+
+// checkOpenEBSNodesSpace evaluates if the provided volumes have enough room to hold opts.biggerThan bytes. if opts.onNode is
+// set only the volume for that node is evaluated. returned checks are sorted by node name.
+func checkOpenEBSNodesSpace(volumes map[string]clusterspace.OpenEBSVolume, opts openEBSFreeSpaceOpts) ([]nodeSpaceCheck, error) {
+	if opts.onNode != "" {
+		volume, ok := volumes[opts.onNode]
+		if !ok {
+			return nil, fmt.Errorf("failed to collect openebs free space: node %q not found", opts.onNode)
+		}
+		return []nodeSpaceCheck{checkOpenEBSNodeSpace(opts.onNode, volume, opts)}, nil
+	}
+
+	var checks []nodeSpaceCheck
+	for node, volume := range volumes {
+		checks = append(checks, checkOpenEBSNodeSpace(node, volume, opts))
+	}
+
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].result.NodeName < checks[j].result.NodeName
+	})
+	return checks, nil
+}
+
+// checkOpenEBSNodeSpace checks if the provided node volume has enough space. nodes whose filesystem
+// shows corruption signals fail regardless of the available space. nodes within the grace band only
+// fail in strict mode, as do nodes whose base path shares its device with the container runtime. the
+// space kept free by the storage class reserve policy, if any, is not considered available.
+func checkOpenEBSNodeSpace(node string, volume clusterspace.OpenEBSVolume, opts openEBSFreeSpaceOpts) nodeSpaceCheck {
+	if volume.Health != nil && !volume.Health.Healthy() {
+		return nodeSpaceCheck{
+			result: clusterspace.NodeSpaceResult{
+				NodeName:      node,
+				FreeBytes:     volume.Free,
+				RequiredBytes: opts.biggerThan,
+				Status:        clusterspace.NodeSpaceFail,
+			},
+			message: fmt.Sprintf("Node %s filesystem is unhealthy: %s", node, volume.Health),
+		}
+	}
+
+	free := volume.Free
+	if reserve, ok := opts.reserves.For(opts.getterOpts.DstSC); ok {
+		free -= reserve(volume)
+	}
+
+	msg, result := hasEnoughSpace(node, free, opts.biggerThan, opts.replicas, opts.gracePercent, opts.bytesFormat)
+	if volume.Runtime != nil && volume.Runtime.Shared() && result.Status == clusterspace.NodeSpaceOK {
+		result.Status = clusterspace.NodeSpaceWarn
+		msg = fmt.Sprintf("%s. Warning: base path %s, images compete with volumes for space and i/o", msg, volume.Runtime)
+	}
+	if fstype := volume.UnreliableFilesystem(); fstype != "" && result.Status == clusterspace.NodeSpaceOK {
+		result.Status = clusterspace.NodeSpaceWarn
+		msg = fmt.Sprintf("%s. Warning: base path lives in filesystem type %s, free space measurement is unreliable", msg, fstype)
+	}
+	passed := result.Status == clusterspace.NodeSpaceOK || (result.Status == clusterspace.NodeSpaceWarn && !opts.strict)
+	return nodeSpaceCheck{result: result, message: msg, passed: passed}
+}
+
+// evaluateOpenEBSPermissions verifies if the current credentials are allowed to execute all the
+// operations needed to evaluate the free space in a storage class backed by openEBSLocalProvisioner,
+// including reading the image from the config map when one has been provided. the missing permissions
+// are printed to out, an error is returned if there is any.
+func evaluateOpenEBSPermissions(ctx context.Context, out io.Writer, kubeCli kubernetes.Interface, opts openEBSFreeSpaceOpts) error {
+	freeSpaceGetter, err := newOpenEBSFreeSpaceGetter(kubeCli, log.New(io.Discard, "", 0), opts)
+	if err != nil {
+		return err
+	}
+
+	perms := freeSpaceGetter.RequiredPermissions()
+	if opts.imageConfigMap != "" {
+		namespace, _ := parseConfigMapRef(opts.imageConfigMap, opts.getterOpts.Namespace)
+		perms = append(perms, k8sutil.Permission{Verb: "get", Resource: "configmaps", Namespace: namespace})
+	}
+
+	missing, err := k8sutil.MissingPermissions(ctx, kubeCli, perms)
+	if err != nil {
+		return fmt.Errorf("failed to check permissions: %w", err)
+	}
+
+	if len(missing) == 0 {
+		fmt.Fprintln(out, "All permissions required to check the free disk space are granted")
+		return nil
+	}
+
+	for _, perm := range missing {
+		fmt.Fprintf(out, "Missing permission: %s\n", perm)
+	}
+	return fmt.Errorf("%d permission(s) missing, grant them through a Role/ClusterRole bound to the current user", len(missing))
+}
+
+// evaluateRookFreeSpace checks how much space is available in the scname storage class, backed by rookRBDProvisioner or
+// rookCephFSProvisioner. requested, in bytes, is used to compare if there is enough room. byte amounts are printed according
+// to format.
+func evaluateRookFreeSpace(ctx context.Context, kubeCli kubernetes.Interface, rookCli rookcli.Interface, scname string, requested int64, format string) error {
 	freeSpaceGetter, err := clusterspace.NewRookFreeDiskSpaceGetter(kubeCli, rookCli, scname)
 	if err != nil {
 		return fmt.Errorf("failed to start rook free space getter: %w", err)
 	}
 
-	free, err := freeSpaceGetter.GetFreeSpace(ctx)
+	free, replicas, err := freeSpaceGetter.GetPhysicalFreeSpace(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get rook free space: %w", err)
 	}
 
-	requestedString := bytefmt.ByteSize(uint64(requested))
-	freeString := bytefmt.ByteSize(uint64(free))
-	if free < requested {
+	requestedString := requirementString(requested, replicas, format)
+	freeString := formatBytes(free, format)
+	if free < clusterspace.ReplicatedRequirement(requested, replicas) {
 		return fmt.Errorf("not enough space on rook (requested %s, available %s)", requestedString, freeString)
 	}
 
@@ -150,13 +732,17 @@ func evaluateRookFreeSpace(ctx context.Context, kubeCli kubernetes.Interface, ro
 }
 
 // NewClusterCheckFreeDiskSpaceCmd returns a command that is capable of reporting back the amount of free space in the cluster for a provided storage class.
-func NewClusterCheckFreeDiskSpaceCmd(_ CLI) *cobra.Command {
-	var forStorageClass, openEBSImage, openEBSNode, biggerThanString string
-	var biggerThanBytes int64
+func NewClusterCheckFreeDiskSpaceCmd(cli CLI) *cobra.Command {
+	var forStorageClass, biggerThanString, requirePreset, tmpPVCSize, nodeSelector string
+	var imageConfigMap, imageConfigMapKey string
+	var reservePolicies []string
+	var jobRetries int
+	var openEBSOpts openEBSFreeSpaceOpts
 	var clientSet kubernetes.Interface
 	var rookClientSet rookcli.Interface
 	var selectedClass *storagev1.StorageClass
-	var debug bool
+	var checkRBAC bool
+	var apiFailureThreshold int
 
 	cmd := &cobra.Command{
 		Use:          "check-free-disk-space",
@@ -174,7 +760,9 @@ func NewClusterCheckFreeDiskSpaceCmd(_ CLI) *cobra.Command {
 			"# checks if there is 10G available on all nodes in the cluster\n"+
 			"kurl cluster check-free-disk-space --storageclass openebs --bigger-than 10G\n"+
 			"# checks if there is 20G available in the cluster on the default storage class\n"+
-			"kurl cluster check-free-disk-space --bigger-than 20G\n",
+			"kurl cluster check-free-disk-space --bigger-than 20G\n"+
+			"# checks if there is enough space in all nodes to hold the registry add-on volume\n"+
+			"kurl cluster check-free-disk-space --storageclass openebs --require-preset registry\n",
 			openEBSLocalProvisioner, rookRBDProvisioner, rookCephFSProvisioner,
 		),
 		Long: fmt.Sprintf(""+
@@ -183,15 +771,23 @@ func NewClusterCheckFreeDiskSpaceCmd(_ CLI) *cobra.Command {
 			"free space, while for Rook storage only the available space is returned. When --bigger-than flag is used this program sets the exit code to zero\n"+
 			"if the space available is bigger than the quantity provided. For OpenEBS, if no node has been provided through --openebs-node-name the exit code\n"+
 			"will be zero only if all nodes free space are bigger than the provided quantity (see Examples section for more details).\n\n"+
-			"Supports the following storage provisioners: %s, %s, %s",
-			openEBSLocalProvisioner, rookRBDProvisioner, rookCephFSProvisioner,
+			"Supports the following storage provisioners: %s, %s, %s, %s",
+			openEBSLocalProvisioner, rookRBDProvisioner, rookCephFSProvisioner, clusterspace.LocalVolumeProvisioner,
 		),
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			k8sConfig, err := config.GetConfig()
+			k8sConfig, err := cli.KubeConfig()
 			if err != nil {
 				return fmt.Errorf("failed to read kubernetes configuration: %w", err)
 			}
 
+			if apiFailureThreshold > 0 {
+				// the temporary resources must be removed even after the breaker trips.
+				if openEBSOpts.getterOpts.CleanupClient, err = kubernetes.NewForConfig(k8sConfig); err != nil {
+					return fmt.Errorf("failed to create kubernetes cleanup client: %w", err)
+				}
+				k8sConfig = k8sutil.WithCircuitBreaker(k8sConfig, k8sutil.NewCircuitBreaker(apiFailureThreshold))
+			}
+
 			clientSet, err = kubernetes.NewForConfig(k8sConfig)
 			if err != nil {
 				return fmt.Errorf("failed to create kubernetes client: %w", err)
@@ -202,24 +798,72 @@ func NewClusterCheckFreeDiskSpaceCmd(_ CLI) *cobra.Command {
 				return fmt.Errorf("failed to create rook client: %w", err)
 			}
 
-			debug, err = cmd.Flags().GetBool("debug")
+			openEBSOpts.debug, err = cmd.Flags().GetBool("debug")
 			if err != nil {
 				return fmt.Errorf("failed to read persistent debug flag: %w", err)
 			}
+			openEBSOpts.getterOpts.Namespace = cli.Namespace()
 
 			if selectedClass, err = getStorageClassByName(cmd.Context(), clientSet, forStorageClass); err != nil {
 				return err
 			}
+			openEBSOpts.getterOpts.DstSC = selectedClass.Name
 
-			if biggerThanString == "" {
-				return nil
+			// the config map access is reported, instead of required, when only checking the permissions.
+			if !cmd.Flags().Changed("openebs-image") {
+				openEBSOpts.imageConfigMap = imageConfigMap
+			}
+			if !checkRBAC && (selectedClass.Provisioner == openEBSLocalProvisioner || selectedClass.Provisioner == clusterspace.LocalVolumeProvisioner) {
+				if openEBSOpts.getterOpts.Image, err = resolveOpenEBSImage(
+					cmd.Context(), clientSet, openEBSOpts.getterOpts.Image, cmd.Flags().Changed("openebs-image"),
+					imageConfigMap, imageConfigMapKey, openEBSOpts.getterOpts.Namespace,
+				); err != nil {
+					return err
+				}
 			}
 
-			parsed, err := resource.ParseQuantity(biggerThanString)
-			if err != nil {
-				return fmt.Errorf("failed to parse %s as a quantity: %w", biggerThanString, err)
+			if err := validateBytesFormat(openEBSOpts.bytesFormat); err != nil {
+				return err
+			}
+
+			if openEBSOpts.getterOpts.Parallelism < 1 {
+				return fmt.Errorf("parallelism must be at least 1")
+			}
+			if openEBSOpts.getterOpts.CreateRate < 0 {
+				return fmt.Errorf("create rate can't be negative")
+			}
+			if openEBSOpts.getterOpts.PVC.MaxInflight < 0 {
+				return fmt.Errorf("max inflight pvcs can't be negative")
+			}
+			if openEBSOpts.getterOpts.StorageClassTimeout <= 0 {
+				return fmt.Errorf("storage class timeout must be positive")
+			}
+			if openEBSOpts.getterOpts.PVC.DeletePVTimeout <= 0 {
+				return fmt.Errorf("delete pv timeout must be positive")
+			}
+			if openEBSOpts.getterOpts.PVC.Size, err = resource.ParseQuantity(tmpPVCSize); err != nil {
+				return fmt.Errorf("failed to parse temporary pvc size: %w", err)
+			}
+			if openEBSOpts.getterOpts.PVC.Size.Sign() <= 0 {
+				return fmt.Errorf("temporary pvc size must be positive")
+			}
+			if nodeSelector != "" {
+				if openEBSOpts.getterOpts.NodeSelector, err = labels.Parse(nodeSelector); err != nil {
+					return fmt.Errorf("failed to parse node selector: %w", err)
+				}
+			}
+
+			if openEBSOpts.biggerThan, err = requiredSpace(biggerThanString, requirePreset); err != nil {
+				return err
+			}
+
+			if openEBSOpts.reserves, err = clusterspace.ParseReservePolicies(reservePolicies); err != nil {
+				return err
+			}
+
+			if openEBSOpts.replicas, err = clusterspace.StorageClassReplicas(*selectedClass); err != nil {
+				return err
 			}
-			biggerThanBytes = parsed.Value()
 
 			return nil
 		},
@@ -229,10 +873,16 @@ func NewClusterCheckFreeDiskSpaceCmd(_ CLI) *cobra.Command {
 
 			switch selectedClass.Provisioner {
 			case openEBSLocalProvisioner:
-				return evaluateOpenEBSFreeSpace(ctx, clientSet, openEBSImage, selectedClass.Name, openEBSNode, biggerThanBytes, debug)
+				if checkRBAC {
+					return evaluateOpenEBSPermissions(ctx, os.Stdout, clientSet, openEBSOpts)
+				}
+				return evaluateOpenEBSFreeSpace(ctx, clientSet, openEBSOpts)
+
+			case clusterspace.LocalVolumeProvisioner:
+				return evaluateLocalVolumesFreeSpace(ctx, clientSet, openEBSOpts)
 
 			case rookCephFSProvisioner, rookRBDProvisioner:
-				return evaluateRookFreeSpace(ctx, clientSet, rookClientSet, selectedClass.Name, biggerThanBytes)
+				return evaluateRookFreeSpace(ctx, clientSet, rookClientSet, selectedClass.Name, openEBSOpts.biggerThan, openEBSOpts.bytesFormat)
 
 			default:
 				fmt.Printf("Provisioner %q is not supported, unable to determine free space.\n", selectedClass.Provisioner)
@@ -243,7 +893,59 @@ func NewClusterCheckFreeDiskSpaceCmd(_ CLI) *cobra.Command {
 
 	cmd.Flags().StringVar(&forStorageClass, "storageclass", "", "Inform the storage class name for which to check the free disk space. If not informed the default storage will be used.")
 	cmd.Flags().StringVar(&biggerThanString, "bigger-than", "", "Compares if the cluster free disk space is bigger than the provided value. Accepts the same format as used when defining storage requests in Kubernetes (e.g. 10G, 5Gi, 500M).")
-	cmd.Flags().StringVar(&openEBSImage, "openebs-image", defaultOpenEBSPodImage, fmt.Sprintf("The image used by OpenEBS disk free evaluation pod. If not informed the default image used is %s", defaultOpenEBSPodImage))
-	cmd.Flags().StringVar(&openEBSNode, "openebs-node-name", "", "Evaluates OpenEBS free disk space only for the provided node name.")
+	cmd.Flags().StringVar(&requirePreset, "require-preset", "", fmt.Sprintf("Compares if the cluster free disk space is bigger than the space required by a kURL add-on. Valid presets: %s.", strings.Join(requiredSpacePresetNames(), ", ")))
+	cmd.Flags().StringVar(&openEBSOpts.getterOpts.Image, "openebs-image", defaultOpenEBSPodImage, fmt.Sprintf("The image used by OpenEBS disk free evaluation pod. If not informed the default image used is %s", defaultOpenEBSPodImage))
+	cmd.Flags().StringSliceVar(&openEBSOpts.getterOpts.Job.ImagePullSecrets, "image-pull-secret", nil, "Names of the secrets, in the kURL namespace, used to pull the OpenEBS disk free evaluation image. May be repeated.")
+	cmd.Flags().StringVar(&imageConfigMap, "openebs-image-configmap", "", "Reads the OpenEBS disk free evaluation pod image from a config map ([namespace/]name). Ignored if --openebs-image is provided.")
+	cmd.Flags().StringVar(&imageConfigMapKey, "openebs-image-configmap-key", "image", "The key holding the image in the --openebs-image-configmap config map.")
+	cmd.Flags().StringVar(&openEBSOpts.onNode, "openebs-node-name", "", "Evaluates OpenEBS free disk space only for the provided node name.")
+	cmd.Flags().BoolVar(&openEBSOpts.followLogs, "follow-logs", false, "Streams the OpenEBS disk free evaluation pods logs to stderr as they are produced, prefixed with the job and container names. Useful to debug a hanging node.")
+	cmd.Flags().BoolVar(&openEBSOpts.getterOpts.Job.RunAsPod, "openebs-run-as-pod", false, "Runs the OpenEBS disk free evaluation as a bare pod instead of a job (for namespaces where jobs are not allowed).")
+	cmd.Flags().BoolVar(&checkRBAC, "check-rbac", false, "Only verifies if the current credentials have all the permissions needed to check the OpenEBS free disk space, as kurl preflight --check-rbac does.")
+	cmd.Flags().BoolVar(&openEBSOpts.getterOpts.Output.StrictParse, "strict-parse", false, "Fails if the OpenEBS df output deviates from the expected format instead of doing a best-effort parse.")
+	cmd.Flags().StringVar(&openEBSOpts.getterOpts.Output.StatfsBinary, "statfs-binary", "", "Path, inside the OpenEBS disk free evaluation image, of the kURL statfs helper. When provided it is used instead of df to measure the free space.")
+	cmd.Flags().StringVar(&openEBSOpts.getterOpts.Output.ResolvePath, "resolve-path", "", "Measures the filesystem backing this node path, after resolving its symlinks in the node, instead of the OpenEBS base path one. The resolved path is reported.")
+	cmd.Flags().StringVar(&openEBSOpts.bundle, "support-bundle", "", "Writes a gzip compressed tarball with the OpenEBS free disk space results, the per node job logs, the storage class definition, the nodes conditions and the job manifests into the provided file, whether the check passes or not.")
+	cmd.Flags().StringVar(&openEBSOpts.junitOutput, "junit-output", "", "Writes the OpenEBS free disk space check results, one test case per node, as a JUnit XML report into the provided file.")
+	cmd.Flags().Float64Var(&openEBSOpts.gracePercent, "grace-percent", 0, "Nodes whose free space is less than this percentage above the requested space (--bigger-than) are reported as warnings.")
+	cmd.Flags().BoolVar(&openEBSOpts.quiet, "quiet-on-success", false, "Prints a single summary line when all nodes have enough space. When any node fails all the per node details and diagnostics are printed.")
+	cmd.Flags().BoolVar(&openEBSOpts.maxVolume, "max-provisionable", false, "Reports the largest volume that could be provisioned in each node and in the cluster, after the storage class reserve policy (--reserve-policy).")
+	cmd.Flags().BoolVar(&openEBSOpts.strict, "strict", false, "Fails when any node free space falls within the grace band (--grace-percent).")
+	cmd.Flags().StringSliceVar(&reservePolicies, "reserve-policy", nil, "Space kept free in each node for a storage class, as storageclass=policy. The policy is an absolute quantity (e.g. 10Gi), a percentage of the volume size (e.g. 20%) or one of: none, root-disk. May be repeated.")
+	cmd.Flags().BoolVar(&openEBSOpts.pendingPVCs, "include-pending-pvcs", false, "Subtracts the space requested by pending PVCs in the storage class from the OpenEBS free disk space.")
+	cmd.Flags().StringVar(&openEBSOpts.bytesFormat, "bytes", bytesFormatShort, fmt.Sprintf("How byte amounts are printed, %q for single letter units (e.g. 6.8G), %q for plain integers or %q for binary units (e.g. 6.8GiB).", bytesFormatShort, bytesFormatRaw, bytesFormatHuman))
+	cmd.Flags().BoolVar(&openEBSOpts.byTopology, "by-topology", false, "Reports the OpenEBS free space grouped by the storage class allowed topologies, only for storage classes using the WaitForFirstConsumer volume binding mode.")
+	cmd.Flags().BoolVar(&openEBSOpts.getterOpts.DetectThinPools, "detect-thin-pools", false, "Reports the physical utilization of LVM thin pools found in the nodes. Requires a privileged container and an image with nsenter.")
+	cmd.Flags().StringSliceVar(&openEBSOpts.getterOpts.Output.AllowedFSTypes, "allowed-fs-types", nil, "Filesystem types (e.g. xfs,ext4) the OpenEBS base path may live in. Nodes whose base path filesystem type is not in the list fail the check. May be repeated.")
+	cmd.Flags().BoolVar(&openEBSOpts.getterOpts.DetectFSCorruption, "detect-fs-corruption", false, "Fails nodes whose base path filesystem has been remounted read only or whose kernel log reports i/o errors. Requires a privileged container and an image with nsenter.")
+	cmd.Flags().BoolVar(&openEBSOpts.getterOpts.DetectRuntimeDevice, "detect-runtime-device", false, "Warns about nodes whose OpenEBS base path lives in the same device as the container runtime data root. Requires a privileged container and an image with nsenter.")
+	cmd.Flags().StringVar(&openEBSOpts.nodeExporter.Selector, "node-exporter-selector", "", "Label selector of node-exporter pods. When provided the OpenEBS free space is read from their node_filesystem_avail_bytes metric, falling back to jobs for nodes that can't be scraped.")
+	cmd.Flags().StringVar(&openEBSOpts.nodeExporter.Namespace, "node-exporter-namespace", "monitoring", "The namespace where the node-exporter pods live.")
+	cmd.Flags().StringVar(&openEBSOpts.nodeExporter.Port, "node-exporter-port", "9100", "The node-exporter pods metrics port.")
+	cmd.Flags().StringVar(&openEBSOpts.getterOpts.WindowsImage, "windows-image", "", "The image, containing powershell, used to measure the free space in Windows nodes. Windows nodes are skipped if not informed.")
+	cmd.Flags().StringVar(&openEBSOpts.getterOpts.WindowsDrive, "windows-drive", "C", "The drive measured in Windows nodes.")
+	cmd.Flags().Float64Var(&openEBSOpts.overcommit, "thin-pool-overcommit-threshold", 1, "Warns when the space allocated to thin volumes is bigger than the thin pool physical size multiplied by this value.")
+	cmd.Flags().StringToStringVar(&openEBSOpts.getterOpts.Job.Labels, "job-label", nil, "Labels (key=value) added to the OpenEBS disk free evaluation jobs and pods, e.g. for cost allocation. May be repeated.")
+	cmd.Flags().StringToStringVar(&openEBSOpts.getterOpts.BasePathVars, "base-path-var", nil, "Values (name=value) for the placeholders, $name or ${name}, found in templated OpenEBS storage class base paths. May be repeated.")
+	cmd.Flags().StringToStringVar(&openEBSOpts.getterOpts.Job.Annotations, "job-annotation", nil, "Annotations (key=value) added to the OpenEBS disk free evaluation jobs and pods. May be repeated.")
+	cmd.Flags().StringVar(&tmpPVCSize, "tmp-pvc-size", "1Mi", "Storage requested by the OpenEBS temporary PVCs, for provisioners whose minimum volume size is bigger than the default.")
+	cmd.Flags().StringVar(&nodeSelector, "node-selector", "", "Label selector (e.g. '!nvidia.com/gpu') restricting the nodes whose OpenEBS free disk space is evaluated. Nodes not matching it are reported as skipped and no job is scheduled on them.")
+	openEBSOpts.getterOpts.Job.Retries = &jobRetries
+	cmd.Flags().IntVar(&jobRetries, "job-retries", 3, "How many times an OpenEBS disk free evaluation job whose pod could not be scheduled is retried, with an exponential backoff. Jobs whose pod ran and failed are not retried. Zero disables the retries.")
+	cmd.Flags().BoolVar(&openEBSOpts.getterOpts.Job.NoControlPlaneTolerations, "no-control-plane-tolerations", false, "Stops the OpenEBS disk free evaluation jobs from tolerating the control plane taints, leaving tainted control plane nodes unmeasured.")
+	cmd.Flags().BoolVar(&openEBSOpts.getterOpts.PVC.Reuse, "reuse-pvc", false, "Names the OpenEBS temporary PVCs after their nodes only, so a PVC left behind by an interrupted run is adopted instead of a new one being created.")
+	cmd.Flags().DurationVar(&openEBSOpts.getterOpts.PVC.DeletePVTimeout, "delete-pv-timeout", 5*time.Minute, "How long to wait for the OpenEBS temporary PVs to be removed after their PVCs have been deleted. Ignored with --skip-pv-wait.")
+	cmd.Flags().BoolVar(&openEBSOpts.getterOpts.PurgeOrphans, "purge-orphans", false, "Deletes the OpenEBS disk free evaluation jobs, pods and temporary PVCs left behind by interrupted runs before evaluating the free disk space. Resources of other runs still in progress are deleted as well, do not use it with concurrent runs.")
+	cmd.Flags().BoolVar(&openEBSOpts.getterOpts.PVC.SkipPVWait, "skip-pv-wait", false, "Deletes the OpenEBS temporary PVCs without waiting for their PVs to be removed, leaving the cleanup to the storage provisioner.")
+	cmd.Flags().DurationVar(&openEBSOpts.getterOpts.ListNodesTimeout, "list-nodes-timeout", 30*time.Second, "How long to wait for the API server to list the cluster nodes before evaluating the OpenEBS free disk space.")
+	cmd.Flags().DurationVar(&openEBSOpts.getterOpts.StorageClassTimeout, "storage-class-timeout", 10*time.Second, "How long to wait for the API server to return the OpenEBS storage class before evaluating its free disk space.")
+	cmd.Flags().IntVar(&openEBSOpts.getterOpts.Parallelism, "parallelism", 1, "How many nodes have their OpenEBS free disk space evaluated at the same time.")
+	cmd.Flags().Float64Var(&openEBSOpts.getterOpts.CreateRate, "create-rate", 0, "Maximum number of OpenEBS disk free evaluation jobs created per second, so creations do not hit the API server in bursts. Zero means no limit.")
+	cmd.Flags().IntVar(&openEBSOpts.getterOpts.PVC.MaxInflight, "max-inflight-pvcs", 0, "Maximum number of OpenEBS temporary pvcs in flight at the same time, regardless of --parallelism, for provisioners that can't cope with many concurrent provisionings. A pvc is in flight until its node has been measured. Zero means no limit.")
+	cmd.Flags().IntVar(&apiFailureThreshold, "api-failure-threshold", 0, "Aborts after this number of consecutive failed requests to the API server. Zero disables the check.")
+	cmd.Flags().StringVar((*string)(&openEBSOpts.getterOpts.Output.MountMatch), "mount-match", string(clusterspace.MountMatchExact), fmt.Sprintf("How the OpenEBS base path is located in the df output, %q, %q (for bind mounted base paths), %q (for stacked mounts) or %q (for base paths under stacked overlay mounts).", clusterspace.MountMatchExact, clusterspace.MountMatchLongestPrefix, clusterspace.MountMatchInnermost, clusterspace.MountMatchBlockDevice))
+	cmd.Flags().StringVar(&openEBSOpts.getterOpts.Output.MountSource, "mount-source", "", "Filesystem source, as listed by df (e.g. /dev/sda1), to be measured when several df lines match the OpenEBS base path. Takes precedence over --mount-match.")
+	cmd.Flags().BoolVar(&openEBSOpts.noCache, "no-cache", false, "Does not reuse nor store OpenEBS node measurements in the local cache, even if --cache-ttl is provided.")
+	cmd.Flags().DurationVar(&openEBSOpts.cacheTTL, "cache-ttl", 0, "For how long OpenEBS node measurements are stored in and reused from the local cache (e.g. 2m). The cache is disabled by default so every node is measured on each run.")
 	return cmd
 }
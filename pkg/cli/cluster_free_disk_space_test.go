@@ -1,16 +1,24 @@
 package cli
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"log"
+	"os"
 	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+
+	clusterspace "github.com/replicatedhq/kurl/pkg/cluster/space"
 )
 
 func Test_getStorageClassByName(t *testing.T) {
@@ -123,6 +131,7 @@ func Test_hasEnoughSpace(t *testing.T) {
 		nodeName   string
 		free       int64
 		biggerThan int64
+		replicas   int
 		msg        string
 		exp        bool
 	}{
@@ -150,10 +159,37 @@ func Test_hasEnoughSpace(t *testing.T) {
 			msg:        "Node node2 has 1000B available",
 			exp:        true,
 		},
+		{
+			name:       "a single replica should not change the requirement",
+			nodeName:   "node3",
+			free:       1000,
+			biggerThan: 1000,
+			replicas:   1,
+			msg:        "Node node3 has 1000B available (requested 1000B)",
+			exp:        true,
+		},
+		{
+			name:       "two replicas should double the requirement",
+			nodeName:   "node4",
+			free:       500,
+			biggerThan: 300,
+			replicas:   2,
+			msg:        "Not enough space on node node4 (requested 300B, effective 600B with 2 replicas, available 500B)",
+			exp:        false,
+		},
+		{
+			name:       "three replicas should triple the requirement",
+			nodeName:   "node5",
+			free:       900,
+			biggerThan: 300,
+			replicas:   3,
+			msg:        "Node node5 has 900B available (requested 300B, effective 900B with 3 replicas)",
+			exp:        true,
+		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
-			out, ok := hasEnoughSpace(tt.nodeName, tt.free, tt.biggerThan)
-			if ok != tt.exp {
+			out, result := hasEnoughSpace(tt.nodeName, tt.free, tt.biggerThan, tt.replicas, 0, bytesFormatHuman)
+			if ok := result.Status != clusterspace.NodeSpaceFail; ok != tt.exp {
 				t.Errorf("expected %v, received %v", tt.exp, ok)
 			}
 			fmt.Println(out)
@@ -164,3 +200,377 @@ func Test_hasEnoughSpace(t *testing.T) {
 		})
 	}
 }
+
+func Test_checkOpenEBSNodeSpaceReservePolicy(t *testing.T) {
+	reserves := clusterspace.ReservePolicies{
+		"logs":     clusterspace.AbsoluteReserve(100),
+		"database": clusterspace.PercentReserve(50),
+	}
+
+	volume := clusterspace.OpenEBSVolume{Free: 1000, Used: 1000}
+	for _, tt := range []struct {
+		scname string
+		free   int64
+		passed bool
+	}{
+		{scname: "logs", free: 900, passed: true},
+		{scname: "database", free: 0, passed: false},
+		{scname: "openebs", free: 1000, passed: true},
+	} {
+		t.Run(tt.scname, func(t *testing.T) {
+			opts := openEBSFreeSpaceOpts{
+				getterOpts: clusterspace.OpenEBSOptions{
+					DstSC: tt.scname,
+				},
+				biggerThan:  500,
+				bytesFormat: bytesFormatRaw,
+				reserves:    reserves,
+			}
+
+			check := checkOpenEBSNodeSpace("node0", volume, opts)
+			if check.result.FreeBytes != tt.free {
+				t.Errorf("expected %d free bytes, %d received instead", tt.free, check.result.FreeBytes)
+			}
+			if check.passed != tt.passed {
+				t.Errorf("expected passed %v, %v received instead", tt.passed, check.passed)
+			}
+		})
+	}
+}
+
+func Test_reportOpenEBSChecks(t *testing.T) {
+	passing := []nodeSpaceCheck{
+		{
+			result:  clusterspace.NodeSpaceResult{NodeName: "node0", Status: clusterspace.NodeSpaceOK},
+			message: "Node node0 has 100B available (requested 50B)",
+			passed:  true,
+		},
+		{
+			result:  clusterspace.NodeSpaceResult{NodeName: "node1", Status: clusterspace.NodeSpaceWarn},
+			message: "Node node1 has 52B available (requested 50B), less than 10% above the requested space",
+			passed:  true,
+		},
+	}
+	failing := append([]nodeSpaceCheck{}, passing...)
+	failing = append(failing, nodeSpaceCheck{
+		result:  clusterspace.NodeSpaceResult{NodeName: "node2", Status: clusterspace.NodeSpaceFail},
+		message: "Not enough space on node node2 (requested 50B, available 10B)",
+	})
+
+	diagnostics := []byte("Topology zone-a (nodes: node0, node1, node2) has 162B available, at most 100B in a single node\n")
+	for _, tt := range []struct {
+		name     string
+		checks   []nodeSpaceCheck
+		quiet    bool
+		expected string
+		err      string
+	}{
+		{
+			name:   "should print all nodes when all pass",
+			checks: passing,
+			expected: "Node node0 has 100B available (requested 50B)\n" +
+				"WARN: Node node1 has 52B available (requested 50B), less than 10% above the requested space\n",
+		},
+		{
+			name:   "should only return the failure when not quiet",
+			checks: failing,
+			err:    "Not enough space on node node2 (requested 50B, available 10B)",
+		},
+		{
+			name:     "should print a single line when all pass in quiet mode",
+			checks:   passing,
+			quiet:    true,
+			expected: "All 2 node(s) have enough space (requested 50B, 1 warning(s))\n",
+		},
+		{
+			name:   "should print diagnostics and all nodes on failure in quiet mode",
+			checks: failing,
+			quiet:  true,
+			expected: string(diagnostics) +
+				"OK: Node node0 has 100B available (requested 50B)\n" +
+				"WARN: Node node1 has 52B available (requested 50B), less than 10% above the requested space\n" +
+				"FAIL: Not enough space on node node2 (requested 50B, available 10B)\n",
+			err: "Not enough space on node node2 (requested 50B, available 10B)",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			out := bytes.NewBuffer(nil)
+			err := reportOpenEBSChecks(out, diagnostics, tt.checks, openEBSFreeSpaceOpts{
+				biggerThan:  50,
+				bytesFormat: bytesFormatHuman,
+				quiet:       tt.quiet,
+			})
+			if err != nil {
+				if len(tt.err) == 0 {
+					t.Errorf("unexpected error: %s", err)
+				} else if err.Error() != tt.err {
+					t.Errorf("expecting %q, %q received instead", tt.err, err)
+				}
+			} else if len(tt.err) > 0 {
+				t.Errorf("expecting error %q, nil received instead", tt.err)
+			}
+
+			if diff := cmp.Diff(tt.expected, out.String()); diff != "" {
+				t.Errorf("unexpected output: %s", diff)
+			}
+		})
+	}
+}
+
+func Test_requiredSpace(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		biggerThan string
+		preset     string
+		expected   int64
+		err        string
+	}{
+		{
+			name:     "returns zero if nothing has been provided",
+			expected: 0,
+		},
+		{
+			name:       "returns the parsed bigger than quantity",
+			biggerThan: "1Ki",
+			expected:   1024,
+		},
+		{
+			name:       "returns error if bigger than is not a quantity",
+			biggerThan: "abc",
+			err:        "failed to parse abc as a quantity",
+		},
+		{
+			name:     "returns the registry preset",
+			preset:   "registry",
+			expected: 50 * 1024 * 1024 * 1024,
+		},
+		{
+			name:     "returns the minio preset",
+			preset:   "minio",
+			expected: 10 * 1024 * 1024 * 1024,
+		},
+		{
+			name:   "returns error for unknown presets",
+			preset: "does-not-exist",
+			err:    `unknown required space preset "does-not-exist", valid presets: kotsadm, minio, prometheus, registry`,
+		},
+		{
+			name:       "returns error if both preset and bigger than are provided",
+			preset:     "minio",
+			biggerThan: "1Gi",
+			err:        "can't be used together",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			required, err := requiredSpace(tt.biggerThan, tt.preset)
+			if err != nil {
+				if len(tt.err) == 0 {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expecting %q, %q received instead", tt.err, err)
+				}
+				return
+			}
+
+			if len(tt.err) > 0 {
+				t.Errorf("expecting error %q, nil received instead", tt.err)
+			}
+
+			if required != tt.expected {
+				t.Errorf("expected %d, received %d", tt.expected, required)
+			}
+		})
+	}
+}
+
+func Test_resolveOpenEBSImage(t *testing.T) {
+	kcli := fake.NewSimpleClientset(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "kurl-checker", Namespace: "kurl"},
+			Data:       map[string]string{"image": "kurl/checker:v1", "other": ""},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "kurl-checker", Namespace: "default"},
+			Data:       map[string]string{"image": "kurl/checker:default"},
+		},
+	)
+
+	for _, tt := range []struct {
+		name      string
+		explicit  bool
+		ref       string
+		key       string
+		namespace string
+		exp       string
+		err       string
+	}{
+		{
+			name: "keeps the image when no config map is provided",
+			exp:  "ubuntu:latest",
+		},
+		{
+			name:     "keeps the image when explicitly provided",
+			explicit: true,
+			ref:      "kurl/kurl-checker",
+			key:      "image",
+			exp:      "ubuntu:latest",
+		},
+		{
+			name: "reads the image from the config map",
+			ref:  "kurl/kurl-checker",
+			key:  "image",
+			exp:  "kurl/checker:v1",
+		},
+		{
+			name:      "uses the provided namespace when the reference has none",
+			ref:       "kurl-checker",
+			key:       "image",
+			namespace: "kurl",
+			exp:       "kurl/checker:v1",
+		},
+		{
+			name: "uses the default namespace when none is provided",
+			ref:  "kurl-checker",
+			key:  "image",
+			exp:  "kurl/checker:default",
+		},
+		{
+			name: "fails when the key is empty",
+			ref:  "kurl/kurl-checker",
+			key:  "other",
+			err:  "key other not found in config map kurl/kurl-checker",
+		},
+		{
+			name: "fails when the config map does not exist",
+			ref:  "kurl/does-not-exist",
+			key:  "image",
+			err:  "failed to get config map kurl/does-not-exist",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			image, err := resolveOpenEBSImage(context.Background(), kcli, "ubuntu:latest", tt.explicit, tt.ref, tt.key, tt.namespace)
+			if err != nil {
+				if len(tt.err) == 0 {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expecting %q, %q received instead", tt.err, err)
+				}
+				return
+			}
+
+			if len(tt.err) > 0 {
+				t.Errorf("expecting error %q, nil received instead", tt.err)
+			}
+
+			if image != tt.exp {
+				t.Errorf("expected image %q, %q received instead", tt.exp, image)
+			}
+		})
+	}
+}
+
+func Test_openEBSGetterOptionsFollowLogs(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	if opts := openEBSGetterOptions(logger, openEBSFreeSpaceOpts{}); opts.Job.FollowLogs != nil {
+		t.Errorf("expected no log streaming without --follow-logs")
+	}
+	if opts := openEBSGetterOptions(logger, openEBSFreeSpaceOpts{followLogs: true}); opts.Job.FollowLogs != os.Stderr {
+		t.Errorf("expected logs to be streamed to stderr with --follow-logs")
+	}
+}
+
+func Test_openEBSGetterOptionsJobRetries(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	if opts := openEBSGetterOptions(logger, openEBSFreeSpaceOpts{}); opts.Job.Retries != nil {
+		t.Errorf("expected unset retries to be left to the getter default, %d received", *opts.Job.Retries)
+	}
+	if opts := openEBSGetterOptions(logger, openEBSFreeSpaceOpts{getterOpts: clusterspace.OpenEBSOptions{Job: clusterspace.OpenEBSJobOptions{Retries: ptr.To(0)}}}); opts.Job.Retries == nil || *opts.Job.Retries != 0 {
+		t.Errorf("expected --job-retries=0 to disable the retries, %v received", opts.Job.Retries)
+	}
+	if opts := openEBSGetterOptions(logger, openEBSFreeSpaceOpts{getterOpts: clusterspace.OpenEBSOptions{Job: clusterspace.OpenEBSJobOptions{Retries: ptr.To(5)}}}); opts.Job.Retries == nil || *opts.Job.Retries != 5 {
+		t.Errorf("expected 5 retries, %v received", opts.Job.Retries)
+	}
+}
+
+func Test_checkOpenEBSNodeSpaceSharedRuntimeDevice(t *testing.T) {
+	opts := openEBSFreeSpaceOpts{biggerThan: 500, bytesFormat: bytesFormatRaw}
+	volume := clusterspace.OpenEBSVolume{
+		Free:    1000,
+		Runtime: &clusterspace.RuntimeDevice{Device: "8:16", SharedRoots: []string{"/var/lib/containerd"}},
+	}
+
+	check := checkOpenEBSNodeSpace("node0", volume, opts)
+	if check.result.Status != clusterspace.NodeSpaceWarn || !check.passed {
+		t.Errorf("expected a passing warning, received status %s passed %v", check.result.Status, check.passed)
+	}
+	expected := "Node node0 has 1000 available (requested 500). Warning: base path device 8:16 is shared with the container runtime data root /var/lib/containerd, images compete with volumes for space and i/o"
+	if check.message != expected {
+		t.Errorf("expected message %q, received %q", expected, check.message)
+	}
+
+	opts.strict = true
+	if check := checkOpenEBSNodeSpace("node0", volume, opts); check.passed {
+		t.Errorf("expected shared runtime device to fail in strict mode")
+	}
+
+	volume.Runtime = &clusterspace.RuntimeDevice{Device: "8:32"}
+	if check := checkOpenEBSNodeSpace("node0", volume, opts); check.result.Status != clusterspace.NodeSpaceOK {
+		t.Errorf("expected dedicated device to be ok, received %s", check.result.Status)
+	}
+}
+
+func Test_checkOpenEBSNodeSpaceUnreliableFilesystem(t *testing.T) {
+	opts := openEBSFreeSpaceOpts{biggerThan: 500, bytesFormat: bytesFormatRaw}
+	volume := clusterspace.OpenEBSVolume{Free: 1000, Source: "overlay"}
+
+	check := checkOpenEBSNodeSpace("node0", volume, opts)
+	if check.result.Status != clusterspace.NodeSpaceWarn || !check.passed {
+		t.Errorf("expected a passing warning, received status %s passed %v", check.result.Status, check.passed)
+	}
+	expected := "Node node0 has 1000 available (requested 500). Warning: base path lives in filesystem type overlay, free space measurement is unreliable"
+	if check.message != expected {
+		t.Errorf("expected message %q, received %q", expected, check.message)
+	}
+
+	opts.strict = true
+	if check := checkOpenEBSNodeSpace("node0", volume, opts); check.passed {
+		t.Errorf("expected unreliable filesystem to fail in strict mode")
+	}
+
+	volume = clusterspace.OpenEBSVolume{Free: 100, FSType: "tmpfs"}
+	if check := checkOpenEBSNodeSpace("node0", volume, opts); check.result.Status != clusterspace.NodeSpaceFail {
+		t.Errorf("expected not enough space to fail, received %s", check.result.Status)
+	}
+
+	volume = clusterspace.OpenEBSVolume{Free: 1000, FSType: "xfs", Source: "/dev/sdb1"}
+	if check := checkOpenEBSNodeSpace("node0", volume, opts); check.result.Status != clusterspace.NodeSpaceOK {
+		t.Errorf("expected xfs volume to be ok, received %s", check.result.Status)
+	}
+}
+
+func Test_NewClusterCheckFreeDiskSpaceCmdCacheDisabledByDefault(t *testing.T) {
+	cmd := NewClusterCheckFreeDiskSpaceCmd(nil)
+	flag := cmd.Flags().Lookup("cache-ttl")
+	if flag == nil {
+		t.Fatalf("--cache-ttl flag not found")
+	}
+	if flag.DefValue != "0s" {
+		t.Errorf("expected the cache to be disabled by default, --cache-ttl defaults to %s", flag.DefValue)
+	}
+}
+
+func Test_NewClusterCheckFreeDiskSpaceCmdBaselineOutputByDefault(t *testing.T) {
+	cmd := NewClusterCheckFreeDiskSpaceCmd(nil)
+	for name, expected := range map[string]string{
+		"bytes":       bytesFormatShort,
+		"by-topology": "false",
+	} {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			t.Fatalf("--%s flag not found", name)
+		}
+		if flag.DefValue != expected {
+			t.Errorf("expected --%s to default to %s, %s found", name, expected, flag.DefValue)
+		}
+	}
+}
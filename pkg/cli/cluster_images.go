@@ -9,10 +9,9 @@ import (
 	"github.com/replicatedhq/kurl/pkg/cluster"
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/kubernetes"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
 )
 
-func NewClusterNodesMissingImageCmd(_ CLI) *cobra.Command {
+func NewClusterNodesMissingImageCmd(cli CLI) *cobra.Command {
 	var opts cluster.NodeImagesJobOptions
 	var excludeHostDeprecated string
 
@@ -21,7 +20,10 @@ func NewClusterNodesMissingImageCmd(_ CLI) *cobra.Command {
 		Short: "Lists nodes missing the provided image(s). If a node is missing multiple images, it is only returned once.",
 		Args:  cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			k8sConfig := config.GetConfigOrDie()
+			k8sConfig, err := cli.KubeConfig()
+			if err != nil {
+				return fmt.Errorf("failed to read kubernetes configuration: %w", err)
+			}
 			clientSet := kubernetes.NewForConfigOrDie(k8sConfig)
 
 			logger := log.New(os.Stderr, "", 0)
@@ -16,7 +16,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	"k8s.io/client-go/rest"
 )
 
 const (
@@ -29,6 +29,7 @@ const (
 
 type migrateOpts struct {
 	log            *log.Logger
+	kubeConfig     func() (*rest.Config, error)
 	authToken      string
 	ekcoAddress    string
 	readyTimeout   time.Duration
@@ -38,7 +39,7 @@ type migrateOpts struct {
 }
 
 func NewClusterMigrateMultinodeStorageCmd(cli CLI) *cobra.Command {
-	opts := migrateOpts{log: cli.Logger()}
+	opts := migrateOpts{log: cli.Logger(), kubeConfig: cli.KubeConfig}
 
 	cmd := &cobra.Command{
 		Use:   "migrate-multinode-storage",
@@ -171,7 +172,7 @@ func approveStorageMigration(ctx context.Context, opts migrateOpts) error {
 
 	authToken := opts.authToken
 	if authToken == "" {
-		if authToken, err = getEkcoStorageMigrationAuthToken(ctx); err != nil {
+		if authToken, err = getEkcoStorageMigrationAuthToken(ctx, opts); err != nil {
 			return fmt.Errorf("authentication token missing: %w", err)
 		}
 	}
@@ -273,9 +274,9 @@ func runStorageMigration(ctx context.Context, opts migrateOpts) error {
 	return nil
 }
 
-func getEkcoStorageMigrationAuthToken(ctx context.Context) (string, error) {
+func getEkcoStorageMigrationAuthToken(ctx context.Context, opts migrateOpts) (string, error) {
 	// Get kube client
-	k8sConfig, err := config.GetConfig()
+	k8sConfig, err := opts.kubeConfig()
 	if err != nil {
 		return "", fmt.Errorf("failed to read kubernetes configuration: %w", err)
 	}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+
+	clusterspace "github.com/replicatedhq/kurl/pkg/cluster/space"
+)
+
+// NewClusterPurgeDiskSpaceJobsCmd returns a command that deletes, across all namespaces, the finished jobs created by the
+// check-free-disk-space command.
+func NewClusterPurgeDiskSpaceJobsCmd(cli CLI) *cobra.Command {
+	var olderThan time.Duration
+	var runID string
+	var yes bool
+	var clientSet kubernetes.Interface
+
+	cmd := &cobra.Command{
+		Use:          "purge-disk-space-jobs",
+		Short:        "Deletes the finished jobs created when checking the OpenEBS free disk space.",
+		SilenceUsage: true,
+		Example: "" +
+			"# deletes all completed and failed free disk space jobs\n" +
+			"kurl cluster purge-disk-space-jobs\n\n" +
+			"# deletes completed and failed free disk space jobs created more than one day ago\n" +
+			"kurl cluster purge-disk-space-jobs --older-than 24h\n\n" +
+			"# deletes all jobs, pods and temporary pvcs created by a single check, running or not\n" +
+			"kurl cluster purge-disk-space-jobs --run-id 1a2b3c4d\n",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if runID != "" && olderThan != 0 {
+				return fmt.Errorf("--older-than can't be used along with --run-id")
+			}
+
+			action := "Finished free disk space jobs will be deleted in all namespaces"
+			if runID != "" {
+				action = fmt.Sprintf("All free disk space jobs, pods and pvcs of run %s will be deleted", runID)
+			}
+
+			if err := confirmDestructive(
+				cmd.InOrStdin(), cmd.ErrOrStderr(), stdinIsTerminal(), yes, action,
+			); err != nil {
+				return err
+			}
+
+			k8sConfig, err := cli.KubeConfig()
+			if err != nil {
+				return fmt.Errorf("failed to read kubernetes configuration: %w", err)
+			}
+
+			clientSet, err = kubernetes.NewForConfig(k8sConfig)
+			if err != nil {
+				return fmt.Errorf("failed to create kubernetes client: %w", err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if runID != "" {
+				purged, err := clusterspace.PurgeRun(cmd.Context(), clientSet, runID)
+				if err != nil {
+					return fmt.Errorf("failed to purge run %s: %w", runID, err)
+				}
+
+				fmt.Printf("Deleted %d job(s), %d pod(s) and %d pvc(s)\n", purged.Jobs, purged.Pods, purged.PVCs)
+				return nil
+			}
+
+			purged, err := clusterspace.PurgeFinishedJobs(cmd.Context(), clientSet, olderThan)
+			if err != nil {
+				return fmt.Errorf("failed to purge jobs: %w", err)
+			}
+
+			fmt.Printf("Deleted %d completed and %d failed job(s)\n", purged.Completed, purged.Failed)
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&olderThan, "older-than", 0, "Only deletes jobs created more than the provided duration ago (e.g. 1h, 24h).")
+	cmd.Flags().StringVar(&runID, "run-id", "", "Deletes all the jobs, pods and temporary pvcs created by the check with the provided run id.")
+	addConfirmFlag(cmd, &yes)
+	return cmd
+}
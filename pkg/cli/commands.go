@@ -12,6 +12,7 @@ func AddCommands(cmd *cobra.Command, cli CLI) {
 	hostCmd.AddCommand(newHostProtectedidCmd(cli))
 	hostCmd.AddCommand(newHostPreflightCmd(cli))
 	hostCmd.AddCommand(newHostnameCmd(cli))
+	hostCmd.AddCommand(newHostParseFstabCmd(cli))
 	cmd.AddCommand(hostCmd)
 
 	rookCmd := NewRookCmd(cli)
@@ -29,13 +30,31 @@ func AddCommands(cmd *cobra.Command, cli CLI) {
 	longhornCmd.AddCommand(NewLonghornRollbackMigrationReplicas(cli))
 	cmd.AddCommand(longhornCmd)
 
+	openEBSCmd := NewOpenEBSCmd(cli)
+	openEBSCmd.AddCommand(NewOpenEBSValidateBasePathCmd(cli))
+	openEBSCmd.AddCommand(NewOpenEBSBasePathsCmd(cli))
+	openEBSCmd.AddCommand(NewOpenEBSMeasureNodeCmd(cli))
+	cmd.AddCommand(openEBSCmd)
+
 	clusterCmd := NewClusterCmd(cli)
 	clusterCmd.AddCommand(NewClusterNodesMissingImageCmd(cli))
 	clusterCmd.AddCommand(NewClusterCheckFreeDiskSpaceCmd(cli))
+	clusterCmd.AddCommand(NewClusterPurgeDiskSpaceJobsCmd(cli))
 	clusterCmd.AddCommand(newPreflightCmd(cli))
 	clusterCmd.AddCommand(NewClusterMigrateMultinodeStorageCmd(cli))
 	cmd.AddCommand(clusterCmd)
 
+	storageCmd := NewStorageCmd(cli)
+	storageCmd.AddCommand(NewStorageSnapshotCmd(cli))
+	storageCmd.AddCommand(NewStorageWatchCmd(cli))
+	storageCmd.AddCommand(NewStorageHealthCmd(cli))
+	storageCmd.AddCommand(NewStorageListCmd(cli))
+	cmd.AddCommand(storageCmd)
+
+	preflightCmd := newPreflightCommand(cli)
+	preflightCmd.AddCommand(newPreflightAllCmd(cli))
+	cmd.AddCommand(preflightCmd)
+
 	netutilCmd := newNetutilCommand(cli)
 	netutilCmd.AddCommand(newNetutilIfaceFromIPCommand(cli))
 	netutilCmd.AddCommand(newNetutilDefaultIfaceCommand(cli))
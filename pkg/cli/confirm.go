@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// ErrConfirmationRequired is returned when a destructive command runs without a terminal and
+// without the --yes flag.
+var ErrConfirmationRequired = errors.New("refusing to run a destructive operation without confirmation, use --yes to proceed")
+
+// ErrNotConfirmed is returned when the user does not confirm a destructive operation.
+var ErrNotConfirmed = errors.New("operation aborted")
+
+// addConfirmFlag registers the --yes flag, used to skip the confirmation prompt of destructive
+// commands, in the provided command.
+func addConfirmFlag(cmd *cobra.Command, yes *bool) {
+	cmd.Flags().BoolVarP(yes, "yes", "y", false, "Skips the confirmation prompt, required when running without a terminal.")
+}
+
+// stdinIsTerminal returns true if the standard input is a terminal.
+func stdinIsTerminal() bool {
+	return isatty.IsTerminal(os.Stdin.Fd()) || isatty.IsCygwinTerminal(os.Stdin.Fd())
+}
+
+// confirmDestructive asks the user to confirm the destructive operation described by action. the
+// prompt is skipped if yes is true. when no terminal is attached the operation is refused unless
+// yes is true as there is nobody to answer the prompt.
+func confirmDestructive(in io.Reader, out io.Writer, isTerminal, yes bool, action string) error {
+	if yes {
+		return nil
+	}
+
+	if !isTerminal {
+		return ErrConfirmationRequired
+	}
+
+	fmt.Fprintf(out, "%s. This operation can't be undone, do you want to continue? [y/N]: ", action)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("failed to read confirmation: %w", err)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return ErrNotConfirmed
+}
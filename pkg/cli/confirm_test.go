@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func Test_confirmDestructive(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		input      string
+		isTerminal bool
+		yes        bool
+		prompted   bool
+		err        error
+	}{
+		{
+			name:       "should skip the prompt with --yes on a terminal",
+			isTerminal: true,
+			yes:        true,
+		},
+		{
+			name: "should skip the prompt with --yes without a terminal",
+			yes:  true,
+		},
+		{
+			name:  "should refuse without --yes and without a terminal",
+			input: "y\n",
+			err:   ErrConfirmationRequired,
+		},
+		{
+			name:       "should proceed when the user confirms",
+			input:      "y\n",
+			isTerminal: true,
+			prompted:   true,
+		},
+		{
+			name:       "should accept a full yes answer",
+			input:      " YES \n",
+			isTerminal: true,
+			prompted:   true,
+		},
+		{
+			name:       "should abort when the user declines",
+			input:      "n\n",
+			isTerminal: true,
+			prompted:   true,
+			err:        ErrNotConfirmed,
+		},
+		{
+			name:       "should abort on an empty answer",
+			input:      "\n",
+			isTerminal: true,
+			prompted:   true,
+			err:        ErrNotConfirmed,
+		},
+		{
+			name:       "should abort when the input is closed",
+			isTerminal: true,
+			prompted:   true,
+			err:        ErrNotConfirmed,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			out := bytes.NewBuffer(nil)
+			err := confirmDestructive(strings.NewReader(tt.input), out, tt.isTerminal, tt.yes, "Data will be deleted")
+			if !errors.Is(err, tt.err) {
+				t.Errorf("expecting error %v, %v received instead", tt.err, err)
+			}
+
+			prompted := strings.Contains(out.String(), "Data will be deleted")
+			if prompted != tt.prompted {
+				t.Errorf("expecting prompted %v, output %q", tt.prompted, out.String())
+			}
+		})
+	}
+}
@@ -6,3 +6,6 @@ import (
 
 // ErrWarn is the standard 'host preflights have warnings' error
 var ErrWarn = errors.New("host preflights have warnings")
+
+// ErrTimeout is returned when the host preflights do not finish before the configured timeout
+var ErrTimeout = errors.New("host preflights timed out")
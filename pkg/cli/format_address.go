@@ -2,6 +2,9 @@ package cli
 
 import (
 	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -15,25 +18,72 @@ func newNetutilFormatIPAddressCmdDeprecated(cli CLI) *cobra.Command {
 }
 
 func newNetutilFormatIPAddressCmd(_ CLI) *cobra.Command {
+	var port string
+
 	cmd := &cobra.Command{
 		Use:   "format-ip-address",
 		Short: "Adds brackets around ipv6 addresses",
-		Args:  cobra.ExactArgs(1),
+		Example: "" +
+			"# prints [2001:db8::1]\n" +
+			"kurl netutil format-ip-address 2001:db8::1\n\n" +
+			"# prints [2001:db8::1]:6443\n" +
+			"kurl netutil format-ip-address 2001:db8::1 --port 6443\n",
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			address := formatAddress(args[0])
+			if port != "" {
+				var err error
+				if address, err = appendAddressPort(args[0], port); err != nil {
+					return err
+				}
+			}
 
 			_, err := fmt.Fprintln(cmd.OutOrStdout(), address)
 
 			return err
 		},
 	}
+
+	cmd.Flags().StringVar(&port, "port", "", "A port to be appended to the address.")
 	return cmd
 }
 
+// formatAddress returns the provided address ready to be used in a url or followed by a port: ipv6
+// addresses, including the ones with a zone (e.g. fe80::1%eth0), are enclosed in brackets while
+// anything else, ipv4 addresses, hostnames and addresses already enclosed in brackets or followed by
+// a port (host:port, [ipv6]:port), is returned as is.
 func formatAddress(addr string) string {
-	if strings.Contains(addr, ":") {
+	if ip, err := netip.ParseAddr(addr); err == nil && ip.Is6() {
 		return fmt.Sprintf("[%s]", addr)
 	}
-
 	return addr
 }
+
+// appendAddressPort appends the provided port to the address, enclosing ipv6 addresses in brackets
+// (see formatAddress). an error is returned if the address already has a port or if it is enclosed
+// in brackets but is not an ipv6 address.
+func appendAddressPort(addr, port string) (string, error) {
+	if number, err := strconv.Atoi(port); err != nil || number < 1 || number > 65535 {
+		return "", fmt.Errorf("invalid port %q", port)
+	}
+
+	host := addr
+	if strings.HasPrefix(addr, "[") {
+		if _, _, err := net.SplitHostPort(addr); err == nil {
+			return "", fmt.Errorf("address %s already has a port", addr)
+		}
+		if !strings.HasSuffix(addr, "]") {
+			return "", fmt.Errorf("invalid bracketed address %q", addr)
+		}
+		host = strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+		if ip, err := netip.ParseAddr(host); err != nil || !ip.Is6() {
+			return "", fmt.Errorf("invalid bracketed address %q, brackets are only allowed around ipv6 addresses", addr)
+		}
+	} else if _, err := netip.ParseAddr(addr); err != nil {
+		// unbracketed ipv6 addresses never get here, they are parsed above.
+		if _, _, err := net.SplitHostPort(addr); err == nil {
+			return "", fmt.Errorf("address %s already has a port", addr)
+		}
+	}
+	return net.JoinHostPort(host, port), nil
+}
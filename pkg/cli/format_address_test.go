@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_formatAddress(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		addr     string
+		port     string
+		expected string
+		err      string
+	}{
+		{
+			name:     "should leave ipv4 addresses untouched",
+			addr:     "10.96.0.10",
+			expected: "10.96.0.10",
+		},
+		{
+			name:     "should leave hostnames untouched",
+			addr:     "kotsadm.default.svc.cluster.local",
+			expected: "kotsadm.default.svc.cluster.local",
+		},
+		{
+			name:     "should bracket ipv6 addresses",
+			addr:     "2001:db8::1",
+			expected: "[2001:db8::1]",
+		},
+		{
+			name:     "should bracket the ipv6 loopback",
+			addr:     "::1",
+			expected: "[::1]",
+		},
+		{
+			name:     "should bracket ipv4 mapped ipv6 addresses",
+			addr:     "::ffff:10.0.0.1",
+			expected: "[::ffff:10.0.0.1]",
+		},
+		{
+			name:     "should bracket ipv6 addresses with a zone",
+			addr:     "fe80::1%eth0",
+			expected: "[fe80::1%eth0]",
+		},
+		{
+			name:     "should leave bracketed ipv6 addresses untouched",
+			addr:     "[2001:db8::1]",
+			expected: "[2001:db8::1]",
+		},
+		{
+			name:     "should leave bracketed ipv6 addresses with a port untouched",
+			addr:     "[2001:db8::1]:6443",
+			expected: "[2001:db8::1]:6443",
+		},
+		{
+			name:     "should leave ipv4 addresses with a port untouched",
+			addr:     "10.96.0.10:6443",
+			expected: "10.96.0.10:6443",
+		},
+		{
+			name:     "should leave hostnames with a port untouched",
+			addr:     "registry.local:443",
+			expected: "registry.local:443",
+		},
+		{
+			name:     "should append a port to ipv6 addresses",
+			addr:     "2001:db8::1",
+			port:     "6443",
+			expected: "[2001:db8::1]:6443",
+		},
+		{
+			name:     "should append a port to ipv6 addresses with a zone",
+			addr:     "fe80::1%eth0",
+			port:     "6443",
+			expected: "[fe80::1%eth0]:6443",
+		},
+		{
+			name:     "should append a port to bracketed ipv6 addresses",
+			addr:     "[2001:db8::1]",
+			port:     "6443",
+			expected: "[2001:db8::1]:6443",
+		},
+		{
+			name:     "should append a port to ipv4 addresses",
+			addr:     "10.96.0.10",
+			port:     "6443",
+			expected: "10.96.0.10:6443",
+		},
+		{
+			name:     "should append a port to hostnames",
+			addr:     "registry.local",
+			port:     "443",
+			expected: "registry.local:443",
+		},
+		{
+			name: "should fail to append a port to addresses that already have one",
+			addr: "10.96.0.10:6443",
+			port: "443",
+			err:  "already has a port",
+		},
+		{
+			name: "should fail to append a port to bracketed addresses that already have one",
+			addr: "[2001:db8::1]:6443",
+			port: "443",
+			err:  "already has a port",
+		},
+		{
+			name: "should fail with invalid ports",
+			addr: "10.96.0.10",
+			port: "65536",
+			err:  `invalid port "65536"`,
+		},
+		{
+			name: "should fail to append a port to unterminated brackets",
+			addr: "[2001:db8::1",
+			port: "6443",
+			err:  "invalid bracketed address",
+		},
+		{
+			name: "should fail to append a port to brackets around ipv4 addresses",
+			addr: "[10.96.0.10]",
+			port: "6443",
+			err:  "brackets are only allowed around ipv6 addresses",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var address string
+			var err error
+			if tt.port == "" {
+				address = formatAddress(tt.addr)
+			} else {
+				address, err = appendAddressPort(tt.addr, tt.port)
+			}
+			if err != nil {
+				if len(tt.err) == 0 {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expecting %q, %q received instead", tt.err, err)
+				}
+				return
+			}
+
+			if len(tt.err) > 0 {
+				t.Errorf("expecting error %q, nil received instead", tt.err)
+			}
+
+			if address != tt.expected {
+				t.Errorf("expected %q, %q received instead", tt.expected, address)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	clusterspace "github.com/replicatedhq/kurl/pkg/cluster/space"
+)
+
+// fstabReport holds the mount points extracted from an fstab file by 'host parse-fstab'. Error is
+// set if the file could not be parsed.
+type fstabReport struct {
+	MountPoints []clusterspace.FstabEntry `json:"mountPoints"`
+	Error       string                    `json:"error,omitempty"`
+}
+
+// newFstabReport parses the provided fstab contents into a report, parse errors are kept in the
+// report.
+func newFstabReport(content []byte, pseudoFilesystems []string) fstabReport {
+	report := fstabReport{MountPoints: []clusterspace.FstabEntry{}}
+	entries, err := clusterspace.ParseFstab(content, pseudoFilesystems)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	report.MountPoints = entries
+	return report
+}
+
+// tableHeader returns the columns of the report when printed as a table.
+func (r fstabReport) tableHeader() []string {
+	return []string{"MOUNT POINT", "SOURCE", "FSTYPE", "BIND", "UNRELIABLE"}
+}
+
+// tableRows returns one row per mount point.
+func (r fstabReport) tableRows() [][]string {
+	var rows [][]string
+	for _, entry := range r.MountPoints {
+		rows = append(rows, []string{
+			entry.MountPoint, entry.Source, entry.FSType,
+			strconv.FormatBool(entry.Bind), strconv.FormatBool(entry.Unreliable),
+		})
+	}
+	return rows
+}
+
+// tableSummary returns the number of mount points found or the parse error.
+func (r fstabReport) tableSummary() string {
+	if r.Error != "" {
+		return fmt.Sprintf("Failed to parse fstab: %s", r.Error)
+	}
+	return fmt.Sprintf("%d mount point(s)", len(r.MountPoints))
+}
+
+// newHostParseFstabCmd returns a command that parses an fstab file, or stdin, and prints the mount
+// points the OpenEBS free disk space checks would extract from it. no cluster access is needed.
+func newHostParseFstabCmd(_ CLI) *cobra.Command {
+	var output string
+	var format outputFormat
+	var pseudoFilesystems []string
+
+	cmd := &cobra.Command{
+		Use:          "parse-fstab [file]",
+		Short:        "Prints the mount points extracted from an fstab file by the disk space checks.",
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		Example: "" +
+			"# prints the mount points extracted from the host fstab\n" +
+			"kurl host parse-fstab /etc/fstab\n\n" +
+			"# prints the mount points extracted from an fstab read from stdin as json\n" +
+			"cat fstab | kurl host parse-fstab -o json\n",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			format, err = parseOutputFormat(output)
+			return err
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var content []byte
+			var err error
+			if len(args) == 0 || args[0] == "-" {
+				content, err = io.ReadAll(cmd.InOrStdin())
+			} else {
+				content, err = os.ReadFile(args[0])
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read fstab: %w", err)
+			}
+
+			report := newFstabReport(content, pseudoFilesystems)
+			if err := renderOutput(cmd.OutOrStdout(), format, report); err != nil {
+				return err
+			}
+
+			if report.Error != "" {
+				return fmt.Errorf("failed to parse fstab: %s", report.Error)
+			}
+			return nil
+		},
+	}
+
+	addOutputFlag(cmd, &output)
+	cmd.Flags().StringSliceVar(&pseudoFilesystems, "pseudo-fs", nil, "Filesystem types ignored when parsing the fstab. If not informed the default list used by the disk space checks is used. May be repeated.")
+	return cmd
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_newHostParseFstabCmd(t *testing.T) {
+	fstab := strings.Join([]string{
+		"# /etc/fstab",
+		"/dev/sda1 / ext4 defaults 0 1",
+		"proc /proc proc defaults 0 0",
+		"/dev/sdb1 /var/openebs xfs defaults 0 2",
+		"/var/openebs /var/lib/data none bind 0 0",
+		"overlay /mnt/overlay overlay defaults 0 0",
+	}, "\n")
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fstab")
+	if err := os.WriteFile(path, []byte(fstab), 0644); err != nil {
+		t.Fatalf("failed to write fstab: %s", err)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		args     []string
+		stdin    string
+		expected []string
+		missing  []string
+		err      string
+	}{
+		{
+			name: "should parse the provided file",
+			args: []string{path},
+			expected: []string{
+				"MOUNT POINT",
+				"/var/openebs   /dev/sdb1     xfs      false  false",
+				"/var/lib/data  /var/openebs  none     true   false",
+				"/mnt/overlay   overlay       overlay  false  true",
+				"4 mount point(s)",
+			},
+			missing: []string{"/proc"},
+		},
+		{
+			name:     "should read stdin if no file is provided",
+			stdin:    fstab,
+			expected: []string{"/var/openebs", "4 mount point(s)"},
+		},
+		{
+			name:  "should print the mount points as json",
+			args:  []string{"-", "-o", "json"},
+			stdin: fstab,
+			expected: []string{
+				`"mountPoint": "/var/lib/data"`,
+				`"bind": true`,
+				`"unreliable": true`,
+			},
+		},
+		{
+			name:     "should honor the provided pseudo filesystems",
+			args:     []string{path, "--pseudo-fs", "overlay"},
+			expected: []string{"/proc", "4 mount point(s)"},
+			missing:  []string{"/mnt/overlay"},
+		},
+		{
+			name:     "should fail if no mount point is found",
+			stdin:    "# empty\nproc /proc proc defaults 0 0\n",
+			expected: []string{"Failed to parse fstab: failed to locate any mount point"},
+			err:      "failed to locate any mount point",
+		},
+		{
+			name: "should fail if the file does not exist",
+			args: []string{filepath.Join(dir, "missing")},
+			err:  "failed to read fstab",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			out := bytes.NewBuffer(nil)
+			cmd := newHostParseFstabCmd(nil)
+			cmd.SetIn(strings.NewReader(tt.stdin))
+			cmd.SetOut(out)
+			cmd.SetErr(bytes.NewBuffer(nil))
+			cmd.SetArgs(tt.args)
+
+			err := cmd.Execute()
+			if tt.err == "" && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			} else if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Fatalf("expected error %q, %v received instead", tt.err, err)
+			}
+
+			for _, expected := range tt.expected {
+				if !strings.Contains(out.String(), expected) {
+					t.Errorf("expected %q in output:\n%s", expected, out)
+				}
+			}
+			for _, missing := range tt.missing {
+				if strings.Contains(out.String(), missing) {
+					t.Errorf("unexpected %q in output:\n%s", missing, out)
+				}
+			}
+		})
+	}
+}
@@ -26,7 +26,10 @@ const hostPreflightCmdExample = `
   $ kurl host preflight spec.yaml
 
   # Installer spec from STDIN
-  $ kubectl get installer 6abe39c -oyaml | kurl host preflight -`
+  $ kubectl get installer 6abe39c -oyaml | kurl host preflight -
+
+  # Give up, with exit code 4, if the preflights take longer than 10 minutes
+  $ kurl host preflight spec.yaml --timeout 10m`
 
 const preflightCmdExample = `
   # Installer spec from file
@@ -39,6 +42,8 @@ const (
 	preflightsWarningCode       = 3
 	preflightsIgnoreWarningCode = 2
 	preflightsErrorCode         = 1
+	// preflightsTimeoutCode is returned when the host preflights did not finish before the timeout.
+	preflightsTimeoutCode = 4
 )
 
 func newHostPreflightCmd(cli CLI) *cobra.Command {
@@ -136,10 +141,28 @@ func newHostPreflightCmd(cli CLI) *cobra.Command {
 			isTerminal := isatty.IsTerminal(os.Stderr.Fd())
 			go writeProgress(cmd.ErrOrStderr(), progressChan, progressCancel, isTerminal)
 
-			results, err := cli.GetHostPreflightRunner().RunHostPreflights(cmd.Context(), preflightSpec, progressChan)
+			ctx := cmd.Context()
+			timeout := v.GetDuration("timeout")
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+
+			results, cutOff, err := runHostPreflights(ctx, cli.GetHostPreflightRunner(), preflightSpec, progressChan)
 			close(progressChan)
 			<-progressContext.Done()
 
+			if errors.Is(err, context.DeadlineExceeded) {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Host preflights timed out after %s, checks cut off:\n", timeout)
+				for _, title := range cutOff {
+					fmt.Fprintf(cmd.ErrOrStderr(), "  %s\n", title)
+				}
+				if v.GetBool("use-exit-codes") {
+					os.Exit(preflightsTimeoutCode)
+				}
+				return ErrTimeout
+			}
 			if err != nil {
 				return errors.Wrap(err, "run host preflight")
 			}
@@ -183,6 +206,7 @@ func newHostPreflightCmd(cli CLI) *cobra.Command {
 	cmd.Flags().StringSlice("primary-host", nil, "host or IP of a control plane node running a Kubernetes API server and etcd peer")
 	cmd.Flags().StringSlice("secondary-host", nil, "host or IP of a secondary node running kubelet")
 	cmd.Flags().StringSlice("spec", nil, "host preflight specs")
+	cmd.Flags().Duration("timeout", 0, "maximum time the host preflights may run, checks still running are cut off and the command exits with code 4. zero means no timeout")
 	_ = cmd.MarkFlagFilename("spec", "yaml", "yml")
 
 	return cmd
@@ -379,7 +403,8 @@ func decodeClusterPreflightSpec(raw string, data installer.TemplateData) (*troub
 	return decoded, errors.Wrap(err, "decode Preflight spec")
 }
 
-var collectorStartRegexp = regexp.MustCompile(`^\[.+\] Running collector\.\.\.$`)
+// collectorStartRegexp matches the progress line printed when a collector starts, capturing its title.
+var collectorStartRegexp = regexp.MustCompile(`^\[(.+)\] Running collector\.\.\.$`)
 
 func writeProgress(w io.Writer, ch <-chan interface{}, cancel func(), isTerminal bool) {
 	var sp *spinner.Spinner
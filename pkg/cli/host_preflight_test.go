@@ -2,6 +2,7 @@ package cli
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"testing"
 
@@ -9,6 +10,7 @@ import (
 	mock_cli "github.com/replicatedhq/kurl/pkg/cli/mock"
 	mock_preflight "github.com/replicatedhq/kurl/pkg/preflight/mock"
 	analyze "github.com/replicatedhq/troubleshoot/pkg/analyze"
+	"github.com/replicatedhq/troubleshoot/pkg/apis/troubleshoot/v1beta2"
 	"github.com/spf13/afero"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -302,3 +304,68 @@ func TestNewClusterPreflightCmd(t *testing.T) {
 		})
 	}
 }
+
+func TestNewHostPreflightCmdTimeout(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	installerFilename := "/tmp/installer.yaml"
+
+	fs := afero.NewMemMapFs()
+	err := afero.WriteFile(fs, installerFilename, []byte(installerYAML), 0666)
+	require.NoError(t, err)
+
+	mockPreflightRunner := mock_preflight.NewMockRunnerHost(mockCtrl)
+	mockPreflightRunner.EXPECT().
+		RunHostPreflights(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ *v1beta2.HostPreflight, progressChan chan interface{}) ([]*analyze.AnalyzeResult, error) {
+			progressChan <- "[Filesystem Performance] Running collector..."
+			<-ctx.Done()
+			progressChan <- "[Filesystem Performance] still running"
+			return nil, nil
+		}).
+		Times(1)
+
+	v := viper.New()
+
+	mockCLI := mock_cli.NewMockCLI(mockCtrl)
+	mockCLI.EXPECT().
+		GetViper().
+		Return(v).
+		Times(3)
+	mockCLI.EXPECT().
+		GetFS().
+		Return(fs).
+		Times(1)
+	mockCLI.EXPECT().
+		GetHostPreflightRunner().
+		Return(mockPreflightRunner).
+		Times(1)
+
+	cmd := newHostPreflightCmd(mockCLI)
+
+	bOut, bErr := bytes.NewBufferString(""), bytes.NewBufferString("")
+	cmd.SetOut(bOut)
+	cmd.SetErr(bErr)
+	cmd.SetArgs([]string{installerFilename, "--use-exit-codes=false", "--exclude-builtin", "--timeout=50ms"})
+
+	err = cmd.Execute()
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.Empty(t, bOut.String())
+	assert.Contains(t, bErr.String(), "Host preflights timed out after 50ms, checks cut off:\n  Filesystem Performance\n")
+}
+
+func Test_cutOffCollectors(t *testing.T) {
+	spec := &v1beta2.HostPreflight{
+		Spec: v1beta2.HostPreflightSpec{
+			Collectors: []*v1beta2.HostCollect{
+				{CPU: &v1beta2.CPU{}},
+				{Memory: &v1beta2.Memory{}},
+			},
+		},
+	}
+
+	assert.Equal(t, []string{"CPU Info", "Amount of Memory"}, cutOffCollectors(spec, []string{"CPU Info"}))
+	assert.Equal(t, []string{"Amount of Memory"}, cutOffCollectors(spec, []string{"CPU Info", "Amount of Memory"}))
+	assert.Equal(t, []string{"CPU Info", "Amount of Memory"}, cutOffCollectors(spec, nil))
+}
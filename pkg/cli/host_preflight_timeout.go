@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/replicatedhq/kurl/pkg/preflight"
+	analyze "github.com/replicatedhq/troubleshoot/pkg/analyze"
+	troubleshootv1beta2 "github.com/replicatedhq/troubleshoot/pkg/apis/troubleshoot/v1beta2"
+	"github.com/replicatedhq/troubleshoot/pkg/collect"
+)
+
+// hostPreflightRun holds the outcome of a host preflight run.
+type hostPreflightRun struct {
+	results []*analyze.AnalyzeResult
+	err     error
+}
+
+// runHostPreflights runs the host preflights, forwarding their progress to progressChan, until they
+// finish or ctx is done. the troubleshoot collectors can't be interrupted while running so once ctx
+// is done the run is abandoned: its progress is discarded and ctx error is returned together with
+// the titles of the collectors that were cut off, the one running followed by the ones that never
+// started. progressChan is not closed.
+func runHostPreflights(ctx context.Context, runner preflight.RunnerHost, spec *troubleshootv1beta2.HostPreflight, progressChan chan interface{}) ([]*analyze.AnalyzeResult, []string, error) {
+	runnerChan := make(chan interface{})
+	done := make(chan hostPreflightRun, 1)
+	go func() {
+		defer close(runnerChan)
+		results, err := runner.RunHostPreflights(ctx, spec, runnerChan)
+		done <- hostPreflightRun{results: results, err: err}
+	}()
+
+	var started []string
+	for {
+		select {
+		case run := <-done:
+			return run.results, nil, run.err
+		case line, ok := <-runnerChan:
+			if !ok {
+				run := <-done
+				return run.results, nil, run.err
+			}
+			if match := collectorStartRegexp.FindStringSubmatch(fmt.Sprintf("%s", line)); match != nil {
+				started = append(started, match[1])
+			}
+			progressChan <- line
+		case <-ctx.Done():
+			go func() {
+				for range runnerChan {
+				}
+			}()
+			return nil, cutOffCollectors(spec, started), ctx.Err()
+		}
+	}
+}
+
+// cutOffCollectors returns the titles of the collectors cut off by a timeout given the titles of
+// the collectors started so far: the last started one, still running, followed by the collectors in
+// the spec that have not been started. excluded collectors never start and are not returned.
+func cutOffCollectors(spec *troubleshootv1beta2.HostPreflight, started []string) []string {
+	var cutOff []string
+	seen := map[string]int{}
+	if len(started) > 0 {
+		cutOff = append(cutOff, started[len(started)-1])
+		for _, title := range started {
+			seen[title]++
+		}
+	}
+
+	if spec == nil {
+		return cutOff
+	}
+
+	for _, desired := range spec.Spec.Collectors {
+		collector, ok := collect.GetHostCollector(desired, "")
+		if !ok {
+			continue
+		}
+		if excluded, _ := collector.IsExcluded(); excluded {
+			continue
+		}
+		if seen[collector.Title()] > 0 {
+			seen[collector.Title()]--
+			continue
+		}
+		cutOff = append(cutOff, collector.Title())
+	}
+	return cutOff
+}
@@ -2,16 +2,61 @@ package cli
 
 import (
 	"fmt"
+	"io"
+	"text/tabwriter"
 
 	"github.com/replicatedhq/kurl/pkg/host"
 	"github.com/spf13/cobra"
 )
 
+// printProtectedIDInputs writes the protected id inputs, redacted unless raw is set, followed by
+// the protected id computed out of them.
+func printProtectedIDInputs(w io.Writer, inputs []host.ProtectedIDInput, id string, raw bool) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "INPUT\tVALUE\tSOURCE")
+	for _, input := range inputs {
+		value := input.Redacted()
+		if raw {
+			value = input.Value
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", input.Name, value, input.Source)
+	}
+	fmt.Fprintf(tw, "protected id\t%s\tHMAC-SHA256(app id, key: machine id)\n", id)
+	tw.Flush()
+}
+
+// warnProtectedIDMismatch writes a warning if the protected id computed out of the printed inputs
+// differs from the one actually reported for the host.
+func warnProtectedIDMismatch(w io.Writer, id, computed string) {
+	if id == computed {
+		return
+	}
+	fmt.Fprintf(w, "Warning: the protected id computed out of the inputs above (%s) differs from the host protected id (%s), the inputs may not be the ones the id is computed from\n", computed, id)
+}
+
 func newHostProtectedidCmd(_ CLI) *cobra.Command {
+	var showInputs, unsafeShowRaw bool
+
 	cmd := &cobra.Command{
 		Use:   "protectedid",
 		Short: "Prints the kURL host protected machine id",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if unsafeShowRaw && !showInputs {
+				return fmt.Errorf("--unsafe-show-raw requires --show-inputs")
+			}
+			return nil
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if showInputs {
+				inputs, id, computed, err := host.ProtectedIDInputs()
+				if err != nil {
+					return err
+				}
+				printProtectedIDInputs(cmd.OutOrStdout(), inputs, id, unsafeShowRaw)
+				warnProtectedIDMismatch(cmd.ErrOrStderr(), id, computed)
+				return nil
+			}
+
 			id, err := host.ProtectedID()
 			if err != nil {
 				return err
@@ -20,5 +65,8 @@ func newHostProtectedidCmd(_ CLI) *cobra.Command {
 			return nil
 		},
 	}
+
+	cmd.Flags().BoolVar(&showInputs, "show-inputs", false, "Print the inputs the protected id is computed from, redacted, and the resulting id.")
+	cmd.Flags().BoolVar(&unsafeShowRaw, "unsafe-show-raw", false, "Do not redact the inputs printed with --show-inputs. The machine id is confidential, do not share the output.")
 	return cmd
 }
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/replicatedhq/kurl/pkg/host"
+)
+
+func Test_printProtectedIDInputs(t *testing.T) {
+	inputs := []host.ProtectedIDInput{
+		{Name: "app id", Source: "built-in", Value: "replicated"},
+		{Name: "machine id", Source: "/etc/machine-id", Value: "d1f0a8e2b6c94c1e9f7a3b5c2d4e6f80", Secret: true},
+	}
+
+	buf := bytes.NewBuffer(nil)
+	printProtectedIDInputs(buf, inputs, "abcdef", false)
+	if strings.Contains(buf.String(), "d1f0a8e2b6c94c1e9f7a3b5c2d4e6f80") {
+		t.Errorf("expected machine id to be redacted:\n%s", buf)
+	}
+	for _, expected := range []string{"d1f0****************************", "replicated", "/etc/machine-id", "abcdef"} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("expected %q in output:\n%s", expected, buf)
+		}
+	}
+
+	buf.Reset()
+	printProtectedIDInputs(buf, inputs, "abcdef", true)
+	if !strings.Contains(buf.String(), "d1f0a8e2b6c94c1e9f7a3b5c2d4e6f80") {
+		t.Errorf("expected raw machine id in output:\n%s", buf)
+	}
+}
+
+func Test_warnProtectedIDMismatch(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	warnProtectedIDMismatch(buf, "abcdef", "abcdef")
+	if buf.Len() != 0 {
+		t.Errorf("expected no warning for matching ids:\n%s", buf)
+	}
+
+	warnProtectedIDMismatch(buf, "abcdef", "012345")
+	for _, expected := range []string{"Warning", "abcdef", "012345"} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("expected %q in output:\n%s", expected, buf)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+
+	clusterspace "github.com/replicatedhq/kurl/pkg/cluster/space"
+)
+
+// nodeSpaceCheck holds the outcome of a free disk space check executed against a single node.
+// message is the user friendly output as returned by hasEnoughSpace while passed tells if the
+// result status is acceptable (warnings are only acceptable when not running in strict mode).
+type nodeSpaceCheck struct {
+	result  clusterspace.NodeSpaceResult
+	message string
+	passed  bool
+}
+
+// junitTestSuites is the root element of a JUnit XML report.
+type junitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	TestSuites []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite groups the test cases executed for a storage class.
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase represents the free disk space check for a single node.
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+// junitFailure holds the reason why a test case failed.
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// buildJUnitReport converts the provided node checks into a JUnit report. each node becomes a
+// test case inside a test suite named after the storage class.
+func buildJUnitReport(scname string, checks []nodeSpaceCheck) junitTestSuites {
+	suite := junitTestSuite{
+		Name:  fmt.Sprintf("check-free-disk-space/%s", scname),
+		Tests: len(checks),
+	}
+
+	for _, check := range checks {
+		tcase := junitTestCase{
+			Name:      check.result.NodeName,
+			ClassName: scname,
+		}
+
+		if !check.passed {
+			suite.Failures++
+			tcase.Failure = &junitFailure{
+				Message: check.message,
+				Text:    check.message,
+			}
+		}
+
+		suite.TestCases = append(suite.TestCases, tcase)
+	}
+
+	return junitTestSuites{TestSuites: []junitTestSuite{suite}}
+}
+
+// writeJUnitReport writes the JUnit XML report for the provided node checks into path.
+func writeJUnitReport(path, scname string, checks []nodeSpaceCheck) error {
+	data, err := xml.MarshalIndent(buildJUnitReport(scname, checks), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode junit report: %w", err)
+	}
+
+	data = append([]byte(xml.Header), data...)
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write junit report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	clusterspace "github.com/replicatedhq/kurl/pkg/cluster/space"
+)
+
+func Test_writeJUnitReport(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		checks []nodeSpaceCheck
+		exp    junitTestSuites
+	}{
+		{
+			name: "no checks produce an empty suite",
+			exp: junitTestSuites{
+				XMLName: xml.Name{Local: "testsuites"},
+				TestSuites: []junitTestSuite{
+					{Name: "check-free-disk-space/openebs"},
+				},
+			},
+		},
+		{
+			name: "passed and failed nodes",
+			checks: []nodeSpaceCheck{
+				{
+					result:  clusterspace.NodeSpaceResult{NodeName: "node0", Status: clusterspace.NodeSpaceOK},
+					message: "Node node0 has 10G available",
+					passed:  true,
+				},
+				{
+					result:  clusterspace.NodeSpaceResult{NodeName: "node1", Status: clusterspace.NodeSpaceFail},
+					message: "Not enough space on node node1 (requested 10G, available 1G)",
+				},
+			},
+			exp: junitTestSuites{
+				XMLName: xml.Name{Local: "testsuites"},
+				TestSuites: []junitTestSuite{
+					{
+						Name:     "check-free-disk-space/openebs",
+						Tests:    2,
+						Failures: 1,
+						TestCases: []junitTestCase{
+							{
+								Name:      "node0",
+								ClassName: "openebs",
+							},
+							{
+								Name:      "node1",
+								ClassName: "openebs",
+								Failure: &junitFailure{
+									Message: "Not enough space on node node1 (requested 10G, available 1G)",
+									Text:    "Not enough space on node node1 (requested 10G, available 1G)",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "junit.xml")
+			if err := writeJUnitReport(path, "openebs", tt.checks); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("unexpected error reading report: %s", err)
+			}
+
+			var report junitTestSuites
+			if err := xml.Unmarshal(data, &report); err != nil {
+				t.Fatalf("invalid xml report: %s", err)
+			}
+
+			if diff := cmp.Diff(tt.exp, report); diff != "" {
+				t.Errorf("unexpected report: %s", diff)
+			}
+		})
+	}
+}
+
+func Test_checkOpenEBSNodesSpace(t *testing.T) {
+	volumes := map[string]clusterspace.OpenEBSVolume{
+		"node2": {Free: 52},
+		"node1": {Free: 10},
+		"node0": {Free: 100},
+	}
+
+	for _, tt := range []struct {
+		name     string
+		strict   bool
+		expected []nodeSpaceCheck
+	}{
+		{
+			name: "should pass nodes within the grace band",
+			expected: []nodeSpaceCheck{
+				{
+					result:  clusterspace.NodeSpaceResult{NodeName: "node0", FreeBytes: 100, RequiredBytes: 50, HasEnough: true, Status: clusterspace.NodeSpaceOK},
+					message: "Node node0 has 100B available (requested 50B)",
+					passed:  true,
+				},
+				{
+					result:  clusterspace.NodeSpaceResult{NodeName: "node1", FreeBytes: 10, RequiredBytes: 50, Status: clusterspace.NodeSpaceFail},
+					message: "Not enough space on node node1 (requested 50B, available 10B)",
+				},
+				{
+					result:  clusterspace.NodeSpaceResult{NodeName: "node2", FreeBytes: 52, RequiredBytes: 50, HasEnough: true, Status: clusterspace.NodeSpaceWarn},
+					message: "Node node2 has 52B available (requested 50B), less than 10% above the requested space",
+					passed:  true,
+				},
+			},
+		},
+		{
+			name:   "should fail nodes within the grace band in strict mode",
+			strict: true,
+			expected: []nodeSpaceCheck{
+				{
+					result:  clusterspace.NodeSpaceResult{NodeName: "node0", FreeBytes: 100, RequiredBytes: 50, HasEnough: true, Status: clusterspace.NodeSpaceOK},
+					message: "Node node0 has 100B available (requested 50B)",
+					passed:  true,
+				},
+				{
+					result:  clusterspace.NodeSpaceResult{NodeName: "node1", FreeBytes: 10, RequiredBytes: 50, Status: clusterspace.NodeSpaceFail},
+					message: "Not enough space on node node1 (requested 50B, available 10B)",
+				},
+				{
+					result:  clusterspace.NodeSpaceResult{NodeName: "node2", FreeBytes: 52, RequiredBytes: 50, HasEnough: true, Status: clusterspace.NodeSpaceWarn},
+					message: "Node node2 has 52B available (requested 50B), less than 10% above the requested space",
+				},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			checks, err := checkOpenEBSNodesSpace(volumes, openEBSFreeSpaceOpts{
+				biggerThan:   50,
+				gracePercent: 10,
+				strict:       tt.strict,
+				bytesFormat:  bytesFormatHuman,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, checks, cmp.AllowUnexported(nodeSpaceCheck{})); diff != "" {
+				t.Errorf("unexpected checks: %s", diff)
+			}
+		})
+	}
+
+	if _, err := checkOpenEBSNodesSpace(volumes, openEBSFreeSpaceOpts{onNode: "node3"}); err == nil {
+		t.Errorf("expected error for unknown node")
+	}
+}
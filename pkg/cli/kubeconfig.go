@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// serviceAccountTokenPath is where the token of the pod service account is mounted when running
+// inside the cluster.
+const serviceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// kubeConfigSource is where the kubernetes client configuration is read from.
+type kubeConfigSource string
+
+const (
+	// kubeConfigFromFlag is the kubeconfig file provided through the --kubeconfig flag.
+	kubeConfigFromFlag kubeConfigSource = "flag"
+	// kubeConfigFromEnv are the kubeconfig files listed in the KUBECONFIG environment variable.
+	kubeConfigFromEnv kubeConfigSource = "env"
+	// kubeConfigInCluster is the service account of the pod we are running in.
+	kubeConfigInCluster kubeConfigSource = "in-cluster"
+	// kubeConfigDefault is the kubeconfig file in the user home directory.
+	kubeConfigDefault kubeConfigSource = "default"
+)
+
+// selectKubeConfigSource decides where the kubernetes client configuration is read from. an
+// explicit kubeconfig always wins, followed by the KUBECONFIG environment variable. when none
+// of them is set and we are running inside a pod (the kubernetes service host is set and the
+// service account token is mounted at tokenPath) the in-cluster configuration is used, otherwise
+// the kubeconfig in the user home directory.
+func selectKubeConfigSource(kubeconfig string, getenv func(string) string, tokenPath string) kubeConfigSource {
+	if kubeconfig != "" {
+		return kubeConfigFromFlag
+	}
+	if getenv(clientcmd.RecommendedConfigPathEnvVar) != "" {
+		return kubeConfigFromEnv
+	}
+	if getenv("KUBERNETES_SERVICE_HOST") != "" && getenv("KUBERNETES_SERVICE_PORT") != "" {
+		if _, err := os.Stat(tokenPath); err == nil {
+			return kubeConfigInCluster
+		}
+	}
+	return kubeConfigDefault
+}
+
+// restConfig returns the kubernetes client configuration read from the source selected by
+// selectKubeConfigSource. kubeconfig is the path provided through the --kubeconfig flag, if any.
+func restConfig(kubeconfig string) (*rest.Config, error) {
+	source := selectKubeConfigSource(kubeconfig, os.Getenv, serviceAccountTokenPath)
+	if source == kubeConfigInCluster {
+		cfg, err := rest.InClusterConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read in-cluster configuration: %w", err)
+		}
+		return cfg, nil
+	}
+
+	// the default loading rules honor the KUBECONFIG environment variable and fall back to the
+	// kubeconfig in the user home directory. an explicit path takes precedence over both.
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s kubeconfig: %w", source, err)
+	}
+	return cfg, nil
+}
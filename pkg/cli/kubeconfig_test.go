@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_selectKubeConfigSource(t *testing.T) {
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenPath, []byte("token"), 0600); err != nil {
+		t.Fatalf("failed to write token: %s", err)
+	}
+	inCluster := map[string]string{
+		"KUBERNETES_SERVICE_HOST": "10.96.0.1",
+		"KUBERNETES_SERVICE_PORT": "443",
+	}
+
+	for _, tt := range []struct {
+		name       string
+		kubeconfig string
+		env        map[string]string
+		tokenPath  string
+		expected   kubeConfigSource
+	}{
+		{
+			name:     "should use the default kubeconfig outside the cluster",
+			expected: kubeConfigDefault,
+		},
+		{
+			name:      "should use the in-cluster configuration inside a pod",
+			env:       inCluster,
+			tokenPath: tokenPath,
+			expected:  kubeConfigInCluster,
+		},
+		{
+			name:      "should not use the in-cluster configuration without a service account token",
+			env:       inCluster,
+			tokenPath: filepath.Join(t.TempDir(), "missing"),
+			expected:  kubeConfigDefault,
+		},
+		{
+			name:      "should not use the in-cluster configuration without the kubernetes service",
+			env:       map[string]string{"KUBERNETES_SERVICE_HOST": "10.96.0.1"},
+			tokenPath: tokenPath,
+			expected:  kubeConfigDefault,
+		},
+		{
+			name: "should prefer the KUBECONFIG environment variable over the in-cluster configuration",
+			env: map[string]string{
+				"KUBECONFIG":              "/etc/kubernetes/admin.conf",
+				"KUBERNETES_SERVICE_HOST": "10.96.0.1",
+				"KUBERNETES_SERVICE_PORT": "443",
+			},
+			tokenPath: tokenPath,
+			expected:  kubeConfigFromEnv,
+		},
+		{
+			name:       "should prefer the explicit kubeconfig over everything else",
+			kubeconfig: "/root/.kube/other",
+			env: map[string]string{
+				"KUBECONFIG":              "/etc/kubernetes/admin.conf",
+				"KUBERNETES_SERVICE_HOST": "10.96.0.1",
+				"KUBERNETES_SERVICE_PORT": "443",
+			},
+			tokenPath: tokenPath,
+			expected:  kubeConfigFromFlag,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string {
+				return tt.env[key]
+			}
+			if source := selectKubeConfigSource(tt.kubeconfig, getenv, tt.tokenPath); source != tt.expected {
+				t.Errorf("expected source %q, received %q", tt.expected, source)
+			}
+		})
+	}
+}
+
+func Test_restConfigExplicit(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	content := `apiVersion: v1
+kind: Config
+clusters:
+- name: kurl
+  cluster:
+    server: https://10.0.0.1:6443
+contexts:
+- name: kurl
+  context:
+    cluster: kurl
+    user: admin
+current-context: kurl
+users:
+- name: admin
+  user:
+    token: abcd
+`
+	if err := os.WriteFile(kubeconfig, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write kubeconfig: %s", err)
+	}
+
+	// the explicit kubeconfig must win over the environment.
+	t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "missing"))
+
+	cfg, err := restConfig(kubeconfig)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if cfg.Host != "https://10.0.0.1:6443" || cfg.BearerToken != "abcd" {
+		t.Errorf("unexpected configuration read: host %q, token %q", cfg.Host, cfg.BearerToken)
+	}
+
+	if _, err := restConfig(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Errorf("expected error reading a missing explicit kubeconfig")
+	}
+}
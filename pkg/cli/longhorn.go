@@ -16,7 +16,6 @@ import (
 	"k8s.io/client-go/util/retry"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
 
 	lhv1b1 "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
 	"github.com/spf13/cobra"
@@ -55,7 +54,11 @@ func NewLonghornRollbackMigrationReplicas(cli CLI) *cobra.Command {
 			logger := cli.Logger()
 
 			logger.Print("Rolling back Longhorn volume replicas to their original value.")
-			cli, err := client.New(config.GetConfigOrDie(), client.Options{})
+			k8sConfig, err := cli.KubeConfig()
+			if err != nil {
+				return fmt.Errorf("failed to read kubernetes configuration: %w", err)
+			}
+			cli, err := client.New(k8sConfig, client.Options{})
 			if err != nil {
 				return fmt.Errorf("error creating client: %s", err)
 			}
@@ -126,7 +129,11 @@ func NewLonghornPrepareForMigration(cli CLI) *cobra.Command {
 			logger := cli.Logger()
 
 			logger.Print("Preparing Longhorn for migration to a different storage provisioner.")
-			cli, err := client.New(config.GetConfigOrDie(), client.Options{})
+			k8sConfig, err := cli.KubeConfig()
+			if err != nil {
+				return fmt.Errorf("failed to read kubernetes configuration: %w", err)
+			}
+			cli, err := client.New(k8sConfig, client.Options{})
 			if err != nil {
 				return fmt.Errorf("error creating client: %s", err)
 			}
@@ -14,6 +14,7 @@ import (
 	preflight "github.com/replicatedhq/kurl/pkg/preflight"
 	afero "github.com/spf13/afero"
 	viper "github.com/spf13/viper"
+	rest "k8s.io/client-go/rest"
 )
 
 // MockCLI is a mock of CLI interface.
@@ -123,6 +124,21 @@ func (mr *MockCLIMockRecorder) GetViper() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetViper", reflect.TypeOf((*MockCLI)(nil).GetViper))
 }
 
+// KubeConfig mocks base method.
+func (m *MockCLI) KubeConfig() (*rest.Config, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KubeConfig")
+	ret0, _ := ret[0].(*rest.Config)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// KubeConfig indicates an expected call of KubeConfig.
+func (mr *MockCLIMockRecorder) KubeConfig() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KubeConfig", reflect.TypeOf((*MockCLI)(nil).KubeConfig))
+}
+
 // Logger mocks base method.
 func (m *MockCLI) Logger() *log.Logger {
 	m.ctrl.T.Helper()
@@ -137,6 +153,20 @@ func (mr *MockCLIMockRecorder) Logger() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logger", reflect.TypeOf((*MockCLI)(nil).Logger))
 }
 
+// Namespace mocks base method.
+func (m *MockCLI) Namespace() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Namespace")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Namespace indicates an expected call of Namespace.
+func (mr *MockCLIMockRecorder) Namespace() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Namespace", reflect.TypeOf((*MockCLI)(nil).Namespace))
+}
+
 // Stderr mocks base method.
 func (m *MockCLI) Stderr() io.Writer {
 	m.ctrl.T.Helper()
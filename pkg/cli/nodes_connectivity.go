@@ -20,7 +20,6 @@ import (
 	"k8s.io/cli-runtime/pkg/printers"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/kustomize/api/types"
@@ -70,7 +69,7 @@ func (n nodeConnectivityOptions) debugf(format string, args ...interface{}) {
 	n.printf(format, args...)
 }
 
-func newNetutilNodesConnectivity(_ CLI) *cobra.Command {
+func newNetutilNodesConnectivity(cli CLI) *cobra.Command {
 	var opts nodeConnectivityOptions
 	cmd := &cobra.Command{
 		Use:     "nodes-connectivity",
@@ -86,19 +85,19 @@ func newNetutilNodesConnectivity(_ CLI) *cobra.Command {
 			}
 			// now that all input args have been validated we can silence the usage print upon error.
 			cmd.SilenceUsage = true
-			cfg, err := config.GetConfig()
+			cfg, err := cli.KubeConfig()
 			if err != nil {
 				return fmt.Errorf("failed to get kubernetes config: %w", err)
 			}
-			cli, err := client.New(cfg, client.Options{})
+			kcli, err := client.New(cfg, client.Options{})
 			if err != nil {
 				return fmt.Errorf("failed to create kubernetes client: %w", err)
 			}
-			cliset, err := kubernetes.NewForConfig(config.GetConfigOrDie())
+			cliset, err := kubernetes.NewForConfig(cfg)
 			if err != nil {
 				return fmt.Errorf("failed to create kubernetes client set: %s", err)
 			}
-			opts.cli = cli
+			opts.cli = kcli
 			opts.cliset = cliset
 			opts.printf = cmd.Printf
 			opts.wait = time.Second
@@ -4,11 +4,13 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/minio/minio-go"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 func newObjectStoreCmd(cli CLI) *cobra.Command {
@@ -42,6 +44,10 @@ func newSyncObjectStoreCmd(_ CLI) *cobra.Command {
 	var dstAccessKeyID string
 	var dstAccessKeySecret string
 
+	var benchmark bool
+	var benchmarkObjects int
+	var benchmarkObjectSize string
+
 	syncObjectStoreCmd := &cobra.Command{
 		Use:   "sync",
 		Short: "Copies buckets and objects from one object store to another",
@@ -81,6 +87,27 @@ func newSyncObjectStoreCmd(_ CLI) *cobra.Command {
 				log.Panic(err)
 			}
 
+			if benchmark {
+				size, err := resource.ParseQuantity(benchmarkObjectSize)
+				if err != nil {
+					log.Fatalf("Failed to parse %s as a quantity: %v", benchmarkObjectSize, err)
+				}
+
+				stores := []struct {
+					host string
+					cli  *minio.Client
+				}{{srcHost, src}, {dstHost, dst}}
+				for _, store := range stores {
+					fmt.Printf("Benchmarking %s\n", store.host)
+					result, err := benchmarkObjectStore(minioBenchmarkStore{cli: store.cli}, store.host, benchmarkObjects, size.Value(), time.Now)
+					if err != nil {
+						log.Fatalf("Failed to benchmark %s: %v", store.host, err)
+					}
+					fmt.Println(result)
+				}
+				return
+			}
+
 			ctx := context.Background()
 
 			srcBuckets, err := src.ListBuckets()
@@ -111,6 +138,10 @@ func newSyncObjectStoreCmd(_ CLI) *cobra.Command {
 	syncObjectStoreCmd.Flags().StringVar(&dstAccessKeyID, "dest_access_key_id", "", "Access key ID for the destination object store")
 	syncObjectStoreCmd.Flags().StringVar(&dstAccessKeySecret, "dest_access_key_secret", "", "Access key secret for the destination object store")
 
+	syncObjectStoreCmd.Flags().BoolVar(&benchmark, "benchmark", false, "Measures the write and read throughput of both object stores instead of syncing them")
+	syncObjectStoreCmd.Flags().IntVar(&benchmarkObjects, "benchmark_objects", 10, "Number of temporary objects written and read by the benchmark")
+	syncObjectStoreCmd.Flags().StringVar(&benchmarkObjectSize, "benchmark_object_size", "10Mi", "Size of each temporary object written and read by the benchmark")
+
 	return syncObjectStoreCmd
 }
 
@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go"
+)
+
+// benchmarkStore is the set of object store operations used when benchmarking an object store.
+type benchmarkStore interface {
+	MakeBucket(bucket string) error
+	RemoveBucket(bucket string) error
+	Put(bucket, key string, data []byte) error
+	Get(bucket, key string) (int64, error)
+	Remove(bucket, key string) error
+}
+
+// minioBenchmarkStore implements benchmarkStore on top of a minio client.
+type minioBenchmarkStore struct {
+	cli *minio.Client
+}
+
+// MakeBucket creates the provided bucket.
+func (m minioBenchmarkStore) MakeBucket(bucket string) error {
+	return m.cli.MakeBucket(bucket, "")
+}
+
+// RemoveBucket removes the provided (empty) bucket.
+func (m minioBenchmarkStore) RemoveBucket(bucket string) error {
+	return m.cli.RemoveBucket(bucket)
+}
+
+// Put uploads data as an object.
+func (m minioBenchmarkStore) Put(bucket, key string, data []byte) error {
+	_, err := m.cli.PutObject(bucket, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{})
+	return err
+}
+
+// Get downloads an object and returns the number of bytes read.
+func (m minioBenchmarkStore) Get(bucket, key string) (int64, error) {
+	obj, err := m.cli.GetObject(bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return 0, err
+	}
+	defer obj.Close()
+	return io.Copy(io.Discard, obj)
+}
+
+// Remove deletes an object.
+func (m minioBenchmarkStore) Remove(bucket, key string) error {
+	return m.cli.RemoveObject(bucket, key)
+}
+
+// benchmarkResult holds how long it took to write and read a number of objects to and from an object store.
+type benchmarkResult struct {
+	host       string
+	objects    int
+	objectSize int64
+	write      time.Duration
+	read       time.Duration
+}
+
+// throughput returns the measured throughput in MB/s for the provided duration.
+func (b benchmarkResult) throughput(elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	total := float64(b.objectSize) * float64(b.objects)
+	return total / 1000 / 1000 / elapsed.Seconds()
+}
+
+// String returns a user friendly report for the benchmark.
+func (b benchmarkResult) String() string {
+	return fmt.Sprintf(
+		"Object store %s: write %.2f MB/s, read %.2f MB/s (%d objects of %s)",
+		b.host, b.throughput(b.write), b.throughput(b.read), b.objects, humanBytes(b.objectSize),
+	)
+}
+
+// benchmarkObjectStore uploads and then downloads the provided number of objects of the provided size to a temporary bucket
+// and measures how long each phase took. the temporary bucket and objects are removed afterwards. now is used to measure
+// time.
+func benchmarkObjectStore(store benchmarkStore, host string, objects int, size int64, now func() time.Time) (result benchmarkResult, err error) {
+	result = benchmarkResult{host: host, objects: objects, objectSize: size}
+	bucket := fmt.Sprintf("kurl-benchmark-%s", uuid.New().String()[:8])
+	if err := store.MakeBucket(bucket); err != nil {
+		return result, fmt.Errorf("failed to create bucket %s: %w", bucket, err)
+	}
+
+	var keys []string
+	defer func() {
+		for _, key := range keys {
+			if rerr := store.Remove(bucket, key); rerr != nil && err == nil {
+				err = fmt.Errorf("failed to remove object %s: %w", key, rerr)
+			}
+		}
+		if rerr := store.RemoveBucket(bucket); rerr != nil && err == nil {
+			err = fmt.Errorf("failed to remove bucket %s: %w", bucket, rerr)
+		}
+	}()
+
+	data := make([]byte, size)
+	start := now()
+	for i := 0; i < objects; i++ {
+		key := fmt.Sprintf("object-%d", i)
+		if err := store.Put(bucket, key, data); err != nil {
+			return result, fmt.Errorf("failed to write object %s: %w", key, err)
+		}
+		keys = append(keys, key)
+	}
+	result.write = now().Sub(start)
+
+	start = now()
+	for _, key := range keys {
+		read, err := store.Get(bucket, key)
+		if err != nil {
+			return result, fmt.Errorf("failed to read object %s: %w", key, err)
+		}
+		if read != size {
+			return result, fmt.Errorf("read %d bytes from object %s, expected %d", read, key, size)
+		}
+	}
+	result.read = now().Sub(start)
+	return result, nil
+}
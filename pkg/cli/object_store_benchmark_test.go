@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubBenchmarkStore is an in memory benchmarkStore that advances a fake clock on every operation.
+type stubBenchmarkStore struct {
+	buckets map[string]map[string][]byte
+	clock   *time.Time
+	putTook time.Duration
+	getTook time.Duration
+	failGet bool
+}
+
+func (s *stubBenchmarkStore) MakeBucket(bucket string) error {
+	s.buckets[bucket] = map[string][]byte{}
+	return nil
+}
+
+func (s *stubBenchmarkStore) RemoveBucket(bucket string) error {
+	if len(s.buckets[bucket]) > 0 {
+		return fmt.Errorf("bucket not empty")
+	}
+	delete(s.buckets, bucket)
+	return nil
+}
+
+func (s *stubBenchmarkStore) Put(bucket, key string, data []byte) error {
+	*s.clock = s.clock.Add(s.putTook)
+	s.buckets[bucket][key] = data
+	return nil
+}
+
+func (s *stubBenchmarkStore) Get(bucket, key string) (int64, error) {
+	*s.clock = s.clock.Add(s.getTook)
+	if s.failGet {
+		return 0, fmt.Errorf("connection reset")
+	}
+	return int64(len(s.buckets[bucket][key])), nil
+}
+
+func (s *stubBenchmarkStore) Remove(bucket, key string) error {
+	delete(s.buckets[bucket], key)
+	return nil
+}
+
+func Test_benchmarkObjectStore(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		failGet  bool
+		err      string
+		expected string
+	}{
+		{
+			name:     "should measure write and read throughput",
+			expected: "Object store minio: write 5.00 MB/s, read 10.00 MB/s (10 objects of 976.6KiB)",
+		},
+		{
+			name:    "should fail and cleanup if reading fails",
+			failGet: true,
+			err:     "failed to read object object-0: connection reset",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := time.Now()
+			store := &stubBenchmarkStore{
+				buckets: map[string]map[string][]byte{},
+				clock:   &clock,
+				putTook: 200 * time.Millisecond,
+				getTook: 100 * time.Millisecond,
+				failGet: tt.failGet,
+			}
+
+			result, err := benchmarkObjectStore(store, "minio", 10, 1000000, func() time.Time { return clock })
+			if len(store.buckets) != 0 {
+				t.Errorf("temporary bucket not removed")
+			}
+
+			if err != nil {
+				if len(tt.err) == 0 {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expecting %q, %q received instead", tt.err, err)
+				}
+				return
+			}
+
+			if len(tt.err) > 0 {
+				t.Errorf("expecting error %q, nil received instead", tt.err)
+			}
+
+			if result.write != 2*time.Second || result.read != time.Second {
+				t.Errorf("unexpected durations: write %v, read %v", result.write, result.read)
+			}
+
+			if result.String() != tt.expected {
+				t.Errorf("expected %q, %q received", tt.expected, result.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewOpenEBSCmd(cli CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "openebs",
+		Short: "Perform operations on an OpenEBS installation within a kURL cluster",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return cli.GetViper().BindPFlags(cmd.PersistentFlags())
+		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return cli.GetViper().BindPFlags(cmd.Flags())
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+
+	clusterspace "github.com/replicatedhq/kurl/pkg/cluster/space"
+)
+
+// storageClassBasePath is the base path resolved for a storage class as printed by 'openebs basepaths'. Status is "resolved",
+// "skipped" when the base path could not be parsed or "assumed" when the openebs default base path is used instead.
+type storageClassBasePath struct {
+	StorageClass string `json:"storageClass"`
+	BasePath     string `json:"basePath,omitempty"`
+	Status       string `json:"status"`
+	Error        string `json:"error,omitempty"`
+}
+
+// storageClassBasePathsReport holds the base paths resolved by 'openebs basepaths'.
+type storageClassBasePathsReport struct {
+	StorageClasses []storageClassBasePath `json:"storageClasses"`
+	Errored        int                    `json:"errored"`
+}
+
+// newStorageClassBasePathsReport builds the report for the provided resolved base paths, counting the storage classes whose
+// base path could not be parsed and has been skipped.
+func newStorageClassBasePathsReport(paths []clusterspace.StorageClassBasePath) storageClassBasePathsReport {
+	report := storageClassBasePathsReport{StorageClasses: []storageClassBasePath{}}
+	for _, path := range paths {
+		result := storageClassBasePath{StorageClass: path.StorageClass, BasePath: path.BasePath, Status: "resolved"}
+		if path.Err != nil {
+			result.Error = path.Err.Error()
+			result.Status = "assumed"
+			if path.BasePath == "" {
+				result.Status = "skipped"
+				report.Errored++
+			}
+		}
+		report.StorageClasses = append(report.StorageClasses, result)
+	}
+	return report
+}
+
+// tableHeader returns the columns of the report when printed as a table.
+func (r storageClassBasePathsReport) tableHeader() []string {
+	return []string{"STORAGE CLASS", "BASE PATH", "STATUS", "ERROR"}
+}
+
+// tableRows returns one row per storage class.
+func (r storageClassBasePathsReport) tableRows() [][]string {
+	var rows [][]string
+	for _, sc := range r.StorageClasses {
+		rows = append(rows, []string{sc.StorageClass, sc.BasePath, sc.Status, sc.Error})
+	}
+	return rows
+}
+
+// tableSummary returns the number of storage classes resolved.
+func (r storageClassBasePathsReport) tableSummary() string {
+	if len(r.StorageClasses) == 0 {
+		return "No OpenEBS storage classes found"
+	}
+	return fmt.Sprintf("%d storage class(es), %d skipped", len(r.StorageClasses), r.Errored)
+}
+
+// NewOpenEBSBasePathsCmd returns a command that resolves the base path of multiple OpenEBS storage classes at once.
+func NewOpenEBSBasePathsCmd(cli CLI) *cobra.Command {
+	var storageClasses []string
+	var policy, output string
+	var format outputFormat
+	var vars map[string]string
+	var clientSet kubernetes.Interface
+
+	var policies []string
+	for _, policy := range clusterspace.BasePathPolicies {
+		policies = append(policies, string(policy))
+	}
+
+	cmd := &cobra.Command{
+		Use:          "basepaths",
+		Short:        "Prints the base path of the OpenEBS storage classes.",
+		SilenceUsage: true,
+		Example: "" +
+			"# prints the base path of all the OpenEBS storage classes, skipping the ones that can't be parsed\n" +
+			"kurl openebs basepaths --base-path-policy skip-unparseable\n\n" +
+			"# prints the base path of two storage classes as yaml\n" +
+			"kurl openebs basepaths --storageclass openebs --storageclass openebs-nvme -o yaml\n",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			if format, err = parseOutputFormat(output); err != nil {
+				return err
+			}
+
+			k8sConfig, err := cli.KubeConfig()
+			if err != nil {
+				return fmt.Errorf("failed to read kubernetes configuration: %w", err)
+			}
+
+			clientSet, err = kubernetes.NewForConfig(k8sConfig)
+			if err != nil {
+				return fmt.Errorf("failed to create kubernetes client: %w", err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			paths, err := clusterspace.ResolveOpenEBSBasePaths(
+				cmd.Context(), clientSet, storageClasses, vars, clusterspace.BasePathPolicy(policy),
+			)
+			if err != nil {
+				return fmt.Errorf("failed to resolve openebs base paths: %w", err)
+			}
+
+			report := newStorageClassBasePathsReport(paths)
+			if err := renderOutput(cmd.OutOrStdout(), format, report); err != nil {
+				return err
+			}
+
+			if report.Errored > 0 {
+				return fmt.Errorf("failed to parse the base path of %d storage class(es)", report.Errored)
+			}
+			return nil
+		},
+	}
+
+	addOutputFlag(cmd, &output)
+	cmd.Flags().StringSliceVar(&storageClasses, "storageclass", nil, "The OpenEBS storage class names. If not informed all the storage classes backed by the OpenEBS local provisioner are resolved. May be repeated.")
+	cmd.Flags().StringVar(&policy, "base-path-policy", string(clusterspace.BasePathPolicyFailFast), fmt.Sprintf("What to do when a storage class base path can't be parsed: %s.", strings.Join(policies, ", ")))
+	cmd.Flags().StringToStringVar(&vars, "base-path-var", nil, "Values (name=value) for the placeholders, $name or ${name}, found in templated OpenEBS storage class base paths. May be repeated.")
+	return cmd
+}
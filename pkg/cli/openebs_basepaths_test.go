@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	clusterspace "github.com/replicatedhq/kurl/pkg/cluster/space"
+)
+
+func Test_newStorageClassBasePathsReport(t *testing.T) {
+	paths := []clusterspace.StorageClassBasePath{
+		{StorageClass: "openebs", BasePath: "/var/openebs/local"},
+		{StorageClass: "openebs-legacy", Err: fmt.Errorf("openebs base path not defined in the storage class")},
+		{StorageClass: "openebs-templated", BasePath: "/var/openebs/local", Err: fmt.Errorf("base path /mnt/$DISK contains unresolved placeholder $DISK")},
+	}
+
+	expected := storageClassBasePathsReport{
+		StorageClasses: []storageClassBasePath{
+			{StorageClass: "openebs", BasePath: "/var/openebs/local", Status: "resolved"},
+			{StorageClass: "openebs-legacy", Status: "skipped", Error: "openebs base path not defined in the storage class"},
+			{StorageClass: "openebs-templated", BasePath: "/var/openebs/local", Status: "assumed", Error: "base path /mnt/$DISK contains unresolved placeholder $DISK"},
+		},
+		Errored: 1,
+	}
+	if diff := cmp.Diff(expected, newStorageClassBasePathsReport(paths)); diff != "" {
+		t.Errorf("unexpected report: %s", diff)
+	}
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+
+	clusterspace "github.com/replicatedhq/kurl/pkg/cluster/space"
+)
+
+// NewOpenEBSMeasureNodeCmd returns a command that runs the OpenEBS disk free evaluation job on a single node and prints
+// the raw job output along with the parsed free and used space.
+func NewOpenEBSMeasureNodeCmd(cli CLI) *cobra.Command {
+	var storageClass, image, node, bytesFormat string
+	var clientSet kubernetes.Interface
+
+	cmd := &cobra.Command{
+		Use:          "measure-node",
+		Short:        "Measures the OpenEBS free disk space on a single node, printing the raw evaluation output.",
+		SilenceUsage: true,
+		Example: "" +
+			"# measures the base path of the 'openebs' storage class on node 'node0'\n" +
+			"kurl openebs measure-node --storageclass openebs --node node0\n",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if node == "" {
+				return fmt.Errorf("--node is required")
+			}
+
+			if err := validateBytesFormat(bytesFormat); err != nil {
+				return err
+			}
+
+			k8sConfig, err := cli.KubeConfig()
+			if err != nil {
+				return fmt.Errorf("failed to read kubernetes configuration: %w", err)
+			}
+
+			clientSet, err = kubernetes.NewForConfig(k8sConfig)
+			if err != nil {
+				return fmt.Errorf("failed to create kubernetes client: %w", err)
+			}
+
+			selectedClass, err := getStorageClassByName(cmd.Context(), clientSet, storageClass)
+			if err != nil {
+				return err
+			}
+
+			if selectedClass.Provisioner != openEBSLocalProvisioner {
+				return fmt.Errorf("storage class %s is not backed by the %s provisioner", selectedClass.Name, openEBSLocalProvisioner)
+			}
+			storageClass = selectedClass.Name
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logger := log.New(io.Discard, "", 0)
+			if debug, _ := cmd.Flags().GetBool("debug"); debug {
+				logger = log.New(os.Stderr, "", 0)
+			}
+
+			getter, err := clusterspace.NewOpenEBSFreeDiskSpaceGetterWithOptions(clientSet, clusterspace.OpenEBSOptions{
+				Log:       logger,
+				Image:     image,
+				DstSC:     storageClass,
+				Namespace: cli.Namespace(),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to start openebs free disk space getter: %w", err)
+			}
+
+			measurement, err := getter.MeasureNode(cmd.Context(), node)
+			printNodeMeasurement(os.Stdout, measurement, err == nil, bytesFormat)
+			if err != nil {
+				return fmt.Errorf("failed to measure openebs free disk space: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&node, "node", "", "The name of the node to be measured.")
+	cmd.Flags().StringVar(&storageClass, "storageclass", "", "The OpenEBS storage class name. If not informed the default storage class will be used.")
+	cmd.Flags().StringVar(&image, "openebs-image", defaultOpenEBSPodImage, fmt.Sprintf("The image used by the evaluation pod. If not informed the default image used is %s", defaultOpenEBSPodImage))
+	cmd.Flags().StringVar(&bytesFormat, "bytes", bytesFormatHuman, fmt.Sprintf("How byte amounts are printed, %q for plain integers or %q for binary units (e.g. 6.8GiB).", bytesFormatRaw, bytesFormatHuman))
+	return cmd
+}
+
+// printNodeMeasurement prints the raw output of each of the evaluation job containers, sorted by
+// container name, followed by the parsed free and used space if the measurement succeeded.
+func printNodeMeasurement(w io.Writer, measurement clusterspace.NodeMeasurement, measured bool, bytesFormat string) {
+	var containers []string
+	for container := range measurement.Outputs {
+		containers = append(containers, container)
+	}
+	sort.Strings(containers)
+
+	for _, container := range containers {
+		fmt.Fprintf(w, "--- %s output ---\n", container)
+		output := measurement.Outputs[container]
+		fmt.Fprint(w, string(output))
+		if len(output) > 0 && output[len(output)-1] != '\n' {
+			fmt.Fprintln(w)
+		}
+	}
+
+	if !measured {
+		return
+	}
+
+	vol := measurement.Volume
+	fmt.Fprintf(
+		w, "Node %s: free %s, used %s\n",
+		measurement.Node, formatBytes(vol.Free, bytesFormat), formatBytes(vol.Used, bytesFormat),
+	)
+	if fstype := vol.UnreliableFilesystem(); fstype != "" {
+		fmt.Fprintf(w, "Warning: filesystem type %s, free space measurement is unreliable\n", fstype)
+	}
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	clusterspace "github.com/replicatedhq/kurl/pkg/cluster/space"
+)
+
+func Test_printNodeMeasurement(t *testing.T) {
+	measurement := clusterspace.NodeMeasurement{
+		Node: "node0",
+		Volume: clusterspace.OpenEBSVolume{
+			Free:   1024,
+			Used:   2048,
+			Source: "overlay",
+		},
+		Outputs: map[string][]byte{
+			"fstab": []byte("/dev/sda1 / ext4 defaults 0 1"),
+			"df":    []byte("Filesystem 1B-blocks Used Available Use% Mounted on\noverlay 3072 2048 1024 67% /data\n"),
+		},
+	}
+
+	for _, tt := range []struct {
+		name     string
+		measured bool
+		expected string
+	}{
+		{
+			name:     "should print the raw outputs and the parsed space",
+			measured: true,
+			expected: `--- df output ---
+Filesystem 1B-blocks Used Available Use% Mounted on
+overlay 3072 2048 1024 67% /data
+--- fstab output ---
+/dev/sda1 / ext4 defaults 0 1
+Node node0: free 1024, used 2048
+Warning: filesystem type overlay, free space measurement is unreliable
+`,
+		},
+		{
+			name: "should print only the raw outputs of failed measurements",
+			expected: `--- df output ---
+Filesystem 1B-blocks Used Available Use% Mounted on
+overlay 3072 2048 1024 67% /data
+--- fstab output ---
+/dev/sda1 / ext4 defaults 0 1
+`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			printNodeMeasurement(buf, measurement, tt.measured, bytesFormatRaw)
+			if buf.String() != tt.expected {
+				t.Errorf("expected:\n%s\nreceived:\n%s", tt.expected, buf.String())
+			}
+		})
+	}
+}
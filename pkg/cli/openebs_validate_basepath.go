@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+
+	clusterspace "github.com/replicatedhq/kurl/pkg/cluster/space"
+)
+
+// NewOpenEBSValidateBasePathCmd returns a command that verifies if the base path of an OpenEBS storage class exists and is a
+// directory on all nodes.
+func NewOpenEBSValidateBasePathCmd(cli CLI) *cobra.Command {
+	var storageClass, image string
+	var clientSet kubernetes.Interface
+
+	cmd := &cobra.Command{
+		Use:          "validate-basepath",
+		Short:        "Validates that the OpenEBS base path exists and is a directory on all nodes.",
+		SilenceUsage: true,
+		Example: "" +
+			"# validates the base path of the 'openebs' storage class\n" +
+			"kurl openebs validate-basepath --storageclass openebs\n",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			k8sConfig, err := cli.KubeConfig()
+			if err != nil {
+				return fmt.Errorf("failed to read kubernetes configuration: %w", err)
+			}
+
+			clientSet, err = kubernetes.NewForConfig(k8sConfig)
+			if err != nil {
+				return fmt.Errorf("failed to create kubernetes client: %w", err)
+			}
+
+			selectedClass, err := getStorageClassByName(cmd.Context(), clientSet, storageClass)
+			if err != nil {
+				return err
+			}
+
+			if selectedClass.Provisioner != openEBSLocalProvisioner {
+				return fmt.Errorf("storage class %s is not backed by the %s provisioner", selectedClass.Name, openEBSLocalProvisioner)
+			}
+			storageClass = selectedClass.Name
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logger := log.New(io.Discard, "", 0)
+			if debug, _ := cmd.Flags().GetBool("debug"); debug {
+				logger = log.New(os.Stderr, "", 0)
+			}
+
+			getter, err := clusterspace.NewOpenEBSFreeDiskSpaceGetterWithOptions(clientSet, clusterspace.OpenEBSOptions{
+				Log:       logger,
+				Image:     image,
+				DstSC:     storageClass,
+				Namespace: cli.Namespace(),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to start openebs base path validator: %w", err)
+			}
+
+			basePath, statuses, err := getter.ValidateBasePath(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to validate openebs base path: %w", err)
+			}
+
+			var nodes []string
+			for node := range statuses {
+				nodes = append(nodes, node)
+			}
+			sort.Strings(nodes)
+
+			var failed int
+			for _, node := range nodes {
+				fmt.Printf("Node %s: base path %s %s\n", node, basePath, statuses[node])
+				if statuses[node] != clusterspace.BasePathPresent {
+					failed++
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("base path %s is not a directory on %d node(s)", basePath, failed)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&storageClass, "storageclass", "", "The OpenEBS storage class name. If not informed the default storage class will be used.")
+	cmd.Flags().StringVar(&image, "openebs-image", defaultOpenEBSPodImage, fmt.Sprintf("The image used by the validation pod. If not informed the default image used is %s", defaultOpenEBSPodImage))
+	return cmd
+}
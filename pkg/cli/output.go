@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+// outputFormat is how a command prints its result, selected through the --output flag.
+type outputFormat string
+
+const (
+	outputTable outputFormat = "table"
+	outputJSON  outputFormat = "json"
+	outputYAML  outputFormat = "yaml"
+)
+
+// outputFormats are all the supported output formats.
+var outputFormats = []outputFormat{outputTable, outputJSON, outputYAML}
+
+// tableResult is implemented by the results that can be printed as a table. each row must have as
+// many columns as the header.
+type tableResult interface {
+	tableHeader() []string
+	tableRows() [][]string
+}
+
+// tableSummarizer is optionally implemented by table results that print a summary line after the
+// table.
+type tableSummarizer interface {
+	tableSummary() string
+}
+
+// parseOutputFormat validates the provided output format. "text" is accepted as an alias of
+// "table" for commands that used to print text.
+func parseOutputFormat(format string) (outputFormat, error) {
+	if format == "text" {
+		return outputTable, nil
+	}
+
+	for _, valid := range outputFormats {
+		if outputFormat(format) == valid {
+			return valid, nil
+		}
+	}
+
+	var valid []string
+	for _, format := range outputFormats {
+		valid = append(valid, string(format))
+	}
+	return "", fmt.Errorf("invalid output format %q, valid formats: %s", format, strings.Join(valid, ", "))
+}
+
+// addOutputFlag registers the --output flag, shorthand -o, in the provided command.
+func addOutputFlag(cmd *cobra.Command, output *string) {
+	cmd.Flags().StringVarP(output, "output", "o", string(outputTable), "Output format, table, json or yaml.")
+}
+
+// renderOutput prints the provided result in the provided format. json and yaml documents are
+// generated from the result json tags, byte amounts are kept as raw integers. results printed as
+// tables must implement tableResult.
+func renderOutput(w io.Writer, format outputFormat, result interface{}) error {
+	switch format {
+	case outputJSON:
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode json output: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	case outputYAML:
+		data, err := yaml.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to encode yaml output: %w", err)
+		}
+		fmt.Fprint(w, string(data))
+		return nil
+	case outputTable:
+		table, ok := result.(tableResult)
+		if !ok {
+			return fmt.Errorf("result of type %T can't be printed as a table", result)
+		}
+
+		// empty tables are not printed when there is a summary to print instead.
+		summarizer, summarize := result.(tableSummarizer)
+		if rows := table.tableRows(); len(rows) > 0 || !summarize {
+			tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, strings.Join(table.tableHeader(), "\t"))
+			for _, row := range rows {
+				fmt.Fprintln(tw, strings.Join(row, "\t"))
+			}
+			if err := tw.Flush(); err != nil {
+				return fmt.Errorf("failed to write table output: %w", err)
+			}
+		}
+
+		if summarize {
+			fmt.Fprintln(w, summarizer.tableSummary())
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported output format %q", format)
+}
@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type sampleOutputResult struct {
+	Items []sampleOutputItem `json:"items"`
+	Total int64              `json:"total"`
+}
+
+type sampleOutputItem struct {
+	Name string `json:"name"`
+	Free int64  `json:"free"`
+}
+
+func (r sampleOutputResult) tableHeader() []string {
+	return []string{"NAME", "FREE"}
+}
+
+func (r sampleOutputResult) tableRows() [][]string {
+	var rows [][]string
+	for _, item := range r.Items {
+		rows = append(rows, []string{item.Name, humanBytes(item.Free)})
+	}
+	return rows
+}
+
+func (r sampleOutputResult) tableSummary() string {
+	return "total " + humanBytes(r.Total)
+}
+
+func Test_renderOutput(t *testing.T) {
+	result := sampleOutputResult{
+		Items: []sampleOutputItem{
+			{Name: "node0", Free: 10737418240},
+			{Name: "node-long-name", Free: 512},
+		},
+		Total: 10737418752,
+	}
+
+	for _, tt := range []struct {
+		name     string
+		format   outputFormat
+		result   interface{}
+		expected string
+		err      string
+	}{
+		{
+			name:   "table",
+			format: outputTable,
+			result: result,
+			expected: "" +
+				"NAME            FREE\n" +
+				"node0           10.0GiB\n" +
+				"node-long-name  512B\n" +
+				"total 10.0GiB\n",
+		},
+		{
+			name:   "json",
+			format: outputJSON,
+			result: result,
+			expected: `{
+  "items": [
+    {
+      "name": "node0",
+      "free": 10737418240
+    },
+    {
+      "name": "node-long-name",
+      "free": 512
+    }
+  ],
+  "total": 10737418752
+}
+`,
+		},
+		{
+			name:   "yaml",
+			format: outputYAML,
+			result: result,
+			expected: "" +
+				"items:\n" +
+				"- free: 10737418240\n" +
+				"  name: node0\n" +
+				"- free: 512\n" +
+				"  name: node-long-name\n" +
+				"total: 10737418752\n",
+		},
+		{
+			name:     "should only print the summary of empty tables",
+			format:   outputTable,
+			result:   sampleOutputResult{},
+			expected: "total 0B\n",
+		},
+		{
+			name:   "should fail to print a table for results without columns",
+			format: outputTable,
+			result: map[string]int{"free": 1},
+			err:    "result of type map[string]int can't be printed as a table",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := renderOutput(&buf, tt.format, tt.result)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("expected error %q, received %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, buf.String()); diff != "" {
+				t.Errorf("unexpected output: %s", diff)
+			}
+		})
+	}
+}
+
+func Test_parseOutputFormat(t *testing.T) {
+	for _, tt := range []struct {
+		format   string
+		expected outputFormat
+		err      string
+	}{
+		{format: "table", expected: outputTable},
+		{format: "text", expected: outputTable},
+		{format: "json", expected: outputJSON},
+		{format: "yaml", expected: outputYAML},
+		{format: "xml", err: `invalid output format "xml", valid formats: table, json, yaml`},
+	} {
+		t.Run(tt.format, func(t *testing.T) {
+			format, err := parseOutputFormat(tt.format)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("expected error %q, received %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if format != tt.expected {
+				t.Errorf("expected format %s, received %s", tt.expected, format)
+			}
+		})
+	}
+}
@@ -0,0 +1,354 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+
+	clusterspace "github.com/replicatedhq/kurl/pkg/cluster/space"
+)
+
+const preflightAllCmdExample = `
+  # Runs all the default checks
+  $ kurl preflight all
+
+  # Verifies that the kURL ports are free on a host where kURL has not been installed yet
+  $ kurl preflight all --ports --space=false --clock-skew=false
+
+  # Runs all checks but the clock skew one, printing a json report
+  $ kurl preflight all --clock-skew=false -o json
+
+  # Requires 20G of free space in all nodes of the openebs storage class
+  $ kurl preflight all --storageclass openebs --bigger-than 20G
+
+  # Verifies that all nodes can pull the space check image using a registry secret
+  $ kurl preflight all --check-image-pull --image-pull-secret registry-creds
+
+  # Fails on the nodes with swap enabled
+  $ kurl preflight all --check-swap --swap-policy require-off
+
+  # Fails on the control plane nodes whose etcd disk is too slow, using an image providing fio
+  $ kurl preflight all --check-etcd-latency --fio-image registry.example.com/fio:3.36
+
+  # Writes the space check manifests for review without running any check
+  $ kurl preflight all --storageclass openebs --export-manifests ./manifests`
+
+// preflightStatus is the outcome of a single preflight check on a single node.
+type preflightStatus string
+
+const (
+	preflightPass preflightStatus = "pass"
+	preflightWarn preflightStatus = "warn"
+	preflightFail preflightStatus = "fail"
+)
+
+// preflightResult is the result of a check on a node. Node is empty for checks that are not bound
+// to any node.
+type preflightResult struct {
+	Check   string          `json:"check"`
+	Node    string          `json:"node,omitempty"`
+	Status  preflightStatus `json:"status"`
+	Message string          `json:"message"`
+}
+
+// preflightReport is the consolidated report of all the checks executed by 'preflight all'.
+type preflightReport struct {
+	Results  []preflightResult `json:"results"`
+	Passed   int               `json:"passed"`
+	Warnings int               `json:"warnings"`
+	Failures int               `json:"failures"`
+}
+
+// preflightCheck is a check registered in 'preflight all'. run returns the per node results, an
+// error means the check itself could not be executed.
+type preflightCheck struct {
+	name string
+	run  func(ctx context.Context) ([]preflightResult, error)
+}
+
+// runPreflightChecks runs all the provided checks and aggregates their results. checks that fail
+// to execute are reported as failures.
+func runPreflightChecks(ctx context.Context, checks []preflightCheck) preflightReport {
+	var results []preflightResult
+	for _, check := range checks {
+		checkResults, err := check.run(ctx)
+		if err != nil {
+			results = append(results, preflightResult{
+				Check:   check.name,
+				Status:  preflightFail,
+				Message: fmt.Sprintf("failed to run check: %s", err),
+			})
+			continue
+		}
+		results = append(results, checkResults...)
+	}
+	return aggregatePreflightResults(results)
+}
+
+// aggregatePreflightResults sorts the results by check and node and counts them by status.
+func aggregatePreflightResults(results []preflightResult) preflightReport {
+	sorted := append([]preflightResult{}, results...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Check != sorted[j].Check {
+			return sorted[i].Check < sorted[j].Check
+		}
+		return sorted[i].Node < sorted[j].Node
+	})
+
+	report := preflightReport{Results: sorted}
+	for _, result := range sorted {
+		switch result.Status {
+		case preflightPass:
+			report.Passed++
+		case preflightWarn:
+			report.Warnings++
+		default:
+			report.Failures++
+		}
+	}
+	return report
+}
+
+// exitCode returns the combined exit code for the report using the same codes as the host and
+// cluster preflights: failures take precedence over warnings.
+func (r preflightReport) exitCode(ignoreWarnings bool) int {
+	switch {
+	case r.Failures > 0:
+		return preflightsErrorCode
+	case r.Warnings > 0 && ignoreWarnings:
+		return preflightsIgnoreWarningCode
+	case r.Warnings > 0:
+		return preflightsWarningCode
+	}
+	return 0
+}
+
+// tableHeader returns the columns of the report when printed as a table.
+func (r preflightReport) tableHeader() []string {
+	return []string{"CHECK", "NODE", "STATUS", "MESSAGE"}
+}
+
+// tableRows returns one row per result, results not bound to any node have "-" as node.
+func (r preflightReport) tableRows() [][]string {
+	var rows [][]string
+	for _, result := range r.Results {
+		node := result.Node
+		if node == "" {
+			node = "-"
+		}
+		rows = append(rows, []string{result.Check, node, string(result.Status), result.Message})
+	}
+	return rows
+}
+
+// tableSummary returns the number of results by status.
+func (r preflightReport) tableSummary() string {
+	return fmt.Sprintf("%d passed, %d warnings, %d failures", r.Passed, r.Warnings, r.Failures)
+}
+
+const preflightRBACCmdExample = `
+  # Verifies that the current credentials can run the space check in the default storage class
+  $ kurl preflight --check-rbac
+
+  # Verifies the permissions for the openebs storage class, reading the image from a config map
+  $ kurl preflight --check-rbac --storageclass openebs --openebs-image-configmap kurl/space-check`
+
+func newPreflightCommand(cli CLI) *cobra.Command {
+	var checkRBAC bool
+	var storageClass, imageConfigMap string
+	cmd := &cobra.Command{
+		Use:     "preflight",
+		Short:   "Runs checks against the kURL cluster and the current host",
+		Example: preflightRBACCmdExample,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return cli.GetViper().BindPFlags(cmd.PersistentFlags())
+		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return cli.GetViper().BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !checkRBAC {
+				return cmd.Help()
+			}
+
+			k8sConfig, err := cli.KubeConfig()
+			if err != nil {
+				return fmt.Errorf("failed to read kubernetes configuration: %w", err)
+			}
+
+			clientSet, err := kubernetes.NewForConfig(k8sConfig)
+			if err != nil {
+				return fmt.Errorf("failed to create kubernetes client: %w", err)
+			}
+			return rbacPreflightCheck(cmd.Context(), cmd.OutOrStdout(), clientSet, storageClass, imageConfigMap, cli.Namespace())
+		},
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().BoolVar(&checkRBAC, "check-rbac", false, "Verifies if the current credentials have all the permissions needed by the space check, reporting the missing ones.")
+	cmd.Flags().StringVar(&storageClass, "storageclass", "", "The OpenEBS storage class evaluated by the space check. If not informed the default storage class will be used.")
+	cmd.Flags().StringVar(&imageConfigMap, "openebs-image-configmap", "", "The config map ([namespace/]name) the space check image is read from, if any.")
+	return cmd
+}
+
+// rbacPreflightCheck verifies, through access reviews, that the current credentials have all the
+// permissions needed to check the free disk space in the provided OpenEBS storage class (or the
+// default one if empty), including reading the image from imageConfigMap if not empty. the missing
+// permissions are printed to out, an error is returned if there is any.
+func rbacPreflightCheck(ctx context.Context, out io.Writer, kubeCli kubernetes.Interface, storageClass, imageConfigMap, namespace string) error {
+	sc, err := getStorageClassByName(ctx, kubeCli, storageClass)
+	if err != nil {
+		return err
+	}
+	if sc.Provisioner != openEBSLocalProvisioner {
+		return fmt.Errorf("storage class %s is not provisioned by %s, only openebs storage classes are supported", sc.Name, openEBSLocalProvisioner)
+	}
+
+	return evaluateOpenEBSPermissions(ctx, out, kubeCli, openEBSFreeSpaceOpts{
+		getterOpts: clusterspace.OpenEBSOptions{
+			Image:     defaultOpenEBSPodImage,
+			DstSC:     sc.Name,
+			Namespace: namespace,
+		},
+		imageConfigMap: imageConfigMap,
+		noCache:        true,
+	})
+}
+
+func newPreflightAllCmd(cli CLI) *cobra.Command {
+	var format outputFormat
+	var output, storageClass, biggerThan, image, exportDir, swapPolicy, fioImage, etcdDataDir string
+	var space, ports, kernelModules, clockSkew, checkImagePull, checkSwap, checkEtcdLatency bool
+	var ignoreWarnings, useExitCodes bool
+	var requiredPorts []int
+	var requiredModules, pullSecrets []string
+	var maxClockSkew, maxEtcdLatency time.Duration
+	var clientSet kubernetes.Interface
+
+	var swapPolicies []string
+	for _, policy := range clusterspace.SwapPolicies {
+		swapPolicies = append(swapPolicies, string(policy))
+	}
+
+	cmd := &cobra.Command{
+		Use:          "all",
+		Short:        "Runs the space, kernel modules and clock skew checks, and optionally the ports check, and prints a consolidated report",
+		Example:      preflightAllCmdExample,
+		SilenceUsage: true,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			if format, err = parseOutputFormat(output); err != nil {
+				return err
+			}
+
+			if !slices.Contains(clusterspace.SwapPolicies, clusterspace.SwapPolicy(swapPolicy)) {
+				return fmt.Errorf("invalid swap policy %q", swapPolicy)
+			}
+
+			if checkEtcdLatency && fioImage == "" {
+				return fmt.Errorf("--fio-image is required by the etcd latency check")
+			}
+
+			if !space && !clockSkew && !checkImagePull && !checkSwap && !checkEtcdLatency && exportDir == "" {
+				return nil
+			}
+
+			k8sConfig, err := cli.KubeConfig()
+			if err != nil {
+				return fmt.Errorf("failed to read kubernetes configuration: %w", err)
+			}
+
+			if clientSet, err = kubernetes.NewForConfig(k8sConfig); err != nil {
+				return fmt.Errorf("failed to create kubernetes client: %w", err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if exportDir != "" {
+				return exportSpaceManifests(cmd.Context(), cmd.OutOrStdout(), clientSet, storageClass, image, cli.Namespace(), pullSecrets, exportDir)
+			}
+
+			var checks []preflightCheck
+			if space {
+				requested, err := requiredSpace(biggerThan, "")
+				if err != nil {
+					return err
+				}
+				checks = append(checks, spacePreflightCheck(clientSet, storageClass, image, cli.Namespace(), pullSecrets, requested))
+			}
+			if checkImagePull {
+				checks = append(checks, imagePullPreflightCheck(clientSet, image, cli.Namespace(), pullSecrets))
+			}
+			if checkSwap {
+				checks = append(checks, swapPreflightCheck(clientSet, image, cli.Namespace(), pullSecrets, clusterspace.SwapPolicy(swapPolicy)))
+			}
+			if checkEtcdLatency {
+				checks = append(checks, etcdLatencyPreflightCheck(clientSet, image, cli.Namespace(), pullSecrets, fioImage, etcdDataDir, maxEtcdLatency))
+			}
+			if ports {
+				checks = append(checks, portsPreflightCheck(requiredPorts))
+			}
+			if kernelModules {
+				checks = append(checks, kernelModulesPreflightCheck(cli.GetFS(), requiredModules))
+			}
+			if clockSkew {
+				checks = append(checks, clockSkewPreflightCheck(clientSet, maxClockSkew, time.Now))
+			}
+
+			report := runPreflightChecks(cmd.Context(), checks)
+			if err := renderOutput(cmd.OutOrStdout(), format, report); err != nil {
+				return err
+			}
+
+			code := report.exitCode(ignoreWarnings)
+			if useExitCodes {
+				if code != 0 {
+					os.Exit(code)
+				}
+				return nil
+			}
+
+			switch code {
+			case preflightsErrorCode:
+				return fmt.Errorf("preflights have failures")
+			case preflightsWarningCode:
+				return ErrWarn
+			case preflightsIgnoreWarningCode:
+				fmt.Fprintln(cmd.ErrOrStderr(), "Warnings ignored by CLI flag \"ignore-warnings\"")
+			}
+			return nil
+		},
+	}
+
+	addOutputFlag(cmd, &output)
+	cmd.Flags().BoolVar(&space, "space", true, "Runs the OpenEBS free disk space check.")
+	cmd.Flags().BoolVar(&ports, "ports", false, "Runs the host ports availability check. Only meant for hosts where kURL has not been installed yet, the ports are in use by the cluster components otherwise.")
+	cmd.Flags().BoolVar(&kernelModules, "kernel-modules", true, "Runs the host kernel modules check.")
+	cmd.Flags().BoolVar(&clockSkew, "clock-skew", true, "Runs the nodes clock skew check.")
+	cmd.Flags().BoolVar(&checkImagePull, "check-image-pull", false, "Runs a pod in each node verifying that the space check image can be pulled, reporting the pull error of the nodes that can't.")
+	cmd.Flags().BoolVar(&checkSwap, "check-swap", false, "Runs a job in each node reporting its swap usage and availability.")
+	cmd.Flags().StringVar(&swapPolicy, "swap-policy", string(clusterspace.SwapPolicyAny), fmt.Sprintf("Whether the swap check requires swap to be enabled in the nodes: %s.", strings.Join(swapPolicies, ", ")))
+	cmd.Flags().BoolVar(&checkEtcdLatency, "check-etcd-latency", false, "Runs a fio job in each control plane node benchmarking the fsync latency of the etcd data directory.")
+	cmd.Flags().StringVar(&fioImage, "fio-image", "", "The image, providing fio, used by the etcd latency check jobs.")
+	cmd.Flags().StringVar(&etcdDataDir, "etcd-data-dir", clusterspace.DefaultEtcdDataDir, "The etcd data directory benchmarked by the etcd latency check.")
+	cmd.Flags().DurationVar(&maxEtcdLatency, "max-etcd-fsync-latency", clusterspace.DefaultEtcdFsyncThreshold, "The maximum 99th percentile fsync latency tolerated by the etcd latency check.")
+	cmd.Flags().StringVar(&storageClass, "storageclass", "", "The OpenEBS storage class evaluated by the space check. If not informed the default storage class will be used.")
+	cmd.Flags().StringVar(&biggerThan, "bigger-than", "", "The free space required in each node by the space check.")
+	cmd.Flags().StringVar(&image, "openebs-image", defaultOpenEBSPodImage, "The image used by the space check pods.")
+	cmd.Flags().StringSliceVar(&pullSecrets, "image-pull-secret", nil, "Names of the secrets, in the kURL namespace, used to pull the space check image. May be repeated.")
+	cmd.Flags().StringVar(&exportDir, "export-manifests", "", "Writes the PVC and Job manifests the space check would create in each node as YAML files into this directory and exits without running any check.")
+	cmd.Flags().IntSliceVar(&requiredPorts, "port", defaultPreflightPorts, "The host ports that must be available.")
+	cmd.Flags().StringSliceVar(&requiredModules, "kernel-module", defaultPreflightKernelModules, "The kernel modules that must be loaded in the host.")
+	cmd.Flags().DurationVar(&maxClockSkew, "max-clock-skew", 30*time.Second, "The maximum clock skew tolerated between the nodes and the current host.")
+	cmd.Flags().BoolVar(&ignoreWarnings, "ignore-warnings", false, "ignore preflight warnings")
+	cmd.Flags().BoolVar(&useExitCodes, "use-exit-codes", true, "set to false to return an error instead of an exit code")
+	return cmd
+}
@@ -0,0 +1,448 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	clusterspace "github.com/replicatedhq/kurl/pkg/cluster/space"
+)
+
+// nodeLeaseNamespace is where the kubelets renew their leases.
+const nodeLeaseNamespace = "kube-node-lease"
+
+// defaultPreflightPorts are the ports used by the kubernetes control plane and the kubelet.
+var defaultPreflightPorts = []int{6443, 2379, 2380, 10250}
+
+// defaultPreflightKernelModules are the kernel modules required by the container runtime and kube-proxy.
+var defaultPreflightKernelModules = []string{"br_netfilter", "overlay", "ip_tables"}
+
+// spacePreflightCheck verifies that all nodes have the requested free space in the provided storage class (or the default one
+// if empty). only storage classes backed by openEBSLocalProvisioner are measured per node.
+func spacePreflightCheck(kubeCli kubernetes.Interface, scname, image, namespace string, pullSecrets []string, requested int64) preflightCheck {
+	return preflightCheck{
+		name: "space",
+		run: func(ctx context.Context) ([]preflightResult, error) {
+			sc, err := getStorageClassByName(ctx, kubeCli, scname)
+			if err != nil {
+				return nil, err
+			}
+
+			if sc.Provisioner != openEBSLocalProvisioner {
+				return []preflightResult{{
+					Check:   "space",
+					Status:  preflightWarn,
+					Message: fmt.Sprintf("storage class %s is not backed by %s, per node space check skipped", sc.Name, openEBSLocalProvisioner),
+				}}, nil
+			}
+
+			opts := openEBSFreeSpaceOpts{
+				getterOpts: clusterspace.OpenEBSOptions{
+					Image:     image,
+					DstSC:     sc.Name,
+					Namespace: namespace,
+					Job: clusterspace.OpenEBSJobOptions{
+						ImagePullSecrets: pullSecrets,
+					},
+				},
+				biggerThan:  requested,
+				bytesFormat: bytesFormatHuman,
+			}
+
+			getter, err := newOpenEBSFreeSpaceGetter(kubeCli, log.New(io.Discard, "", 0), opts)
+			if err != nil {
+				return nil, err
+			}
+
+			volumes, err := getter.OpenEBSVolumes(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get openebs free space: %w", err)
+			}
+
+			checks, err := checkOpenEBSNodesSpace(volumes, opts)
+			if err != nil {
+				return nil, err
+			}
+
+			var results []preflightResult
+			for node, reason := range getter.SkippedNodes() {
+				results = append(results, preflightResult{
+					Check:   "space",
+					Node:    node,
+					Status:  preflightWarn,
+					Message: fmt.Sprintf("node skipped: %s", reason),
+				})
+			}
+
+			for _, check := range checks {
+				status := preflightFail
+				switch {
+				case check.passed && check.result.Status == clusterspace.NodeSpaceWarn:
+					status = preflightWarn
+				case check.passed:
+					status = preflightPass
+				}
+				results = append(results, preflightResult{
+					Check:   "space",
+					Node:    check.result.NodeName,
+					Status:  status,
+					Message: check.message,
+				})
+			}
+			return results, nil
+		},
+	}
+}
+
+// exportSpaceManifests writes into dir the temporary pvcs and jobs the space check would create in each node of the provided
+// storage class (or the default one if empty), so they can be reviewed before anything is created. only the nodes and the
+// storage class base path are read from the cluster.
+func exportSpaceManifests(ctx context.Context, out io.Writer, kubeCli kubernetes.Interface, scname, image, namespace string, pullSecrets []string, dir string) error {
+	sc, err := getStorageClassByName(ctx, kubeCli, scname)
+	if err != nil {
+		return err
+	}
+
+	getter, err := newOpenEBSFreeSpaceGetter(kubeCli, log.New(io.Discard, "", 0), openEBSFreeSpaceOpts{
+		getterOpts: clusterspace.OpenEBSOptions{
+			Image:     image,
+			DstSC:     sc.Name,
+			Namespace: namespace,
+			Job: clusterspace.OpenEBSJobOptions{
+				ImagePullSecrets: pullSecrets,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	manifests, err := getter.Manifests(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to build space check manifests: %w", err)
+	}
+
+	reportSkippedNodes(out, getter.SkippedNodes())
+
+	written, err := clusterspace.WriteManifests(dir, manifests)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range written {
+		fmt.Fprintf(out, "Wrote %s\n", path)
+	}
+	return nil
+}
+
+// newPreflightNodeJobRunner returns a runner for the preflight jobs run in every linux node with the provided image and pull
+// secrets. unlike the space check no storage class is involved.
+func newPreflightNodeJobRunner(kubeCli kubernetes.Interface, image, namespace string, pullSecrets []string) (*clusterspace.NodeJobRunner, error) {
+	return clusterspace.NewNodeJobRunner(kubeCli, clusterspace.OpenEBSOptions{
+		Log:       log.New(io.Discard, "", 0),
+		Image:     image,
+		Namespace: namespace,
+		Job: clusterspace.OpenEBSJobOptions{
+			ImagePullSecrets: pullSecrets,
+		},
+	})
+}
+
+// imagePullPreflightCheck verifies that all linux nodes can pull the space check image, using the provided pull secrets, by
+// scheduling a trivial pod in each of them.
+func imagePullPreflightCheck(kubeCli kubernetes.Interface, image, namespace string, pullSecrets []string) preflightCheck {
+	return preflightCheck{
+		name: "image-pull",
+		run: func(ctx context.Context) ([]preflightResult, error) {
+			runner, err := newPreflightNodeJobRunner(kubeCli, image, namespace, pullSecrets)
+			if err != nil {
+				return nil, err
+			}
+
+			pulls, err := runner.CheckImagePull(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check image pull: %w", err)
+			}
+			return imagePullResults(image, pulls, runner.SkippedNodes()), nil
+		},
+	}
+}
+
+// imagePullResults converts the per node image pull errors into preflight results. nodes with an empty error have pulled the
+// image while skipped nodes are reported as warnings.
+func imagePullResults(image string, pulls, skipped map[string]string) []preflightResult {
+	var results []preflightResult
+	for node, reason := range skipped {
+		results = append(results, preflightResult{
+			Check:   "image-pull",
+			Node:    node,
+			Status:  preflightWarn,
+			Message: fmt.Sprintf("node skipped: %s", reason),
+		})
+	}
+
+	for node, pullErr := range pulls {
+		result := preflightResult{
+			Check:   "image-pull",
+			Node:    node,
+			Status:  preflightPass,
+			Message: fmt.Sprintf("image %s pulled", image),
+		}
+		if pullErr != "" {
+			result.Status = preflightFail
+			result.Message = fmt.Sprintf("failed to pull image %s: %s", image, pullErr)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// swapPreflightCheck reports the swap of all linux nodes, failing the nodes whose swap violates the provided policy. the swap
+// is read by a job scheduled in each node.
+func swapPreflightCheck(kubeCli kubernetes.Interface, image, namespace string, pullSecrets []string, policy clusterspace.SwapPolicy) preflightCheck {
+	return preflightCheck{
+		name: "swap",
+		run: func(ctx context.Context) ([]preflightResult, error) {
+			runner, err := newPreflightNodeJobRunner(kubeCli, image, namespace, pullSecrets)
+			if err != nil {
+				return nil, err
+			}
+
+			swaps, err := runner.NodesSwap(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to measure swap: %w", err)
+			}
+			return swapResults(policy, swaps, runner.SkippedNodes()), nil
+		},
+	}
+}
+
+// swapResults converts the per node swap into preflight results, nodes whose swap violates the provided policy fail while skipped
+// nodes are reported as warnings.
+func swapResults(policy clusterspace.SwapPolicy, swaps map[string]clusterspace.NodeSwap, skipped map[string]string) []preflightResult {
+	var results []preflightResult
+	for node, reason := range skipped {
+		results = append(results, preflightResult{
+			Check:   "swap",
+			Node:    node,
+			Status:  preflightWarn,
+			Message: fmt.Sprintf("node skipped: %s", reason),
+		})
+	}
+
+	for node, swap := range swaps {
+		message := "swap is off"
+		if swap.Enabled() {
+			message = fmt.Sprintf("swap is on, %s used of %s", humanBytes(swap.Used), humanBytes(swap.Total))
+			if len(swap.Devices) > 0 {
+				message = fmt.Sprintf("%s (%s)", message, strings.Join(swap.Devices, ", "))
+			}
+		}
+
+		result := preflightResult{
+			Check:   "swap",
+			Node:    node,
+			Status:  preflightPass,
+			Message: message,
+		}
+		if err := swap.Check(policy); err != nil {
+			result.Status = preflightFail
+			result.Message = fmt.Sprintf("%s: %s", err, message)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// etcdLatencyPreflightCheck benchmarks the fsync latency of the etcd data directory in all control plane nodes, failing the nodes
+// whose 99th percentile latency exceeds the provided threshold. the benchmark is run by a fio job scheduled in each node, fioImage
+// must provide fio.
+func etcdLatencyPreflightCheck(kubeCli kubernetes.Interface, image, namespace string, pullSecrets []string, fioImage, dataDir string, threshold time.Duration) preflightCheck {
+	return preflightCheck{
+		name: "etcd-latency",
+		run: func(ctx context.Context) ([]preflightResult, error) {
+			runner, err := newPreflightNodeJobRunner(kubeCli, image, namespace, pullSecrets)
+			if err != nil {
+				return nil, err
+			}
+
+			latencies, err := runner.NodesEtcdLatency(ctx, fioImage, dataDir)
+			if err != nil {
+				return nil, fmt.Errorf("failed to benchmark etcd latency: %w", err)
+			}
+			return etcdLatencyResults(threshold, latencies, runner.SkippedNodes()), nil
+		},
+	}
+}
+
+// etcdLatencyResults converts the per node etcd fsync latency into preflight results, nodes whose latency exceeds the provided
+// threshold fail while skipped nodes are reported as warnings.
+func etcdLatencyResults(threshold time.Duration, latencies map[string]clusterspace.EtcdLatency, skipped map[string]string) []preflightResult {
+	var results []preflightResult
+	for node, reason := range skipped {
+		results = append(results, preflightResult{
+			Check:   "etcd-latency",
+			Node:    node,
+			Status:  preflightWarn,
+			Message: fmt.Sprintf("node skipped: %s", reason),
+		})
+	}
+
+	for node, latency := range latencies {
+		result := preflightResult{
+			Check:   "etcd-latency",
+			Node:    node,
+			Status:  preflightPass,
+			Message: fmt.Sprintf("99th percentile fsync latency is %s (max %s, %d fsyncs)", latency.P99, latency.Max, latency.Syncs),
+		}
+		if err := latency.Check(threshold); err != nil {
+			result.Status = preflightFail
+			result.Message = fmt.Sprintf("%s (max %s, %d fsyncs)", err, latency.Max, latency.Syncs)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// portsPreflightCheck verifies that the provided tcp ports are not in use in the current host. It binds
+// the ports so it only makes sense before kURL is installed, the cluster components use them afterwards.
+func portsPreflightCheck(ports []int) preflightCheck {
+	return preflightCheck{
+		name: "ports",
+		run: func(ctx context.Context) ([]preflightResult, error) {
+			hostname, err := os.Hostname()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get hostname: %w", err)
+			}
+
+			var results []preflightResult
+			for _, port := range ports {
+				result := preflightResult{
+					Check:   "ports",
+					Node:    hostname,
+					Status:  preflightPass,
+					Message: fmt.Sprintf("port %d is available", port),
+				}
+
+				listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+				if err != nil {
+					result.Status = preflightFail
+					result.Message = fmt.Sprintf("port %d is not available: %s", port, err)
+				} else {
+					listener.Close()
+				}
+				results = append(results, result)
+			}
+			return results, nil
+		},
+	}
+}
+
+// kernelModulesPreflightCheck verifies that the provided kernel modules are loaded in the current host. modules not listed in
+// /proc/modules are also looked up in /sys/module as they may be built into the kernel.
+func kernelModulesPreflightCheck(fs afero.Fs, modules []string) preflightCheck {
+	return preflightCheck{
+		name: "kernel-modules",
+		run: func(ctx context.Context) ([]preflightResult, error) {
+			hostname, err := os.Hostname()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get hostname: %w", err)
+			}
+
+			content, err := afero.ReadFile(fs, "/proc/modules")
+			if err != nil {
+				return nil, fmt.Errorf("failed to read loaded kernel modules: %w", err)
+			}
+			loaded := parseProcModules(content)
+
+			var results []preflightResult
+			for _, module := range modules {
+				result := preflightResult{
+					Check:   "kernel-modules",
+					Node:    hostname,
+					Status:  preflightPass,
+					Message: fmt.Sprintf("kernel module %s is loaded", module),
+				}
+
+				if !loaded[module] {
+					if _, err := fs.Stat(filepath.Join("/sys/module", module)); err != nil {
+						result.Status = preflightWarn
+						result.Message = fmt.Sprintf("kernel module %s is not loaded", module)
+					}
+				}
+				results = append(results, result)
+			}
+			return results, nil
+		},
+	}
+}
+
+// parseProcModules returns the names of the modules listed in /proc/modules.
+func parseProcModules(content []byte) map[string]bool {
+	modules := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) > 0 {
+			modules[fields[0]] = true
+		}
+	}
+	return modules
+}
+
+// clockSkewPreflightCheck estimates the clock skew between each node and the current host using the node leases. kubelets renew
+// their leases every few seconds using their own clock so a renew time in the future means the node clock is ahead while a renew
+// time older than the lease duration means the node clock is behind (or the node has stopped renewing it).
+func clockSkewPreflightCheck(kubeCli kubernetes.Interface, maxSkew time.Duration, now func() time.Time) preflightCheck {
+	return preflightCheck{
+		name: "clock-skew",
+		run: func(ctx context.Context) ([]preflightResult, error) {
+			leases, err := kubeCli.CoordinationV1().Leases(nodeLeaseNamespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to list node leases: %w", err)
+			}
+
+			var results []preflightResult
+			for _, lease := range leases.Items {
+				if lease.Spec.RenewTime == nil {
+					continue
+				}
+
+				duration := 40 * time.Second
+				if lease.Spec.LeaseDurationSeconds != nil {
+					duration = time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
+				}
+
+				result := preflightResult{
+					Check:   "clock-skew",
+					Node:    lease.Name,
+					Status:  preflightPass,
+					Message: fmt.Sprintf("clock skew is within %s", maxSkew),
+				}
+
+				skew := lease.Spec.RenewTime.Sub(now())
+				switch {
+				case skew > maxSkew:
+					result.Status = preflightFail
+					result.Message = fmt.Sprintf("node clock is ahead by about %s", skew.Round(time.Second))
+				case -skew > maxSkew+duration:
+					result.Status = preflightWarn
+					result.Message = fmt.Sprintf("node lease renewed %s ago, node clock is behind or the node is not ready", (-skew).Round(time.Second))
+				}
+				results = append(results, result)
+			}
+			return results, nil
+		},
+	}
+}
@@ -0,0 +1,293 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	clusterspace "github.com/replicatedhq/kurl/pkg/cluster/space"
+)
+
+func Test_runPreflightChecks(t *testing.T) {
+	checks := []preflightCheck{
+		{
+			name: "space",
+			run: func(ctx context.Context) ([]preflightResult, error) {
+				return []preflightResult{
+					{Check: "space", Node: "node1", Status: preflightWarn, Message: "marginal"},
+					{Check: "space", Node: "node0", Status: preflightPass, Message: "ok"},
+				}, nil
+			},
+		},
+		{
+			name: "clock-skew",
+			run: func(ctx context.Context) ([]preflightResult, error) {
+				return nil, fmt.Errorf("forbidden")
+			},
+		},
+		{
+			name: "ports",
+			run: func(ctx context.Context) ([]preflightResult, error) {
+				return []preflightResult{
+					{Check: "ports", Node: "node0", Status: preflightPass, Message: "port 6443 is available"},
+				}, nil
+			},
+		},
+	}
+
+	expected := preflightReport{
+		Results: []preflightResult{
+			{Check: "clock-skew", Status: preflightFail, Message: "failed to run check: forbidden"},
+			{Check: "ports", Node: "node0", Status: preflightPass, Message: "port 6443 is available"},
+			{Check: "space", Node: "node0", Status: preflightPass, Message: "ok"},
+			{Check: "space", Node: "node1", Status: preflightWarn, Message: "marginal"},
+		},
+		Passed:   2,
+		Warnings: 1,
+		Failures: 1,
+	}
+
+	report := runPreflightChecks(context.Background(), checks)
+	if diff := cmp.Diff(expected, report); diff != "" {
+		t.Errorf("unexpected report: %s", diff)
+	}
+}
+
+func Test_preflightReportExitCode(t *testing.T) {
+	for _, tt := range []struct {
+		name           string
+		results        []preflightResult
+		ignoreWarnings bool
+		exp            int
+	}{
+		{
+			name: "returns zero when nothing has been run",
+			exp:  0,
+		},
+		{
+			name: "returns zero when all checks pass",
+			results: []preflightResult{
+				{Check: "ports", Status: preflightPass},
+				{Check: "space", Status: preflightPass},
+			},
+			exp: 0,
+		},
+		{
+			name: "returns the warning code when any check warns",
+			results: []preflightResult{
+				{Check: "ports", Status: preflightPass},
+				{Check: "space", Status: preflightWarn},
+			},
+			exp: preflightsWarningCode,
+		},
+		{
+			name: "returns the ignore warning code when warnings are ignored",
+			results: []preflightResult{
+				{Check: "space", Status: preflightWarn},
+			},
+			ignoreWarnings: true,
+			exp:            preflightsIgnoreWarningCode,
+		},
+		{
+			name: "returns the error code when any check fails regardless of warnings",
+			results: []preflightResult{
+				{Check: "kernel-modules", Status: preflightWarn},
+				{Check: "clock-skew", Status: preflightFail},
+			},
+			ignoreWarnings: true,
+			exp:            preflightsErrorCode,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			report := aggregatePreflightResults(tt.results)
+			if code := report.exitCode(tt.ignoreWarnings); code != tt.exp {
+				t.Errorf("expected exit code %d, %d received instead", tt.exp, code)
+			}
+		})
+	}
+}
+
+func Test_imagePullResults(t *testing.T) {
+	pulls := map[string]string{
+		"node0": "",
+		"node1": "ImagePullBackOff: unauthorized",
+	}
+	skipped := map[string]string{
+		"win0": "windows nodes are only measured when a windows image is provided",
+	}
+
+	expected := preflightReport{
+		Results: []preflightResult{
+			{Check: "image-pull", Node: "node0", Status: preflightPass, Message: "image myimage:latest pulled"},
+			{Check: "image-pull", Node: "node1", Status: preflightFail, Message: "failed to pull image myimage:latest: ImagePullBackOff: unauthorized"},
+			{Check: "image-pull", Node: "win0", Status: preflightWarn, Message: "node skipped: windows nodes are only measured when a windows image is provided"},
+		},
+		Passed:   1,
+		Warnings: 1,
+		Failures: 1,
+	}
+
+	report := aggregatePreflightResults(imagePullResults("myimage:latest", pulls, skipped))
+	if diff := cmp.Diff(expected, report); diff != "" {
+		t.Errorf("unexpected report: %s", diff)
+	}
+}
+
+func Test_swapResults(t *testing.T) {
+	swaps := map[string]clusterspace.NodeSwap{
+		"node0": {},
+		"node1": {Total: 2147483648, Used: 1073741824, Devices: []string{"/dev/sda3"}},
+		"node2": {Total: 1073741824},
+	}
+	skipped := map[string]string{
+		"win0": "windows nodes are only measured when a windows image is provided",
+	}
+
+	expected := preflightReport{
+		Results: []preflightResult{
+			{Check: "swap", Node: "node0", Status: preflightPass, Message: "swap is off"},
+			{Check: "swap", Node: "node1", Status: preflightFail, Message: "swap is enabled but required to be off: swap is on, 1.0GiB used of 2.0GiB (/dev/sda3)"},
+			{Check: "swap", Node: "node2", Status: preflightFail, Message: "swap is enabled but required to be off: swap is on, 0B used of 1.0GiB"},
+			{Check: "swap", Node: "win0", Status: preflightWarn, Message: "node skipped: windows nodes are only measured when a windows image is provided"},
+		},
+		Passed:   1,
+		Warnings: 1,
+		Failures: 2,
+	}
+
+	report := aggregatePreflightResults(swapResults(clusterspace.SwapPolicyRequireOff, swaps, skipped))
+	if diff := cmp.Diff(expected, report); diff != "" {
+		t.Errorf("unexpected report: %s", diff)
+	}
+
+	report = aggregatePreflightResults(swapResults(clusterspace.SwapPolicyAny, swaps, nil))
+	if report.Failures != 0 || report.Passed != 3 {
+		t.Errorf("expected all nodes to pass with the any policy, received %+v", report)
+	}
+}
+
+func Test_etcdLatencyResults(t *testing.T) {
+	latencies := map[string]clusterspace.EtcdLatency{
+		"cp0": {P99: 2 * time.Millisecond, Max: 8 * time.Millisecond, Syncs: 10029},
+		"cp1": {P99: 25 * time.Millisecond, Max: 90 * time.Millisecond, Syncs: 10029},
+	}
+	skipped := map[string]string{
+		"cp2": "opted out through the kurl.sh/skip-space-check annotation",
+	}
+
+	expected := preflightReport{
+		Results: []preflightResult{
+			{Check: "etcd-latency", Node: "cp0", Status: preflightPass, Message: "99th percentile fsync latency is 2ms (max 8ms, 10029 fsyncs)"},
+			{Check: "etcd-latency", Node: "cp1", Status: preflightFail, Message: "99th percentile fsync latency 25ms exceeds 10ms (max 90ms, 10029 fsyncs)"},
+			{Check: "etcd-latency", Node: "cp2", Status: preflightWarn, Message: "node skipped: opted out through the kurl.sh/skip-space-check annotation"},
+		},
+		Passed:   1,
+		Warnings: 1,
+		Failures: 1,
+	}
+
+	report := aggregatePreflightResults(etcdLatencyResults(clusterspace.DefaultEtcdFsyncThreshold, latencies, skipped))
+	if diff := cmp.Diff(expected, report); diff != "" {
+		t.Errorf("unexpected report: %s", diff)
+	}
+
+	report = aggregatePreflightResults(etcdLatencyResults(30*time.Millisecond, latencies, nil))
+	if report.Failures != 0 || report.Passed != 2 {
+		t.Errorf("expected all nodes to pass with a 30ms threshold, received %+v", report)
+	}
+}
+
+func Test_newPreflightAllCmdPortsDisabledByDefault(t *testing.T) {
+	cmd := newPreflightAllCmd(nil)
+	flag := cmd.Flags().Lookup("ports")
+	if flag == nil {
+		t.Fatalf("--ports flag not found")
+	}
+	if flag.DefValue != "false" {
+		t.Errorf("expected the ports check to be disabled by default, --ports defaults to %s", flag.DefValue)
+	}
+}
+
+func Test_rbacPreflightCheck(t *testing.T) {
+	openebs := &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "openebs",
+			Annotations: map[string]string{isDefaultStorageClassAnnotation: "true"},
+		},
+		Provisioner: openEBSLocalProvisioner,
+	}
+	rook := &storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: "rook"},
+		Provisioner: rookRBDProvisioner,
+	}
+
+	for _, tt := range []struct {
+		name           string
+		storageClass   string
+		imageConfigMap string
+		denied         map[string]bool
+		output         []string
+		err            string
+	}{
+		{
+			name:   "all permissions granted",
+			output: []string{"All permissions required to check the free disk space are granted"},
+		},
+		{
+			name:           "missing permissions",
+			imageConfigMap: "kurl/space-check",
+			denied: map[string]bool{
+				"delete/persistentvolumes": true,
+				"get/configmaps":           true,
+			},
+			output: []string{
+				"Missing permission: delete persistentvolumes",
+				"Missing permission: get configmaps in namespace kurl",
+			},
+			err: "2 permission(s) missing",
+		},
+		{
+			name:         "unsupported storage class",
+			storageClass: "rook",
+			err:          "only openebs storage classes are supported",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			kcli := fake.NewSimpleClientset(openebs, rook)
+			kcli.PrependReactor(
+				"create", "selfsubjectaccessreviews",
+				func(action k8stesting.Action) (bool, runtime.Object, error) {
+					review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+					attrs := review.Spec.ResourceAttributes
+					review.Status.Allowed = !tt.denied[attrs.Verb+"/"+attrs.Resource]
+					return true, review, nil
+				},
+			)
+
+			out := bytes.NewBuffer(nil)
+			err := rbacPreflightCheck(context.Background(), out, kcli, tt.storageClass, tt.imageConfigMap, "default")
+			if tt.err == "" && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			} else if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Fatalf("expected error %q, %v received", tt.err, err)
+			}
+
+			for _, expected := range tt.output {
+				if !strings.Contains(out.String(), expected) {
+					t.Errorf("expected %q in output:\n%s", expected, out)
+				}
+			}
+		})
+	}
+}
@@ -1,28 +1,31 @@
 package cli
 
 import (
+	"fmt"
 	"log"
 	"os"
 
 	"github.com/replicatedhq/kurl/pkg/rook"
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/kubernetes"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
 )
 
-func NewRookFlexvolumeToCSI(_ CLI) *cobra.Command {
+func NewRookFlexvolumeToCSI(cli CLI) *cobra.Command {
 	var opts rook.FlexvolumeToCSIOpts
 
 	cmd := &cobra.Command{
 		Use:   "flexvolume-to-csi",
 		Short: "Converts Rook Flex volumes to Ceph-CSI volumes.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			clientConfig := config.GetConfigOrDie()
+			clientConfig, err := cli.KubeConfig()
+			if err != nil {
+				return fmt.Errorf("failed to read kubernetes configuration: %w", err)
+			}
 			clientset := kubernetes.NewForConfigOrDie(clientConfig)
 
 			logger := log.New(os.Stdout, "", 0)
 
-			err := rook.FlexvolumeToCSI(cmd.Context(), logger, clientset, clientConfig, opts)
+			err = rook.FlexvolumeToCSI(cmd.Context(), logger, clientset, clientConfig, opts)
 			return err
 		},
 		SilenceUsage: true,
@@ -7,16 +7,18 @@ import (
 	"github.com/replicatedhq/kurl/pkg/rook"
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/kubernetes"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
 )
 
-func NewRookHasSufficientBlockDevicesCmd(_ CLI) *cobra.Command {
+func NewRookHasSufficientBlockDevicesCmd(cli CLI) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "has-sufficient-blockdevices",
 		Short: "Exits 0 if there are enough block devices in the cluster, 1 otherwise",
 		RunE: func(cmd *cobra.Command, args []string) error {
 
-			k8sConfig := config.GetConfigOrDie()
+			k8sConfig, err := cli.KubeConfig()
+			if err != nil {
+				return fmt.Errorf("failed to read kubernetes configuration: %w", err)
+			}
 			clientSet := kubernetes.NewForConfigOrDie(k8sConfig)
 
 			rook.InitWriter(cmd.OutOrStdout())
@@ -6,19 +6,61 @@ import (
 	"github.com/replicatedhq/kurl/pkg/rook"
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/kubernetes"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
 )
 
-func NewRookHealthCmd(_ CLI) *cobra.Command {
+const rookHealthCmdExample = `
+  # Checks rook-ceph health
+  $ kurl rook health
+
+  # Prints the recovery rate in binary units (e.g. 1.5MiB)
+  $ kurl rook health --bytes human
+
+  # Prints the ceph health status as json
+  $ kurl rook health -o json | jq .ceph_status`
+
+func NewRookHealthCmd(cli CLI) *cobra.Command {
 	var ignoreChecks []string
+	var output, bytesFormat string
+	var format outputFormat
 	cmd := &cobra.Command{
-		Use:   "health",
-		Short: "Checks rook-ceph health and returns any issues",
+		Use:     "health",
+		Short:   "Checks rook-ceph health and returns any issues",
+		Example: rookHealthCmdExample,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			if format, err = parseOutputFormat(output); err != nil {
+				return err
+			}
+			return validateBytesFormat(bytesFormat)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			k8sConfig := config.GetConfigOrDie()
+			k8sConfig, err := cli.KubeConfig()
+			if err != nil {
+				return fmt.Errorf("failed to read kubernetes configuration: %w", err)
+			}
+			rook.InitConfig(k8sConfig)
 			clientSet := kubernetes.NewForConfigOrDie(k8sConfig)
 
+			if format != outputTable {
+				// progress messages must not be mixed with the json or yaml document.
+				rook.InitWriter(cmd.ErrOrStderr())
+				report, err := rook.RookHealthReport(cmd.Context(), clientSet, ignoreChecks)
+				if err != nil {
+					return fmt.Errorf("failed to check rook health: %w", err)
+				}
+				if err := renderOutput(cmd.OutOrStdout(), format, report); err != nil {
+					return err
+				}
+				if !report.Healthy {
+					return fmt.Errorf("rook unhealthy: %s", report.Message)
+				}
+				return nil
+			}
+
 			rook.InitWriter(cmd.OutOrStdout())
+			if bytesFormat != bytesFormatRaw {
+				rook.InitBytesFormatter(func(b int64) string { return formatBytes(b, bytesFormat) })
+			}
 
 			healthy, errMsg, err := rook.RookHealth(cmd.Context(), clientSet, ignoreChecks)
 			if err != nil {
@@ -34,5 +76,7 @@ func NewRookHealthCmd(_ CLI) *cobra.Command {
 		SilenceUsage: true,
 	}
 	cmd.Flags().StringSliceVar(&ignoreChecks, "ignore-checks", nil, "a list of Ceph health check unique identifiers to ignore when reporting health")
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "Output format, text, json or yaml.")
+	cmd.Flags().StringVar(&bytesFormat, "bytes", bytesFormatRaw, fmt.Sprintf("How byte amounts are printed in the text output, %q for single letter units (e.g. 6.8G), %q for plain integers or %q for binary units (e.g. 6.8GiB).", bytesFormatShort, bytesFormatRaw, bytesFormatHuman))
 	return cmd
 }
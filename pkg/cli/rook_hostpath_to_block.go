@@ -1,25 +1,123 @@
 package cli
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+
 	"github.com/replicatedhq/kurl/pkg/rook"
 	"github.com/spf13/cobra"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
 )
 
-func NewHostpathToBlockCmd(_ CLI) *cobra.Command {
+func NewHostpathToBlockCmd(cli CLI) *cobra.Command {
+	var output, bytesFormat string
+	var yes, dryRun bool
 	cmd := &cobra.Command{
 		Use:   "hostpath-to-block",
 		Short: "Migrates rook hostpath data to block device volumes, changing the rook cluster config if needed",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if output != "text" && output != "json" {
+				return fmt.Errorf("invalid output format %q, valid formats: text, json", output)
+			}
+			if err := validateBytesFormat(bytesFormat); err != nil {
+				return err
+			}
+			if dryRun {
+				return nil
+			}
+			return confirmDestructive(
+				cmd.InOrStdin(), cmd.ErrOrStderr(), stdinIsTerminal(), yes,
+				"Rook hostpath OSDs will be removed and their data migrated to block devices",
+			)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			k8sConfig := config.GetConfigOrDie()
+			k8sConfig, err := cli.KubeConfig()
+			if err != nil {
+				return fmt.Errorf("failed to read kubernetes configuration: %w", err)
+			}
+			rook.InitConfig(k8sConfig)
+
+			// progress goes to stderr so the json report is the only thing in stdout.
+			if output == "json" {
+				rook.InitWriter(cmd.ErrOrStderr())
+			} else {
+				rook.InitWriter(cmd.OutOrStdout())
+			}
 
-			rook.InitWriter(cmd.OutOrStdout())
+			if dryRun {
+				plan, err := rook.HostpathToOsdPlan(cmd.Context(), k8sConfig)
+				if err != nil {
+					return fmt.Errorf("failed to plan migration: %w", err)
+				}
+				return reportHostpathToBlockPlan(cmd.OutOrStdout(), output, bytesFormat, plan)
+			}
 
-			err := rook.HostpathToOsd(cmd.Context(), k8sConfig)
+			report, err := rook.HostpathToOsd(cmd.Context(), k8sConfig)
+			if output == "json" {
+				data, merr := json.MarshalIndent(report, "", "  ")
+				if merr != nil {
+					return fmt.Errorf("failed to encode migration report: %w", merr)
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return err
+			}
+
+			if len(report.OSDs) > 0 {
+				fmt.Fprintf(
+					cmd.OutOrStdout(), "Converted %d OSD(s), %d failed, %d skipped, %s moved\n",
+					report.Converted, report.Failed, report.Skipped, formatBytes(report.BytesMoved, bytesFormat),
+				)
+				for _, osd := range report.OSDs {
+					msg := fmt.Sprintf("osd.%d (node %s): %s", osd.OSD, osd.Node, osd.Status)
+					if osd.Error != "" {
+						msg = fmt.Sprintf("%s: %s", msg, osd.Error)
+					}
+					fmt.Fprintln(cmd.OutOrStdout(), msg)
+				}
+			}
 			return err
 		},
 		SilenceUsage: true,
 	}
 
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "Output format for the migration report, text or json")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Prints the hostpath OSDs that would be migrated and the data to be moved out of them, without changing the cluster. Fails if the block device OSDs can't hold the data, or if the rook-ceph-tools deployment is not running as it is not started by a dry run.")
+	cmd.Flags().StringVar(&bytesFormat, "bytes", bytesFormatHuman, fmt.Sprintf("How byte amounts are printed in the text output, %q for single letter units (e.g. 6.8G), %q for plain integers or %q for binary units (e.g. 6.8GiB).", bytesFormatShort, bytesFormatRaw, bytesFormatHuman))
+	addConfirmFlag(cmd, &yes)
 	return cmd
 }
+
+// reportHostpathToBlockPlan prints the migration plan in the provided output format, text or json. byte
+// amounts in the text output and in the returned error are printed according to bytesFormat. returns an
+// error if the data to be migrated would not fit in the block device OSDs.
+func reportHostpathToBlockPlan(w io.Writer, output, bytesFormat string, plan rook.MigrationPlan) error {
+	if output == "json" {
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode migration plan: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+	} else {
+		if len(plan.OSDs) == 0 {
+			fmt.Fprintln(w, "No hostpath OSDs found, no migration is required")
+		}
+		for _, osd := range plan.OSDs {
+			fmt.Fprintf(w, "Would migrate osd.%d (node %s), %s to be moved\n", osd.OSD, osd.Node, formatBytes(osd.BytesMoved, bytesFormat))
+		}
+		fmt.Fprintf(
+			w, "Data to be moved: %s, available in %d block device OSD(s): %s\n",
+			formatBytes(plan.BytesToMove, bytesFormat), len(plan.BlockOSDs), formatBytes(plan.BlockAvailableBytes, bytesFormat),
+		)
+		if !plan.SufficientBlockOSDs && len(plan.OSDs) > 0 {
+			fmt.Fprintln(w, "Not enough block device OSDs are attached yet, the migration would wait for them")
+		}
+	}
+
+	if !plan.Fits {
+		return fmt.Errorf(
+			"migration would not fit: %s to be moved, %s available in block device OSDs",
+			formatBytes(plan.BytesToMove, bytesFormat), formatBytes(plan.BlockAvailableBytes, bytesFormat),
+		)
+	}
+	return nil
+}
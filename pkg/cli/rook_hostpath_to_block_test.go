@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/replicatedhq/kurl/pkg/rook"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_reportHostpathToBlockPlan(t *testing.T) {
+	plan := rook.MigrationPlan{
+		OSDs: []rook.OSDMigrationResult{
+			{OSD: 0, Node: "10.0.0.1", Status: rook.OSDMigrationPlanned, BytesMoved: 1 << 30},
+		},
+		BytesToMove:         1 << 30,
+		BlockOSDs:           []int64{1},
+		BlockAvailableBytes: 4 << 30,
+		SufficientBlockOSDs: true,
+		Fits:                true,
+	}
+
+	req := require.New(t)
+	out := bytes.NewBuffer(nil)
+	req.NoError(reportHostpathToBlockPlan(out, "text", bytesFormatHuman, plan))
+	req.Equal(
+		"Would migrate osd.0 (node 10.0.0.1), 1.0GiB to be moved\n"+
+			"Data to be moved: 1.0GiB, available in 1 block device OSD(s): 4.0GiB\n",
+		out.String(),
+	)
+
+	out.Reset()
+	req.NoError(reportHostpathToBlockPlan(out, "text", bytesFormatRaw, plan))
+	req.Equal(
+		"Would migrate osd.0 (node 10.0.0.1), 1073741824 to be moved\n"+
+			"Data to be moved: 1073741824, available in 1 block device OSD(s): 4294967296\n",
+		out.String(),
+	)
+
+	plan.Fits = false
+	plan.BlockAvailableBytes = 512 << 20
+	out.Reset()
+	err := reportHostpathToBlockPlan(out, "json", bytesFormatHuman, plan)
+	req.EqualError(err, "migration would not fit: 1.0GiB to be moved, 512.0MiB available in block device OSDs")
+	req.Contains(out.String(), `"fits": false`)
+}
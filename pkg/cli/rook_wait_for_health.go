@@ -9,17 +9,20 @@ import (
 	"github.com/replicatedhq/kurl/pkg/rook"
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/kubernetes"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
 )
 
-func NewRookWaitForHealthCmd(_ CLI) *cobra.Command {
+func NewRookWaitForHealthCmd(cli CLI) *cobra.Command {
 	var ignoreChecks []string
 	cmd := &cobra.Command{
 		Use:   "wait-for-health [TIMEOUT]",
 		Short: "Waits for Rook to report that it is healthy, and prints what it's waiting for",
 		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			k8sConfig := config.GetConfigOrDie()
+			k8sConfig, err := cli.KubeConfig()
+			if err != nil {
+				return fmt.Errorf("failed to read kubernetes configuration: %w", err)
+			}
+			rook.InitConfig(k8sConfig)
 			clientSet := kubernetes.NewForConfigOrDie(k8sConfig)
 
 			rook.InitWriter(cmd.OutOrStdout())
@@ -37,7 +40,7 @@ func NewRookWaitForHealthCmd(_ CLI) *cobra.Command {
 				defer cancel()
 			}
 
-			err := rook.WaitForRookHealth(ctx, clientSet, ignoreChecks)
+			err = rook.WaitForRookHealth(ctx, clientSet, ignoreChecks)
 			if err != nil {
 				return fmt.Errorf("failed to check rook health: %w", err)
 			}
@@ -8,10 +8,9 @@ import (
 	"github.com/replicatedhq/kurl/pkg/rook"
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/kubernetes"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
 )
 
-func NewRookWaitForRookVersionCmd(_ CLI) *cobra.Command {
+func NewRookWaitForRookVersionCmd(cli CLI) *cobra.Command {
 	timeoutSeconds := 0
 	cmd := &cobra.Command{
 		Use:   "wait-for-rook-version VERSION",
@@ -20,7 +19,10 @@ func NewRookWaitForRookVersionCmd(_ CLI) *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			rookVersion := args[0]
 
-			k8sConfig := config.GetConfigOrDie()
+			k8sConfig, err := cli.KubeConfig()
+			if err != nil {
+				return fmt.Errorf("failed to read kubernetes configuration: %w", err)
+			}
 			clientSet := kubernetes.NewForConfigOrDie(k8sConfig)
 
 			ctx := cmd.Context()
@@ -32,7 +34,7 @@ func NewRookWaitForRookVersionCmd(_ CLI) *cobra.Command {
 
 			rook.InitWriter(cmd.OutOrStdout())
 
-			err := rook.WaitForRookOrCephVersion(ctx, clientSet, rookVersion, "rook-version", "Rook")
+			err = rook.WaitForRookOrCephVersion(ctx, clientSet, rookVersion, "rook-version", "Rook")
 			if err != nil {
 				return fmt.Errorf("failed to wait for Rook %q: %w", rookVersion, err)
 			}
@@ -46,7 +48,7 @@ func NewRookWaitForRookVersionCmd(_ CLI) *cobra.Command {
 	return cmd
 }
 
-func NewRookWaitForCephVersionCmd(_ CLI) *cobra.Command {
+func NewRookWaitForCephVersionCmd(cli CLI) *cobra.Command {
 	timeoutSeconds := 0
 	cmd := &cobra.Command{
 		Use:   "wait-for-ceph-version VERSION",
@@ -55,7 +57,10 @@ func NewRookWaitForCephVersionCmd(_ CLI) *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			rookVersion := args[0]
 
-			k8sConfig := config.GetConfigOrDie()
+			k8sConfig, err := cli.KubeConfig()
+			if err != nil {
+				return fmt.Errorf("failed to read kubernetes configuration: %w", err)
+			}
 			clientSet := kubernetes.NewForConfigOrDie(k8sConfig)
 
 			ctx := cmd.Context()
@@ -67,7 +72,7 @@ func NewRookWaitForCephVersionCmd(_ CLI) *cobra.Command {
 
 			rook.InitWriter(cmd.OutOrStdout())
 
-			err := rook.WaitForRookOrCephVersion(ctx, clientSet, rookVersion, "ceph-version", "Ceph")
+			err = rook.WaitForRookOrCephVersion(ctx, clientSet, rookVersion, "ceph-version", "Ceph")
 			if err != nil {
 				return fmt.Errorf("failed to wait for Ceph %q: %w", rookVersion, err)
 			}
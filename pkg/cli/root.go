@@ -21,6 +21,13 @@ func NewKurlCmd(cli CLI) *cobra.Command {
 	}
 
 	cmd.PersistentFlags().Bool("debug", false, "enable debug logging")
+	cmd.PersistentFlags().String(kubeconfigFlag, "", "path to the kubeconfig file used by commands that talk to the cluster, when not provided the KUBECONFIG environment variable, the in-cluster service account and ~/.kube/config are tried in this order")
+	cmd.PersistentFlags().String(namespaceFlag, "", "default namespace for commands that create or read namespaced resources (jobs, config maps, secrets), commands with their own --namespace flag take precedence")
+
+	// subcommands replace the persistent pre run function so the namespace and kubeconfig flags
+	// are bound here to make them available to all of them.
+	_ = cli.GetViper().BindPFlag(namespaceFlag, cmd.PersistentFlags().Lookup(namespaceFlag))
+	_ = cli.GetViper().BindPFlag(kubeconfigFlag, cmd.PersistentFlags().Lookup(kubeconfigFlag))
 
 	AddCommands(cmd, cli)
 
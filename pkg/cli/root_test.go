@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func TestNewKurlCmdNamespace(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "should be empty when the flag is not provided",
+			args:     []string{"cluster", "probe"},
+			expected: "",
+		},
+		{
+			name:     "should pick up the flag provided before the subcommand",
+			args:     []string{"--namespace", "kurl", "cluster", "probe"},
+			expected: "kurl",
+		},
+		{
+			name:     "should pick up the flag provided after the subcommand",
+			args:     []string{"cluster", "probe", "--namespace", "kurl"},
+			expected: "kurl",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			t.Cleanup(viper.Reset)
+
+			kcli := &KurlCLI{
+				fs:     afero.NewMemMapFs(),
+				stdout: &bytes.Buffer{},
+				stderr: &bytes.Buffer{},
+			}
+			cmd := NewKurlCmd(kcli)
+
+			clusterCmd, _, err := cmd.Find([]string{"cluster"})
+			if err != nil {
+				t.Fatalf("unexpected error finding cluster command: %s", err)
+			}
+
+			var namespace string
+			clusterCmd.AddCommand(&cobra.Command{
+				Use: "probe",
+				RunE: func(cmd *cobra.Command, args []string) error {
+					namespace = kcli.Namespace()
+					return nil
+				},
+			})
+
+			cmd.SetArgs(tt.args)
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if namespace != tt.expected {
+				t.Errorf("expected namespace %q, %q received instead", tt.expected, namespace)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// storageHealthIssue is an inconsistency found between the cluster persistent volumes and claims.
+type storageHealthIssue struct {
+	Object  string `json:"object"`
+	Message string `json:"message"`
+	Hint    string `json:"hint"`
+}
+
+// storageHealthReport holds all the inconsistencies found by 'storage health'.
+type storageHealthReport struct {
+	Issues []storageHealthIssue `json:"issues"`
+}
+
+// tableHeader returns the columns of the report when printed as a table.
+func (r storageHealthReport) tableHeader() []string {
+	return []string{"OBJECT", "MESSAGE", "HINT"}
+}
+
+// tableRows returns one row per issue.
+func (r storageHealthReport) tableRows() [][]string {
+	var rows [][]string
+	for _, issue := range r.Issues {
+		rows = append(rows, []string{issue.Object, issue.Message, issue.Hint})
+	}
+	return rows
+}
+
+// tableSummary returns the number of issues found.
+func (r storageHealthReport) tableSummary() string {
+	if len(r.Issues) == 0 {
+		return "No storage inconsistencies found"
+	}
+	return fmt.Sprintf("%d storage inconsistencies found", len(r.Issues))
+}
+
+// findReleasedVolumeIssues reports the pvs that have been released for longer than grace. pvs whose
+// phase transition time is unknown are reported as soon as they are released.
+func findReleasedVolumeIssues(pvs []corev1.PersistentVolume, now time.Time, grace time.Duration) []storageHealthIssue {
+	var issues []storageHealthIssue
+	for _, pv := range pvs {
+		if pv.Status.Phase != corev1.VolumeReleased {
+			continue
+		}
+
+		since := pv.Status.LastPhaseTransitionTime
+		if since != nil && now.Sub(since.Time) < grace {
+			continue
+		}
+
+		message := "volume released and not reclaimed"
+		if since != nil {
+			message = fmt.Sprintf("volume released %s ago and not reclaimed", now.Sub(since.Time).Round(time.Second))
+		}
+
+		hint := "check the storage provisioner logs, it should have deleted the volume"
+		if pv.Spec.PersistentVolumeReclaimPolicy != corev1.PersistentVolumeReclaimDelete {
+			hint = fmt.Sprintf(
+				"reclaim policy is %s: back up and delete the volume or remove its claimRef to make it available again",
+				pv.Spec.PersistentVolumeReclaimPolicy,
+			)
+		}
+
+		issues = append(issues, storageHealthIssue{
+			Object:  fmt.Sprintf("pv/%s", pv.Name),
+			Message: message,
+			Hint:    hint,
+		})
+	}
+	return issues
+}
+
+// findPendingClaimIssues reports the pvcs that have been pending for longer than grace.
+func findPendingClaimIssues(pvcs []corev1.PersistentVolumeClaim, now time.Time, grace time.Duration) []storageHealthIssue {
+	var issues []storageHealthIssue
+	for _, pvc := range pvcs {
+		if pvc.Status.Phase != corev1.ClaimPending {
+			continue
+		}
+
+		age := now.Sub(pvc.CreationTimestamp.Time)
+		if age < grace {
+			continue
+		}
+
+		issues = append(issues, storageHealthIssue{
+			Object:  fmt.Sprintf("pvc/%s/%s", pvc.Namespace, pvc.Name),
+			Message: fmt.Sprintf("claim pending for %s", age.Round(time.Second)),
+			Hint: fmt.Sprintf(
+				"run 'kubectl describe pvc -n %s %s' and check the storage class provisioner, claims in storage classes binding on first consumer stay pending until a pod uses them",
+				pvc.Namespace, pvc.Name,
+			),
+		})
+	}
+	return issues
+}
+
+// findDanglingClaimRefIssues reports the pvs whose claimRef points to a pvc that does not exist, or
+// that has been recreated since the pv was bound to it (uid mismatch).
+func findDanglingClaimRefIssues(pvs []corev1.PersistentVolume, pvcs []corev1.PersistentVolumeClaim) []storageHealthIssue {
+	claims := map[string]corev1.PersistentVolumeClaim{}
+	for _, pvc := range pvcs {
+		claims[fmt.Sprintf("%s/%s", pvc.Namespace, pvc.Name)] = pvc
+	}
+
+	var issues []storageHealthIssue
+	for _, pv := range pvs {
+		ref := pv.Spec.ClaimRef
+		if ref == nil {
+			continue
+		}
+
+		// released volumes reference a deleted claim by definition, they are reported by
+		// findReleasedVolumeIssues.
+		if pv.Status.Phase == corev1.VolumeReleased {
+			continue
+		}
+
+		claimName := fmt.Sprintf("%s/%s", ref.Namespace, ref.Name)
+		pvc, found := claims[claimName]
+		switch {
+		case !found:
+			issues = append(issues, storageHealthIssue{
+				Object:  fmt.Sprintf("pv/%s", pv.Name),
+				Message: fmt.Sprintf("claimRef points to pvc %s that does not exist", claimName),
+				Hint:    "if the data is no longer needed delete the volume, otherwise remove its claimRef to make it available again",
+			})
+		case ref.UID != "" && ref.UID != pvc.UID:
+			issues = append(issues, storageHealthIssue{
+				Object:  fmt.Sprintf("pv/%s", pv.Name),
+				Message: fmt.Sprintf("claimRef points to a previous incarnation of pvc %s (uid %s, current uid %s)", claimName, ref.UID, pvc.UID),
+				Hint:    "remove the claimRef uid so the volume can be bound to the recreated claim, or delete the volume if the data is no longer needed",
+			})
+		}
+	}
+	return issues
+}
+
+// findStorageHealthIssues returns all the inconsistencies found between the provided pvs and pvcs,
+// sorted by object. grace is how long volumes may stay released and claims may stay pending before
+// being reported.
+func findStorageHealthIssues(pvs []corev1.PersistentVolume, pvcs []corev1.PersistentVolumeClaim, now time.Time, grace time.Duration) []storageHealthIssue {
+	var issues []storageHealthIssue
+	issues = append(issues, findReleasedVolumeIssues(pvs, now, grace)...)
+	issues = append(issues, findPendingClaimIssues(pvcs, now, grace)...)
+	issues = append(issues, findDanglingClaimRefIssues(pvs, pvcs)...)
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Object < issues[j].Object
+	})
+	return issues
+}
+
+// collectStorageHealthIssues lists all the pvs and pvcs in the cluster and returns the inconsistencies
+// found between them.
+func collectStorageHealthIssues(ctx context.Context, kubeCli kubernetes.Interface, now time.Time, grace time.Duration) ([]storageHealthIssue, error) {
+	pvs, err := kubeCli.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persistent volumes: %w", err)
+	}
+
+	pvcs, err := kubeCli.CoreV1().PersistentVolumeClaims("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persistent volume claims: %w", err)
+	}
+	return findStorageHealthIssues(pvs.Items, pvcs.Items, now, grace), nil
+}
+
+// NewStorageHealthCmd returns a command that reports inconsistencies between the cluster persistent
+// volumes and claims.
+func NewStorageHealthCmd(cli CLI) *cobra.Command {
+	var grace time.Duration
+	var output string
+	var format outputFormat
+	var clientSet kubernetes.Interface
+
+	cmd := &cobra.Command{
+		Use:          "health",
+		Short:        "Reports released volumes, pending claims and volumes bound to missing claims",
+		SilenceUsage: true,
+		Example: "" +
+			"# reports volumes released and claims pending for more than 10 minutes\n" +
+			"kurl storage health\n\n" +
+			"# reports volumes released and claims pending for more than an hour\n" +
+			"kurl storage health --grace-period 1h\n\n" +
+			"# reports the inconsistencies as json\n" +
+			"kurl storage health -o json\n",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			if format, err = parseOutputFormat(output); err != nil {
+				return err
+			}
+
+			k8sConfig, err := cli.KubeConfig()
+			if err != nil {
+				return fmt.Errorf("failed to read kubernetes configuration: %w", err)
+			}
+
+			clientSet, err = kubernetes.NewForConfig(k8sConfig)
+			if err != nil {
+				return fmt.Errorf("failed to create kubernetes client: %w", err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			issues, err := collectStorageHealthIssues(cmd.Context(), clientSet, time.Now(), grace)
+			if err != nil {
+				return err
+			}
+
+			report := storageHealthReport{Issues: issues}
+			if report.Issues == nil {
+				report.Issues = []storageHealthIssue{}
+			}
+			if err := renderOutput(cmd.OutOrStdout(), format, report); err != nil {
+				return err
+			}
+
+			if len(issues) > 0 {
+				return fmt.Errorf("found %d storage inconsistencies", len(issues))
+			}
+			return nil
+		},
+	}
+
+	addOutputFlag(cmd, &output)
+	cmd.Flags().DurationVar(&grace, "grace-period", 10*time.Minute, "How long volumes may stay released and claims may stay pending before being reported.")
+	return cmd
+}
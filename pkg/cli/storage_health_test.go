@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_collectStorageHealthIssues(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) *metav1.Time {
+		return &metav1.Time{Time: now.Add(-d)}
+	}
+
+	kcli := fake.NewSimpleClientset(
+		// healthy bound pair.
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "data", UID: "uid-data"},
+			Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
+		},
+		&corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-data"},
+			Spec: corev1.PersistentVolumeSpec{
+				ClaimRef: &corev1.ObjectReference{Namespace: "default", Name: "data", UID: "uid-data"},
+			},
+			Status: corev1.PersistentVolumeStatus{Phase: corev1.VolumeBound},
+		},
+		// released long ago with retain policy.
+		&corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-retained"},
+			Spec: corev1.PersistentVolumeSpec{
+				PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimRetain,
+				ClaimRef:                      &corev1.ObjectReference{Namespace: "default", Name: "gone"},
+			},
+			Status: corev1.PersistentVolumeStatus{Phase: corev1.VolumeReleased, LastPhaseTransitionTime: ago(time.Hour)},
+		},
+		// released long ago with delete policy, the provisioner should have deleted it.
+		&corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-stuck"},
+			Spec: corev1.PersistentVolumeSpec{
+				PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimDelete,
+			},
+			Status: corev1.PersistentVolumeStatus{Phase: corev1.VolumeReleased, LastPhaseTransitionTime: ago(30 * time.Minute)},
+		},
+		// released within the grace period.
+		&corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-recent"},
+			Spec: corev1.PersistentVolumeSpec{
+				PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimDelete,
+			},
+			Status: corev1.PersistentVolumeStatus{Phase: corev1.VolumeReleased, LastPhaseTransitionTime: ago(time.Minute)},
+		},
+		// bound to a claim that does not exist.
+		&corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-dangling"},
+			Spec: corev1.PersistentVolumeSpec{
+				ClaimRef: &corev1.ObjectReference{Namespace: "kurl", Name: "missing"},
+			},
+			Status: corev1.PersistentVolumeStatus{Phase: corev1.VolumeBound},
+		},
+		// bound to a previous incarnation of a claim.
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kurl", Name: "recreated", UID: "uid-new", CreationTimestamp: *ago(time.Minute)},
+			Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+		},
+		&corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-old-uid"},
+			Spec: corev1.PersistentVolumeSpec{
+				ClaimRef: &corev1.ObjectReference{Namespace: "kurl", Name: "recreated", UID: "uid-old"},
+			},
+			Status: corev1.PersistentVolumeStatus{Phase: corev1.VolumeBound},
+		},
+		// pending for too long.
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kurl", Name: "pending", CreationTimestamp: *ago(2 * time.Hour)},
+			Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+		},
+	)
+
+	issues, err := collectStorageHealthIssues(context.Background(), kcli, now, 10*time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []storageHealthIssue{
+		{
+			Object:  "pv/pv-dangling",
+			Message: "claimRef points to pvc kurl/missing that does not exist",
+			Hint:    "if the data is no longer needed delete the volume, otherwise remove its claimRef to make it available again",
+		},
+		{
+			Object:  "pv/pv-old-uid",
+			Message: "claimRef points to a previous incarnation of pvc kurl/recreated (uid uid-old, current uid uid-new)",
+			Hint:    "remove the claimRef uid so the volume can be bound to the recreated claim, or delete the volume if the data is no longer needed",
+		},
+		{
+			Object:  "pv/pv-retained",
+			Message: "volume released 1h0m0s ago and not reclaimed",
+			Hint:    "reclaim policy is Retain: back up and delete the volume or remove its claimRef to make it available again",
+		},
+		{
+			Object:  "pv/pv-stuck",
+			Message: "volume released 30m0s ago and not reclaimed",
+			Hint:    "check the storage provisioner logs, it should have deleted the volume",
+		},
+		{
+			Object:  "pvc/kurl/pending",
+			Message: "claim pending for 2h0m0s",
+			Hint:    "run 'kubectl describe pvc -n kurl pending' and check the storage class provisioner, claims in storage classes binding on first consumer stay pending until a pod uses them",
+		},
+	}
+	if diff := cmp.Diff(expected, issues); diff != "" {
+		t.Errorf("unexpected issues: %s", diff)
+	}
+}
+
+func Test_findReleasedVolumeIssuesUnknownTransition(t *testing.T) {
+	pvs := []corev1.PersistentVolume{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv0"},
+			Spec: corev1.PersistentVolumeSpec{
+				PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimDelete,
+			},
+			Status: corev1.PersistentVolumeStatus{Phase: corev1.VolumeReleased},
+		},
+	}
+
+	issues := findReleasedVolumeIssues(pvs, time.Now(), time.Hour)
+	if len(issues) != 1 || issues[0].Message != "volume released and not reclaimed" {
+		t.Errorf("expected released volume without transition time to be reported, received %+v", issues)
+	}
+}
@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	clusterspace "github.com/replicatedhq/kurl/pkg/cluster/space"
+)
+
+// storage class types reported by 'storage list', telling apart the storage classes that can be used
+// as source or destination of the migration commands.
+const (
+	storageClassTypeOpenEBS     = "openebs-hostpath"
+	storageClassTypeRookBlock   = "rook-block"
+	storageClassTypeRookFS      = "rook-filesystem"
+	storageClassTypeLocalVolume = "local-volume"
+	storageClassTypeOther       = "other"
+)
+
+// storageClassType returns the type of the storage class backed by the provided provisioner.
+func storageClassType(provisioner string) string {
+	switch provisioner {
+	case openEBSLocalProvisioner:
+		return storageClassTypeOpenEBS
+	case rookRBDProvisioner:
+		return storageClassTypeRookBlock
+	case rookCephFSProvisioner:
+		return storageClassTypeRookFS
+	case clusterspace.LocalVolumeProvisioner:
+		return storageClassTypeLocalVolume
+	default:
+		return storageClassTypeOther
+	}
+}
+
+// storageClassInfo is a storage class as printed by 'storage list'. the base path is only resolved for
+// OpenEBS storage classes, Error holds the reason it could not be parsed.
+type storageClassInfo struct {
+	Name        string `json:"name"`
+	Provisioner string `json:"provisioner"`
+	Type        string `json:"type"`
+	Default     bool   `json:"default"`
+	BasePath    string `json:"basePath,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// storageClassesReport holds the storage classes listed by 'storage list'.
+type storageClassesReport struct {
+	StorageClasses []storageClassInfo `json:"storageClasses"`
+}
+
+// tableHeader returns the columns of the report when printed as a table.
+func (r storageClassesReport) tableHeader() []string {
+	return []string{"NAME", "PROVISIONER", "TYPE", "DEFAULT", "BASE PATH"}
+}
+
+// tableRows returns one row per storage class. base paths that could not be parsed are replaced by
+// the parse error.
+func (r storageClassesReport) tableRows() [][]string {
+	var rows [][]string
+	for _, sc := range r.StorageClasses {
+		basePath := sc.BasePath
+		if sc.Error != "" {
+			basePath = fmt.Sprintf("<%s>", sc.Error)
+		}
+		rows = append(rows, []string{sc.Name, sc.Provisioner, sc.Type, strconv.FormatBool(sc.Default), basePath})
+	}
+	return rows
+}
+
+// tableSummary returns the number of storage classes listed.
+func (r storageClassesReport) tableSummary() string {
+	if len(r.StorageClasses) == 0 {
+		return "No storage classes found"
+	}
+	return fmt.Sprintf("%d storage class(es)", len(r.StorageClasses))
+}
+
+// listStorageClasses returns all the storage classes in the cluster sorted by name. the base path of
+// the OpenEBS ones is resolved replacing the placeholders of templated base paths by vars, base paths
+// that can't be parsed are reported instead of aborting the listing.
+func listStorageClasses(ctx context.Context, kubeCli kubernetes.Interface, vars map[string]string) ([]storageClassInfo, error) {
+	sclasses, err := kubeCli.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list storage classes: %w", err)
+	}
+
+	var openEBSClasses []string
+	result := []storageClassInfo{}
+	for _, sclass := range sclasses.Items {
+		info := storageClassInfo{
+			Name:        sclass.Name,
+			Provisioner: sclass.Provisioner,
+			Type:        storageClassType(sclass.Provisioner),
+			Default:     sclass.Annotations[isDefaultStorageClassAnnotation] == "true",
+		}
+		if info.Type == storageClassTypeOpenEBS {
+			openEBSClasses = append(openEBSClasses, sclass.Name)
+		}
+		result = append(result, info)
+	}
+
+	if len(openEBSClasses) > 0 {
+		paths, err := clusterspace.ResolveOpenEBSBasePaths(
+			ctx, kubeCli, openEBSClasses, vars, clusterspace.BasePathPolicySkipUnparseable,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve openebs base paths: %w", err)
+		}
+
+		byName := map[string]clusterspace.StorageClassBasePath{}
+		for _, path := range paths {
+			byName[path.StorageClass] = path
+		}
+		for i := range result {
+			path, ok := byName[result[i].Name]
+			if !ok {
+				continue
+			}
+			result[i].BasePath = path.BasePath
+			if path.Err != nil {
+				result[i].Error = path.Err.Error()
+			}
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// NewStorageListCmd returns a command that lists the cluster storage classes along with their
+// provisioner, whether they are the default one and, for OpenEBS ones, their base path.
+func NewStorageListCmd(cli CLI) *cobra.Command {
+	var output string
+	var format outputFormat
+	var vars map[string]string
+	var clientSet kubernetes.Interface
+
+	cmd := &cobra.Command{
+		Use:          "list",
+		Short:        "Lists the storage classes, their provisioner and, for OpenEBS ones, their base path",
+		SilenceUsage: true,
+		Example: "" +
+			"# lists all the storage classes\n" +
+			"kurl storage list\n\n" +
+			"# lists all the storage classes as json\n" +
+			"kurl storage list -o json\n",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			if format, err = parseOutputFormat(output); err != nil {
+				return err
+			}
+
+			k8sConfig, err := cli.KubeConfig()
+			if err != nil {
+				return fmt.Errorf("failed to read kubernetes configuration: %w", err)
+			}
+
+			clientSet, err = kubernetes.NewForConfig(k8sConfig)
+			if err != nil {
+				return fmt.Errorf("failed to create kubernetes client: %w", err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sclasses, err := listStorageClasses(cmd.Context(), clientSet, vars)
+			if err != nil {
+				return err
+			}
+			return renderOutput(cmd.OutOrStdout(), format, storageClassesReport{StorageClasses: sclasses})
+		},
+	}
+
+	addOutputFlag(cmd, &output)
+	cmd.Flags().StringToStringVar(&vars, "base-path-var", nil, "Values (name=value) for the placeholders, $name or ${name}, found in templated OpenEBS storage class base paths. May be repeated.")
+	return cmd
+}
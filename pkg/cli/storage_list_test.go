@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_listStorageClasses(t *testing.T) {
+	kcli := fake.NewSimpleClientset(
+		&storagev1.StorageClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "longhorn",
+				Annotations: map[string]string{isDefaultStorageClassAnnotation: "false"},
+			},
+			Provisioner: "driver.longhorn.io",
+		},
+		&storagev1.StorageClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "openebs",
+				Annotations: map[string]string{
+					isDefaultStorageClassAnnotation: "true",
+					"cas.openebs.io/config":         "- name: BasePath\n  value: /var/openebs/${DISK}",
+				},
+			},
+			Provisioner: openEBSLocalProvisioner,
+		},
+		&storagev1.StorageClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "openebs-broken",
+				Annotations: map[string]string{"cas.openebs.io/config": "not yaml: ["},
+			},
+			Provisioner: openEBSLocalProvisioner,
+		},
+		&storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: "distributed"},
+			Provisioner: rookRBDProvisioner,
+		},
+		&storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: "shared"},
+			Provisioner: rookCephFSProvisioner,
+		},
+	)
+
+	sclasses, err := listStorageClasses(context.Background(), kcli, map[string]string{"DISK": "nvme"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(sclasses) != 5 || sclasses[3].Name != "openebs-broken" || sclasses[3].Error == "" || sclasses[3].BasePath != "" {
+		t.Fatalf("expected the unparseable base path to be reported, received %+v", sclasses)
+	}
+	sclasses[3].Error = ""
+
+	expected := []storageClassInfo{
+		{Name: "distributed", Provisioner: rookRBDProvisioner, Type: storageClassTypeRookBlock},
+		{Name: "longhorn", Provisioner: "driver.longhorn.io", Type: storageClassTypeOther},
+		{Name: "openebs", Provisioner: openEBSLocalProvisioner, Type: storageClassTypeOpenEBS, Default: true, BasePath: "/var/openebs/nvme"},
+		{Name: "openebs-broken", Provisioner: openEBSLocalProvisioner, Type: storageClassTypeOpenEBS},
+		{Name: "shared", Provisioner: rookCephFSProvisioner, Type: storageClassTypeRookFS},
+	}
+	if diff := cmp.Diff(expected, sclasses); diff != "" {
+		t.Errorf("unexpected storage classes: %s", diff)
+	}
+}
+
+func Test_storageClassesReportTable(t *testing.T) {
+	report := storageClassesReport{StorageClasses: []storageClassInfo{
+		{Name: "openebs", Provisioner: openEBSLocalProvisioner, Type: storageClassTypeOpenEBS, Default: true, BasePath: "/var/openebs/local"},
+		{Name: "openebs-broken", Provisioner: openEBSLocalProvisioner, Type: storageClassTypeOpenEBS, Error: "bad config"},
+	}}
+
+	buf := bytes.NewBuffer(nil)
+	if err := renderOutput(buf, outputTable, report); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	out := buf.String()
+	for _, expected := range []string{"NAME", "/var/openebs/local", "<bad config>", "2 storage class(es)"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected %q in output:\n%s", expected, out)
+		}
+	}
+
+	buf.Reset()
+	if err := renderOutput(buf, outputTable, storageClassesReport{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if strings.TrimSpace(buf.String()) != "No storage classes found" {
+		t.Errorf("unexpected output for no storage classes: %q", buf.String())
+	}
+}
@@ -0,0 +1,251 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	clusterspace "github.com/replicatedhq/kurl/pkg/cluster/space"
+	"github.com/replicatedhq/kurl/pkg/k8sutil"
+)
+
+// storageSnapshotVersion is the version of the storage snapshot document. it must be bumped whenever
+// a backwards incompatible change is made to the document.
+const storageSnapshotVersion = "v1"
+
+// storageSnapshot is a point in time capture of the cluster storage state.
+type storageSnapshot struct {
+	Version           string                   `json:"version"`
+	CreatedAt         time.Time                `json:"createdAt"`
+	StorageClasses    []storageSnapshotClass   `json:"storageClasses"`
+	PersistentVolumes []storageSnapshotVolume  `json:"persistentVolumes"`
+	Nodes             []storageSnapshotNodeUse `json:"nodes,omitempty"`
+}
+
+// storageSnapshotClass is a storage class as captured in a storage snapshot.
+type storageSnapshotClass struct {
+	Name        string            `json:"name"`
+	Provisioner string            `json:"provisioner"`
+	Default     bool              `json:"default"`
+	Parameters  map[string]string `json:"parameters,omitempty"`
+}
+
+// storageSnapshotVolume is a persistent volume as captured in a storage snapshot. Node is only set
+// for volumes bound to a node through their node affinity (e.g. openebs local volumes).
+type storageSnapshotVolume struct {
+	Name         string `json:"name"`
+	StorageClass string `json:"storageClass"`
+	Capacity     int64  `json:"capacity"`
+	Phase        string `json:"phase"`
+	Claim        string `json:"claim,omitempty"`
+	Node         string `json:"node,omitempty"`
+}
+
+// storageSnapshotNodeUse is the space used and available in a node for an openebs storage class.
+type storageSnapshotNodeUse struct {
+	Node         string `json:"node"`
+	StorageClass string `json:"storageClass"`
+	Free         int64  `json:"free"`
+	Used         int64  `json:"used"`
+	RootVolume   bool   `json:"rootVolume"`
+}
+
+// collectStorageSnapshot captures the storage classes and persistent volumes in the cluster. the
+// provided openebs volumes, measured in the scname storage class, are captured as node usage.
+func collectStorageSnapshot(ctx context.Context, kubeCli kubernetes.Interface, scname string, volumes map[string]clusterspace.OpenEBSVolume, now time.Time) (*storageSnapshot, error) {
+	snapshot := &storageSnapshot{
+		Version:           storageSnapshotVersion,
+		CreatedAt:         now.UTC(),
+		StorageClasses:    []storageSnapshotClass{},
+		PersistentVolumes: []storageSnapshotVolume{},
+	}
+
+	classes, err := kubeCli.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cluster storage classes: %w", err)
+	}
+
+	for _, class := range classes.Items {
+		snapshot.StorageClasses = append(snapshot.StorageClasses, storageSnapshotClass{
+			Name:        class.Name,
+			Provisioner: class.Provisioner,
+			Default:     class.Annotations[isDefaultStorageClassAnnotation] == "true",
+			Parameters:  class.Parameters,
+		})
+	}
+
+	pvs, err := kubeCli.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persistent volumes: %w", err)
+	}
+
+	for _, pv := range pvs.Items {
+		volume := storageSnapshotVolume{
+			Name:         pv.Name,
+			StorageClass: pv.Spec.StorageClassName,
+			Capacity:     pv.Spec.Capacity.Storage().Value(),
+			Phase:        string(pv.Status.Phase),
+			Node:         k8sutil.PersistentVolumeNode(pv),
+		}
+		if ref := pv.Spec.ClaimRef; ref != nil {
+			volume.Claim = fmt.Sprintf("%s/%s", ref.Namespace, ref.Name)
+		}
+		snapshot.PersistentVolumes = append(snapshot.PersistentVolumes, volume)
+	}
+
+	for node, volume := range volumes {
+		snapshot.Nodes = append(snapshot.Nodes, storageSnapshotNodeUse{
+			Node:         node,
+			StorageClass: scname,
+			Free:         volume.Free,
+			Used:         volume.Used,
+			RootVolume:   volume.RootVolume,
+		})
+	}
+
+	sort.Slice(snapshot.StorageClasses, func(i, j int) bool {
+		return snapshot.StorageClasses[i].Name < snapshot.StorageClasses[j].Name
+	})
+	sort.Slice(snapshot.PersistentVolumes, func(i, j int) bool {
+		return snapshot.PersistentVolumes[i].Name < snapshot.PersistentVolumes[j].Name
+	})
+	sort.Slice(snapshot.Nodes, func(i, j int) bool {
+		return snapshot.Nodes[i].Node < snapshot.Nodes[j].Node
+	})
+	return snapshot, nil
+}
+
+// writeStorageSnapshot writes the snapshot as json into the provided path.
+func writeStorageSnapshot(fs afero.Fs, path string, snapshot *storageSnapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode storage snapshot: %w", err)
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := fs.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+	}
+
+	if err := afero.WriteFile(fs, path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write storage snapshot: %w", err)
+	}
+	return nil
+}
+
+// readStorageSnapshot reads a snapshot written by writeStorageSnapshot. fails if the document has
+// been written by an incompatible version.
+func readStorageSnapshot(fs afero.Fs, path string) (*storageSnapshot, error) {
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read storage snapshot: %w", err)
+	}
+
+	var snapshot storageSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode storage snapshot: %w", err)
+	}
+
+	if snapshot.Version != storageSnapshotVersion {
+		return nil, fmt.Errorf("unsupported storage snapshot version %q, expected %q", snapshot.Version, storageSnapshotVersion)
+	}
+	return &snapshot, nil
+}
+
+// NewStorageSnapshotCmd returns a command that captures the cluster storage state into a file.
+func NewStorageSnapshotCmd(cli CLI) *cobra.Command {
+	var storageClass, image string
+	var skipNodes bool
+	var clientSet kubernetes.Interface
+
+	cmd := &cobra.Command{
+		Use:          "snapshot FILE",
+		Short:        "Captures the storage classes, persistent volumes and nodes free space into a json file",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		Example: "" +
+			"# captures the storage state, measuring the nodes free space in the default storage class\n" +
+			"kurl storage snapshot /var/lib/kurl/storage-snapshot.json\n\n" +
+			"# captures the storage state without running jobs in the nodes\n" +
+			"kurl storage snapshot --skip-nodes snapshot.json\n",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			k8sConfig, err := cli.KubeConfig()
+			if err != nil {
+				return fmt.Errorf("failed to read kubernetes configuration: %w", err)
+			}
+
+			clientSet, err = kubernetes.NewForConfig(k8sConfig)
+			if err != nil {
+				return fmt.Errorf("failed to create kubernetes client: %w", err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logger := log.New(io.Discard, "", 0)
+			if debug, _ := cmd.Flags().GetBool("debug"); debug {
+				logger = log.New(os.Stderr, "", 0)
+			}
+
+			var scname string
+			var volumes map[string]clusterspace.OpenEBSVolume
+			if !skipNodes {
+				sc, err := getStorageClassByName(cmd.Context(), clientSet, storageClass)
+				if err != nil {
+					return err
+				}
+
+				if sc.Provisioner == openEBSLocalProvisioner {
+					scname = sc.Name
+					getter, err := newOpenEBSFreeSpaceGetter(clientSet, logger, openEBSFreeSpaceOpts{
+						getterOpts: clusterspace.OpenEBSOptions{
+							Image:     image,
+							DstSC:     scname,
+							Namespace: cli.Namespace(),
+						},
+					})
+					if err != nil {
+						return err
+					}
+
+					if volumes, err = getter.OpenEBSVolumes(cmd.Context()); err != nil {
+						return fmt.Errorf("failed to get openebs free space: %w", err)
+					}
+				} else {
+					fmt.Fprintf(cmd.ErrOrStderr(), "Storage class %s is not backed by %s, nodes free space not captured\n", sc.Name, openEBSLocalProvisioner)
+				}
+			}
+
+			snapshot, err := collectStorageSnapshot(cmd.Context(), clientSet, scname, volumes, time.Now())
+			if err != nil {
+				return err
+			}
+
+			if err := writeStorageSnapshot(cli.GetFS(), args[0], snapshot); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(
+				cmd.OutOrStdout(), "Storage snapshot with %d storage class(es), %d volume(s) and %d node(s) written to %s\n",
+				len(snapshot.StorageClasses), len(snapshot.PersistentVolumes), len(snapshot.Nodes), args[0],
+			)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&storageClass, "storageclass", "", "The OpenEBS storage class where the nodes free space is measured. If not informed the default storage class will be used.")
+	cmd.Flags().StringVar(&image, "openebs-image", defaultOpenEBSPodImage, "The image used by the OpenEBS disk free evaluation pods.")
+	cmd.Flags().BoolVar(&skipNodes, "skip-nodes", false, "Does not measure the nodes free space, only the storage classes and persistent volumes are captured.")
+	return cmd
+}
@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	clusterspace "github.com/replicatedhq/kurl/pkg/cluster/space"
+)
+
+func storageSnapshotFixture() *fake.Clientset {
+	return fake.NewSimpleClientset(
+		&storagev1.StorageClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "openebs",
+				Annotations: map[string]string{isDefaultStorageClassAnnotation: "true"},
+			},
+			Provisioner: openEBSLocalProvisioner,
+		},
+		&storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: "rook"},
+			Provisioner: rookRBDProvisioner,
+			Parameters:  map[string]string{"pool": "replicapool"},
+		},
+		&corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv1"},
+			Spec: corev1.PersistentVolumeSpec{
+				StorageClassName: "openebs",
+				Capacity:         corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+				ClaimRef:         &corev1.ObjectReference{Namespace: "default", Name: "data"},
+				NodeAffinity: &corev1.VolumeNodeAffinity{
+					Required: &corev1.NodeSelector{
+						NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+							MatchExpressions: []corev1.NodeSelectorRequirement{{
+								Key:      corev1.LabelHostname,
+								Operator: corev1.NodeSelectorOpIn,
+								Values:   []string{"node0"},
+							}},
+						}},
+					},
+				},
+			},
+			Status: corev1.PersistentVolumeStatus{Phase: corev1.VolumeBound},
+		},
+		&corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv0"},
+			Spec: corev1.PersistentVolumeSpec{
+				StorageClassName: "rook",
+				Capacity:         corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("2Ki")},
+			},
+			Status: corev1.PersistentVolumeStatus{Phase: corev1.VolumeAvailable},
+		},
+	)
+}
+
+func Test_collectStorageSnapshot(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	volumes := map[string]clusterspace.OpenEBSVolume{
+		"node1": {Free: 10, Used: 20},
+		"node0": {Free: 30, Used: 40, RootVolume: true},
+	}
+
+	snapshot, err := collectStorageSnapshot(context.Background(), storageSnapshotFixture(), "openebs", volumes, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := &storageSnapshot{
+		Version:   storageSnapshotVersion,
+		CreatedAt: now,
+		StorageClasses: []storageSnapshotClass{
+			{Name: "openebs", Provisioner: openEBSLocalProvisioner, Default: true},
+			{Name: "rook", Provisioner: rookRBDProvisioner, Parameters: map[string]string{"pool": "replicapool"}},
+		},
+		PersistentVolumes: []storageSnapshotVolume{
+			{Name: "pv0", StorageClass: "rook", Capacity: 2048, Phase: "Available"},
+			{Name: "pv1", StorageClass: "openebs", Capacity: 1073741824, Phase: "Bound", Claim: "default/data", Node: "node0"},
+		},
+		Nodes: []storageSnapshotNodeUse{
+			{Node: "node0", StorageClass: "openebs", Free: 30, Used: 40, RootVolume: true},
+			{Node: "node1", StorageClass: "openebs", Free: 10, Used: 20},
+		},
+	}
+	if diff := cmp.Diff(expected, snapshot); diff != "" {
+		t.Errorf("unexpected snapshot: %s", diff)
+	}
+}
+
+func Test_storageSnapshotSchema(t *testing.T) {
+	snapshot, err := collectStorageSnapshot(context.Background(), fake.NewSimpleClientset(), "", nil, time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := `{"version":"v1","createdAt":"1970-01-01T00:00:00Z","storageClasses":[],"persistentVolumes":[]}`
+	if string(data) != expected {
+		t.Errorf("expected %s, %s received instead", expected, string(data))
+	}
+}
+
+func Test_storageSnapshotRoundTrip(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	volumes := map[string]clusterspace.OpenEBSVolume{"node0": {Free: 30, Used: 40}}
+
+	snapshot, err := collectStorageSnapshot(context.Background(), storageSnapshotFixture(), "openebs", volumes, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := writeStorageSnapshot(fs, "/var/lib/kurl/snapshot.json", snapshot); err != nil {
+		t.Fatalf("unexpected error writing snapshot: %s", err)
+	}
+
+	read, err := readStorageSnapshot(fs, "/var/lib/kurl/snapshot.json")
+	if err != nil {
+		t.Fatalf("unexpected error reading snapshot: %s", err)
+	}
+
+	if diff := cmp.Diff(snapshot, read); diff != "" {
+		t.Errorf("snapshot changed after round trip: %s", diff)
+	}
+
+	if err := afero.WriteFile(fs, "/v2.json", []byte(`{"version":"v2"}`), 0644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := readStorageSnapshot(fs, "/v2.json"); err == nil || !strings.Contains(err.Error(), "unsupported storage snapshot version") {
+		t.Errorf("expected unsupported version error, %v received instead", err)
+	}
+}
@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/kubernetes"
+
+	clusterspace "github.com/replicatedhq/kurl/pkg/cluster/space"
+)
+
+// storageSpaceMeasurer measures the free space in each node, implemented by the openebs free disk
+// space getter.
+type storageSpaceMeasurer interface {
+	OpenEBSVolumes(ctx context.Context) (map[string]clusterspace.OpenEBSVolume, error)
+}
+
+// storageWatchBreach is a node whose free space dropped below the watch threshold.
+type storageWatchBreach struct {
+	Node string
+	Free int64
+}
+
+// findStorageBreaches returns, sorted by node name, the nodes whose free space is below the threshold.
+func findStorageBreaches(volumes map[string]clusterspace.OpenEBSVolume, threshold int64) []storageWatchBreach {
+	var breaches []storageWatchBreach
+	for node, vol := range volumes {
+		if vol.Free < threshold {
+			breaches = append(breaches, storageWatchBreach{Node: node, Free: vol.Free})
+		}
+	}
+	sort.Slice(breaches, func(i, j int) bool {
+		return breaches[i].Node < breaches[j].Node
+	})
+	return breaches
+}
+
+// watchStorageSpace measures the nodes free space right away and then every time tick fires, until the
+// context is cancelled. onBreach is called with the nodes below the threshold after every measurement
+// finding any, if it returns an error the watch stops and the error is returned. measurement failures
+// are reported through onError and do not stop the watch. returns nil when the context is cancelled.
+func watchStorageSpace(ctx context.Context, measurer storageSpaceMeasurer, threshold int64, tick <-chan time.Time, onBreach func([]storageWatchBreach) error, onError func(error)) error {
+	for {
+		volumes, err := measurer.OpenEBSVolumes(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case err != nil:
+			onError(err)
+		default:
+			if breaches := findStorageBreaches(volumes, threshold); len(breaches) > 0 {
+				if err := onBreach(breaches); err != nil {
+					return err
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-tick:
+		}
+	}
+}
+
+func NewStorageWatchCmd(cli CLI) *cobra.Command {
+	var storageClass, image, thresholdString string
+	var interval time.Duration
+	var exitOnBreach bool
+	var threshold int64
+	var clientSet kubernetes.Interface
+
+	cmd := &cobra.Command{
+		Use:          "watch",
+		Short:        "Periodically measures the OpenEBS free space in all nodes and reports the nodes below a threshold",
+		SilenceUsage: true,
+		Example: "" +
+			"# reports, every 5 minutes, the nodes with less than 10Gi free in the default storage class\n" +
+			"kurl storage watch --threshold 10Gi --interval 5m\n\n" +
+			"# exits with a non zero code as soon as any node has less than 10Gi free\n" +
+			"kurl storage watch --threshold 10Gi --exit-on-breach\n",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if interval <= 0 {
+				return fmt.Errorf("interval must be positive")
+			}
+
+			quantity, err := resource.ParseQuantity(thresholdString)
+			if err != nil {
+				return fmt.Errorf("failed to parse threshold %q: %w", thresholdString, err)
+			}
+			threshold = quantity.Value()
+
+			k8sConfig, err := cli.KubeConfig()
+			if err != nil {
+				return fmt.Errorf("failed to read kubernetes configuration: %w", err)
+			}
+
+			clientSet, err = kubernetes.NewForConfig(k8sConfig)
+			if err != nil {
+				return fmt.Errorf("failed to create kubernetes client: %w", err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(cmd.Context(), syscall.SIGTERM, syscall.SIGINT)
+			defer cancel()
+
+			logger := log.New(io.Discard, "", 0)
+			if debug, _ := cmd.Flags().GetBool("debug"); debug {
+				logger = log.New(os.Stderr, "", 0)
+			}
+
+			sc, err := getStorageClassByName(ctx, clientSet, storageClass)
+			if err != nil {
+				return err
+			}
+			if sc.Provisioner != openEBSLocalProvisioner {
+				return fmt.Errorf("storage class %s is not backed by %s", sc.Name, openEBSLocalProvisioner)
+			}
+
+			getter, err := newOpenEBSFreeSpaceGetter(clientSet, logger, openEBSFreeSpaceOpts{
+				getterOpts: clusterspace.OpenEBSOptions{
+					Image:     image,
+					DstSC:     sc.Name,
+					Namespace: cli.Namespace(),
+				},
+			})
+			if err != nil {
+				return err
+			}
+
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			out := log.New(cmd.OutOrStdout(), "", log.LstdFlags)
+			onBreach := func(breaches []storageWatchBreach) error {
+				for _, breach := range breaches {
+					out.Printf(
+						"Node %s has %s available, below the %s threshold",
+						breach.Node, formatBytes(breach.Free, bytesFormatHuman), formatBytes(threshold, bytesFormatHuman),
+					)
+				}
+				if exitOnBreach {
+					return fmt.Errorf("%d node(s) below the %s threshold", len(breaches), formatBytes(threshold, bytesFormatHuman))
+				}
+				return nil
+			}
+			onError := func(err error) {
+				out.Printf("Failed to measure the free space: %s", err)
+			}
+
+			out.Printf("Watching the free space in storage class %s every %s", sc.Name, interval)
+			return watchStorageSpace(ctx, getter, threshold, ticker.C, onBreach, onError)
+		},
+	}
+
+	cmd.Flags().StringVar(&storageClass, "storageclass", "", "The OpenEBS storage class where the nodes free space is measured. If not informed the default storage class will be used.")
+	cmd.Flags().StringVar(&image, "openebs-image", defaultOpenEBSPodImage, "The image used by the OpenEBS disk free evaluation pods.")
+	cmd.Flags().StringVar(&thresholdString, "threshold", "", "Nodes with less free space than this are reported. Accepts the same format as used when defining storage requests in Kubernetes (e.g. 10G, 5Gi, 500M).")
+	cmd.Flags().DurationVar(&interval, "interval", 5*time.Minute, "How often the free space is measured.")
+	cmd.Flags().BoolVar(&exitOnBreach, "exit-on-breach", false, "Exits with a non zero code as soon as any node is below the threshold instead of keep watching.")
+	cmd.MarkFlagRequired("threshold")
+	return cmd
+}
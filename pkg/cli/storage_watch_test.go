@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	clusterspace "github.com/replicatedhq/kurl/pkg/cluster/space"
+)
+
+// stubMeasurer returns the provided measurements, one per call, cancelling the context after the
+// last one.
+type stubMeasurer struct {
+	measurements []map[string]clusterspace.OpenEBSVolume
+	errors       []error
+	calls        int
+	cancel       context.CancelFunc
+}
+
+func (s *stubMeasurer) OpenEBSVolumes(ctx context.Context) (map[string]clusterspace.OpenEBSVolume, error) {
+	idx := s.calls
+	s.calls++
+	if s.calls == len(s.measurements) {
+		s.cancel()
+	}
+	return s.measurements[idx], s.errors[idx]
+}
+
+func Test_watchStorageSpace(t *testing.T) {
+	healthy := map[string]clusterspace.OpenEBSVolume{
+		"node0": {Free: 2000},
+		"node1": {Free: 3000},
+	}
+	breached := map[string]clusterspace.OpenEBSVolume{
+		"node0": {Free: 2000},
+		"node1": {Free: 500},
+		"node2": {Free: 100},
+	}
+
+	for _, tt := range []struct {
+		name         string
+		measurements []map[string]clusterspace.OpenEBSVolume
+		errors       []error
+		exitOnBreach bool
+		breaches     [][]storageWatchBreach
+		failures     int
+		calls        int
+		err          string
+	}{
+		{
+			name:         "should keep watching while all nodes are above the threshold",
+			measurements: []map[string]clusterspace.OpenEBSVolume{healthy, healthy, healthy},
+			errors:       []error{nil, nil, nil},
+			calls:        3,
+		},
+		{
+			name:         "should report the nodes below the threshold and keep watching",
+			measurements: []map[string]clusterspace.OpenEBSVolume{healthy, breached, healthy},
+			errors:       []error{nil, nil, nil},
+			breaches:     [][]storageWatchBreach{{{Node: "node1", Free: 500}, {Node: "node2", Free: 100}}},
+			calls:        3,
+		},
+		{
+			name:         "should stop on the first breach when the callback fails",
+			measurements: []map[string]clusterspace.OpenEBSVolume{healthy, breached, healthy},
+			errors:       []error{nil, nil, nil},
+			exitOnBreach: true,
+			breaches:     [][]storageWatchBreach{{{Node: "node1", Free: 500}, {Node: "node2", Free: 100}}},
+			calls:        2,
+			err:          "2 node(s) below threshold",
+		},
+		{
+			name:         "should keep watching on measurement failures",
+			measurements: []map[string]clusterspace.OpenEBSVolume{nil, healthy},
+			errors:       []error{fmt.Errorf("api server unavailable"), nil},
+			failures:     1,
+			calls:        2,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			measurer := &stubMeasurer{measurements: tt.measurements, errors: tt.errors, cancel: cancel}
+
+			// the ticker always fires so the loop only stops on cancellation or breach.
+			tick := make(chan time.Time)
+			close(tick)
+
+			var breaches [][]storageWatchBreach
+			onBreach := func(nodes []storageWatchBreach) error {
+				breaches = append(breaches, nodes)
+				if tt.exitOnBreach {
+					return fmt.Errorf("%d node(s) below threshold", len(nodes))
+				}
+				return nil
+			}
+
+			var failures int
+			onError := func(error) { failures++ }
+
+			err := watchStorageSpace(ctx, measurer, 1000, tick, onBreach, onError)
+			if err != nil {
+				if tt.err == "" {
+					t.Fatalf("unexpected error: %s", err)
+				} else if err.Error() != tt.err {
+					t.Errorf("expecting %q, %q received instead", tt.err, err)
+				}
+			} else if tt.err != "" {
+				t.Errorf("expecting error %q, nil received instead", tt.err)
+			}
+
+			if diff := cmp.Diff(tt.breaches, breaches); diff != "" {
+				t.Errorf("unexpected breaches: %s", diff)
+			}
+			if failures != tt.failures {
+				t.Errorf("expecting %d failures, %d received instead", tt.failures, failures)
+			}
+			if measurer.calls != tt.calls {
+				t.Errorf("expecting %d measurements, %d executed instead", tt.calls, measurer.calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	clusterspace "github.com/replicatedhq/kurl/pkg/cluster/space"
+)
+
+// supportBundleFile is a file to be written into a support bundle. name is the path inside the archive.
+type supportBundleFile struct {
+	name string
+	data []byte
+}
+
+// supportBundleResults is the outcome of a free disk space run as written into the support bundle results.json.
+type supportBundleResults struct {
+	StorageClass string                                `json:"storageClass"`
+	RunID        string                                `json:"runId"`
+	Passed       bool                                  `json:"passed"`
+	Error        string                                `json:"error,omitempty"`
+	Volumes      map[string]clusterspace.OpenEBSVolume `json:"volumes,omitempty"`
+	Checks       []supportBundleCheck                  `json:"checks,omitempty"`
+	SkippedNodes map[string]string                     `json:"skippedNodes,omitempty"`
+}
+
+// supportBundleCheck is the free space check of a node as written into the support bundle results.json.
+type supportBundleCheck struct {
+	Node          string `json:"node"`
+	Status        string `json:"status"`
+	Passed        bool   `json:"passed"`
+	FreeBytes     int64  `json:"freeBytes"`
+	RequiredBytes int64  `json:"requiredBytes"`
+	Message       string `json:"message"`
+}
+
+// newSupportBundleResults summarizes a free disk space run. runErr is the error the run finished with, if any.
+func newSupportBundleResults(getter *clusterspace.OpenEBSFreeDiskSpaceGetter, scname string, volumes map[string]clusterspace.OpenEBSVolume, checks []nodeSpaceCheck, runErr error) supportBundleResults {
+	results := supportBundleResults{
+		StorageClass: scname,
+		RunID:        getter.RunID(),
+		Passed:       runErr == nil,
+		Volumes:      volumes,
+		SkippedNodes: getter.SkippedNodes(),
+	}
+	if runErr != nil {
+		results.Error = runErr.Error()
+	}
+
+	for _, check := range checks {
+		results.Checks = append(results.Checks, supportBundleCheck{
+			Node:          check.result.NodeName,
+			Status:        string(check.result.Status),
+			Passed:        check.passed,
+			FreeBytes:     check.result.FreeBytes,
+			RequiredBytes: check.result.RequiredBytes,
+			Message:       check.message,
+		})
+	}
+	return results
+}
+
+// collectSupportBundle gathers the files of the support bundle of a free disk space run: the run results, the output of the
+// containers of each node job, the storage class definition, the nodes conditions and the manifests of the objects created in
+// each node. failures to collect any of them are written into errors.txt instead of aborting the collection so a bundle is
+// always produced.
+func collectSupportBundle(ctx context.Context, kubeCli kubernetes.Interface, getter *clusterspace.OpenEBSFreeDiskSpaceGetter, results supportBundleResults) []supportBundleFile {
+	var files []supportBundleFile
+	var errs []string
+	addJSON := func(name string, obj interface{}) {
+		data, err := json.MarshalIndent(obj, "", "  ")
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("failed to encode %s: %s", name, err))
+			return
+		}
+		files = append(files, supportBundleFile{name: name, data: data})
+	}
+	addYAML := func(name string, obj interface{}) {
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("failed to encode %s: %s", name, err))
+			return
+		}
+		files = append(files, supportBundleFile{name: name, data: data})
+	}
+
+	addJSON("results.json", results)
+
+	outputs := getter.JobOutputs()
+	var nodes []string
+	for node := range outputs {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	for _, node := range nodes {
+		var containers []string
+		for container := range outputs[node] {
+			containers = append(containers, container)
+		}
+		sort.Strings(containers)
+		for _, container := range containers {
+			name := fmt.Sprintf("logs/%s/%s.log", node, container)
+			files = append(files, supportBundleFile{name: name, data: outputs[node][container]})
+		}
+	}
+
+	if sc, err := kubeCli.StorageV1().StorageClasses().Get(ctx, results.StorageClass, metav1.GetOptions{}); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to get storage class %s: %s", results.StorageClass, err))
+	} else {
+		sc.ManagedFields = nil
+		addYAML(fmt.Sprintf("storageclasses/%s.yaml", sc.Name), sc)
+	}
+
+	if nodeList, err := kubeCli.CoreV1().Nodes().List(ctx, metav1.ListOptions{}); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to list nodes: %s", err))
+	} else {
+		conditions := map[string][]corev1.NodeCondition{}
+		for _, node := range nodeList.Items {
+			conditions[node.Name] = node.Status.Conditions
+		}
+		addJSON("node-conditions.json", conditions)
+	}
+
+	if manifests, err := getter.Manifests(ctx); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to build manifests: %s", err))
+	} else {
+		for _, manifest := range manifests {
+			addYAML(fmt.Sprintf("manifests/%s-pvc.yaml", manifest.Node), manifest.PVC)
+			addYAML(fmt.Sprintf("manifests/%s-job.yaml", manifest.Node), manifest.Job)
+		}
+	}
+
+	if len(errs) > 0 {
+		files = append(files, supportBundleFile{name: "errors.txt", data: []byte(strings.Join(errs, "\n") + "\n")})
+	}
+	return files
+}
+
+// writeSupportBundle writes the provided files as a gzip compressed tarball into path. all the files are placed inside a
+// directory named after the archive.
+func writeSupportBundle(path string, files []supportBundleFile, now time.Time) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create support bundle: %w", err)
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("failed to close support bundle: %w", cerr)
+		}
+	}()
+
+	root := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), ".tgz"), ".tar.gz")
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, file := range files {
+		header := &tar.Header{
+			Name:    filepath.ToSlash(filepath.Join(root, file.name)),
+			Mode:    0644,
+			Size:    int64(len(file.data)),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to write %s header: %w", file.name, err)
+		}
+		if _, err := tw.Write(file.data); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to close support bundle tarball: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to close support bundle compression: %w", err)
+	}
+	return nil
+}
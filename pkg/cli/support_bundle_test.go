@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	clusterspace "github.com/replicatedhq/kurl/pkg/cluster/space"
+)
+
+// readSupportBundle returns the content of the files in the provided gzip compressed tarball indexed by name.
+func readSupportBundle(t *testing.T, path string) map[string][]byte {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open support bundle: %s", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("failed to decompress support bundle: %s", err)
+	}
+
+	files := map[string][]byte{}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read support bundle: %s", err)
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("failed to read %s: %s", header.Name, err)
+		}
+		files[header.Name] = data
+	}
+	return files
+}
+
+func Test_supportBundle(t *testing.T) {
+	objs := []runtime.Object{
+		&storagev1.StorageClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "openebs",
+				Annotations: map[string]string{"cas.openebs.io/config": "- name: BasePath\n  value: /var/openebs/local"},
+			},
+			Provisioner: clusterspace.OpenEBSLocalProvisioner,
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node0"},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			},
+		},
+	}
+
+	for _, tt := range []struct {
+		name     string
+		scname   string
+		runErr   error
+		expected []string
+	}{
+		{
+			name:   "should bundle a passing run",
+			scname: "openebs",
+			expected: []string{
+				"bundle/manifests/node0-job.yaml",
+				"bundle/manifests/node0-pvc.yaml",
+				"bundle/node-conditions.json",
+				"bundle/results.json",
+				"bundle/storageclasses/openebs.yaml",
+			},
+		},
+		{
+			name:   "should bundle a failed run, recording what could not be collected",
+			scname: "missing",
+			runErr: fmt.Errorf("failed to read openebs base path"),
+			expected: []string{
+				"bundle/errors.txt",
+				"bundle/node-conditions.json",
+				"bundle/results.json",
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			kcli := fake.NewSimpleClientset(objs...)
+			getter, err := clusterspace.NewOpenEBSFreeDiskSpaceGetterWithOptions(kcli, clusterspace.OpenEBSOptions{
+				Log:   log.New(io.Discard, "", 0),
+				Image: "myimage:latest",
+				DstSC: tt.scname,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			volumes := map[string]clusterspace.OpenEBSVolume{"node0": {Free: 1000, Used: 500}}
+			checks := []nodeSpaceCheck{{
+				result:  clusterspace.NodeSpaceResult{NodeName: "node0", FreeBytes: 1000, RequiredBytes: 500, Status: clusterspace.NodeSpaceOK},
+				message: "Node node0 has 1000 available (requested 500)",
+				passed:  true,
+			}}
+			results := newSupportBundleResults(getter, tt.scname, volumes, checks, tt.runErr)
+			files := collectSupportBundle(context.Background(), kcli, getter, results)
+
+			path := filepath.Join(t.TempDir(), "bundle.tgz")
+			if err := writeSupportBundle(path, files, time.Now()); err != nil {
+				t.Fatalf("unexpected error writing support bundle: %s", err)
+			}
+
+			content := readSupportBundle(t, path)
+			var names []string
+			for name := range content {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			if diff := cmp.Diff(tt.expected, names); diff != "" {
+				t.Fatalf("unexpected support bundle entries: %s", diff)
+			}
+
+			var decoded supportBundleResults
+			if err := json.Unmarshal(content["bundle/results.json"], &decoded); err != nil {
+				t.Fatalf("invalid results.json: %s", err)
+			}
+			if decoded.Passed != (tt.runErr == nil) || decoded.RunID != getter.RunID() || len(decoded.Checks) != 1 {
+				t.Errorf("unexpected results: %+v", decoded)
+			}
+			if tt.runErr != nil && decoded.Error != tt.runErr.Error() {
+				t.Errorf("expected error %q in results, received %q", tt.runErr, decoded.Error)
+			}
+		})
+	}
+}
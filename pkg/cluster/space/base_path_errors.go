@@ -0,0 +1,54 @@
+package clusterspace
+
+import (
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+var (
+	// ErrStorageClassNotFound means the storage class does not exist.
+	ErrStorageClassNotFound = errors.New("storage class not found")
+	// ErrOpenEBSConfigNotFound means the storage class has no openebs config annotation.
+	ErrOpenEBSConfigNotFound = errors.New("openebs config annotation not found")
+	// ErrOpenEBSConfigInvalid means the openebs config annotation could not be parsed.
+	ErrOpenEBSConfigInvalid = errors.New("invalid openebs config annotation")
+	// ErrBasePathNotDefined means the openebs config annotation does not define the base path.
+	ErrBasePathNotDefined = errors.New("openebs base path not defined")
+	// ErrBasePathUnresolved means the base path contains placeholders no value has been provided for.
+	ErrBasePathUnresolved = errors.New("unresolved openebs base path placeholders")
+	// ErrBasePathInvalid means the base path is not an absolute path.
+	ErrBasePathInvalid = errors.New("invalid openebs base path")
+)
+
+// BasePathError is returned when the openebs base path can't be read out of a storage class. Kind
+// is one of the sentinel errors above and can be checked with errors.Is, Err is the underlying
+// error and provides the message.
+type BasePathError struct {
+	StorageClass string
+	Kind         error
+	Err          error
+}
+
+// Error returns the message of the underlying error.
+func (e *BasePathError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns both the kind and the underlying error so both can be matched with errors.Is and
+// errors.As.
+func (e *BasePathError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// storageClassReadError wraps the error returned when reading the provided storage class, a not
+// found error is flagged as ErrStorageClassNotFound. format must end with
+// the %w verb err is formatted with, args are the values of the verbs before it.
+func storageClassReadError(scname string, err error, format string, args ...interface{}) error {
+	wrapped := fmt.Errorf(format, append(args, err)...)
+	if !apierrors.IsNotFound(err) {
+		return wrapped
+	}
+	return &BasePathError{StorageClass: scname, Kind: ErrStorageClassNotFound, Err: wrapped}
+}
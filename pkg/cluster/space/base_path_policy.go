@@ -0,0 +1,95 @@
+package clusterspace
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultOpenEBSBasePath is the base path used by the openebs local provisioner when none is
+// configured in the storage class.
+const DefaultOpenEBSBasePath = "/var/openebs/local"
+
+// BasePathPolicy defines what happens when the base path of one of many storage classes can't be
+// parsed out of its openebs config annotation, e.g. in clusters whose storage classes have been
+// authored for different openebs versions.
+type BasePathPolicy string
+
+const (
+	// BasePathPolicyFailFast aborts the resolution on the first base path that can't be parsed.
+	BasePathPolicyFailFast BasePathPolicy = "fail-fast"
+	// BasePathPolicySkipUnparseable records the storage class as errored and carries on with the
+	// others. errored storage classes have no base path.
+	BasePathPolicySkipUnparseable BasePathPolicy = "skip-unparseable"
+	// BasePathPolicyBestEffort records the parse error and assumes the openebs default base path
+	// (DefaultOpenEBSBasePath) for the storage class.
+	BasePathPolicyBestEffort BasePathPolicy = "best-effort"
+)
+
+// BasePathPolicies are all the supported base path policies.
+var BasePathPolicies = []BasePathPolicy{
+	BasePathPolicyFailFast, BasePathPolicySkipUnparseable, BasePathPolicyBestEffort,
+}
+
+// StorageClassBasePath is the base path resolved for a storage class. Err holds the reason the
+// base path could not be parsed when the policy allowed the resolution to carry on: BasePath is
+// then empty (skip-unparseable) or the openebs default one (best-effort).
+type StorageClassBasePath struct {
+	StorageClass string
+	Provisioner  string
+	BasePath     string
+	Err          error
+}
+
+// ResolveOpenEBSBasePaths reads the base path of each of the provided storage classes, replacing
+// the placeholders found in templated base paths by vars. if no storage class is provided all the
+// storage classes backed by the openebs local provisioner are resolved. parse failures are handled
+// according to the provided policy while failures to read the storage classes always abort the
+// resolution. results are sorted by storage class name.
+func ResolveOpenEBSBasePaths(ctx context.Context, kcli kubernetes.Interface, scnames []string, vars map[string]string, policy BasePathPolicy) ([]StorageClassBasePath, error) {
+	switch policy {
+	case BasePathPolicyFailFast, BasePathPolicySkipUnparseable, BasePathPolicyBestEffort:
+	default:
+		return nil, fmt.Errorf("invalid base path policy %q", policy)
+	}
+
+	if len(scnames) == 0 {
+		sclasses, err := kcli.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list storage classes: %w", err)
+		}
+
+		for _, sclass := range sclasses.Items {
+			if sclass.Provisioner == OpenEBSLocalProvisioner {
+				scnames = append(scnames, sclass.Name)
+			}
+		}
+	}
+
+	sorted := append([]string{}, scnames...)
+	sort.Strings(sorted)
+
+	var result []StorageClassBasePath
+	for _, scname := range sorted {
+		sclass, err := kcli.StorageV1().StorageClasses().Get(ctx, scname, metav1.GetOptions{})
+		if err != nil {
+			return nil, storageClassReadError(scname, err, "failed to read storage class %s: %w", scname)
+		}
+
+		resolved := StorageClassBasePath{StorageClass: scname, Provisioner: sclass.Provisioner}
+		if resolved.BasePath, err = parseOpenEBSBasePath(sclass, vars); err != nil {
+			switch policy {
+			case BasePathPolicyFailFast:
+				return nil, fmt.Errorf("failed to parse storage class %s base path: %w", scname, err)
+			case BasePathPolicyBestEffort:
+				resolved.BasePath = DefaultOpenEBSBasePath
+			}
+			resolved.Err = err
+		}
+		result = append(result, resolved)
+	}
+	return result, nil
+}
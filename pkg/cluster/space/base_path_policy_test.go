@@ -0,0 +1,137 @@
+package clusterspace
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestResolveOpenEBSBasePaths(t *testing.T) {
+	sclass := func(name, provisioner, config string) *storagev1.StorageClass {
+		sc := &storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: name},
+			Provisioner: provisioner,
+		}
+		if config != "" {
+			sc.Annotations = map[string]string{"cas.openebs.io/config": config}
+		}
+		return sc
+	}
+
+	objs := []runtime.Object{
+		sclass("openebs", OpenEBSLocalProvisioner, "- name: BasePath\n  value: /var/openebs/local"),
+		sclass("openebs-legacy", OpenEBSLocalProvisioner, "BasePath=/var/openebs/legacy"),
+		sclass("openebs-nvme", OpenEBSLocalProvisioner, "- name: StorageType\n  value: hostpath\n- name: BasePath\n  value: /mnt/nvme"),
+		sclass("openebs-templated", OpenEBSLocalProvisioner, "- name: BasePath\n  value: /mnt/${DISK}"),
+		sclass("longhorn", "driver.longhorn.io", ""),
+	}
+
+	// summary renders the resolved base paths as "storageclass=basepath", flagging the errored
+	// ones, for comparison.
+	summary := func(paths []StorageClassBasePath) []string {
+		var result []string
+		for _, path := range paths {
+			line := path.StorageClass + "=" + path.BasePath
+			if path.Err != nil {
+				line += " (errored)"
+			}
+			result = append(result, line)
+		}
+		return result
+	}
+
+	for _, tt := range []struct {
+		name     string
+		scnames  []string
+		vars     map[string]string
+		policy   BasePathPolicy
+		expected []string
+		err      string
+		kind     error
+	}{
+		{
+			name:   "fail-fast should abort on the first unparseable storage class",
+			policy: BasePathPolicyFailFast,
+			err:    "failed to parse storage class openebs-legacy base path: failed to parse openebs config annotation",
+			kind:   ErrOpenEBSConfigInvalid,
+		},
+		{
+			name:    "fail-fast should succeed if all storage classes are parseable",
+			scnames: []string{"openebs-nvme", "openebs"},
+			policy:  BasePathPolicyFailFast,
+			expected: []string{
+				"openebs=/var/openebs/local",
+				"openebs-nvme=/mnt/nvme",
+			},
+		},
+		{
+			name:   "skip-unparseable should record the unparseable storage classes and continue",
+			policy: BasePathPolicySkipUnparseable,
+			expected: []string{
+				"openebs=/var/openebs/local",
+				"openebs-legacy= (errored)",
+				"openebs-nvme=/mnt/nvme",
+				"openebs-templated= (errored)",
+			},
+		},
+		{
+			name:    "skip-unparseable should resolve placeholders with the provided variables",
+			scnames: []string{"openebs-templated"},
+			vars:    map[string]string{"DISK": "sdb"},
+			policy:  BasePathPolicySkipUnparseable,
+			expected: []string{
+				"openebs-templated=/mnt/sdb",
+			},
+		},
+		{
+			name:    "best-effort should assume the default base path for unparseable storage classes",
+			scnames: []string{"openebs-templated", "openebs", "longhorn"},
+			policy:  BasePathPolicyBestEffort,
+			expected: []string{
+				"longhorn=/var/openebs/local (errored)",
+				"openebs=/var/openebs/local",
+				"openebs-templated=/var/openebs/local (errored)",
+			},
+		},
+		{
+			name:    "should fail if a storage class does not exist regardless of the policy",
+			scnames: []string{"openebs", "missing"},
+			policy:  BasePathPolicyBestEffort,
+			err:     "failed to read storage class missing",
+			kind:    ErrStorageClassNotFound,
+		},
+		{
+			name:   "should fail if the policy is invalid",
+			policy: "ignore",
+			err:    `invalid base path policy "ignore"`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			kcli := fake.NewSimpleClientset(objs...)
+			paths, err := ResolveOpenEBSBasePaths(context.Background(), kcli, tt.scnames, tt.vars, tt.policy)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, received %v", tt.err, err)
+				}
+				if tt.kind != nil && !errors.Is(err, tt.kind) {
+					t.Errorf("expected error to be %v, %v received instead", tt.kind, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, summary(paths)); diff != "" {
+				t.Errorf("unexpected base paths: %s", diff)
+			}
+		})
+	}
+}
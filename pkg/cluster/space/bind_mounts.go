@@ -0,0 +1,61 @@
+package clusterspace
+
+import (
+	"path"
+	"strings"
+)
+
+// maxBindMountHops is the maximum number of bind mounts followed when resolving a path, it protects
+// against fstabs with circular bind mounts.
+const maxBindMountHops = 10
+
+// isBindMount returns true if the provided comma separated fstab options make the entry a bind
+// mount, i.e. if any of them is bind or rbind.
+func isBindMount(options string) bool {
+	for _, option := range strings.Split(options, ",") {
+		if option == "bind" || option == "rbind" {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveBindMounts returns the path the provided one points to once the bind mounts holding it are
+// followed: if the entry with the longest mount point holding the path is a bind mount the path is
+// rewritten relative to its source, and the process is repeated. the path is returned unchanged if
+// it is not held by any bind mount.
+func resolveBindMounts(entries []FstabEntry, p string) string {
+	for i := 0; i < maxBindMountHops; i++ {
+		var holder *FstabEntry
+		for j := range entries {
+			entry := &entries[j]
+			if !pathHasPrefix(p, entry.MountPoint) {
+				continue
+			}
+			if holder == nil || len(entry.MountPoint) > len(holder.MountPoint) {
+				holder = entry
+			}
+		}
+
+		if holder == nil || !holder.Bind || !strings.HasPrefix(holder.Source, "/") {
+			return p
+		}
+		p = path.Join(holder.Source, strings.TrimPrefix(p, holder.MountPoint))
+	}
+	return p
+}
+
+// isRootVolume returns true if the provided path is held by the root filesystem, i.e. if no fstab
+// entry other than / holds it. bind mounts are not filesystems of their own, the path must have been
+// resolved with resolveBindMounts beforehand.
+func isRootVolume(entries []FstabEntry, p string) bool {
+	for _, entry := range entries {
+		if entry.Bind || entry.MountPoint == "/" {
+			continue
+		}
+		if pathHasPrefix(p, entry.MountPoint) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package clusterspace
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_parseFstabEntries(t *testing.T) {
+	content := []byte(`UUID=be35a709-c787-4198-a903-d5fdc80ab2f8  /  ext4  defaults  0  1
+/dev/sdb1  /mnt/bigdisk  xfs  defaults  0  2
+/mnt/bigdisk/openebs  /data  none  bind  0  0
+/srv/logs  /var/log/app  none  defaults,bind,nofail  0  0
+/mnt/bigdisk/exports  /exports  none  rbind,ro  0  0
+/srv/binding  /binding  none  defaults,binding  0  0
+overlay  /var/lib/overlay  overlay  defaults  0  0
+proc  /proc  proc  defaults  0  0`)
+
+	expected := []FstabEntry{
+		{Source: "UUID=be35a709-c787-4198-a903-d5fdc80ab2f8", MountPoint: "/", FSType: "ext4"},
+		{Source: "/dev/sdb1", MountPoint: "/mnt/bigdisk", FSType: "xfs"},
+		{Source: "/mnt/bigdisk/openebs", MountPoint: "/data", FSType: "none", Bind: true},
+		{Source: "/srv/logs", MountPoint: "/var/log/app", FSType: "none", Bind: true},
+		{Source: "/mnt/bigdisk/exports", MountPoint: "/exports", FSType: "none", Bind: true},
+		{Source: "/srv/binding", MountPoint: "/binding", FSType: "none"},
+		{Source: "overlay", MountPoint: "/var/lib/overlay", FSType: "overlay", Unreliable: true},
+	}
+
+	getter := OpenEBSFreeDiskSpaceGetter{}
+	entries, err := getter.parseFstabEntries(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff := cmp.Diff(expected, entries); diff != "" {
+		t.Errorf("unexpected entries: %s", diff)
+	}
+}
+
+func Test_resolveBindMounts(t *testing.T) {
+	entries := []FstabEntry{
+		{Source: "/dev/sda1", MountPoint: "/"},
+		{Source: "/dev/sdb1", MountPoint: "/mnt/bigdisk"},
+		{Source: "/mnt/bigdisk/openebs", MountPoint: "/data", Bind: true},
+		{Source: "/data/nested", MountPoint: "/chained", Bind: true},
+		{Source: "/var/lib/local", MountPoint: "/local", Bind: true},
+		{Source: "/loop-b", MountPoint: "/loop-a", Bind: true},
+		{Source: "/loop-a", MountPoint: "/loop-b", Bind: true},
+	}
+
+	for _, tt := range []struct {
+		name     string
+		path     string
+		expected string
+		root     bool
+	}{
+		{
+			name:     "should keep paths not held by bind mounts",
+			path:     "/var/openebs/local",
+			expected: "/var/openebs/local",
+			root:     true,
+		},
+		{
+			name:     "should resolve the bind mount point itself",
+			path:     "/data",
+			expected: "/mnt/bigdisk/openebs",
+		},
+		{
+			name:     "should resolve paths under bind mounts",
+			path:     "/data/openebs/local",
+			expected: "/mnt/bigdisk/openebs/openebs/local",
+		},
+		{
+			name:     "should follow chained bind mounts",
+			path:     "/chained/local",
+			expected: "/mnt/bigdisk/openebs/nested/local",
+		},
+		{
+			name:     "should report bind mounts from the root filesystem as root volumes",
+			path:     "/local/openebs",
+			expected: "/var/lib/local/openebs",
+			root:     true,
+		},
+		{
+			name:     "should not match mount points by string prefix",
+			path:     "/database",
+			expected: "/database",
+			root:     true,
+		},
+		{
+			name:     "should stop following circular bind mounts",
+			path:     "/loop-a/x",
+			expected: "/loop-a/x",
+			root:     true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved := resolveBindMounts(entries, tt.path)
+			if resolved != tt.expected {
+				t.Errorf("expected %s, %s received instead", tt.expected, resolved)
+			}
+			if root := isRootVolume(entries, resolved); root != tt.root {
+				t.Errorf("expected root volume %v, %v received instead", tt.root, root)
+			}
+		})
+	}
+}
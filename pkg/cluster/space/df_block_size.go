@@ -0,0 +1,63 @@
+package clusterspace
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// dfBlockSizeRegexp matches the size column of the df header, e.g. 1B-blocks for 'df -B1',
+// 1K-blocks for 'df -k' or 1024-blocks for 'df -P'. units are powers of 1024.
+var dfBlockSizeRegexp = regexp.MustCompile(`^([0-9]+)([BKMGT]?)-blocks$`)
+
+// dfBlockUnits maps the df block size unit suffixes to their size in bytes.
+var dfBlockUnits = map[string]int64{
+	"":  1,
+	"B": 1,
+	"K": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30,
+	"T": 1 << 40,
+}
+
+// dfBlockSize returns the size, in bytes, of the blocks the used and available columns of the df
+// output are expressed in, as announced by its header. some minimal images ship a busybox df that
+// can only report 1K-blocks. output without a header, or whose header does not announce a block
+// size (e.g. human readable output), is assumed to be in bytes.
+func dfBlockSize(output []byte) (int64, error) {
+	scanner := bufio.NewScanner(bytes.NewBuffer(output))
+	for scanner.Scan() {
+		words := strings.Fields(scanner.Text())
+		if len(words) < 2 || words[0] != "Filesystem" {
+			continue
+		}
+
+		matches := dfBlockSizeRegexp.FindStringSubmatch(words[1])
+		if matches == nil {
+			return 1, nil
+		}
+
+		count, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil || count == 0 || count > math.MaxInt64/dfBlockUnits[matches[2]] {
+			return 0, fmt.Errorf("invalid df block size %q", words[1])
+		}
+		return count * dfBlockUnits[matches[2]], nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to process container log: %w", err)
+	}
+	return 1, nil
+}
+
+// dfBlocksToBytes converts an amount of df blocks of the provided size into bytes.
+func dfBlocksToBytes(blocks, blockSize int64) (int64, error) {
+	if blocks > math.MaxInt64/blockSize {
+		return 0, fmt.Errorf("%d blocks of %d bytes overflow", blocks, blockSize)
+	}
+	return blocks * blockSize, nil
+}
@@ -0,0 +1,121 @@
+package clusterspace
+
+import (
+	"testing"
+)
+
+func Test_dfBlockSize(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		output   string
+		expected int64
+		err      string
+	}{
+		{
+			name:     "bytes",
+			output:   "Filesystem 1B-blocks Used Available Use% Mounted on\n/dev/sda2 1 1 1 1% /data",
+			expected: 1,
+		},
+		{
+			name:     "kilobytes",
+			output:   "Filesystem 1K-blocks Used Available Use% Mounted on\n/dev/sda2 1 1 1 1% /data",
+			expected: 1024,
+		},
+		{
+			name:     "megabytes",
+			output:   "Filesystem 1M-blocks Used Available Use% Mounted on",
+			expected: 1 << 20,
+		},
+		{
+			name:     "posix output",
+			output:   "Filesystem 1024-blocks Used Available Capacity Mounted on",
+			expected: 1024,
+		},
+		{
+			name:     "noise before the header",
+			output:   "some noise\nFilesystem 512-blocks Used Available Use% Mounted on",
+			expected: 512,
+		},
+		{
+			name:     "human readable output is left for the parser to reject",
+			output:   "Filesystem Size Used Avail Use% Mounted on\n/dev/sda2 59G 49G 6.9G 88% /data",
+			expected: 1,
+		},
+		{
+			name:     "no header",
+			output:   "/dev/sda2 1 1 1 1% /data",
+			expected: 1,
+		},
+		{
+			name:   "zero sized blocks",
+			output: "Filesystem 0K-blocks Used Available Use% Mounted on",
+			err:    `invalid df block size "0K-blocks"`,
+		},
+		{
+			name:   "overflowing block size",
+			output: "Filesystem 99999999999T-blocks Used Available Use% Mounted on",
+			err:    `invalid df block size "99999999999T-blocks"`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			size, err := dfBlockSize([]byte(tt.output))
+			if err != nil {
+				if tt.err == "" || err.Error() != tt.err {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if tt.err != "" {
+				t.Fatalf("expected error %q, none received", tt.err)
+			}
+			if size != tt.expected {
+				t.Errorf("expected block size %d, %d received", tt.expected, size)
+			}
+		})
+	}
+}
+
+func Test_dfBlocksToBytes(t *testing.T) {
+	if b, err := dfBlocksToBytes(10, 1024); err != nil || b != 10240 {
+		t.Errorf("expected 10240 bytes, %d (%v) received", b, err)
+	}
+	if _, err := dfBlocksToBytes(1<<60, 1024); err == nil {
+		t.Errorf("expected overflow error, none received")
+	}
+}
+
+func Test_validateStrictDFOutputBlockSize(t *testing.T) {
+	ochecker := OpenEBSFreeDiskSpaceGetter{}
+	for _, tt := range []struct {
+		name   string
+		output string
+		err    string
+	}{
+		{
+			name:   "bytes",
+			output: "Filesystem 1B-blocks Used Available Use% Mounted on\n/dev/sda2 4096 1024 3072 25% /data",
+		},
+		{
+			name:   "kilobytes from the busybox fallback",
+			output: "Filesystem 1K-blocks Used Available Use% Mounted on\n/dev/sda2 4 1 3 25% /data",
+		},
+		{
+			name:   "human readable output",
+			output: "Filesystem Size Used Avail Use% Mounted on\n/dev/sda2 4.0K 1.0K 3.0K 25% /data",
+			err:    "unexpected df header: Filesystem Size Used Avail Use% Mounted on",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ochecker.validateStrictDFOutput([]byte(tt.output))
+			if err != nil {
+				if tt.err == "" || err.Error() != tt.err {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if tt.err != "" {
+				t.Fatalf("expected error %q, none received", tt.err)
+			}
+		})
+	}
+}
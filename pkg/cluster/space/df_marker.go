@@ -0,0 +1,49 @@
+package clusterspace
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+)
+
+// DefaultDFMarker is printed by the df container on its own line right before the df output.
+const DefaultDFMarker = "KURL_DF_BEGIN"
+
+// dfMarkerRegexp restricts the df markers to words that can't be mistaken for df output and are
+// safe to be echoed by the container shell.
+var dfMarkerRegexp = regexp.MustCompile(`^[A-Za-z0-9_.:-]+$`)
+
+// isValidDFMarker returns true if the provided marker can be used to delimit the df output.
+func isValidDFMarker(marker string) bool {
+	return dfMarkerRegexp.MatchString(marker)
+}
+
+// dfCommand returns the script executed by the df container, measuring the base path mounted under
+// the provided path. it prints the marker before the df output so the parser can ignore anything
+// printed before it. df falls back to 1K-blocks when it
+// does not support byte sized blocks, as busybox builds in minimal images, see dfBlockSize. its
+// output is base64 encoded by the container, see encodeOutputCommand.
+func dfCommand(mountPath, marker string) string {
+	return basePathScript(mountPath, fmt.Sprintf("echo %s; df -B1 %s 2>/dev/null || df -k %s", marker, mountPath, mountPath))
+}
+
+// dfOutputMarker returns the marker printed by the df container before the df output.
+func (o *OpenEBSFreeDiskSpaceGetter) dfOutputMarker() string {
+	if o.opts.Output.DFMarker == "" {
+		return DefaultDFMarker
+	}
+	return o.opts.Output.DFMarker
+}
+
+// cutDFMarker returns the part of the df container output following the first line that matches
+// the marker. an error is returned if the marker can't be found, in that case we can't tell the df
+// output apart from any other noise printed by the container.
+func cutDFMarker(output []byte, marker string) ([]byte, error) {
+	lines := bytes.Split(output, []byte("\n"))
+	for i, line := range lines {
+		if string(bytes.TrimSpace(line)) == marker {
+			return bytes.Join(lines[i+1:], []byte("\n")), nil
+		}
+	}
+	return nil, fmt.Errorf("df output marker %s not found in pod log: %s", marker, string(output))
+}
@@ -0,0 +1,156 @@
+package clusterspace
+
+import (
+	"io"
+	"log"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_parseFreeSpaceDFMarker(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		marker       string
+		strict       bool
+		content      string
+		err          string
+		expectedFree int64
+		expectedUsed int64
+	}{
+		{
+			name: "should parse the output following the marker",
+			content: `KURL_DF_BEGIN
+Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sda2      63087357952 52521754624 7327760384  88% /data`,
+			expectedFree: 7327760384,
+			expectedUsed: 52521754624,
+		},
+		{
+			name: "should fail without the marker",
+			content: `Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sda2      63087357952 52521754624 7327760384  88% /data`,
+			err: "df output marker KURL_DF_BEGIN not found",
+		},
+		{
+			name:    "should fail with empty output",
+			content: ``,
+			err:     "df output marker KURL_DF_BEGIN not found",
+		},
+		{
+			name: "should ignore noise printed before the marker",
+			content: `initializing container
+WARNING: image platform does not match the host /data
+fake 1 2 3 4% /data
+  KURL_DF_BEGIN  
+Filesystem       1B-blocks       Used   Available Use% Mounted on
+/dev/xvda1     85886742528 8500056064 77386686464  10% /data`,
+			expectedFree: 77386686464,
+			expectedUsed: 8500056064,
+		},
+		{
+			name:   "should pass strict parsing with noise printed before the marker",
+			strict: true,
+			content: `image warning: something something /data
+KURL_DF_BEGIN
+Filesystem       1B-blocks       Used   Available Use% Mounted on
+/dev/xvda1     85886742528 8500056064 77386686464  10% /data`,
+			expectedFree: 77386686464,
+			expectedUsed: 8500056064,
+		},
+		{
+			name:   "should use the configured marker",
+			marker: "MY_MARKER",
+			content: `KURL_DF_BEGIN
+Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sda2      63087357952 52521754624 7327760384  88% /data
+MY_MARKER
+Filesystem       1B-blocks       Used   Available Use% Mounted on
+/dev/xvda1     85886742528 8500056064 77386686464  10% /data`,
+			expectedFree: 77386686464,
+			expectedUsed: 8500056064,
+		},
+		{
+			name:   "should fail if only the default marker is present when another is configured",
+			marker: "MY_MARKER",
+			content: `KURL_DF_BEGIN
+Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sda2      63087357952 52521754624 7327760384  88% /data`,
+			err: "df output marker MY_MARKER not found",
+		},
+		{
+			name: "should fail if nothing follows the marker",
+			content: `Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sda2      63087357952 52521754624 7327760384  88% /data
+KURL_DF_BEGIN`,
+			err: "failed to locate free space info in pod log",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ochecker := OpenEBSFreeDiskSpaceGetter{
+				opts: OpenEBSOptions{
+					Output: OpenEBSOutputOptions{
+						DFMarker:    tt.marker,
+						StrictParse: tt.strict,
+					},
+				},
+				log: log.New(io.Discard, "", 0),
+			}
+			free, used, _, err := ochecker.parseFreeSpace([]byte(tt.content))
+			if err != nil {
+				if len(tt.err) == 0 {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expecting %q, %q received instead", tt.err, err)
+				}
+				return
+			}
+
+			if len(tt.err) > 0 {
+				t.Errorf("expecting error %q, nil received instead", tt.err)
+			}
+
+			if free != tt.expectedFree {
+				t.Errorf("expected free %v, received %v", tt.expectedFree, free)
+			}
+			if used != tt.expectedUsed {
+				t.Errorf("expected used %v, received %v", tt.expectedUsed, used)
+			}
+		})
+	}
+}
+
+func Test_dfCommandPrintsMarker(t *testing.T) {
+	ochecker := OpenEBSFreeDiskSpaceGetter{opts: OpenEBSOptions{Output: OpenEBSOutputOptions{DFMarker: "MY_MARKER"}}}
+	if command := ochecker.measureCommand(); !strings.Contains(command, "echo MY_MARKER; df -B1 /data") {
+		t.Errorf("expected df command to print the marker, received %q", command)
+	}
+}
+
+func TestNewOpenEBSFreeDiskSpaceGetterDFMarker(t *testing.T) {
+	for _, tt := range []struct {
+		marker string
+		err    bool
+	}{
+		{marker: ""},
+		{marker: "MY-MARKER_1.0:begin"},
+		{marker: "MY MARKER", err: true},
+		{marker: "$(reboot)", err: true},
+		{marker: "MARKER\n", err: true},
+	} {
+		_, err := NewOpenEBSFreeDiskSpaceGetterWithOptions(fake.NewSimpleClientset(), OpenEBSOptions{
+			Log:   log.New(io.Discard, "", 0),
+			Image: "myimage:latest",
+			DstSC: "openebs",
+			Output: OpenEBSOutputOptions{
+				DFMarker: tt.marker,
+			},
+		})
+		if tt.err && err == nil {
+			t.Errorf("expected marker %q to be rejected", tt.marker)
+		} else if !tt.err && err != nil {
+			t.Errorf("unexpected error for marker %q: %s", tt.marker, err)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package clusterspace
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DefaultDFMountPath is where the openebs base path is mounted inside the df container.
+const DefaultDFMountPath = "/data"
+
+// dfMountPathRegexp restricts the df mount paths to absolute paths that are safe to be used, as
+// is, in the df container scripts.
+var dfMountPathRegexp = regexp.MustCompile(`^(/[A-Za-z0-9_.-]+)+$`)
+
+// dfReservedMountPaths are the paths where other volumes are mounted in the df container, see
+// buildJob. the base path can't be mounted on them or under them.
+var dfReservedMountPaths = []string{"/tmpmount", "/host", "/node"}
+
+// isValidDFMountPath returns true if the provided path can be used to mount the base path in the
+// df container: a clean absolute path not colliding with the other volumes mounted in it.
+func isValidDFMountPath(path string) bool {
+	if !dfMountPathRegexp.MatchString(path) {
+		return false
+	}
+	for _, elem := range strings.Split(path, "/")[1:] {
+		if elem == "." || elem == ".." {
+			return false
+		}
+	}
+	for _, reserved := range dfReservedMountPaths {
+		if pathHasPrefix(path, reserved) || pathHasPrefix(reserved, path) {
+			return false
+		}
+	}
+	return true
+}
+
+// dfMountPath returns where the base path is mounted inside the df container, both the job spec
+// and the parsers of the df container output rely on it.
+func (o *OpenEBSFreeDiskSpaceGetter) dfMountPath() string {
+	if o.opts.Output.DFMountPath == "" {
+		return DefaultDFMountPath
+	}
+	return o.opts.Output.DFMountPath
+}
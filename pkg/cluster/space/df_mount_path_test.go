@@ -0,0 +1,188 @@
+package clusterspace
+
+import (
+	"context"
+	"io"
+	"log"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_parseFreeSpaceDFMountPath(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		mountPath    string
+		strict       bool
+		statfs       bool
+		content      string
+		err          string
+		expectedFree int64
+		expectedUsed int64
+	}{
+		{
+			name:      "should parse the output for a custom mount path",
+			mountPath: "/openebs/local",
+			content: `KURL_DF_BEGIN
+Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sda2      63087357952 52521754624 7327760384  88% /openebs/local`,
+			expectedFree: 7327760384,
+			expectedUsed: 52521754624,
+		},
+		{
+			name:      "should pass strict parsing for a custom mount path",
+			mountPath: "/openebs/local",
+			strict:    true,
+			content: `KURL_DF_BEGIN
+Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sda2      63087357952 52521754624 7327760384  88% /openebs/local`,
+			expectedFree: 7327760384,
+			expectedUsed: 52521754624,
+		},
+		{
+			name:      "should not match the default mount path when a custom one is configured",
+			mountPath: "/openebs/local",
+			content: `KURL_DF_BEGIN
+Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sda2      63087357952 52521754624 7327760384  88% /data`,
+			err: "failed to locate free space info in pod log",
+		},
+		{
+			name:      "should fail strict parsing with the default mount path when a custom one is configured",
+			mountPath: "/openebs/local",
+			strict:    true,
+			content: `KURL_DF_BEGIN
+Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sda2      63087357952 52521754624 7327760384  88% /data`,
+			err: `unexpected mount point "/data" in df output`,
+		},
+		{
+			name: "should default to /data",
+			content: `KURL_DF_BEGIN
+Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sda2      63087357952 52521754624 7327760384  88% /data`,
+			expectedFree: 7327760384,
+			expectedUsed: 52521754624,
+		},
+		{
+			name:         "should parse the statfs output for a custom mount path",
+			mountPath:    "/openebs/local",
+			statfs:       true,
+			content:      "KURL_STATFS v1 1000 400 300 /openebs/local\n",
+			expectedFree: 300,
+			expectedUsed: 600,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ochecker := OpenEBSFreeDiskSpaceGetter{
+				opts: OpenEBSOptions{
+					Output: OpenEBSOutputOptions{
+						DFMountPath: tt.mountPath,
+						StrictParse: tt.strict,
+					},
+				},
+				log: log.New(io.Discard, "", 0),
+			}
+			if tt.statfs {
+				ochecker.opts.Output.StatfsBinary = "/usr/local/bin/statfs"
+			}
+
+			free, used, _, err := ochecker.parseFreeSpace([]byte(tt.content))
+			if err != nil {
+				if len(tt.err) == 0 {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expecting %q, %q received instead", tt.err, err)
+				}
+				return
+			}
+
+			if len(tt.err) > 0 {
+				t.Errorf("expecting error %q, nil received instead", tt.err)
+			}
+
+			if free != tt.expectedFree {
+				t.Errorf("expected free %v, received %v", tt.expectedFree, free)
+			}
+			if used != tt.expectedUsed {
+				t.Errorf("expected used %v, received %v", tt.expectedUsed, used)
+			}
+		})
+	}
+}
+
+func Test_buildJobDFMountPath(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		mountPath string
+		expected  string
+	}{
+		{
+			name:     "should mount the base path under /data by default",
+			expected: "/data",
+		},
+		{
+			name:      "should mount the base path under the configured path",
+			mountPath: "/openebs/local",
+			expected:  "/openebs/local",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ochecker := OpenEBSFreeDiskSpaceGetter{opts: OpenEBSOptions{Image: "myimage:latest", Namespace: "default", Output: OpenEBSOutputOptions{DFMountPath: tt.mountPath}}}
+			job := ochecker.buildJob(context.Background(), "node0", "/var/local", "tmppvc")
+			container := job.Spec.Template.Spec.Containers[0]
+
+			var mountPath string
+			for _, mount := range container.VolumeMounts {
+				if mount.Name == "openebs" {
+					mountPath = mount.MountPath
+				}
+			}
+			if mountPath != tt.expected {
+				t.Errorf("expected base path mounted under %s, %s received instead", tt.expected, mountPath)
+			}
+
+			for _, expected := range []string{"stat " + tt.expected, "df -B1 " + tt.expected} {
+				if !strings.Contains(container.Args[0], expected) {
+					t.Errorf("expected %q in df container command: %s", expected, container.Args[0])
+				}
+			}
+		})
+	}
+}
+
+func TestNewOpenEBSFreeDiskSpaceGetterDFMountPath(t *testing.T) {
+	for _, tt := range []struct {
+		path string
+		err  bool
+	}{
+		{path: ""},
+		{path: "/data"},
+		{path: "/var/openebs-local/v1.0_data"},
+		{path: "data", err: true},
+		{path: "/", err: true},
+		{path: "/data/", err: true},
+		{path: "/data//local", err: true},
+		{path: "/data/../host", err: true},
+		{path: "/my data", err: true},
+		{path: "/$(reboot)", err: true},
+		{path: "/tmpmount", err: true},
+		{path: "/host/data", err: true},
+		{path: "/node", err: true},
+	} {
+		_, err := NewOpenEBSFreeDiskSpaceGetterWithOptions(fake.NewSimpleClientset(), OpenEBSOptions{
+			Log:   log.New(io.Discard, "", 0),
+			Image: "myimage:latest",
+			DstSC: "openebs",
+			Output: OpenEBSOutputOptions{
+				DFMountPath: tt.path,
+			},
+		})
+		if tt.err && err == nil {
+			t.Errorf("expected mount path %q to be rejected", tt.path)
+		} else if !tt.err && err != nil {
+			t.Errorf("unexpected error for mount path %q: %s", tt.path, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package clusterspace
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+const (
+	// encodedOutputBegin is printed by the job containers before their base64 encoded output.
+	encodedOutputBegin = "---BEGIN---"
+	// encodedOutputEnd is printed by the job containers after their base64 encoded output.
+	encodedOutputEnd = "---END---"
+)
+
+// encodeOutputCommand wraps the provided shell script so its output (stdout and stderr) is printed
+// base64 encoded between the encodedOutputBegin and encodedOutputEnd markers. this protects the
+// output from log processors that may rewrite or truncate the container logs. every output line is
+// encoded in its own log line as soon as it is printed, so the output of a hung script can still be
+// followed (see copyFollowedLogs). the script exit code is preserved.
+func encodeOutputCommand(script string) string {
+	return fmt.Sprintf(
+		`echo %q; exec 4>&1; rc=$({ { (%s) 2>&1; echo $? >&3; } | `+
+			`while IFS= read -r line || [ -n "$line" ]; do printf '%%s\n' "$line" | base64 | tr -d '\n'; echo; done >&4; } 3>&1); `+
+			`echo %q; exit $rc`,
+		encodedOutputBegin, script, encodedOutputEnd,
+	)
+}
+
+// decodeOutput extracts and decodes the base64 block printed by a command wrapped with
+// encodeOutputCommand. lines outside the markers are ignored. output without the begin marker
+// is returned as is so plain text logs are still supported.
+func decodeOutput(output []byte) ([]byte, error) {
+	var decoded []byte
+	var begin, end bool
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !begin {
+			begin = line == encodedOutputBegin
+			continue
+		}
+
+		if line == encodedOutputEnd {
+			end = true
+			break
+		}
+
+		chunk, err := decodeOutputLine(line)
+		if err != nil {
+			return nil, err
+		}
+		decoded = append(decoded, chunk...)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to process container log: %w", err)
+	}
+
+	if !begin {
+		return output, nil
+	}
+
+	if !end {
+		return nil, fmt.Errorf("encoded output truncated, %s marker not found", encodedOutputEnd)
+	}
+	return decoded, nil
+}
+
+// decodeOutputLine decodes a single line of a block printed by encodeOutputCommand. lines are
+// decoded on their own as each one holds a complete base64 string, blocks wrapped at 76 columns by
+// the base64 command are supported as well as every wrapped line holds a multiple of 3 bytes.
+func decodeOutputLine(line string) ([]byte, error) {
+	decoded, err := base64.StdEncoding.DecodeString(line)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode container output: %w", err)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,83 @@
+package clusterspace
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_decodeOutput(t *testing.T) {
+	plain := `Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sda2      63087357952 52521754624 7327760384  88% /data
+`
+
+	// plain, base64 encoded and wrapped at 76 columns as done by the base64 command.
+	encoded := `RmlsZXN5c3RlbSAgICAgICAxQi1ibG9ja3MgICAgICAgIFVzZWQgIEF2YWlsYWJsZSBVc2UlIE1v
+dW50ZWQgb24KL2Rldi9zZGEyICAgICAgNjMwODczNTc5NTIgNTI1MjE3NTQ2MjQgNzMyNzc2MDM4
+NCAgODglIC9kYXRhCg==`
+
+	for _, tt := range []struct {
+		name     string
+		content  string
+		expected string
+		err      string
+	}{
+		{
+			name:     "should decode output wrapped in multiple lines",
+			content:  "---BEGIN---\n" + encoded + "\n---END---\n",
+			expected: plain,
+		},
+		{
+			name:     "should decode output encoded line by line",
+			content:  "---BEGIN---\nRmlsZXN5c3RlbSAgICAgICAxQi1ibG9ja3MgICAgICAgIFVzZWQgIEF2YWlsYWJsZSBVc2UlIE1vdW50ZWQgb24K\nL2Rldi9zZGEyICAgICAgNjMwODczNTc5NTIgNTI1MjE3NTQ2MjQgNzMyNzc2MDM4NCAgODglIC9kYXRhCg==\n---END---\n",
+			expected: plain,
+		},
+		{
+			name:     "should ignore lines around the markers",
+			content:  "some log processor noise\n---BEGIN---\n" + encoded + "\n---END---\nmore noise\n",
+			expected: plain,
+		},
+		{
+			name:     "should return plain text output as is",
+			content:  plain,
+			expected: plain,
+		},
+		{
+			name:    "should fail on truncated output",
+			content: "---BEGIN---\n" + encoded[:40] + "\n",
+			err:     "encoded output truncated",
+		},
+		{
+			name:    "should fail on corrupted output",
+			content: "---BEGIN---\n!!not base64!!\n---END---\n",
+			err:     "failed to decode container output",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := decodeOutput([]byte(tt.content))
+			if err != nil {
+				if len(tt.err) == 0 {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expecting %q, %q received instead", tt.err, err)
+				}
+				return
+			}
+
+			if len(tt.err) > 0 {
+				t.Errorf("expecting error %q, nil received instead", tt.err)
+			}
+			if string(output) != tt.expected {
+				t.Errorf("expecting %q, %q received instead", tt.expected, string(output))
+			}
+		})
+	}
+}
+
+func Test_encodeOutputCommand(t *testing.T) {
+	command := encodeOutputCommand(dfCommand(DefaultDFMountPath, DefaultDFMarker))
+	for _, expected := range []string{dfCommand(DefaultDFMountPath, DefaultDFMarker), encodedOutputBegin, encodedOutputEnd, "base64", "exit $rc"} {
+		if !strings.Contains(command, expected) {
+			t.Errorf("expecting %q in command %q", expected, command)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package clusterspace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path"
+	"strconv"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// DefaultEtcdDataDir is the etcd data directory in the control plane nodes.
+	DefaultEtcdDataDir = "/var/lib/etcd"
+	// DefaultEtcdFsyncThreshold is the 99th percentile fsync latency recommended by etcd.
+	DefaultEtcdFsyncThreshold = 10 * time.Millisecond
+	// etcdBenchDir is where the etcd data directory is mounted inside the benchmark container.
+	etcdBenchDir = "/etcd"
+	// etcdBenchScratch is the directory, inside the etcd data directory, the benchmark writes to. it
+	// is removed once the benchmark finishes.
+	etcdBenchScratch = ".kurl-fsync-benchmark"
+)
+
+// etcdControlPlaneLabels are the labels kubeadm sets on the nodes running etcd.
+var etcdControlPlaneLabels = []string{
+	"node-role.kubernetes.io/control-plane",
+	"node-role.kubernetes.io/master",
+}
+
+// etcdLatencyCommand writes to the etcd data directory the way etcd writes its wal, calling
+// fdatasync after each write, and prints the fio report as json. the parameters are the ones
+// recommended by etcd and used by the host filesystem performance preflight.
+var etcdLatencyCommand = fmt.Sprintf(
+	`mkdir -p %[1]s && fio --name=etcd-fsync --directory=%[1]s --rw=write --ioengine=sync --fdatasync=1 --size=22m --bs=2300 --output-format=json; rc=$?; rm -rf %[1]s; exit $rc`,
+	path.Join(etcdBenchDir, etcdBenchScratch),
+)
+
+// EtcdLatency holds the fsync latency measured in a node etcd data directory. P99 is the 99th
+// percentile and Max the slowest fsync, Syncs is the number of fsync calls measured.
+type EtcdLatency struct {
+	P99   time.Duration `json:"p99"`
+	Max   time.Duration `json:"max"`
+	Syncs int64         `json:"syncs"`
+}
+
+// Check returns an error if the 99th percentile fsync latency exceeds the provided threshold.
+func (l EtcdLatency) Check(threshold time.Duration) error {
+	if l.P99 > threshold {
+		return fmt.Errorf("99th percentile fsync latency %s exceeds %s", l.P99, threshold)
+	}
+	return nil
+}
+
+// fioReport is the part of the fio json report the etcd latency is read from.
+type fioReport struct {
+	Jobs []struct {
+		JobName string `json:"jobname"`
+		Error   int    `json:"error"`
+		Sync    struct {
+			TotalIOs int64 `json:"total_ios"`
+			LatNS    struct {
+				Max        float64            `json:"max"`
+				Percentile map[string]float64 `json:"percentile"`
+			} `json:"lat_ns"`
+		} `json:"sync"`
+	} `json:"jobs"`
+}
+
+// parseEtcdLatencyOutput parses the output of the etcdLatencyCommand. fio may print notes and
+// warnings before the json report, everything before the first line starting with '{' is ignored.
+// the fsync latencies are reported by fio in nanoseconds with the percentiles indexed by strings
+// like "99.000000".
+func parseEtcdLatencyOutput(output []byte) (EtcdLatency, error) {
+	start := bytes.Index(output, []byte("\n{"))
+	switch {
+	case bytes.HasPrefix(output, []byte("{")):
+		start = 0
+	case start == -1:
+		return EtcdLatency{}, fmt.Errorf("failed to locate fio report in pod log: %s", string(output))
+	default:
+		start++
+	}
+
+	var report fioReport
+	if err := json.NewDecoder(bytes.NewReader(output[start:])).Decode(&report); err != nil {
+		return EtcdLatency{}, fmt.Errorf("failed to decode fio report: %w", err)
+	}
+
+	if len(report.Jobs) == 0 {
+		return EtcdLatency{}, fmt.Errorf("no job found in fio report")
+	}
+
+	job := report.Jobs[0]
+	if job.Error != 0 {
+		return EtcdLatency{}, fmt.Errorf("fio job %s failed with error %d", job.JobName, job.Error)
+	}
+	if job.Sync.TotalIOs == 0 {
+		return EtcdLatency{}, fmt.Errorf("no fsync measured by fio job %s", job.JobName)
+	}
+
+	var p99 float64
+	var found bool
+	for key, value := range job.Sync.LatNS.Percentile {
+		percentile, err := strconv.ParseFloat(key, 64)
+		if err != nil {
+			continue
+		}
+		if math.Abs(percentile-99) < 1e-6 {
+			p99, found = value, true
+			break
+		}
+	}
+	if !found {
+		return EtcdLatency{}, fmt.Errorf("99th percentile fsync latency not found in fio report")
+	}
+
+	return EtcdLatency{
+		P99:   time.Duration(p99),
+		Max:   time.Duration(job.Sync.LatNS.Max),
+		Syncs: job.Sync.TotalIOs,
+	}, nil
+}
+
+// isEtcdNode returns true if the node is a control plane node, thus running etcd.
+func isEtcdNode(node corev1.Node) bool {
+	for _, label := range etcdControlPlaneLabels {
+		if _, ok := node.Labels[label]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// buildEtcdLatencyJob returns a job that benchmarks the fsync latency of the provided etcd data
+// directory in the node. the image must provide fio.
+func (o *OpenEBSFreeDiskSpaceGetter) buildEtcdLatencyJob(node, image, dataDir string) *batchv1.Job {
+	typeDir := corev1.HostPathDirectory
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        jobName(node),
+			Namespace:   o.opts.Namespace,
+			Labels:      o.jobLabels(node),
+			Annotations: o.jobAnnotations(),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          ptr.To(int32(1)),
+			ActiveDeadlineSeconds: activeDeadlineSeconds(o.opts.Job.Timeout),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      o.jobLabels(node),
+					Annotations: o.jobAnnotations(),
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:    corev1.RestartPolicyNever,
+					Tolerations:      o.tolerations,
+					Affinity:         nodeAffinity(node),
+					ImagePullSecrets: o.imagePullSecrets(),
+					Volumes: []corev1.Volume{
+						{
+							Name: "etcd",
+							VolumeSource: corev1.VolumeSource{
+								HostPath: &corev1.HostPathVolumeSource{
+									Type: &typeDir,
+									Path: dataDir,
+								},
+							},
+						},
+					},
+					Containers: []corev1.Container{
+						{
+							Name:    "fio",
+							Image:   image,
+							Command: []string{"/bin/sh", "-c"},
+							Args:    []string{encodeOutputCommand(etcdLatencyCommand)},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									MountPath: etcdBenchDir,
+									Name:      "etcd",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// nodeEtcdLatency runs an etcd latency job in the provided node and returns the measured latency.
+func (o *OpenEBSFreeDiskSpaceGetter) nodeEtcdLatency(ctx context.Context, node, image, dataDir string) (EtcdLatency, error) {
+	job := o.buildEtcdLatencyJob(node, image, dataDir)
+	out, status, err := o.runJob(ctx, job)
+	if err != nil {
+		o.logContainersState(out, status)
+		return EtcdLatency{}, fmt.Errorf(
+			"failed to run job %s/%s on node %s: %w", job.Namespace, job.Name, node, err,
+		)
+	}
+
+	fioOutput, err := decodeOutput(out["fio"])
+	if err != nil {
+		o.logContainersState(out, status)
+		return EtcdLatency{}, fmt.Errorf("failed to read node %s fio output: %w", node, err)
+	}
+
+	latency, err := parseEtcdLatencyOutput(fioOutput)
+	if err != nil {
+		o.logContainersState(out, status)
+		return EtcdLatency{}, fmt.Errorf("failed to parse node %s fio output: %w", node, err)
+	}
+	return latency, nil
+}
+
+// NodesEtcdLatency benchmarks the fsync latency of the etcd data directory in every control plane
+// node by running a fio job in each of them. image must provide fio. returns the latency of each
+// node, indexed by node name. worker nodes do not run etcd and are ignored, control plane nodes
+// that can't run linux jobs are available through SkippedNodes.
+func (r *NodeJobRunner) NodesEtcdLatency(ctx context.Context, image, dataDir string) (map[string]EtcdLatency, error) {
+	if !path.IsAbs(dataDir) {
+		return nil, fmt.Errorf("etcd data directory %q is not absolute", dataDir)
+	}
+
+	nodes, err := r.jobs.listNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var etcdNodes []corev1.Node
+	for _, node := range nodes.Items {
+		if isEtcdNode(node) {
+			etcdNodes = append(etcdNodes, node)
+		}
+	}
+
+	return runNodeJobs(ctx, r, etcdNodes, func(ctx context.Context, node string) (EtcdLatency, error) {
+		r.jobs.log.Printf("Benchmarking etcd fsync latency on node %s", node)
+		return r.jobs.nodeEtcdLatency(ctx, node, image, dataDir)
+	})
+}
@@ -0,0 +1,134 @@
+package clusterspace
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_parseEtcdLatencyOutput(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		content  string
+		expected EtcdLatency
+		err      string
+	}{
+		{
+			name: "should read the 99th percentile fsync latency",
+			content: `{
+  "fio version" : "fio-3.28",
+  "jobs" : [
+    {
+      "jobname" : "etcd-fsync",
+      "error" : 0,
+      "sync" : {
+        "total_ios" : 10029,
+        "lat_ns" : {
+          "min" : 331000,
+          "max" : 15925000,
+          "mean" : 1276040.215,
+          "percentile" : {
+            "90.000000" : 2146304,
+            "95.000000" : 2670592,
+            "99.000000" : 4751360,
+            "99.500000" : 5603328
+          }
+        }
+      }
+    }
+  ]
+}
+`,
+			expected: EtcdLatency{P99: 4751360, Max: 15925000, Syncs: 10029},
+		},
+		{
+			name: "should ignore the notes printed before the report",
+			content: `note: both iodepth >= 1 and synchronous I/O engine are selected, queue depth will be capped at 1
+{"jobs":[{"jobname":"etcd-fsync","error":0,"sync":{"total_ios":100,"lat_ns":{"max":30000000,"percentile":{"99.000000":12058624}}}}]}
+`,
+			expected: EtcdLatency{P99: 12058624, Max: 30000000, Syncs: 100},
+		},
+		{
+			name:    "should fail without a report",
+			content: "sh: fio: not found\n",
+			err:     "failed to locate fio report in pod log",
+		},
+		{
+			name:    "should fail on truncated reports",
+			content: `{"jobs":[{"jobname":"etcd-fsync",`,
+			err:     "failed to decode fio report",
+		},
+		{
+			name:    "should fail on failed jobs",
+			content: `{"jobs":[{"jobname":"etcd-fsync","error":28}]}`,
+			err:     "fio job etcd-fsync failed with error 28",
+		},
+		{
+			name:    "should fail if no fsync has been measured",
+			content: `{"jobs":[{"jobname":"etcd-fsync","error":0,"sync":{"total_ios":0}}]}`,
+			err:     "no fsync measured by fio job etcd-fsync",
+		},
+		{
+			name:    "should fail without the 99th percentile",
+			content: `{"jobs":[{"jobname":"etcd-fsync","error":0,"sync":{"total_ios":10,"lat_ns":{"percentile":{"99.900000":100}}}}]}`,
+			err:     "99th percentile fsync latency not found",
+		},
+		{
+			name:    "should fail without jobs",
+			content: `{"fio version":"fio-3.28","jobs":[]}`,
+			err:     "no job found in fio report",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			latency, err := parseEtcdLatencyOutput([]byte(tt.content))
+			if err != nil {
+				if len(tt.err) == 0 {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expecting %q, %q received instead", tt.err, err)
+				}
+				return
+			}
+
+			if len(tt.err) > 0 {
+				t.Errorf("expecting error %q, nil received instead", tt.err)
+			}
+			if diff := cmp.Diff(tt.expected, latency); diff != "" {
+				t.Errorf("unexpected latency: %s", diff)
+			}
+		})
+	}
+}
+
+func TestEtcdLatencyCheck(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		p99       time.Duration
+		threshold time.Duration
+		err       string
+	}{
+		{name: "should accept latencies below the threshold", p99: 2 * time.Millisecond, threshold: DefaultEtcdFsyncThreshold},
+		{name: "should accept latencies matching the threshold", p99: 10 * time.Millisecond, threshold: DefaultEtcdFsyncThreshold},
+		{
+			name:      "should reject latencies above the threshold",
+			p99:       12 * time.Millisecond,
+			threshold: DefaultEtcdFsyncThreshold,
+			err:       "99th percentile fsync latency 12ms exceeds 10ms",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := EtcdLatency{P99: tt.p99}.Check(tt.threshold)
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("expected error %q, received %v", tt.err, err)
+			}
+		})
+	}
+}
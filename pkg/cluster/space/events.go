@@ -0,0 +1,128 @@
+package clusterspace
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/bytefmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// EventComponent is the source component of the events recorded by the recorder returned by
+// NewEventRecorder.
+const EventComponent = "kurl-space-check"
+
+// reasons of the events recorded during the space checks.
+const (
+	// EventReasonNodeCheckStarted is recorded when a node starts being measured.
+	EventReasonNodeCheckStarted = "SpaceCheckStarted"
+	// EventReasonNodeCheckFinished is recorded with the free bytes once a node has been measured.
+	EventReasonNodeCheckFinished = "SpaceCheckFinished"
+	// EventReasonNodeCheckFailed is recorded, as a warning, when a node fails to be measured.
+	EventReasonNodeCheckFailed = "SpaceCheckFailed"
+	// EventReasonEnoughSpace is recorded by the disk space validator when all nodes have enough
+	// space for the migration.
+	EventReasonEnoughSpace = "EnoughSpace"
+	// EventReasonNotEnoughSpace is recorded, as a warning, by the disk space validator when some
+	// nodes lack space for the migration.
+	EventReasonNotEnoughSpace = "NotEnoughSpace"
+)
+
+// NewEventRecorder returns an event recorder writing to the events api through the provided
+// client, to be used as OpenEBSOptions.Recorder. events are delivered in the background, the
+// returned function must be called once done to flush them.
+func NewEventRecorder(kcli kubernetes.Interface) (record.EventRecorder, func()) {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kcli.CoreV1().Events("")})
+	recorder := broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: EventComponent})
+	return recorder, broadcaster.Shutdown
+}
+
+// nodeEventRef returns the reference the events about the provided node are attached to. the
+// events are recorded in the namespace where the jobs run so they can be listed along with them.
+func (o *OpenEBSFreeDiskSpaceGetter) nodeEventRef(node corev1.Node) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		APIVersion: "v1",
+		Kind:       "Node",
+		Name:       node.Name,
+		UID:        node.UID,
+		Namespace:  o.opts.Namespace,
+	}
+}
+
+// nodeCheckStarted records the event telling the provided node is being measured and returns the
+// time the measurement started, to be passed on to nodeCheckFinished. nothing is recorded if no
+// recorder has been configured.
+func (o *OpenEBSFreeDiskSpaceGetter) nodeCheckStarted(node corev1.Node) time.Time {
+	started := time.Now()
+	if o.opts.Recorder == nil {
+		return started
+	}
+	o.opts.Recorder.Eventf(
+		o.nodeEventRef(node), corev1.EventTypeNormal, EventReasonNodeCheckStarted,
+		"Measuring the free space of node %s in the %s storage class (run %s)", node.Name, o.opts.DstSC, o.runID,
+	)
+	return started
+}
+
+// nodeCheckFinished records the outcome of the measurement of the provided node: its free bytes or
+// the error that prevented it from being measured. the metrics, if configured, are updated as well.
+func (o *OpenEBSFreeDiskSpaceGetter) nodeCheckFinished(node corev1.Node, started time.Time, vol OpenEBSVolume, err error) {
+	o.metrics.observeNode(o.opts.DstSC, node.Name, time.Since(started), vol, err)
+	if o.opts.Recorder == nil {
+		return
+	}
+	if err != nil {
+		o.opts.Recorder.Eventf(
+			o.nodeEventRef(node), corev1.EventTypeWarning, EventReasonNodeCheckFailed,
+			"Failed to measure the free space of node %s (run %s): %s", node.Name, o.runID, err,
+		)
+		return
+	}
+	o.opts.Recorder.Eventf(
+		o.nodeEventRef(node), corev1.EventTypeNormal, EventReasonNodeCheckFinished,
+		"Node %s has %s (%d bytes) free in the %s storage class (run %s)",
+		node.Name, bytefmt.ByteSize(uint64(max(vol.Free, 0))), vol.Free, o.opts.DstSC, o.runID,
+	)
+}
+
+// recordVerdict records the event with the validator verdict, attached to the destination storage
+// class, naming the nodes without enough space and their effective free bytes.
+func (o *OpenEBSDiskSpaceValidator) recordVerdict(results []NodeSpaceResult) {
+	getter := o.freeSpaceGetter
+	if getter.opts.Recorder == nil {
+		return
+	}
+
+	ref := &corev1.ObjectReference{
+		APIVersion: "storage.k8s.io/v1",
+		Kind:       "StorageClass",
+		Name:       getter.opts.DstSC,
+		Namespace:  getter.opts.Namespace,
+	}
+
+	var without []string
+	for _, result := range results {
+		if result.Status == NodeSpaceFail {
+			without = append(without, fmt.Sprintf("%s (%d bytes free)", result.NodeName, result.FreeBytes))
+		}
+	}
+
+	if len(without) == 0 {
+		getter.opts.Recorder.Eventf(
+			ref, corev1.EventTypeNormal, EventReasonEnoughSpace,
+			"All nodes have enough space to migrate the %s storage class volumes (run %s)", o.srcSC, getter.runID,
+		)
+		return
+	}
+	getter.opts.Recorder.Eventf(
+		ref, corev1.EventTypeWarning, EventReasonNotEnoughSpace,
+		"Nodes without enough space to migrate the %s storage class volumes (run %s): %s",
+		o.srcSC, getter.runID, strings.Join(without, ", "),
+	)
+}
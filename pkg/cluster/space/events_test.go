@@ -0,0 +1,146 @@
+package clusterspace
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+// drainEvents returns, sorted, all the events recorded so far by the fake recorder.
+func drainEvents(recorder *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			sort.Strings(events)
+			return events
+		}
+	}
+}
+
+func TestNodesSpaceEvents(t *testing.T) {
+	kcli := fake.NewSimpleClientset(
+		kubeSystemNamespace(),
+		&storagev1.StorageClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "openebs",
+				Annotations: map[string]string{
+					"cas.openebs.io/config": "- name: BasePath\n  value: /var/local",
+				},
+			},
+			Provisioner: OpenEBSLocalProvisioner,
+		},
+		&storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: "longhorn"},
+			Provisioner: "driver.longhorn.io",
+		},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node0"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+	)
+
+	// the measurements are served from the cache so no job is executed.
+	cache, err := NewOpenEBSVolumeCache(filepath.Join(t.TempDir(), "cache.json"), time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error creating cache: %s", err)
+	}
+	for node, vol := range map[string]OpenEBSVolume{
+		"node0": {Free: 1000, Used: 1000},
+		"node1": {Free: 100, Used: 1900},
+	} {
+		if err := cache.Set(node, testCacheKey, vol); err != nil {
+			t.Fatalf("unexpected error caching volume: %s", err)
+		}
+	}
+
+	recorder := record.NewFakeRecorder(10)
+	ochecker := OpenEBSDiskSpaceValidator{
+		kcli: kcli,
+		log:  log.New(io.Discard, "", 0),
+		freeSpaceGetter: &OpenEBSFreeDiskSpaceGetter{
+			kcli: kcli,
+			opts: OpenEBSOptions{
+				DstSC: "openebs",
+				Cache: cache,
+				PVC: OpenEBSPVCOptions{
+					DeletePVTimeout: time.Minute,
+				},
+				Recorder: recorder,
+			},
+			runID: "abc",
+			log:   log.New(io.Discard, "", 0),
+		},
+		srcSC:    "longhorn",
+		reserved: 500,
+		reserve:  func(OpenEBSVolume) int64 { return 0 },
+	}
+
+	if _, err := ochecker.NodesSpace(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []string{
+		"Normal SpaceCheckFinished Node node0 has 1000B (1000 bytes) free in the openebs storage class (run abc)",
+		"Normal SpaceCheckFinished Node node1 has 100B (100 bytes) free in the openebs storage class (run abc)",
+		"Normal SpaceCheckStarted Measuring the free space of node node0 in the openebs storage class (run abc)",
+		"Normal SpaceCheckStarted Measuring the free space of node node1 in the openebs storage class (run abc)",
+		"Warning NotEnoughSpace Nodes without enough space to migrate the longhorn storage class volumes (run abc): node1 (100 bytes free)",
+	}
+	if diff := cmp.Diff(expected, drainEvents(recorder)); diff != "" {
+		t.Errorf("unexpected events: %s", diff)
+	}
+}
+
+func Test_nodeCheckFinished(t *testing.T) {
+	node := corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node0"}}
+
+	// no recorder, nothing is recorded nor panics.
+	getter := OpenEBSFreeDiskSpaceGetter{}
+	started := getter.nodeCheckStarted(node)
+	getter.nodeCheckFinished(node, started, OpenEBSVolume{}, nil)
+
+	recorder := record.NewFakeRecorder(10)
+	getter = OpenEBSFreeDiskSpaceGetter{opts: OpenEBSOptions{Recorder: recorder, DstSC: "openebs"}, runID: "abc"}
+	getter.nodeCheckFinished(node, started, OpenEBSVolume{}, fmt.Errorf("job failed"))
+	getter.nodeCheckFinished(node, started, OpenEBSVolume{Free: 2048}, nil)
+
+	expected := []string{
+		"Normal SpaceCheckFinished Node node0 has 2K (2048 bytes) free in the openebs storage class (run abc)",
+		"Warning SpaceCheckFailed Failed to measure the free space of node node0 (run abc): job failed",
+	}
+	if diff := cmp.Diff(expected, drainEvents(recorder)); diff != "" {
+		t.Errorf("unexpected events: %s", diff)
+	}
+}
+
+func Test_recordVerdict(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	ochecker := OpenEBSDiskSpaceValidator{
+		srcSC:           "longhorn",
+		freeSpaceGetter: &OpenEBSFreeDiskSpaceGetter{opts: OpenEBSOptions{Recorder: recorder, DstSC: "openebs"}, runID: "abc"},
+	}
+	ochecker.recordVerdict([]NodeSpaceResult{
+		{NodeName: "node0", Status: NodeSpaceOK},
+		{NodeName: "win0", Status: NodeSpaceSkipped},
+	})
+
+	expected := []string{
+		"Normal EnoughSpace All nodes have enough space to migrate the longhorn storage class volumes (run abc)",
+	}
+	if diff := cmp.Diff(expected, drainEvents(recorder)); diff != "" {
+		t.Errorf("unexpected events: %s", diff)
+	}
+}
@@ -0,0 +1,222 @@
+package clusterspace
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// followLogsPollInterval is how often we look for the job pod before its logs can be followed.
+var followLogsPollInterval = time.Second
+
+// copyFollowedLogs copies the log lines read from r into w, prefixing each one with the provided
+// prefix. lines of blocks encoded by encodeOutputCommand are printed decoded as they arrive, a
+// decoded line is held until its end is read (or until the block or r ends). writes to w are
+// serialized through mtx as multiple containers may be followed at the same time.
+func copyFollowedLogs(w io.Writer, mtx *sync.Mutex, prefix string, r io.Reader) {
+	emit := func(line string) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		fmt.Fprintf(w, "%s%s\n", prefix, line)
+	}
+
+	var inBlock bool
+	var pending []byte
+	flush := func() {
+		if len(pending) > 0 {
+			emit(string(pending))
+		}
+		pending = nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case !inBlock && trimmed == encodedOutputBegin:
+			inBlock = true
+		case inBlock && trimmed == encodedOutputEnd:
+			flush()
+			inBlock = false
+		case inBlock:
+			decoded, err := decodeOutputLine(trimmed)
+			if err != nil {
+				emit(fmt.Sprintf("failed to decode output: %s", err))
+				continue
+			}
+
+			pending = append(pending, decoded...)
+			for {
+				idx := bytes.IndexByte(pending, '\n')
+				if idx == -1 {
+					break
+				}
+				emit(string(pending[:idx]))
+				pending = pending[idx+1:]
+			}
+		default:
+			emit(line)
+		}
+	}
+	flush()
+}
+
+// jobPod waits until the pod running the provided job (or the bare pod created out of it when the
+// getter runs pods) has started and returns it.
+func (o *OpenEBSFreeDiskSpaceGetter) jobPod(ctx context.Context, job *batchv1.Job) (*corev1.Pod, error) {
+	selector := labels.SelectorFromSet(map[string]string{"job-name": job.Name}).String()
+	for {
+		var pods []corev1.Pod
+		if o.opts.Job.RunAsPod {
+			pod, err := o.kcli.CoreV1().Pods(job.Namespace).Get(ctx, job.Name, metav1.GetOptions{})
+			if err == nil {
+				pods = append(pods, *pod)
+			}
+		} else {
+			list, err := o.kcli.CoreV1().Pods(job.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+			if err == nil {
+				pods = list.Items
+			}
+		}
+
+		for _, pod := range pods {
+			if pod.Status.Phase != corev1.PodPending && pod.Status.Phase != "" {
+				return &pod, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(followLogsPollInterval):
+		}
+	}
+}
+
+// streamedLogs buffers the logs streamed out of the containers of a job pod, indexed by container
+// name. it is safe for concurrent use.
+type streamedLogs struct {
+	mtx  sync.Mutex
+	logs map[string]*bytes.Buffer
+}
+
+// streamedLogsWriter appends everything written to it to the logs of a container.
+type streamedLogsWriter struct {
+	logs      *streamedLogs
+	container string
+}
+
+// Write appends p to the container buffered logs.
+func (w streamedLogsWriter) Write(p []byte) (int, error) {
+	w.logs.mtx.Lock()
+	defer w.logs.mtx.Unlock()
+	buf, ok := w.logs.logs[w.container]
+	if !ok {
+		buf = &bytes.Buffer{}
+		w.logs.logs[w.container] = buf
+	}
+	return buf.Write(p)
+}
+
+// writer returns a writer appending to the buffered logs of the provided container.
+func (l *streamedLogs) writer(container string) io.Writer {
+	return streamedLogsWriter{logs: l, container: container}
+}
+
+// snapshot returns a copy of the logs buffered so far, containers that printed nothing are left out.
+func (l *streamedLogs) snapshot() map[string][]byte {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	result := map[string][]byte{}
+	for container, buf := range l.logs {
+		if buf.Len() == 0 {
+			continue
+		}
+		result[container] = append([]byte(nil), buf.Bytes()...)
+	}
+	return result
+}
+
+// streamJobLogs streams, as they are produced, the logs of all the containers of the pod running the
+// provided job and buffers them. this way, if the job hangs (e.g. df stalled on an nfs backed base
+// path), whatever the containers printed before stalling can still be reported. if a writer has been
+// configured through the FollowLogs option the logs are copied into it as well, each line prefixed
+// with the job name and the container name. the returned function stops the streaming, must be
+// called once the job has finished and returns the logs buffered so far indexed by container name.
+func (o *OpenEBSFreeDiskSpaceGetter) streamJobLogs(ctx context.Context, job *batchv1.Job) func() map[string][]byte {
+	ctx, cancel := context.WithCancel(ctx)
+	logs := &streamedLogs{logs: map[string]*bytes.Buffer{}}
+	var wg sync.WaitGroup
+	var mtx sync.Mutex
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		pod, err := o.jobPod(ctx, job)
+		if err != nil {
+			return
+		}
+
+		for _, container := range pod.Spec.Containers {
+			wg.Add(1)
+			go func(container string) {
+				defer wg.Done()
+				options := &corev1.PodLogOptions{Container: container, Follow: true}
+				stream, err := o.kcli.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, options).Stream(ctx)
+				if err != nil {
+					if ctx.Err() == nil {
+						o.log.Printf("Failed to follow container %s logs: %s", container, err)
+					}
+					return
+				}
+				defer stream.Close()
+
+				r := io.TeeReader(stream, logs.writer(container))
+				if o.opts.Job.FollowLogs == nil {
+					_, _ = io.Copy(io.Discard, r)
+					return
+				}
+				copyFollowedLogs(o.opts.Job.FollowLogs, &mtx, fmt.Sprintf("[%s/%s] ", job.Name, container), r)
+			}(container.Name)
+		}
+	}()
+
+	return func() map[string][]byte {
+		cancel()
+		wg.Wait()
+		return logs.snapshot()
+	}
+}
+
+// partialOutputError returns err along with the logs the job containers printed before it failed,
+// sorted by container name. the lines of blocks encoded by encodeOutputCommand are included even if
+// the block is incomplete. err is returned as is if no container printed anything.
+func partialOutputError(err error, job string, logs map[string][]byte) error {
+	var containers []string
+	for container := range logs {
+		containers = append(containers, container)
+	}
+	sort.Strings(containers)
+
+	var out bytes.Buffer
+	var mtx sync.Mutex
+	for _, container := range containers {
+		copyFollowedLogs(&out, &mtx, fmt.Sprintf("[%s/%s] ", job, container), bytes.NewReader(logs[container]))
+	}
+
+	if out.Len() == 0 {
+		return err
+	}
+	return fmt.Errorf("%w, output before failing:\n%s", err, strings.TrimRight(out.String(), "\n"))
+}
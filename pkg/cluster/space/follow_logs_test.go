@@ -0,0 +1,174 @@
+package clusterspace
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mtx sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.String()
+}
+
+func Test_copyFollowedLogs(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte("Filesystem 1B-blocks\n/dev/sda1 100\n"))
+	input := strings.Join([]string{
+		"starting",
+		encodedOutputBegin,
+		encoded,
+		encodedOutputEnd,
+		"done",
+	}, "\n")
+
+	var out bytes.Buffer
+	var mtx sync.Mutex
+	copyFollowedLogs(&out, &mtx, "[job/df] ", strings.NewReader(input))
+
+	expected := "" +
+		"[job/df] starting\n" +
+		"[job/df] Filesystem 1B-blocks\n" +
+		"[job/df] /dev/sda1 100\n" +
+		"[job/df] done\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\nreceived:\n%s", expected, out.String())
+	}
+}
+
+func Test_streamJobLogs(t *testing.T) {
+	followLogsPollInterval = 10 * time.Millisecond
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "disk-free-node0-abcde", Namespace: "default"}}
+	kcli := fake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "disk-free-node0-abcde-xyz",
+			Namespace: "default",
+			Labels:    map[string]string{"job-name": job.Name},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "df"}, {Name: "fstab"}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	})
+
+	// the fake client returns "fake logs" for every container.
+	waitFor := func(cond func() bool) {
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) && !cond() {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	t.Run("should buffer all containers logs without a writer", func(t *testing.T) {
+		getter := OpenEBSFreeDiskSpaceGetter{kcli: kcli, log: log.New(os.Stderr, "", 0)}
+
+		// logs can only be read once the streaming stops, stream again until they have been read.
+		var logs map[string][]byte
+		waitFor(func() bool {
+			stop := getter.streamJobLogs(context.Background(), job)
+			time.Sleep(50 * time.Millisecond)
+			logs = stop()
+			return len(logs) == 2
+		})
+
+		for _, container := range []string{"df", "fstab"} {
+			if string(logs[container]) != "fake logs" {
+				t.Errorf("expected container %s logs to be buffered, received %q", container, logs[container])
+			}
+		}
+	})
+
+	t.Run("should stream all containers logs", func(t *testing.T) {
+		out := &syncBuffer{}
+		getter := OpenEBSFreeDiskSpaceGetter{kcli: kcli, opts: OpenEBSOptions{Job: OpenEBSJobOptions{FollowLogs: out}}, log: log.New(os.Stderr, "", 0)}
+		stop := getter.streamJobLogs(context.Background(), job)
+
+		expected := []string{"[disk-free-node0-abcde/df] fake logs", "[disk-free-node0-abcde/fstab] fake logs"}
+		waitFor(func() bool {
+			return strings.Contains(out.String(), expected[0]) && strings.Contains(out.String(), expected[1])
+		})
+		logs := stop()
+
+		for _, line := range expected {
+			if !strings.Contains(out.String(), line) {
+				t.Errorf("expected %q in the followed logs, received: %q", line, out.String())
+			}
+		}
+		if len(logs) != 2 {
+			t.Errorf("expected the followed logs to be buffered as well, received %v", logs)
+		}
+	})
+}
+
+func Test_partialOutputError(t *testing.T) {
+	timeout := errors.New("timeout waiting for job")
+	encoded := base64.StdEncoding.EncodeToString([]byte("/dev/sda1 / ext4 defaults 0 1\n"))
+
+	for _, tt := range []struct {
+		name     string
+		logs     map[string][]byte
+		expected string
+	}{
+		{
+			name:     "should return the error as is without output",
+			expected: "timeout waiting for job",
+		},
+		{
+			name: "should include the output of every container",
+			logs: map[string][]byte{
+				"fstab": []byte(strings.Join([]string{encodedOutputBegin, encoded, encodedOutputEnd}, "\n")),
+				"df":    []byte("measuring /data\n"),
+			},
+			expected: "timeout waiting for job, output before failing:\n" +
+				"[job/df] measuring /data\n" +
+				"[job/fstab] /dev/sda1 / ext4 defaults 0 1",
+		},
+		{
+			name: "should include the lines of incomplete encoded blocks",
+			logs: map[string][]byte{
+				"df": []byte(strings.Join([]string{
+					"measuring /data",
+					encodedOutputBegin,
+					base64.StdEncoding.EncodeToString([]byte("Filesystem 1B-blocks\n")),
+				}, "\n")),
+			},
+			expected: "timeout waiting for job, output before failing:\n" +
+				"[job/df] measuring /data\n" +
+				"[job/df] Filesystem 1B-blocks",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := partialOutputError(timeout, "job", tt.logs)
+			if err.Error() != tt.expected {
+				t.Errorf("expected:\n%s\nreceived:\n%s", tt.expected, err)
+			}
+			if !errors.Is(err, timeout) {
+				t.Errorf("expected the original error to be wrapped")
+			}
+		})
+	}
+}
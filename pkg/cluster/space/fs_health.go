@@ -0,0 +1,174 @@
+package clusterspace
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// fsHealthMountsMarker precedes the node /proc/mounts content in the fs health container output.
+	fsHealthMountsMarker = "KURL_FSHEALTH_MOUNTS"
+	// fsHealthDmesgMarker precedes the node kernel ring buffer content in the fs health container output.
+	fsHealthDmesgMarker = "KURL_FSHEALTH_DMESG"
+	// fsHealthDmesgLines is the number of recent kernel ring buffer lines inspected for i/o errors.
+	fsHealthDmesgLines = 1000
+	// fsHealthMaxIOErrors is the maximum number of kernel messages kept for each node.
+	fsHealthMaxIOErrors = 10
+)
+
+// fsHealthCommand prints the node mount table and the most recent kernel messages. the container
+// enters the host mount namespace so /proc/mounts reflects the node mounts. if dmesg is not
+// available nothing is printed after the dmesg marker.
+var fsHealthCommand = fmt.Sprintf(
+	`echo %s; nsenter --target 1 --mount -- cat /proc/mounts; echo %s; nsenter --target 1 --mount -- dmesg 2>/dev/null | tail -n %d; true`,
+	fsHealthMountsMarker, fsHealthDmesgMarker, fsHealthDmesgLines,
+)
+
+// fsIOErrorPatterns are the (lower case) kernel message fragments that indicate a failing disk or
+// a corrupted filesystem.
+var fsIOErrorPatterns = []string{
+	"i/o error",
+	"ext4-fs error",
+	"xfs_corrupt",
+	"corruption detected",
+	"remounting filesystem read-only",
+	"critical medium error",
+	"unrecovered read error",
+}
+
+// FSHealth holds the filesystem corruption signals found in a node. MountPoint is the mount
+// holding the openebs base path, ReadOnly is set when it has been (re)mounted read only and
+// IOErrors holds the most recent kernel messages reporting i/o errors or filesystem corruption.
+type FSHealth struct {
+	MountPoint string   `json:"mountPoint"`
+	ReadOnly   bool     `json:"readOnly"`
+	IOErrors   []string `json:"ioErrors,omitempty"`
+}
+
+// Healthy returns true if no corruption signal has been found.
+func (f FSHealth) Healthy() bool {
+	return !f.ReadOnly && len(f.IOErrors) == 0
+}
+
+// String returns a user friendly description of the signals found.
+func (f FSHealth) String() string {
+	if f.Healthy() {
+		return fmt.Sprintf("filesystem mounted on %s is healthy", f.MountPoint)
+	}
+
+	var reasons []string
+	if f.ReadOnly {
+		reasons = append(reasons, fmt.Sprintf("%s is mounted read only", f.MountPoint))
+	}
+	if len(f.IOErrors) > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d i/o error(s) in the kernel log, last: %s", len(f.IOErrors), f.IOErrors[len(f.IOErrors)-1]))
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// parseFSHealthOutput parses the output of the fsHealthCommand. the mount holding basePath is the
+// one with the longest mount point containing it, if multiple mounts share the same mount point the
+// last one (the one on top) is used. malformed lines are ignored as the mount table and the kernel
+// log may contain entries we do not know about.
+func parseFSHealthOutput(output []byte, basePath string) (FSHealth, error) {
+	var section string
+	var health FSHealth
+	var found bool
+
+	scanner := bufio.NewScanner(bytes.NewBuffer(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch line {
+		case "":
+			continue
+		case fsHealthMountsMarker, fsHealthDmesgMarker:
+			section = line
+			continue
+		}
+
+		switch section {
+		case fsHealthMountsMarker:
+			fields := strings.Fields(line)
+			if len(fields) < 4 {
+				continue
+			}
+
+			mountPoint := unescapeMountPath(fields[1])
+			if !pathHasPrefix(basePath, mountPoint) {
+				continue
+			}
+
+			if found && len(mountPoint) < len(health.MountPoint) {
+				continue
+			}
+
+			found = true
+			health.MountPoint = mountPoint
+			health.ReadOnly = hasMountOption(fields[3], "ro")
+
+		case fsHealthDmesgMarker:
+			if !isIOErrorMessage(line) {
+				continue
+			}
+
+			health.IOErrors = append(health.IOErrors, line)
+			if len(health.IOErrors) > fsHealthMaxIOErrors {
+				health.IOErrors = health.IOErrors[1:]
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return FSHealth{}, fmt.Errorf("failed to read fs health output: %w", err)
+	}
+
+	if !found {
+		return FSHealth{}, fmt.Errorf("failed to find the mount for %s in the node mount table", basePath)
+	}
+	return health, nil
+}
+
+// hasMountOption returns true if the comma separated mount options contain the provided option.
+func hasMountOption(options, option string) bool {
+	for _, opt := range strings.Split(options, ",") {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// isIOErrorMessage returns true if the kernel message reports an i/o error or a filesystem
+// corruption.
+func isIOErrorMessage(line string) bool {
+	line = strings.ToLower(line)
+	for _, pattern := range fsIOErrorPatterns {
+		if strings.Contains(line, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// unescapeMountPath decodes the octal escapes (e.g. \040 for space) used by the kernel for special
+// characters in /proc/mounts paths. invalid escapes are kept as they are.
+func unescapeMountPath(path string) string {
+	if !strings.Contains(path, `\`) {
+		return path
+	}
+
+	var result strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+3 < len(path) {
+			if c, err := strconv.ParseUint(path[i+1:i+4], 8, 8); err == nil {
+				result.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		result.WriteByte(path[i])
+	}
+	return result.String()
+}
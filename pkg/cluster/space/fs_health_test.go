@@ -0,0 +1,143 @@
+package clusterspace
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_parseFSHealthOutput(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		content  []byte
+		basePath string
+		err      string
+		expected FSHealth
+	}{
+		{
+			name:     "should report a healthy filesystem",
+			basePath: "/var/openebs/local",
+			content: []byte(`KURL_FSHEALTH_MOUNTS
+/dev/sda1 / ext4 rw,relatime 0 0
+proc /proc proc rw,nosuid,nodev,noexec,relatime 0 0
+/dev/sdb1 /var/openebs ext4 rw,relatime 0 0
+KURL_FSHEALTH_DMESG
+[    1.000000] usb 1-1: new high-speed USB device number 2 using xhci_hcd
+`),
+			expected: FSHealth{MountPoint: "/var/openebs"},
+		},
+		{
+			name:     "should detect the base path mount remounted read only",
+			basePath: "/var/openebs/local",
+			content: []byte(`KURL_FSHEALTH_MOUNTS
+/dev/sda1 / ext4 rw,relatime 0 0
+/dev/sdb1 /var/openebs ext4 ro,relatime,errors=remount-ro 0 0
+KURL_FSHEALTH_DMESG
+`),
+			expected: FSHealth{MountPoint: "/var/openebs", ReadOnly: true},
+		},
+		{
+			name:     "should not flag the errors=remount-ro option as read only",
+			basePath: "/var/openebs/local",
+			content: []byte(`KURL_FSHEALTH_MOUNTS
+/dev/sda1 / ext4 rw,relatime,errors=remount-ro 0 0
+KURL_FSHEALTH_DMESG
+`),
+			expected: FSHealth{MountPoint: "/"},
+		},
+		{
+			name:     "should ignore read only mounts not holding the base path",
+			basePath: "/var/openebs/local",
+			content: []byte(`KURL_FSHEALTH_MOUNTS
+/dev/sda1 / ext4 rw,relatime 0 0
+/dev/sr0 /media/cdrom iso9660 ro,relatime 0 0
+/dev/sdb1 /var/openebs-old ext4 ro,relatime 0 0
+KURL_FSHEALTH_DMESG
+`),
+			expected: FSHealth{MountPoint: "/"},
+		},
+		{
+			name:     "should use the last mount when mounts are stacked",
+			basePath: "/var/openebs/local",
+			content: []byte(`KURL_FSHEALTH_MOUNTS
+/dev/sda1 / ext4 rw,relatime 0 0
+/dev/sdb1 /var/openebs ext4 rw,relatime 0 0
+/dev/sdb1 /var/openebs ext4 ro,relatime 0 0
+KURL_FSHEALTH_DMESG
+`),
+			expected: FSHealth{MountPoint: "/var/openebs", ReadOnly: true},
+		},
+		{
+			name:     "should decode escaped mount points",
+			basePath: "/mnt/openebs data/local",
+			content: []byte(`KURL_FSHEALTH_MOUNTS
+/dev/sda1 / ext4 rw,relatime 0 0
+/dev/sdb1 /mnt/openebs\040data ext4 ro,relatime 0 0
+KURL_FSHEALTH_DMESG
+`),
+			expected: FSHealth{MountPoint: "/mnt/openebs data", ReadOnly: true},
+		},
+		{
+			name:     "should collect i/o errors and ignore malformed lines",
+			basePath: "/var/openebs/local",
+			content: []byte(`KURL_FSHEALTH_MOUNTS
+garbage
+/dev/sda1 / ext4 rw,relatime 0 0
+KURL_FSHEALTH_DMESG
+[ 100.000000] blk_update_request: I/O error, dev sdb, sector 2048 op 0x0:(READ)
+[ 101.000000] EXT4-fs error (device sdb1): ext4_find_entry:1455: inode #2: comm ls: reading directory lblock 0
+[ 102.000000] eth0: link up
+[ 103.000000] EXT4-fs (sdb1): Remounting filesystem read-only
+`),
+			expected: FSHealth{
+				MountPoint: "/",
+				IOErrors: []string{
+					"[ 100.000000] blk_update_request: I/O error, dev sdb, sector 2048 op 0x0:(READ)",
+					"[ 101.000000] EXT4-fs error (device sdb1): ext4_find_entry:1455: inode #2: comm ls: reading directory lblock 0",
+					"[ 103.000000] EXT4-fs (sdb1): Remounting filesystem read-only",
+				},
+			},
+		},
+		{
+			name:     "should fail if no mount holds the base path",
+			basePath: "/var/openebs/local",
+			content: []byte(`KURL_FSHEALTH_MOUNTS
+KURL_FSHEALTH_DMESG
+`),
+			err: "failed to find the mount for /var/openebs/local",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			health, err := parseFSHealthOutput(tt.content, tt.basePath)
+			if err != nil {
+				if len(tt.err) == 0 {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expecting %q, %q received instead", tt.err, err)
+				}
+				return
+			}
+
+			if len(tt.err) > 0 {
+				t.Errorf("expecting error %q, nil received instead", tt.err)
+			}
+
+			if diff := cmp.Diff(tt.expected, health); diff != "" {
+				t.Errorf("unexpected fs health: %s", diff)
+			}
+		})
+	}
+}
+
+func TestFSHealthString(t *testing.T) {
+	health := FSHealth{MountPoint: "/var/openebs", ReadOnly: true, IOErrors: []string{"first", "last"}}
+	if health.Healthy() {
+		t.Errorf("expected read only filesystem to be unhealthy")
+	}
+
+	expected := "/var/openebs is mounted read only, 2 i/o error(s) in the kernel log, last: last"
+	if health.String() != expected {
+		t.Errorf("expected %q, %q received instead", expected, health.String())
+	}
+}
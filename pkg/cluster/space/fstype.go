@@ -0,0 +1,71 @@
+package clusterspace
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FSTypeNotAllowedError is returned when the openebs base path of a node lives in a filesystem type
+// not included in the allowed filesystem types.
+type FSTypeNotAllowedError struct {
+	Node    string
+	FSType  string
+	Allowed []string
+}
+
+// Error returns a message describing the offending node and filesystem type.
+func (e *FSTypeNotAllowedError) Error() string {
+	fstype := e.FSType
+	if fstype == "" {
+		fstype = "unknown"
+	}
+	return fmt.Sprintf(
+		"base path on node %s lives in filesystem type %s, allowed filesystem types: %s",
+		e.Node, fstype, strings.Join(e.Allowed, ", "),
+	)
+}
+
+// checkFSType verifies that the provided node volume filesystem type is allowed. any type is allowed
+// if no allowed filesystem types have been configured, volumes whose type is unknown are rejected
+// otherwise.
+func (o *OpenEBSFreeDiskSpaceGetter) checkFSType(node string, vol OpenEBSVolume) error {
+	if len(o.opts.Output.AllowedFSTypes) == 0 {
+		return nil
+	}
+
+	for _, allowed := range o.opts.Output.AllowedFSTypes {
+		if vol.FSType != "" && vol.FSType == allowed {
+			return nil
+		}
+	}
+	return &FSTypeNotAllowedError{Node: node, FSType: vol.FSType, Allowed: o.opts.Output.AllowedFSTypes}
+}
+
+// unreliableFilesystems are the filesystem types whose df readings say nothing about the storage
+// available to the openebs volumes, e.g. a base path landing on the overlay root filesystem of a container.
+var unreliableFilesystems = map[string]bool{
+	"tmpfs":   true,
+	"overlay": true,
+	"proc":    true,
+	"sysfs":   true,
+}
+
+// isUnreliableFilesystem returns true if the df readings of the provided filesystem type do not
+// reflect any real storage, see unreliableFilesystems.
+func isUnreliableFilesystem(fstype string) bool {
+	return unreliableFilesystems[fstype]
+}
+
+// UnreliableFilesystem returns the filesystem type of the volume if its free space measurement is
+// unreliable (tmpfs, overlay, proc or sysfs), an empty string otherwise. both the fstab filesystem
+// type and the df source column are inspected as the latter reports overlay and tmpfs mounts
+// missing from the node fstab.
+func (v OpenEBSVolume) UnreliableFilesystem() string {
+	if isUnreliableFilesystem(v.FSType) {
+		return v.FSType
+	}
+	if isUnreliableFilesystem(v.Source) {
+		return v.Source
+	}
+	return ""
+}
@@ -0,0 +1,124 @@
+package clusterspace
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_fstabFilesystemType(t *testing.T) {
+	fstab := []byte(`# <file system> <mount point>   <type>  <options>       <dump>  <pass>
+UUID=d8605abb-d6cd-4a46-a657-b6bd206da2ab /            ext4   defaults  0 1
+/dev/sdb1                                 /var/openebs xfs    defaults  0 2
+nfs.example.com:/exports                  /var/openebs-nfs nfs defaults 0 0
+  # /dev/sdc1                             /var/openebs/local btrfs defaults 0 2
+/dev/sdd1                                 none         swap   sw        0 0`)
+
+	for _, tt := range []struct {
+		path     string
+		expected string
+	}{
+		{path: "/var/openebs/local", expected: "xfs"},
+		{path: "/var/openebs", expected: "xfs"},
+		{path: "/var/openebs-nfs/local", expected: "nfs"},
+		{path: "/opt/openebs", expected: "ext4"},
+	} {
+		if fstype := fstabFilesystemType(fstab, tt.path); fstype != tt.expected {
+			t.Errorf("expected %q for %s, %q received", tt.expected, tt.path, fstype)
+		}
+	}
+
+	if fstype := fstabFilesystemType([]byte("/dev/sdb1 /mnt xfs defaults 0 0"), "/var/openebs"); fstype != "" {
+		t.Errorf("expected no filesystem type for a path out of any entry, %q received", fstype)
+	}
+}
+
+func Test_checkFSType(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		allowed []string
+		fstype  string
+		err     string
+	}{
+		{
+			name:   "should allow any type without an allowlist",
+			fstype: "tmpfs",
+		},
+		{
+			name:    "should allow listed types",
+			allowed: []string{"xfs", "ext4"},
+			fstype:  "ext4",
+		},
+		{
+			name:    "should reject types out of the list",
+			allowed: []string{"xfs", "ext4"},
+			fstype:  "nfs",
+			err:     "base path on node node0 lives in filesystem type nfs, allowed filesystem types: xfs, ext4",
+		},
+		{
+			name:    "should reject unknown types",
+			allowed: []string{"xfs"},
+			err:     "base path on node node0 lives in filesystem type unknown, allowed filesystem types: xfs",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			getter := OpenEBSFreeDiskSpaceGetter{opts: OpenEBSOptions{Output: OpenEBSOutputOptions{AllowedFSTypes: tt.allowed}}}
+			err := getter.checkFSType("node0", OpenEBSVolume{FSType: tt.fstype})
+			if err == nil {
+				if tt.err != "" {
+					t.Errorf("expecting error %q, nil received instead", tt.err)
+				}
+				return
+			}
+
+			if tt.err == "" {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err.Error() != tt.err {
+				t.Errorf("expecting %q, %q received instead", tt.err, err)
+			}
+
+			var fserr *FSTypeNotAllowedError
+			if !errors.As(err, &fserr) || fserr.Node != "node0" || fserr.FSType != tt.fstype {
+				t.Errorf("expected a FSTypeNotAllowedError for node0, received %#v", err)
+			}
+		})
+	}
+}
+
+func TestOpenEBSVolume_UnreliableFilesystem(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		volume   OpenEBSVolume
+		expected string
+	}{
+		{
+			name:   "should not flag regular filesystems",
+			volume: OpenEBSVolume{FSType: "xfs", Source: "/dev/sdb1"},
+		},
+		{
+			name:   "should not flag unknown filesystems",
+			volume: OpenEBSVolume{},
+		},
+		{
+			name:     "should flag tmpfs fstab entries",
+			volume:   OpenEBSVolume{FSType: "tmpfs", Source: "tmpfs"},
+			expected: "tmpfs",
+		},
+		{
+			name:     "should flag overlay df sources missing from the fstab",
+			volume:   OpenEBSVolume{FSType: "ext4", Source: "overlay"},
+			expected: "overlay",
+		},
+		{
+			name:     "should flag sysfs",
+			volume:   OpenEBSVolume{FSType: "sysfs"},
+			expected: "sysfs",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if fstype := tt.volume.UnreliableFilesystem(); fstype != tt.expected {
+				t.Errorf("expected %q, %q received", tt.expected, fstype)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package clusterspace
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// imagePullPollInterval is how often the image pull pods are inspected.
+var imagePullPollInterval = time.Second
+
+// imagePullFailureReasons are the container waiting reasons reported by the kubelet when an image
+// can't be pulled.
+var imagePullFailureReasons = map[string]bool{
+	"ErrImagePull":        true,
+	"ImagePullBackOff":    true,
+	"InvalidImageName":    true,
+	"ErrImageNeverPull":   true,
+	"RegistryUnavailable": true,
+}
+
+// buildImagePullPod returns a pod scheduled to run in the provided node that does nothing but
+// pulling the getter image. the image is always pulled so the registry and the credentials are
+// verified even if the image is already present in the node.
+func (o *OpenEBSFreeDiskSpaceGetter) buildImagePullPod(node string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        jobName(node),
+			Namespace:   o.opts.Namespace,
+			Labels:      o.jobLabels(node),
+			Annotations: o.jobAnnotations(),
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy:         corev1.RestartPolicyNever,
+			ActiveDeadlineSeconds: activeDeadlineSeconds(o.opts.Job.Timeout),
+			Tolerations:           o.tolerations,
+			Affinity:              nodeAffinity(node),
+			ImagePullSecrets:      o.imagePullSecrets(),
+			Containers: []corev1.Container{
+				{
+					Name:            "pull",
+					Image:           o.opts.Image,
+					ImagePullPolicy: corev1.PullAlways,
+					Command:         []string{"/bin/sh", "-c", "exit 0"},
+				},
+			},
+		},
+	}
+}
+
+// imagePullStatus inspects the provided image pull pod. returns true once the outcome is known,
+// along with the pull error, if any. a container that has started, whatever its exit code, means
+// the image has been pulled.
+func imagePullStatus(pod *corev1.Pod) (bool, string) {
+	for _, status := range pod.Status.ContainerStatuses {
+		if waiting := status.State.Waiting; waiting != nil && imagePullFailureReasons[waiting.Reason] {
+			message := waiting.Reason
+			if waiting.Message != "" {
+				message = fmt.Sprintf("%s: %s", waiting.Reason, waiting.Message)
+			}
+			return true, message
+		}
+
+		if status.State.Running != nil || status.State.Terminated != nil {
+			return true, ""
+		}
+	}
+
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		return true, ""
+	case corev1.PodFailed:
+		return true, fmt.Sprintf("pod failed before starting: %s", pod.Status.Message)
+	}
+	return false, ""
+}
+
+// nodeImagePull runs an image pull pod in the provided node and waits until the image is pulled
+// or fails to be pulled. returns the pull error, empty if the image has been pulled.
+func (o *OpenEBSFreeDiskSpaceGetter) nodeImagePull(ctx context.Context, node string) (string, error) {
+	if err := o.waitCreate(ctx); err != nil {
+		return "", err
+	}
+
+	pod, err := o.kcli.CoreV1().Pods(o.opts.Namespace).Create(ctx, o.buildImagePullPod(node), metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to create image pull pod on node %s: %w", node, err)
+	}
+
+	defer func() {
+		// Cleanup should use background context so as not to fail if context has already been canceled
+		if err := o.kcli.CoreV1().Pods(pod.Namespace).Delete(
+			context.Background(), pod.Name, metav1.DeleteOptions{},
+		); err != nil {
+			o.log.Printf("failed to delete image pull pod %s: %s", pod.Name, err)
+		}
+	}()
+
+	timeout := time.After(o.opts.Job.Timeout)
+	for {
+		current, err := o.kcli.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("failed to get image pull pod on node %s: %w", node, err)
+		}
+
+		if done, pullErr := imagePullStatus(current); done {
+			return pullErr, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-timeout:
+			return fmt.Sprintf("timeout waiting for the image to be pulled after %s", o.opts.Job.Timeout), nil
+		case <-time.After(imagePullPollInterval):
+		}
+	}
+}
+
+// CheckImagePull verifies that every node can pull the runner image by scheduling a trivial pod in
+// each of them. returns the pull error for each node, indexed by node name, an empty string means
+// the image has been pulled. nodes that can't run linux jobs are not checked, they are available
+// through SkippedNodes.
+func (r *NodeJobRunner) CheckImagePull(ctx context.Context) (map[string]string, error) {
+	nodes, err := r.jobs.listNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return runNodeJobs(ctx, r, nodes.Items, func(ctx context.Context, node string) (string, error) {
+		r.jobs.log.Printf("Pulling image %s on node %s", r.jobs.opts.Image, node)
+		return r.jobs.nodeImagePull(ctx, node)
+	})
+}
+
+// imagePullSecrets returns the references to the configured image pull secrets.
+func (o *OpenEBSFreeDiskSpaceGetter) imagePullSecrets() []corev1.LocalObjectReference {
+	var refs []corev1.LocalObjectReference
+	for _, name := range o.opts.Job.ImagePullSecrets {
+		refs = append(refs, corev1.LocalObjectReference{Name: name})
+	}
+	return refs
+}
@@ -0,0 +1,192 @@
+package clusterspace
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func Test_imagePullStatus(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		pod     corev1.Pod
+		done    bool
+		pullErr string
+	}{
+		{
+			name: "should wait while the pod is pending",
+			pod:  corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodPending}},
+		},
+		{
+			name: "should wait while the image is being pulled",
+			pod: corev1.Pod{Status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}}},
+				},
+			}},
+		},
+		{
+			name: "should succeed once the container has started",
+			pod: corev1.Pod{Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+				},
+			}},
+			done: true,
+		},
+		{
+			name: "should succeed even if the container exited with an error",
+			pod: corev1.Pod{Status: corev1.PodStatus{
+				Phase: corev1.PodFailed,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 127}}},
+				},
+			}},
+			done: true,
+		},
+		{
+			name: "should report the pull error",
+			pod: corev1.Pod{Status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+						Reason:  "ErrImagePull",
+						Message: "pull access denied for myimage",
+					}}},
+				},
+			}},
+			done:    true,
+			pullErr: "ErrImagePull: pull access denied for myimage",
+		},
+		{
+			name: "should report the back off reason without message",
+			pod: corev1.Pod{Status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}}},
+				},
+			}},
+			done:    true,
+			pullErr: "ImagePullBackOff",
+		},
+		{
+			name: "should report pods failed before starting any container",
+			pod: corev1.Pod{Status: corev1.PodStatus{
+				Phase:   corev1.PodFailed,
+				Message: "Pod was rejected: node out of disk",
+			}},
+			done:    true,
+			pullErr: "pod failed before starting: Pod was rejected: node out of disk",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			done, pullErr := imagePullStatus(&tt.pod)
+			if done != tt.done || pullErr != tt.pullErr {
+				t.Errorf("expected (%v, %q), received (%v, %q)", tt.done, tt.pullErr, done, pullErr)
+			}
+		})
+	}
+}
+
+func TestCheckImagePull(t *testing.T) {
+	pollInterval := imagePullPollInterval
+	imagePullPollInterval = 10 * time.Millisecond
+	defer func() { imagePullPollInterval = pollInterval }()
+
+	var objs []runtime.Object
+	for _, name := range []string{"node0", "node1", "node2"} {
+		objs = append(objs, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+	objs = append(objs, &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "win0", Labels: map[string]string{"kubernetes.io/os": "windows"}},
+	})
+
+	// each node pod reaches a different state: node0 pulls the image, node1 fails to pull it and
+	// node2 never gets past the image pull.
+	states := map[string]corev1.ContainerState{
+		"node0": {Running: &corev1.ContainerStateRunning{}},
+		"node1": {Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "unauthorized"}},
+		"node2": {Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+	}
+
+	kcli := fake.NewSimpleClientset(objs...)
+	var secrets []string
+	kcli.PrependReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		get := action.(k8stesting.GetAction)
+		obj, err := kcli.Tracker().Get(action.GetResource(), get.GetNamespace(), get.GetName())
+		if err != nil {
+			return true, nil, err
+		}
+
+		pod := obj.(*corev1.Pod).DeepCopy()
+		for _, secret := range pod.Spec.ImagePullSecrets {
+			secrets = append(secrets, secret.Name)
+		}
+		affinity := pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+		node := affinity.NodeSelectorTerms[0].MatchExpressions[0].Values[0]
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "pull", State: states[node]}}
+		return true, pod, nil
+	})
+
+	runner := NodeJobRunner{
+		jobs: &OpenEBSFreeDiskSpaceGetter{
+			kcli: kcli,
+			log:  log.New(io.Discard, "", 0),
+			opts: OpenEBSOptions{
+				Image:     "myimage:latest",
+				Namespace: "default",
+				Job: OpenEBSJobOptions{
+					Timeout:          200 * time.Millisecond,
+					ImagePullSecrets: []string{"registry"},
+				},
+				Parallelism: 1,
+			},
+		},
+	}
+
+	pulls, err := runner.CheckImagePull(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := map[string]string{
+		"node0": "",
+		"node1": "ImagePullBackOff: unauthorized",
+		"node2": "timeout waiting for the image to be pulled after 200ms",
+	}
+	if diff := cmp.Diff(expected, pulls); diff != "" {
+		t.Errorf("unexpected pull results: %s", diff)
+	}
+
+	if _, ok := runner.SkippedNodes()["win0"]; !ok {
+		t.Errorf("expected windows node to be skipped, skipped: %v", runner.SkippedNodes())
+	}
+
+	for _, secret := range secrets {
+		if secret != "registry" {
+			t.Errorf("unexpected image pull secret %s", secret)
+		}
+	}
+	if len(secrets) == 0 {
+		t.Errorf("expected pods to reference the image pull secret")
+	}
+
+	pods, err := kcli.CoreV1().Pods("default").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error listing pods: %s", err)
+	}
+	if len(pods.Items) != 0 {
+		t.Errorf("expected image pull pods to be deleted, %d left", len(pods.Items))
+	}
+}
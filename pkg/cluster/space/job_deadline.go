@@ -0,0 +1,18 @@
+package clusterspace
+
+import (
+	"time"
+
+	"k8s.io/utils/ptr"
+)
+
+// activeDeadlineSeconds returns the ActiveDeadlineSeconds of the jobs and pods waited for up to the
+// provided timeout, rounded up to the next second. the deadline follows the timeout so the workload
+// is not killed by kubernetes while we are still waiting for it, nor left running once we gave up.
+func activeDeadlineSeconds(timeout time.Duration) *int64 {
+	seconds := int64((timeout + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return ptr.To(seconds)
+}
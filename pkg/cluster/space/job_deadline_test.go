@@ -0,0 +1,58 @@
+package clusterspace
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+	"time"
+)
+
+func Test_activeDeadlineSeconds(t *testing.T) {
+	for _, tt := range []struct {
+		timeout  time.Duration
+		expected int64
+	}{
+		{timeout: 5 * time.Minute, expected: 300},
+		{timeout: 1500 * time.Millisecond, expected: 2},
+		{timeout: time.Millisecond, expected: 1},
+		{timeout: 0, expected: 1},
+	} {
+		if deadline := activeDeadlineSeconds(tt.timeout); *deadline != tt.expected {
+			t.Errorf("expected a %d seconds deadline for %s, %d received", tt.expected, tt.timeout, *deadline)
+		}
+	}
+}
+
+func TestJobDeadlineFollowsJobTimeout(t *testing.T) {
+	getter, err := NewOpenEBSFreeDiskSpaceGetterWithOptions(nil, OpenEBSOptions{
+		Log:          log.New(io.Discard, "", 0),
+		Image:        "image",
+		DstSC:        "openebs",
+		WindowsImage: "windows",
+		Job: OpenEBSJobOptions{
+			Timeout: 10 * time.Minute,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected failure creating object: %v", err)
+	}
+
+	job := getter.buildJob(context.Background(), "node0", "/var/local", "tmppvc")
+	deadlines := map[string]*int64{
+		"df job":           job.Spec.ActiveDeadlineSeconds,
+		"df pod":           getter.buildPod(job).Spec.ActiveDeadlineSeconds,
+		"windows job":      getter.buildWindowsJob("node0").Spec.ActiveDeadlineSeconds,
+		"mount job":        getter.buildMountPointsJob("node0", []string{"/var/local"}).Spec.ActiveDeadlineSeconds,
+		"base path job":    getter.buildBasePathJob("node0", "/var/local").Spec.ActiveDeadlineSeconds,
+		"image pull pod":   getter.buildImagePullPod("node0").Spec.ActiveDeadlineSeconds,
+		"swap job":         getter.buildSwapJob("node0").Spec.ActiveDeadlineSeconds,
+		"etcd job":         getter.buildEtcdLatencyJob("node0", "fio", "/var/lib/etcd").Spec.ActiveDeadlineSeconds,
+		"source usage job": getter.buildDUJob("node0", []string{"/var/local"}).Spec.ActiveDeadlineSeconds,
+	}
+	for name, deadline := range deadlines {
+		if deadline == nil || *deadline != 600 {
+			t.Errorf("expected the %s deadline to follow the 10m job timeout, %v found", name, deadline)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package clusterspace
+
+// recordJobOutput keeps the output of the containers of the job run in the provided node so it can
+// be inspected after the evaluation, see JobOutputs. the df container output is kept decoded when
+// possible.
+func (o *OpenEBSFreeDiskSpaceGetter) recordJobOutput(node string, out map[string][]byte) {
+	if len(out) == 0 {
+		return
+	}
+
+	outputs := map[string][]byte{}
+	for container, output := range out {
+		if container == "df" {
+			if decoded, err := decodeOutput(output); err == nil {
+				output = decoded
+			}
+		}
+		outputs[container] = output
+	}
+
+	o.outputsMtx.Lock()
+	defer o.outputsMtx.Unlock()
+	if o.outputs == nil {
+		o.outputs = map[string]map[string][]byte{}
+	}
+	o.outputs[node] = outputs
+}
+
+// JobOutputs returns the output of the containers of the jobs run by the last OpenEBSVolumes call,
+// indexed by node and container name. nodes whose measurement did not run a job (e.g. read from the
+// cache or from node exporter metrics) are not present. outputs are kept even for the jobs that
+// failed, for troubleshooting.
+func (o *OpenEBSFreeDiskSpaceGetter) JobOutputs() map[string]map[string][]byte {
+	o.outputsMtx.Lock()
+	defer o.outputsMtx.Unlock()
+
+	result := map[string]map[string][]byte{}
+	for node, outputs := range o.outputs {
+		result[node] = outputs
+	}
+	return result
+}
@@ -0,0 +1,34 @@
+package clusterspace
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestJobOutputs(t *testing.T) {
+	df := "KURL_DF_BEGIN\nFilesystem 1B-blocks Used Available Use% Mounted on\n"
+	encoded := "---BEGIN---\n" + base64.StdEncoding.EncodeToString([]byte(df)) + "\n---END---\n"
+
+	var getter OpenEBSFreeDiskSpaceGetter
+	getter.recordJobOutput("node0", map[string][]byte{
+		"df":    []byte(encoded),
+		"fstab": []byte("/dev/sda1 / xfs defaults 0 0\n"),
+	})
+	getter.recordJobOutput("node1", map[string][]byte{"df": []byte("---BEGIN---\ntruncated")})
+	getter.recordJobOutput("node2", nil)
+
+	expected := map[string]map[string][]byte{
+		"node0": {
+			"df":    []byte(df),
+			"fstab": []byte("/dev/sda1 / xfs defaults 0 0\n"),
+		},
+		"node1": {
+			"df": []byte("---BEGIN---\ntruncated"),
+		},
+	}
+	if diff := cmp.Diff(expected, getter.JobOutputs()); diff != "" {
+		t.Errorf("unexpected job outputs: %s", diff)
+	}
+}
@@ -0,0 +1,56 @@
+package clusterspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/replicatedhq/kurl/pkg/k8sutil"
+)
+
+// jobRetryBackoff is how long we wait before retrying a job whose pod could not be scheduled, it is
+// doubled after every retry.
+var jobRetryBackoff = 5 * time.Second
+
+// retryJobName returns the provided job name with a new random suffix, see jobName. retried jobs
+// can't reuse the name as the previous job may still be being deleted.
+func retryJobName(name string) string {
+	return name[:len(name)-5] + uuid.New().String()[:5]
+}
+
+// retryUnschedulable calls run until it succeeds, fails with an error other than a pod that could
+// not be scheduled (k8sutil.ErrPodNotScheduled) or the provided number of retries is exhausted. the
+// wait between attempts starts at backoff and doubles after every retry. run receives the attempt
+// number, starting at zero. the number of retries is added to the returned error if any was done.
+func retryUnschedulable(ctx context.Context, retries int, backoff time.Duration, run func(attempt int) error) error {
+	for attempt := 0; ; attempt++ {
+		err := run(attempt)
+		if err == nil {
+			return nil
+		}
+
+		if !errors.Is(err, k8sutil.ErrPodNotScheduled) {
+			if attempt > 0 {
+				return fmt.Errorf("failed after %d retries: %w", attempt, err)
+			}
+			return err
+		}
+
+		if attempt >= retries {
+			if attempt > 0 {
+				return fmt.Errorf("pod not scheduled after %d retries: %w", attempt, err)
+			}
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			return fmt.Errorf("cancelled waiting to retry after %d retries: %w", attempt, err)
+		}
+	}
+}
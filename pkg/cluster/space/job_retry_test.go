@@ -0,0 +1,177 @@
+package clusterspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
+
+	"github.com/replicatedhq/kurl/pkg/k8sutil"
+)
+
+func Test_retryUnschedulable(t *testing.T) {
+	notScheduled := fmt.Errorf("%w: 0/3 nodes are available", k8sutil.ErrPodNotScheduled)
+	for _, tt := range []struct {
+		name     string
+		retries  int
+		errs     []error
+		attempts int
+		err      string
+	}{
+		{
+			name:     "should not retry successful jobs",
+			retries:  3,
+			errs:     []error{nil},
+			attempts: 1,
+		},
+		{
+			name:     "should retry until the pod is scheduled",
+			retries:  3,
+			errs:     []error{notScheduled, notScheduled, nil},
+			attempts: 3,
+		},
+		{
+			name:     "should not retry jobs that ran and failed",
+			retries:  3,
+			errs:     []error{errors.New("container df failed")},
+			attempts: 1,
+			err:      "container df failed",
+		},
+		{
+			name:     "should report retries of jobs that failed after being scheduled",
+			retries:  3,
+			errs:     []error{notScheduled, errors.New("container df failed")},
+			attempts: 2,
+			err:      "failed after 1 retries: container df failed",
+		},
+		{
+			name:     "should give up once the retries are exhausted",
+			retries:  2,
+			errs:     []error{notScheduled, notScheduled, notScheduled, nil},
+			attempts: 3,
+			err:      "pod not scheduled after 2 retries: pod not scheduled: 0/3 nodes are available",
+		},
+		{
+			name:     "should not retry if retries are disabled",
+			retries:  0,
+			errs:     []error{notScheduled, nil},
+			attempts: 1,
+			err:      "pod not scheduled: 0/3 nodes are available",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int
+			err := retryUnschedulable(context.Background(), tt.retries, time.Millisecond, func(attempt int) error {
+				if attempt != attempts {
+					t.Errorf("expected attempt %d, received %d", attempts, attempt)
+				}
+				attempts++
+				return tt.errs[attempt]
+			})
+
+			if attempts != tt.attempts {
+				t.Errorf("expected %d attempts, %d made", tt.attempts, attempts)
+			}
+
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected error %q, received %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func Test_retryUnschedulableCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var attempts int
+	err := retryUnschedulable(ctx, 3, time.Hour, func(int) error {
+		attempts++
+		cancel()
+		return k8sutil.ErrPodNotScheduled
+	})
+	if attempts != 1 {
+		t.Errorf("expected a single attempt, %d made", attempts)
+	}
+	if !errors.Is(err, k8sutil.ErrPodNotScheduled) {
+		t.Errorf("expected pod not scheduled error, received %v", err)
+	}
+}
+
+func Test_retryJobName(t *testing.T) {
+	name := jobName("node-1")
+	retried := retryJobName(name)
+	if retried == name || len(retried) != len(name) {
+		t.Errorf("unexpected retry job name %q for %q", retried, name)
+	}
+	if !strings.HasPrefix(retried, name[:len(name)-5]) {
+		t.Errorf("retry job name %q does not keep the %q prefix", retried, name[:len(name)-5])
+	}
+}
+
+func TestRunJobRetriesJobsPastTheirDeadline(t *testing.T) {
+	backoff := jobRetryBackoff
+	jobRetryBackoff = time.Millisecond
+	defer func() { jobRetryBackoff = backoff }()
+
+	pending := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "default", Labels: map[string]string{"test": "pending"}},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Message: "0/3 nodes are available"},
+			},
+		},
+	}
+	kcli := fake.NewSimpleClientset(pending)
+
+	// jobs fail right away as if the job controller had killed them once their active deadline
+	// was reached, with their pod still pending.
+	var created int
+	kcli.PrependReactor("create", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		created++
+		job := action.(k8stesting.CreateAction).GetObject().(*batchv1.Job)
+		job.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"test": "pending"}}
+		job.Status.Failed = 1
+		job.Status.Conditions = []batchv1.JobCondition{
+			{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: batchv1.JobReasonDeadlineExceeded},
+		}
+		return false, nil, nil
+	})
+
+	getter, err := NewOpenEBSFreeDiskSpaceGetterWithOptions(kcli, OpenEBSOptions{
+		Log:   log.New(io.Discard, "", 0),
+		Image: "image",
+		DstSC: "openebs",
+		Job: OpenEBSJobOptions{
+			Retries: ptr.To(2),
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected failure creating object: %v", err)
+	}
+
+	_, _, err = getter.runJob(context.Background(), getter.buildSwapJob("node0"))
+	if !errors.Is(err, k8sutil.ErrPodNotScheduled) {
+		t.Errorf("expected pod not scheduled error, received %v", err)
+	}
+	if created != 3 {
+		t.Errorf("expected the job to be created 3 times, %d found", created)
+	}
+}
@@ -0,0 +1,142 @@
+package clusterspace
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/replicatedhq/kurl/pkg/k8sutil"
+)
+
+// LocalVolumeProvisioner is the provisioner of the storage classes backed by statically defined local
+// persistent volumes. these storage classes do not provision volumes dynamically.
+const LocalVolumeProvisioner = "kubernetes.io/no-provisioner"
+
+// availableLocalVolumes returns the provided pvs that are still available to be claimed, i.e. pvs not
+// bound nor reserved to any pvc.
+func availableLocalVolumes(pvs map[string]corev1.PersistentVolume) map[string]corev1.PersistentVolume {
+	available := map[string]corev1.PersistentVolume{}
+	for name, pv := range pvs {
+		if pv.Spec.ClaimRef != nil {
+			continue
+		}
+		available[name] = pv
+	}
+	return available
+}
+
+// localVolumesByPath returns the node paths to be measured for the provided node volumes and, for
+// each path, the volumes living on it. paths are returned sorted.
+func localVolumesByPath(volumes []sourceVolume) ([]string, map[string][]string) {
+	byPath := map[string][]string{}
+	for _, vol := range volumes {
+		byPath[vol.path] = append(byPath[vol.path], vol.name)
+	}
+
+	var paths []string
+	for p := range byPath {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths, byPath
+}
+
+// LocalVolumesSpace measures the free and used space of the local persistent volumes statically
+// defined for the getter storage class, a storage class using the LocalVolumeProvisioner. as these
+// storage classes have no base path the path of each volume is measured instead, in the node its
+// node affinity binds it to. only volumes still available to be claimed are measured as they are
+// the only ones new pvcs can use. the result is indexed by node name and then by pv name. nodes
+// that can't be measured with df (e.g. windows nodes) or that do not exist are skipped, see
+// SkippedNodes.
+func (o *OpenEBSFreeDiskSpaceGetter) LocalVolumesSpace(ctx context.Context) (map[string]map[string]OpenEBSVolume, error) {
+	sclass, err := o.kcli.StorageV1().StorageClasses().Get(ctx, o.opts.DstSC, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read storage class: %w", err)
+	}
+	if sclass.Provisioner != LocalVolumeProvisioner {
+		return nil, fmt.Errorf(
+			"storage class %s uses provisioner %s, expected %s",
+			o.opts.DstSC, sclass.Provisioner, LocalVolumeProvisioner,
+		)
+	}
+
+	pvs, err := k8sutil.PVSByStorageClass(ctx, o.kcli, o.opts.DstSC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list local volumes: %w", err)
+	}
+
+	perNode, unmeasured := groupSourceVolumes(availableLocalVolumes(pvs))
+	if len(unmeasured) > 0 {
+		o.log.Printf("Ignoring %d volume(s) not bound to a node path: %s", len(unmeasured), strings.Join(unmeasured, ", "))
+	}
+
+	nodes, err := o.listNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nodesByName := map[string]corev1.Node{}
+	for _, node := range nodes.Items {
+		nodesByName[node.Name] = node
+	}
+
+	o.skipped = map[string]string{}
+	result := map[string]map[string]OpenEBSVolume{}
+	for nodeName, volumes := range perNode {
+		node, ok := nodesByName[nodeName]
+		if !ok {
+			o.log.Printf("Skipping node %s: node not found", nodeName)
+			o.skipped[nodeName] = "node not found"
+			continue
+		}
+
+		if measurement, reason := o.measurementFor(node); measurement != measureDF {
+			if reason == "" {
+				reason = "only local volumes in linux nodes can be measured"
+			}
+			o.log.Printf("Skipping node %s: %s", nodeName, reason)
+			o.skipped[nodeName] = reason
+			continue
+		}
+
+		if err := o.nodeIsSchedulable(node); err != nil {
+			return nil, fmt.Errorf("failed to assess node %s: %w", nodeName, err)
+		}
+
+		paths, byPath := localVolumesByPath(volumes)
+		o.log.Printf("Analyzing free space of %d local volume(s) on node %s", len(volumes), nodeName)
+		space, err := o.nodeMountPoints(ctx, nodeName, paths)
+		if err != nil {
+			return nil, err
+		}
+
+		result[nodeName] = map[string]OpenEBSVolume{}
+		for p, vol := range space {
+			for _, name := range byPath[p] {
+				result[nodeName][name] = vol
+			}
+		}
+	}
+	return result, nil
+}
+
+// LargestLocalVolumes reduces the space measured by LocalVolumesSpace to the local volume with the
+// most free space in each node, as a new pvc is bound to a single local volume this is the biggest
+// claim each node can hold.
+func LargestLocalVolumes(space map[string]map[string]OpenEBSVolume) map[string]OpenEBSVolume {
+	result := map[string]OpenEBSVolume{}
+	for node, volumes := range space {
+		var largest OpenEBSVolume
+		for _, vol := range volumes {
+			if vol.Free > largest.Free {
+				largest = vol
+			}
+		}
+		result[node] = largest
+	}
+	return result
+}
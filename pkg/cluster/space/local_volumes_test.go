@@ -0,0 +1,136 @@
+package clusterspace
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func localPV(name, node, path string, claimed bool) corev1.PersistentVolume {
+	pv := corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: corev1.PersistentVolumeSpec{
+			StorageClassName: "local-storage",
+			PersistentVolumeSource: corev1.PersistentVolumeSource{
+				Local: &corev1.LocalVolumeSource{Path: path},
+			},
+		},
+	}
+	if node != "" {
+		pv.Spec.NodeAffinity = &corev1.VolumeNodeAffinity{
+			Required: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{
+					{
+						MatchExpressions: []corev1.NodeSelectorRequirement{
+							{
+								Key:      corev1.LabelHostname,
+								Operator: corev1.NodeSelectorOpIn,
+								Values:   []string{node},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+	if claimed {
+		pv.Spec.ClaimRef = &corev1.ObjectReference{Namespace: "default", Name: "claim-" + name}
+	}
+	return pv
+}
+
+func Test_localVolumesToNodePaths(t *testing.T) {
+	pvs := map[string]corev1.PersistentVolume{
+		"local-a": localPV("local-a", "node0", "/mnt/disks/ssd0", false),
+		"local-b": localPV("local-b", "node0", "/mnt/disks/ssd1", false),
+		"local-c": localPV("local-c", "node1", "/mnt/disks/ssd0", false),
+		"bound":   localPV("bound", "node1", "/mnt/disks/ssd1", true),
+		"no-node": localPV("no-node", "", "/mnt/disks/ssd2", false),
+	}
+
+	perNode, unmeasured := groupSourceVolumes(availableLocalVolumes(pvs))
+	expected := map[string][]sourceVolume{
+		"node0": {
+			{name: "local-a", path: "/mnt/disks/ssd0"},
+			{name: "local-b", path: "/mnt/disks/ssd1"},
+		},
+		"node1": {
+			{name: "local-c", path: "/mnt/disks/ssd0"},
+		},
+	}
+	if diff := cmp.Diff(expected, perNode, cmp.AllowUnexported(sourceVolume{})); diff != "" {
+		t.Errorf("unexpected node paths: %s", diff)
+	}
+	if diff := cmp.Diff([]string{"no-node"}, unmeasured); diff != "" {
+		t.Errorf("unexpected unmeasured volumes: %s", diff)
+	}
+}
+
+func Test_localVolumesByPath(t *testing.T) {
+	paths, byPath := localVolumesByPath([]sourceVolume{
+		{name: "local-b", path: "/mnt/disks/ssd1"},
+		{name: "local-a", path: "/mnt/disks/ssd0"},
+		{name: "local-c", path: "/mnt/disks/ssd1"},
+	})
+
+	if diff := cmp.Diff([]string{"/mnt/disks/ssd0", "/mnt/disks/ssd1"}, paths); diff != "" {
+		t.Errorf("unexpected paths: %s", diff)
+	}
+
+	expected := map[string][]string{
+		"/mnt/disks/ssd0": {"local-a"},
+		"/mnt/disks/ssd1": {"local-b", "local-c"},
+	}
+	if diff := cmp.Diff(expected, byPath); diff != "" {
+		t.Errorf("unexpected volumes by path: %s", diff)
+	}
+}
+
+func TestLargestLocalVolumes(t *testing.T) {
+	space := map[string]map[string]OpenEBSVolume{
+		"node0": {
+			"local-a": {Free: 100, Used: 10},
+			"local-b": {Free: 300, Used: 20},
+		},
+		"node1": {
+			"local-c": {Free: 50, Used: 5},
+		},
+		"node2": {},
+	}
+
+	expected := map[string]OpenEBSVolume{
+		"node0": {Free: 300, Used: 20},
+		"node1": {Free: 50, Used: 5},
+		"node2": {},
+	}
+	if diff := cmp.Diff(expected, LargestLocalVolumes(space)); diff != "" {
+		t.Errorf("unexpected largest volumes: %s", diff)
+	}
+}
+
+func TestLocalVolumesSpaceWrongProvisioner(t *testing.T) {
+	kcli := fake.NewSimpleClientset(&storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: "openebs"},
+		Provisioner: OpenEBSLocalProvisioner,
+	})
+	getter := OpenEBSFreeDiskSpaceGetter{
+		kcli: kcli,
+		opts: OpenEBSOptions{
+			DstSC: "openebs",
+		},
+		log: log.New(os.Stdout, "", 0),
+	}
+
+	_, err := getter.LocalVolumesSpace(context.Background())
+	if err == nil || !strings.Contains(err.Error(), LocalVolumeProvisioner) {
+		t.Errorf("expected provisioner error, received %v", err)
+	}
+}
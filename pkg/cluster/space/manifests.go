@@ -0,0 +1,102 @@
+package clusterspace
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// NodeManifests holds the objects that would be created in a node to measure its free space.
+type NodeManifests struct {
+	Node string
+	PVC  *corev1.PersistentVolumeClaim
+	Job  *batchv1.Job
+}
+
+// buildNodeManifests returns the temporary pvc and the df job that would be created for each of the
+// provided nodes. the type meta is populated so the objects can be applied as they are.
+func (o *OpenEBSFreeDiskSpaceGetter) buildNodeManifests(ctx context.Context, nodes []string, basePath string) []NodeManifests {
+	var manifests []NodeManifests
+	for _, node := range nodes {
+		pvc := o.buildTmpPVC(node)
+		pvc.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "PersistentVolumeClaim"}
+
+		job := o.buildJob(ctx, node, basePath, pvc.Name)
+		job.TypeMeta = metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"}
+
+		manifests = append(manifests, NodeManifests{Node: node, PVC: pvc, Job: job})
+	}
+	return manifests
+}
+
+// Manifests returns the temporary pvcs and df jobs OpenEBSVolumes would create, without creating
+// anything in the cluster. the nodes and the storage class base path are still read from the
+// cluster. only the nodes measured with df jobs are returned, skipped nodes are available through
+// SkippedNodes.
+func (o *OpenEBSFreeDiskSpaceGetter) Manifests(ctx context.Context) ([]NodeManifests, error) {
+	nodes, err := o.listNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	basePath, provisioner, err := o.basePath(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read openebs base path: %w", err)
+	}
+
+	if err := o.validateProvisioner(provisioner); err != nil {
+		return nil, err
+	}
+
+	o.skipped = map[string]string{}
+	var names []string
+	for _, node := range nodes.Items {
+		if measurement, reason := o.measurementFor(node); measurement != measureDF {
+			if reason == "" {
+				reason = "not measured with a df job"
+			}
+			o.skipped[node.Name] = reason
+			continue
+		}
+		names = append(names, node.Name)
+	}
+	return o.buildNodeManifests(ctx, names, basePath), nil
+}
+
+// WriteManifests writes the provided manifests as yaml into dir, two files per node named after
+// the node: <node>-pvc.yaml and <node>-job.yaml. dir is created if it does not exist. returns the
+// paths of the written files.
+func WriteManifests(dir string, manifests []NodeManifests) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	var written []string
+	for _, manifest := range manifests {
+		for _, file := range []struct {
+			suffix string
+			obj    interface{}
+		}{
+			{suffix: "pvc", obj: manifest.PVC},
+			{suffix: "job", obj: manifest.Job},
+		} {
+			data, err := yaml.Marshal(file.obj)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode node %s %s: %w", manifest.Node, file.suffix, err)
+			}
+
+			path := filepath.Join(dir, fmt.Sprintf("%s-%s.yaml", manifest.Node, file.suffix))
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				return nil, fmt.Errorf("failed to write %s: %w", path, err)
+			}
+			written = append(written, path)
+		}
+	}
+	return written, nil
+}
@@ -0,0 +1,89 @@
+package clusterspace
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestWriteManifests(t *testing.T) {
+	getter := OpenEBSFreeDiskSpaceGetter{
+		opts: OpenEBSOptions{
+			Image:     "myimage:latest",
+			DstSC:     "openebs",
+			Namespace: "kurl",
+		},
+		runID: "abcd1234",
+	}
+
+	nodes := []string{"node0", "node1", "node2"}
+	manifests := getter.buildNodeManifests(context.Background(), nodes, "/var/local/openebs")
+
+	dir := filepath.Join(t.TempDir(), "manifests")
+	written, err := WriteManifests(dir, manifests)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var expected []string
+	for _, node := range nodes {
+		expected = append(expected, filepath.Join(dir, node+"-pvc.yaml"), filepath.Join(dir, node+"-job.yaml"))
+	}
+	if diff := cmp.Diff(expected, written); diff != "" {
+		t.Fatalf("unexpected written files: %s", diff)
+	}
+
+	for _, node := range nodes {
+		data, err := os.ReadFile(filepath.Join(dir, node+"-pvc.yaml"))
+		if err != nil {
+			t.Fatalf("failed to read pvc manifest: %s", err)
+		}
+
+		var pvc corev1.PersistentVolumeClaim
+		if err := yaml.UnmarshalStrict(data, &pvc); err != nil {
+			t.Fatalf("invalid pvc manifest for node %s: %s", node, err)
+		}
+		if pvc.Kind != "PersistentVolumeClaim" || pvc.APIVersion != "v1" {
+			t.Errorf("unexpected pvc type %s/%s", pvc.APIVersion, pvc.Kind)
+		}
+		if pvc.Namespace != "kurl" || pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName != "openebs" {
+			t.Errorf("unexpected pvc for node %s: %+v", node, pvc)
+		}
+		if pvc.Labels[OpenEBSRunIDLabel] != "abcd1234" {
+			t.Errorf("expected pvc to carry the run id label, labels: %v", pvc.Labels)
+		}
+
+		if data, err = os.ReadFile(filepath.Join(dir, node+"-job.yaml")); err != nil {
+			t.Fatalf("failed to read job manifest: %s", err)
+		}
+
+		var job batchv1.Job
+		if err := yaml.UnmarshalStrict(data, &job); err != nil {
+			t.Fatalf("invalid job manifest for node %s: %s", node, err)
+		}
+		if job.Kind != "Job" || job.APIVersion != "batch/v1" {
+			t.Errorf("unexpected job type %s/%s", job.APIVersion, job.Kind)
+		}
+
+		affinity := job.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+		if values := affinity.NodeSelectorTerms[0].MatchExpressions[0].Values; len(values) != 1 || values[0] != node {
+			t.Errorf("expected job to be bound to node %s, bound to %v", node, values)
+		}
+
+		var claims []string
+		for _, vol := range job.Spec.Template.Spec.Volumes {
+			if vol.PersistentVolumeClaim != nil {
+				claims = append(claims, vol.PersistentVolumeClaim.ClaimName)
+			}
+		}
+		if diff := cmp.Diff([]string{pvc.Name}, claims); diff != "" {
+			t.Errorf("expected job to mount the node pvc: %s", diff)
+		}
+	}
+}
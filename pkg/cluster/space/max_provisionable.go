@@ -0,0 +1,62 @@
+package clusterspace
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// ProvisionableCapacity holds the size of the largest volume that could be provisioned in each node.
+// as volumes can't span nodes the largest volume provisionable in the cluster is the one in the node
+// with the most provisionable space, Node and Largest hold it.
+type ProvisionableCapacity struct {
+	PerNode map[string]int64 `json:"perNode"`
+	Node    string           `json:"node"`
+	Largest int64            `json:"largest"`
+}
+
+// MaxProvisionable calculates, for each node, the largest volume that could be provisioned: the
+// free space minus the space kept free by the reserve calculator. a nil calculator reserves nothing.
+// nodes whose reserve is bigger than the free space can't provision anything. on ties the first node
+// in alphabetical order is reported as the cluster wide largest.
+func MaxProvisionable(volumes map[string]OpenEBSVolume, reserve ReserveCalculator) ProvisionableCapacity {
+	if reserve == nil {
+		reserve = AbsoluteReserve(0)
+	}
+
+	var nodes []string
+	for node := range volumes {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	capacity := ProvisionableCapacity{PerNode: map[string]int64{}}
+	for _, node := range nodes {
+		size := volumes[node].Free - reserve(volumes[node])
+		if size < 0 {
+			size = 0
+		}
+
+		capacity.PerNode[node] = size
+		if capacity.Node == "" || size > capacity.Largest {
+			capacity.Node = node
+			capacity.Largest = size
+		}
+	}
+	return capacity
+}
+
+// MaxProvisionable measures the destination storage class in all nodes and returns the largest
+// volume that could be provisioned in each of them, the storage class reserve policy applied.
+func (o *OpenEBSDiskSpaceValidator) MaxProvisionable(ctx context.Context) (ProvisionableCapacity, error) {
+	volumes, err := o.freeSpaceGetter.OpenEBSVolumes(ctx)
+	if err != nil {
+		return ProvisionableCapacity{}, fmt.Errorf("failed to calculate available disk space per node: %w", err)
+	}
+
+	reserve := o.reserve
+	if reserve == nil {
+		reserve = RootDiskReserve
+	}
+	return MaxProvisionable(volumes, reserve), nil
+}
@@ -0,0 +1,79 @@
+package clusterspace
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMaxProvisionable(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		volumes  map[string]OpenEBSVolume
+		reserve  ReserveCalculator
+		expected ProvisionableCapacity
+	}{
+		{
+			name:     "should return an empty capacity without volumes",
+			expected: ProvisionableCapacity{PerNode: map[string]int64{}},
+		},
+		{
+			name: "should use the free space without a reserve",
+			volumes: map[string]OpenEBSVolume{
+				"node0": {Free: 100, Used: 100, RootVolume: true},
+				"node1": {Free: 50, Used: 10},
+			},
+			expected: ProvisionableCapacity{
+				PerNode: map[string]int64{"node0": 100, "node1": 50},
+				Node:    "node0",
+				Largest: 100,
+			},
+		},
+		{
+			name: "should only reserve space in root volumes with the root disk reserve",
+			volumes: map[string]OpenEBSVolume{
+				"node0": {Free: 100, Used: 100, RootVolume: true},
+				"node1": {Free: 80, Used: 20},
+			},
+			reserve: RootDiskReserve,
+			expected: ProvisionableCapacity{
+				PerNode: map[string]int64{"node0": 70, "node1": 80},
+				Node:    "node1",
+				Largest: 80,
+			},
+		},
+		{
+			name: "should not return negative sizes",
+			volumes: map[string]OpenEBSVolume{
+				"node0": {Free: 10, Used: 100},
+				"node1": {Free: 200, Used: 100},
+			},
+			reserve: AbsoluteReserve(50),
+			expected: ProvisionableCapacity{
+				PerNode: map[string]int64{"node0": 0, "node1": 150},
+				Node:    "node1",
+				Largest: 150,
+			},
+		},
+		{
+			name: "should report the first node in alphabetical order on ties",
+			volumes: map[string]OpenEBSVolume{
+				"node1": {Free: 100, Used: 100},
+				"node0": {Free: 100, Used: 100},
+			},
+			reserve: PercentReserve(10),
+			expected: ProvisionableCapacity{
+				PerNode: map[string]int64{"node0": 80, "node1": 80},
+				Node:    "node0",
+				Largest: 80,
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			capacity := MaxProvisionable(tt.volumes, tt.reserve)
+			if diff := cmp.Diff(tt.expected, capacity); diff != "" {
+				t.Errorf("unexpected capacity: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package clusterspace
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// outcomes of a node measurement, used as the outcome label of the node duration histogram.
+const (
+	metricsOutcomeMeasured = "measured"
+	metricsOutcomeFailed   = "failed"
+)
+
+// outcomes of a disk space validator run, used as the outcome label of the runs counter.
+const (
+	metricsOutcomeEnoughSpace    = "enough_space"
+	metricsOutcomeNotEnoughSpace = "not_enough_space"
+	metricsOutcomeError          = "error"
+)
+
+// spaceCheckMetrics holds the prometheus metrics updated as nodes are checked, see
+// OpenEBSOptions.Metrics. a nil *spaceCheckMetrics is valid and updates nothing.
+type spaceCheckMetrics struct {
+	duration *prometheus.HistogramVec
+	failures *prometheus.CounterVec
+	free     *prometheus.GaugeVec
+	runs     *prometheus.CounterVec
+}
+
+// newSpaceCheckMetrics creates the space check metrics and registers them in the provided registry.
+// metrics already registered by a previous getter are reused so several getters can share the same
+// registry. nil is returned if no registry has been provided.
+func newSpaceCheckMetrics(reg prometheus.Registerer) (*spaceCheckMetrics, error) {
+	if reg == nil {
+		return nil, nil
+	}
+
+	duration, err := registerCollector(reg, prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "kurl_spacecheck_node_duration_seconds",
+			Help:    "Time spent measuring the free space of a node.",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 10),
+		},
+		[]string{"storage_class", "outcome"},
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	failures, err := registerCollector(reg, prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kurl_spacecheck_failures_total",
+			Help: "Number of nodes whose free space could not be measured.",
+		},
+		[]string{"storage_class", "node"},
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	free, err := registerCollector(reg, prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kurl_spacecheck_node_free_bytes",
+			Help: "Free bytes last measured in the storage class base path of a node.",
+		},
+		[]string{"storage_class", "node"},
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	runs, err := registerCollector(reg, prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kurl_spacecheck_runs_total",
+			Help: "Number of disk space validator runs by outcome.",
+		},
+		[]string{"source_storage_class", "storage_class", "outcome"},
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	return &spaceCheckMetrics{
+		duration: duration.(*prometheus.HistogramVec),
+		failures: failures.(*prometheus.CounterVec),
+		free:     free.(*prometheus.GaugeVec),
+		runs:     runs.(*prometheus.CounterVec),
+	}, nil
+}
+
+// registerCollector registers the provided collector, returning the collector already registered
+// if an equivalent one exists.
+func registerCollector(reg prometheus.Registerer, collector prometheus.Collector) (prometheus.Collector, error) {
+	err := reg.Register(collector)
+	if err == nil {
+		return collector, nil
+	}
+
+	var registered prometheus.AlreadyRegisteredError
+	if errors.As(err, &registered) {
+		return registered.ExistingCollector, nil
+	}
+	return nil, fmt.Errorf("failed to register metric: %w", err)
+}
+
+// observeNode updates the metrics with the measurement of a node: how long it took and either the
+// free bytes or the failure.
+func (m *spaceCheckMetrics) observeNode(scname, node string, elapsed time.Duration, vol OpenEBSVolume, err error) {
+	if m == nil {
+		return
+	}
+
+	if err != nil {
+		m.duration.WithLabelValues(scname, metricsOutcomeFailed).Observe(elapsed.Seconds())
+		m.failures.WithLabelValues(scname, node).Inc()
+		return
+	}
+	m.duration.WithLabelValues(scname, metricsOutcomeMeasured).Observe(elapsed.Seconds())
+	m.free.WithLabelValues(scname, node).Set(float64(vol.Free))
+}
+
+// observeRun counts a disk space validator run. runs that failed count as errors, runs that found
+// nodes without enough space count as such.
+func (m *spaceCheckMetrics) observeRun(srcSC, dstSC string, results []NodeSpaceResult, err error) {
+	if m == nil {
+		return
+	}
+
+	outcome := metricsOutcomeEnoughSpace
+	if err != nil {
+		outcome = metricsOutcomeError
+	} else {
+		for _, result := range results {
+			if result.Status == NodeSpaceFail {
+				outcome = metricsOutcomeNotEnoughSpace
+				break
+			}
+		}
+	}
+	m.runs.WithLabelValues(srcSC, dstSC, outcome).Inc()
+}
@@ -0,0 +1,117 @@
+package clusterspace
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// gatherMetrics returns the value of every sample in the registry indexed by metric name and label
+// values, e.g. "kurl_spacecheck_node_free_bytes{openebs,node0}". histograms are reported through
+// their sample count.
+func gatherMetrics(t *testing.T, reg *prometheus.Registry) map[string]float64 {
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %s", err)
+	}
+
+	result := map[string]float64{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			var values []string
+			for _, label := range metric.GetLabel() {
+				values = append(values, label.GetValue())
+			}
+			key := fmt.Sprintf("%s{%s}", family.GetName(), strings.Join(values, ","))
+			switch {
+			case metric.GetHistogram() != nil:
+				result[key] = float64(metric.GetHistogram().GetSampleCount())
+			case metric.GetCounter() != nil:
+				result[key] = metric.GetCounter().GetValue()
+			case metric.GetGauge() != nil:
+				result[key] = metric.GetGauge().GetValue()
+			}
+		}
+	}
+	return result
+}
+
+func Test_spaceCheckMetrics(t *testing.T) {
+	// no registry, nothing is collected nor panics.
+	metrics, err := newSpaceCheckMetrics(nil)
+	if err != nil || metrics != nil {
+		t.Fatalf("expected no metrics without a registry, received %v (%v)", metrics, err)
+	}
+	metrics.observeNode("openebs", "node0", time.Second, OpenEBSVolume{}, nil)
+	metrics.observeRun("default", "openebs", nil, nil)
+
+	reg := prometheus.NewRegistry()
+	metrics, err = newSpaceCheckMetrics(reg)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// metrics already registered are reused.
+	shared, err := newSpaceCheckMetrics(reg)
+	if err != nil {
+		t.Fatalf("unexpected error registering the metrics twice: %s", err)
+	}
+
+	metrics.observeNode("openebs", "node0", 2*time.Second, OpenEBSVolume{Free: 2048}, nil)
+	metrics.observeNode("openebs", "node1", time.Second, OpenEBSVolume{}, fmt.Errorf("job failed"))
+	shared.observeNode("openebs", "node1", time.Second, OpenEBSVolume{}, fmt.Errorf("job failed"))
+	metrics.observeRun("default", "openebs", []NodeSpaceResult{{NodeName: "node0", Status: NodeSpaceOK}}, nil)
+	metrics.observeRun("default", "openebs", []NodeSpaceResult{{NodeName: "node0", Status: NodeSpaceFail}}, nil)
+	metrics.observeRun("default", "openebs", nil, fmt.Errorf("failed to list nodes"))
+
+	expected := map[string]float64{
+		"kurl_spacecheck_node_duration_seconds{measured,openebs}":      1,
+		"kurl_spacecheck_node_duration_seconds{failed,openebs}":        2,
+		"kurl_spacecheck_failures_total{node1,openebs}":                2,
+		"kurl_spacecheck_node_free_bytes{node0,openebs}":               2048,
+		"kurl_spacecheck_runs_total{enough_space,default,openebs}":     1,
+		"kurl_spacecheck_runs_total{not_enough_space,default,openebs}": 1,
+		"kurl_spacecheck_runs_total{error,default,openebs}":            1,
+	}
+	if diff := cmp.Diff(expected, gatherMetrics(t, reg)); diff != "" {
+		t.Errorf("unexpected metrics: %s", diff)
+	}
+}
+
+func TestNewOpenEBSFreeDiskSpaceGetterMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	opts := OpenEBSOptions{
+		Log:     log.New(io.Discard, "", 0),
+		Image:   "myimage:latest",
+		DstSC:   "openebs",
+		Metrics: reg,
+	}
+
+	for i := 0; i < 2; i++ {
+		getter, err := NewOpenEBSFreeDiskSpaceGetterWithOptions(fake.NewSimpleClientset(), opts)
+		if err != nil {
+			t.Fatalf("unexpected error creating getter %d: %s", i, err)
+		}
+		if getter.metrics == nil {
+			t.Fatalf("expected getter %d to collect metrics", i)
+		}
+	}
+
+	// a registry already holding a different metric under the same name can't be used.
+	reg = prometheus.NewRegistry()
+	reg.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "kurl_spacecheck_failures_total",
+		Help: "Something else.",
+	}))
+	opts.Metrics = reg
+	if _, err := NewOpenEBSFreeDiskSpaceGetterWithOptions(fake.NewSimpleClientset(), opts); err == nil {
+		t.Errorf("expected error registering conflicting metrics, nil received instead")
+	}
+}
@@ -0,0 +1,280 @@
+package clusterspace
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// mountPointsDir is where the measured host paths are mounted inside the df container. each path
+// is mounted in a directory named after its position in the list of paths.
+const mountPointsDir = "/mounts"
+
+// mountPointsCommand returns the script executed by the df container when measuring the provided
+// number of host paths. a single df call reports all of them.
+func mountPointsCommand(count int) string {
+	args := make([]string, count)
+	for i := range args {
+		args[i] = fmt.Sprintf("%s/%d", mountPointsDir, i)
+	}
+	return fmt.Sprintf("df -B1 %s", strings.Join(args, " "))
+}
+
+// validateMountPoints verifies that the provided paths are absolute and not repeated.
+func validateMountPoints(paths []string) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("no paths provided")
+	}
+
+	seen := map[string]bool{}
+	for _, p := range paths {
+		if !strings.HasPrefix(p, "/") {
+			return fmt.Errorf("path %q is not absolute", p)
+		}
+		cleaned := path.Clean(p)
+		if seen[cleaned] {
+			return fmt.Errorf("path %q provided more than once", p)
+		}
+		seen[cleaned] = true
+	}
+	return nil
+}
+
+// hostPathMounts returns the volumes and read only volume mounts needed to mount the provided host
+// paths inside a container. each path is mounted in dir, in a directory named after its position
+// in the list of paths.
+func hostPathMounts(paths []string, dir string) ([]corev1.Volume, []corev1.VolumeMount) {
+	typeDir := corev1.HostPathDirectory
+	var volumes []corev1.Volume
+	var mounts []corev1.VolumeMount
+	for i, p := range paths {
+		name := fmt.Sprintf("mount-%d", i)
+		volumes = append(volumes, corev1.Volume{
+			Name: name,
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Type: &typeDir,
+					Path: p,
+				},
+			},
+		})
+		mounts = append(mounts, corev1.VolumeMount{
+			MountPath: fmt.Sprintf("%s/%d", dir, i),
+			Name:      name,
+			ReadOnly:  true,
+		})
+	}
+	return volumes, mounts
+}
+
+// buildMountPointsJob returns a job that measures the provided host paths in the node. the job is
+// similar to the one built by buildJob but it mounts the provided paths instead of the openebs base
+// path and does not mount any temporary pvc as no storage class is involved.
+func (o *OpenEBSFreeDiskSpaceGetter) buildMountPointsJob(node string, paths []string) *batchv1.Job {
+	typeFile := corev1.HostPathFile
+	volumes := []corev1.Volume{
+		{
+			Name: "fstab",
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Type: &typeFile,
+					Path: "/etc/fstab",
+				},
+			},
+		},
+	}
+
+	pathVolumes, mounts := hostPathMounts(paths, mountPointsDir)
+	volumes = append(volumes, pathVolumes...)
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        jobName(node),
+			Namespace:   o.opts.Namespace,
+			Labels:      o.jobLabels(node),
+			Annotations: o.jobAnnotations(),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          ptr.To(int32(1)),
+			ActiveDeadlineSeconds: activeDeadlineSeconds(o.opts.Job.Timeout),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      o.jobLabels(node),
+					Annotations: o.jobAnnotations(),
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:    corev1.RestartPolicyNever,
+					Tolerations:      o.tolerations,
+					Affinity:         nodeAffinity(node),
+					ImagePullSecrets: o.imagePullSecrets(),
+					Volumes:          volumes,
+					Containers: []corev1.Container{
+						{
+							Name:         "df",
+							Image:        o.opts.Image,
+							Command:      []string{"/bin/sh", "-c"},
+							Args:         []string{encodeOutputCommand(mountPointsCommand(len(paths)))},
+							VolumeMounts: mounts,
+						},
+						{
+							Name:    "fstab",
+							Image:   o.opts.Image,
+							Command: []string{"cat"},
+							Args:    []string{"/node/etc/fstab"},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									MountPath: "/node/etc/fstab",
+									Name:      "fstab",
+									ReadOnly:  true,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// parseMountPointsOutput parses the output of the mountPointsCommand and returns the free and used
+// space for each of the provided paths, indexed by path. each path is located in the output by the
+// mount point it has been given inside the container:
+//
+// Filesystem       1B-blocks        Used   Available Use% Mounted on
+// /dev/sda2      63087357952 52521754624  7327760384  88% /mounts/0
+// /dev/sdb1     105087357952  1521754624 98327760384   2% /mounts/1
+func parseMountPointsOutput(output []byte, paths []string) (map[string]OpenEBSVolume, error) {
+	result := map[string]OpenEBSVolume{}
+	scanner := bufio.NewScanner(bytes.NewBuffer(output))
+	for scanner.Scan() {
+		words := strings.Fields(scanner.Text())
+		if len(words) < 5 {
+			continue
+		}
+
+		mount := words[len(words)-1]
+		if !strings.HasPrefix(mount, mountPointsDir+"/") {
+			continue
+		}
+
+		idx, err := strconv.Atoi(strings.TrimPrefix(mount, mountPointsDir+"/"))
+		if err != nil || idx < 0 || idx >= len(paths) {
+			return nil, fmt.Errorf("unexpected mount point %q in df output", mount)
+		}
+
+		free, err := strconv.ParseInt(words[len(words)-3], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %q as available space: %w", words[len(words)-3], err)
+		}
+
+		used, err := strconv.ParseInt(words[len(words)-4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %q as used space: %w", words[len(words)-4], err)
+		}
+
+		result[paths[idx]] = OpenEBSVolume{Free: free, Used: used}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to process container log: %w", err)
+	}
+
+	for _, p := range paths {
+		if _, ok := result[p]; !ok {
+			return nil, fmt.Errorf("failed to locate free space info for %s in pod log: %s", p, string(output))
+		}
+	}
+	return result, nil
+}
+
+// MountPointsSpace measures the free and used space of the provided host paths in all nodes of the
+// cluster, regardless of any storage class. a single df job is executed in each node reporting all
+// the paths. the result is indexed by node name and then by path. paths are flagged as root volume
+// if they are not part of any other mount point listed in the node fstab. nodes that can't be
+// measured with df (e.g. windows nodes) are skipped, see SkippedNodes.
+func (o *OpenEBSFreeDiskSpaceGetter) MountPointsSpace(ctx context.Context, paths []string) (map[string]map[string]OpenEBSVolume, error) {
+	if err := validateMountPoints(paths); err != nil {
+		return nil, fmt.Errorf("invalid paths: %w", err)
+	}
+
+	nodes, err := o.listNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	o.skipped = map[string]string{}
+	result := map[string]map[string]OpenEBSVolume{}
+	for _, node := range nodes.Items {
+		o.log.Printf("Analyzing free space of %s on node %s", strings.Join(paths, ", "), node.Name)
+		if measurement, reason := o.measurementFor(node); measurement != measureDF {
+			if reason == "" {
+				reason = "only paths in linux nodes can be measured"
+			}
+			o.log.Printf("Skipping node %s: %s", node.Name, reason)
+			o.skipped[node.Name] = reason
+			continue
+		}
+
+		if err := o.nodeIsSchedulable(node); err != nil {
+			return nil, fmt.Errorf("failed to assess node %s: %w", node.Name, err)
+		}
+
+		volumes, err := o.nodeMountPoints(ctx, node.Name, paths)
+		if err != nil {
+			return nil, err
+		}
+		result[node.Name] = volumes
+	}
+	return result, nil
+}
+
+// nodeMountPoints runs a df job in the node measuring the provided host paths and returns their free
+// and used space indexed by path. paths are flagged as root volume if they are not part of any other
+// mount point listed in the node fstab.
+func (o *OpenEBSFreeDiskSpaceGetter) nodeMountPoints(ctx context.Context, node string, paths []string) (map[string]OpenEBSVolume, error) {
+	job := o.buildMountPointsJob(node, paths)
+	out, status, err := o.runJob(ctx, job)
+	if err != nil {
+		o.logContainersState(out, status)
+		return nil, fmt.Errorf(
+			"failed to run job %s/%s on node %s: %w", job.Namespace, job.Name, node, err,
+		)
+	}
+
+	dfOutput, err := decodeOutput(out["df"])
+	if err != nil {
+		o.logContainersState(out, status)
+		return nil, fmt.Errorf("failed to read node %s df output: %w", node, err)
+	}
+
+	volumes, err := parseMountPointsOutput(dfOutput, paths)
+	if err != nil {
+		o.logContainersState(out, status)
+		return nil, fmt.Errorf("failed to parse node %s df output: %w", node, err)
+	}
+
+	fstab, err := o.parseFstabEntries(out["fstab"])
+	if err != nil {
+		o.logContainersState(out, status)
+		return nil, fmt.Errorf("failed to parse node %s fstab output: %w", node, err)
+	}
+
+	for p, vol := range volumes {
+		backing := resolveBindMounts(fstab, p)
+		if backing != p {
+			vol.BindSource = backing
+		}
+		vol.RootVolume = isRootVolume(fstab, backing)
+		volumes[p] = vol
+	}
+	return volumes, nil
+}
@@ -0,0 +1,150 @@
+package clusterspace
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_parseMountPointsOutput(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		paths    []string
+		content  string
+		expected map[string]OpenEBSVolume
+		err      string
+	}{
+		{
+			name:  "should parse all the paths",
+			paths: []string{"/var/lib/kurl", "/opt/data"},
+			content: `Filesystem        1B-blocks        Used   Available Use% Mounted on
+/dev/sda2       63087357952 52521754624  7327760384  88% /mounts/0
+/dev/sdb1      105087357952  1521754624 98327760384   2% /mounts/1
+`,
+			expected: map[string]OpenEBSVolume{
+				"/var/lib/kurl": {Free: 7327760384, Used: 52521754624},
+				"/opt/data":     {Free: 98327760384, Used: 1521754624},
+			},
+		},
+		{
+			name:  "should parse paths listed out of order and wrapped filesystem names",
+			paths: []string{"/var/lib/kurl", "/opt/data"},
+			content: `Filesystem        1B-blocks        Used   Available Use% Mounted on
+/dev/mapper/a-very-long-volume-group-name
+               105087357952  1521754624 98327760384   2% /mounts/1
+/dev/sda2       63087357952 52521754624  7327760384  88% /mounts/0
+`,
+			expected: map[string]OpenEBSVolume{
+				"/var/lib/kurl": {Free: 7327760384, Used: 52521754624},
+				"/opt/data":     {Free: 98327760384, Used: 1521754624},
+			},
+		},
+		{
+			name:  "should fail when a path is missing",
+			paths: []string{"/var/lib/kurl", "/opt/data"},
+			content: `Filesystem        1B-blocks        Used   Available Use% Mounted on
+/dev/sda2       63087357952 52521754624  7327760384  88% /mounts/0
+`,
+			err: "failed to locate free space info for /opt/data",
+		},
+		{
+			name:  "should fail on unexpected mount points",
+			paths: []string{"/var/lib/kurl"},
+			content: `Filesystem        1B-blocks        Used   Available Use% Mounted on
+/dev/sda2       63087357952 52521754624  7327760384  88% /mounts/3
+`,
+			err: `unexpected mount point "/mounts/3"`,
+		},
+		{
+			name:  "should fail on invalid numbers",
+			paths: []string{"/var/lib/kurl"},
+			content: `Filesystem        1B-blocks        Used   Available Use% Mounted on
+/dev/sda2       63087357952 52521754624  abc  88% /mounts/0
+`,
+			err: `failed to parse "abc" as available space`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseMountPointsOutput([]byte(tt.content), tt.paths)
+			if err != nil {
+				if len(tt.err) == 0 {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expecting %q, %q received instead", tt.err, err)
+				}
+				return
+			}
+
+			if len(tt.err) > 0 {
+				t.Errorf("expecting error %q, nil received instead", tt.err)
+			}
+			if diff := cmp.Diff(tt.expected, result); diff != "" {
+				t.Errorf("unexpected result: %s", diff)
+			}
+		})
+	}
+}
+
+func Test_validateMountPoints(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		paths []string
+		err   string
+	}{
+		{
+			name:  "should accept absolute paths",
+			paths: []string{"/var/lib/kurl", "/opt"},
+		},
+		{
+			name: "should fail without paths",
+			err:  "no paths provided",
+		},
+		{
+			name:  "should fail on relative paths",
+			paths: []string{"var/lib/kurl"},
+			err:   "not absolute",
+		},
+		{
+			name:  "should fail on repeated paths",
+			paths: []string{"/opt/data", "/opt/data/"},
+			err:   "provided more than once",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateMountPoints(tt.paths)
+			if err == nil && tt.err != "" {
+				t.Errorf("expecting error %q, nil received instead", tt.err)
+			} else if err != nil && (tt.err == "" || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("expecting %q, %q received instead", tt.err, err)
+			}
+		})
+	}
+}
+
+func Test_buildMountPointsJob(t *testing.T) {
+	getter := OpenEBSFreeDiskSpaceGetter{opts: OpenEBSOptions{Image: "myimage:latest", Namespace: "default"}}
+	job := getter.buildMountPointsJob("node0", []string{"/var/lib/kurl", "/opt/data"})
+
+	hostPaths := map[string]string{}
+	for _, vol := range job.Spec.Template.Spec.Volumes {
+		if vol.HostPath != nil {
+			hostPaths[vol.Name] = vol.HostPath.Path
+		}
+	}
+
+	mounts := map[string]string{}
+	df := job.Spec.Template.Spec.Containers[0]
+	for _, mount := range df.VolumeMounts {
+		mounts[mount.MountPath] = hostPaths[mount.Name]
+	}
+
+	expected := map[string]string{"/mounts/0": "/var/lib/kurl", "/mounts/1": "/opt/data"}
+	if diff := cmp.Diff(expected, mounts); diff != "" {
+		t.Errorf("unexpected mounts: %s", diff)
+	}
+
+	if !strings.Contains(df.Args[0], "df -B1 /mounts/0 /mounts/1") {
+		t.Errorf("unexpected df command: %s", df.Args[0])
+	}
+}
@@ -0,0 +1,150 @@
+package clusterspace
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/prometheus/common/expfmt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// defaultNodeExporterPort is the port node-exporter listens on by default.
+	defaultNodeExporterPort = "9100"
+	// nodeFilesystemAvailMetric holds the space available to non root users per mount point.
+	nodeFilesystemAvailMetric = "node_filesystem_avail_bytes"
+	// nodeFilesystemSizeMetric holds the total size of the filesystem per mount point.
+	nodeFilesystemSizeMetric = "node_filesystem_size_bytes"
+)
+
+// NodeExporterSource points to a node-exporter like DaemonSet whose pods expose filesystem
+// metrics. pods are located by label selector and scraped through the API server pod proxy.
+type NodeExporterSource struct {
+	// Namespace is where the node-exporter pods live.
+	Namespace string
+	// Selector is the label selector matching the node-exporter pods (e.g. "app=node-exporter").
+	Selector string
+	// Port is the pod port serving the metrics. defaults to 9100.
+	Port string
+}
+
+// nodeExporterMount holds the filesystem metrics reported for a single mount point.
+type nodeExporterMount struct {
+	avail  float64
+	size   float64
+	fstype string
+}
+
+// nodeExporterVolumes scrapes the node-exporter pods and returns the openebs volume for each node
+// where a pod could be scraped. nodes whose pod isn't running or whose metrics can't be read or
+// parsed are left out so the caller can fall back to the df job for them.
+func (o *OpenEBSFreeDiskSpaceGetter) nodeExporterVolumes(ctx context.Context, basePath string) (map[string]OpenEBSVolume, error) {
+	pods, err := o.kcli.CoreV1().Pods(o.opts.NodeExporter.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: o.opts.NodeExporter.Selector,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list node exporter pods: %w", err)
+	}
+
+	port := o.opts.NodeExporter.Port
+	if port == "" {
+		port = defaultNodeExporterPort
+	}
+
+	result := map[string]OpenEBSVolume{}
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == "" || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+
+		data, err := o.kcli.CoreV1().Pods(pod.Namespace).ProxyGet("http", pod.Name, port, "metrics", nil).DoRaw(ctx)
+		if err != nil {
+			o.log.Printf("Failed to scrape node exporter pod %s/%s: %s", pod.Namespace, pod.Name, err)
+			continue
+		}
+
+		volume, err := o.parseNodeExporterMetrics(data, basePath)
+		if err != nil {
+			o.log.Printf("Failed to parse node exporter pod %s/%s metrics: %s", pod.Namespace, pod.Name, err)
+			continue
+		}
+		result[pod.Spec.NodeName] = volume
+	}
+	return result, nil
+}
+
+// parseNodeExporterMetrics parses a node-exporter metrics payload (prometheus text format) and returns
+// the volume holding basePath. the mount holding the base path is the longest mount point containing
+// it, as with df its filesystem type is reported as is, even if it does not back any real storage
+// (e.g. tmpfs), see OpenEBSVolume.UnreliableFilesystem. the used space is the filesystem size minus
+// the available space, thus it includes the blocks reserved for the root user.
+func (o *OpenEBSFreeDiskSpaceGetter) parseNodeExporterMetrics(data []byte, basePath string) (OpenEBSVolume, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(data))
+	if err != nil {
+		return OpenEBSVolume{}, fmt.Errorf("failed to parse metrics: %w", err)
+	}
+
+	mounts := map[string]*nodeExporterMount{}
+	for _, name := range []string{nodeFilesystemAvailMetric, nodeFilesystemSizeMetric} {
+		family, ok := families[name]
+		if !ok {
+			return OpenEBSVolume{}, fmt.Errorf("metric %s not found", name)
+		}
+
+		for _, metric := range family.GetMetric() {
+			var mountPoint, fstype string
+			for _, label := range metric.GetLabel() {
+				switch label.GetName() {
+				case "mountpoint":
+					mountPoint = label.GetValue()
+				case "fstype":
+					fstype = label.GetValue()
+				}
+			}
+
+			if mountPoint == "" {
+				continue
+			}
+
+			if _, ok := mounts[mountPoint]; !ok {
+				mounts[mountPoint] = &nodeExporterMount{fstype: fstype}
+			}
+
+			// payloads without TYPE comments carry untyped metrics.
+			value := metric.GetUntyped().GetValue()
+			if metric.GetGauge() != nil {
+				value = metric.GetGauge().GetValue()
+			}
+			if name == nodeFilesystemAvailMetric {
+				mounts[mountPoint].avail = value
+			} else {
+				mounts[mountPoint].size = value
+			}
+		}
+	}
+
+	var selected string
+	for mountPoint := range mounts {
+		if !pathHasPrefix(basePath, mountPoint) {
+			continue
+		}
+		if len(mountPoint) > len(selected) {
+			selected = mountPoint
+		}
+	}
+
+	if selected == "" {
+		return OpenEBSVolume{}, fmt.Errorf("failed to find the mount for %s in the node exporter metrics", basePath)
+	}
+
+	mount := mounts[selected]
+	return OpenEBSVolume{
+		Free:       int64(mount.avail),
+		Used:       int64(mount.size - mount.avail),
+		RootVolume: selected == "/",
+		FSType:     mount.fstype,
+	}, nil
+}
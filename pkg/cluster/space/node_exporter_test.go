@@ -0,0 +1,169 @@
+package clusterspace
+
+import (
+	"context"
+	"io"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const nodeExporterPayload = `# HELP node_filesystem_avail_bytes Filesystem space available to non-root users in bytes.
+# TYPE node_filesystem_avail_bytes gauge
+node_filesystem_avail_bytes{device="/dev/sda1",fstype="ext4",mountpoint="/"} 1.2345678848e+10
+node_filesystem_avail_bytes{device="/dev/sdb1",fstype="xfs",mountpoint="/var/openebs"} 5.36870912e+10
+node_filesystem_avail_bytes{device="/dev/sdc1",fstype="xfs",mountpoint="/var/openebs-old"} 1024
+node_filesystem_avail_bytes{device="tmpfs",fstype="tmpfs",mountpoint="/var/openebs/local"} 1.048576e+06
+node_filesystem_avail_bytes{device="tmpfs",fstype="tmpfs",mountpoint="/run"} 8.23570432e+08
+# HELP node_filesystem_size_bytes Filesystem size in bytes.
+# TYPE node_filesystem_size_bytes gauge
+node_filesystem_size_bytes{device="/dev/sda1",fstype="ext4",mountpoint="/"} 2.0957446144e+10
+node_filesystem_size_bytes{device="/dev/sdb1",fstype="xfs",mountpoint="/var/openebs"} 1.073741824e+11
+node_filesystem_size_bytes{device="/dev/sdc1",fstype="xfs",mountpoint="/var/openebs-old"} 2048
+node_filesystem_size_bytes{device="tmpfs",fstype="tmpfs",mountpoint="/var/openebs/local"} 1.048576e+06
+node_filesystem_size_bytes{device="tmpfs",fstype="tmpfs",mountpoint="/run"} 8.23570432e+08
+# HELP node_load1 1m load average.
+# TYPE node_load1 gauge
+node_load1 0.42
+`
+
+func Test_parseNodeExporterMetrics(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		content  string
+		basePath string
+		err      string
+		expected OpenEBSVolume
+	}{
+		{
+			name:     "should use the longest mount holding the base path",
+			content:  nodeExporterPayload,
+			basePath: "/var/openebs/data",
+			expected: OpenEBSVolume{
+				Free:   53687091200,
+				Used:   53687091200,
+				FSType: "xfs",
+			},
+		},
+		{
+			name:     "should use the exact mount even if it does not back real storage",
+			content:  nodeExporterPayload,
+			basePath: "/var/openebs/local",
+			expected: OpenEBSVolume{
+				Free:   1048576,
+				Used:   0,
+				FSType: "tmpfs",
+			},
+		},
+		{
+			name:     "should flag volumes living in the root filesystem",
+			content:  nodeExporterPayload,
+			basePath: "/opt/openebs/local",
+			expected: OpenEBSVolume{
+				Free:       12345678848,
+				Used:       8611767296,
+				RootVolume: true,
+				FSType:     "ext4",
+			},
+		},
+		{
+			name:     "should fail when filesystem metrics are missing",
+			content:  "# TYPE node_load1 gauge\nnode_load1 0.42\n",
+			basePath: "/var/openebs/local",
+			err:      "metric node_filesystem_avail_bytes not found",
+		},
+		{
+			name: "should fail when no mount holds the base path",
+			content: `node_filesystem_avail_bytes{fstype="xfs",mountpoint="/var/openebs"} 100
+node_filesystem_size_bytes{fstype="xfs",mountpoint="/var/openebs"} 200
+`,
+			basePath: "/opt/openebs/local",
+			err:      "failed to find the mount for /opt/openebs/local",
+		},
+		{
+			name:     "should fail with an invalid payload",
+			content:  "node_filesystem_avail_bytes{mountpoint=\"/\" 100\n",
+			basePath: "/var/openebs/local",
+			err:      "failed to parse metrics",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			getter := OpenEBSFreeDiskSpaceGetter{}
+			volume, err := getter.parseNodeExporterMetrics([]byte(tt.content), tt.basePath)
+			if err != nil {
+				if len(tt.err) == 0 {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expecting %q, %q received instead", tt.err, err)
+				}
+				return
+			}
+
+			if len(tt.err) > 0 {
+				t.Errorf("expecting error %q, nil received instead", tt.err)
+			}
+
+			if diff := cmp.Diff(tt.expected, volume); diff != "" {
+				t.Errorf("unexpected volume: %s", diff)
+			}
+		})
+	}
+}
+
+func Test_parseNodeExporterMetricsUnreliableFilesystem(t *testing.T) {
+	getter := OpenEBSFreeDiskSpaceGetter{}
+	volume, err := getter.parseNodeExporterMetrics([]byte(nodeExporterPayload), "/var/openebs/local")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if fstype := volume.UnreliableFilesystem(); fstype != "tmpfs" {
+		t.Errorf("expected base path in tmpfs to be flagged as unreliable, %q received", fstype)
+	}
+}
+
+func Test_measureNodeNodeExporterSelector(t *testing.T) {
+	selector, err := labels.Parse("kurl.sh/storage=true")
+	if err != nil {
+		t.Fatalf("unexpected error parsing selector: %s", err)
+	}
+
+	getter := OpenEBSFreeDiskSpaceGetter{
+		log: log.New(io.Discard, "", 0),
+		opts: OpenEBSOptions{
+			NodeSelector: selector,
+		},
+	}
+	scraped := map[string]OpenEBSVolume{
+		"node0": {Free: 1000, Used: 1000},
+		"node1": {Free: 2000, Used: 1000},
+	}
+
+	// node1 does not match the node selector so its scraped metrics must be ignored.
+	excluded := corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	vol, pvc, reason, err := getter.measureNode(context.Background(), excluded, "/var/openebs/local", scraped)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if reason == "" || pvc != nil || vol.Free != 0 {
+		t.Errorf("expected node1 to be skipped, received volume %+v and reason %q", vol, reason)
+	}
+
+	included := corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node0", Labels: map[string]string{"kurl.sh/storage": "true"}},
+	}
+	vol, _, reason, err = getter.measureNode(context.Background(), included, "/var/openebs/local", scraped)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if reason != "" {
+		t.Errorf("expected node0 to be measured, skipped: %s", reason)
+	}
+	if diff := cmp.Diff(scraped["node0"], vol); diff != "" {
+		t.Errorf("expected the scraped volume for node0: %s", diff)
+	}
+}
@@ -0,0 +1,66 @@
+package clusterspace
+
+import (
+	"context"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// NodeJobRunner runs jobs in the cluster nodes to inspect them beyond their free disk space, e.g.
+// to read their swap or to benchmark the etcd disk. jobs are configured as the ones run by
+// OpenEBSFreeDiskSpaceGetter (image, namespace, tolerations, pull secrets, labels, retries and
+// parallelism) but no storage class is involved, the storage class related options are ignored.
+type NodeJobRunner struct {
+	jobs *OpenEBSFreeDiskSpaceGetter
+}
+
+// NewNodeJobRunner returns a runner for jobs configured according to the provided options. the
+// image and the logger are mandatory.
+func NewNodeJobRunner(kcli kubernetes.Interface, opts OpenEBSOptions) (*NodeJobRunner, error) {
+	jobs, err := newOpenEBSFreeDiskSpaceGetter(kcli, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &NodeJobRunner{jobs: jobs}, nil
+}
+
+// SkippedNodes returns the nodes the last run did not run a job in, along with the reason.
+func (r *NodeJobRunner) SkippedNodes() map[string]string {
+	return r.jobs.SkippedNodes()
+}
+
+// runNodeJobs calls fn for every provided node able to run linux jobs, up to the configured
+// parallelism at the same time, and returns its results indexed by node name. the first failure
+// aborts the evaluation of the remaining nodes. the other nodes, e.g. windows nodes or nodes excluded
+// by the node selector, are recorded along with the reason in the runner skipped nodes.
+func runNodeJobs[T any](ctx context.Context, r *NodeJobRunner, nodes []corev1.Node, fn func(context.Context, string) (T, error)) (map[string]T, error) {
+	var mtx sync.Mutex
+	r.jobs.skipped = map[string]string{}
+	result := map[string]T{}
+	if err := r.jobs.forEachNode(ctx, nodes, func(ctx context.Context, node corev1.Node) error {
+		if measurement, reason := r.jobs.measurementFor(node); measurement != measureDF {
+			if reason == "" {
+				reason = "not measured with a df job"
+			}
+			mtx.Lock()
+			r.jobs.skipped[node.Name] = reason
+			mtx.Unlock()
+			return nil
+		}
+
+		res, err := fn(ctx, node.Name)
+		if err != nil {
+			return err
+		}
+
+		mtx.Lock()
+		defer mtx.Unlock()
+		result[node.Name] = res
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
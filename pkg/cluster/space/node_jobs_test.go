@@ -0,0 +1,25 @@
+package clusterspace
+
+import (
+	"io"
+	"log"
+	"testing"
+)
+
+func TestNewNodeJobRunner(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+
+	// test empty image
+	_, err := NewNodeJobRunner(nil, OpenEBSOptions{Log: logger})
+	if err == nil || err.Error() != "empty image" {
+		t.Errorf("expected failure creating object: %v", err)
+	}
+
+	// happy path, no storage class is required
+	runner, err := NewNodeJobRunner(nil, OpenEBSOptions{Log: logger, Image: "image"})
+	if err != nil {
+		t.Errorf("unexpected failure creating object: %v", err)
+	} else if len(runner.SkippedNodes()) != 0 {
+		t.Errorf("expected no skipped nodes, %v found", runner.SkippedNodes())
+	}
+}
@@ -0,0 +1,88 @@
+package clusterspace
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// NodeMeasurement is the result of measuring a single node: the volume parsed out of the job output
+// along with the raw output of each of the job containers, indexed by container name.
+type NodeMeasurement struct {
+	Node    string            `json:"node"`
+	Volume  OpenEBSVolume     `json:"volume"`
+	Outputs map[string][]byte `json:"outputs,omitempty"`
+}
+
+// MeasureNode runs the df job on the provided node only and returns its measurement, meant to debug a
+// node reporting unexpected numbers without evaluating the whole cluster. the local cache and the
+// node exporter are not used so the job always runs. fails if the node does not exist or if it would
+// be skipped by OpenEBSVolumes. the raw outputs are returned even if the measurement fails, the
+// temporary pvc is deleted before returning.
+func (o *OpenEBSFreeDiskSpaceGetter) MeasureNode(ctx context.Context, name string) (NodeMeasurement, error) {
+	result := NodeMeasurement{Node: name}
+
+	nodes, err := o.listNodes(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	var node *corev1.Node
+	for i := range nodes.Items {
+		if nodes.Items[i].Name == name {
+			node = &nodes.Items[i]
+			break
+		}
+	}
+	if node == nil {
+		return result, fmt.Errorf("node %s not found", name)
+	}
+
+	measurement, reason := o.measurementFor(*node)
+	if measurement == measureSkip {
+		return result, fmt.Errorf("node %s can't be measured: %s", name, reason)
+	}
+
+	basePath, provisioner, err := o.basePath(ctx)
+	if err != nil {
+		return result, fmt.Errorf("failed to read openebs base path: %w", err)
+	}
+
+	if err := o.validateProvisioner(provisioner); err != nil {
+		return result, err
+	}
+
+	if err := o.reapOrphans(ctx); err != nil {
+		return result, fmt.Errorf("failed to purge orphaned resources: %w", err)
+	}
+
+	cache := o.opts.Cache
+	o.opts.Cache = nil
+	defer func() {
+		o.opts.Cache = cache
+	}()
+
+	o.outputsMtx.Lock()
+	o.outputs = nil
+	o.outputsMtx.Unlock()
+
+	o.log.Printf("Analyzing free space on node %s", name)
+	started := o.nodeCheckStarted(*node)
+	vol, pvc, err := o.nodeVolume(ctx, *node, basePath, measurement)
+	o.nodeCheckFinished(*node, started, vol, err)
+	result.Outputs = o.JobOutputs()[name]
+
+	if pvc != nil {
+		o.log.Printf("Deleting temporary pvcs")
+		if err := o.deleteTmpPVCs([]*corev1.PersistentVolumeClaim{pvc}); err != nil {
+			o.log.Printf("Failed to delete tmp claims: %s", err)
+		}
+	}
+
+	if err != nil {
+		return result, fmt.Errorf("failed to measure node %s: %w", name, err)
+	}
+	result.Volume = vol
+	return result, nil
+}
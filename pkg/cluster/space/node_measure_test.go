@@ -0,0 +1,101 @@
+package clusterspace
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestMeasureNode(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		node string
+		err  string
+	}{
+		{
+			name: "should fail for nodes that do not exist",
+			node: "node9",
+			err:  "node node9 not found",
+		},
+		{
+			name: "should fail for nodes that would be skipped",
+			node: "win0",
+			err:  "node win0 can't be measured: windows nodes are only measured when a windows image is provided",
+		},
+		{
+			name: "should report the node whose job failed",
+			node: "node1",
+			err:  "failed to measure node node1",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			kcli := fake.NewSimpleClientset(
+				&storagev1.StorageClass{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "default",
+						Annotations: map[string]string{
+							"cas.openebs.io/config": "- name: BasePath\n  value: /var/local",
+						},
+					},
+					Provisioner: "openebs.io/local",
+				},
+				&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node0"}},
+				&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+				&corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{Name: "win0", Labels: map[string]string{corev1.LabelOSStable: "windows"}},
+				},
+			)
+
+			var jobs []string
+			kcli.PrependReactor("create", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				jobs = append(jobs, action.(k8stesting.CreateAction).GetObject().(metav1.Object).GetName())
+				return true, nil, fmt.Errorf("quota exceeded")
+			})
+
+			getter := OpenEBSFreeDiskSpaceGetter{
+				kcli: kcli,
+				log:  log.New(io.Discard, "", 0),
+				opts: OpenEBSOptions{
+					DstSC:     "default",
+					Image:     "myimage:latest",
+					Namespace: "default",
+					PVC: OpenEBSPVCOptions{
+						SkipPVWait: true,
+					},
+				},
+			}
+
+			_, err := getter.MeasureNode(context.Background(), tt.node)
+			if err == nil {
+				t.Fatalf("expected error %q, nil received instead", tt.err)
+			}
+			if !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected error to contain %q, received %q", tt.err, err)
+			}
+
+			for _, job := range jobs {
+				if !strings.Contains(job, tt.node) {
+					t.Errorf("expected a job only for node %s, job %s created", tt.node, job)
+				}
+			}
+
+			pvcs, err := kcli.CoreV1().PersistentVolumeClaims("default").List(context.Background(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("failed to list pvcs: %s", err)
+			}
+			if len(pvcs.Items) != 0 {
+				t.Errorf("expected the temporary pvc to be deleted, %d found", len(pvcs.Items))
+			}
+		})
+	}
+}
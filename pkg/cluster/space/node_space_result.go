@@ -0,0 +1,80 @@
+package clusterspace
+
+// NodeSpaceStatus classifies the free space found in a node against the space required in it.
+type NodeSpaceStatus string
+
+const (
+	// NodeSpaceOK means the node has the required space plus the grace band.
+	NodeSpaceOK NodeSpaceStatus = "OK"
+	// NodeSpaceWarn means the node has the required space but it falls within the grace band,
+	// i.e. the node is marginal.
+	NodeSpaceWarn NodeSpaceStatus = "WARN"
+	// NodeSpaceFail means the node does not have the required space.
+	NodeSpaceFail NodeSpaceStatus = "FAIL"
+	// NodeSpaceUnknown means the node free space could not be measured.
+	NodeSpaceUnknown NodeSpaceStatus = "UNKNOWN"
+	// NodeSpaceSkipped means the node has not been measured on purpose, e.g. it does not match the
+	// node selector, and does not take part in the evaluation.
+	NodeSpaceSkipped NodeSpaceStatus = "SKIPPED"
+)
+
+// NodeSpaceResult holds the outcome of the free space evaluation for a single node. RequiredBytes is
+// the space reserved for the volumes to be placed in the node and HasEnough tells if the node is
+// capable of holding them, i.e. the status is NodeSpaceOK or NodeSpaceWarn. UsedBytes and RootVolume
+// describe the measured volume and are only set when known. Err is only set when the node could not
+// be measured, the status is NodeSpaceUnknown then. SkipReason is only set for skipped nodes.
+type NodeSpaceResult struct {
+	NodeName      string
+	FreeBytes     int64
+	UsedBytes     int64
+	RequiredBytes int64
+	RootVolume    bool
+	HasEnough     bool
+	Status        NodeSpaceStatus
+	SkipReason    string
+	Err           error
+}
+
+// NewSkippedNodeSpaceResult returns the result for a node left out of the evaluation.
+func NewSkippedNodeSpaceResult(node, reason string) NodeSpaceResult {
+	return NodeSpaceResult{
+		NodeName:   node,
+		Status:     NodeSpaceSkipped,
+		SkipReason: reason,
+	}
+}
+
+// Shortfall returns how many more free bytes the node would need to hold the required space, zero
+// if the node has enough space, has been skipped or could not be measured.
+func (r NodeSpaceResult) Shortfall() int64 {
+	if r.Status == NodeSpaceSkipped || r.Status == NodeSpaceUnknown || r.FreeBytes >= r.RequiredBytes {
+		return 0
+	}
+	return r.RequiredBytes - r.FreeBytes
+}
+
+// ClassifyNodeSpace compares the free space against the required one. nodes without the required
+// space fail while nodes whose free space is less than gracePercent percent above the required space
+// are flagged with a warning. a zero gracePercent disables the warning band.
+func ClassifyNodeSpace(free, required int64, gracePercent float64) NodeSpaceStatus {
+	if free < required {
+		return NodeSpaceFail
+	}
+
+	if float64(free) < float64(required)+float64(required)*gracePercent/100 {
+		return NodeSpaceWarn
+	}
+	return NodeSpaceOK
+}
+
+// NewNodeSpaceResult returns the classified result for the provided node.
+func NewNodeSpaceResult(node string, free, required int64, gracePercent float64) NodeSpaceResult {
+	status := ClassifyNodeSpace(free, required, gracePercent)
+	return NodeSpaceResult{
+		NodeName:      node,
+		FreeBytes:     free,
+		RequiredBytes: required,
+		HasEnough:     status != NodeSpaceFail,
+		Status:        status,
+	}
+}
@@ -0,0 +1,88 @@
+package clusterspace
+
+import "testing"
+
+func TestClassifyNodeSpace(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		free     int64
+		required int64
+		grace    float64
+		expected NodeSpaceStatus
+	}{
+		{
+			name:     "should be ok when the free space is above the grace band",
+			free:     111,
+			required: 100,
+			grace:    10,
+			expected: NodeSpaceOK,
+		},
+		{
+			name:     "should be ok at the upper limit of the grace band",
+			free:     110,
+			required: 100,
+			grace:    10,
+			expected: NodeSpaceOK,
+		},
+		{
+			name:     "should warn within the grace band",
+			free:     105,
+			required: 100,
+			grace:    10,
+			expected: NodeSpaceWarn,
+		},
+		{
+			name:     "should warn when the free space equals the required space",
+			free:     100,
+			required: 100,
+			grace:    10,
+			expected: NodeSpaceWarn,
+		},
+		{
+			name:     "should fail below the required space",
+			free:     99,
+			required: 100,
+			grace:    10,
+			expected: NodeSpaceFail,
+		},
+		{
+			name:     "should not warn without a grace band",
+			free:     100,
+			required: 100,
+			expected: NodeSpaceOK,
+		},
+		{
+			name:     "should be ok when nothing is required",
+			grace:    10,
+			expected: NodeSpaceOK,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			status := ClassifyNodeSpace(tt.free, tt.required, tt.grace)
+			if status != tt.expected {
+				t.Errorf("expected %s, %s received instead", tt.expected, status)
+			}
+		})
+	}
+}
+
+func TestNodeSpaceResultShortfall(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		result   NodeSpaceResult
+		expected int64
+	}{
+		{name: "should be zero with enough space", result: NodeSpaceResult{FreeBytes: 100, RequiredBytes: 50}},
+		{name: "should be zero when the free space matches", result: NodeSpaceResult{FreeBytes: 50, RequiredBytes: 50}},
+		{name: "should return the missing bytes", result: NodeSpaceResult{FreeBytes: 10, RequiredBytes: 50}, expected: 40},
+		{name: "should account for negative free space", result: NodeSpaceResult{FreeBytes: -10, RequiredBytes: 50}, expected: 60},
+		{name: "should be zero for skipped nodes", result: NodeSpaceResult{Status: NodeSpaceSkipped, RequiredBytes: 50}},
+		{name: "should be zero for nodes that could not be measured", result: NodeSpaceResult{Status: NodeSpaceUnknown, RequiredBytes: 50}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if shortfall := tt.result.Shortfall(); shortfall != tt.expected {
+				t.Errorf("expected shortfall %d, received %d", tt.expected, shortfall)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package clusterspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ErrListNodesTimeout is returned when the nodes can't be listed within the configured timeout,
+// usually a sign of an overloaded or unreachable API server.
+var ErrListNodesTimeout = errors.New("timed out listing nodes")
+
+// listNodes lists all nodes in the cluster, failing with ErrListNodesTimeout if the API server does
+// not answer within the configured timeout. this timeout is independent of the per node job timeout
+// and only bounds the initial listing, so a stalled API server is reported before any node is
+// evaluated. the request runs in a separate goroutine so we don't rely on the client honoring the
+// context deadline. defaultOpenEBSListNodesTimeout is used if no timeout has been configured.
+func (o *OpenEBSFreeDiskSpaceGetter) listNodes(ctx context.Context) (*corev1.NodeList, error) {
+	timeout := o.opts.ListNodesTimeout
+	if timeout == 0 {
+		timeout = defaultOpenEBSListNodesTimeout
+	}
+
+	lctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type listResult struct {
+		nodes *corev1.NodeList
+		err   error
+	}
+
+	done := make(chan listResult, 1)
+	go func() {
+		nodes, err := o.kcli.CoreV1().Nodes().List(lctx, metav1.ListOptions{})
+		done <- listResult{nodes: nodes, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.err == nil {
+			return res.nodes, nil
+		}
+		if ctx.Err() == nil && errors.Is(lctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w after %s: %s", ErrListNodesTimeout, timeout, res.err)
+		}
+		return nil, fmt.Errorf("failed to list nodes: %w", res.err)
+	case <-lctx.Done():
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("failed to list nodes: %w", ctx.Err())
+		}
+		return nil, fmt.Errorf("%w after %s", ErrListNodesTimeout, timeout)
+	}
+}
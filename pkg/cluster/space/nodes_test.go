@@ -0,0 +1,84 @@
+package clusterspace
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func Test_listNodes(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		delay   time.Duration
+		listErr error
+		timeout error
+		err     string
+	}{
+		{
+			name: "should list the nodes",
+		},
+		{
+			name:    "should time out when listing hangs",
+			delay:   time.Second,
+			timeout: ErrListNodesTimeout,
+			err:     "timed out listing nodes after 50ms",
+		},
+		{
+			name:    "should report api errors",
+			listErr: errors.New("connection refused"),
+			err:     "failed to list nodes: connection refused",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			kcli := fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node0"}})
+			kcli.PrependReactor(
+				"list", "nodes",
+				func(action k8stesting.Action) (bool, runtime.Object, error) {
+					time.Sleep(tt.delay)
+					if tt.listErr != nil {
+						return true, nil, tt.listErr
+					}
+					return false, nil, nil
+				},
+			)
+
+			getter := OpenEBSFreeDiskSpaceGetter{
+				kcli: kcli,
+				opts: OpenEBSOptions{
+					ListNodesTimeout: 50 * time.Millisecond,
+				},
+				log: log.New(io.Discard, "", 0),
+			}
+
+			nodes, err := getter.listNodes(context.Background())
+			if err != nil {
+				if tt.err == "" {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if err.Error() != tt.err {
+					t.Errorf("expecting %q, %q received instead", tt.err, err)
+				}
+				if tt.timeout != nil && !errors.Is(err, tt.timeout) {
+					t.Errorf("expecting %v to wrap %v", err, tt.timeout)
+				}
+				return
+			}
+
+			if tt.err != "" {
+				t.Fatalf("expecting error %q, nil received instead", tt.err)
+			}
+			if len(nodes.Items) != 1 || nodes.Items[0].Name != "node0" {
+				t.Errorf("unexpected nodes: %+v", nodes.Items)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package clusterspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// NodesWithSpace verifies if the openebs volume in every node is capable of holding the provided
+// reserved amount of bytes, after the space kept free by the reserve policy (see hasEnoughSpace).
+// unlike NodesWithoutSpace a node that fails to be measured does not abort the evaluation of the
+// others: it is returned with the NodeSpaceUnknown status and its error is joined into the returned
+// error. up to the configured space workers nodes are measured at the same time, the temporary pvcs
+// of all of them are deleted before returning and the running jobs are deleted if ctx is cancelled.
+// the returned results are sorted by node name, skipped nodes are returned with the NodeSpaceSkipped
+// status.
+func (o *OpenEBSDiskSpaceValidator) NodesWithSpace(ctx context.Context, reserved int64) ([]NodeSpaceResult, error) {
+	volumes, failures, err := o.freeSpaceGetter.measureVolumes(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate available disk space per node: %w", err)
+	}
+
+	if err := o.loadNodeLabels(ctx); err != nil {
+		return nil, fmt.Errorf("failed to read node labels: %w", err)
+	}
+
+	var results []NodeSpaceResult
+	for node, vol := range volumes {
+		free, ok := o.hasEnoughSpace(node, vol, reserved)
+		result := NodeSpaceResult{
+			NodeName:      node,
+			FreeBytes:     free,
+			UsedBytes:     vol.Used,
+			RequiredBytes: reserved,
+			RootVolume:    vol.RootVolume,
+			HasEnough:     ok,
+			Status:        NodeSpaceOK,
+		}
+		if !ok {
+			result.Status = NodeSpaceFail
+		}
+		results = append(results, result)
+	}
+
+	for node, err := range failures {
+		results = append(results, NodeSpaceResult{
+			NodeName:      node,
+			RequiredBytes: reserved,
+			Status:        NodeSpaceUnknown,
+			Err:           err,
+		})
+	}
+
+	for node, reason := range o.freeSpaceGetter.SkippedNodes() {
+		results = append(results, NewSkippedNodeSpaceResult(node, reason))
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].NodeName < results[j].NodeName
+	})
+
+	var errs []error
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, result.Err)
+		}
+	}
+	return results, errors.Join(errs...)
+}
@@ -0,0 +1,123 @@
+package clusterspace
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestNodesWithSpace(t *testing.T) {
+	objs := []runtime.Object{
+		&storagev1.StorageClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "default",
+				Annotations: map[string]string{
+					"cas.openebs.io/config": "- name: BasePath\n  value: /var/local",
+				},
+			},
+			Provisioner: "openebs.io/local",
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "win0", Labels: map[string]string{corev1.LabelOSStable: "windows"}},
+		},
+	}
+	for _, name := range []string{"node0", "node1", "node2"} {
+		objs = append(objs, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+	objs = append(objs, kubeSystemNamespace())
+
+	// node0 and node1 are served from the cache, every job fails to be created so node2 fails to be
+	// measured after its pvc is created.
+	cache, err := NewOpenEBSVolumeCache(filepath.Join(t.TempDir(), "cache.json"), time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error creating cache: %s", err)
+	}
+	for node, vol := range map[string]OpenEBSVolume{
+		"node0": {Free: 1000, Used: 1000},
+		"node1": {Free: 1000, Used: 1000, RootVolume: true},
+	} {
+		if err := cache.Set(node, testCacheKey, vol); err != nil {
+			t.Fatalf("unexpected error caching volume: %s", err)
+		}
+	}
+
+	kcli := fake.NewSimpleClientset(objs...)
+	kcli.PrependReactor("create", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("quota exceeded")
+	})
+
+	getter := &OpenEBSFreeDiskSpaceGetter{
+		kcli: kcli,
+		log:  log.New(io.Discard, "", 0),
+		opts: OpenEBSOptions{
+			DstSC:        "default",
+			Image:        "myimage:latest",
+			Namespace:    "default",
+			Cache:        cache,
+			SpaceWorkers: 2,
+			PVC: OpenEBSPVCOptions{
+				SkipPVWait: true,
+			},
+		},
+	}
+	ochecker := OpenEBSDiskSpaceValidator{
+		kcli:            kcli,
+		log:             log.New(io.Discard, "", 0),
+		freeSpaceGetter: getter,
+	}
+
+	results, err := ochecker.NodesWithSpace(context.Background(), 800)
+	if err == nil {
+		t.Fatalf("expected error, nil received instead")
+	}
+	if !strings.Contains(err.Error(), "failed to measure node node2") {
+		t.Errorf("expected error to report node node2, received %q", err)
+	}
+
+	if len(results) != 4 {
+		t.Fatalf("expected a result for each node, %d received", len(results))
+	}
+	if results[2].Status != NodeSpaceUnknown || results[2].Err == nil || results[2].RequiredBytes != 800 {
+		t.Errorf("unexpected result for node node2: %+v", results[2])
+	}
+	results[2].Err = nil
+
+	// node1 is part of the root filesystem so 15% of its volume is kept free by the reserve policy.
+	expected := []NodeSpaceResult{
+		{NodeName: "node0", FreeBytes: 1000, UsedBytes: 1000, RequiredBytes: 800, HasEnough: true, Status: NodeSpaceOK},
+		{NodeName: "node1", FreeBytes: 700, UsedBytes: 1000, RequiredBytes: 800, RootVolume: true, Status: NodeSpaceFail},
+		{NodeName: "node2", RequiredBytes: 800, Status: NodeSpaceUnknown},
+		{NodeName: "win0", Status: NodeSpaceSkipped, SkipReason: results[3].SkipReason},
+	}
+	if diff := cmp.Diff(expected, results); diff != "" {
+		t.Errorf("unexpected results: %s", diff)
+	}
+	if results[3].SkipReason == "" {
+		t.Errorf("expected windows node to be reported with a skip reason")
+	}
+
+	if _, ok := getter.SkippedNodes()["win0"]; !ok {
+		t.Errorf("expected windows node to be skipped, skipped: %v", getter.SkippedNodes())
+	}
+
+	pvcs, err := kcli.CoreV1().PersistentVolumeClaims("default").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("failed to list pvcs: %s", err)
+	}
+	if len(pvcs.Items) != 0 {
+		t.Errorf("expected all temporary pvcs to be deleted, %d found", len(pvcs.Items))
+	}
+}
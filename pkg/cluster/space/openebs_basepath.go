@@ -0,0 +1,206 @@
+package clusterspace
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// basePathStatusMarker prefixes the line printed by the base path validation job with the status
+// of the base path in the node.
+const basePathStatusMarker = "KURL_BASEPATH_STATUS:"
+
+// BasePathStatus is the status of the openebs base path in a node.
+type BasePathStatus string
+
+const (
+	// BasePathPresent means the base path exists and is a directory.
+	BasePathPresent BasePathStatus = "present"
+	// BasePathMissing means the base path does not exist.
+	BasePathMissing BasePathStatus = "missing"
+	// BasePathNotADirectory means the base path exists but it is not a directory.
+	BasePathNotADirectory BasePathStatus = "not-a-directory"
+	// BasePathBrokenSymlink means the base path is a symlink pointing to nowhere.
+	BasePathBrokenSymlink BasePathStatus = "broken-symlink"
+)
+
+// basePathStatusCommand returns the script used to verify the base path status. the script runs
+// chrooted into the node root filesystem so symlinks are resolved as in the node.
+func basePathStatusCommand(basePath string) string {
+	script := fmt.Sprintf(
+		`p=%q; if [ -L "$p" ] && [ ! -e "$p" ]; then s=%s; elif [ ! -e "$p" ]; then s=%s; elif [ ! -d "$p" ]; then s=%s; else s=%s; fi; echo %q "$s"`,
+		basePath, BasePathBrokenSymlink, BasePathMissing, BasePathNotADirectory, BasePathPresent, basePathStatusMarker,
+	)
+	return fmt.Sprintf("chroot /host /bin/sh -c %s", shellQuote(script))
+}
+
+// shellQuote quotes the provided string so it can be used as a single shell argument.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// parseBasePathStatus parses the output of the base path validation job.
+func parseBasePathStatus(output []byte) (BasePathStatus, error) {
+	buf := bytes.NewBuffer(output)
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, basePathStatusMarker) {
+			continue
+		}
+
+		status := BasePathStatus(strings.TrimSpace(strings.TrimPrefix(line, basePathStatusMarker)))
+		switch status {
+		case BasePathPresent, BasePathMissing, BasePathNotADirectory, BasePathBrokenSymlink:
+			return status, nil
+		default:
+			return "", fmt.Errorf("unknown base path status %q", status)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to process container log: %w", err)
+	}
+	return "", fmt.Errorf("failed to locate base path status in pod log: %s", string(output))
+}
+
+// buildBasePathJob returns a job scheduled to run in the provided node that reports the status of
+// the base path. the node root filesystem is mounted read only inside the job pod.
+func (o *OpenEBSFreeDiskSpaceGetter) buildBasePathJob(node, basePath string) *batchv1.Job {
+	typeDir := corev1.HostPathDirectory
+	podSpec := corev1.PodSpec{
+		RestartPolicy:    corev1.RestartPolicyNever,
+		Tolerations:      o.tolerations,
+		Affinity:         nodeAffinity(node),
+		ImagePullSecrets: o.imagePullSecrets(),
+		Volumes: []corev1.Volume{
+			{
+				Name: "host",
+				VolumeSource: corev1.VolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{
+						Type: &typeDir,
+						Path: "/",
+					},
+				},
+			},
+		},
+		Containers: []corev1.Container{
+			{
+				Name:    "basepath",
+				Image:   o.opts.Image,
+				Command: []string{"/bin/sh", "-c"},
+				Args:    []string{basePathStatusCommand(basePath)},
+				VolumeMounts: []corev1.VolumeMount{
+					{
+						MountPath: "/host",
+						Name:      "host",
+						ReadOnly:  true,
+					},
+				},
+			},
+		},
+	}
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName(node),
+			Namespace: o.opts.Namespace,
+			Labels:    o.jobLabels(node),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          ptr.To(int32(1)),
+			ActiveDeadlineSeconds: activeDeadlineSeconds(o.opts.Job.Timeout),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: o.jobLabels(node),
+				},
+				Spec: podSpec,
+			},
+		},
+	}
+}
+
+// ValidateBasePath verifies, in all nodes, if the storage class base path exists and is a directory.
+// returns the base path and its status indexed by node name.
+func (o *OpenEBSFreeDiskSpaceGetter) ValidateBasePath(ctx context.Context) (string, map[string]BasePathStatus, error) {
+	nodes, err := o.listNodes(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	basePath, provisioner, err := o.basePath(ctx)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read openebs base path: %w", err)
+	}
+
+	if err := o.validateProvisioner(provisioner); err != nil {
+		return "", nil, err
+	}
+
+	result := map[string]BasePathStatus{}
+	for _, node := range nodes.Items {
+		o.log.Printf("Validating base path on node %s", node.Name)
+		if err := o.nodeIsSchedulable(node); err != nil {
+			return "", nil, fmt.Errorf("failed to assess node %s: %w", node.Name, err)
+		}
+
+		job := o.buildBasePathJob(node.Name, basePath)
+		out, status, err := o.runJob(ctx, job)
+		if err != nil {
+			o.logContainersState(out, status)
+			return "", nil, fmt.Errorf(
+				"failed to run job %s/%s on node %s: %w", job.Namespace, job.Name, node.Name, err,
+			)
+		}
+
+		if result[node.Name], err = parseBasePathStatus(out["basepath"]); err != nil {
+			o.logContainersState(out, status)
+			return "", nil, fmt.Errorf("failed to parse node %s base path status: %w", node.Name, err)
+		}
+	}
+	return basePath, result, nil
+}
+
+// basePathPlaceholderRegexp matches the placeholders that may be found in templated base paths:
+// environment style ones ($NAME and ${NAME}) and go template actions ({{ ... }}). an unterminated
+// ${ is matched as well so it is reported instead of measured as a literal path.
+var basePathPlaceholderRegexp = regexp.MustCompile(`\$\{[^}]*\}?|\$[A-Za-z_][A-Za-z0-9_]*|\{\{.*?\}\}`)
+
+// resolveBasePathPlaceholders replaces the environment style placeholders found in the provided
+// base path with their values in vars. an error is returned for any placeholder that can't be
+// resolved, go template actions are never resolved.
+func resolveBasePathPlaceholders(basePath string, vars map[string]string) (string, error) {
+	var unresolved []string
+	resolved := basePathPlaceholderRegexp.ReplaceAllStringFunc(basePath, func(placeholder string) string {
+		name := strings.TrimPrefix(placeholder, "$")
+		if strings.HasPrefix(name, "{") {
+			if !strings.HasSuffix(name, "}") {
+				unresolved = append(unresolved, placeholder)
+				return placeholder
+			}
+			name = strings.TrimSuffix(strings.TrimPrefix(name, "{"), "}")
+		}
+
+		value, ok := vars[name]
+		if !ok || strings.HasPrefix(placeholder, "{{") {
+			unresolved = append(unresolved, placeholder)
+			return placeholder
+		}
+		return value
+	})
+
+	if len(unresolved) > 0 {
+		return "", fmt.Errorf(
+			"base path %s contains unresolved placeholder %s", basePath, strings.Join(unresolved, ", "),
+		)
+	}
+	return resolved, nil
+}
@@ -0,0 +1,134 @@
+package clusterspace
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_parseBasePathStatus(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		content  []byte
+		err      string
+		expected BasePathStatus
+	}{
+		{
+			name:    "should fail with empty output",
+			content: []byte(``),
+			err:     "failed to locate base path status in pod log",
+		},
+		{
+			name:     "should parse present base path",
+			content:  []byte(`KURL_BASEPATH_STATUS: present`),
+			expected: BasePathPresent,
+		},
+		{
+			name:     "should parse missing base path",
+			content:  []byte(`KURL_BASEPATH_STATUS: missing`),
+			expected: BasePathMissing,
+		},
+		{
+			name:     "should parse base path that is not a directory",
+			content:  []byte(`KURL_BASEPATH_STATUS: not-a-directory`),
+			expected: BasePathNotADirectory,
+		},
+		{
+			name: "should parse broken symlink among other lines",
+			content: []byte(`some noise printed by the image
+KURL_BASEPATH_STATUS: broken-symlink
+`),
+			expected: BasePathBrokenSymlink,
+		},
+		{
+			name:    "should fail with unknown status",
+			content: []byte(`KURL_BASEPATH_STATUS: something-else`),
+			err:     `unknown base path status "something-else"`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := parseBasePathStatus(tt.content)
+			if err != nil {
+				if len(tt.err) == 0 {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expecting %q, %q received instead", tt.err, err)
+				}
+				return
+			}
+
+			if len(tt.err) > 0 {
+				t.Errorf("expecting error %q, nil received instead", tt.err)
+			}
+
+			if status != tt.expected {
+				t.Errorf("expected status %q, %q received", tt.expected, status)
+			}
+		})
+	}
+}
+
+func Test_basePathStatusCommand(t *testing.T) {
+	cmd := basePathStatusCommand("/var/local/it's")
+	if !strings.HasPrefix(cmd, "chroot /host /bin/sh -c '") {
+		t.Errorf("expected command to run chrooted in the host: %s", cmd)
+	}
+	if !strings.Contains(cmd, `p="/var/local/it'\''s"`) {
+		t.Errorf("expected base path to be quoted: %s", cmd)
+	}
+}
+
+func Test_resolveBasePathPlaceholders(t *testing.T) {
+	vars := map[string]string{"DATA": "/mnt/data", "NODE_DIR": "local"}
+	for _, tt := range []struct {
+		name     string
+		basePath string
+		expected string
+		err      string
+	}{
+		{
+			name:     "should return paths without placeholders as they are",
+			basePath: "/var/local/openebs",
+			expected: "/var/local/openebs",
+		},
+		{
+			name:     "should resolve braced and bare placeholders",
+			basePath: "${DATA}/$NODE_DIR/openebs",
+			expected: "/mnt/data/local/openebs",
+		},
+		{
+			name:     "should report all unknown placeholders",
+			basePath: "$DATA/${UNKNOWN}/$OTHER",
+			err:      "base path $DATA/${UNKNOWN}/$OTHER contains unresolved placeholder ${UNKNOWN}, $OTHER",
+		},
+		{
+			name:     "should report unterminated placeholders",
+			basePath: "/var/${DATA",
+			err:      "contains unresolved placeholder ${DATA",
+		},
+		{
+			name:     "should never resolve template actions",
+			basePath: "/var/{{ .DATA }}",
+			err:      "contains unresolved placeholder {{ .DATA }}",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, err := resolveBasePathPlaceholders(tt.basePath, vars)
+			if err != nil {
+				if len(tt.err) == 0 {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expecting %q, %q received instead", tt.err, err)
+				}
+				return
+			}
+
+			if len(tt.err) > 0 {
+				t.Errorf("expecting error %q, nil received instead", tt.err)
+			}
+
+			if resolved != tt.expected {
+				t.Errorf("expected %q, %q received", tt.expected, resolved)
+			}
+		})
+	}
+}
@@ -4,8 +4,15 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
 
 	"code.cloudfoundry.org/bytefmt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
@@ -18,23 +25,182 @@ type OpenEBSDiskSpaceValidator struct {
 	kcli            kubernetes.Interface
 	log             *log.Logger
 	srcSC           string
+	reserved        int64
+	reserve         ReserveCalculator
+	nodeReserves    NodeReserveOverrides
+	nodeLabels      map[string]map[string]string
+	pendingPVCs     bool
+	tracer          trace.Tracer
 }
 
-// hasEnoughSpace calculates if the openebs volume is capable of holding the provided reserved
-// amount of bytes. if the openebs volume is part of the root filesystem then we decrease 15%
-// of its space. returns the effective free space as well.
-func (o *OpenEBSDiskSpaceValidator) hasEnoughSpace(vol OpenEBSVolume, reserved int64) (int64, bool) {
-	total := float64(vol.Free + vol.Used)
-	if vol.RootVolume {
-		total *= 0.85
+// reserveFor returns the reserve calculator applied to the volume in the provided node. node overrides
+// take precedence over the destination storage class reserve policy, see NodeReserveOverrides.For.
+func (o *OpenEBSDiskSpaceValidator) reserveFor(node string) ReserveCalculator {
+	if reserve, ok := o.nodeReserves.For(node, o.nodeLabels[node]); ok {
+		return reserve
 	}
-	free := int64(total) - vol.Used
+	if o.reserve == nil {
+		return RootDiskReserve
+	}
+	return o.reserve
+}
+
+// hasEnoughSpace calculates if the openebs volume in the node is capable of holding the provided
+// reserved amount of bytes. the space kept free by the node reserve override or, if none applies,
+// by the destination storage class reserve policy is not considered available (by default 15% of
+// the volume if it is part of the root filesystem). returns the effective free space as well.
+func (o *OpenEBSDiskSpaceValidator) hasEnoughSpace(node string, vol OpenEBSVolume, reserved int64) (int64, bool) {
+	free := vol.Free - o.reserveFor(node)(vol)
 	return free, free > reserved
 }
 
-// Check verifies if we have enough disk space to execute the migration. returns a list of nodes
-// where the migration can't execute due to a possible lack of disk space.
+// loadNodeLabels reads the labels of all nodes, needed to select the node reserve overrides targeting
+// label selectors. nothing is read if no override uses a selector.
+func (o *OpenEBSDiskSpaceValidator) loadNodeLabels(ctx context.Context) error {
+	if !o.nodeReserves.HasSelectors() {
+		return nil
+	}
+
+	nodes, err := o.freeSpaceGetter.listNodes(ctx)
+	if err != nil {
+		return err
+	}
+
+	o.nodeLabels = map[string]map[string]string{}
+	for _, node := range nodes.Items {
+		o.nodeLabels[node.Name] = node.Labels
+	}
+	return nil
+}
+
+// subtractPendingDemand decreases the free space of the provided volumes by the amount of storage requested
+// by pvcs in the destination storage class that are still pending provisioning. as we can't know where the
+// pending pvcs not yet scheduled to a node will land their demand is subtracted from all nodes.
+func (o *OpenEBSDiskSpaceValidator) subtractPendingDemand(ctx context.Context, volumes map[string]OpenEBSVolume) (map[string]OpenEBSVolume, error) {
+	perNode, detached, err := k8sutil.PendingPVCSReservationPerNode(ctx, o.kcli, o.freeSpaceGetter.opts.DstSC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate pending pvcs demand: %w", err)
+	}
+
+	if detached != 0 {
+		o.log.Printf(
+			"Amount of pending PVCs demand not yet scheduled to a node (%q storage class): %s",
+			o.freeSpaceGetter.opts.DstSC,
+			bytefmt.ByteSize(uint64(detached)),
+		)
+	}
+
+	result := map[string]OpenEBSVolume{}
+	for node, vol := range volumes {
+		if perNode[node] != 0 {
+			o.log.Printf(
+				"Node %q has %s of pending PVCs demand (%q storage class)",
+				node,
+				bytefmt.ByteSize(uint64(perNode[node])),
+				o.freeSpaceGetter.opts.DstSC,
+			)
+		}
+
+		demand := perNode[node] + detached
+		vol.Free -= demand
+		vol.Used += demand
+		result[node] = vol
+	}
+	return result, nil
+}
+
+// RunID returns the identifier labeling all the resources created by the validator, see
+// OpenEBSFreeDiskSpaceGetter.RunID.
+func (o *OpenEBSDiskSpaceValidator) RunID() string {
+	return o.freeSpaceGetter.RunID()
+}
+
+// getTracer returns the tracer provided through the options or a noop one if none has been provided.
+func (o *OpenEBSDiskSpaceValidator) getTracer() trace.Tracer {
+	if o.tracer == nil {
+		return noop.NewTracerProvider().Tracer("")
+	}
+	return o.tracer
+}
+
+// traceNodeChecks records a span, child of the provided context span, for each of the evaluated nodes.
+// the spans carry the node name, its effective free space, the space required in it and whether it
+// has passed the check.
+func (o *OpenEBSDiskSpaceValidator) traceNodeChecks(ctx context.Context, free, required map[string]int64, faulty map[string]bool) {
+	var nodes []string
+	for node := range free {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	for _, node := range nodes {
+		_, span := o.getTracer().Start(ctx, "openebs.node", trace.WithAttributes(
+			attribute.String("kurl.node", node),
+			attribute.Int64("kurl.free_bytes", free[node]),
+			attribute.Int64("kurl.required_bytes", required[node]),
+			attribute.Bool("kurl.passed", !faulty[node]),
+		))
+		if faulty[node] {
+			span.SetStatus(codes.Error, "not enough disk space")
+		}
+		span.End()
+	}
+}
+
+// NodesWithoutSpace verifies if we have enough disk space to execute the migration. returns a list
+// of nodes where the migration can't execute due to a possible lack of disk space, skipped nodes are
+// not part of it. if a tracer has been provided a span is recorded for the whole run with a child
+// span for each node.
 func (o *OpenEBSDiskSpaceValidator) NodesWithoutSpace(ctx context.Context) ([]string, error) {
+	results, err := o.nodesSpace(ctx, "openebs.NodesWithoutSpace")
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []string
+	for _, result := range results {
+		if result.Status == NodeSpaceFail {
+			nodes = append(nodes, result.NodeName)
+		}
+	}
+	return nodes, nil
+}
+
+// NodesSpace verifies if we have enough disk space to execute the migration and returns the outcome
+// for every node, sorted by node name. the free bytes of each node are the ones left after the
+// reserve policy and the required bytes account for the volumes already bound to the node plus the
+// detached ones, which may land in any node. nodes without enough space have HasEnough unset and
+// their Shortfall tells how much space is missing. nodes left out by the getter, e.g. not matching
+// the node selector, are returned with the NodeSpaceSkipped status. tracing works as in
+// NodesWithoutSpace.
+func (o *OpenEBSDiskSpaceValidator) NodesSpace(ctx context.Context) ([]NodeSpaceResult, error) {
+	return o.nodesSpace(ctx, "openebs.NodesSpace")
+}
+
+// nodesSpace evaluates the space in every node, see NodesSpace, recording the run in a span with
+// the provided name.
+func (o *OpenEBSDiskSpaceValidator) nodesSpace(ctx context.Context, spanName string) (results []NodeSpaceResult, err error) {
+	ctx, span := o.getTracer().Start(ctx, spanName, trace.WithAttributes(
+		attribute.String("kurl.source_storage_class", o.srcSC),
+		attribute.String("kurl.destination_storage_class", o.freeSpaceGetter.opts.DstSC),
+		attribute.String("kurl.run_id", o.freeSpaceGetter.RunID()),
+	))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		var without int
+		for _, result := range results {
+			if result.Status == NodeSpaceFail {
+				without++
+			}
+		}
+		span.SetAttributes(attribute.Int("kurl.nodes_without_space", without))
+		span.End()
+		o.freeSpaceGetter.metrics.observeRun(o.srcSC, o.freeSpaceGetter.opts.DstSC, results, err)
+	}()
+
 	o.log.Printf("Analyzing reserved and free disk space per node...")
 	reservedPerNode, reservedDetached, err := k8sutil.PVSReservationPerNode(ctx, o.kcli, o.srcSC)
 	if err != nil {
@@ -46,17 +212,32 @@ func (o *OpenEBSDiskSpaceValidator) NodesWithoutSpace(ctx context.Context) ([]st
 		return nil, fmt.Errorf("failed to calculate available disk space per node: %w", err)
 	}
 
+	if o.pendingPVCs {
+		if volumes, err = o.subtractPendingDemand(ctx, volumes); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := o.loadNodeLabels(ctx); err != nil {
+		return nil, fmt.Errorf("failed to read node labels: %w", err)
+	}
+
 	faultyNodes := map[string]bool{}
+	freePerNode := map[string]int64{}
+	requiredPerNode := map[string]int64{}
 	for node, vol := range volumes {
 		var ok bool
 		var free int64
-		if free, ok = o.hasEnoughSpace(vol, reservedPerNode[node]); ok {
+		free, ok = o.hasEnoughSpace(node, vol, reservedPerNode[node]+o.reserved)
+		freePerNode[node] = free
+		requiredPerNode[node] = reservedPerNode[node] + o.reserved + reservedDetached
+		if ok {
 			continue
 		}
 
 		var reservedMsg string
-		if vol.RootVolume {
-			reservedMsg = "(15% of the root disk is reserved to prevent DiskPressure evictions)"
+		if kept := vol.Free - free; kept > 0 {
+			reservedMsg = fmt.Sprintf("(%s is kept free by the reserve policy)", bytefmt.ByteSize(uint64(kept)))
 		}
 
 		faultyNodes[node] = true
@@ -83,7 +264,7 @@ func (o *OpenEBSDiskSpaceValidator) NodesWithoutSpace(ctx context.Context) ([]st
 		for node, vol := range volumes {
 			vol.Used += reservedPerNode[node]
 			vol.Free -= reservedPerNode[node]
-			if free, hasSpace := o.hasEnoughSpace(vol, reservedDetached); !hasSpace {
+			if free, hasSpace := o.hasEnoughSpace(node, vol, reservedDetached+o.reserved); !hasSpace {
 				if free < 0 {
 					free = 0
 				}
@@ -99,47 +280,135 @@ func (o *OpenEBSDiskSpaceValidator) NodesWithoutSpace(ctx context.Context) ([]st
 		}
 	}
 
-	if len(faultyNodes) > 0 {
-		var nodeNames []string
-		for name := range faultyNodes {
-			nodeNames = append(nodeNames, name)
+	o.traceNodeChecks(ctx, freePerNode, requiredPerNode, faultyNodes)
+	for node, vol := range volumes {
+		result := NodeSpaceResult{
+			NodeName:      node,
+			FreeBytes:     freePerNode[node],
+			UsedBytes:     vol.Used,
+			RequiredBytes: requiredPerNode[node],
+			RootVolume:    vol.RootVolume,
+			HasEnough:     !faultyNodes[node],
+			Status:        NodeSpaceOK,
+		}
+		if faultyNodes[node] {
+			result.Status = NodeSpaceFail
 		}
-		return nodeNames, nil
+		results = append(results, result)
+	}
+	for node, reason := range o.freeSpaceGetter.SkippedNodes() {
+		results = append(results, NewSkippedNodeSpaceResult(node, reason))
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].NodeName < results[j].NodeName
+	})
+
+	if len(faultyNodes) == 0 {
+		o.log.Printf("Enough disk space found, moving on")
+	}
+	o.recordVerdict(results)
+	return results, nil
+}
+
+// checkStorageClassesExist lists the cluster storage classes and returns an error naming the
+// provided ones that do not exist.
+func checkStorageClassesExist(ctx context.Context, kcli kubernetes.Interface, scnames ...string) error {
+	sclasses, err := kcli.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list storage classes: %w", err)
 	}
 
-	o.log.Printf("Enough disk space found, moving on")
-	return nil, nil
+	existing := map[string]bool{}
+	for _, sclass := range sclasses.Items {
+		existing[sclass.Name] = true
+	}
+
+	var missing []string
+	for _, scname := range scnames {
+		if !existing[scname] {
+			missing = append(missing, scname)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("storage class(es) %s not found", strings.Join(missing, ", "))
+	}
+	return nil
 }
 
 // NewOpenEBSDiskSpaceValidator returns a disk free analyser for openebs storage local volume provisioner.
-func NewOpenEBSDiskSpaceValidator(cfg *rest.Config, log *log.Logger, image, srcSC, dstSC string) (*OpenEBSDiskSpaceValidator, error) {
+// the optional knobs are set through the provided options, see OpenEBSOption.
+func NewOpenEBSDiskSpaceValidator(cfg *rest.Config, log *log.Logger, image, srcSC, dstSC string, options ...OpenEBSOption) (*OpenEBSDiskSpaceValidator, error) {
+	var opts OpenEBSOptions
+	for _, option := range options {
+		option(&opts)
+	}
+	opts.Log = log
+	opts.Image = image
+	opts.SrcSC = srcSC
+	opts.DstSC = dstSC
+	return NewOpenEBSDiskSpaceValidatorWithOptions(cfg, opts)
+}
+
+// NewOpenEBSDiskSpaceValidatorWithOptions returns a disk free analyser for openebs storage local volume
+// provisioner configured through the provided options.
+func NewOpenEBSDiskSpaceValidatorWithOptions(cfg *rest.Config, opts OpenEBSOptions) (*OpenEBSDiskSpaceValidator, error) {
+	if opts.APIFailureThreshold > 0 {
+		cfg = k8sutil.WithCircuitBreaker(cfg, k8sutil.NewCircuitBreaker(opts.APIFailureThreshold))
+	}
+
 	kcli, err := kubernetes.NewForConfig(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
 	}
 
-	if image == "" {
+	if opts.Image == "" {
 		return nil, fmt.Errorf("empty image")
 	}
-	if srcSC == "" {
+	if opts.SrcSC == "" {
 		return nil, fmt.Errorf("empty source storage class")
 	}
-	if dstSC == "" {
+	if opts.DstSC == "" {
 		return nil, fmt.Errorf("empty destination storage class")
 	}
-	if log == nil {
+	if opts.SrcSC == opts.DstSC {
+		return nil, fmt.Errorf("source and destination storage classes must differ")
+	}
+	if opts.Log == nil {
 		return nil, fmt.Errorf("no logger provided")
 	}
 
-	freeSpaceGetter, err := NewOpenEBSFreeDiskSpaceGetter(kcli, log, image, dstSC)
+	freeSpaceGetter, err := NewOpenEBSFreeDiskSpaceGetterWithOptions(kcli, opts)
 	if err != nil {
 		return nil, fmt.Errorf("unable to create free space getter: %w", err)
 	}
 
+	if opts.CheckStorageClasses {
+		ctx, cancel := context.WithTimeout(context.Background(), freeSpaceGetter.opts.ListNodesTimeout)
+		defer cancel()
+		if err := checkStorageClassesExist(ctx, kcli, opts.SrcSC, opts.DstSC); err != nil {
+			return nil, err
+		}
+	}
+
+	reserve, ok := opts.ReservePolicies.For(opts.DstSC)
+	if !ok {
+		reserve = RootDiskReserve
+		if opts.ReserveFraction != nil {
+			if reserve, err = FractionReserve(*opts.ReserveFraction); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	return &OpenEBSDiskSpaceValidator{
 		freeSpaceGetter: freeSpaceGetter,
+		reserve:         reserve,
+		nodeReserves:    opts.NodeReserves,
 		kcli:            kcli,
-		log:             log,
-		srcSC:           srcSC,
+		log:             freeSpaceGetter.log,
+		srcSC:           opts.SrcSC,
+		reserved:        opts.Reserved,
+		pendingPVCs:     opts.AccountPendingPVCs,
+		tracer:          opts.Tracer,
 	}, nil
 }
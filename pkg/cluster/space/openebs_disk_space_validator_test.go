@@ -1,11 +1,27 @@
 package clusterspace
 
 import (
+	"context"
 	"io"
 	"log"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
+	"k8s.io/utils/ptr"
 )
 
 func Test_hasEnoughSpace(t *testing.T) {
@@ -13,6 +29,7 @@ func Test_hasEnoughSpace(t *testing.T) {
 		name     string
 		volume   OpenEBSVolume
 		reserved int64
+		reserve  ReserveCalculator
 		hasSpace bool
 		free     int64
 	}{
@@ -52,10 +69,34 @@ func Test_hasEnoughSpace(t *testing.T) {
 				RootVolume: true,
 			},
 		},
+		{
+			name:     "should apply the storage class absolute reserve",
+			reserved: 50,
+			reserve:  AbsoluteReserve(60),
+			free:     40,
+			hasSpace: false,
+			volume: OpenEBSVolume{
+				Free:       100,
+				Used:       0,
+				RootVolume: true,
+			},
+		},
+		{
+			name:     "should apply the storage class percent reserve to non root volumes",
+			reserved: 50,
+			reserve:  PercentReserve(10),
+			free:     80,
+			hasSpace: true,
+			volume: OpenEBSVolume{
+				Free:       100,
+				Used:       100,
+				RootVolume: false,
+			},
+		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
-			ochecker := OpenEBSDiskSpaceValidator{}
-			free, hasSpace := ochecker.hasEnoughSpace(tt.volume, tt.reserved)
+			ochecker := OpenEBSDiskSpaceValidator{reserve: tt.reserve}
+			free, hasSpace := ochecker.hasEnoughSpace("node0", tt.volume, tt.reserved)
 
 			if hasSpace != tt.hasSpace {
 				t.Errorf("expected hasSpace to be %v, %v received instead", tt.hasSpace, hasSpace)
@@ -68,6 +109,62 @@ func Test_hasEnoughSpace(t *testing.T) {
 	}
 }
 
+func Test_subtractPendingDemand(t *testing.T) {
+	scname := "openebs"
+	claim := func(name string, phase corev1.PersistentVolumeClaimPhase, size, node string) *corev1.PersistentVolumeClaim {
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				StorageClassName: &scname,
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceStorage: resource.MustParse(size),
+					},
+				},
+			},
+			Status: corev1.PersistentVolumeClaimStatus{
+				Phase: phase,
+			},
+		}
+		if node != "" {
+			pvc.Annotations = map[string]string{"volume.kubernetes.io/selected-node": node}
+		}
+		return pvc
+	}
+
+	kcli := fake.NewSimpleClientset(
+		claim("bound", corev1.ClaimBound, "500", "node0"),
+		claim("pending-node0", corev1.ClaimPending, "100", "node0"),
+		claim("pending-detached", corev1.ClaimPending, "10", ""),
+	)
+
+	ochecker := OpenEBSDiskSpaceValidator{
+		kcli:            kcli,
+		log:             log.New(io.Discard, "", 0),
+		freeSpaceGetter: &OpenEBSFreeDiskSpaceGetter{opts: OpenEBSOptions{DstSC: scname}},
+		pendingPVCs:     true,
+	}
+
+	volumes, err := ochecker.subtractPendingDemand(context.Background(), map[string]OpenEBSVolume{
+		"node0": {Free: 1000, Used: 0},
+		"node1": {Free: 1000, Used: 0},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := map[string]OpenEBSVolume{
+		"node0": {Free: 890, Used: 110},
+		"node1": {Free: 990, Used: 10},
+	}
+	if diff := cmp.Diff(expected, volumes); diff != "" {
+		t.Errorf("unexpected volumes: %s", diff)
+	}
+}
+
 func TestNewOpenEBSChecker(t *testing.T) {
 	// test empty logger
 	_, err := NewOpenEBSDiskSpaceValidator(&rest.Config{}, nil, "image", "src", "dst")
@@ -101,4 +198,437 @@ func TestNewOpenEBSChecker(t *testing.T) {
 	if err != nil {
 		t.Errorf("unexpected failure creating object: %v", err)
 	}
+
+	// invalid values set through options are still validated
+	_, err = NewOpenEBSDiskSpaceValidator(
+		&rest.Config{}, logger, "image", "src", "dst", WithDeletePVTimeout(-time.Second),
+	)
+	if err == nil || err.Error() != "unable to create free space getter: invalid delete pv timeout -1s" {
+		t.Errorf("expected failure creating object: %v", err)
+	}
+
+	// options are applied in order, the last one setting a knob wins
+	selector := labels.SelectorFromSet(labels.Set{"disk": "ssd"})
+	checker, err := NewOpenEBSDiskSpaceValidator(
+		&rest.Config{}, logger, "image", "src", "dst",
+		WithJobTimeout(time.Minute),
+		WithJobTimeout(time.Second),
+		WithDeletePVTimeout(20*time.Second),
+		WithConcurrency(3),
+		WithTmpPVCSize(resource.MustParse("1Gi")),
+		WithNodeSelector(selector),
+	)
+	if err != nil {
+		t.Fatalf("unexpected failure creating object: %v", err)
+	}
+	getter := checker.freeSpaceGetter
+	if getter.opts.Image != "image" || getter.opts.DstSC != "dst" || checker.srcSC != "src" {
+		t.Errorf("unexpected image or storage classes: %q, %q, %q", getter.opts.Image, getter.opts.DstSC, checker.srcSC)
+	}
+	if getter.opts.Job.Timeout != time.Second || getter.opts.PVC.DeletePVTimeout != 20*time.Second {
+		t.Errorf("unexpected timeouts: %s, %s", getter.opts.Job.Timeout, getter.opts.PVC.DeletePVTimeout)
+	}
+	if getter.opts.Parallelism != 3 || getter.opts.PVC.Size.String() != "1Gi" || getter.opts.NodeSelector.String() != "disk=ssd" {
+		t.Errorf("unexpected parallelism, pvc size or node selector: %d, %s, %v", getter.opts.Parallelism, getter.opts.PVC.Size.String(), getter.opts.NodeSelector)
+	}
+}
+
+func TestNewOpenEBSCheckerReservePolicy(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	policies := ReservePolicies{
+		"logs":     AbsoluteReserve(10),
+		"database": PercentReserve(50),
+	}
+
+	vol := OpenEBSVolume{Free: 100, Used: 100, RootVolume: true}
+	for _, tt := range []struct {
+		name     string
+		dstSC    string
+		fraction *float64
+		expected int64
+	}{
+		{name: "logs", dstSC: "logs", expected: 10},
+		{name: "database", dstSC: "database", expected: 100},
+		{name: "openebs", dstSC: "openebs", expected: 30},
+		{name: "fraction without policy", dstSC: "openebs", fraction: ptr.To(0.25), expected: 50},
+		{name: "zero fraction without policy", dstSC: "openebs", fraction: ptr.To(0.0), expected: 0},
+		{name: "policy over fraction", dstSC: "logs", fraction: ptr.To(0.25), expected: 10},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			checker, err := NewOpenEBSDiskSpaceValidatorWithOptions(&rest.Config{}, OpenEBSOptions{
+				Log:             logger,
+				Image:           "image",
+				SrcSC:           "src",
+				DstSC:           tt.dstSC,
+				ReservePolicies: policies,
+				ReserveFraction: tt.fraction,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if reserve := checker.reserve(vol); reserve != tt.expected {
+				t.Errorf("expected reserve %d, %d received instead", tt.expected, reserve)
+			}
+		})
+	}
+}
+
+func TestNewOpenEBSCheckerWithOptions(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	for _, tt := range []struct {
+		name string
+		opts OpenEBSOptions
+		err  string
+	}{
+		{
+			name: "should fail without logger",
+			opts: OpenEBSOptions{Image: "image", SrcSC: "src", DstSC: "dst"},
+			err:  "no logger provided",
+		},
+		{
+			name: "should fail without image",
+			opts: OpenEBSOptions{Log: logger, SrcSC: "src", DstSC: "dst"},
+			err:  "empty image",
+		},
+		{
+			name: "should fail without source storage class",
+			opts: OpenEBSOptions{Log: logger, Image: "image", DstSC: "dst"},
+			err:  "empty source storage class",
+		},
+		{
+			name: "should fail without destination storage class",
+			opts: OpenEBSOptions{Log: logger, Image: "image", SrcSC: "src"},
+			err:  "empty destination storage class",
+		},
+		{
+			name: "should fail with a reserve fraction out of range",
+			opts: OpenEBSOptions{Log: logger, Image: "image", SrcSC: "src", DstSC: "dst", ReserveFraction: ptr.To(1.2)},
+			err:  "invalid reserve fraction 1.2, expected a value between 0 and 1",
+		},
+		{
+			name: "should fail if source and destination storage classes are the same",
+			opts: OpenEBSOptions{Log: logger, Image: "image", SrcSC: "openebs", DstSC: "openebs"},
+			err:  "source and destination storage classes must differ",
+		},
+		{
+			name: "should pass with only mandatory options",
+			opts: OpenEBSOptions{Log: logger, Image: "image", SrcSC: "src", DstSC: "dst"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewOpenEBSDiskSpaceValidatorWithOptions(&rest.Config{}, tt.opts)
+			if err != nil {
+				if len(tt.err) == 0 {
+					t.Errorf("unexpected error: %s", err)
+				} else if err.Error() != tt.err {
+					t.Errorf("expecting %q, %q received instead", tt.err, err)
+				}
+				return
+			}
+
+			if len(tt.err) > 0 {
+				t.Errorf("expecting error %q, nil received instead", tt.err)
+			}
+		})
+	}
+}
+
+func Test_checkStorageClassesExist(t *testing.T) {
+	kcli := fake.NewSimpleClientset(
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "longhorn"}},
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "openebs"}},
+	)
+
+	for _, tt := range []struct {
+		name    string
+		scnames []string
+		err     string
+	}{
+		{
+			name:    "should pass if all storage classes exist",
+			scnames: []string{"longhorn", "openebs"},
+		},
+		{
+			name:    "should report a missing storage class",
+			scnames: []string{"longhorn", "opnebs"},
+			err:     "storage class(es) opnebs not found",
+		},
+		{
+			name:    "should report all missing storage classes",
+			scnames: []string{"rook", "opnebs"},
+			err:     "storage class(es) rook, opnebs not found",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkStorageClassesExist(context.Background(), kcli, tt.scnames...)
+			if err != nil {
+				if tt.err == "" || err.Error() != tt.err {
+					t.Errorf("unexpected error: %s", err)
+				}
+				return
+			}
+			if tt.err != "" {
+				t.Errorf("expecting error %q, nil received instead", tt.err)
+			}
+		})
+	}
+}
+
+func TestNewOpenEBSCheckerWithOptionsDefaults(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	tolerations := []corev1.Toleration{{Key: "key", Operator: corev1.TolerationOpExists}}
+	validator, err := NewOpenEBSDiskSpaceValidatorWithOptions(&rest.Config{}, OpenEBSOptions{
+		Log:      logger,
+		Image:    "image",
+		SrcSC:    "src",
+		DstSC:    "dst",
+		Reserved: 10,
+		Job: OpenEBSJobOptions{
+			Tolerations: tolerations,
+			RunAsPod:    true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if validator.reserved != 10 {
+		t.Errorf("expected reserved to be 10, %d received", validator.reserved)
+	}
+
+	getter := validator.freeSpaceGetter
+	if getter.opts.Namespace != defaultOpenEBSNamespace {
+		t.Errorf("expected default namespace, %q received", getter.opts.Namespace)
+	}
+	if getter.opts.Job.Timeout != defaultOpenEBSJobTimeout {
+		t.Errorf("expected default job timeout, %v received", getter.opts.Job.Timeout)
+	}
+	if getter.opts.PVC.DeletePVTimeout != defaultOpenEBSDeletePVTimeout {
+		t.Errorf("expected default delete pv timeout, %v received", getter.opts.PVC.DeletePVTimeout)
+	}
+	if getter.opts.DstSC != "dst" {
+		t.Errorf("expected getter to measure the destination storage class, %q received", getter.opts.DstSC)
+	}
+	if !getter.opts.Job.RunAsPod {
+		t.Errorf("expected getter to run as pod")
+	}
+
+	expected := []corev1.Toleration{
+		{Key: "node-role.kubernetes.io/control-plane", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+		{Key: "node-role.kubernetes.io/master", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+		{Key: "key", Operator: corev1.TolerationOpExists},
+	}
+	job := getter.buildJob(context.Background(), "node0", "/var/local", "tmppvc")
+	if diff := cmp.Diff(expected, job.Spec.Template.Spec.Tolerations); diff != "" {
+		t.Errorf("unexpected tolerations: %s", diff)
+	}
+}
+
+func TestNodesWithoutSpaceTracing(t *testing.T) {
+	kcli := fake.NewSimpleClientset(
+		kubeSystemNamespace(),
+		&storagev1.StorageClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "openebs",
+				Annotations: map[string]string{
+					"cas.openebs.io/config": "- name: BasePath\n  value: /var/local",
+				},
+			},
+			Provisioner: OpenEBSLocalProvisioner,
+		},
+		&storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: "longhorn"},
+			Provisioner: "driver.longhorn.io",
+		},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node0"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+	)
+
+	// the measurements are served from the cache so no job is executed.
+	cache, err := NewOpenEBSVolumeCache(filepath.Join(t.TempDir(), "cache.json"), time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error creating cache: %s", err)
+	}
+	for node, vol := range map[string]OpenEBSVolume{
+		"node0": {Free: 1000, Used: 1000},
+		"node1": {Free: 100, Used: 1900},
+	} {
+		if err := cache.Set(node, testCacheKey, vol); err != nil {
+			t.Fatalf("unexpected error caching volume: %s", err)
+		}
+	}
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	getter := &OpenEBSFreeDiskSpaceGetter{
+		kcli: kcli,
+		opts: OpenEBSOptions{
+			DstSC: "openebs",
+			Cache: cache,
+			PVC: OpenEBSPVCOptions{
+				DeletePVTimeout: time.Minute,
+			},
+		},
+		runID: "abcd1234",
+		log:   log.New(io.Discard, "", 0),
+	}
+	ochecker := OpenEBSDiskSpaceValidator{
+		kcli:            kcli,
+		log:             log.New(io.Discard, "", 0),
+		freeSpaceGetter: getter,
+		srcSC:           "longhorn",
+		reserved:        500,
+		reserve:         func(OpenEBSVolume) int64 { return 0 },
+		tracer:          provider.Tracer("test"),
+	}
+
+	nodes, err := ochecker.NodesWithoutSpace(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff := cmp.Diff([]string{"node1"}, nodes); diff != "" {
+		t.Errorf("unexpected nodes without space: %s", diff)
+	}
+
+	type spanSummary struct {
+		Name   string
+		Parent string
+		Status codes.Code
+		Attrs  map[string]string
+	}
+
+	spans := exporter.GetSpans()
+	names := map[trace.SpanID]string{}
+	for _, span := range spans {
+		names[span.SpanContext.SpanID()] = span.Name
+	}
+
+	var summaries []spanSummary
+	for _, span := range spans {
+		attrs := map[string]string{}
+		for _, attr := range span.Attributes {
+			attrs[string(attr.Key)] = attr.Value.Emit()
+		}
+		summaries = append(summaries, spanSummary{
+			Name:   span.Name,
+			Parent: names[span.Parent.SpanID()],
+			Status: span.Status.Code,
+			Attrs:  attrs,
+		})
+	}
+
+	expected := []spanSummary{
+		{
+			Name:   "openebs.node",
+			Parent: "openebs.NodesWithoutSpace",
+			Attrs: map[string]string{
+				"kurl.node":           "node0",
+				"kurl.free_bytes":     "1000",
+				"kurl.required_bytes": "500",
+				"kurl.passed":         "true",
+			},
+		},
+		{
+			Name:   "openebs.node",
+			Parent: "openebs.NodesWithoutSpace",
+			Status: codes.Error,
+			Attrs: map[string]string{
+				"kurl.node":           "node1",
+				"kurl.free_bytes":     "100",
+				"kurl.required_bytes": "500",
+				"kurl.passed":         "false",
+			},
+		},
+		{
+			Name: "openebs.NodesWithoutSpace",
+			Attrs: map[string]string{
+				"kurl.source_storage_class":      "longhorn",
+				"kurl.destination_storage_class": "openebs",
+				"kurl.run_id":                    "abcd1234",
+				"kurl.nodes_without_space":       "1",
+			},
+		},
+	}
+	if diff := cmp.Diff(expected, summaries); diff != "" {
+		t.Errorf("unexpected spans: %s", diff)
+	}
+}
+
+func TestNodesSpace(t *testing.T) {
+	kcli := fake.NewSimpleClientset(
+		kubeSystemNamespace(),
+		&storagev1.StorageClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "openebs",
+				Annotations: map[string]string{
+					"cas.openebs.io/config": "- name: BasePath\n  value: /var/local",
+				},
+			},
+			Provisioner: OpenEBSLocalProvisioner,
+		},
+		&storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: "longhorn"},
+			Provisioner: "driver.longhorn.io",
+		},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node0"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "gpu0", Labels: map[string]string{"nvidia.com/gpu": "true"}}},
+	)
+
+	// the measurements are served from the cache so no job is executed.
+	cache, err := NewOpenEBSVolumeCache(filepath.Join(t.TempDir(), "cache.json"), time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error creating cache: %s", err)
+	}
+	for node, vol := range map[string]OpenEBSVolume{
+		"node0": {Free: 1000, Used: 1000},
+		"node1": {Free: 100, Used: 1900, RootVolume: true},
+	} {
+		if err := cache.Set(node, testCacheKey, vol); err != nil {
+			t.Fatalf("unexpected error caching volume: %s", err)
+		}
+	}
+
+	notGPU, err := labels.NewRequirement("nvidia.com/gpu", selection.DoesNotExist, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating requirement: %s", err)
+	}
+	getter := &OpenEBSFreeDiskSpaceGetter{
+		kcli: kcli,
+		opts: OpenEBSOptions{
+			DstSC: "openebs",
+			Cache: cache,
+			PVC: OpenEBSPVCOptions{
+				DeletePVTimeout: time.Minute,
+			},
+			NodeSelector: labels.NewSelector().Add(*notGPU),
+		},
+		log: log.New(io.Discard, "", 0),
+	}
+	ochecker := OpenEBSDiskSpaceValidator{
+		kcli:            kcli,
+		log:             log.New(io.Discard, "", 0),
+		freeSpaceGetter: getter,
+		srcSC:           "longhorn",
+		reserved:        500,
+		reserve:         func(OpenEBSVolume) int64 { return 0 },
+	}
+
+	results, err := ochecker.NodesSpace(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []NodeSpaceResult{
+		{NodeName: "gpu0", Status: NodeSpaceSkipped, SkipReason: `excluded by the node selector "!nvidia.com/gpu"`},
+		{NodeName: "node0", FreeBytes: 1000, UsedBytes: 1000, RequiredBytes: 500, HasEnough: true, Status: NodeSpaceOK},
+		{NodeName: "node1", FreeBytes: 100, UsedBytes: 1900, RequiredBytes: 500, RootVolume: true, Status: NodeSpaceFail},
+	}
+	if diff := cmp.Diff(expected, results); diff != "" {
+		t.Errorf("unexpected results: %s", diff)
+	}
+	if shortfall := results[2].Shortfall(); shortfall != 400 {
+		t.Errorf("expected node1 shortfall of 400 bytes, %d received", shortfall)
+	}
 }
@@ -8,51 +8,137 @@ import (
 	"log"
 	"strconv"
 	"strings"
+	"sync"
 	"text/tabwriter"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/replicatedhq/kurl/pkg/k8sutil"
+	"golang.org/x/sync/semaphore"
+	"golang.org/x/time/rate"
 	"gopkg.in/yaml.v2"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/utils/ptr"
 )
 
+// OpenEBSLocalProvisioner is the provisioner of the storage classes backed by openebs local volumes.
+const OpenEBSLocalProvisioner = "openebs.io/local"
+
+// basePathInaccessibleMarker is printed by the df container, followed by the reason, when the
+// openebs base path can't be accessed inside the container.
+const basePathInaccessibleMarker = "KURL_BASEPATH_INACCESSIBLE:"
+
+// basePathScript returns a script that verifies that the openebs base path, mounted under the
+// provided path, is accessible before executing the provided measurement command.
+func basePathScript(mountPath, measure string) string {
+	return fmt.Sprintf(
+		`if ! reason=$(stat %s 2>&1 >/dev/null && cd %s 2>&1); then echo %q $reason; exit 0; fi; %s`,
+		mountPath, mountPath, basePathInaccessibleMarker, measure,
+	)
+}
+
+// BasePathInaccessibleError is returned when the openebs base path does not exist or can't be
+// traversed on a node.
+type BasePathInaccessibleError struct {
+	BasePath string
+	Node     string
+	Reason   string
+}
+
+// Error returns a user friendly message describing why the base path is inaccessible.
+func (e *BasePathInaccessibleError) Error() string {
+	return fmt.Sprintf("base path %s inaccessible on node %s: %s", e.BasePath, e.Node, e.Reason)
+}
+
+// defaultPseudoFilesystems are the filesystem types that do not back any real storage. mount points
+// using these filesystems are ignored when parsing the node fstab.
+var defaultPseudoFilesystems = []string{
+	"proc", "sysfs", "tmpfs", "devtmpfs", "devpts", "cgroup", "cgroup2", "securityfs",
+	"debugfs", "tracefs", "pstore", "bpf", "mqueue", "hugetlbfs", "configfs", "fusectl",
+	"autofs", "binfmt_misc", "rpc_pipefs", "nsfs",
+}
+
 type OpenEBSFreeDiskSpaceGetter struct {
-	kcli            kubernetes.Interface
-	deletePVTimeout time.Duration
-	scname          string
-	image           string
-	log             *log.Logger
+	// opts are the options the getter has been created with, their defaults already set.
+	opts        OpenEBSOptions
+	kcli        kubernetes.Interface
+	limiter     *rate.Limiter
+	pvcSlots    *semaphore.Weighted
+	tolerations []corev1.Toleration
+	skipped     map[string]string
+	outputs     map[string]map[string][]byte
+	outputsMtx  sync.Mutex
+	runID       string
+	clusterID   string
+	metrics     *spaceCheckMetrics
+	log         *log.Logger
 }
 
 // OpenEBSVolume represents an OpenEBS volume in a node. Holds space related information and
-// a flag indicating if the volume is part of the root (/) volume.
+// a flag indicating if the volume is part of the root (/) volume. ThinPools is only populated
+// when thin pool detection is enabled, it holds all the LVM thin pools found in the node. Health
+// is only populated when filesystem corruption detection is enabled and Runtime when runtime
+// device detection is. Source is the filesystem source, as reported by df, of the mount selected
+// for the base path. BindSource is the directory bound to the base path when it is a bind mount.
 type OpenEBSVolume struct {
-	Free       int64
-	Used       int64
-	RootVolume bool
+	Free       int64          `json:"free"`
+	Used       int64          `json:"used"`
+	RootVolume bool           `json:"rootVolume"`
+	ThinPools  []ThinPool     `json:"thinPools,omitempty"`
+	Health     *FSHealth      `json:"health,omitempty"`
+	Runtime    *RuntimeDevice `json:"runtimeDevice,omitempty"`
+	FSType     string         `json:"fsType,omitempty"`
+	Resolved   string         `json:"resolvedPath,omitempty"`
+	Source     string         `json:"mountSource,omitempty"`
+	BindSource string         `json:"bindSource,omitempty"`
 }
 
 // OpenEBSVolumes attempts to gather the free and used disk space for the openebs volume in
 // all nodes in the cluster. this function creates a temporary pod in each of the nodes of
-// the cluster, the pod runs a "df" command and we parse its output.
+// the cluster, the pod runs a "df" command and we parse its output. if a node exporter source
+// has been configured its metrics are used instead for the nodes where it could be scraped. nodes
+// that can't be measured (e.g. windows nodes without a windows image) are left out of the returned
+// map, SkippedNodes returns them along with the reason. up to the configured parallelism nodes are
+// measured at the same time, the first failure aborts the evaluation of the remaining nodes.
 func (o *OpenEBSFreeDiskSpaceGetter) OpenEBSVolumes(ctx context.Context) (map[string]OpenEBSVolume, error) {
-	nodes, err := o.kcli.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	volumes, _, err := o.measureVolumes(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	return volumes, nil
+}
+
+// measureVolumes measures the openebs volume in all nodes in the cluster, see OpenEBSVolumes. if
+// keepGoing is set a node that fails to be measured does not abort the evaluation of the others,
+// its error is returned in the failures map instead and up to the configured space workers nodes
+// are measured at the same time. the temporary pvcs of all measured nodes are deleted before
+// returning.
+func (o *OpenEBSFreeDiskSpaceGetter) measureVolumes(ctx context.Context, keepGoing bool) (map[string]OpenEBSVolume, map[string]error, error) {
+	nodes, err := o.listNodes(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list nodes: %w", err)
+		return nil, nil, err
 	}
 
-	basePath, err := o.basePath(ctx)
+	basePath, provisioner, err := o.basePath(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read openebs base path: %w", err)
+		return nil, nil, fmt.Errorf("failed to read openebs base path: %w", err)
+	}
+
+	if err := o.validateProvisioner(provisioner); err != nil {
+		return nil, nil, err
 	}
 
+	if err := o.reapOrphans(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to purge orphaned resources: %w", err)
+	}
+	o.loadClusterID(ctx)
+
+	var mtx sync.Mutex
 	var tmpPVCs []*corev1.PersistentVolumeClaim
 	defer func() {
 		o.log.Printf("Deleting temporary pvcs")
@@ -61,74 +147,406 @@ func (o *OpenEBSFreeDiskSpaceGetter) OpenEBSVolumes(ctx context.Context) (map[st
 		}
 	}()
 
+	// node exporter metrics do not carry thin pools, filesystem health or runtime device information
+	// so they are not used when any of these detections is enabled.
+	var scraped map[string]OpenEBSVolume
+	if o.opts.NodeExporter != nil && !o.opts.DetectThinPools && !o.opts.DetectFSCorruption && !o.opts.DetectRuntimeDevice {
+		if scraped, err = o.nodeExporterVolumes(ctx, basePath); err != nil {
+			o.log.Printf("Failed to use node exporter metrics, falling back to df jobs: %s", err)
+		}
+	}
+
+	limit := o.opts.Parallelism
+	if keepGoing {
+		limit = o.opts.SpaceWorkers
+	}
+
+	o.skipped = map[string]string{}
+	o.outputsMtx.Lock()
+	o.outputs = nil
+	o.outputsMtx.Unlock()
 	result := map[string]OpenEBSVolume{}
-	for _, node := range nodes.Items {
-		o.log.Printf("Analyzing free space on node %s", node.Name)
-		if err := o.nodeIsSchedulable(node); err != nil {
-			return nil, fmt.Errorf("failed to assess node %s: %w", node.Name, err)
+	failures := map[string]error{}
+	if err := forEachNodeLimit(ctx, nodes.Items, limit, func(ctx context.Context, node corev1.Node) error {
+		vol, pvc, reason, err := o.measureNode(ctx, node, basePath, scraped)
+
+		mtx.Lock()
+		defer mtx.Unlock()
+		if pvc != nil {
+			tmpPVCs = append(tmpPVCs, pvc)
+		}
+		switch {
+		case err != nil && keepGoing:
+			failures[node.Name] = fmt.Errorf("failed to measure node %s: %w", node.Name, err)
+		case err != nil:
+			return err
+		case reason != "":
+			o.skipped[node.Name] = reason
+		default:
+			result[node.Name] = vol
 		}
+		return nil
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	// with keepGoing set no call fails so forEachNodeLimit does not report the cancellation.
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	return result, failures, nil
+}
 
-		pvc := o.buildTmpPVC(node.Name)
-		if pvc, err = o.kcli.CoreV1().PersistentVolumeClaims("default").Create(
-			ctx, pvc, metav1.CreateOptions{},
-		); err != nil {
-			return nil, fmt.Errorf("failed to create temporary pvc: %w", err)
+// measureNode measures the openebs volume in the provided node, using the scraped node exporter
+// metrics if available for it. if the node can't be measured, or must not be (see measurementFor),
+// the reason it has been skipped is returned instead and its scraped metrics are ignored. the
+// temporary pvc created for the node, if any, is returned even on failure.
+func (o *OpenEBSFreeDiskSpaceGetter) measureNode(ctx context.Context, node corev1.Node, basePath string, scraped map[string]OpenEBSVolume) (OpenEBSVolume, *corev1.PersistentVolumeClaim, string, error) {
+	o.log.Printf("Analyzing free space on node %s", node.Name)
+	measurement, reason := o.measurementFor(node)
+	if measurement == measureSkip {
+		o.log.Printf("Skipping node %s: %s", node.Name, reason)
+		return OpenEBSVolume{}, nil, reason, nil
+	}
+
+	// scraped metrics stand in for the df job, nodes measured otherwise do not use them.
+	if vol, ok := scraped[node.Name]; ok && measurement == measureDF {
+		o.log.Printf("Using node exporter metrics for node %s", node.Name)
+		if err := o.checkFSType(node.Name, vol); err != nil {
+			return OpenEBSVolume{}, nil, "", err
 		}
-		tmpPVCs = append(tmpPVCs, pvc.DeepCopy())
+		if fstype := vol.UnreliableFilesystem(); fstype != "" {
+			o.log.Printf(
+				"Warning: path %s on node %s lives in filesystem type %s, its free space is unreliable",
+				basePath, node.Name, fstype,
+			)
+		}
+		return vol, nil, "", nil
+	}
+
+	started := o.nodeCheckStarted(node)
+	vol, pvc, err := o.nodeVolume(ctx, node, basePath, measurement)
+	if err == nil {
+		err = o.checkFSType(node.Name, vol)
+	}
+	o.nodeCheckFinished(node, started, vol, err)
+	return vol, pvc, "", err
+}
 
-		job := o.buildJob(ctx, node.Name, basePath, pvc.Name)
-		out, status, err := k8sutil.RunJob(ctx, o.kcli, o.log, job, 5*time.Minute)
+// nodeVolume measures the openebs volume in the provided node, either reading it from the cache or
+// running a job in the node. the temporary pvc created for the job, if any, is returned even if the
+// measurement fails so the caller can delete it.
+func (o *OpenEBSFreeDiskSpaceGetter) nodeVolume(ctx context.Context, node corev1.Node, basePath string, measurement nodeMeasurement) (OpenEBSVolume, *corev1.PersistentVolumeClaim, error) {
+	if err := o.nodeIsSchedulable(node); err != nil {
+		return OpenEBSVolume{}, nil, fmt.Errorf("failed to assess node %s: %w", node.Name, err)
+	}
+
+	// cached measurements may not carry thin pools, filesystem health or runtime device information
+	// so they are not used when any of these detections is enabled. measurements cached without the
+	// filesystem type are not used either when the filesystem types are restricted.
+	if o.useCache() && !o.opts.DetectThinPools && !o.opts.DetectFSCorruption && !o.opts.DetectRuntimeDevice {
+		if vol, ok := o.opts.Cache.Get(node.Name, o.cacheKey(basePath)); ok && (len(o.opts.Output.AllowedFSTypes) == 0 || vol.FSType != "") {
+			o.log.Printf("Using cached measurement for node %s", node.Name)
+			return vol, nil, nil
+		}
+	}
+
+	if measurement == measureWindows {
+		vol, err := o.windowsVolume(ctx, node.Name)
 		if err != nil {
+			return OpenEBSVolume{}, nil, err
+		}
+		o.cacheVolume(node.Name, basePath, vol)
+		return vol, nil, nil
+	}
+
+	release, err := o.acquirePVCSlot(ctx)
+	if err != nil {
+		return OpenEBSVolume{}, nil, err
+	}
+	defer release()
+
+	pvc, err := o.createTmpPVC(ctx, o.buildTmpPVC(node.Name))
+	if err != nil {
+		return OpenEBSVolume{}, nil, fmt.Errorf("failed to create temporary pvc: %w", err)
+	}
+	pvc = pvc.DeepCopy()
+
+	job := o.buildJob(ctx, node.Name, basePath, pvc.Name)
+	out, status, err := o.runJob(ctx, job)
+	o.recordJobOutput(node.Name, out)
+	if err != nil {
+		o.logContainersState(out, status)
+		return OpenEBSVolume{}, pvc, fmt.Errorf(
+			"failed to run job %s/%s on node %s: %w", job.Namespace, job.Name, node.Name, err,
+		)
+	}
+
+	dfOutput, err := decodeOutput(out["df"])
+	if err != nil {
+		o.logContainersState(out, status)
+		return OpenEBSVolume{}, pvc, fmt.Errorf(
+			"failed to read node %s df output: %w", node.Name, err,
+		)
+	}
+
+	if reason, inaccessible := o.parseBasePathInaccessible(dfOutput); inaccessible {
+		return OpenEBSVolume{}, pvc, &BasePathInaccessibleError{
+			BasePath: basePath,
+			Node:     node.Name,
+			Reason:   reason,
+		}
+	}
+
+	free, used, source, err := o.parseFreeSpace(dfOutput)
+	if err != nil {
+		o.logContainersState(out, status)
+		return OpenEBSVolume{}, pvc, fmt.Errorf(
+			"failed to parse node %s df output: %w", node.Name, err,
+		)
+	}
+
+	fstab, err := o.parseFstabEntries(out["fstab"])
+	if err != nil {
+		o.logContainersState(out, status)
+		return OpenEBSVolume{}, pvc, fmt.Errorf(
+			"failed to parse node %s fstab output: %w", node.Name, err,
+		)
+	}
+
+	// when a node path is resolved the volume is the filesystem holding the resolved path.
+	measured, resolved := basePath, ""
+	if o.opts.Output.ResolvePath != "" {
+		if resolved, err = parseResolvedPath(dfOutput); err != nil {
+			return OpenEBSVolume{}, pvc, fmt.Errorf("failed to resolve %s on node %s: %w", o.opts.Output.ResolvePath, node.Name, err)
+		}
+		o.log.Printf("Path %s resolved to %s on node %s", o.opts.Output.ResolvePath, resolved, node.Name)
+		measured = resolved
+	}
+
+	// bind mounted paths live in the filesystem of the directory bound to them.
+	backing, bindSource := resolveBindMounts(fstab, measured), ""
+	if backing != measured {
+		o.log.Printf("Path %s is bind mounted from %s on node %s", measured, backing, node.Name)
+		bindSource = backing
+	}
+	rootVolume := isRootVolume(fstab, backing)
+
+	var thinPools []ThinPool
+	if o.opts.DetectThinPools {
+		if thinPools, err = parseLVSOutput(out["lvs"]); err != nil {
 			o.logContainersState(out, status)
-			return nil, fmt.Errorf(
-				"failed to run job %s/%s on node %s: %w", job.Namespace, job.Name, node.Name, err,
+			return OpenEBSVolume{}, pvc, fmt.Errorf(
+				"failed to parse node %s lvs output: %w", node.Name, err,
 			)
 		}
+	}
 
-		free, used, err := o.parseDFContainerOutput(out["df"])
+	var health *FSHealth
+	if o.opts.DetectFSCorruption {
+		nodeHealth, err := parseFSHealthOutput(out["fshealth"], basePath)
 		if err != nil {
 			o.logContainersState(out, status)
-			return nil, fmt.Errorf(
-				"failed to parse node %s df output: %w", node.Name, err,
+			return OpenEBSVolume{}, pvc, fmt.Errorf(
+				"failed to parse node %s fs health output: %w", node.Name, err,
 			)
 		}
+		health = &nodeHealth
+	}
 
-		volumes, err := o.parseFstabContainerOutput(out["fstab"])
+	var runtime *RuntimeDevice
+	if o.opts.DetectRuntimeDevice {
+		nodeRuntime, err := parseRuntimeDeviceOutput(out["runtime"], measured)
 		if err != nil {
 			o.logContainersState(out, status)
-			return nil, fmt.Errorf(
-				"failed to parse node %s fstab output: %w", node.Name, err,
+			return OpenEBSVolume{}, pvc, fmt.Errorf(
+				"failed to parse node %s runtime device output: %w", node.Name, err,
 			)
 		}
+		runtime = &nodeRuntime
+	}
 
-		rootVolume := true
-		for _, mount := range volumes {
-			if mount != "/" && strings.HasPrefix(basePath, mount) {
-				rootVolume = false
-				break
-			}
-		}
+	vol := OpenEBSVolume{
+		Free:       free,
+		Used:       used,
+		RootVolume: rootVolume,
+		ThinPools:  thinPools,
+		Health:     health,
+		Runtime:    runtime,
+		FSType:     fstabFilesystemType(out["fstab"], backing),
+		Resolved:   resolved,
+		Source:     source,
+		BindSource: bindSource,
+	}
+	if fstype := vol.UnreliableFilesystem(); fstype != "" {
+		o.log.Printf(
+			"Warning: path %s on node %s lives in filesystem type %s, its free space is unreliable",
+			measured, node.Name, fstype,
+		)
+	}
+	o.cacheVolume(node.Name, basePath, vol)
+	return vol, pvc, nil
+}
 
-		result[node.Name] = OpenEBSVolume{
-			Free:       free,
-			Used:       used,
-			RootVolume: rootVolume,
+// RunID returns the identifier generated for this getter. all the jobs, pods and temporary pvcs
+// it creates are labeled with it (OpenEBSRunIDLabel) and all its log lines are prefixed with it.
+func (o *OpenEBSFreeDiskSpaceGetter) RunID() string {
+	return o.runID
+}
+
+// SkippedNodes returns the nodes left out by the last OpenEBSVolumes call indexed by node name. the
+// values are the reasons why each node has been skipped.
+func (o *OpenEBSFreeDiskSpaceGetter) SkippedNodes() map[string]string {
+	return o.skipped
+}
+
+// loadClusterID reads the uid of the kube-system namespace, identifying the cluster the cached
+// measurements belong to. the cache is not used if it can't be read, see useCache.
+func (o *OpenEBSFreeDiskSpaceGetter) loadClusterID(ctx context.Context) {
+	if o.opts.Cache == nil || o.clusterID != "" {
+		return
+	}
+
+	ns, err := o.kcli.CoreV1().Namespaces().Get(ctx, metav1.NamespaceSystem, metav1.GetOptions{})
+	if err != nil {
+		o.log.Printf("Not using cached measurements, failed to identify the cluster: %s", err)
+		return
+	}
+	o.clusterID = string(ns.UID)
+}
+
+// useCache returns true if a cache has been configured and the cluster it is used for is known.
+func (o *OpenEBSFreeDiskSpaceGetter) useCache() bool {
+	return o.opts.Cache != nil && o.clusterID != ""
+}
+
+// cacheKey returns the key the measurements of the provided base path are cached under. besides the
+// base path it holds the cluster id, so clusters sharing node names don't reuse each other
+// measurements, and every setting changing which filesystem is measured or how its output is read,
+// so measurements taken with different settings never replace each other. settings left with their
+// default values are not included.
+func (o *OpenEBSFreeDiskSpaceGetter) cacheKey(basePath string) string {
+	key := basePath
+	for _, setting := range []struct {
+		name, value, defaultValue string
+	}{
+		{"cluster", o.clusterID, ""},
+		{"resolve", o.opts.Output.ResolvePath, ""},
+		{"match", string(o.opts.Output.MountMatch), string(MountMatchExact)},
+		{"source", o.opts.Output.MountSource, ""},
+		{"statfs", o.opts.Output.StatfsBinary, ""},
+		{"drive", o.opts.WindowsDrive, defaultWindowsDrive},
+	} {
+		if setting.value == "" || setting.value == setting.defaultValue {
+			continue
 		}
+		key = fmt.Sprintf("%s;%s=%s", key, setting.name, setting.value)
+	}
+	return key
+}
+
+// cacheVolume stores the node measurement in the cache, if one has been configured. see cacheKey.
+func (o *OpenEBSFreeDiskSpaceGetter) cacheVolume(node, basePath string, vol OpenEBSVolume) {
+	if !o.useCache() {
+		return
+	}
+	if err := o.opts.Cache.Set(node, o.cacheKey(basePath), vol); err != nil {
+		o.log.Printf("Failed to cache measurement for node %s: %s", node, err)
+	}
+}
+
+// RequiredPermissions returns the list of permissions the current credentials must have in order
+// to gather the openebs volumes free space.
+func (o *OpenEBSFreeDiskSpaceGetter) RequiredPermissions() []k8sutil.Permission {
+	perms := []k8sutil.Permission{
+		{Verb: "list", Resource: "nodes"},
+		{Verb: "get", Group: "storage.k8s.io", Resource: "storageclasses"},
+		{Verb: "list", Resource: "persistentvolumes"},
+		{Verb: "get", Resource: "persistentvolumes"},
+		{Verb: "delete", Resource: "persistentvolumes"},
+		{Verb: "create", Resource: "persistentvolumeclaims", Namespace: o.opts.Namespace},
+		{Verb: "delete", Resource: "persistentvolumeclaims", Namespace: o.opts.Namespace},
+	}
+
+	if o.opts.PVC.Reuse {
+		perms = append(perms, k8sutil.Permission{Verb: "get", Resource: "persistentvolumeclaims", Namespace: o.opts.Namespace})
 	}
-	return result, nil
+
+	if o.opts.Recorder != nil {
+		perms = append(perms, k8sutil.Permission{Verb: "create", Resource: "events", Namespace: o.opts.Namespace})
+	}
+
+	if o.opts.Cache != nil {
+		perms = append(perms, k8sutil.Permission{Verb: "get", Resource: "namespaces"})
+	}
+
+	if o.opts.PurgeOrphans {
+		perms = append(
+			perms,
+			k8sutil.Permission{Verb: "list", Resource: "persistentvolumeclaims", Namespace: o.opts.Namespace},
+			k8sutil.Permission{Verb: "list", Group: "batch", Resource: "jobs", Namespace: o.opts.Namespace},
+			k8sutil.Permission{Verb: "delete", Group: "batch", Resource: "jobs", Namespace: o.opts.Namespace},
+			k8sutil.Permission{Verb: "get", Group: "batch", Resource: "jobs", Namespace: o.opts.Namespace},
+			k8sutil.Permission{Verb: "list", Resource: "pods", Namespace: o.opts.Namespace},
+			k8sutil.Permission{Verb: "delete", Resource: "pods", Namespace: o.opts.Namespace},
+			k8sutil.Permission{Verb: "get", Resource: "pods", Namespace: o.opts.Namespace},
+		)
+	}
+
+	if o.opts.Job.RunAsPod {
+		return append(
+			perms,
+			k8sutil.Permission{Verb: "create", Resource: "pods", Namespace: o.opts.Namespace},
+			k8sutil.Permission{Verb: "get", Resource: "pods", Namespace: o.opts.Namespace},
+			k8sutil.Permission{Verb: "delete", Resource: "pods", Namespace: o.opts.Namespace},
+			k8sutil.Permission{Verb: "get", Resource: "pods/log", Namespace: o.opts.Namespace},
+		)
+	}
+
+	return append(
+		perms,
+		k8sutil.Permission{Verb: "create", Group: "batch", Resource: "jobs", Namespace: o.opts.Namespace},
+		k8sutil.Permission{Verb: "get", Group: "batch", Resource: "jobs", Namespace: o.opts.Namespace},
+		k8sutil.Permission{Verb: "delete", Group: "batch", Resource: "jobs", Namespace: o.opts.Namespace},
+		k8sutil.Permission{Verb: "list", Resource: "pods", Namespace: o.opts.Namespace},
+		k8sutil.Permission{Verb: "get", Resource: "pods/log", Namespace: o.opts.Namespace},
+	)
+}
+
+// MissingPermissions verifies, through SelfSubjectAccessReviews, which of the required permissions
+// are not granted to the current credentials.
+func (o *OpenEBSFreeDiskSpaceGetter) MissingPermissions(ctx context.Context) ([]k8sutil.Permission, error) {
+	return k8sutil.MissingPermissions(ctx, o.kcli, o.RequiredPermissions())
 }
 
 // basePath inspects the destination storage class and checks what is the openebs base path
-// configured for the storage.
-func (o *OpenEBSFreeDiskSpaceGetter) basePath(ctx context.Context) (string, error) {
-	sclass, err := o.kcli.StorageV1().StorageClasses().Get(ctx, o.scname, metav1.GetOptions{})
+// configured for the storage. the storage class provisioner is returned as well. placeholders in
+// templated base paths are replaced by the configured base path variables, an error is returned
+// if any of them can't be resolved. the storage class must be read within the configured storage
+// class timeout, see getStorageClass.
+func (o *OpenEBSFreeDiskSpaceGetter) basePath(ctx context.Context) (string, string, error) {
+	sclass, err := o.getStorageClass(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to read destination storage class: %w", err)
+		return "", "", storageClassReadError(o.opts.DstSC, err, "failed to read destination storage class: %w")
+	}
+
+	basePath, err := parseOpenEBSBasePath(sclass, o.opts.BasePathVars)
+	if err != nil {
+		return "", "", err
+	}
+	return basePath, sclass.Provisioner, nil
+}
+
+// parseOpenEBSBasePath returns the base path found in the openebs config annotation of the provided
+// storage class, with its placeholders replaced by vars. all the returned errors are BasePathErrors.
+func parseOpenEBSBasePath(sclass *storagev1.StorageClass, vars map[string]string) (string, error) {
+	fail := func(kind, err error) (string, error) {
+		return "", &BasePathError{StorageClass: sclass.Name, Kind: kind, Err: err}
 	}
 
 	cfg, ok := sclass.Annotations["cas.openebs.io/config"]
 	if !ok {
-		return "", fmt.Errorf("cas.openebs.io/config annotation not found in storage class")
+		return fail(ErrOpenEBSConfigNotFound, fmt.Errorf("cas.openebs.io/config annotation not found in storage class"))
 	}
 
 	var pairs = []struct {
@@ -136,7 +554,7 @@ func (o *OpenEBSFreeDiskSpaceGetter) basePath(ctx context.Context) (string, erro
 		Value string `yaml:"value"`
 	}{}
 	if err := yaml.Unmarshal([]byte(cfg), &pairs); err != nil {
-		return "", fmt.Errorf("failed to parse openebs config annotation: %w", err)
+		return fail(ErrOpenEBSConfigInvalid, fmt.Errorf("failed to parse openebs config annotation: %w", err))
 	}
 
 	for _, p := range pairs {
@@ -144,12 +562,30 @@ func (o *OpenEBSFreeDiskSpaceGetter) basePath(ctx context.Context) (string, erro
 			continue
 		}
 
-		if !strings.HasPrefix(p.Value, "/") {
-			return "", fmt.Errorf("invalid opeenbs base path: %s", p.Value)
+		value, err := resolveBasePathPlaceholders(p.Value, vars)
+		if err != nil {
+			return fail(ErrBasePathUnresolved, err)
+		}
+
+		if !strings.HasPrefix(value, "/") {
+			return fail(ErrBasePathInvalid, fmt.Errorf("invalid opeenbs base path: %s", value))
 		}
-		return p.Value, nil
+		return value, nil
 	}
-	return "", fmt.Errorf("openebs base path not defined in the storage class")
+	return fail(ErrBasePathNotDefined, fmt.Errorf("openebs base path not defined in the storage class"))
+}
+
+// validateProvisioner verifies that the destination storage class is backed by the openebs local
+// volume provisioner. measuring a storage class backed by any other provisioner yields meaningless
+// results.
+func (o *OpenEBSFreeDiskSpaceGetter) validateProvisioner(provisioner string) error {
+	if provisioner != OpenEBSLocalProvisioner {
+		return fmt.Errorf(
+			"storage class %s uses provisioner %s, expected %s",
+			o.opts.DstSC, provisioner, OpenEBSLocalProvisioner,
+		)
+	}
+	return nil
 }
 
 // nodeIsSchedulable verifies if the node has been flagged with some well known annotations.
@@ -172,61 +608,153 @@ func (o *OpenEBSFreeDiskSpaceGetter) nodeIsSchedulable(node corev1.Node) error {
 	return nil
 }
 
-// buildTmpPVC creates a temporary PVC requesting for 1Mi of space.
+// buildTmpPVC creates a temporary PVC requesting the configured size, 1Mi by default. the pvc is named after the node
+// with a random suffix, unless pvcs are reused.
 func (o *OpenEBSFreeDiskSpaceGetter) buildTmpPVC(node string) *corev1.PersistentVolumeClaim {
-	tmp := uuid.New().String()[:5]
-	pvcName := fmt.Sprintf("disk-free-%s-%s", node, tmp)
+	pvcName := fmt.Sprintf("disk-free-%s", node)
+	if !o.opts.PVC.Reuse {
+		pvcName = fmt.Sprintf("%s-%s", pvcName, uuid.New().String()[:5])
+	}
 	if len(pvcName) > 63 {
 		pvcName = pvcName[0:31] + pvcName[len(pvcName)-32:]
 	}
 
+	size := o.opts.PVC.Size.DeepCopy()
+	if size.IsZero() {
+		size = defaultOpenEBSTmpPVCSize.DeepCopy()
+	}
+
 	return &corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      pvcName,
-			Namespace: "default",
+			Namespace: o.opts.Namespace,
+			Labels:    o.resourceLabels(node),
 		},
 		Spec: corev1.PersistentVolumeClaimSpec{
-			StorageClassName: ptr.To(o.scname),
+			StorageClassName: ptr.To(o.opts.DstSC),
 			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
 			Resources: corev1.VolumeResourceRequirements{
 				Requests: corev1.ResourceList{
-					corev1.ResourceStorage: resource.MustParse("1Mi"),
+					corev1.ResourceStorage: size,
 				},
 			},
 		},
 	}
 }
 
-// buildJob returns a job scheduled to run in provided node. this job runs a pod with two
-// containers, one to capture the disk size and the other to capture the content of the
-// node fstab. timeout for the job is 2 minutes as in some cases we need to pull the image
-// and then it takes longer to boostrap the job pod. this job also mounts the provided temp
-// pvc, this is done to make sure that the openebs has created the base path inside the node
-// (it only creates it when some kind of allocation already happened in the node).
-func (o *OpenEBSFreeDiskSpaceGetter) buildJob(_ context.Context, node, basePath, tmpPVC string) *batchv1.Job {
-	schedRules := &corev1.NodeSelector{
-		NodeSelectorTerms: []corev1.NodeSelectorTerm{
-			{
-				MatchExpressions: []corev1.NodeSelectorRequirement{
+// nodeAffinity returns an affinity that makes pods to be scheduled only on the provided node.
+func nodeAffinity(node string) *corev1.Affinity {
+	return &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{
 					{
-						Key:      "kubernetes.io/hostname",
-						Operator: corev1.NodeSelectorOperator("In"),
-						Values:   []string{node},
+						MatchExpressions: []corev1.NodeSelectorRequirement{
+							{
+								Key:      "kubernetes.io/hostname",
+								Operator: corev1.NodeSelectorOperator("In"),
+								Values:   []string{node},
+							},
+						},
 					},
 				},
 			},
 		},
 	}
+}
+
+// jobName returns a random name for a job running on the provided node. the name is kept within
+// the 63 characters limit.
+func jobName(node string) string {
+	tmp := uuid.New().String()[:5]
+	name := fmt.Sprintf("disk-free-%s-%s", node, tmp)
+	if len(name) > 63 {
+		name = name[0:31] + name[len(name)-32:]
+	}
+	return name
+}
+
+// runLabels returns the labels identifying the resources created by this getter run. returns nil
+// if no run id has been generated.
+func (o *OpenEBSFreeDiskSpaceGetter) runLabels() map[string]string {
+	if o.runID == "" {
+		return nil
+	}
+	return map[string]string{OpenEBSRunIDLabel: o.runID}
+}
+
+// resourceLabels returns the labels identifying the resources created by this getter run for the
+// provided node: the run id labels along with the managed-by, component and node labels.
+func (o *OpenEBSFreeDiskSpaceGetter) resourceLabels(node string) map[string]string {
+	labels := map[string]string{
+		OpenEBSManagedByLabel: OpenEBSManagedBy,
+		OpenEBSComponentLabel: OpenEBSComponent,
+		OpenEBSNodeLabel:      nodeLabelValue(node),
+	}
+	for key, value := range o.runLabels() {
+		labels[key] = value
+	}
+	return labels
+}
+
+// nodeLabelValue returns the provided node name as a valid label value. node names may be longer
+// than the 63 characters allowed in label values so they are truncated, trailing characters that
+// can't end a label value are trimmed.
+func nodeLabelValue(node string) string {
+	if len(node) > 63 {
+		node = node[:63]
+	}
+	return strings.TrimRight(node, "-_.")
+}
+
+// jobLabels returns the labels set in the jobs, and in their pods, running on the provided node. the
+// labels provided through the options are merged with the "app" and resource labels, used to
+// identify our jobs, which can't be overridden.
+func (o *OpenEBSFreeDiskSpaceGetter) jobLabels(node string) map[string]string {
+	labels := map[string]string{}
+	for key, value := range o.opts.Job.Labels {
+		labels[key] = value
+	}
+	for key, value := range o.resourceLabels(node) {
+		labels[key] = value
+	}
+	labels["app"] = OpenEBSJobAppLabel
+	return labels
+}
 
+// jobAnnotations returns a copy of the annotations set in the jobs and in their pods. returns nil
+// if no annotations have been provided.
+func (o *OpenEBSFreeDiskSpaceGetter) jobAnnotations() map[string]string {
+	if len(o.opts.Job.Annotations) == 0 {
+		return nil
+	}
+
+	annotations := map[string]string{}
+	for key, value := range o.opts.Job.Annotations {
+		annotations[key] = value
+	}
+	return annotations
+}
+
+// buildJob returns a job scheduled to run in provided node. this job runs a pod with two
+// containers, one to capture the disk size and the other to capture the content of the
+// node fstab. timeout for the job is 2 minutes as in some cases we need to pull the image
+// and then it takes longer to boostrap the job pod. this job also mounts the provided temp
+// pvc, this is done to make sure that the openebs has created the base path inside the node
+// (it only creates it when some kind of allocation already happened in the node). if thin pool
+// detection is enabled a third privileged container lists the node lvm logical volumes while if
+// filesystem corruption detection is enabled a privileged container dumps the node mount table
+// and kernel log. runtime device detection adds a privileged container printing the container
+// runtime data roots and the node mountinfo. if a node path is resolved the node root filesystem
+// is mounted read only in the df container.
+func (o *OpenEBSFreeDiskSpaceGetter) buildJob(_ context.Context, node, basePath, tmpPVC string) *batchv1.Job {
 	typeDir := corev1.HostPathDirectory
 	typeFile := corev1.HostPathFile
 	podSpec := corev1.PodSpec{
-		RestartPolicy: corev1.RestartPolicyNever,
-		Affinity: &corev1.Affinity{
-			NodeAffinity: &corev1.NodeAffinity{
-				RequiredDuringSchedulingIgnoredDuringExecution: schedRules,
-			},
-		},
+		RestartPolicy:    corev1.RestartPolicyNever,
+		Tolerations:      o.tolerations,
+		Affinity:         nodeAffinity(node),
+		ImagePullSecrets: o.imagePullSecrets(),
 		Volumes: []corev1.Volume{
 			{
 				Name: "openebs",
@@ -258,12 +786,12 @@ func (o *OpenEBSFreeDiskSpaceGetter) buildJob(_ context.Context, node, basePath,
 		Containers: []corev1.Container{
 			{
 				Name:    "df",
-				Image:   o.image,
-				Command: []string{"df"},
-				Args:    []string{"-B1", "/data"},
+				Image:   o.opts.Image,
+				Command: []string{"/bin/sh", "-c"},
+				Args:    []string{encodeOutputCommand(o.measureCommand())},
 				VolumeMounts: []corev1.VolumeMount{
 					{
-						MountPath: "/data",
+						MountPath: o.dfMountPath(),
 						Name:      "openebs",
 						ReadOnly:  true,
 					},
@@ -276,7 +804,7 @@ func (o *OpenEBSFreeDiskSpaceGetter) buildJob(_ context.Context, node, basePath,
 			},
 			{
 				Name:    "fstab",
-				Image:   o.image,
+				Image:   o.opts.Image,
 				Command: []string{"cat"},
 				Args:    []string{"/node/etc/fstab"},
 				VolumeMounts: []corev1.VolumeMount{
@@ -290,46 +818,182 @@ func (o *OpenEBSFreeDiskSpaceGetter) buildJob(_ context.Context, node, basePath,
 		},
 	}
 
-	tmp := uuid.New().String()[:5]
-	jobName := fmt.Sprintf("disk-free-%s-%s", node, tmp)
-	if len(jobName) > 63 {
-		jobName = jobName[0:31] + jobName[len(jobName)-32:]
+	if o.opts.Output.ResolvePath != "" {
+		// the path is resolved and measured chrooted into the node root filesystem.
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: "host",
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Type: &typeDir,
+					Path: "/",
+				},
+			},
+		})
+		podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, corev1.VolumeMount{
+			MountPath: "/host",
+			Name:      "host",
+			ReadOnly:  true,
+		})
+	}
+
+	if o.opts.DetectThinPools {
+		// the lvs container needs to enter the host mount namespace to use the node lvm tooling.
+		podSpec.HostPID = true
+		podSpec.Containers = append(podSpec.Containers, corev1.Container{
+			Name:    "lvs",
+			Image:   o.opts.Image,
+			Command: []string{"/bin/sh", "-c"},
+			Args:    []string{lvsCommand},
+			SecurityContext: &corev1.SecurityContext{
+				Privileged: ptr.To(true),
+			},
+		})
+	}
+
+	if o.opts.DetectFSCorruption {
+		// reading the node mount table and kernel log requires the host mount namespace.
+		podSpec.HostPID = true
+		podSpec.Containers = append(podSpec.Containers, corev1.Container{
+			Name:    "fshealth",
+			Image:   o.opts.Image,
+			Command: []string{"/bin/sh", "-c"},
+			Args:    []string{fsHealthCommand},
+			SecurityContext: &corev1.SecurityContext{
+				Privileged: ptr.To(true),
+			},
+		})
+	}
+
+	if o.opts.DetectRuntimeDevice {
+		// the runtime configuration and the node mountinfo are only visible in the host mount
+		// namespace.
+		podSpec.HostPID = true
+		podSpec.Containers = append(podSpec.Containers, corev1.Container{
+			Name:    "runtime",
+			Image:   o.opts.Image,
+			Command: []string{"/bin/sh", "-c"},
+			Args:    []string{runtimeDeviceCommand},
+			SecurityContext: &corev1.SecurityContext{
+				Privileged: ptr.To(true),
+			},
+		})
 	}
 
 	return &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      jobName,
-			Namespace: "default",
-			Labels: map[string]string{
-				"app": "kurl-job-openebs-disk-free",
-			},
+			Name:        jobName(node),
+			Namespace:   o.opts.Namespace,
+			Labels:      o.jobLabels(node),
+			Annotations: o.jobAnnotations(),
 		},
 		Spec: batchv1.JobSpec{
 			BackoffLimit:          ptr.To(int32(1)),
-			ActiveDeadlineSeconds: ptr.To(int64(120)),
+			ActiveDeadlineSeconds: activeDeadlineSeconds(o.opts.Job.Timeout),
 			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      o.jobLabels(node),
+					Annotations: o.jobAnnotations(),
+				},
 				Spec: podSpec,
 			},
 		},
 	}
 }
 
-// deleteTmpPVCs deletes the provided pvcs from the default namespace and waits until all their
+// buildPod returns a bare pod using the provided job pod template. this is used when the getter
+// has been configured to run pods instead of jobs (some clusters disallow jobs through policies).
+func (o *OpenEBSFreeDiskSpaceGetter) buildPod(job *batchv1.Job) *corev1.Pod {
+	spec := job.Spec.Template.Spec.DeepCopy()
+	spec.RestartPolicy = corev1.RestartPolicyNever
+	spec.ActiveDeadlineSeconds = job.Spec.ActiveDeadlineSeconds
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        job.Name,
+			Namespace:   job.Namespace,
+			Labels:      job.Labels,
+			Annotations: job.Annotations,
+		},
+		Spec: *spec,
+	}
+}
+
+// runJob runs the provided job and returns its containers logs and states. jobs whose pod can't be
+// scheduled before the job timeout are retried, with a new name, up to the configured number of
+// retries with an exponential backoff. jobs that ran and failed are not retried.
+func (o *OpenEBSFreeDiskSpaceGetter) runJob(ctx context.Context, job *batchv1.Job) (map[string][]byte, map[string]corev1.ContainerState, error) {
+	var out map[string][]byte
+	var status map[string]corev1.ContainerState
+	retries := ptr.Deref(o.opts.Job.Retries, 0)
+	err := retryUnschedulable(ctx, retries, jobRetryBackoff, func(attempt int) error {
+		if attempt > 0 {
+			previous := job.Name
+			job.Name = retryJobName(job.Name)
+			o.log.Printf("Pod of job %s/%s not scheduled, retrying as %s (retry %d of %d)", job.Namespace, previous, job.Name, attempt, retries)
+		}
+
+		var err error
+		out, status, err = o.runJobOnce(ctx, job)
+		return err
+	})
+	return out, status, err
+}
+
+// runJobOnce runs the provided job and returns its containers logs and states. if the getter has
+// been configured to run pods instead of jobs then a bare pod is created using the job pod template.
+// the creation is delayed as needed to respect the configured creation rate. the containers logs are
+// streamed while the job runs (see streamJobLogs) so if the job fails without its logs being read,
+// e.g. it times out, the logs streamed so far are returned instead and included in the error.
+func (o *OpenEBSFreeDiskSpaceGetter) runJobOnce(ctx context.Context, job *batchv1.Job) (map[string][]byte, map[string]corev1.ContainerState, error) {
+	if err := o.waitCreate(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	stop := o.streamJobLogs(ctx, job)
+
+	var out map[string][]byte
+	var status map[string]corev1.ContainerState
+	var err error
+	if o.opts.Job.RunAsPod {
+		out, status, err = k8sutil.RunPod(ctx, o.kcli, o.log, o.buildPod(job), o.opts.Job.Timeout)
+	} else {
+		out, status, err = k8sutil.RunJob(ctx, o.kcli, o.log, job.DeepCopy(), o.opts.Job.Timeout)
+	}
+
+	streamed := stop()
+	if err != nil && out == nil && len(streamed) > 0 {
+		return streamed, status, partialOutputError(err, job.Name, streamed)
+	}
+	return out, status, err
+}
+
+// deleteTmpPVCs deletes the provided pvcs from the getter namespace and waits until all their
 // backing pvs disappear as well (this is mandatory so we don't leave any orphan pv as this would
-// make the pvmigrate to fail). this function has a timeout of 5 minutes, after that an error is
-// returned.
+// make the pvmigrate to fail). deletions failing with transient api errors (conflicts, throttling,
+// timeouts) are retried and the pvs are polled, both with an exponential backoff (see
+// tmpPVCCleanupBackoff). the whole cleanup is bounded by the configured delete pv timeout (see
+// OpenEBSPVCOptions.DeletePVTimeout), after that an error is returned. if the getter has been
+// configured to skip the pv wait only the pvcs are deleted. pvs with a Retain reclaim policy are
+// never removed by the provisioner, they are deleted explicitly instead of waited for. the cleanup
+// client is used so the resources are removed even if the measuring client gave up on the api.
 func (o *OpenEBSFreeDiskSpaceGetter) deleteTmpPVCs(pvcs []*corev1.PersistentVolumeClaim) error {
 	// Cleanup should use background context so as not to fail if context has already been canceled
 	ctx := context.Background()
 
-	pvs, err := o.kcli.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	deletePVTimeout := o.opts.PVC.DeletePVTimeout
+	if deletePVTimeout <= 0 {
+		deletePVTimeout = defaultOpenEBSDeletePVTimeout
+	}
+	timeout := time.NewTimer(deletePVTimeout)
+	defer timeout.Stop()
+
+	pvs, err := o.cleanupClient().CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to list persistent volumes: %w", err)
 	}
 
 	pvsByPVCName := map[string]corev1.PersistentVolume{}
 	for _, pv := range pvs.Items {
-		if pv.Spec.ClaimRef == nil || pv.Spec.ClaimRef.Namespace != "default" {
+		if pv.Spec.ClaimRef == nil || pv.Spec.ClaimRef.Namespace != o.opts.Namespace {
 			continue
 		}
 		pvsByPVCName[pv.Spec.ClaimRef.Name] = pv
@@ -337,24 +1001,28 @@ func (o *OpenEBSFreeDiskSpaceGetter) deleteTmpPVCs(pvcs []*corev1.PersistentVolu
 
 	var waitFor []string
 	for _, pvc := range pvcs {
-		propagation := metav1.DeletePropagationForeground
-		delopts := metav1.DeleteOptions{PropagationPolicy: &propagation}
-		if err := o.kcli.CoreV1().PersistentVolumeClaims("default").Delete(
-			ctx, pvc.Name, delopts,
-		); err != nil {
-			if errors.IsNotFound(err) {
-				continue
-			}
-			o.log.Printf("failed to delete temp pvc %s: %s", pvc.Name, err)
+		deleted, err := o.deleteTmpPVC(ctx, pvc.Name, timeout.C)
+		if err != nil {
+			return err
+		}
+		if !deleted {
+			continue
+		}
+
+		if pv, ok := pvsByPVCName[pvc.Name]; ok && pv.Spec.PersistentVolumeReclaimPolicy == corev1.PersistentVolumeReclaimRetain {
+			o.deleteRetainedPV(ctx, pv)
 			continue
 		}
 		waitFor = append(waitFor, pvc.Name)
 	}
 
-	timeout := time.NewTicker(o.deletePVTimeout)
-	interval := time.NewTicker(5 * time.Second)
-	defer timeout.Stop()
-	defer interval.Stop()
+	if o.opts.PVC.SkipPVWait {
+		if len(waitFor) > 0 {
+			o.log.Printf("Not waiting for temporary pvs to be deleted, cleanup deferred to the provisioner")
+		}
+		return nil
+	}
+
 	for _, pvc := range waitFor {
 		pv, ok := pvsByPVCName[pvc]
 		if !ok {
@@ -362,9 +1030,10 @@ func (o *OpenEBSFreeDiskSpaceGetter) deleteTmpPVCs(pvcs []*corev1.PersistentVolu
 			continue
 		}
 
+		backoff := tmpPVCCleanupBackoff
 		for {
 			// break the loop as soon as we can't find the pv anymore.
-			if _, err := o.kcli.CoreV1().PersistentVolumes().Get(
+			if _, err := o.cleanupClient().CoreV1().PersistentVolumes().Get(
 				ctx, pv.Name, metav1.GetOptions{},
 			); err != nil && !errors.IsNotFound(err) {
 				o.log.Printf("failed to get pv for temp pvc %s: %s", pvc, err)
@@ -373,8 +1042,8 @@ func (o *OpenEBSFreeDiskSpaceGetter) deleteTmpPVCs(pvcs []*corev1.PersistentVolu
 			}
 
 			select {
-			case <-interval.C:
-				continue
+			case <-time.After(backoff):
+				backoff = nextTmpPVCCleanupBackoff(backoff)
 			case <-timeout.C:
 				return fmt.Errorf("failed to delete pvs: timeout")
 			}
@@ -383,6 +1052,60 @@ func (o *OpenEBSFreeDiskSpaceGetter) deleteTmpPVCs(pvcs []*corev1.PersistentVolu
 	return nil
 }
 
+// deleteTmpPVC deletes the temporary pvc with the provided name, retrying with an exponential
+// backoff while the deletion fails with a transient api error (see isTransientAPIError) until the
+// provided timeout channel fires. returns true if the pvc has been deleted, false if it did not
+// exist or its deletion failed with a non transient error, which is only logged. an error is only
+// returned if the timeout fires before the pvc could be deleted.
+func (o *OpenEBSFreeDiskSpaceGetter) deleteTmpPVC(ctx context.Context, name string, timeout <-chan time.Time) (bool, error) {
+	propagation := metav1.DeletePropagationForeground
+	delopts := metav1.DeleteOptions{PropagationPolicy: &propagation}
+
+	backoff := tmpPVCCleanupBackoff
+	for {
+		err := o.cleanupClient().CoreV1().PersistentVolumeClaims(o.opts.Namespace).Delete(ctx, name, delopts)
+		if err == nil {
+			return true, nil
+		}
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		if !isTransientAPIError(err) {
+			o.log.Printf("failed to delete temp pvc %s: %s", name, err)
+			return false, nil
+		}
+
+		o.log.Printf("failed to delete temp pvc %s, retrying in %s: %s", name, backoff, err)
+		select {
+		case <-time.After(backoff):
+			backoff = nextTmpPVCCleanupBackoff(backoff)
+		case <-timeout:
+			return false, fmt.Errorf("failed to delete pvc %s: timeout: %w", name, err)
+		}
+	}
+}
+
+// deleteRetainedPV deletes a temporary pv whose reclaim policy is Retain. such pvs are left behind
+// in the Released phase once their pvc is deleted. the data written in the volume is not removed
+// by the provisioner, this is only logged as the temporary volumes are empty.
+func (o *OpenEBSFreeDiskSpaceGetter) deleteRetainedPV(ctx context.Context, pv corev1.PersistentVolume) {
+	o.log.Printf("Temporary pv %s has a Retain reclaim policy, deleting it explicitly", pv.Name)
+	if err := o.cleanupClient().CoreV1().PersistentVolumes().Delete(
+		ctx, pv.Name, metav1.DeleteOptions{},
+	); err != nil && !errors.IsNotFound(err) {
+		o.log.Printf("failed to delete retained pv %s, it must be deleted manually: %s", pv.Name, err)
+	}
+}
+
+// cleanupClient returns the client used to delete the temporary resources, the measuring client
+// unless a cleanup client has been provided (see OpenEBSOptions.CleanupClient).
+func (o *OpenEBSFreeDiskSpaceGetter) cleanupClient() kubernetes.Interface {
+	if o.opts.CleanupClient != nil {
+		return o.opts.CleanupClient
+	}
+	return o.kcli
+}
+
 // logContainersState prints the provided pod logs and pod status conditions.
 func (o *OpenEBSFreeDiskSpaceGetter) logContainersState(logs map[string][]byte, states map[string]corev1.ContainerState) {
 	o.log.Println("")
@@ -413,15 +1136,12 @@ func (o *OpenEBSFreeDiskSpaceGetter) logContainersState(logs map[string][]byte,
 	tw.Flush()
 }
 
-// parseDFContainerOutput parses the output (log) of the 'disk available' pod. the output of the
-// container is expected to be the default df command output (with bytes as unit or measurement):
-//
-// Filesystem     1K-blocks     Used Available Use% Mounted on
-// /dev/sda2       61608748 48707392   9739400  84% /data
-//
-// the openebs node volume is mounted under /data inside the pod. this function returns the
-// amount of used and available space as bytes.
-func (o *OpenEBSFreeDiskSpaceGetter) parseDFContainerOutput(output []byte) (int64, int64, error) {
+// validateStrictDFOutput verifies that the df output matches exactly what we expect from the
+// 'df -B1 /data' command, or its 'df -k /data' fallback: a header line announcing the block size
+// (1B-blocks, 1K-blocks, ..., see dfBlockSize) followed by a single line for the df mount path
+// (/data by default, see dfMountPath) with six columns.
+func (o *OpenEBSFreeDiskSpaceGetter) validateStrictDFOutput(output []byte) error {
+	var lines [][]string
 	buf := bytes.NewBuffer(output)
 	scanner := bufio.NewScanner(buf)
 	for scanner.Scan() {
@@ -429,40 +1149,382 @@ func (o *OpenEBSFreeDiskSpaceGetter) parseDFContainerOutput(output []byte) (int6
 		if len(words) == 0 {
 			continue
 		}
+		lines = append(lines, words)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to process container log: %w", err)
+	}
+
+	if len(lines) != 2 {
+		return fmt.Errorf("expected header and one mount line, found %d lines", len(lines))
+	}
+
+	header := lines[0]
+	if len(header) != 7 || header[0] != "Filesystem" || !dfBlockSizeRegexp.MatchString(header[1]) {
+		return fmt.Errorf("unexpected df header: %s", strings.Join(header, " "))
+	}
+
+	mount := lines[1]
+	if len(mount) != 6 {
+		return fmt.Errorf("expected 6 columns in df output, found %d", len(mount))
+	}
+
+	if mount[5] != o.dfMountPath() {
+		return fmt.Errorf("unexpected mount point %q in df output", mount[5])
+	}
+
+	for _, word := range mount[1:4] {
+		if _, err := strconv.ParseInt(word, 10, 64); err != nil {
+			return fmt.Errorf("failed to parse %q as a number of blocks: %w", word, err)
+		}
+	}
 
-		// lastpos is where the mount point lives.
-		lastpos := len(words) - 1
-		if words[lastpos] != "/data" || len(words) < 5 {
+	if !strings.HasSuffix(mount[4], "%") {
+		return fmt.Errorf("failed to parse %q as use percentage", mount[4])
+	}
+	return nil
+}
+
+// parseBasePathInaccessible looks for the basePathInaccessibleMarker in the df container output.
+// returns the reason reported by the container and true if the marker has been found.
+func (o *OpenEBSFreeDiskSpaceGetter) parseBasePathInaccessible(output []byte) (string, bool) {
+	buf := bytes.NewBuffer(output)
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, basePathInaccessibleMarker) {
 			continue
 		}
 
-		// pos is the position where the actual available space is.
-		pos := len(words) - 3
-		freeBytes, err := strconv.ParseInt(words[pos], 10, 64)
-		if err != nil {
-			return 0, 0, fmt.Errorf("failed to parse %q as available space: %w", words[pos], err)
+		reason := strings.TrimSpace(strings.TrimPrefix(line, basePathInaccessibleMarker))
+		if reason == "" {
+			reason = "unknown reason"
 		}
+		return reason, true
+	}
+	return "", false
+}
 
-		// pos is now the position where the actual used space is.
-		pos = len(words) - 4
-		usedBytes, err := strconv.ParseInt(words[pos], 10, 64)
-		if err != nil {
-			return 0, 0, fmt.Errorf("failed to parse %q as used space: %w", words[pos], err)
+// dfEntry is a df output line whose mount point is the probed path or one of its parents.
+type dfEntry struct {
+	mountPoint string
+	words      []string
+}
+
+// matchingDFEntries returns, in the order they appear, all the df output lines whose mount point
+// is the probed path or one of its parents.
+func matchingDFEntries(output []byte, probe string) ([]dfEntry, error) {
+	var entries []dfEntry
+	buf := bytes.NewBuffer(output)
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		line := strings.Fields(scanner.Text())
+		if len(line) < 5 {
+			continue
 		}
 
-		return freeBytes, usedBytes, nil
+		mount := line[len(line)-1]
+		if !pathHasPrefix(probe, mount) {
+			continue
+		}
+		entries = append(entries, dfEntry{mountPoint: mount, words: line})
 	}
 
 	if err := scanner.Err(); err != nil {
-		return 0, 0, fmt.Errorf("failed to process container log: %w", err)
+		return nil, fmt.Errorf("failed to process container log: %w", err)
+	}
+	return entries, nil
+}
+
+// isBlockDeviceSource returns true if the provided df filesystem source is a block device, as
+// opposed to overlay, tmpfs and other virtual filesystem layers.
+func isBlockDeviceSource(source string) bool {
+	return strings.HasPrefix(source, "/dev/") && !strings.HasPrefix(source, "/dev/shm")
+}
+
+// innermostDFEntry returns the entry, among the provided ones accepted by the filter, whose mount
+// point is the most specific. the last entry wins ties as stacked mounts are listed in the order
+// they were mounted. returns nil if no entry is accepted.
+func innermostDFEntry(entries []dfEntry, accept func(dfEntry) bool) *dfEntry {
+	var selected *dfEntry
+	for i := range entries {
+		entry := &entries[i]
+		if !accept(*entry) {
+			continue
+		}
+		if selected == nil || len(entry.mountPoint) >= len(selected.mountPoint) {
+			selected = entry
+		}
+	}
+	return selected
+}
+
+// selectDFEntry picks the df entry holding the df mount path. if a mount source has been configured the
+// innermost entry with that source is used, otherwise (or if no entry has it) the entry is picked
+// according to the mount match strategy. returns false if no entry could be selected.
+func (o *OpenEBSFreeDiskSpaceGetter) selectDFEntry(entries []dfEntry) (dfEntry, bool) {
+	anyEntry := func(dfEntry) bool { return true }
+
+	var selected *dfEntry
+	if o.opts.Output.MountSource != "" {
+		selected = innermostDFEntry(entries, func(entry dfEntry) bool {
+			return entry.words[0] == o.opts.Output.MountSource
+		})
+		if selected == nil && len(entries) > 0 {
+			o.log.Printf("No df entry matching %s has source %s, using the %s strategy", o.dfMountPath(), o.opts.Output.MountSource, o.opts.Output.MountMatch)
+		}
+	}
+
+	if selected == nil {
+		switch o.opts.Output.MountMatch {
+		case MountMatchInnermost:
+			selected = innermostDFEntry(entries, anyEntry)
+
+		case MountMatchBlockDevice:
+			selected = innermostDFEntry(entries, func(entry dfEntry) bool {
+				return isBlockDeviceSource(entry.words[0])
+			})
+			if selected == nil {
+				selected = innermostDFEntry(entries, anyEntry)
+			}
+
+		case MountMatchLongestPrefix:
+			for i := range entries {
+				entry := &entries[i]
+				if entry.mountPoint == o.dfMountPath() {
+					return *entry, true
+				}
+				if selected == nil || len(entry.mountPoint) > len(selected.mountPoint) {
+					selected = entry
+				}
+			}
+
+		default:
+			for i := range entries {
+				if entries[i].mountPoint == o.dfMountPath() {
+					return entries[i], true
+				}
+			}
+		}
+	}
+
+	if selected == nil {
+		return dfEntry{}, false
+	}
+
+	if len(entries) > 1 && o.opts.Output.MountMatch == MountMatchInnermost && o.opts.Output.MountSource == "" {
+		var mounts []string
+		for _, entry := range entries {
+			mounts = append(mounts, entry.mountPoint)
+		}
+		o.log.Printf(
+			"Ambiguous df output, %d entries (%s) match %s, using the innermost one (%s)",
+			len(entries), strings.Join(mounts, ", "), o.dfMountPath(), selected.mountPoint,
+		)
+	} else if len(entries) > 1 && (o.opts.Output.MountMatch == MountMatchBlockDevice || o.opts.Output.MountSource != "") {
+		var mounts []string
+		for _, entry := range entries {
+			mounts = append(mounts, fmt.Sprintf("%s on %s", entry.words[0], entry.mountPoint))
+		}
+		o.log.Printf(
+			"Ambiguous df output, %d entries (%s) match %s, using %s on %s",
+			len(entries), strings.Join(mounts, ", "), o.dfMountPath(), selected.words[0], selected.mountPoint,
+		)
+	}
+	return *selected, true
+}
+
+// parseDFContainerOutput parses the output (log) of the 'disk available' pod. the output of the
+// container is expected to be the default df command output, with the block size announced in
+// the header (1B-blocks, 1K-blocks, 1M-blocks, ...), see dfBlockSize:
+//
+// Filesystem     1K-blocks     Used Available Use% Mounted on
+// /dev/sda2       61608748 48707392   9739400  84% /data
+//
+// the openebs node volume is mounted under /data inside the pod, or under the configured df mount
+// path (see dfMountPath). this function returns the amount of used and available space as bytes.
+// by default only the first line whose mount point is exactly the mount path is used, with the
+// longest prefix strategy the line whose mount point is the most specific parent of the mount path
+// is used when no exact match exists (bind mounts) while with the
+// innermost strategy all matching lines are considered and the most specific one, the last one
+// for stacked mounts, is used. the block device strategy does the same but only considers the
+// lines whose source is a block device, as long as there is one. a configured mount source takes
+// precedence over all strategies. if strict parsing is enabled the output must match exactly the
+// expected format, otherwise an error is returned.
+func (o *OpenEBSFreeDiskSpaceGetter) parseDFContainerOutput(output []byte) (int64, int64, error) {
+	entry, err := o.parseDFContainerEntry(output)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	blockSize, err := dfBlockSize(output)
+	if err != nil {
+		return 0, 0, err
+	}
+	return dfEntryAmounts(entry.words, blockSize)
+}
+
+// parseDFContainerEntry returns the df output line selected by parseDFContainerOutput.
+func (o *OpenEBSFreeDiskSpaceGetter) parseDFContainerEntry(output []byte) (dfEntry, error) {
+	if o.opts.Output.StrictParse {
+		if err := o.validateStrictDFOutput(output); err != nil {
+			return dfEntry{}, fmt.Errorf("strict parse: %w", err)
+		}
+	}
+
+	entries, err := matchingDFEntries(output, o.dfMountPath())
+	if err != nil {
+		return dfEntry{}, err
+	}
+
+	entry, found := o.selectDFEntry(entries)
+	if !found {
+		return dfEntry{}, fmt.Errorf("failed to locate free space info in pod log: %s", string(output))
+	}
+	return entry, nil
+}
+
+// dfEntryAmounts returns the available and used space, in bytes, of the provided df output line
+// whose amounts are expressed in blocks of the provided size.
+func dfEntryAmounts(words []string, blockSize int64) (int64, int64, error) {
+	// pos is the position where the actual available space is.
+	pos := len(words) - 3
+	freeBlocks, err := strconv.ParseInt(words[pos], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse %q as available space: %w", words[pos], err)
+	}
+
+	freeBytes, err := dfBlocksToBytes(freeBlocks, blockSize)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to convert available space: %w", err)
+	}
+
+	// pos is now the position where the actual used space is.
+	pos = len(words) - 4
+	usedBlocks, err := strconv.ParseInt(words[pos], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse %q as used space: %w", words[pos], err)
+	}
+
+	usedBytes, err := dfBlocksToBytes(usedBlocks, blockSize)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to convert used space: %w", err)
+	}
+
+	return freeBytes, usedBytes, nil
+}
+
+// measureCommand returns the script executed by the df container: dfCommand or, if a statfs helper
+// has been configured, statfsCommand. if a node path has been configured to be resolved the
+// script returned by resolvePathCommand is used instead.
+func (o *OpenEBSFreeDiskSpaceGetter) measureCommand() string {
+	if o.opts.Output.ResolvePath != "" {
+		return resolvePathCommand(o.opts.Output.ResolvePath, o.dfMountPath(), o.dfOutputMarker())
+	}
+	if o.opts.Output.StatfsBinary != "" {
+		return statfsCommand(o.opts.Output.StatfsBinary, o.dfMountPath())
+	}
+	return dfCommand(o.dfMountPath(), o.dfOutputMarker())
+}
+
+// parseFreeSpace parses the df container output according to the command it has executed, see
+// measureCommand, and returns the available and used space in bytes along with the source of the
+// measured filesystem. the source is only known when df has been executed on the base path, it is
+// empty otherwise. df output is only parsed after the df marker, anything printed before it is
+// ignored.
+func (o *OpenEBSFreeDiskSpaceGetter) parseFreeSpace(output []byte) (int64, int64, string, error) {
+	if o.opts.Output.ResolvePath != "" {
+		free, used, err := o.parseResolvedDFOutput(output)
+		return free, used, "", err
+	}
+	if o.opts.Output.StatfsBinary != "" {
+		free, used, err := parseStatfsOutput(output, o.dfMountPath())
+		return free, used, "", err
+	}
+
+	output, err := cutDFMarker(output, o.dfOutputMarker())
+	if err != nil {
+		return 0, 0, "", err
+	}
+
+	entry, err := o.parseDFContainerEntry(output)
+	if err != nil {
+		return 0, 0, "", err
+	}
+
+	blockSize, err := dfBlockSize(output)
+	if err != nil {
+		return 0, 0, "", err
+	}
+
+	free, used, err := dfEntryAmounts(entry.words, blockSize)
+	if err != nil {
+		return 0, 0, "", err
 	}
-	return 0, 0, fmt.Errorf("failed to locate free space info in pod log: %s", string(output))
+	return free, used, entry.words[0], nil
+}
+
+// pathHasPrefix returns true if path is equal to or lives inside the prefix directory.
+func pathHasPrefix(path, prefix string) bool {
+	if prefix == "/" || path == prefix {
+		return true
+	}
+	return strings.HasPrefix(path, prefix+"/")
+}
+
+// isPseudoFilesystem returns true if the provided filesystem type does not back any real storage. if
+// no list of pseudo filesystems has been configured defaultPseudoFilesystems is used.
+func (o *OpenEBSFreeDiskSpaceGetter) isPseudoFilesystem(fstype string) bool {
+	pseudo := o.opts.Output.PseudoFilesystems
+	if pseudo == nil {
+		pseudo = defaultPseudoFilesystems
+	}
+
+	for _, candidate := range pseudo {
+		if candidate == fstype {
+			return true
+		}
+	}
+	return false
+}
+
+// FstabEntry is a mount point read from the node fstab. Source is the first fstab column: the device
+// or, for bind mounts, the directory bound to the mount point. Bind is set for bind mounts, those
+// whose options include bind or rbind. Unreliable is set for mount points whose filesystem type
+// (tmpfs, overlay, proc or sysfs) does not back any real storage, measuring them is meaningless.
+type FstabEntry struct {
+	Source     string `json:"source"`
+	MountPoint string `json:"mountPoint"`
+	FSType     string `json:"fsType,omitempty"`
+	Bind       bool   `json:"bind,omitempty"`
+	Unreliable bool   `json:"unreliable,omitempty"`
 }
 
-// parseFstabContainerOutput parses the fstab container output and return all mount points.
+// parseFstabContainerOutput parses the fstab container output and return all mount points. mount
+// points using pseudo filesystems (proc, tmpfs, etc) are ignored. see parseFstabEntries for the
+// filesystem type of each mount point.
 func (o *OpenEBSFreeDiskSpaceGetter) parseFstabContainerOutput(output []byte) ([]string, error) {
-	seen := map[string]bool{}
+	entries, err := o.parseFstabEntries(output)
+	if err != nil {
+		return nil, err
+	}
+
 	mounts := []string{}
+	for _, entry := range entries {
+		mounts = append(mounts, entry.MountPoint)
+	}
+	return mounts, nil
+}
+
+// parseFstabEntries parses the fstab container output and returns all its entries, along with their
+// source, filesystem type and whether they are bind mounts. entries using pseudo filesystems (proc,
+// tmpfs, etc) are ignored, only the first entry of a repeated mount point is kept. entries whose
+// filesystem type makes their measurement unreliable (e.g. overlay, or tmpfs when it is not taken
+// as a pseudo filesystem) are flagged as such.
+func (o *OpenEBSFreeDiskSpaceGetter) parseFstabEntries(output []byte) ([]FstabEntry, error) {
+	seen := map[string]bool{}
+	entries := []FstabEntry{}
 	buf := bytes.NewBuffer(output)
 	scanner := bufio.NewScanner(buf)
 	for scanner.Scan() {
@@ -476,42 +1538,113 @@ func (o *OpenEBSFreeDiskSpaceGetter) parseFstabContainerOutput(output []byte) ([
 			continue
 		}
 
-		if _, ok := seen[words[1]]; ok {
+		entry := FstabEntry{Source: words[0], MountPoint: words[1]}
+		if len(words) > 2 {
+			if o.isPseudoFilesystem(words[2]) {
+				continue
+			}
+			entry.FSType = words[2]
+			entry.Unreliable = isUnreliableFilesystem(words[2])
+		}
+		if len(words) > 3 {
+			entry.Bind = isBindMount(words[3])
+		}
+
+		if _, ok := seen[entry.MountPoint]; ok {
 			continue
 		}
 
-		seen[words[1]] = true
-		mounts = append(mounts, words[1])
+		seen[entry.MountPoint] = true
+		entries = append(entries, entry)
 	}
 
 	if err := scanner.Err(); err != nil {
 		return nil, fmt.Errorf("failed to process container log: %w", err)
 	}
 
-	if len(mounts) == 0 {
+	if len(entries) == 0 {
 		return nil, fmt.Errorf("failed to locate any mount point")
 	}
-	return mounts, nil
+	return entries, nil
+}
+
+// ParseFstab parses the provided fstab contents the same way the fstab read from the nodes is parsed,
+// see parseFstabEntries. pseudoFilesystems are the filesystem types ignored, if nil the default list
+// is used. meant to reproduce offline how a node fstab is interpreted.
+func ParseFstab(content []byte, pseudoFilesystems []string) ([]FstabEntry, error) {
+	getter := &OpenEBSFreeDiskSpaceGetter{opts: OpenEBSOptions{Output: OpenEBSOutputOptions{PseudoFilesystems: pseudoFilesystems}}}
+	return getter.parseFstabEntries(content)
+}
+
+// fstabFilesystemType returns the filesystem type of the fstab entry holding the provided path: the
+// one with the longest mount point containing it. an empty string is returned if no entry holds it.
+func fstabFilesystemType(output []byte, path string) string {
+	var mountPoint, fstype string
+	scanner := bufio.NewScanner(bytes.NewBuffer(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		words := strings.Fields(line)
+		if len(words) < 3 || !pathHasPrefix(path, words[1]) {
+			continue
+		}
+
+		if len(words[1]) > len(mountPoint) {
+			mountPoint, fstype = words[1], words[2]
+		}
+	}
+	return fstype
 }
 
 // NewOpenEBSFreeDiskSpaceGetter returns an object capable of retrieving the volumes assigned to OpenEBS
 // in all cluster nodes. based on the volumes one can verify how much free space exists in the nodes.
 func NewOpenEBSFreeDiskSpaceGetter(kcli kubernetes.Interface, log *log.Logger, image, scname string) (*OpenEBSFreeDiskSpaceGetter, error) {
-	if image == "" {
-		return nil, fmt.Errorf("empty image")
-	}
-	if scname == "" {
+	return NewOpenEBSFreeDiskSpaceGetterWithOptions(kcli, OpenEBSOptions{
+		Log:   log,
+		Image: image,
+		DstSC: scname,
+	})
+}
+
+// NewOpenEBSFreeDiskSpaceGetterWithOptions returns a free disk space getter for the OpenEBS storage
+// class opts.DstSC. opts.SrcSC and opts.Reserved are ignored. a new run id is generated for every
+// getter, see RunID.
+func NewOpenEBSFreeDiskSpaceGetterWithOptions(kcli kubernetes.Interface, opts OpenEBSOptions) (*OpenEBSFreeDiskSpaceGetter, error) {
+	if opts.DstSC == "" {
 		return nil, fmt.Errorf("empty storage class")
 	}
-	if log == nil {
-		return nil, fmt.Errorf("no logger provided")
+	return newOpenEBSFreeDiskSpaceGetter(kcli, opts)
+}
+
+// newOpenEBSFreeDiskSpaceGetter returns a getter configured with the provided options without
+// requiring a storage class, for the callers that only run node jobs (see NodeJobRunner).
+func newOpenEBSFreeDiskSpaceGetter(kcli kubernetes.Interface, opts OpenEBSOptions) (*OpenEBSFreeDiskSpaceGetter, error) {
+	opts = opts.withDefaults()
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	metrics, err := newSpaceCheckMetrics(opts.Metrics)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metrics: %w", err)
 	}
 
+	// the run id prefixes all log lines so the output of concurrent runs can be told apart.
+	runID := uuid.New().String()[:8]
+	logger := log.New(
+		opts.Log.Writer(), fmt.Sprintf("%s[run %s] ", opts.Log.Prefix(), runID), opts.Log.Flags(),
+	)
 	return &OpenEBSFreeDiskSpaceGetter{
-		deletePVTimeout: 5 * time.Minute,
-		kcli:            kcli,
-		log:             log,
-		image:           image,
-		scname:          scname,
+		opts:        opts,
+		kcli:        kcli,
+		log:         logger,
+		limiter:     newCreateLimiter(opts.CreateRate),
+		pvcSlots:    newPVCSlots(opts.PVC.MaxInflight),
+		tolerations: jobTolerations(opts.Job.Tolerations, !opts.Job.NoControlPlaneTolerations),
+		metrics:     metrics,
+		runID:       runID,
 	}, nil
 }
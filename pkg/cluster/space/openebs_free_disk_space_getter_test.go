@@ -1,7 +1,9 @@
 package clusterspace
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"io"
 	"log"
 	"reflect"
@@ -10,24 +12,31 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 	"k8s.io/utils/ptr"
+
+	"github.com/replicatedhq/kurl/pkg/k8sutil"
 )
 
 func Test_deleteTmpPVCs(t *testing.T) {
 	for _, tt := range []struct {
-		name    string
-		objs    []runtime.Object
-		timeout time.Duration
-		pvcs    []*corev1.PersistentVolumeClaim
-		err     string
-		gofn    func(*testing.T, kubernetes.Interface)
+		name       string
+		objs       []runtime.Object
+		timeout    time.Duration
+		skipPVWait bool
+		pvcs       []*corev1.PersistentVolumeClaim
+		err        string
+		gofn       func(*testing.T, kubernetes.Interface)
+		deletedPVs []string
 	}{
 		{
 			name:    "deleting empty list of pvcs should succeed",
@@ -172,6 +181,71 @@ func Test_deleteTmpPVCs(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:       "pv not disappearing should not block when skipping the pv wait",
+			timeout:    10 * time.Second,
+			skipPVWait: true,
+			objs: []runtime.Object{
+				&corev1.PersistentVolumeClaim{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pvc",
+						Namespace: "default",
+					},
+				},
+				&corev1.PersistentVolume{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "pv",
+					},
+					Spec: corev1.PersistentVolumeSpec{
+						ClaimRef: &corev1.ObjectReference{
+							Name:      "pvc",
+							Namespace: "default",
+						},
+					},
+				},
+			},
+			pvcs: []*corev1.PersistentVolumeClaim{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pvc",
+						Namespace: "default",
+					},
+				},
+			},
+		},
+		{
+			name:       "pv with retain reclaim policy should be deleted without waiting",
+			timeout:    2 * time.Second,
+			deletedPVs: []string{"pv"},
+			objs: []runtime.Object{
+				&corev1.PersistentVolumeClaim{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pvc",
+						Namespace: "default",
+					},
+				},
+				&corev1.PersistentVolume{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "pv",
+					},
+					Spec: corev1.PersistentVolumeSpec{
+						PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimRetain,
+						ClaimRef: &corev1.ObjectReference{
+							Name:      "pvc",
+							Namespace: "default",
+						},
+					},
+				},
+			},
+			pvcs: []*corev1.PersistentVolumeClaim{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pvc",
+						Namespace: "default",
+					},
+				},
+			},
+		},
 		{
 			name:    "pvs referring to pvcs from different namespaces should not interfere",
 			timeout: 20 * time.Second,
@@ -238,16 +312,26 @@ func Test_deleteTmpPVCs(t *testing.T) {
 			logger := log.New(io.Discard, "", 0)
 			kcli := fake.NewSimpleClientset(tt.objs...)
 			ochecker := OpenEBSFreeDiskSpaceGetter{
-				deletePVTimeout: tt.timeout,
-				kcli:            kcli,
-				log:             logger,
+				opts: OpenEBSOptions{
+					PVC: OpenEBSPVCOptions{
+						DeletePVTimeout: tt.timeout,
+						SkipPVWait:      tt.skipPVWait,
+					},
+					Namespace: "default",
+				},
+				kcli: kcli,
+				log:  logger,
 			}
 
 			if tt.gofn != nil {
 				go tt.gofn(t, kcli)
 			}
 
+			start := time.Now()
 			err := ochecker.deleteTmpPVCs(tt.pvcs)
+			if tt.skipPVWait && time.Since(start) >= tt.timeout {
+				t.Errorf("pv wait loop not skipped")
+			}
 			if err != nil {
 				if len(tt.err) == 0 {
 					t.Errorf("unexpected error: %s", err)
@@ -260,6 +344,14 @@ func Test_deleteTmpPVCs(t *testing.T) {
 			if len(tt.err) > 0 {
 				t.Errorf("expecting error %q, nil received instead", tt.err)
 			}
+
+			for _, name := range tt.deletedPVs {
+				if _, err := kcli.CoreV1().PersistentVolumes().Get(
+					context.Background(), name, metav1.GetOptions{},
+				); !apierrors.IsNotFound(err) {
+					t.Errorf("expected pv %s to be deleted, get returned: %v", name, err)
+				}
+			}
 		})
 	}
 }
@@ -315,6 +407,7 @@ func Test_bulidTmpPVC(t *testing.T) {
 		name         string
 		nodeName     string
 		scname       string
+		size         string
 		expectedName string
 		expectedSpec corev1.PersistentVolumeClaimSpec
 	}{
@@ -348,10 +441,31 @@ func Test_bulidTmpPVC(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:         "should request the configured size",
+			nodeName:     "node0",
+			expectedName: "disk-free-node0-",
+			scname:       "xyz",
+			size:         "1Gi",
+			expectedSpec: corev1.PersistentVolumeClaimSpec{
+				StorageClassName: ptr.To("xyz"),
+				AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceStorage: resource.MustParse("1Gi"),
+					},
+				},
+			},
+		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
 			ochecker := OpenEBSFreeDiskSpaceGetter{
-				scname: tt.scname,
+				opts: OpenEBSOptions{
+					DstSC: tt.scname,
+				},
+			}
+			if tt.size != "" {
+				ochecker.opts.PVC.Size = resource.MustParse(tt.size)
 			}
 			pvc := ochecker.buildTmpPVC(tt.nodeName)
 
@@ -370,6 +484,7 @@ func Test_parseDFContainerOutput(t *testing.T) {
 	for _, tt := range []struct {
 		name         string
 		content      []byte
+		mountMatch   MountMatchStrategy
 		err          string
 		expectedFree int64
 		expectedUsed int64
@@ -434,6 +549,27 @@ some prefixes go in here /dev/sda2      63087357952 52521754624 7327760384  88%
 			expectedFree: 7327760384,
 			expectedUsed: 52521754624,
 		},
+		{
+			name: "should scale busybox 1K-blocks output into bytes",
+			content: []byte(`Filesystem     1K-blocks     Used Available Use% Mounted on
+/dev/sda2       61608748 48707392   9739400  84% /data`),
+			expectedFree: 9739400 * 1024,
+			expectedUsed: 48707392 * 1024,
+		},
+		{
+			name: "should scale 1M-blocks output into bytes",
+			content: []byte(`Filesystem     1M-blocks  Used Available Use% Mounted on
+/dev/sda2          60165 47566      9512  84% /data`),
+			expectedFree: 9512 << 20,
+			expectedUsed: 47566 << 20,
+		},
+		{
+			name: "should scale posix 1024-blocks output into bytes",
+			content: []byte(`Filesystem     1024-blocks     Used Available Capacity Mounted on
+/dev/sda2         61608748 48707392   9739400      84% /data`),
+			expectedFree: 9739400 * 1024,
+			expectedUsed: 48707392 * 1024,
+		},
 		{
 			name: "should be able to parse df result (oracle linux output)",
 			content: []byte(`Filesystem       1B-blocks       Used   Available Use% Mounted on
@@ -441,9 +577,76 @@ some prefixes go in here /dev/sda2      63087357952 52521754624 7327760384  88%
 			expectedFree: 77386686464,
 			expectedUsed: 8500056064,
 		},
+		{
+			name: "should not match a parent mount point with the exact strategy",
+			content: []byte(`Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sda2      63087357952 52521754624 7327760384  88% /`),
+			err: "failed to locate free space info in pod log",
+		},
+		{
+			name:       "should match the longest parent mount point with the longest prefix strategy",
+			mountMatch: MountMatchLongestPrefix,
+			content: []byte(`Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sda2      63087357952 52521754624 7327760384  88% /
+/dev/sdb1      85886742528  8500056064 77386686464  10% /dat
+/dev/sdc1      10737418240  1073741824  9663676416  10% /data/sub`),
+			expectedFree: 7327760384,
+			expectedUsed: 52521754624,
+		},
+		{
+			name:       "should prefer the exact mount point with the longest prefix strategy",
+			mountMatch: MountMatchLongestPrefix,
+			content: []byte(`Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sda2      63087357952 52521754624 7327760384  88% /
+/dev/xvda1     85886742528 8500056064 77386686464  10% /data`),
+			expectedFree: 77386686464,
+			expectedUsed: 8500056064,
+		},
+		{
+			name:       "should fail with the longest prefix strategy if no parent is found",
+			mountMatch: MountMatchLongestPrefix,
+			content: []byte(`Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sdb1      85886742528  8500056064 77386686464  10% /var/lib`),
+			err: "failed to locate free space info in pod log",
+		},
+		{
+			name: "should use the first exact match with stacked mounts by default",
+			content: []byte(`Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sda2      63087357952 52521754624 7327760384  88% /data
+/dev/sdb1      85886742528  8500056064 77386686464  10% /data`),
+			expectedFree: 7327760384,
+			expectedUsed: 52521754624,
+		},
+		{
+			name:       "should pick the innermost of the stacked mounts on the probe path",
+			mountMatch: MountMatchInnermost,
+			content: []byte(`Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sda2      63087357952 52521754624 7327760384  88% /
+/dev/sdb1      85886742528  8500056064 77386686464  10% /data
+/dev/sdc1      10737418240  1073741824  9663676416  10% /data
+/dev/sdd1      10737418240  2147483648  8589934592  20% /var/lib`),
+			expectedFree: 9663676416,
+			expectedUsed: 1073741824,
+		},
+		{
+			name:       "should pick the most specific parent with the innermost strategy",
+			mountMatch: MountMatchInnermost,
+			content: []byte(`Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sdb1      85886742528  8500056064 77386686464  10% /data/sub
+/dev/sda2      63087357952 52521754624 7327760384  88% /`),
+			expectedFree: 7327760384,
+			expectedUsed: 52521754624,
+		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
-			ochecker := OpenEBSFreeDiskSpaceGetter{}
+			ochecker := OpenEBSFreeDiskSpaceGetter{
+				opts: OpenEBSOptions{
+					Output: OpenEBSOutputOptions{
+						MountMatch: tt.mountMatch,
+					},
+				},
+				log: log.New(io.Discard, "", 0),
+			}
 			free, used, err := ochecker.parseDFContainerOutput(tt.content)
 			if err != nil {
 				if len(tt.err) == 0 {
@@ -468,10 +671,289 @@ some prefixes go in here /dev/sda2      63087357952 52521754624 7327760384  88%
 	}
 }
 
+func Test_parseDFContainerOutputReportsAmbiguity(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	ochecker := OpenEBSFreeDiskSpaceGetter{
+		opts: OpenEBSOptions{
+			Output: OpenEBSOutputOptions{
+				MountMatch: MountMatchInnermost,
+			},
+		},
+		log: log.New(buf, "", 0),
+	}
+
+	if _, _, err := ochecker.parseDFContainerOutput([]byte(`Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sdb1      85886742528  8500056064 77386686464  10% /data`)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("unexpected ambiguity reported: %s", buf.String())
+	}
+
+	if _, _, err := ochecker.parseDFContainerOutput([]byte(`Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sdb1      85886742528  8500056064 77386686464  10% /data
+/dev/sdc1      10737418240  1073741824  9663676416  10% /data`)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := "Ambiguous df output, 2 entries (/data, /data) match /data, using the innermost one (/data)"
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("expected ambiguity to be reported, %q logged instead", buf.String())
+	}
+}
+
+func Test_parseFreeSpaceStackedOverlay(t *testing.T) {
+	// df output as seen on container optimized nodes, where the base path sits under stacked
+	// overlay and tmpfs layers and df lists several plausible lines for it.
+	cos := `KURL_DF_BEGIN
+Filesystem       1B-blocks        Used   Available Use% Mounted on
+overlay        10434699264  4214448128  6203473920  41% /
+tmpfs           4124078080           0  4124078080   0% /data
+/dev/sda1     101241290752 23148969984 78075543552  23% /data
+overlay        10434699264  4214448128  6203473920  41% /data`
+
+	for _, tt := range []struct {
+		name           string
+		content        string
+		mountMatch     MountMatchStrategy
+		mountSource    string
+		expectedFree   int64
+		expectedUsed   int64
+		expectedSource string
+	}{
+		{
+			name:           "should use the first exact match by default",
+			content:        cos,
+			expectedFree:   4124078080,
+			expectedUsed:   0,
+			expectedSource: "tmpfs",
+		},
+		{
+			name:           "should use the top layer with the innermost strategy",
+			content:        cos,
+			mountMatch:     MountMatchInnermost,
+			expectedFree:   6203473920,
+			expectedUsed:   4214448128,
+			expectedSource: "overlay",
+		},
+		{
+			name:           "should prefer the block device with the block device strategy",
+			content:        cos,
+			mountMatch:     MountMatchBlockDevice,
+			expectedFree:   78075543552,
+			expectedUsed:   23148969984,
+			expectedSource: "/dev/sda1",
+		},
+		{
+			name: "should prefer a block device parent over overlay layers on the probe path",
+			content: `KURL_DF_BEGIN
+Filesystem       1B-blocks        Used   Available Use% Mounted on
+/dev/sda1     101241290752 23148969984 78075543552  23% /
+overlay        10434699264  4214448128  6203473920  41% /data`,
+			mountMatch:     MountMatchBlockDevice,
+			expectedFree:   78075543552,
+			expectedUsed:   23148969984,
+			expectedSource: "/dev/sda1",
+		},
+		{
+			name: "should fall back to the innermost layer when there is no block device",
+			content: `KURL_DF_BEGIN
+Filesystem       1B-blocks        Used   Available Use% Mounted on
+overlay        10434699264  4214448128  6203473920  41% /
+tmpfs           4124078080           0  4124078080   0% /data
+shm               67108864           0    67108864   0% /data`,
+			mountMatch:     MountMatchBlockDevice,
+			expectedFree:   67108864,
+			expectedUsed:   0,
+			expectedSource: "shm",
+		},
+		{
+			name:           "should use the configured mount source",
+			content:        cos,
+			mountMatch:     MountMatchBlockDevice,
+			mountSource:    "overlay",
+			expectedFree:   6203473920,
+			expectedUsed:   4214448128,
+			expectedSource: "overlay",
+		},
+		{
+			name:           "should use the strategy when no line has the configured mount source",
+			content:        cos,
+			mountMatch:     MountMatchBlockDevice,
+			mountSource:    "/dev/nvme0n1p1",
+			expectedFree:   78075543552,
+			expectedUsed:   23148969984,
+			expectedSource: "/dev/sda1",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ochecker := OpenEBSFreeDiskSpaceGetter{
+				opts: OpenEBSOptions{
+					Output: OpenEBSOutputOptions{
+						MountMatch:  tt.mountMatch,
+						MountSource: tt.mountSource,
+					},
+				},
+				log: log.New(io.Discard, "", 0),
+			}
+			free, used, source, err := ochecker.parseFreeSpace([]byte(tt.content))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if free != tt.expectedFree || used != tt.expectedUsed {
+				t.Errorf("expected free %d and used %d, received %d and %d", tt.expectedFree, tt.expectedUsed, free, used)
+			}
+			if source != tt.expectedSource {
+				t.Errorf("expected mount source %q, received %q", tt.expectedSource, source)
+			}
+		})
+	}
+}
+
+func Test_parseFreeSpaceStackedOverlayReportsSelection(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	ochecker := OpenEBSFreeDiskSpaceGetter{
+		opts: OpenEBSOptions{
+			Output: OpenEBSOutputOptions{
+				MountMatch: MountMatchBlockDevice,
+			},
+		},
+		log: log.New(buf, "", 0),
+	}
+
+	if _, _, _, err := ochecker.parseFreeSpace([]byte(`KURL_DF_BEGIN
+Filesystem       1B-blocks        Used   Available Use% Mounted on
+/dev/sda1     101241290752 23148969984 78075543552  23% /data
+overlay        10434699264  4214448128  6203473920  41% /data`)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := "Ambiguous df output, 2 entries (/dev/sda1 on /data, overlay on /data) match /data, using /dev/sda1 on /data"
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("expected selection to be reported, %q logged instead", buf.String())
+	}
+}
+
+func Test_parseDFContainerOutputStrict(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		content   []byte
+		strictErr string
+	}{
+		{
+			name: "should pass in both modes with the expected output",
+			content: []byte(`Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sda2      63087357952 52521754624 7327760384  88% /data`),
+		},
+		{
+			name: "should fail in strict mode if the line contains extra prefixes",
+			content: []byte(`Filesystem       1B-blocks        Used  Available Use% Mounted on
+some prefixes go in here /dev/sda2      63087357952 52521754624 7327760384  88% /data`),
+			strictErr: "expected 6 columns in df output, found 11",
+		},
+		{
+			name:      "should fail in strict mode if the header is missing",
+			content:   []byte(`/dev/sda2      63087357952 52521754624 7327760384  88% /data`),
+			strictErr: "expected header and one mount line, found 1 lines",
+		},
+		{
+			name: "should pass in both modes with the 1K-blocks header of the df -k fallback",
+			content: []byte(`Filesystem     1K-blocks     Used Available Use% Mounted on
+/dev/sda2       61608748 48707392   9739400  84% /data`),
+		},
+		{
+			name: "should fail in strict mode if there is noise after the mount line",
+			content: []byte(`Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sda2      63087357952 52521754624 7327760384  88% /data
+/dev/sda3      63087357952 52521754624 7327760384  88% /data`),
+			strictErr: "expected header and one mount line, found 3 lines",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			lenient := OpenEBSFreeDiskSpaceGetter{}
+			if _, _, err := lenient.parseDFContainerOutput(tt.content); err != nil {
+				t.Errorf("unexpected error in lenient mode: %s", err)
+			}
+
+			strict := OpenEBSFreeDiskSpaceGetter{opts: OpenEBSOptions{Output: OpenEBSOutputOptions{StrictParse: true}}}
+			_, _, err := strict.parseDFContainerOutput(tt.content)
+			if err != nil {
+				if len(tt.strictErr) == 0 {
+					t.Errorf("unexpected error in strict mode: %s", err)
+				} else if !strings.Contains(err.Error(), tt.strictErr) {
+					t.Errorf("expecting %q, %q received instead", tt.strictErr, err)
+				}
+				return
+			}
+
+			if len(tt.strictErr) > 0 {
+				t.Errorf("expecting error %q, nil received instead", tt.strictErr)
+			}
+		})
+	}
+}
+
+func Test_parseBasePathInaccessible(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		content      []byte
+		inaccessible bool
+		reason       string
+	}{
+		{
+			name:    "should not find the marker in an empty output",
+			content: []byte(``),
+		},
+		{
+			name: "should not find the marker in a valid df output",
+			content: []byte(`Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sda2      63087357952 52521754624 7327760384  88% /data`),
+		},
+		{
+			name:         "should find the marker and the reason",
+			content:      []byte(`KURL_BASEPATH_INACCESSIBLE: stat: can't stat '/data': No such file or directory`),
+			inaccessible: true,
+			reason:       `stat: can't stat '/data': No such file or directory`,
+		},
+		{
+			name: "should find the marker among other lines",
+			content: []byte(`some noise
+KURL_BASEPATH_INACCESSIBLE: sh: cd: can't cd to /data: Permission denied
+`),
+			inaccessible: true,
+			reason:       `sh: cd: can't cd to /data: Permission denied`,
+		},
+		{
+			name:         "should report unknown reason if none was printed",
+			content:      []byte(`KURL_BASEPATH_INACCESSIBLE:`),
+			inaccessible: true,
+			reason:       "unknown reason",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ochecker := OpenEBSFreeDiskSpaceGetter{}
+			reason, inaccessible := ochecker.parseBasePathInaccessible(tt.content)
+			if inaccessible != tt.inaccessible {
+				t.Errorf("expected inaccessible to be %v, %v received", tt.inaccessible, inaccessible)
+			}
+			if reason != tt.reason {
+				t.Errorf("expected reason %q, %q received", tt.reason, reason)
+			}
+		})
+	}
+}
+
+func TestBasePathInaccessibleError(t *testing.T) {
+	err := &BasePathInaccessibleError{BasePath: "/var/local", Node: "node0", Reason: "Permission denied"}
+	expected := "base path /var/local inaccessible on node node0: Permission denied"
+	if err.Error() != expected {
+		t.Errorf("expected %q, %q received", expected, err.Error())
+	}
+}
+
 func Test_parseFstabContainerOutput(t *testing.T) {
 	for _, tt := range []struct {
 		name     string
 		content  []byte
+		pseudoFS []string
 		err      string
 		expected []string
 	}{
@@ -526,7 +1008,31 @@ UUID=be35a709-c787-4198-a903-d5fdc80ab2f8  /  ext3  relatime,errors=remount-ro
 UUID=cee15eca-5b2e-48ad-9735-eae5ac14bc90  none  swap  sw  0  0
 
 /dev/scd0  /media/cdrom0  udf,iso9660  user,noauto,exec,utf8  0  0`),
-			expected: []string{"/proc", "/", "/media/cdrom0"},
+			expected: []string{"/", "/media/cdrom0"},
+		},
+		{
+			name: "should exclude pseudo filesystems",
+			content: []byte(`proc  /proc  proc  defaults  0  0
+sysfs  /sys  sysfs  defaults  0  0
+tmpfs  /tmp  tmpfs  defaults,size=2G  0  0
+devtmpfs  /dev  devtmpfs  defaults  0  0
+cgroup2  /sys/fs/cgroup  cgroup2  defaults  0  0
+UUID=be35a709-c787-4198-a903-d5fdc80ab2f8  /  ext4  defaults  0  1
+/dev/sdb1  /var/openebs  xfs  defaults  0  2`),
+			expected: []string{"/", "/var/openebs"},
+		},
+		{
+			name:     "should exclude only the configured pseudo filesystems",
+			pseudoFS: []string{"proc"},
+			content: []byte(`proc  /proc  proc  defaults  0  0
+tmpfs  /tmp  tmpfs  defaults,size=2G  0  0
+UUID=be35a709-c787-4198-a903-d5fdc80ab2f8  /  ext4  defaults  0  1`),
+			expected: []string{"/tmp", "/"},
+		},
+		{
+			name:    "should fail if only pseudo filesystems are found",
+			content: []byte(`proc  /proc  proc  defaults  0  0`),
+			err:     "failed to locate any mount point",
 		},
 		{
 			name: "should dedup repeated mount point",
@@ -572,7 +1078,7 @@ sshfs#user@server:/share  fuse  user,allow_other  0  0
 		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
-			ochecker := OpenEBSFreeDiskSpaceGetter{}
+			ochecker := OpenEBSFreeDiskSpaceGetter{opts: OpenEBSOptions{Output: OpenEBSOutputOptions{PseudoFilesystems: tt.pseudoFS}}}
 			output, err := ochecker.parseFstabContainerOutput(tt.content)
 			if err != nil {
 				if len(tt.err) == 0 {
@@ -596,22 +1102,27 @@ sshfs#user@server:/share  fuse  user,allow_other  0  0
 
 func Test_basePath(t *testing.T) {
 	for _, tt := range []struct {
-		name     string
-		expected string
-		err      string
-		scname   string
-		objs     []runtime.Object
+		name        string
+		expected    string
+		provisioner string
+		err         string
+		kind        error
+		scname      string
+		vars        map[string]string
+		objs        []runtime.Object
 	}{
 		{
 			name:   "should fail if can't get the storage class",
 			scname: "does-not-exist",
 			err:    `class: storageclasses.storage.k8s.io "does-not-exist" not found`,
+			kind:   ErrStorageClassNotFound,
 			objs:   []runtime.Object{},
 		},
 		{
 			name:   "no annotation",
 			scname: "default",
 			err:    "annotation not found in storage class",
+			kind:   ErrOpenEBSConfigNotFound,
 			objs: []runtime.Object{
 				&storagev1.StorageClass{
 					ObjectMeta: metav1.ObjectMeta{
@@ -624,6 +1135,7 @@ func Test_basePath(t *testing.T) {
 			name:   "should fail if the openebs configuration is invalid",
 			scname: "default",
 			err:    "failed to parse openebs config annotation",
+			kind:   ErrOpenEBSConfigInvalid,
 			objs: []runtime.Object{
 				&storagev1.StorageClass{
 					ObjectMeta: metav1.ObjectMeta{
@@ -639,6 +1151,7 @@ func Test_basePath(t *testing.T) {
 			name:   "should fail if opeenbs configuration does not contain the base path",
 			scname: "default",
 			err:    "openebs base path not defined in the storage class",
+			kind:   ErrBasePathNotDefined,
 			objs: []runtime.Object{
 				&storagev1.StorageClass{
 					ObjectMeta: metav1.ObjectMeta{
@@ -654,6 +1167,7 @@ func Test_basePath(t *testing.T) {
 			name:   "should fail if opeenbs base path is empty",
 			scname: "default",
 			err:    "invalid opeenbs base path",
+			kind:   ErrBasePathInvalid,
 			objs: []runtime.Object{
 				&storagev1.StorageClass{
 					ObjectMeta: metav1.ObjectMeta{
@@ -669,6 +1183,7 @@ func Test_basePath(t *testing.T) {
 			name:   "should fail if openebs base path is not a path",
 			scname: "default",
 			err:    "invalid opeenbs base path",
+			kind:   ErrBasePathInvalid,
 			objs: []runtime.Object{
 				&storagev1.StorageClass{
 					ObjectMeta: metav1.ObjectMeta{
@@ -681,9 +1196,10 @@ func Test_basePath(t *testing.T) {
 			},
 		},
 		{
-			name:     "should be able to parse openebs configuration",
-			scname:   "default",
-			expected: "/var/local",
+			name:        "should be able to parse openebs configuration",
+			scname:      "default",
+			expected:    "/var/local",
+			provisioner: OpenEBSLocalProvisioner,
 			objs: []runtime.Object{
 				&storagev1.StorageClass{
 					ObjectMeta: metav1.ObjectMeta{
@@ -692,6 +1208,60 @@ func Test_basePath(t *testing.T) {
 							"cas.openebs.io/config": "- name: BasePath\n  value: /var/local",
 						},
 					},
+					Provisioner: OpenEBSLocalProvisioner,
+				},
+			},
+		},
+		{
+			name:        "should resolve known placeholders in templated base paths",
+			scname:      "default",
+			expected:    "/mnt/disk1/openebs",
+			provisioner: OpenEBSLocalProvisioner,
+			vars:        map[string]string{"DATA_DIR": "/mnt/disk1"},
+			objs: []runtime.Object{
+				&storagev1.StorageClass{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "default",
+						Annotations: map[string]string{
+							"cas.openebs.io/config": "- name: BasePath\n  value: ${DATA_DIR}/openebs",
+						},
+					},
+					Provisioner: OpenEBSLocalProvisioner,
+				},
+			},
+		},
+		{
+			name:   "should fail if the templated base path contains unknown placeholders",
+			scname: "default",
+			err:    "base path $DATA_DIR/openebs contains unresolved placeholder $DATA_DIR",
+			kind:   ErrBasePathUnresolved,
+			objs: []runtime.Object{
+				&storagev1.StorageClass{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "default",
+						Annotations: map[string]string{
+							"cas.openebs.io/config": "- name: BasePath\n  value: $DATA_DIR/openebs",
+						},
+					},
+					Provisioner: OpenEBSLocalProvisioner,
+				},
+			},
+		},
+		{
+			name:   "should fail if the base path is a go template",
+			scname: "default",
+			err:    "contains unresolved placeholder {{ .Values.basePath }}",
+			kind:   ErrBasePathUnresolved,
+			vars:   map[string]string{"Values": "/var/local"},
+			objs: []runtime.Object{
+				&storagev1.StorageClass{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "default",
+						Annotations: map[string]string{
+							"cas.openebs.io/config": "- name: BasePath\n  value: \"{{ .Values.basePath }}\"",
+						},
+					},
+					Provisioner: OpenEBSLocalProvisioner,
 				},
 			},
 		},
@@ -699,17 +1269,28 @@ func Test_basePath(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			fakecli := fake.NewSimpleClientset(tt.objs...)
 			ochecker := OpenEBSFreeDiskSpaceGetter{
-				kcli:   fakecli,
-				scname: tt.scname,
+				kcli: fakecli,
+				opts: OpenEBSOptions{
+					DstSC:        tt.scname,
+					BasePathVars: tt.vars,
+				},
 			}
 
-			bpath, err := ochecker.basePath(context.Background())
+			bpath, provisioner, err := ochecker.basePath(context.Background())
 			if err != nil {
 				if len(tt.err) == 0 {
 					t.Errorf("unexpected error: %s", err)
 				} else if !strings.Contains(err.Error(), tt.err) {
 					t.Errorf("expecting %q, %q received instead", tt.err, err)
 				}
+
+				if !errors.Is(err, tt.kind) {
+					t.Errorf("expected error to be %v, %v received instead", tt.kind, err)
+				}
+				var bperr *BasePathError
+				if !errors.As(err, &bperr) || bperr.StorageClass != tt.scname {
+					t.Errorf("expected a base path error for storage class %s, %#v received instead", tt.scname, err)
+				}
 				return
 			}
 
@@ -720,13 +1301,57 @@ func Test_basePath(t *testing.T) {
 			if bpath != tt.expected {
 				t.Errorf("expected %v, received %v", tt.expected, bpath)
 			}
+
+			if provisioner != tt.provisioner {
+				t.Errorf("expected provisioner %v, received %v", tt.provisioner, provisioner)
+			}
 		})
 	}
 }
 
+func Test_validateProvisioner(t *testing.T) {
+	ochecker := OpenEBSFreeDiskSpaceGetter{opts: OpenEBSOptions{DstSC: "default"}}
+	if err := ochecker.validateProvisioner(OpenEBSLocalProvisioner); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	expected := "storage class default uses provisioner rook-ceph.rbd.csi.ceph.com, expected openebs.io/local"
+	if err := ochecker.validateProvisioner("rook-ceph.rbd.csi.ceph.com"); err == nil || err.Error() != expected {
+		t.Errorf("expecting %q, %v received instead", expected, err)
+	}
+}
+
+func TestOpenEBSVolumesMismatchedProvisioner(t *testing.T) {
+	fakecli := fake.NewSimpleClientset(
+		&storagev1.StorageClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "default",
+				Annotations: map[string]string{
+					"cas.openebs.io/config": "- name: BasePath\n  value: /var/local",
+				},
+			},
+			Provisioner: "kubernetes.io/no-provisioner",
+		},
+	)
+
+	ochecker := OpenEBSFreeDiskSpaceGetter{
+		kcli: fakecli,
+		opts: OpenEBSOptions{
+			DstSC: "default",
+		},
+		log: log.New(io.Discard, "", 0),
+	}
+
+	_, err := ochecker.OpenEBSVolumes(context.Background())
+	expected := "storage class default uses provisioner kubernetes.io/no-provisioner, expected openebs.io/local"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expecting %q, %v received instead", expected, err)
+	}
+}
+
 func Test_buildJob(t *testing.T) {
 	nname := "this-is-a-very-long-node-name-this-will-extrapolate-the-limit"
-	ochecker := OpenEBSFreeDiskSpaceGetter{image: "myimage:latest"}
+	ochecker := OpenEBSFreeDiskSpaceGetter{opts: OpenEBSOptions{Image: "myimage:latest", Namespace: "default"}}
 	job := ochecker.buildJob(context.Background(), nname, "/var/local", "tmppvc")
 
 	// check that the job name is within boundaries
@@ -780,6 +1405,197 @@ func Test_buildJob(t *testing.T) {
 	}
 }
 
+func Test_buildJobLabels(t *testing.T) {
+	ochecker := OpenEBSFreeDiskSpaceGetter{
+		opts: OpenEBSOptions{
+			Image:     "myimage:latest",
+			Namespace: "default",
+			Job: OpenEBSJobOptions{
+				Labels:      map[string]string{"cost-center": "platform", "app": "overridden"},
+				Annotations: map[string]string{"team": "storage"},
+			},
+		},
+	}
+
+	expectedLabels := map[string]string{
+		"cost-center":         "platform",
+		"app":                 OpenEBSJobAppLabel,
+		OpenEBSManagedByLabel: OpenEBSManagedBy,
+		OpenEBSComponentLabel: OpenEBSComponent,
+		OpenEBSNodeLabel:      "node0",
+	}
+	expectedAnnotations := map[string]string{"team": "storage"}
+
+	job := ochecker.buildJob(context.Background(), "node0", "/var/local", "tmppvc")
+	pod := ochecker.buildPod(job)
+	for name, meta := range map[string]metav1.ObjectMeta{
+		"job":          job.ObjectMeta,
+		"pod template": job.Spec.Template.ObjectMeta,
+		"pod":          pod.ObjectMeta,
+	} {
+		if diff := cmp.Diff(expectedLabels, meta.Labels); diff != "" {
+			t.Errorf("unexpected %s labels: %s", name, diff)
+		}
+		if diff := cmp.Diff(expectedAnnotations, meta.Annotations); diff != "" {
+			t.Errorf("unexpected %s annotations: %s", name, diff)
+		}
+	}
+
+	// the getter maps must not be shared with the job.
+	job.Labels["extra"] = "value"
+	if _, ok := ochecker.opts.Job.Labels["extra"]; ok {
+		t.Errorf("job labels are shared with the getter")
+	}
+}
+
+func Test_nodeLabelValue(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		node string
+		exp  string
+	}{
+		{
+			name: "should keep short node names",
+			node: "node0.example.com",
+			exp:  "node0.example.com",
+		},
+		{
+			name: "should truncate long node names",
+			node: strings.Repeat("a", 70),
+			exp:  strings.Repeat("a", 63),
+		},
+		{
+			name: "should trim trailing separators after truncating",
+			node: strings.Repeat("a", 61) + ".-b",
+			exp:  strings.Repeat("a", 61),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if value := nodeLabelValue(tt.node); value != tt.exp {
+				t.Errorf("expecting %q, %q received instead", tt.exp, value)
+			}
+		})
+	}
+}
+
+func Test_runIDLabels(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	getter, err := NewOpenEBSFreeDiskSpaceGetterWithOptions(fake.NewSimpleClientset(), OpenEBSOptions{
+		Log:          log.New(buf, "", 0),
+		Image:        "myimage:latest",
+		DstSC:        "openebs",
+		WindowsImage: "mcr.microsoft.com/powershell:lts-nanoserver-ltsc2022",
+		Job: OpenEBSJobOptions{
+			Labels: map[string]string{OpenEBSRunIDLabel: "overridden"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	runID := getter.RunID()
+	if runID == "" {
+		t.Fatalf("empty run id")
+	}
+
+	job := getter.buildJob(context.Background(), "node0", "/var/local", "tmppvc")
+	windowsJob := getter.buildWindowsJob("node1")
+	for name, meta := range map[string]metav1.ObjectMeta{
+		"pvc":                  getter.buildTmpPVC("node0").ObjectMeta,
+		"job":                  job.ObjectMeta,
+		"pod template":         job.Spec.Template.ObjectMeta,
+		"pod":                  getter.buildPod(job).ObjectMeta,
+		"windows job":          windowsJob.ObjectMeta,
+		"windows pod template": windowsJob.Spec.Template.ObjectMeta,
+	} {
+		if meta.Labels[OpenEBSRunIDLabel] != runID {
+			t.Errorf("expecting %s run id %q, %q received instead", name, runID, meta.Labels[OpenEBSRunIDLabel])
+		}
+		if meta.Labels[OpenEBSManagedByLabel] != OpenEBSManagedBy || meta.Labels[OpenEBSComponentLabel] != OpenEBSComponent {
+			t.Errorf("expecting %s to be labeled as a kurl spacecheck resource, labels %v received", name, meta.Labels)
+		}
+		if node := meta.Labels[OpenEBSNodeLabel]; node != "node0" && node != "node1" {
+			t.Errorf("expecting %s node label, %q received instead", name, node)
+		}
+	}
+
+	getter.log.Printf("Analyzing free space on node %s", "node0")
+	if expected := "[run " + runID + "] Analyzing"; !strings.Contains(buf.String(), expected) {
+		t.Errorf("expecting %q in log, %q received instead", expected, buf.String())
+	}
+
+	other, err := NewOpenEBSFreeDiskSpaceGetter(fake.NewSimpleClientset(), log.New(io.Discard, "", 0), "myimage:latest", "openebs")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if other.RunID() == runID {
+		t.Errorf("expecting different run ids, %q received twice", runID)
+	}
+}
+
+func Test_runJobAsPod(t *testing.T) {
+	kcli := fake.NewSimpleClientset()
+	kcli.PrependReactor(
+		"create", "pods",
+		func(action k8stesting.Action) (bool, runtime.Object, error) {
+			// pods are marked as succeeded as soon as they are created.
+			pod := action.(k8stesting.CreateAction).GetObject().(*corev1.Pod)
+			pod.Status.Phase = corev1.PodSucceeded
+			return false, nil, nil
+		},
+	)
+
+	ochecker := OpenEBSFreeDiskSpaceGetter{
+		opts: OpenEBSOptions{
+			Image:     "myimage:latest",
+			Namespace: "default",
+			Job: OpenEBSJobOptions{
+				Timeout:  time.Minute,
+				RunAsPod: true,
+			},
+		},
+		kcli: kcli,
+		log:  log.New(io.Discard, "", 0),
+	}
+
+	job := ochecker.buildJob(context.Background(), "node0", "/var/local", "tmppvc")
+	out, _, err := ochecker.runJob(context.Background(), job)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var createdPod *corev1.Pod
+	for _, action := range kcli.Actions() {
+		if action.GetVerb() != "create" {
+			continue
+		}
+		if action.GetResource().Resource == "jobs" {
+			t.Errorf("job created while running as pod")
+		}
+		if action.GetResource().Resource == "pods" {
+			createdPod = action.(k8stesting.CreateAction).GetObject().(*corev1.Pod)
+		}
+	}
+
+	if createdPod == nil {
+		t.Fatalf("pod has not been created")
+	}
+
+	if createdPod.Spec.RestartPolicy != corev1.RestartPolicyNever {
+		t.Errorf("expected restart policy Never, received %s", createdPod.Spec.RestartPolicy)
+	}
+
+	if diff := cmp.Diff(job.Spec.Template.Spec.Containers, createdPod.Spec.Containers); diff != "" {
+		t.Errorf("pod containers differ from the job template: %s", diff)
+	}
+
+	for _, container := range []string{"df", "fstab"} {
+		if _, ok := out[container]; !ok {
+			t.Errorf("logs for container %s not read", container)
+		}
+	}
+}
+
 func TestNewOpenEBSVolumesGetter(t *testing.T) {
 	// test empty logger
 	_, err := NewOpenEBSFreeDiskSpaceGetter(nil, nil, "image", "scname")
@@ -802,8 +1618,181 @@ func TestNewOpenEBSVolumesGetter(t *testing.T) {
 	}
 
 	// happy path
-	_, err = NewOpenEBSFreeDiskSpaceGetter(nil, logger, "image", "scname")
+	getter, err := NewOpenEBSFreeDiskSpaceGetter(nil, logger, "image", "scname")
+	if err != nil {
+		t.Errorf("unexpected failure creating object: %v", err)
+	} else if getter.opts.PVC.Size.String() != "1Mi" {
+		t.Errorf("expected the temporary pvc size to default to 1Mi, %s found", getter.opts.PVC.Size.String())
+	}
+
+	// test negative temporary pvc size
+	_, err = NewOpenEBSFreeDiskSpaceGetterWithOptions(nil, OpenEBSOptions{
+		Log:   logger,
+		Image: "image",
+		DstSC: "scname",
+		PVC: OpenEBSPVCOptions{
+			Size: resource.MustParse("-1Gi"),
+		},
+	})
+	if err == nil || err.Error() != "invalid temporary pvc size -1Gi" {
+		t.Errorf("expected failure creating object: %v", err)
+	}
+
+	// test negative delete pv timeout
+	_, err = NewOpenEBSFreeDiskSpaceGetterWithOptions(nil, OpenEBSOptions{
+		Log:   logger,
+		Image: "image",
+		DstSC: "scname",
+		PVC: OpenEBSPVCOptions{
+			DeletePVTimeout: -time.Second,
+		},
+	})
+	if err == nil || err.Error() != "invalid delete pv timeout -1s" {
+		t.Errorf("expected failure creating object: %v", err)
+	}
+
+	// test custom delete pv timeout
+	getter, err = NewOpenEBSFreeDiskSpaceGetterWithOptions(nil, OpenEBSOptions{
+		Log:   logger,
+		Image: "image",
+		DstSC: "scname",
+		PVC: OpenEBSPVCOptions{
+			DeletePVTimeout: 20 * time.Second,
+		},
+	})
 	if err != nil {
 		t.Errorf("unexpected failure creating object: %v", err)
+	} else if getter.opts.PVC.DeletePVTimeout != 20*time.Second {
+		t.Errorf("expected delete pv timeout to be 20s, %s found", getter.opts.PVC.DeletePVTimeout)
+	}
+
+	// test job retries, unset, disabled and negative
+	for _, tt := range []struct {
+		retries  *int
+		expected int
+		err      string
+	}{
+		{retries: nil, expected: 3},
+		{retries: ptr.To(0), expected: 0},
+		{retries: ptr.To(-1), err: "invalid job retries -1"},
+	} {
+		getter, err = NewOpenEBSFreeDiskSpaceGetterWithOptions(nil, OpenEBSOptions{
+			Log:   logger,
+			Image: "image",
+			DstSC: "scname",
+			Job: OpenEBSJobOptions{
+				Retries: tt.retries,
+			},
+		})
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("expected failure creating object: %v", err)
+			}
+		} else if err != nil {
+			t.Errorf("unexpected failure creating object: %v", err)
+		} else if *getter.opts.Job.Retries != tt.expected {
+			t.Errorf("expected %d job retries, %d found", tt.expected, *getter.opts.Job.Retries)
+		}
+	}
+}
+
+func TestMissingPermissions(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		denied   map[string]bool
+		expected []string
+	}{
+		{
+			name:   "should return nothing if all permissions are granted",
+			denied: map[string]bool{},
+		},
+		{
+			name: "should return the denied permissions",
+			denied: map[string]bool{
+				"create/batch/jobs":                 true,
+				"delete//persistentvolumeclaims":    true,
+				"get/storage.k8s.io/storageclasses": true,
+			},
+			expected: []string{
+				"get storageclasses.storage.k8s.io",
+				"delete persistentvolumeclaims in namespace default",
+				"create jobs.batch in namespace default",
+			},
+		},
+		{
+			name: "should handle subresources",
+			denied: map[string]bool{
+				"get//pods/log": true,
+			},
+			expected: []string{
+				"get pods/log in namespace default",
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			kcli := fake.NewSimpleClientset()
+			kcli.PrependReactor(
+				"create", "selfsubjectaccessreviews",
+				func(action k8stesting.Action) (bool, runtime.Object, error) {
+					review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+					attrs := review.Spec.ResourceAttributes
+					resource := attrs.Resource
+					if attrs.Subresource != "" {
+						resource = resource + "/" + attrs.Subresource
+					}
+					key := strings.Join([]string{attrs.Verb, attrs.Group, resource}, "/")
+					review.Status.Allowed = !tt.denied[key]
+					return true, review, nil
+				},
+			)
+
+			ochecker := OpenEBSFreeDiskSpaceGetter{kcli: kcli, opts: OpenEBSOptions{Namespace: "default"}}
+			missing, err := ochecker.MissingPermissions(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			var received []string
+			for _, perm := range missing {
+				received = append(received, perm.String())
+			}
+
+			if diff := cmp.Diff(tt.expected, received); diff != "" {
+				t.Errorf("unexpected return: %s", diff)
+			}
+		})
+	}
+}
+
+func TestOpenEBSFreeDiskSpaceGetter_RequiredPermissions(t *testing.T) {
+	getPVC := k8sutil.Permission{Verb: "get", Resource: "persistentvolumeclaims", Namespace: "default"}
+	deletePV := k8sutil.Permission{Verb: "delete", Resource: "persistentvolumes"}
+	for _, tt := range []struct {
+		name     string
+		reusePVC bool
+		expected map[k8sutil.Permission]bool
+	}{
+		{
+			name:     "pvcs are only read when reused",
+			expected: map[k8sutil.Permission]bool{getPVC: false, deletePV: true},
+		},
+		{
+			name:     "reused pvcs must be readable",
+			reusePVC: true,
+			expected: map[k8sutil.Permission]bool{getPVC: true, deletePV: true},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			getter := OpenEBSFreeDiskSpaceGetter{opts: OpenEBSOptions{Namespace: "default", PVC: OpenEBSPVCOptions{Reuse: tt.reusePVC}}}
+			required := map[k8sutil.Permission]bool{}
+			for _, perm := range getter.RequiredPermissions() {
+				required[perm] = true
+			}
+			for perm, expected := range tt.expected {
+				if required[perm] != expected {
+					t.Errorf("expected %s to be required: %v", perm, expected)
+				}
+			}
+		})
 	}
 }
@@ -0,0 +1,175 @@
+package clusterspace
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// OpenEBSJobAppLabel is the value of the "app" label set in all jobs created to check the openebs
+// free disk space.
+const OpenEBSJobAppLabel = "kurl-job-openebs-disk-free"
+
+// OpenEBSRunIDLabel is the label holding the id of the run that created a job, pod or temporary pvc
+// while checking the openebs free disk space.
+const OpenEBSRunIDLabel = "kurl.sh/disk-free-run-id"
+
+// labels set, along with the run id, in all jobs, pods and temporary pvcs created while checking
+// the openebs free disk space so they can be found through label selectors, e.g. kubectl get -l
+// kurl.sh/component=spacecheck. OpenEBSNodeLabel holds the name of the node being checked.
+const (
+	OpenEBSManagedByLabel = "app.kubernetes.io/managed-by"
+	OpenEBSManagedBy      = "kurl"
+	OpenEBSComponentLabel = "kurl.sh/component"
+	OpenEBSComponent      = "spacecheck"
+	OpenEBSNodeLabel      = "kurl.sh/node"
+)
+
+// controlPlaneTaints are the keys of the taints kubeadm sets on the control plane nodes, the older
+// master one is still found in clusters upgraded from old kubernetes versions.
+var controlPlaneTaints = []string{
+	"node-role.kubernetes.io/control-plane",
+	"node-role.kubernetes.io/master",
+}
+
+// jobTolerations returns the tolerations of the job pods: the provided ones preceded, if
+// controlPlane is set, by tolerations for the control plane NoSchedule taints. tolerations only
+// allow a pod in a node, the node affinity of each job still pins its pod to the measured node.
+func jobTolerations(tolerations []corev1.Toleration, controlPlane bool) []corev1.Toleration {
+	if !controlPlane {
+		return tolerations
+	}
+
+	var result []corev1.Toleration
+	for _, key := range controlPlaneTaints {
+		result = append(result, corev1.Toleration{
+			Key:      key,
+			Operator: corev1.TolerationOpExists,
+			Effect:   corev1.TaintEffectNoSchedule,
+		})
+	}
+	return append(result, tolerations...)
+}
+
+// PurgedJobs holds the number of checker jobs deleted by PurgeFinishedJobs.
+type PurgedJobs struct {
+	Completed int
+	Failed    int
+}
+
+// PurgedRun holds the number of resources deleted by PurgeRun.
+type PurgedRun struct {
+	Jobs int
+	Pods int
+	PVCs int
+}
+
+// jobFinishedCondition returns the condition type (Complete or Failed) if the job has finished.
+func jobFinishedCondition(job batchv1.Job) (batchv1.JobConditionType, bool) {
+	for _, cond := range job.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		if cond.Type == batchv1.JobComplete || cond.Type == batchv1.JobFailed {
+			return cond.Type, true
+		}
+	}
+	return "", false
+}
+
+// PurgeFinishedJobs deletes, across all namespaces, the openebs free disk space checker jobs that have
+// already completed or failed. only jobs created more than olderThan ago are deleted, jobs still running
+// are never touched.
+func PurgeFinishedJobs(ctx context.Context, kcli kubernetes.Interface, olderThan time.Duration) (PurgedJobs, error) {
+	var purged PurgedJobs
+	jobs, err := kcli.BatchV1().Jobs("").List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("app=%s", OpenEBSJobAppLabel),
+	})
+	if err != nil {
+		return purged, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	now := time.Now()
+	for _, job := range jobs.Items {
+		condition, finished := jobFinishedCondition(job)
+		if !finished {
+			continue
+		}
+
+		if now.Sub(job.CreationTimestamp.Time) < olderThan {
+			continue
+		}
+
+		propagation := metav1.DeletePropagationBackground
+		if err := kcli.BatchV1().Jobs(job.Namespace).Delete(
+			ctx, job.Name, metav1.DeleteOptions{PropagationPolicy: &propagation},
+		); err != nil {
+			return purged, fmt.Errorf("failed to delete job %s/%s: %w", job.Namespace, job.Name, err)
+		}
+
+		if condition == batchv1.JobComplete {
+			purged.Completed++
+			continue
+		}
+		purged.Failed++
+	}
+	return purged, nil
+}
+
+// PurgeRun deletes, across all namespaces, the jobs, pods and temporary pvcs created by the openebs free
+// disk space checker run with the provided id, regardless of their state.
+func PurgeRun(ctx context.Context, kcli kubernetes.Interface, runID string) (PurgedRun, error) {
+	var purged PurgedRun
+	if runID == "" {
+		return purged, fmt.Errorf("empty run id")
+	}
+
+	selector := metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", OpenEBSRunIDLabel, runID),
+	}
+	propagation := metav1.DeletePropagationBackground
+	delopts := metav1.DeleteOptions{PropagationPolicy: &propagation}
+
+	jobs, err := kcli.BatchV1().Jobs("").List(ctx, selector)
+	if err != nil {
+		return purged, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	for _, job := range jobs.Items {
+		if err := kcli.BatchV1().Jobs(job.Namespace).Delete(ctx, job.Name, delopts); err != nil {
+			return purged, fmt.Errorf("failed to delete job %s/%s: %w", job.Namespace, job.Name, err)
+		}
+		purged.Jobs++
+	}
+
+	// pods created by the jobs are removed along with them, only the bare pods are deleted here.
+	pods, err := kcli.CoreV1().Pods("").List(ctx, selector)
+	if err != nil {
+		return purged, fmt.Errorf("failed to list pods: %w", err)
+	}
+	for _, pod := range pods.Items {
+		if metav1.GetControllerOf(&pod) != nil {
+			continue
+		}
+		if err := kcli.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, delopts); err != nil {
+			return purged, fmt.Errorf("failed to delete pod %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+		purged.Pods++
+	}
+
+	pvcs, err := kcli.CoreV1().PersistentVolumeClaims("").List(ctx, selector)
+	if err != nil {
+		return purged, fmt.Errorf("failed to list persistent volume claims: %w", err)
+	}
+	for _, pvc := range pvcs.Items {
+		if err := kcli.CoreV1().PersistentVolumeClaims(pvc.Namespace).Delete(ctx, pvc.Name, delopts); err != nil {
+			return purged, fmt.Errorf("failed to delete pvc %s/%s: %w", pvc.Namespace, pvc.Name, err)
+		}
+		purged.PVCs++
+	}
+	return purged, nil
+}
@@ -0,0 +1,196 @@
+package clusterspace
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPurgeFinishedJobs(t *testing.T) {
+	job := func(name, namespace, app string, age time.Duration, condition batchv1.JobConditionType) *batchv1.Job {
+		job := &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         namespace,
+				Labels:            map[string]string{"app": app},
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+			},
+		}
+		if condition != "" {
+			job.Status.Conditions = []batchv1.JobCondition{
+				{Type: condition, Status: corev1.ConditionTrue},
+			}
+		}
+		return job
+	}
+
+	for _, tt := range []struct {
+		name      string
+		olderThan time.Duration
+		objs      []runtime.Object
+		expected  PurgedJobs
+		remaining []string
+	}{
+		{
+			name: "should do nothing without jobs",
+		},
+		{
+			name: "should delete only finished checker jobs",
+			objs: []runtime.Object{
+				job("completed", "default", OpenEBSJobAppLabel, time.Minute, batchv1.JobComplete),
+				job("failed", "kurl", OpenEBSJobAppLabel, time.Minute, batchv1.JobFailed),
+				job("running", "default", OpenEBSJobAppLabel, time.Minute, ""),
+				job("other-app", "default", "something-else", time.Minute, batchv1.JobComplete),
+			},
+			expected:  PurgedJobs{Completed: 1, Failed: 1},
+			remaining: []string{"default/other-app", "default/running"},
+		},
+		{
+			name:      "should delete only jobs older than the provided duration",
+			olderThan: time.Hour,
+			objs: []runtime.Object{
+				job("old-completed", "default", OpenEBSJobAppLabel, 2*time.Hour, batchv1.JobComplete),
+				job("new-completed", "default", OpenEBSJobAppLabel, time.Minute, batchv1.JobComplete),
+				job("new-failed", "default", OpenEBSJobAppLabel, time.Minute, batchv1.JobFailed),
+			},
+			expected:  PurgedJobs{Completed: 1},
+			remaining: []string{"default/new-completed", "default/new-failed"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			kcli := fake.NewSimpleClientset(tt.objs...)
+			purged, err := PurgeFinishedJobs(context.Background(), kcli, tt.olderThan)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, purged); diff != "" {
+				t.Errorf("unexpected purged jobs: %s", diff)
+			}
+
+			jobs, err := kcli.BatchV1().Jobs("").List(context.Background(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error listing jobs: %s", err)
+			}
+
+			var remaining []string
+			for _, job := range jobs.Items {
+				remaining = append(remaining, job.Namespace+"/"+job.Name)
+			}
+			sort.Strings(remaining)
+			if diff := cmp.Diff(tt.remaining, remaining); diff != "" {
+				t.Errorf("unexpected remaining jobs: %s", diff)
+			}
+		})
+	}
+}
+
+func TestPurgeRun(t *testing.T) {
+	meta := func(kind, name, namespace, runID string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{
+			Name:      kind + "-" + name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": OpenEBSJobAppLabel, OpenEBSRunIDLabel: runID},
+		}
+	}
+
+	objs := []runtime.Object{
+		&batchv1.Job{ObjectMeta: meta("job", "a", "default", "run1")},
+		&batchv1.Job{ObjectMeta: meta("job", "b", "kurl", "run1")},
+		&batchv1.Job{ObjectMeta: meta("job", "c", "default", "run2")},
+		&corev1.Pod{ObjectMeta: meta("pod", "a", "default", "run1")},
+		&corev1.Pod{ObjectMeta: meta("pod", "b", "default", "run2")},
+		&corev1.PersistentVolumeClaim{ObjectMeta: meta("pvc", "a", "default", "run1")},
+		&corev1.PersistentVolumeClaim{ObjectMeta: meta("pvc", "b", "default", "run2")},
+	}
+
+	kcli := fake.NewSimpleClientset(objs...)
+	purged, err := PurgeRun(context.Background(), kcli, "run1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if diff := cmp.Diff(PurgedRun{Jobs: 2, Pods: 1, PVCs: 1}, purged); diff != "" {
+		t.Errorf("unexpected purged resources: %s", diff)
+	}
+
+	var remaining []string
+	jobs, err := kcli.BatchV1().Jobs("").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error listing jobs: %s", err)
+	}
+	for _, job := range jobs.Items {
+		remaining = append(remaining, job.Name)
+	}
+	pods, err := kcli.CoreV1().Pods("").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error listing pods: %s", err)
+	}
+	for _, pod := range pods.Items {
+		remaining = append(remaining, pod.Name)
+	}
+	pvcs, err := kcli.CoreV1().PersistentVolumeClaims("").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error listing pvcs: %s", err)
+	}
+	for _, pvc := range pvcs.Items {
+		remaining = append(remaining, pvc.Name)
+	}
+
+	sort.Strings(remaining)
+	if diff := cmp.Diff([]string{"job-c", "pod-b", "pvc-b"}, remaining); diff != "" {
+		t.Errorf("unexpected remaining resources: %s", diff)
+	}
+
+	if _, err := PurgeRun(context.Background(), kcli, ""); err == nil {
+		t.Errorf("expecting error for empty run id")
+	}
+}
+
+func Test_jobTolerations(t *testing.T) {
+	custom := []corev1.Toleration{{Key: "gpu", Operator: corev1.TolerationOpEqual, Value: "true", Effect: corev1.TaintEffectNoExecute}}
+	for _, tt := range []struct {
+		name         string
+		tolerations  []corev1.Toleration
+		controlPlane bool
+		expected     []corev1.Toleration
+	}{
+		{
+			name:        "should only return the provided tolerations",
+			tolerations: custom,
+			expected:    custom,
+		},
+		{
+			name:         "should tolerate the control plane taints",
+			controlPlane: true,
+			expected: []corev1.Toleration{
+				{Key: "node-role.kubernetes.io/control-plane", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+				{Key: "node-role.kubernetes.io/master", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+			},
+		},
+		{
+			name:         "should keep the provided tolerations after the control plane ones",
+			tolerations:  custom,
+			controlPlane: true,
+			expected: []corev1.Toleration{
+				{Key: "node-role.kubernetes.io/control-plane", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+				{Key: "node-role.kubernetes.io/master", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+				custom[0],
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.expected, jobTolerations(tt.tolerations, tt.controlPlane)); diff != "" {
+				t.Errorf("unexpected tolerations: %s", diff)
+			}
+		})
+	}
+}
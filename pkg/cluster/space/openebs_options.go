@@ -0,0 +1,356 @@
+package clusterspace
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// defaultOpenEBSNamespace is the namespace where the temporary pvcs and jobs are created.
+	defaultOpenEBSNamespace = "default"
+	// defaultOpenEBSJobTimeout is how long we wait for the df job to finish on each node.
+	defaultOpenEBSJobTimeout = 5 * time.Minute
+	// defaultOpenEBSJobRetries is how many times a job whose pod could not be scheduled is retried.
+	defaultOpenEBSJobRetries = 3
+	// defaultOpenEBSDeletePVTimeout is how long we wait for the temporary pvs to disappear.
+	defaultOpenEBSDeletePVTimeout = 5 * time.Minute
+	// defaultOpenEBSListNodesTimeout is how long we wait for the API server to list the nodes.
+	defaultOpenEBSListNodesTimeout = 30 * time.Second
+	// defaultOpenEBSStorageClassTimeout is how long we wait for the API server to return the
+	// destination storage class.
+	defaultOpenEBSStorageClassTimeout = 10 * time.Second
+	// defaultOpenEBSSpaceWorkers is the number of nodes NodesWithSpace measures at the same time.
+	defaultOpenEBSSpaceWorkers = 5
+)
+
+// defaultOpenEBSTmpPVCSize is the storage requested by the temporary pvcs.
+var defaultOpenEBSTmpPVCSize = resource.MustParse("1Mi")
+
+// MountMatchStrategy defines how the df output line for the openebs base path is located.
+type MountMatchStrategy string
+
+const (
+	// MountMatchExact only accepts the df line whose mount point is exactly the probed path.
+	MountMatchExact MountMatchStrategy = "exact"
+	// MountMatchLongestPrefix falls back to the df line whose mount point is the longest parent
+	// of the probed path when no exact match exists.
+	MountMatchLongestPrefix MountMatchStrategy = "longest-prefix"
+	// MountMatchInnermost considers all the df lines whose mount point is the probed path or one of
+	// its parents and picks the most specific one. for stacked mounts on the same path the last one
+	// listed, the one on top, is used. ambiguities are logged.
+	MountMatchInnermost MountMatchStrategy = "innermost"
+	// MountMatchBlockDevice considers, like MountMatchInnermost, all the df lines whose mount point
+	// is the probed path or one of its parents but prefers the ones whose source is a block device
+	// over overlay, tmpfs and other virtual layers. meant for nodes where the base path sits under
+	// stacked overlay mounts, as in container optimized operating systems.
+	MountMatchBlockDevice MountMatchStrategy = "block-device"
+)
+
+// OpenEBSOptions holds all the knobs used when evaluating the disk space available in a storage
+// class backed by the OpenEBS local volume provisioner. Only Log, Image and DstSC are mandatory,
+// SrcSC is mandatory only for the disk space validator.
+type OpenEBSOptions struct {
+	// Log is where progress is reported to.
+	Log *log.Logger
+	// Image is used by the df job, it may be any image containing 'df' and 'cat' commands.
+	Image string
+	// SrcSC is the storage class volumes are migrated from.
+	SrcSC string
+	// DstSC is the OpenEBS storage class being measured.
+	DstSC string
+	// BasePathVars hold the values of the placeholders, $NAME or ${NAME}, found in templated
+	// storage class base paths. base paths with placeholders not listed here are rejected.
+	BasePathVars map[string]string
+	// Namespace is where the temporary pvcs and jobs are created. defaults to "default".
+	Namespace string
+	// Job configures the df jobs (or pods) run in every node.
+	Job OpenEBSJobOptions
+	// PVC configures the temporary pvcs used to learn the node base paths.
+	PVC OpenEBSPVCOptions
+	// Output configures how the free space is measured in the df jobs and how their output is
+	// parsed.
+	Output OpenEBSOutputOptions
+	// ListNodesTimeout is how long we wait for the API server to list the cluster nodes before
+	// any node is evaluated. defaults to 30 seconds.
+	ListNodesTimeout time.Duration
+	// StorageClassTimeout is how long we wait for the API server to return the destination
+	// storage class when reading its base path. defaults to 10 seconds.
+	StorageClassTimeout time.Duration
+	// Parallelism is the maximum number of nodes evaluated at the same time. defaults to one, nodes
+	// are evaluated sequentially.
+	Parallelism int
+	// SpaceWorkers is the maximum number of nodes measured at the same time by NodesWithSpace,
+	// which keeps measuring the remaining nodes when one of them fails. defaults to 5.
+	SpaceWorkers int
+	// CreateRate is the maximum number of jobs (or pods) created per second, in addition to the
+	// Parallelism cap, so creations are evenly spaced instead of hitting the API server in bursts.
+	// zero means no limit.
+	CreateRate float64
+	// Reserved is an extra amount of bytes that must be kept free on every node.
+	Reserved int64
+	// ReservePolicies define, per destination storage class, how much space must be kept free in
+	// each volume. storage classes without a policy use RootDiskReserve. only used by the disk
+	// space validator.
+	ReservePolicies ReservePolicies
+	// ReserveFraction is the fraction, between 0 and 1, of each volume size kept free when no
+	// reserve policy has been set for DstSC, replacing RootDiskReserve. nil keeps RootDiskReserve
+	// while zero keeps no space free, see FractionReserve. only used by the disk space validator.
+	ReserveFraction *float64
+	// NodeReserves override, for specific nodes, the reserve policy of the destination storage
+	// class. an override for the node name takes precedence over the ones using label selectors.
+	// only used by the disk space validator.
+	NodeReserves NodeReserveOverrides
+	// CheckStorageClasses makes the disk space validator verify, when created, that both SrcSC and
+	// DstSC exist so a typo is reported upfront instead of deep inside the evaluation. the storage
+	// classes are listed within the ListNodesTimeout.
+	CheckStorageClasses bool
+	// AccountPendingPVCs makes the validator subtract the storage requested by pending pvcs in the
+	// destination storage class from the free space before evaluating it.
+	AccountPendingPVCs bool
+	// DetectThinPools makes the df job also report the LVM thin pools in each node. this requires
+	// a privileged container and an image with the nsenter command.
+	DetectThinPools bool
+	// DetectFSCorruption makes the df job also inspect the node mount table and kernel log looking
+	// for signs of a failing disk: the base path mount remounted read only or i/o errors. this
+	// requires a privileged container and an image with the nsenter command.
+	DetectFSCorruption bool
+	// DetectRuntimeDevice makes the df job also compare the device of the base path mount with the
+	// device of the container runtime (containerd or docker) data root, as read from the node
+	// mountinfo. nodes where they match are reported through OpenEBSVolume.Runtime. this requires
+	// a privileged container and an image with the nsenter command.
+	DetectRuntimeDevice bool
+	// APIFailureThreshold is the number of consecutive failed API server requests after which the
+	// validator aborts. zero disables the circuit breaker. only used by the disk space validator,
+	// the getter uses the client it has been given.
+	APIFailureThreshold int
+	// NodeExporter, if not nil, makes the getter read the free space from the node-exporter pods
+	// metrics instead of running df jobs. nodes without a node-exporter pod, or whose metrics can't
+	// be read, are still measured with df jobs.
+	NodeExporter *NodeExporterSource
+	// NodeSelector restricts the nodes measured to the ones matching it, nodes not matching are
+	// skipped (see SkippedNodes) and no job is scheduled on them. nil means all nodes.
+	NodeSelector labels.Selector
+	// WindowsImage is used to measure the free space in windows nodes, it must contain powershell.
+	// windows nodes are skipped when it is empty.
+	WindowsImage string
+	// WindowsDrive is the drive measured in windows nodes. defaults to "C".
+	WindowsDrive string
+	// Cache, if not nil, is used to reuse recent node measurements.
+	Cache *OpenEBSVolumeCache
+	// Tracer, if not nil, is used by the disk space validator to record a span for each run and a
+	// child span for each evaluated node.
+	Tracer trace.Tracer
+	// Recorder, if not nil, receives kubernetes events, in Namespace, as each node starts and
+	// finishes being measured (along with its free bytes) and, for the disk space validator, once
+	// the verdict is reached. see NewEventRecorder.
+	Recorder record.EventRecorder
+	// Metrics, if not nil, is the prometheus registry (e.g. a *prometheus.Registry) where the space
+	// check metrics are registered: the time spent measuring each node, the nodes that failed to be
+	// measured, the free bytes of each node and, for the disk space validator, the runs outcome.
+	// metrics are not collected when it is nil.
+	Metrics prometheus.Registerer
+	// PurgeOrphans makes each run start by deleting the jobs, pods and temporary pvcs left behind,
+	// in Namespace, by previous runs that were interrupted before cleaning up. as resources of
+	// other runs still in progress are deleted as well it must not be set when runs are concurrent.
+	PurgeOrphans bool
+	// CleanupClient, if not nil, is used instead of the measuring client to delete the temporary
+	// pvcs and pvs. callers protecting their client with a circuit breaker provide an unprotected
+	// one here so the temporary resources are still removed after the breaker trips.
+	CleanupClient kubernetes.Interface
+}
+
+// OpenEBSJobOptions holds the knobs of the df jobs (or pods) run in every node, see
+// OpenEBSOptions.Job.
+type OpenEBSJobOptions struct {
+	// Timeout is how long we wait for the df job on each node, it is also set as the active
+	// deadline of the jobs and pods so kubernetes stops them at the same time. defaults to 5 minutes.
+	Timeout time.Duration
+	// Retries is how many times a job whose pod could not be scheduled before the Timeout is
+	// retried, waiting longer before every retry. jobs that ran and failed are not retried. nil
+	// defaults to 3, zero disables the retries and negative values are rejected.
+	Retries *int
+	// RunAsPod makes the df workload run as a bare pod instead of a job.
+	RunAsPod bool
+	// Tolerations are added to the df job pods.
+	Tolerations []corev1.Toleration
+	// NoControlPlaneTolerations stops the df job pods from tolerating the control plane taints. by
+	// default they are tolerated so control plane nodes are measured as well, the pods are still
+	// pinned to their node through node affinity.
+	NoControlPlaneTolerations bool
+	// ImagePullSecrets are the names of the secrets, in OpenEBSOptions.Namespace, used to pull
+	// OpenEBSOptions.Image.
+	ImagePullSecrets []string
+	// Labels are added to the df jobs and their pods, e.g. to comply with policies requiring
+	// cost allocation labels. the "app" label identifying the jobs can't be overridden.
+	Labels map[string]string
+	// Annotations are added to the df jobs and their pods.
+	Annotations map[string]string
+	// FollowLogs, if not nil, receives the logs of the job containers as they are produced, for
+	// interactive debugging. the logs are still parsed once each job finishes.
+	FollowLogs io.Writer
+}
+
+// OpenEBSPVCOptions holds the knobs of the temporary pvcs created, one per node, to learn where the
+// base path lives, see OpenEBSOptions.PVC.
+type OpenEBSPVCOptions struct {
+	// Size is the storage requested by the temporary pvcs, for provisioners whose minimum
+	// volume size is bigger than the default. defaults to 1Mi.
+	Size resource.Quantity
+	// Reuse makes the temporary pvcs to be named after their nodes only, without a random
+	// suffix. a pvc left behind by a previous run is adopted if its spec matches the expected one.
+	Reuse bool
+	// SkipPVWait makes the temporary pvcs to be deleted without waiting for their pvs to be
+	// removed, leaving the pv reclamation to the provisioner.
+	SkipPVWait bool
+	// DeletePVTimeout is how long we wait for the temporary pvs to be removed by the provisioner
+	// after their pvcs have been deleted, an error is returned if some pv is still around once it
+	// expires. it has no effect when SkipPVWait is set. defaults to 5 minutes, negative values are
+	// rejected.
+	DeletePVTimeout time.Duration
+	// MaxInflight is the maximum number of temporary pvcs in flight at the same time, regardless
+	// of OpenEBSOptions.Parallelism, for provisioners that can't cope with many concurrent
+	// provisionings. a pvc is in flight until the measurement of its node finishes. zero means no
+	// limit.
+	MaxInflight int
+}
+
+// OpenEBSOutputOptions holds the knobs deciding how the free space is measured in the df jobs and
+// how their output is parsed, see OpenEBSOptions.Output.
+type OpenEBSOutputOptions struct {
+	// StrictParse makes the df output parser fail on any deviation from the expected format.
+	StrictParse bool
+	// DFMarker is printed by the df container on its own line before the df output, only the
+	// output following it is parsed. it may only contain letters, digits, '_', '.', ':' and '-'.
+	// defaults to DefaultDFMarker.
+	DFMarker string
+	// DFMountPath is where the base path is mounted inside the df container, the df output is
+	// parsed looking for it. it must be a clean absolute path made of letters, digits, '_', '.' and
+	// '-' not colliding with the other volumes mounted in the container. defaults to
+	// DefaultDFMountPath.
+	DFMountPath string
+	// StatfsBinary is the path, inside OpenEBSOptions.Image, of the statfs helper
+	// (kurl_util/cmd/statfs). when set the free space is measured with it instead of df, avoiding
+	// any df output format variability.
+	StatfsBinary string
+	// ResolvePath, if not empty, is an absolute node path whose backing filesystem is measured
+	// instead of the base path one. symlinks are resolved in the node, e.g. for a component data
+	// directory symlinked elsewhere, and the resolved path is reported. this requires an image
+	// whose shell can chroot into the node root filesystem, strict parsing does not apply.
+	ResolvePath string
+	// AllowedFSTypes, if not empty, are the only filesystem types the base path may live in. the
+	// evaluation fails for any node whose base path filesystem type is not in the list.
+	AllowedFSTypes []string
+	// PseudoFilesystems are the filesystem types ignored when parsing the node fstab. if nil a
+	// default list (proc, sysfs, tmpfs, devtmpfs, cgroup, etc) is used, an empty list disables
+	// the filtering.
+	PseudoFilesystems []string
+	// MountMatch is the strategy used to locate the base path in the df output. defaults to
+	// MountMatchExact.
+	MountMatch MountMatchStrategy
+	// MountSource, if not empty, is the df filesystem source (e.g. /dev/sda1) to be measured when
+	// several df lines match the base path. it takes precedence over the MountMatch strategy, which
+	// is still used when no matching line has this source.
+	MountSource string
+}
+
+// withDefaults returns a copy of the options with the default values set for all the unset
+// optional fields.
+func (o OpenEBSOptions) withDefaults() OpenEBSOptions {
+	if o.Namespace == "" {
+		o.Namespace = defaultOpenEBSNamespace
+	}
+	if o.Job.Timeout == 0 {
+		o.Job.Timeout = defaultOpenEBSJobTimeout
+	}
+	if o.Job.Retries == nil {
+		o.Job.Retries = ptr.To(defaultOpenEBSJobRetries)
+	}
+	if o.PVC.DeletePVTimeout == 0 {
+		o.PVC.DeletePVTimeout = defaultOpenEBSDeletePVTimeout
+	}
+	if o.ListNodesTimeout == 0 {
+		o.ListNodesTimeout = defaultOpenEBSListNodesTimeout
+	}
+	if o.StorageClassTimeout == 0 {
+		o.StorageClassTimeout = defaultOpenEBSStorageClassTimeout
+	}
+	if o.Parallelism < 1 {
+		o.Parallelism = 1
+	}
+	if o.PVC.Size.IsZero() {
+		o.PVC.Size = defaultOpenEBSTmpPVCSize.DeepCopy()
+	}
+	if o.SpaceWorkers < 1 {
+		o.SpaceWorkers = defaultOpenEBSSpaceWorkers
+	}
+	if o.WindowsDrive == "" {
+		o.WindowsDrive = defaultWindowsDrive
+	}
+	if o.Output.DFMarker == "" {
+		o.Output.DFMarker = DefaultDFMarker
+	}
+	if o.Output.DFMountPath == "" {
+		o.Output.DFMountPath = DefaultDFMountPath
+	}
+	if o.Output.MountMatch == "" {
+		o.Output.MountMatch = MountMatchExact
+	}
+	return o
+}
+
+// validate returns an error if the options, with their defaults already set (see withDefaults),
+// can't be used to build a free disk space getter. the destination storage class is not verified
+// as the node jobs do not require it.
+func (o OpenEBSOptions) validate() error {
+	if o.Image == "" {
+		return fmt.Errorf("empty image")
+	}
+	if o.Log == nil {
+		return fmt.Errorf("no logger provided")
+	}
+	switch o.Output.MountMatch {
+	case MountMatchExact, MountMatchLongestPrefix, MountMatchInnermost, MountMatchBlockDevice:
+	default:
+		return fmt.Errorf("invalid mount match strategy %q", o.Output.MountMatch)
+	}
+	if o.Output.ResolvePath != "" && !strings.HasPrefix(o.Output.ResolvePath, "/") {
+		return fmt.Errorf("resolve path %q is not absolute", o.Output.ResolvePath)
+	}
+	if o.Output.ResolvePath != "" && o.Output.StatfsBinary != "" {
+		return fmt.Errorf("a resolve path can't be measured with the statfs helper")
+	}
+	if !isValidDFMarker(o.Output.DFMarker) {
+		return fmt.Errorf("invalid df marker %q", o.Output.DFMarker)
+	}
+	if !isValidDFMountPath(o.Output.DFMountPath) {
+		return fmt.Errorf("invalid df mount path %q", o.Output.DFMountPath)
+	}
+	if !isValidWindowsDrive(o.WindowsDrive) {
+		return fmt.Errorf("invalid windows drive %q", o.WindowsDrive)
+	}
+	if o.PVC.Size.Sign() <= 0 {
+		return fmt.Errorf("invalid temporary pvc size %s", o.PVC.Size.String())
+	}
+	if o.StorageClassTimeout < 0 {
+		return fmt.Errorf("invalid storage class timeout %s", o.StorageClassTimeout)
+	}
+	if o.PVC.DeletePVTimeout < 0 {
+		return fmt.Errorf("invalid delete pv timeout %s", o.PVC.DeletePVTimeout)
+	}
+	if o.Job.Retries != nil && *o.Job.Retries < 0 {
+		return fmt.Errorf("invalid job retries %d", *o.Job.Retries)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package clusterspace
+
+import (
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+)
+
+func TestOpenEBSOptionsValidate(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	for _, tt := range []struct {
+		name string
+		opts OpenEBSOptions
+		err  string
+	}{
+		{
+			name: "should accept the defaults",
+			opts: OpenEBSOptions{Log: logger, Image: "image"},
+		},
+		{
+			name: "should fail without image",
+			opts: OpenEBSOptions{Log: logger},
+			err:  "empty image",
+		},
+		{
+			name: "should fail without logger",
+			opts: OpenEBSOptions{Image: "image"},
+			err:  "no logger provided",
+		},
+		{
+			name: "should fail with an unknown mount match strategy",
+			opts: OpenEBSOptions{Log: logger, Image: "image", Output: OpenEBSOutputOptions{MountMatch: "closest"}},
+			err:  `invalid mount match strategy "closest"`,
+		},
+		{
+			name: "should fail with a relative resolve path",
+			opts: OpenEBSOptions{Log: logger, Image: "image", Output: OpenEBSOutputOptions{ResolvePath: "var/openebs"}},
+			err:  `resolve path "var/openebs" is not absolute`,
+		},
+		{
+			name: "should fail with a negative temporary pvc size",
+			opts: OpenEBSOptions{Log: logger, Image: "image", PVC: OpenEBSPVCOptions{Size: resource.MustParse("-1Gi")}},
+			err:  "invalid temporary pvc size -1Gi",
+		},
+		{
+			name: "should fail with a negative delete pv timeout",
+			opts: OpenEBSOptions{Log: logger, Image: "image", PVC: OpenEBSPVCOptions{DeletePVTimeout: -time.Second}},
+			err:  "invalid delete pv timeout -1s",
+		},
+		{
+			name: "should fail with negative job retries",
+			opts: OpenEBSOptions{Log: logger, Image: "image", Job: OpenEBSJobOptions{Retries: ptr.To(-1)}},
+			err:  "invalid job retries -1",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.withDefaults().validate()
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("expected error %q, received %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package clusterspace
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/record"
+)
+
+// OpenEBSOption sets an optional knob of the disk space validator returned by
+// NewOpenEBSDiskSpaceValidator. every option sets a single OpenEBSOptions field, options are applied
+// in order so the last one setting a knob wins. the mandatory arguments of the constructor are set
+// after the options, callers holding a whole OpenEBSOptions should use
+// NewOpenEBSDiskSpaceValidatorWithOptions instead.
+type OpenEBSOption func(*OpenEBSOptions)
+
+// WithDeletePVTimeout sets how long the validator waits for the temporary pvs to disappear after
+// their pvcs have been deleted, see OpenEBSPVCOptions.DeletePVTimeout.
+func WithDeletePVTimeout(timeout time.Duration) OpenEBSOption {
+	return func(o *OpenEBSOptions) {
+		o.PVC.DeletePVTimeout = timeout
+	}
+}
+
+// WithJobTimeout sets how long the validator waits for the df job on each node, and the active
+// deadline of the job, see OpenEBSJobOptions.Timeout.
+func WithJobTimeout(timeout time.Duration) OpenEBSOption {
+	return func(o *OpenEBSOptions) {
+		o.Job.Timeout = timeout
+	}
+}
+
+// WithConcurrency sets how many nodes are evaluated at the same time, see
+// OpenEBSOptions.Parallelism.
+func WithConcurrency(nodes int) OpenEBSOption {
+	return func(o *OpenEBSOptions) {
+		o.Parallelism = nodes
+	}
+}
+
+// WithTmpPVCSize sets the storage requested by the temporary pvcs, see OpenEBSPVCOptions.Size.
+func WithTmpPVCSize(size resource.Quantity) OpenEBSOption {
+	return func(o *OpenEBSOptions) {
+		o.PVC.Size = size
+	}
+}
+
+// WithNodeSelector restricts the evaluated nodes to the ones matching the selector, see
+// OpenEBSOptions.NodeSelector.
+func WithNodeSelector(selector labels.Selector) OpenEBSOption {
+	return func(o *OpenEBSOptions) {
+		o.NodeSelector = selector
+	}
+}
+
+// WithEventRecorder makes the validator record kubernetes events describing the progress of the
+// space check, see OpenEBSOptions.Recorder.
+func WithEventRecorder(recorder record.EventRecorder) OpenEBSOption {
+	return func(o *OpenEBSOptions) {
+		o.Recorder = recorder
+	}
+}
+
+// WithMetrics registers the space check metrics in the provided prometheus registry and updates
+// them as nodes are checked, see OpenEBSOptions.Metrics.
+func WithMetrics(reg prometheus.Registerer) OpenEBSOption {
+	return func(o *OpenEBSOptions) {
+		o.Metrics = reg
+	}
+}
+
+// WithOrphanPurge makes the validator delete, before measuring the nodes, the jobs, pods and
+// temporary pvcs left behind by interrupted runs, see OpenEBSOptions.PurgeOrphans.
+func WithOrphanPurge() OpenEBSOption {
+	return func(o *OpenEBSOptions) {
+		o.PurgeOrphans = true
+	}
+}
+
+// WithReserveFraction sets the fraction, between 0 and 1, of each volume size kept free when no
+// reserve policy applies, see OpenEBSOptions.ReserveFraction.
+func WithReserveFraction(fraction float64) OpenEBSOption {
+	return func(o *OpenEBSOptions) {
+		o.ReserveFraction = &fraction
+	}
+}
+
+// WithStorageClassCheck makes the validator verify, when created, that the source and destination
+// storage classes exist, see OpenEBSOptions.CheckStorageClasses.
+func WithStorageClassCheck() OpenEBSOption {
+	return func(o *OpenEBSOptions) {
+		o.CheckStorageClasses = true
+	}
+}
@@ -0,0 +1,121 @@
+package clusterspace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// cachedOpenEBSVolume is an OpenEBS volume measurement as stored in the cache file.
+type cachedOpenEBSVolume struct {
+	Volume     OpenEBSVolume `json:"volume"`
+	MeasuredAt time.Time     `json:"measuredAt"`
+}
+
+// OpenEBSVolumeCache keeps OpenEBS volume measurements on disk for a short period of time so
+// checks executed in quick succession don't need to dispatch node jobs again. entries are
+// indexed by node name and measurement key: the openebs base path along with the cluster id and the
+// settings that change what is measured in it, see OpenEBSFreeDiskSpaceGetter.cacheKey.
+type OpenEBSVolumeCache struct {
+	mtx  sync.Mutex
+	path string
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+// key returns the cache key for the provided node and measurement key.
+func (c *OpenEBSVolumeCache) key(node, measurement string) string {
+	return fmt.Sprintf("%s:%s", node, measurement)
+}
+
+// read returns all entries currently stored in the cache file.
+func (c *OpenEBSVolumeCache) read() (map[string]cachedOpenEBSVolume, error) {
+	entries := map[string]cachedOpenEBSVolume{}
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return entries, nil
+		}
+		return nil, fmt.Errorf("failed to read cache file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse cache file: %w", err)
+	}
+	return entries, nil
+}
+
+// Get returns the cached volume for the provided node and measurement key. returns false if there
+// is no entry or if the entry has expired.
+func (c *OpenEBSVolumeCache) Get(node, measurement string) (OpenEBSVolume, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	entries, err := c.read()
+	if err != nil {
+		return OpenEBSVolume{}, false
+	}
+
+	entry, ok := entries[c.key(node, measurement)]
+	if !ok || c.now().Sub(entry.MeasuredAt) > c.ttl {
+		return OpenEBSVolume{}, false
+	}
+	return entry.Volume, true
+}
+
+// Set stores the provided volume in the cache. expired entries are purged from the cache file.
+func (c *OpenEBSVolumeCache) Set(node, measurement string, vol OpenEBSVolume) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	entries, err := c.read()
+	if err != nil {
+		entries = map[string]cachedOpenEBSVolume{}
+	}
+
+	now := c.now()
+	for key, entry := range entries {
+		if now.Sub(entry.MeasuredAt) > c.ttl {
+			delete(entries, key)
+		}
+	}
+	entries[c.key(node, measurement)] = cachedOpenEBSVolume{
+		Volume:     vol,
+		MeasuredAt: now,
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entries: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	if err := os.WriteFile(c.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	return nil
+}
+
+// NewOpenEBSVolumeCache returns a cache for OpenEBS volume measurements stored in the provided
+// file path. entries older than ttl are ignored.
+func NewOpenEBSVolumeCache(path string, ttl time.Duration) (*OpenEBSVolumeCache, error) {
+	if path == "" {
+		return nil, fmt.Errorf("empty cache path")
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("cache ttl must be positive")
+	}
+
+	return &OpenEBSVolumeCache{
+		path: path,
+		ttl:  ttl,
+		now:  time.Now,
+	}, nil
+}
@@ -0,0 +1,202 @@
+package clusterspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// testClusterID is the uid of the namespace returned by kubeSystemNamespace, measurements cached
+// by the tests must be stored under testCacheKey to be used.
+const (
+	testClusterID = "5f0a4c4e-3c0e-4cbb-9a57-8d6f4c1b0b6e"
+	testCacheKey  = "/var/local;cluster=" + testClusterID
+)
+
+// kubeSystemNamespace returns the namespace identifying the cluster of the cached measurements.
+func kubeSystemNamespace() *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: metav1.NamespaceSystem, UID: types.UID(testClusterID)},
+	}
+}
+
+func TestOpenEBSVolumeCache(t *testing.T) {
+	now := time.Now()
+	cache, err := NewOpenEBSVolumeCache(filepath.Join(t.TempDir(), "cache", "volumes.json"), time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error creating cache: %s", err)
+	}
+	cache.now = func() time.Time { return now }
+
+	// cache miss when nothing has been stored yet
+	if _, ok := cache.Get("node0", "/var/local"); ok {
+		t.Errorf("expected cache miss on empty cache")
+	}
+
+	vol := OpenEBSVolume{Free: 100, Used: 50, RootVolume: true}
+	if err := cache.Set("node0", "/var/local", vol); err != nil {
+		t.Fatalf("unexpected error storing in cache: %s", err)
+	}
+
+	// cache hit for the same node and base path
+	cached, ok := cache.Get("node0", "/var/local")
+	if !ok {
+		t.Errorf("expected cache hit")
+	}
+	if diff := cmp.Diff(vol, cached); diff != "" {
+		t.Errorf("unexpected cached volume: %s", diff)
+	}
+
+	// cache miss for a different node or base path
+	if _, ok := cache.Get("node1", "/var/local"); ok {
+		t.Errorf("expected cache miss for different node")
+	}
+	if _, ok := cache.Get("node0", "/var/openebs"); ok {
+		t.Errorf("expected cache miss for different base path")
+	}
+
+	// cache hit right before expiring
+	now = now.Add(time.Minute)
+	if _, ok := cache.Get("node0", "/var/local"); !ok {
+		t.Errorf("expected cache hit before expiration")
+	}
+
+	// cache miss after expiring
+	now = now.Add(time.Second)
+	if _, ok := cache.Get("node0", "/var/local"); ok {
+		t.Errorf("expected cache miss after expiration")
+	}
+
+	// expired entries are purged when a new one is stored
+	if err := cache.Set("node1", "/var/local", vol); err != nil {
+		t.Fatalf("unexpected error storing in cache: %s", err)
+	}
+	entries, err := cache.read()
+	if err != nil {
+		t.Fatalf("unexpected error reading cache: %s", err)
+	}
+	if _, ok := entries[cache.key("node0", "/var/local")]; ok {
+		t.Errorf("expected expired entry to be purged")
+	}
+}
+
+func TestOpenEBSVolumeCacheInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "volumes.json")
+	if err := os.WriteFile(path, []byte("...---...<<>>"), 0600); err != nil {
+		t.Fatalf("unexpected error writing file: %s", err)
+	}
+
+	cache, err := NewOpenEBSVolumeCache(path, time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error creating cache: %s", err)
+	}
+
+	if _, ok := cache.Get("node0", "/var/local"); ok {
+		t.Errorf("expected cache miss with invalid cache file")
+	}
+
+	if err := cache.Set("node0", "/var/local", OpenEBSVolume{Free: 1}); err != nil {
+		t.Errorf("expected invalid cache file to be overwritten: %s", err)
+	}
+
+	if _, ok := cache.Get("node0", "/var/local"); !ok {
+		t.Errorf("expected cache hit after overwriting invalid file")
+	}
+}
+
+func TestNewOpenEBSVolumeCache(t *testing.T) {
+	if _, err := NewOpenEBSVolumeCache("", time.Minute); err == nil || err.Error() != "empty cache path" {
+		t.Errorf("expected failure creating object: %v", err)
+	}
+
+	if _, err := NewOpenEBSVolumeCache("/tmp/cache.json", 0); err == nil || err.Error() != "cache ttl must be positive" {
+		t.Errorf("expected failure creating object: %v", err)
+	}
+}
+
+func Test_cacheKey(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		getter   *OpenEBSFreeDiskSpaceGetter
+		expected string
+	}{
+		{
+			name:     "should use the base path with the default settings",
+			getter:   &OpenEBSFreeDiskSpaceGetter{},
+			expected: "/var/local",
+		},
+		{
+			name:     "should ignore settings set to their default values",
+			getter:   &OpenEBSFreeDiskSpaceGetter{opts: OpenEBSOptions{Output: OpenEBSOutputOptions{MountMatch: MountMatchExact}, WindowsDrive: defaultWindowsDrive}},
+			expected: "/var/local",
+		},
+		{
+			name:     "should include the cluster id",
+			getter:   &OpenEBSFreeDiskSpaceGetter{clusterID: testClusterID},
+			expected: testCacheKey,
+		},
+		{
+			name:     "should include the resolve path",
+			getter:   &OpenEBSFreeDiskSpaceGetter{opts: OpenEBSOptions{Output: OpenEBSOutputOptions{ResolvePath: "/var/lib/kotsadm"}}},
+			expected: "/var/local;resolve=/var/lib/kotsadm",
+		},
+		{
+			name: "should include all the settings changing the measurement",
+			getter: &OpenEBSFreeDiskSpaceGetter{
+				opts: OpenEBSOptions{
+					Output: OpenEBSOutputOptions{
+						MountMatch:   MountMatchInnermost,
+						MountSource:  "/dev/sdb1",
+						StatfsBinary: "/usr/local/bin/statfs",
+					},
+					WindowsDrive: "D",
+				},
+			},
+			expected: "/var/local;match=innermost;source=/dev/sdb1;statfs=/usr/local/bin/statfs;drive=D",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if key := tt.getter.cacheKey("/var/local"); key != tt.expected {
+				t.Errorf("expected key %q, %q received instead", tt.expected, key)
+			}
+		})
+	}
+}
+
+func Test_cacheVolumeSettingsIsolation(t *testing.T) {
+	cache, err := NewOpenEBSVolumeCache(filepath.Join(t.TempDir(), "volumes.json"), time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error creating cache: %s", err)
+	}
+
+	plain := &OpenEBSFreeDiskSpaceGetter{opts: OpenEBSOptions{Cache: cache}, clusterID: testClusterID}
+	resolved := &OpenEBSFreeDiskSpaceGetter{opts: OpenEBSOptions{Cache: cache, Output: OpenEBSOutputOptions{ResolvePath: "/var/lib/kotsadm"}}, clusterID: testClusterID}
+	statfs := &OpenEBSFreeDiskSpaceGetter{opts: OpenEBSOptions{Cache: cache, Output: OpenEBSOutputOptions{StatfsBinary: "/usr/local/bin/statfs"}}, clusterID: testClusterID}
+
+	plain.cacheVolume("node0", "/var/local", OpenEBSVolume{Free: 100})
+	resolved.cacheVolume("node0", "/var/local", OpenEBSVolume{Free: 5})
+
+	for name, getter := range map[string]*OpenEBSFreeDiskSpaceGetter{"plain": plain, "resolved": resolved, "statfs": statfs} {
+		vol, ok := cache.Get("node0", getter.cacheKey("/var/local"))
+		switch name {
+		case "plain":
+			if !ok || vol.Free != 100 {
+				t.Errorf("expected the plain measurement to be kept, %+v received", vol)
+			}
+		case "resolved":
+			if !ok || vol.Free != 5 {
+				t.Errorf("expected the resolved path measurement, %+v received", vol)
+			}
+		case "statfs":
+			if ok {
+				t.Errorf("expected cache miss with a different measurement setting, %+v received", vol)
+			}
+		}
+	}
+}
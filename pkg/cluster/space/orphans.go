@@ -0,0 +1,152 @@
+package clusterspace
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// orphanNamePrefix is the prefix of the names of all jobs, pods and temporary pvcs created by the
+// getter, see jobName and buildTmpPVC. resources created before the component label was introduced
+// are only identifiable through it.
+const orphanNamePrefix = "disk-free-"
+
+// defaultOpenEBSPurgeOrphansTimeout is how long we wait for the orphaned jobs and pods to disappear
+// once deleted.
+const defaultOpenEBSPurgeOrphansTimeout = 2 * time.Minute
+
+// purgeOrphansPollInterval is how often we check if the deleted orphaned jobs and pods are gone.
+var purgeOrphansPollInterval = time.Second
+
+// isOrphan returns true if the provided object, found in the getter namespace, has been created by
+// another getter run: it carries a run id label different from ours and either our component label
+// or, for resources created by older versions, a name with our prefix.
+func (o *OpenEBSFreeDiskSpaceGetter) isOrphan(obj metav1.Object) bool {
+	labels := obj.GetLabels()
+	runID, ok := labels[OpenEBSRunIDLabel]
+	if !ok || runID == o.runID {
+		return false
+	}
+	if labels[OpenEBSComponentLabel] == OpenEBSComponent {
+		return true
+	}
+	return strings.HasPrefix(obj.GetName(), orphanNamePrefix)
+}
+
+// reapOrphans deletes the jobs, bare pods and temporary pvcs left in the getter namespace by
+// previous runs that were interrupted (e.g. killed or rebooted) before cleaning up after
+// themselves, see isOrphan. it waits until the jobs and pods are gone and, as for our own temporary
+// pvcs, until the pvs of the deleted pvcs are removed (see deleteTmpPVCs). every reaped resource is
+// logged. nothing is done unless the getter has been configured to purge orphans, as this would
+// also reap the resources of other runs still in progress.
+func (o *OpenEBSFreeDiskSpaceGetter) reapOrphans(ctx context.Context) error {
+	if !o.opts.PurgeOrphans {
+		return nil
+	}
+
+	propagation := metav1.DeletePropagationForeground
+	delopts := metav1.DeleteOptions{PropagationPolicy: &propagation}
+	appSelector := metav1.ListOptions{LabelSelector: fmt.Sprintf("app=%s", OpenEBSJobAppLabel)}
+
+	var waitFor []func() error
+	jobs, err := o.kcli.BatchV1().Jobs(o.opts.Namespace).List(ctx, appSelector)
+	if err != nil {
+		return fmt.Errorf("failed to list jobs: %w", err)
+	}
+	for _, job := range jobs.Items {
+		if !o.isOrphan(&job) {
+			continue
+		}
+		o.log.Printf("Deleting orphaned job %s/%s of run %s", job.Namespace, job.Name, job.Labels[OpenEBSRunIDLabel])
+		if err := o.kcli.BatchV1().Jobs(job.Namespace).Delete(ctx, job.Name, delopts); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete job %s/%s: %w", job.Namespace, job.Name, err)
+		}
+		name := job.Name
+		waitFor = append(waitFor, func() error {
+			_, err := o.kcli.BatchV1().Jobs(o.opts.Namespace).Get(ctx, name, metav1.GetOptions{})
+			return err
+		})
+	}
+
+	// pods created by the jobs are removed along with them, only the bare pods are deleted here.
+	pods, err := o.kcli.CoreV1().Pods(o.opts.Namespace).List(ctx, appSelector)
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	for _, pod := range pods.Items {
+		if !o.isOrphan(&pod) || metav1.GetControllerOf(&pod) != nil {
+			continue
+		}
+		o.log.Printf("Deleting orphaned pod %s/%s of run %s", pod.Namespace, pod.Name, pod.Labels[OpenEBSRunIDLabel])
+		if err := o.kcli.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, delopts); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete pod %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+		name := pod.Name
+		waitFor = append(waitFor, func() error {
+			_, err := o.kcli.CoreV1().Pods(o.opts.Namespace).Get(ctx, name, metav1.GetOptions{})
+			return err
+		})
+	}
+
+	if err := o.waitOrphansDeleted(ctx, waitFor); err != nil {
+		return err
+	}
+
+	pvcs, err := o.kcli.CoreV1().PersistentVolumeClaims(o.opts.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: OpenEBSRunIDLabel,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list persistent volume claims: %w", err)
+	}
+	var orphans []*corev1.PersistentVolumeClaim
+	for i := range pvcs.Items {
+		pvc := &pvcs.Items[i]
+		if !o.isOrphan(pvc) {
+			continue
+		}
+		o.log.Printf("Deleting orphaned pvc %s/%s of run %s", pvc.Namespace, pvc.Name, pvc.Labels[OpenEBSRunIDLabel])
+		orphans = append(orphans, pvc)
+	}
+	if len(orphans) == 0 {
+		return nil
+	}
+	if err := o.deleteTmpPVCs(orphans); err != nil {
+		return fmt.Errorf("failed to delete orphaned pvcs: %w", err)
+	}
+	return nil
+}
+
+// waitOrphansDeleted waits until all the provided getters report the object they read is not found,
+// up to defaultOpenEBSPurgeOrphansTimeout.
+func (o *OpenEBSFreeDiskSpaceGetter) waitOrphansDeleted(ctx context.Context, getters []func() error) error {
+	if len(getters) == 0 {
+		return nil
+	}
+
+	timeout := time.NewTimer(defaultOpenEBSPurgeOrphansTimeout)
+	defer timeout.Stop()
+	for _, get := range getters {
+		for {
+			err := get()
+			if errors.IsNotFound(err) {
+				break
+			} else if err != nil {
+				o.log.Printf("Failed to check orphaned resource deletion: %s", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("failed to wait for orphaned resources deletion: %w", ctx.Err())
+			case <-timeout.C:
+				return fmt.Errorf("timed out waiting for orphaned resources deletion")
+			case <-time.After(purgeOrphansPollInterval):
+			}
+		}
+	}
+	return nil
+}
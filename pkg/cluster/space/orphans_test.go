@@ -0,0 +1,150 @@
+package clusterspace
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_reapOrphans(t *testing.T) {
+	meta := func(name, runID string) metav1.ObjectMeta {
+		labels := map[string]string{"app": OpenEBSJobAppLabel}
+		if runID != "" {
+			labels[OpenEBSRunIDLabel] = runID
+		}
+		return metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels}
+	}
+
+	labeled := func(name, runID string) metav1.ObjectMeta {
+		objmeta := meta(name, runID)
+		objmeta.Labels[OpenEBSComponentLabel] = OpenEBSComponent
+		return objmeta
+	}
+
+	controller := true
+	owned := meta("disk-free-node2-abcde-xyz", "old")
+	owned.OwnerReferences = []metav1.OwnerReference{
+		{APIVersion: "batch/v1", Kind: "Job", Name: "disk-free-node2-abcde", Controller: &controller},
+	}
+
+	objects := []runtime.Object{
+		&batchv1.Job{ObjectMeta: meta("disk-free-node0-abcde", "old")},
+		&batchv1.Job{ObjectMeta: meta("disk-free-node1-fghij", "current")},
+		&batchv1.Job{ObjectMeta: meta("another-job", "old")},
+		&batchv1.Job{ObjectMeta: labeled("renamed-job", "old")},
+		&batchv1.Job{ObjectMeta: meta("disk-free-node3-klmno", "")},
+		&corev1.Pod{ObjectMeta: meta("disk-free-node0-pqrst", "old")},
+		&corev1.Pod{ObjectMeta: owned},
+		&corev1.PersistentVolumeClaim{ObjectMeta: meta("disk-free-node0-uvwxy", "old")},
+		&corev1.PersistentVolumeClaim{ObjectMeta: meta("disk-free-node1-zabcd", "current")},
+		&corev1.PersistentVolumeClaim{ObjectMeta: meta("disk-free-node2", "")},
+	}
+
+	for _, tt := range []struct {
+		name  string
+		purge bool
+		jobs  []string
+		pods  []string
+		pvcs  []string
+		logs  []string
+	}{
+		{
+			name: "should not reap anything unless configured",
+			jobs: []string{"another-job", "disk-free-node0-abcde", "disk-free-node1-fghij", "disk-free-node3-klmno", "renamed-job"},
+			pods: []string{"disk-free-node0-pqrst", "disk-free-node2-abcde-xyz"},
+			pvcs: []string{"disk-free-node0-uvwxy", "disk-free-node1-zabcd", "disk-free-node2"},
+		},
+		{
+			name:  "should reap the resources of other runs",
+			purge: true,
+			jobs:  []string{"another-job", "disk-free-node1-fghij", "disk-free-node3-klmno"},
+			pods:  []string{"disk-free-node2-abcde-xyz"},
+			pvcs:  []string{"disk-free-node1-zabcd", "disk-free-node2"},
+			logs: []string{
+				"Deleting orphaned job default/disk-free-node0-abcde of run old",
+				"Deleting orphaned job default/renamed-job of run old",
+				"Deleting orphaned pod default/disk-free-node0-pqrst of run old",
+				"Deleting orphaned pvc default/disk-free-node0-uvwxy of run old",
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			purgeOrphansPollInterval = 10 * time.Millisecond
+			kcli := fake.NewSimpleClientset(objects...)
+			logs := &bytes.Buffer{}
+			getter := OpenEBSFreeDiskSpaceGetter{
+				kcli: kcli,
+				log:  log.New(logs, "", 0),
+				opts: OpenEBSOptions{
+					Namespace:    "default",
+					PurgeOrphans: tt.purge,
+					PVC: OpenEBSPVCOptions{
+						SkipPVWait: true,
+					},
+				},
+				runID: "current",
+			}
+
+			if err := getter.reapOrphans(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			ctx := context.Background()
+			jobs, err := kcli.BatchV1().Jobs("default").List(ctx, metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("failed to list jobs: %s", err)
+			}
+			var jobNames []string
+			for _, job := range jobs.Items {
+				jobNames = append(jobNames, job.Name)
+			}
+
+			pods, err := kcli.CoreV1().Pods("default").List(ctx, metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("failed to list pods: %s", err)
+			}
+			var podNames []string
+			for _, pod := range pods.Items {
+				podNames = append(podNames, pod.Name)
+			}
+
+			pvcs, err := kcli.CoreV1().PersistentVolumeClaims("default").List(ctx, metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("failed to list pvcs: %s", err)
+			}
+			var pvcNames []string
+			for _, pvc := range pvcs.Items {
+				pvcNames = append(pvcNames, pvc.Name)
+			}
+
+			sort.Strings(jobNames)
+			sort.Strings(podNames)
+			sort.Strings(pvcNames)
+			if diff := cmp.Diff(tt.jobs, jobNames); diff != "" {
+				t.Errorf("unexpected jobs: %s", diff)
+			}
+			if diff := cmp.Diff(tt.pods, podNames); diff != "" {
+				t.Errorf("unexpected pods: %s", diff)
+			}
+			if diff := cmp.Diff(tt.pvcs, pvcNames); diff != "" {
+				t.Errorf("unexpected pvcs: %s", diff)
+			}
+			for _, line := range tt.logs {
+				if !strings.Contains(logs.String(), line) {
+					t.Errorf("expected %q to be logged, logs: %s", line, logs.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package clusterspace
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// newCreateLimiter returns a limiter allowing the creation of perSecond jobs per second, without
+// bursts so creations are evenly spaced. returns nil, meaning no limit, if perSecond is not positive.
+func newCreateLimiter(perSecond float64) *rate.Limiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(perSecond), 1)
+}
+
+// waitCreate blocks until the configured creation rate allows a new job (or pod) to be created. it
+// is called before every job creation so the API server does not see bursts of creations when many
+// nodes are evaluated in parallel. returns immediately if no creation rate has been configured.
+func (o *OpenEBSFreeDiskSpaceGetter) waitCreate(ctx context.Context) error {
+	if o.limiter == nil {
+		return nil
+	}
+	if err := o.limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("failed to wait for job creation rate limiter: %w", err)
+	}
+	return nil
+}
+
+// newPVCSlots returns a semaphore allowing up to max temporary pvcs to be in flight at the same time.
+// returns nil, meaning no limit, if max is not positive.
+func newPVCSlots(max int) *semaphore.Weighted {
+	if max <= 0 {
+		return nil
+	}
+	return semaphore.NewWeighted(int64(max))
+}
+
+// acquirePVCSlot blocks until a temporary pvc can be created without exceeding the configured maximum
+// number of pvcs in flight. a pvc is in flight from its creation until the measurement of its node
+// finishes, by then the provisioner is done with it. the returned function releases the slot and
+// must be called once the node measurement is over. returns immediately if no maximum has been
+// configured.
+func (o *OpenEBSFreeDiskSpaceGetter) acquirePVCSlot(ctx context.Context) (func(), error) {
+	if o.pvcSlots == nil {
+		return func() {}, nil
+	}
+	if err := o.pvcSlots.Acquire(ctx, 1); err != nil {
+		return nil, fmt.Errorf("failed to wait for an in flight pvc slot: %w", err)
+	}
+	return func() { o.pvcSlots.Release(1) }, nil
+}
+
+// forEachNode calls fn for each of the provided nodes, running up to the configured parallelism
+// calls at the same time (one at a time if no parallelism has been configured). the context given
+// to fn is cancelled as soon as any call fails, no new calls are started after that. returns the
+// first error returned by fn once all the running calls have returned.
+func (o *OpenEBSFreeDiskSpaceGetter) forEachNode(ctx context.Context, nodes []corev1.Node, fn func(context.Context, corev1.Node) error) error {
+	return forEachNodeLimit(ctx, nodes, o.opts.Parallelism, fn)
+}
+
+// forEachNodeLimit is like forEachNode but running up to limit calls at the same time instead of
+// the configured parallelism.
+func forEachNodeLimit(ctx context.Context, nodes []corev1.Node, limit int, fn func(context.Context, corev1.Node) error) error {
+	if limit < 1 {
+		limit = 1
+	}
+
+	group, gctx := errgroup.WithContext(ctx)
+	group.SetLimit(limit)
+	for _, node := range nodes {
+		if gctx.Err() != nil {
+			break
+		}
+		group.Go(func() error {
+			return fn(gctx, node)
+		})
+	}
+	return group.Wait()
+}
@@ -0,0 +1,238 @@
+package clusterspace
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_waitCreate(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		rate       float64
+		creations  int
+		minElapsed time.Duration
+		maxElapsed time.Duration
+	}{
+		{
+			name:       "no rate should not delay creations",
+			creations:  10,
+			maxElapsed: 50 * time.Millisecond,
+		},
+		{
+			name:       "creations should be evenly paced",
+			rate:       20,
+			creations:  5,
+			minElapsed: 180 * time.Millisecond,
+			maxElapsed: 500 * time.Millisecond,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			getter := OpenEBSFreeDiskSpaceGetter{limiter: newCreateLimiter(tt.rate)}
+
+			var times []time.Time
+			start := time.Now()
+			for i := 0; i < tt.creations; i++ {
+				if err := getter.waitCreate(context.Background()); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				times = append(times, time.Now())
+			}
+
+			elapsed := time.Since(start)
+			if elapsed < tt.minElapsed || elapsed > tt.maxElapsed {
+				t.Errorf("expected creations to take between %s and %s, took %s", tt.minElapsed, tt.maxElapsed, elapsed)
+			}
+
+			if tt.rate == 0 {
+				return
+			}
+			interval := time.Duration(float64(time.Second) / tt.rate)
+			for i := 1; i < len(times); i++ {
+				if gap := times[i].Sub(times[i-1]); gap < interval*8/10 {
+					t.Errorf("creation %d happened %s after the previous one, expected at least %s", i, gap, interval)
+				}
+			}
+		})
+	}
+}
+
+func Test_waitCreateCancelled(t *testing.T) {
+	getter := OpenEBSFreeDiskSpaceGetter{limiter: newCreateLimiter(0.1)}
+	if err := getter.waitCreate(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := getter.waitCreate(ctx); err == nil {
+		t.Errorf("expected error waiting with a cancelled context")
+	}
+}
+
+func Test_forEachNode(t *testing.T) {
+	var nodes []corev1.Node
+	for i := 0; i < 6; i++ {
+		nodes = append(nodes, corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("node%d", i)}})
+	}
+
+	for _, tt := range []struct {
+		name        string
+		parallelism int
+		failOn      string
+		maxRunning  int
+		err         bool
+	}{
+		{
+			name:       "zero parallelism should evaluate nodes sequentially",
+			maxRunning: 1,
+		},
+		{
+			name:        "should respect the parallelism",
+			parallelism: 3,
+			maxRunning:  3,
+		},
+		{
+			name:        "should return the node failure",
+			parallelism: 2,
+			failOn:      "node1",
+			maxRunning:  2,
+			err:         true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			getter := OpenEBSFreeDiskSpaceGetter{opts: OpenEBSOptions{Parallelism: tt.parallelism}}
+
+			var mtx sync.Mutex
+			var running, maxRunning int
+			visited := map[string]bool{}
+			err := getter.forEachNode(context.Background(), nodes, func(ctx context.Context, node corev1.Node) error {
+				mtx.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				visited[node.Name] = true
+				mtx.Unlock()
+
+				time.Sleep(20 * time.Millisecond)
+
+				mtx.Lock()
+				running--
+				mtx.Unlock()
+				if node.Name == tt.failOn {
+					return fmt.Errorf("failed on %s", node.Name)
+				}
+				return nil
+			})
+
+			if tt.err != (err != nil) {
+				t.Fatalf("expected error %v, received %v", tt.err, err)
+			}
+			if maxRunning > tt.maxRunning {
+				t.Errorf("expected at most %d nodes evaluated at the same time, %d found", tt.maxRunning, maxRunning)
+			}
+			if !tt.err && len(visited) != len(nodes) {
+				t.Errorf("expected all %d nodes to be evaluated, %d evaluated", len(nodes), len(visited))
+			}
+			if tt.err && visited[nodes[len(nodes)-1].Name] {
+				t.Errorf("expected the evaluation to stop after the failure")
+			}
+		})
+	}
+}
+
+func Test_acquirePVCSlot(t *testing.T) {
+	var nodes []corev1.Node
+	for i := 0; i < 12; i++ {
+		nodes = append(nodes, corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("node%d", i)}})
+	}
+
+	for _, tt := range []struct {
+		name        string
+		parallelism int
+		maxInflight int
+		expected    int
+	}{
+		{
+			name:        "no limit should let the parallelism drive the pvcs in flight",
+			parallelism: 4,
+			expected:    4,
+		},
+		{
+			name:        "should respect the in flight cap below the parallelism",
+			parallelism: 6,
+			maxInflight: 2,
+			expected:    2,
+		},
+		{
+			name:        "a cap above the parallelism should have no effect",
+			parallelism: 3,
+			maxInflight: 5,
+			expected:    3,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			getter := OpenEBSFreeDiskSpaceGetter{
+				opts: OpenEBSOptions{
+					Parallelism: tt.parallelism,
+				},
+				pvcSlots: newPVCSlots(tt.maxInflight),
+			}
+
+			var mtx sync.Mutex
+			var inflight, maxInflight, measured int
+			err := getter.forEachNode(context.Background(), nodes, func(ctx context.Context, node corev1.Node) error {
+				release, err := getter.acquirePVCSlot(ctx)
+				if err != nil {
+					return err
+				}
+				defer release()
+
+				mtx.Lock()
+				inflight++
+				if inflight > maxInflight {
+					maxInflight = inflight
+				}
+				mtx.Unlock()
+
+				time.Sleep(20 * time.Millisecond)
+
+				mtx.Lock()
+				inflight--
+				measured++
+				mtx.Unlock()
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if measured != len(nodes) {
+				t.Errorf("expected %d nodes measured, %d measured", len(nodes), measured)
+			}
+			if maxInflight != tt.expected {
+				t.Errorf("expected at most %d pvcs in flight, %d seen", tt.expected, maxInflight)
+			}
+		})
+	}
+}
+
+func Test_acquirePVCSlotCancelled(t *testing.T) {
+	getter := OpenEBSFreeDiskSpaceGetter{pvcSlots: newPVCSlots(1)}
+	release, err := getter.acquirePVCSlot(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := getter.acquirePVCSlot(ctx); err == nil {
+		t.Errorf("expected error waiting for a slot while all of them are taken")
+	}
+}
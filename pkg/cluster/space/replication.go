@@ -0,0 +1,48 @@
+package clusterspace
+
+import (
+	"fmt"
+	"strconv"
+
+	storagev1 "k8s.io/api/storage/v1"
+)
+
+// LonghornProvisioner is the provisioner of the storage classes backed by longhorn.
+const LonghornProvisioner = "driver.longhorn.io"
+
+// defaultLonghornReplicas is the number of replicas longhorn keeps for each volume when the storage
+// class does not say otherwise.
+const defaultLonghornReplicas = 3
+
+// StorageClassReplicas returns how many copies of each volume the provided storage class keeps. for
+// longhorn it is read from the storage class numberOfReplicas parameter, all other provisioners known
+// to be measured through their storage class keep a single copy. rook replication is defined in the
+// ceph pool instead, see RookFreeDiskSpaceGetter.GetPhysicalFreeSpace.
+func StorageClassReplicas(sc storagev1.StorageClass) (int, error) {
+	if sc.Provisioner != LonghornProvisioner {
+		return 1, nil
+	}
+
+	value, ok := sc.Parameters["numberOfReplicas"]
+	if !ok {
+		return defaultLonghornReplicas, nil
+	}
+
+	replicas, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse storage class %s number of replicas %q: %w", sc.Name, value, err)
+	}
+	if replicas < 1 {
+		return 0, fmt.Errorf("invalid storage class %s number of replicas %d", sc.Name, replicas)
+	}
+	return replicas, nil
+}
+
+// ReplicatedRequirement returns the physical space consumed by requested logical bytes when every
+// byte is stored replicas times. replicas lower than one are considered one.
+func ReplicatedRequirement(requested int64, replicas int) int64 {
+	if replicas < 1 {
+		replicas = 1
+	}
+	return requested * int64(replicas)
+}
@@ -0,0 +1,85 @@
+package clusterspace
+
+import (
+	"testing"
+
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStorageClassReplicas(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		provisioner string
+		params      map[string]string
+		expected    int
+		err         bool
+	}{
+		{
+			name:        "openebs keeps a single copy",
+			provisioner: OpenEBSLocalProvisioner,
+			expected:    1,
+		},
+		{
+			name:        "longhorn with a single replica",
+			provisioner: LonghornProvisioner,
+			params:      map[string]string{"numberOfReplicas": "1"},
+			expected:    1,
+		},
+		{
+			name:        "longhorn with two replicas",
+			provisioner: LonghornProvisioner,
+			params:      map[string]string{"numberOfReplicas": "2"},
+			expected:    2,
+		},
+		{
+			name:        "longhorn defaults to three replicas",
+			provisioner: LonghornProvisioner,
+			expected:    3,
+		},
+		{
+			name:        "longhorn with an invalid number of replicas",
+			provisioner: LonghornProvisioner,
+			params:      map[string]string{"numberOfReplicas": "three"},
+			err:         true,
+		},
+		{
+			name:        "longhorn with zero replicas",
+			provisioner: LonghornProvisioner,
+			params:      map[string]string{"numberOfReplicas": "0"},
+			err:         true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := storagev1.StorageClass{
+				ObjectMeta:  metav1.ObjectMeta{Name: "sc"},
+				Provisioner: tt.provisioner,
+				Parameters:  tt.params,
+			}
+
+			replicas, err := StorageClassReplicas(sc)
+			if tt.err != (err != nil) {
+				t.Fatalf("expected error %v, received %v", tt.err, err)
+			}
+			if replicas != tt.expected {
+				t.Errorf("expected %d replicas, received %d", tt.expected, replicas)
+			}
+		})
+	}
+}
+
+func TestReplicatedRequirement(t *testing.T) {
+	for _, tt := range []struct {
+		replicas int
+		expected int64
+	}{
+		{replicas: 0, expected: 10},
+		{replicas: 1, expected: 10},
+		{replicas: 2, expected: 20},
+		{replicas: 3, expected: 30},
+	} {
+		if got := ReplicatedRequirement(10, tt.replicas); got != tt.expected {
+			t.Errorf("expected %d for %d replicas, received %d", tt.expected, tt.replicas, got)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package clusterspace
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ReserveCalculator returns the amount of bytes that must be kept free in the provided volume.
+type ReserveCalculator func(vol OpenEBSVolume) int64
+
+// AbsoluteReserve returns a calculator that always reserves the provided amount of bytes.
+func AbsoluteReserve(bytes int64) ReserveCalculator {
+	return func(OpenEBSVolume) int64 {
+		return bytes
+	}
+}
+
+// PercentReserve returns a calculator that reserves the provided percentage of the volume size.
+func PercentReserve(percent float64) ReserveCalculator {
+	return func(vol OpenEBSVolume) int64 {
+		return int64(float64(vol.Free+vol.Used) * percent / 100)
+	}
+}
+
+// FractionReserve returns a calculator that reserves the provided fraction, between 0 and 1, of the
+// volume size (free plus used space), e.g. 0.15 keeps 15% of headroom in the volume.
+func FractionReserve(fraction float64) (ReserveCalculator, error) {
+	if fraction < 0 || fraction > 1 {
+		return nil, fmt.Errorf("invalid reserve fraction %v, expected a value between 0 and 1", fraction)
+	}
+	return PercentReserve(fraction * 100), nil
+}
+
+// RootDiskReserve reserves 15% of the volume size if the volume is part of the root filesystem,
+// this prevents DiskPressure evictions. this is the reserve used by the disk space validator when
+// no policy has been set for the storage class.
+func RootDiskReserve(vol OpenEBSVolume) int64 {
+	if !vol.RootVolume {
+		return 0
+	}
+	return PercentReserve(15)(vol)
+}
+
+// namedReserveCalculators are the calculators that can be referred to by name in a policy.
+var namedReserveCalculators = map[string]ReserveCalculator{
+	"none":      AbsoluteReserve(0),
+	"root-disk": RootDiskReserve,
+}
+
+// ParseReserveCalculator parses a reserve policy. the policy is either a calculator name ("none" or
+// "root-disk"), a percentage of the volume size (e.g. "20%") or an absolute quantity as used when
+// defining storage requests in Kubernetes (e.g. "10Gi").
+func ParseReserveCalculator(policy string) (ReserveCalculator, error) {
+	if calculator, ok := namedReserveCalculators[policy]; ok {
+		return calculator, nil
+	}
+
+	if strings.HasSuffix(policy, "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(policy, "%"), 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return nil, fmt.Errorf("invalid reserve percentage %q", policy)
+		}
+		return PercentReserve(percent), nil
+	}
+
+	quantity, err := resource.ParseQuantity(policy)
+	if err != nil {
+		var names []string
+		for name := range namedReserveCalculators {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf(
+			"invalid reserve policy %q, expected a percentage, a quantity or one of: %s",
+			policy, strings.Join(names, ", "),
+		)
+	}
+	if quantity.Sign() < 0 {
+		return nil, fmt.Errorf("invalid negative reserve %q", policy)
+	}
+	return AbsoluteReserve(quantity.Value()), nil
+}
+
+// ReservePolicies maps storage class names to the reserve calculator applied to their volumes.
+type ReservePolicies map[string]ReserveCalculator
+
+// ParseReservePolicies parses a list of policies in the storageclass=policy format, see
+// ParseReserveCalculator for the policy format.
+func ParseReservePolicies(specs []string) (ReservePolicies, error) {
+	policies := ReservePolicies{}
+	for _, spec := range specs {
+		scname, policy, found := strings.Cut(spec, "=")
+		if !found || scname == "" || policy == "" {
+			return nil, fmt.Errorf("invalid reserve policy %q, expected storageclass=policy", spec)
+		}
+
+		calculator, err := ParseReserveCalculator(policy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse reserve policy for storage class %s: %w", scname, err)
+		}
+		policies[scname] = calculator
+	}
+	return policies, nil
+}
+
+// For returns the reserve calculator for the provided storage class and true if a policy has been
+// set for it.
+func (p ReservePolicies) For(scname string) (ReserveCalculator, bool) {
+	calculator, ok := p[scname]
+	return calculator, ok
+}
+
+// nodeReserveSelectorPrefix prefixes the node reserve overrides targeting a label selector instead of
+// a node name.
+const nodeReserveSelectorPrefix = "selector:"
+
+// NodeReserveOverride overrides the reserve applied to the volumes of the nodes it targets. it targets
+// either a single node, by name, or all the nodes matching a label selector.
+type NodeReserveOverride struct {
+	Node     string
+	Selector labels.Selector
+	Reserve  ReserveCalculator
+}
+
+// NodeReserveOverrides is a list of node reserve overrides, see For for how the override applied to
+// each node is selected.
+type NodeReserveOverrides []NodeReserveOverride
+
+// ParseNodeReserveOverrides parses a list of overrides in the node=policy or selector:<selector>=policy
+// formats, e.g. "node0=10Gi" or "selector:disk=large,zone=a=20%". see ParseReserveCalculator for the
+// policy format. as policies never contain an equal sign the last one separates the policy.
+func ParseNodeReserveOverrides(specs []string) (NodeReserveOverrides, error) {
+	var overrides NodeReserveOverrides
+	for _, spec := range specs {
+		idx := strings.LastIndex(spec, "=")
+		if idx == -1 || idx == 0 || idx == len(spec)-1 {
+			return nil, fmt.Errorf("invalid node reserve %q, expected node=policy or selector:<selector>=policy", spec)
+		}
+		target, policy := spec[:idx], spec[idx+1:]
+
+		calculator, err := ParseReserveCalculator(policy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse reserve policy for %s: %w", target, err)
+		}
+
+		if !strings.HasPrefix(target, nodeReserveSelectorPrefix) {
+			overrides = append(overrides, NodeReserveOverride{Node: target, Reserve: calculator})
+			continue
+		}
+
+		selector, err := labels.Parse(strings.TrimPrefix(target, nodeReserveSelectorPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse node selector %q: %w", target, err)
+		}
+		overrides = append(overrides, NodeReserveOverride{Selector: selector, Reserve: calculator})
+	}
+	return overrides, nil
+}
+
+// HasSelectors returns true if any of the overrides targets nodes through a label selector, in that
+// case the node labels are needed to select the override.
+func (o NodeReserveOverrides) HasSelectors() bool {
+	for _, override := range o {
+		if override.Selector != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// For returns the reserve calculator overriding the reserve for the provided node and true if any
+// override applies to it. an override for the node name takes precedence over the selectors, among
+// the selectors the first one matching the node labels is used.
+func (o NodeReserveOverrides) For(node string, nodeLabels map[string]string) (ReserveCalculator, bool) {
+	for _, override := range o {
+		if override.Selector == nil && override.Node == node {
+			return override.Reserve, true
+		}
+	}
+
+	for _, override := range o {
+		if override.Selector != nil && override.Selector.Matches(labels.Set(nodeLabels)) {
+			return override.Reserve, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,227 @@
+package clusterspace
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseReserveCalculator(t *testing.T) {
+	root := OpenEBSVolume{Free: 600, Used: 400, RootVolume: true}
+	nonRoot := OpenEBSVolume{Free: 600, Used: 400}
+	for _, tt := range []struct {
+		name    string
+		policy  string
+		err     string
+		root    int64
+		nonRoot int64
+	}{
+		{
+			name:    "should parse an absolute quantity",
+			policy:  "1Ki",
+			root:    1024,
+			nonRoot: 1024,
+		},
+		{
+			name:    "should parse a percentage of the volume size",
+			policy:  "20%",
+			root:    200,
+			nonRoot: 200,
+		},
+		{
+			name:   "should parse the root-disk calculator",
+			policy: "root-disk",
+			root:   150,
+		},
+		{
+			name:   "should parse the none calculator",
+			policy: "none",
+		},
+		{
+			name:   "should fail on percentages above 100",
+			policy: "120%",
+			err:    "invalid reserve percentage",
+		},
+		{
+			name:   "should fail on negative quantities",
+			policy: "-1Gi",
+			err:    "invalid negative reserve",
+		},
+		{
+			name:   "should fail on unknown names",
+			policy: "database",
+			err:    "expected a percentage, a quantity or one of: none, root-disk",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			calculator, err := ParseReserveCalculator(tt.policy)
+			if err != nil {
+				if len(tt.err) == 0 {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expecting %q, %q received instead", tt.err, err)
+				}
+				return
+			}
+
+			if len(tt.err) > 0 {
+				t.Errorf("expecting error %q, nil received instead", tt.err)
+				return
+			}
+			if reserve := calculator(root); reserve != tt.root {
+				t.Errorf("expecting %d reserved in root volume, %d received instead", tt.root, reserve)
+			}
+			if reserve := calculator(nonRoot); reserve != tt.nonRoot {
+				t.Errorf("expecting %d reserved in non root volume, %d received instead", tt.nonRoot, reserve)
+			}
+		})
+	}
+}
+
+func TestFractionReserve(t *testing.T) {
+	vol := OpenEBSVolume{Free: 600, Used: 400}
+	for _, tt := range []struct {
+		fraction float64
+		expected int64
+		err      string
+	}{
+		{fraction: 0.15, expected: 150},
+		{fraction: 0, expected: 0},
+		{fraction: 1, expected: 1000},
+		{fraction: 1.5, err: "invalid reserve fraction 1.5, expected a value between 0 and 1"},
+		{fraction: -0.1, err: "invalid reserve fraction -0.1, expected a value between 0 and 1"},
+	} {
+		calculator, err := FractionReserve(tt.fraction)
+		if err != nil {
+			if tt.err == "" || err.Error() != tt.err {
+				t.Errorf("unexpected error for fraction %v: %s", tt.fraction, err)
+			}
+			continue
+		}
+		if tt.err != "" {
+			t.Errorf("expected error %q for fraction %v, nil received", tt.err, tt.fraction)
+			continue
+		}
+		if reserve := calculator(vol); reserve != tt.expected {
+			t.Errorf("expected reserve %d for fraction %v, %d received", tt.expected, tt.fraction, reserve)
+		}
+	}
+}
+
+func TestParseReservePolicies(t *testing.T) {
+	policies, err := ParseReservePolicies([]string{"logs=1Ki", "database=50%"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	vol := OpenEBSVolume{Free: 1000, Used: 1000}
+	for scname, expected := range map[string]int64{"logs": 1024, "database": 1000} {
+		calculator, ok := policies.For(scname)
+		if !ok {
+			t.Errorf("expecting policy for storage class %s", scname)
+			continue
+		}
+		if reserve := calculator(vol); reserve != expected {
+			t.Errorf("expecting %d reserved for storage class %s, %d received instead", expected, scname, reserve)
+		}
+	}
+
+	if _, ok := policies.For("openebs"); ok {
+		t.Errorf("unexpected policy for storage class openebs")
+	}
+
+	for _, invalid := range []string{"logs", "=10Gi", "logs=", "logs=abc"} {
+		if _, err := ParseReservePolicies([]string{invalid}); err == nil {
+			t.Errorf("expecting error parsing %q", invalid)
+		}
+	}
+}
+
+func TestNodeReserveOverridesPrecedence(t *testing.T) {
+	overrides, err := ParseNodeReserveOverrides([]string{
+		"selector:disk=large=10%",
+		"node0=100",
+		"selector:zone in (a,b)=200",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	vol := OpenEBSVolume{Free: 600, Used: 400}
+	for _, tt := range []struct {
+		name     string
+		node     string
+		labels   map[string]string
+		found    bool
+		expected int64
+	}{
+		{
+			name:     "exact name should take precedence over matching selectors",
+			node:     "node0",
+			labels:   map[string]string{"disk": "large", "zone": "a"},
+			found:    true,
+			expected: 100,
+		},
+		{
+			name:     "first matching selector should be used",
+			node:     "node1",
+			labels:   map[string]string{"disk": "large", "zone": "a"},
+			found:    true,
+			expected: 100,
+		},
+		{
+			name:     "set based selectors should be supported",
+			node:     "node2",
+			labels:   map[string]string{"zone": "b"},
+			found:    true,
+			expected: 200,
+		},
+		{
+			name:   "nodes not targeted should fall back to the default",
+			node:   "node3",
+			labels: map[string]string{"zone": "c"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			reserve, found := overrides.For(tt.node, tt.labels)
+			if found != tt.found {
+				t.Fatalf("expected found %v, received %v", tt.found, found)
+			}
+			if !found {
+				return
+			}
+			if got := reserve(vol); got != tt.expected {
+				t.Errorf("expected reserve %d, received %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseNodeReserveOverridesErrors(t *testing.T) {
+	for _, spec := range []string{"node0", "=10Gi", "node0=", "node0=lots", "selector:disk in large=10Gi"} {
+		if _, err := ParseNodeReserveOverrides([]string{spec}); err == nil {
+			t.Errorf("expected error parsing %q", spec)
+		}
+	}
+}
+
+func TestValidatorReserveFor(t *testing.T) {
+	overrides, err := ParseNodeReserveOverrides([]string{"node0=100", "selector:disk=small=none"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	validator := OpenEBSDiskSpaceValidator{
+		reserve:      AbsoluteReserve(50),
+		nodeReserves: overrides,
+		nodeLabels: map[string]map[string]string{
+			"node1": {"disk": "small"},
+		},
+	}
+
+	vol := OpenEBSVolume{Free: 1000}
+	for node, expected := range map[string]int64{"node0": 900, "node1": 1000, "node2": 950} {
+		if free, _ := validator.hasEnoughSpace(node, vol, 0); free != expected {
+			t.Errorf("expected %d free in %s, received %d", expected, node, free)
+		}
+	}
+}
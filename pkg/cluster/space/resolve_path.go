@@ -0,0 +1,84 @@
+package clusterspace
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// resolvedPathMarker is printed by the df container, followed by the resolved path, when the
+// filesystem backing a specific node path is measured. nothing follows the marker if the path
+// could not be resolved.
+const resolvedPathMarker = "KURL_RESOLVED_PATH:"
+
+// resolvePathCommand returns the script executed by the df container to measure the filesystem
+// backing the provided node path. the script runs chrooted into the node root filesystem so
+// symlinks are resolved as in the node, then df is executed against the resolved path. the base
+// path, mounted under mountPath, must still be accessible.
+func resolvePathCommand(path, mountPath, marker string) string {
+	script := fmt.Sprintf(
+		`t=$(readlink -f %s); if [ -z "$t" ] || [ ! -e "$t" ]; then echo %s; exit 0; fi; echo %s "$t"; echo %s; df -B1 "$t"`,
+		shellQuote(path), resolvedPathMarker, resolvedPathMarker, marker,
+	)
+	return basePathScript(mountPath, fmt.Sprintf("chroot /host /bin/sh -c %s", shellQuote(script)))
+}
+
+// parseResolvedPath returns the resolved path printed by the script returned by resolvePathCommand.
+func parseResolvedPath(output []byte) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewBuffer(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, resolvedPathMarker) {
+			continue
+		}
+
+		resolved := strings.TrimSpace(strings.TrimPrefix(line, resolvedPathMarker))
+		if resolved == "" {
+			return "", fmt.Errorf("path does not exist or can't be resolved in the node")
+		}
+		return resolved, nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to process container log: %w", err)
+	}
+	return "", fmt.Errorf("failed to locate the resolved path in pod log: %s", string(output))
+}
+
+// parseResolvedDFOutput parses the output of the script returned by resolvePathCommand and returns
+// the available and used space of the filesystem holding the resolved path: the df line whose
+// mount point is the longest parent of the resolved path.
+func (o *OpenEBSFreeDiskSpaceGetter) parseResolvedDFOutput(output []byte) (int64, int64, error) {
+	resolved, err := parseResolvedPath(output)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to resolve %s: %w", o.opts.Output.ResolvePath, err)
+	}
+
+	dfOutput, err := cutDFMarker(output, o.dfOutputMarker())
+	if err != nil {
+		return 0, 0, err
+	}
+
+	entries, err := matchingDFEntries(dfOutput, resolved)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var selected *dfEntry
+	for i := range entries {
+		if selected == nil || len(entries[i].mountPoint) > len(selected.mountPoint) {
+			selected = &entries[i]
+		}
+	}
+
+	if selected == nil {
+		return 0, 0, fmt.Errorf("failed to locate free space info for %s in pod log: %s", resolved, string(output))
+	}
+
+	blockSize, err := dfBlockSize(dfOutput)
+	if err != nil {
+		return 0, 0, err
+	}
+	return dfEntryAmounts(selected.words, blockSize)
+}
@@ -0,0 +1,172 @@
+package clusterspace
+
+import (
+	"context"
+	"io"
+	"log"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_parseResolvedDFOutput(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		content      string
+		err          string
+		expectedPath string
+		expectedFree int64
+		expectedUsed int64
+	}{
+		{
+			name: "should measure the filesystem holding the symlink target",
+			content: `KURL_RESOLVED_PATH: /mnt/disk1/kotsadm
+KURL_DF_BEGIN
+Filesystem       1B-blocks       Used   Available Use% Mounted on
+/dev/sdb1      85886742528 8500056064 77386686464  10% /mnt/disk1`,
+			expectedPath: "/mnt/disk1/kotsadm",
+			expectedFree: 77386686464,
+			expectedUsed: 8500056064,
+		},
+		{
+			name: "should pick the most specific mount holding the target",
+			content: `noise before anything /mnt/disk1
+KURL_RESOLVED_PATH: /mnt/disk1/kotsadm
+KURL_DF_BEGIN
+Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sda2      63087357952 52521754624 7327760384  88% /
+/dev/sdb1      85886742528  8500056064 77386686464  10% /mnt/disk1
+/dev/sdc1      10737418240  1073741824  9663676416  10% /mnt/disk10`,
+			expectedPath: "/mnt/disk1/kotsadm",
+			expectedFree: 77386686464,
+			expectedUsed: 8500056064,
+		},
+		{
+			name: "should measure the root filesystem when the target lives in it",
+			content: `KURL_RESOLVED_PATH: /var/lib/kotsadm
+KURL_DF_BEGIN
+Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sda2      63087357952 52521754624 7327760384  88% /`,
+			expectedPath: "/var/lib/kotsadm",
+			expectedFree: 7327760384,
+			expectedUsed: 52521754624,
+		},
+		{
+			name: "should fail if the path could not be resolved",
+			content: `KURL_RESOLVED_PATH:
+`,
+			err: "failed to resolve /var/lib/kotsadm: path does not exist or can't be resolved in the node",
+		},
+		{
+			name: "should fail if the resolved path is not reported",
+			content: `KURL_DF_BEGIN
+Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sda2      63087357952 52521754624 7327760384  88% /`,
+			err: "failed to locate the resolved path in pod log",
+		},
+		{
+			name: "should fail without the df marker",
+			content: `KURL_RESOLVED_PATH: /mnt/disk1/kotsadm
+/dev/sdb1      85886742528 8500056064 77386686464  10% /mnt/disk1`,
+			err: "df output marker KURL_DF_BEGIN not found",
+		},
+		{
+			name: "should fail if no mount holds the target",
+			content: `KURL_RESOLVED_PATH: /mnt/disk1/kotsadm
+KURL_DF_BEGIN
+Filesystem       1B-blocks        Used  Available Use% Mounted on
+/dev/sdc1      10737418240  1073741824  9663676416  10% /mnt/disk10`,
+			err: "failed to locate free space info for /mnt/disk1/kotsadm",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ochecker := OpenEBSFreeDiskSpaceGetter{
+				opts: OpenEBSOptions{
+					Output: OpenEBSOutputOptions{
+						ResolvePath: "/var/lib/kotsadm",
+					},
+				},
+				log: log.New(io.Discard, "", 0),
+			}
+			free, used, _, err := ochecker.parseFreeSpace([]byte(tt.content))
+			if err != nil {
+				if len(tt.err) == 0 {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expecting %q, %q received instead", tt.err, err)
+				}
+				return
+			}
+
+			if len(tt.err) > 0 {
+				t.Errorf("expecting error %q, nil received instead", tt.err)
+			}
+
+			if free != tt.expectedFree {
+				t.Errorf("expected free %v, received %v", tt.expectedFree, free)
+			}
+			if used != tt.expectedUsed {
+				t.Errorf("expected used %v, received %v", tt.expectedUsed, used)
+			}
+
+			resolved, err := parseResolvedPath([]byte(tt.content))
+			if err != nil {
+				t.Fatalf("unexpected error parsing the resolved path: %s", err)
+			}
+			if resolved != tt.expectedPath {
+				t.Errorf("expected resolved path %q, %q received", tt.expectedPath, resolved)
+			}
+		})
+	}
+}
+
+func Test_resolvePathCommand(t *testing.T) {
+	cmd := resolvePathCommand("/var/lib/it's", DefaultDFMountPath, DefaultDFMarker)
+	for _, expected := range []string{
+		"chroot /host /bin/sh -c '",
+		`readlink -f '\''/var/lib/it'\''\'\'''\''s'\''`,
+		"echo KURL_DF_BEGIN; df -B1",
+	} {
+		if !strings.Contains(cmd, expected) {
+			t.Errorf("expected %q in command: %s", expected, cmd)
+		}
+	}
+
+	ochecker := OpenEBSFreeDiskSpaceGetter{opts: OpenEBSOptions{Output: OpenEBSOutputOptions{ResolvePath: "/var/lib/kotsadm"}}}
+	job := ochecker.buildJob(context.Background(), "node0", "/var/openebs/local", "pvc")
+	var mounted bool
+	for _, mount := range job.Spec.Template.Spec.Containers[0].VolumeMounts {
+		mounted = mounted || (mount.MountPath == "/host" && mount.ReadOnly)
+	}
+	if !mounted {
+		t.Errorf("expected the node root filesystem to be mounted read only in the df container")
+	}
+}
+
+func TestNewOpenEBSFreeDiskSpaceGetterResolvePath(t *testing.T) {
+	for _, tt := range []struct {
+		path    string
+		statfs  string
+		invalid bool
+	}{
+		{path: "/var/lib/kotsadm"},
+		{path: "var/lib/kotsadm", invalid: true},
+		{path: "/var/lib/kotsadm", statfs: "/statfs", invalid: true},
+	} {
+		_, err := NewOpenEBSFreeDiskSpaceGetterWithOptions(fake.NewSimpleClientset(), OpenEBSOptions{
+			Log:   log.New(io.Discard, "", 0),
+			Image: "myimage:latest",
+			DstSC: "openebs",
+			Output: OpenEBSOutputOptions{
+				ResolvePath:  tt.path,
+				StatfsBinary: tt.statfs,
+			},
+		})
+		if tt.invalid && err == nil {
+			t.Errorf("expected path %q with statfs %q to be rejected", tt.path, tt.statfs)
+		} else if !tt.invalid && err != nil {
+			t.Errorf("unexpected error for path %q: %s", tt.path, err)
+		}
+	}
+}
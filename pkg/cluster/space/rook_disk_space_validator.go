@@ -44,7 +44,7 @@ func (r *RookDiskSpaceValidator) reservedSpace(ctx context.Context) (int64, erro
 func (r *RookDiskSpaceValidator) HasEnoughDiskSpace(ctx context.Context) (bool, error) {
 	r.log.Print("Analysing reserved and free Ceph disk space...")
 
-	free, err := r.freeSpaceGetter.GetFreeSpace(ctx)
+	space, err := r.freeSpaceGetter.GetSpace(ctx)
 	if err != nil {
 		return false, fmt.Errorf("failed to verify free space: %w", err)
 	}
@@ -55,10 +55,18 @@ func (r *RookDiskSpaceValidator) HasEnoughDiskSpace(ctx context.Context) (bool,
 	}
 
 	r.log.Print("\n")
-	r.log.Printf("Free space in Ceph: %s", bytefmt.ByteSize(uint64(free)))
+	r.log.Printf("Free space in Ceph: %s", bytefmt.ByteSize(uint64(space.Free)))
+	r.log.Printf("Used space in Ceph: %s", bytefmt.ByteSize(uint64(space.Used)))
 	r.log.Printf("Reserved (%q storage class): %s", r.srcSC, bytefmt.ByteSize(uint64(reserved)))
 	r.log.Print("\n")
-	return free > reserved, nil
+	return hasEnoughSpace(space, reserved), nil
+}
+
+// hasEnoughSpace returns true if the ceph pool is capable of holding the provided reserved amount of
+// bytes. unlike openebs volumes no space is kept free in the pool, ceph itself stops accepting writes
+// once its full ratio is reached and the free space reported already accounts for it.
+func hasEnoughSpace(space RookSpace, reserved int64) bool {
+	return space.Free > reserved
 }
 
 // NewRookDiskSpaceValidator returns a disk free analyser for rook storage provisioner.
@@ -414,3 +414,22 @@ func TestNewRookChecker(t *testing.T) {
 		t.Errorf("unexpected failure creating object: %v", err)
 	}
 }
+
+func Test_rookHasEnoughSpace(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		space    RookSpace
+		reserved int64
+		expected bool
+	}{
+		{name: "should fit volumes smaller than the free space", space: RookSpace{Free: 100, Used: 900}, reserved: 99, expected: true},
+		{name: "should not fit volumes as big as the free space", space: RookSpace{Free: 100}, reserved: 100},
+		{name: "should not fit volumes bigger than the free space", space: RookSpace{Free: 100}, reserved: 200},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := hasEnoughSpace(tt.space, tt.reserved); result != tt.expected {
+				t.Errorf("expected %v, received %v", tt.expected, result)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	rookv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	rookcli "github.com/rook/rook/pkg/client/clientset/versioned"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -41,35 +42,74 @@ func (r *RookFreeDiskSpaceGetter) getPoolAndClusterNames(ctx context.Context) (s
 	return pname, cname, nil
 }
 
-// GetFreeSpace attempts to get the ceph free space. returns the number of available bytes.
+// GetFreeSpace attempts to get the ceph free space. returns the number of available bytes, this is
+// the physical free space divided by the pool replica size.
 func (r *RookFreeDiskSpaceGetter) GetFreeSpace(ctx context.Context) (int64, error) {
+	free, replicas, err := r.GetPhysicalFreeSpace(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return free / int64(replicas), nil
+}
+
+// GetPhysicalFreeSpace returns the number of bytes physically available in the ceph cluster and the
+// replica size of the storage class pool, i.e. how many physical bytes each logical byte consumes.
+func (r *RookFreeDiskSpaceGetter) GetPhysicalFreeSpace(ctx context.Context) (int64, int, error) {
+	capacity, replicas, err := r.capacity(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int64(capacity.AvailableBytes), replicas, nil
+}
+
+// RookSpace holds the space of a ceph pool as seen by its volumes: the physical free and used bytes
+// of the ceph cluster divided by the pool replica size.
+type RookSpace struct {
+	Free int64 `json:"free"`
+	Used int64 `json:"used"`
+}
+
+// GetSpace returns the free and used bytes of the storage class pool, see RookSpace. this is the
+// rook counterpart of the openebs volumes returned by OpenEBSFreeDiskSpaceGetter.
+func (r *RookFreeDiskSpaceGetter) GetSpace(ctx context.Context) (RookSpace, error) {
+	capacity, replicas, err := r.capacity(ctx)
+	if err != nil {
+		return RookSpace{}, err
+	}
+	return RookSpace{
+		Free: int64(capacity.AvailableBytes) / int64(replicas),
+		Used: int64(capacity.UsedBytes) / int64(replicas),
+	}, nil
+}
+
+// capacity returns the ceph cluster capacity, as reported by the rook operator in the ceph cluster
+// status, and the replica size of the storage class pool.
+func (r *RookFreeDiskSpaceGetter) capacity(ctx context.Context) (rookv1.Capacity, int, error) {
 	pname, cname, err := r.getPoolAndClusterNames(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get ceph pool: %w", err)
+		return rookv1.Capacity{}, 0, fmt.Errorf("failed to get ceph pool: %w", err)
 	}
 
 	pool, err := r.rcli.CephV1().CephBlockPools(namespace).Get(ctx, pname, metav1.GetOptions{})
 	if err != nil {
-		return 0, fmt.Errorf("failed to get pool %s: %w", pname, err)
+		return rookv1.Capacity{}, 0, fmt.Errorf("failed to get pool %s: %w", pname, err)
 	}
 
 	// this should never happen but we better this than a division by zero.
 	if pool.Spec.Replicated.Size == 0 {
-		return 0, fmt.Errorf("pool replica size is zeroed")
+		return rookv1.Capacity{}, 0, fmt.Errorf("pool replica size is zeroed")
 	}
 
 	cluster, err := r.rcli.CephV1().CephClusters(namespace).Get(ctx, cname, metav1.GetOptions{})
 	if err != nil {
-		return 0, fmt.Errorf("failed to get ceph cluster %s: %w", cname, err)
+		return rookv1.Capacity{}, 0, fmt.Errorf("failed to get ceph cluster %s: %w", cname, err)
 	}
 
 	if cluster.Status.CephStatus == nil {
-		return 0, fmt.Errorf("failed to read ceph status (nil)")
+		return rookv1.Capacity{}, 0, fmt.Errorf("failed to read ceph status (nil)")
 	}
 
-	availint64 := int64(cluster.Status.CephStatus.Capacity.AvailableBytes)
-	replicasint64 := int64(pool.Spec.Replicated.Size)
-	return availint64 / replicasint64, nil
+	return cluster.Status.CephStatus.Capacity, int(pool.Spec.Replicated.Size), nil
 }
 
 // NewRookFreeDiskSpaceGetter returns a disk free getter for rook storage provisioner.
@@ -374,3 +374,57 @@ func TestGetFreeSpace(t *testing.T) {
 		})
 	}
 }
+
+func TestGetSpace(t *testing.T) {
+	sc := &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+		},
+		Parameters: map[string]string{
+			"pool":      "poolname",
+			"clusterID": "clustername",
+		},
+	}
+	pool := &rookv1.CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "poolname",
+			Namespace: namespace,
+		},
+		Spec: rookv1.NamedBlockPoolSpec{
+			PoolSpec: rookv1.PoolSpec{
+				Replicated: rookv1.ReplicatedSpec{
+					Size: 3,
+				},
+			},
+		},
+	}
+	cluster := &rookv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "clustername",
+			Namespace: namespace,
+		},
+		Status: rookv1.ClusterStatus{
+			CephStatus: &rookv1.CephStatus{
+				Capacity: rookv1.Capacity{
+					TotalBytes:     900,
+					UsedBytes:      300,
+					AvailableBytes: 600,
+				},
+			},
+		},
+	}
+
+	getter, err := NewRookFreeDiskSpaceGetter(fake.NewSimpleClientset(sc), rookfake.NewSimpleClientset(pool, cluster), "test")
+	if err != nil {
+		t.Fatalf("unexpected error creating getter: %s", err)
+	}
+
+	space, err := getter.GetSpace(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if expected := (RookSpace{Free: 200, Used: 100}); space != expected {
+		t.Errorf("expected space %+v, received %+v", expected, space)
+	}
+}
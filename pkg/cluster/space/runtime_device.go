@@ -0,0 +1,133 @@
+package clusterspace
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	// runtimeRootsMarker precedes the container runtime data roots in the runtime container output.
+	runtimeRootsMarker = "KURL_RUNTIME_ROOTS"
+	// runtimeMountInfoMarker precedes the node mountinfo content in the runtime container output.
+	runtimeMountInfoMarker = "KURL_RUNTIME_MOUNTINFO"
+)
+
+// runtimeRootsScript prints the data roots of containerd and docker as configured in the node. if
+// neither runtime can be queried the default data roots that exist in the node are printed instead.
+var runtimeRootsScript = `roots=$(containerd config dump 2>/dev/null | sed -n 's/^root = "\(.*\)"$/\1/p'; docker info --format '{{.DockerRootDir}}' 2>/dev/null); ` +
+	`if [ -n "$roots" ]; then echo "$roots"; else for d in /var/lib/containerd /var/lib/docker; do [ -d "$d" ] && echo "$d"; done; fi; true`
+
+// runtimeDeviceCommand prints the container runtime data roots and the node mountinfo. both are
+// read in the host mount namespace so they reflect the node and not the container.
+var runtimeDeviceCommand = fmt.Sprintf(
+	`echo %s; nsenter --target 1 --mount -- sh -c %s; echo %s; nsenter --target 1 --mount -- cat /proc/1/mountinfo; true`,
+	runtimeRootsMarker, shellQuote(runtimeRootsScript), runtimeMountInfoMarker,
+)
+
+// RuntimeDevice tells whether the openebs base path lives in the same device as the container
+// runtime data root, in which case image pulls compete with the volumes for space and i/o. Device
+// is the major:minor of the mount holding the base path and SharedRoots are the runtime data roots
+// living in the same device.
+type RuntimeDevice struct {
+	Device      string   `json:"device"`
+	SharedRoots []string `json:"sharedRoots,omitempty"`
+}
+
+// Shared returns true if any container runtime data root lives in the base path device.
+func (r RuntimeDevice) Shared() bool {
+	return len(r.SharedRoots) > 0
+}
+
+// String returns a user friendly description of the device sharing.
+func (r RuntimeDevice) String() string {
+	if !r.Shared() {
+		return fmt.Sprintf("device %s is not shared with the container runtime", r.Device)
+	}
+	return fmt.Sprintf("device %s is shared with the container runtime data root %s", r.Device, strings.Join(r.SharedRoots, ", "))
+}
+
+// mountInfoEntry is a mount as read from /proc/<pid>/mountinfo.
+type mountInfoEntry struct {
+	device     string
+	mountPoint string
+}
+
+// mountDevice returns the major:minor of the mount holding the provided path: the one with the
+// longest mount point containing it. if multiple mounts share the same mount point the last one (the
+// one on top) is used.
+func mountDevice(mounts []mountInfoEntry, path string) (string, bool) {
+	var found *mountInfoEntry
+	for i, mount := range mounts {
+		if !pathHasPrefix(path, mount.mountPoint) {
+			continue
+		}
+		if found != nil && len(mount.mountPoint) < len(found.mountPoint) {
+			continue
+		}
+		found = &mounts[i]
+	}
+	if found == nil {
+		return "", false
+	}
+	return found.device, true
+}
+
+// parseRuntimeDeviceOutput parses the output of the runtimeDeviceCommand and compares the device of
+// the mount holding basePath with the devices of the mounts holding the runtime data roots.
+// malformed mountinfo lines are ignored.
+func parseRuntimeDeviceOutput(output []byte, basePath string) (RuntimeDevice, error) {
+	var section string
+	var roots []string
+	var mounts []mountInfoEntry
+
+	scanner := bufio.NewScanner(bytes.NewBuffer(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch line {
+		case "":
+			continue
+		case runtimeRootsMarker, runtimeMountInfoMarker:
+			section = line
+			continue
+		}
+
+		switch section {
+		case runtimeRootsMarker:
+			if strings.HasPrefix(line, "/") {
+				roots = append(roots, line)
+			}
+
+		case runtimeMountInfoMarker:
+			// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+			fields := strings.Fields(line)
+			if len(fields) < 5 || !strings.Contains(fields[2], ":") {
+				continue
+			}
+			mounts = append(mounts, mountInfoEntry{device: fields[2], mountPoint: unescapeMountPath(fields[4])})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return RuntimeDevice{}, fmt.Errorf("failed to read runtime device output: %w", err)
+	}
+
+	device, found := mountDevice(mounts, basePath)
+	if !found {
+		return RuntimeDevice{}, fmt.Errorf("failed to find the mount for %s in the node mountinfo", basePath)
+	}
+
+	result := RuntimeDevice{Device: device}
+	seen := map[string]bool{}
+	for _, root := range roots {
+		if seen[root] {
+			continue
+		}
+		seen[root] = true
+
+		if rootDevice, found := mountDevice(mounts, root); found && rootDevice == device {
+			result.SharedRoots = append(result.SharedRoots, root)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,107 @@
+package clusterspace
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_parseRuntimeDeviceOutput(t *testing.T) {
+	mountInfo := `KURL_RUNTIME_MOUNTINFO
+22 1 253:0 / / rw,relatime shared:1 - xfs /dev/mapper/rhel-root rw
+24 22 0:5 / /dev rw,nosuid shared:2 - devtmpfs devtmpfs rw
+40 22 8:16 / /var/lib/containerd rw,relatime shared:20 - xfs /dev/sdb rw
+41 22 8:32 / /var/openebs rw,relatime shared:21 - ext4 /dev/sdc rw
+42 22 8:48 / /mnt/fast\040disk rw,relatime shared:22 - ext4 /dev/sdd rw
+`
+
+	for _, tt := range []struct {
+		name     string
+		output   string
+		basePath string
+		expected RuntimeDevice
+		err      string
+	}{
+		{
+			name:     "should not report dedicated devices",
+			output:   "KURL_RUNTIME_ROOTS\n/var/lib/containerd\n" + mountInfo,
+			basePath: "/var/openebs/local",
+			expected: RuntimeDevice{Device: "8:32"},
+		},
+		{
+			name:     "should report a base path sharing the device with the runtime",
+			output:   "KURL_RUNTIME_ROOTS\n/var/lib/containerd\n" + mountInfo,
+			basePath: "/var/lib/containerd/openebs",
+			expected: RuntimeDevice{Device: "8:16", SharedRoots: []string{"/var/lib/containerd"}},
+		},
+		{
+			name:     "should report runtimes living in the root filesystem along with the base path",
+			output:   "KURL_RUNTIME_ROOTS\n/var/lib/docker\n/var/lib/docker\n" + mountInfo,
+			basePath: "/opt/openebs/local",
+			expected: RuntimeDevice{Device: "253:0", SharedRoots: []string{"/var/lib/docker"}},
+		},
+		{
+			name:     "should handle escaped mount points",
+			output:   "KURL_RUNTIME_ROOTS\n/mnt/fast disk/containerd\n" + mountInfo,
+			basePath: "/mnt/fast disk/openebs",
+			expected: RuntimeDevice{Device: "8:48", SharedRoots: []string{"/mnt/fast disk/containerd"}},
+		},
+		{
+			name: "should use the mount on top when mounts are stacked",
+			output: "KURL_RUNTIME_ROOTS\n/var/lib/containerd\nKURL_RUNTIME_MOUNTINFO\n" +
+				"22 1 253:0 / / rw shared:1 - xfs /dev/root rw\n" +
+				"40 22 8:16 / /data rw shared:2 - xfs /dev/sdb rw\n" +
+				"41 40 8:32 / /data rw shared:3 - xfs /dev/sdc rw\n",
+			basePath: "/data/openebs",
+			expected: RuntimeDevice{Device: "8:32"},
+		},
+		{
+			name:     "should ignore malformed lines and nothing being discovered",
+			output:   "KURL_RUNTIME_ROOTS\ncontainerd: command not found\n" + mountInfo + "garbage\n",
+			basePath: "/var/openebs/local",
+			expected: RuntimeDevice{Device: "8:32"},
+		},
+		{
+			name:     "should fail if the base path mount is not found",
+			output:   "KURL_RUNTIME_ROOTS\n/var/lib/containerd\nKURL_RUNTIME_MOUNTINFO\n",
+			basePath: "/var/openebs/local",
+			err:      "failed to find the mount for /var/openebs/local in the node mountinfo",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseRuntimeDeviceOutput([]byte(tt.output), tt.basePath)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, received %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, result); diff != "" {
+				t.Errorf("unexpected runtime device: %s", diff)
+			}
+		})
+	}
+}
+
+func TestRuntimeDeviceString(t *testing.T) {
+	shared := RuntimeDevice{Device: "8:16", SharedRoots: []string{"/var/lib/containerd"}}
+	if !shared.Shared() {
+		t.Errorf("expected device to be shared")
+	}
+	if expected := "device 8:16 is shared with the container runtime data root /var/lib/containerd"; shared.String() != expected {
+		t.Errorf("expected %q, received %q", expected, shared.String())
+	}
+
+	dedicated := RuntimeDevice{Device: "8:32"}
+	if dedicated.Shared() {
+		t.Errorf("expected device not to be shared")
+	}
+	if expected := "device 8:32 is not shared with the container runtime"; dedicated.String() != expected {
+		t.Errorf("expected %q, received %q", expected, dedicated.String())
+	}
+}
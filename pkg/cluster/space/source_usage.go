@@ -0,0 +1,230 @@
+package clusterspace
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/replicatedhq/kurl/pkg/k8sutil"
+)
+
+// sourceVolumesDir is where the source volumes directories are mounted inside the du container.
+const sourceVolumesDir = "/volumes"
+
+// SourceUsage holds the space actually used by the source storage class volumes. PerVolume is indexed
+// by pv name and PerNode, the sum of the volumes in each node, by node name. Unmeasured holds the pvs
+// whose usage can't be measured as they are not backed by a directory in a known node.
+type SourceUsage struct {
+	PerNode    map[string]int64 `json:"perNode"`
+	PerVolume  map[string]int64 `json:"perVolume"`
+	Unmeasured []string         `json:"unmeasured,omitempty"`
+}
+
+// sourceVolume is a source storage class pv whose data lives in a node directory.
+type sourceVolume struct {
+	name string
+	path string
+}
+
+// groupSourceVolumes groups by node the provided pvs whose data lives in a node directory, i.e. local
+// and host path volumes bound to a node through their node affinity. the volumes in each node are
+// sorted by name. the names of the pvs that can't be mapped to a node directory are returned as well.
+func groupSourceVolumes(pvs map[string]corev1.PersistentVolume) (map[string][]sourceVolume, []string) {
+	perNode := map[string][]sourceVolume{}
+	var unmeasured []string
+	for name, pv := range pvs {
+		var path string
+		switch {
+		case pv.Spec.Local != nil:
+			path = pv.Spec.Local.Path
+		case pv.Spec.HostPath != nil:
+			path = pv.Spec.HostPath.Path
+		}
+
+		node := k8sutil.PersistentVolumeNode(pv)
+		if path == "" || node == "" {
+			unmeasured = append(unmeasured, name)
+			continue
+		}
+		perNode[node] = append(perNode[node], sourceVolume{name: name, path: path})
+	}
+
+	for _, volumes := range perNode {
+		sort.Slice(volumes, func(i, j int) bool {
+			return volumes[i].name < volumes[j].name
+		})
+	}
+	sort.Strings(unmeasured)
+	return perNode, unmeasured
+}
+
+// duCommand returns the command that prints the disk usage, in KiB, of each of the provided number of
+// directories mounted under sourceVolumesDir. -k is used instead of -b as the latter is not supported
+// by busybox.
+func duCommand(count int) string {
+	args := make([]string, count)
+	for i := range args {
+		args[i] = fmt.Sprintf("%s/%d", sourceVolumesDir, i)
+	}
+	return fmt.Sprintf("du -sxk %s", strings.Join(args, " "))
+}
+
+// parseDUOutput parses the output of the duCommand and returns the usage, in bytes, of each of the
+// provided number of directories, in the order they have been provided:
+//
+// 1024	/volumes/0
+// 52428	/volumes/1
+func parseDUOutput(output []byte, count int) ([]int64, error) {
+	usage := make([]int64, count)
+	found := make([]bool, count)
+	scanner := bufio.NewScanner(bytes.NewBuffer(output))
+	for scanner.Scan() {
+		words := strings.Fields(scanner.Text())
+		if len(words) != 2 || !strings.HasPrefix(words[1], sourceVolumesDir+"/") {
+			continue
+		}
+
+		idx, err := strconv.Atoi(strings.TrimPrefix(words[1], sourceVolumesDir+"/"))
+		if err != nil || idx < 0 || idx >= count {
+			return nil, fmt.Errorf("unexpected directory %q in du output", words[1])
+		}
+
+		kbytes, err := strconv.ParseInt(words[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %q as disk usage: %w", words[0], err)
+		}
+		usage[idx] = kbytes * 1024
+		found[idx] = true
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to process container log: %w", err)
+	}
+
+	for idx := range found {
+		if !found[idx] {
+			return nil, fmt.Errorf("failed to locate disk usage of %s/%d in pod log: %s", sourceVolumesDir, idx, string(output))
+		}
+	}
+	return usage, nil
+}
+
+// buildDUJob returns a job that measures the disk usage of the provided directories in the node.
+func (o *OpenEBSFreeDiskSpaceGetter) buildDUJob(node string, paths []string) *batchv1.Job {
+	volumes, mounts := hostPathMounts(paths, sourceVolumesDir)
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        jobName(node),
+			Namespace:   o.opts.Namespace,
+			Labels:      o.jobLabels(node),
+			Annotations: o.jobAnnotations(),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          ptr.To(int32(1)),
+			ActiveDeadlineSeconds: activeDeadlineSeconds(o.opts.Job.Timeout),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      o.jobLabels(node),
+					Annotations: o.jobAnnotations(),
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:    corev1.RestartPolicyNever,
+					Tolerations:      o.tolerations,
+					Affinity:         nodeAffinity(node),
+					ImagePullSecrets: o.imagePullSecrets(),
+					Volumes:          volumes,
+					Containers: []corev1.Container{
+						{
+							Name:         "du",
+							Image:        o.opts.Image,
+							Command:      []string{"/bin/sh", "-c"},
+							Args:         []string{encodeOutputCommand(duCommand(len(paths)))},
+							VolumeMounts: mounts,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// directoriesUsage runs a du job in the node and returns the usage, in bytes, of each of the provided
+// directories in the order they have been provided.
+func (o *OpenEBSFreeDiskSpaceGetter) directoriesUsage(ctx context.Context, node string, paths []string) ([]int64, error) {
+	job := o.buildDUJob(node, paths)
+	out, status, err := o.runJob(ctx, job)
+	if err != nil {
+		o.logContainersState(out, status)
+		return nil, fmt.Errorf(
+			"failed to run job %s/%s on node %s: %w", job.Namespace, job.Name, node, err,
+		)
+	}
+
+	duOutput, err := decodeOutput(out["du"])
+	if err != nil {
+		o.logContainersState(out, status)
+		return nil, fmt.Errorf("failed to read node %s du output: %w", node, err)
+	}
+
+	usage, err := parseDUOutput(duOutput, len(paths))
+	if err != nil {
+		o.logContainersState(out, status)
+		return nil, fmt.Errorf("failed to parse node %s du output: %w", node, err)
+	}
+	return usage, nil
+}
+
+// aggregateSourceUsage builds the SourceUsage out of the usage measured for the volumes in each node.
+// usage holds, for each node, the usage of the node volumes in the same order as in perNode.
+func aggregateSourceUsage(perNode map[string][]sourceVolume, usage map[string][]int64, unmeasured []string) SourceUsage {
+	result := SourceUsage{
+		PerNode:    map[string]int64{},
+		PerVolume:  map[string]int64{},
+		Unmeasured: unmeasured,
+	}
+	for node, volumes := range perNode {
+		for i, vol := range volumes {
+			result.PerVolume[vol.name] = usage[node][i]
+			result.PerNode[node] += usage[node][i]
+		}
+	}
+	return result
+}
+
+// SourceUsage measures the space actually used by the source storage class volumes in each node, as
+// opposed to the space they have requested. only local and host path volumes bound to a node can be
+// measured, a du job is executed in each node holding any of them.
+func (o *OpenEBSDiskSpaceValidator) SourceUsage(ctx context.Context) (SourceUsage, error) {
+	pvs, err := k8sutil.PVSByStorageClass(ctx, o.kcli, o.srcSC)
+	if err != nil {
+		return SourceUsage{}, fmt.Errorf("failed to list source storage class volumes: %w", err)
+	}
+
+	perNode, unmeasured := groupSourceVolumes(pvs)
+	if len(unmeasured) > 0 {
+		o.log.Printf("Unable to measure the usage of %d volume(s) not bound to a node directory: %s", len(unmeasured), strings.Join(unmeasured, ", "))
+	}
+
+	usage := map[string][]int64{}
+	for node, volumes := range perNode {
+		o.log.Printf("Measuring the usage of %d %q volume(s) on node %s", len(volumes), o.srcSC, node)
+		var paths []string
+		for _, vol := range volumes {
+			paths = append(paths, vol.path)
+		}
+
+		if usage[node], err = o.freeSpaceGetter.directoriesUsage(ctx, node, paths); err != nil {
+			return SourceUsage{}, err
+		}
+	}
+	return aggregateSourceUsage(perNode, usage, unmeasured), nil
+}
@@ -0,0 +1,174 @@
+package clusterspace
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_groupSourceVolumes(t *testing.T) {
+	affinity := func(node string) *corev1.VolumeNodeAffinity {
+		return &corev1.VolumeNodeAffinity{
+			Required: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{
+					{
+						MatchExpressions: []corev1.NodeSelectorRequirement{
+							{
+								Key:      corev1.LabelHostname,
+								Operator: corev1.NodeSelectorOpIn,
+								Values:   []string{node},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	pvs := map[string]corev1.PersistentVolume{
+		"pv-local": {
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-local"},
+			Spec: corev1.PersistentVolumeSpec{
+				PersistentVolumeSource: corev1.PersistentVolumeSource{
+					Local: &corev1.LocalVolumeSource{Path: "/var/local/pv-local"},
+				},
+				NodeAffinity: affinity("node0"),
+			},
+		},
+		"pv-hostpath": {
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-hostpath"},
+			Spec: corev1.PersistentVolumeSpec{
+				PersistentVolumeSource: corev1.PersistentVolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{Path: "/var/local/pv-hostpath"},
+				},
+				NodeAffinity: affinity("node0"),
+			},
+		},
+		"pv-node1": {
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-node1"},
+			Spec: corev1.PersistentVolumeSpec{
+				PersistentVolumeSource: corev1.PersistentVolumeSource{
+					Local: &corev1.LocalVolumeSource{Path: "/var/local/pv-node1"},
+				},
+				NodeAffinity: affinity("node1"),
+			},
+		},
+		"pv-no-affinity": {
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-no-affinity"},
+			Spec: corev1.PersistentVolumeSpec{
+				PersistentVolumeSource: corev1.PersistentVolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{Path: "/var/local/pv-no-affinity"},
+				},
+			},
+		},
+		"pv-csi": {
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-csi"},
+			Spec: corev1.PersistentVolumeSpec{
+				PersistentVolumeSource: corev1.PersistentVolumeSource{
+					CSI: &corev1.CSIPersistentVolumeSource{Driver: "rook-ceph.rbd.csi.ceph.com"},
+				},
+				NodeAffinity: affinity("node0"),
+			},
+		},
+	}
+
+	perNode, unmeasured := groupSourceVolumes(pvs)
+	expected := map[string][]sourceVolume{
+		"node0": {
+			{name: "pv-hostpath", path: "/var/local/pv-hostpath"},
+			{name: "pv-local", path: "/var/local/pv-local"},
+		},
+		"node1": {
+			{name: "pv-node1", path: "/var/local/pv-node1"},
+		},
+	}
+	if diff := cmp.Diff(expected, perNode, cmp.AllowUnexported(sourceVolume{})); diff != "" {
+		t.Errorf("unexpected volumes per node: %s", diff)
+	}
+	if diff := cmp.Diff([]string{"pv-csi", "pv-no-affinity"}, unmeasured); diff != "" {
+		t.Errorf("unexpected unmeasured volumes: %s", diff)
+	}
+}
+
+func Test_parseDUOutput(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		count    int
+		content  string
+		expected []int64
+		err      string
+	}{
+		{
+			name:     "should parse the usage in the provided order",
+			count:    2,
+			content:  "52428\t/volumes/1\n1024\t/volumes/0\n",
+			expected: []int64{1048576, 53686272},
+		},
+		{
+			name:     "should ignore du warnings",
+			count:    1,
+			content:  "du: cannot read directory '/volumes/0/lost+found': Permission denied\n16\t/volumes/0\n",
+			expected: []int64{16384},
+		},
+		{
+			name:    "should fail when a directory is missing",
+			count:   2,
+			content: "1024\t/volumes/0\n",
+			err:     "failed to locate disk usage of /volumes/1",
+		},
+		{
+			name:    "should fail on unexpected directories",
+			count:   1,
+			content: "1024\t/volumes/4\n",
+			err:     `unexpected directory "/volumes/4"`,
+		},
+		{
+			name:    "should fail on invalid numbers",
+			count:   1,
+			content: "1.5M\t/volumes/0\n",
+			err:     `failed to parse "1.5M" as disk usage`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			usage, err := parseDUOutput([]byte(tt.content), tt.count)
+			if err != nil {
+				if len(tt.err) == 0 {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expecting %q, %q received instead", tt.err, err)
+				}
+				return
+			}
+
+			if len(tt.err) > 0 {
+				t.Errorf("expecting error %q, nil received instead", tt.err)
+			}
+			if diff := cmp.Diff(tt.expected, usage); diff != "" {
+				t.Errorf("unexpected usage: %s", diff)
+			}
+		})
+	}
+}
+
+func Test_aggregateSourceUsage(t *testing.T) {
+	perNode := map[string][]sourceVolume{
+		"node0": {{name: "pv-a", path: "/a"}, {name: "pv-b", path: "/b"}},
+		"node1": {{name: "pv-c", path: "/c"}},
+	}
+	usage := map[string][]int64{
+		"node0": {100, 200},
+		"node1": {50},
+	}
+
+	expected := SourceUsage{
+		PerNode:    map[string]int64{"node0": 300, "node1": 50},
+		PerVolume:  map[string]int64{"pv-a": 100, "pv-b": 200, "pv-c": 50},
+		Unmeasured: []string{"pv-d"},
+	}
+	if diff := cmp.Diff(expected, aggregateSourceUsage(perNode, usage, []string{"pv-d"})); diff != "" {
+		t.Errorf("unexpected source usage: %s", diff)
+	}
+}
@@ -0,0 +1,74 @@
+package clusterspace
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// statfsMarker starts the line printed by the statfs helper (kurl_util/cmd/statfs) for each measured path.
+const statfsMarker = "KURL_STATFS"
+
+// statfsVersion is the version of the statfs line format, bumped on any incompatible change.
+const statfsVersion = "v1"
+
+// statfsCommand returns the script executed by the df container when the free space is measured with
+// the statfs helper instead of df, measuring the base path mounted under the provided path.
+func statfsCommand(binary, mountPath string) string {
+	return basePathScript(mountPath, binary+" "+mountPath)
+}
+
+// parseStatfsOutput parses the output of the statfs helper and returns the available and used space, in
+// bytes, for the provided path. the helper prints one line per path, with all amounts in bytes and the
+// path last so it may contain spaces:
+//
+// KURL_STATFS v1 <total> <free> <available> <path>
+//
+// free is the space free in the filesystem while available is the part of it usable by unprivileged
+// users (free minus the blocks reserved for root). used space is the total minus the free space, as
+// reported by df. the helper does not import this package, to keep it small, so both must be kept in
+// sync.
+func parseStatfsOutput(output []byte, path string) (int64, int64, error) {
+	scanner := bufio.NewScanner(bytes.NewBuffer(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, statfsMarker+" ") {
+			continue
+		}
+
+		words := strings.SplitN(line, " ", 6)
+		if len(words) != 6 {
+			return 0, 0, fmt.Errorf("unexpected number of fields in statfs line %q", line)
+		}
+
+		if words[1] != statfsVersion {
+			return 0, 0, fmt.Errorf("unsupported statfs format version %q", words[1])
+		}
+
+		if words[5] != path {
+			continue
+		}
+
+		var amounts [3]uint64
+		for i, word := range words[2:5] {
+			amount, err := strconv.ParseUint(word, 10, 63)
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to parse %q as a number of bytes: %w", word, err)
+			}
+			amounts[i] = amount
+		}
+
+		total, free, available := amounts[0], amounts[1], amounts[2]
+		if free > total || available > free {
+			return 0, 0, fmt.Errorf("inconsistent statfs line %q", line)
+		}
+		return int64(available), int64(total - free), nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return 0, 0, fmt.Errorf("failed to process container log: %w", err)
+	}
+	return 0, 0, fmt.Errorf("failed to locate statfs info for %s in pod log: %s", path, string(output))
+}
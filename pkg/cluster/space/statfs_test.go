@@ -0,0 +1,107 @@
+package clusterspace
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func Test_parseStatfsOutput(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		path    string
+		content string
+		err     string
+		expfree int64
+		expused int64
+	}{
+		{
+			name:    "should parse the statfs line",
+			path:    "/data",
+			content: "KURL_STATFS v1 63087357952 10559602688 7327760384 /data\n",
+			expfree: 7327760384,
+			expused: 52527755264,
+		},
+		{
+			name: "should pick the line for the requested path",
+			path: "/data",
+			content: `some noise
+KURL_STATFS v1 100 50 40 /other
+KURL_STATFS v1 1000 300 200 /data
+`,
+			expfree: 200,
+			expused: 700,
+		},
+		{
+			name:    "should parse paths with spaces",
+			path:    "/my data",
+			content: "KURL_STATFS v1 1000 300 200 /my data\n",
+			expfree: 200,
+			expused: 700,
+		},
+		{
+			name:    "should fail on unsupported versions",
+			path:    "/data",
+			content: "KURL_STATFS v2 1000 300 200 /data\n",
+			err:     `unsupported statfs format version "v2"`,
+		},
+		{
+			name:    "should fail on missing fields",
+			path:    "/data",
+			content: "KURL_STATFS v1 1000 300\n",
+			err:     "unexpected number of fields",
+		},
+		{
+			name:    "should fail on invalid numbers",
+			path:    "/data",
+			content: "KURL_STATFS v1 1000 -300 200 /data\n",
+			err:     `failed to parse "-300" as a number of bytes`,
+		},
+		{
+			name:    "should fail on inconsistent amounts",
+			path:    "/data",
+			content: "KURL_STATFS v1 1000 300 400 /data\n",
+			err:     "inconsistent statfs line",
+		},
+		{
+			name:    "should fail when the path is not found",
+			path:    "/data",
+			content: "statfs: not found\n",
+			err:     "failed to locate statfs info for /data",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			free, used, err := parseStatfsOutput([]byte(tt.content), tt.path)
+			if err != nil {
+				if len(tt.err) == 0 {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expecting %q, %q received instead", tt.err, err)
+				}
+				return
+			}
+
+			if len(tt.err) > 0 {
+				t.Errorf("expecting error %q, nil received instead", tt.err)
+			}
+			if free != tt.expfree {
+				t.Errorf("expecting free %d, received %d", tt.expfree, free)
+			}
+			if used != tt.expused {
+				t.Errorf("expecting used %d, received %d", tt.expused, used)
+			}
+		})
+	}
+}
+
+func Test_buildJobStatfs(t *testing.T) {
+	getter := OpenEBSFreeDiskSpaceGetter{opts: OpenEBSOptions{Image: "myimage:latest", Namespace: "default", Output: OpenEBSOutputOptions{StatfsBinary: "/usr/local/bin/statfs"}}}
+	job := getter.buildJob(context.Background(), "node0", "/var/local", "tmppvc")
+	args := job.Spec.Template.Spec.Containers[0].Args[0]
+	if !strings.Contains(args, "/usr/local/bin/statfs /data") {
+		t.Errorf("statfs helper not used by the df container: %s", args)
+	}
+	if strings.Contains(args, "df -B1") {
+		t.Errorf("df used along with the statfs helper: %s", args)
+	}
+}
@@ -0,0 +1,55 @@
+package clusterspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ErrStorageClassTimeout is returned when the destination storage class can't be read within the
+// configured timeout, usually a sign of an overloaded or unreachable API server.
+var ErrStorageClassTimeout = errors.New("timed out reading storage class")
+
+// getStorageClass reads the destination storage class, failing with ErrStorageClassTimeout if the
+// API server does not answer within the configured timeout. as in listNodes the request runs in a
+// separate goroutine so we don't rely on the client honoring the context deadline.
+// defaultOpenEBSStorageClassTimeout is used if no timeout has been configured.
+func (o *OpenEBSFreeDiskSpaceGetter) getStorageClass(ctx context.Context) (*storagev1.StorageClass, error) {
+	timeout := o.opts.StorageClassTimeout
+	if timeout == 0 {
+		timeout = defaultOpenEBSStorageClassTimeout
+	}
+
+	gctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type getResult struct {
+		sclass *storagev1.StorageClass
+		err    error
+	}
+
+	done := make(chan getResult, 1)
+	go func() {
+		sclass, err := o.kcli.StorageV1().StorageClasses().Get(gctx, o.opts.DstSC, metav1.GetOptions{})
+		done <- getResult{sclass: sclass, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.err == nil {
+			return res.sclass, nil
+		}
+		if ctx.Err() == nil && errors.Is(gctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w %s after %s: %s", ErrStorageClassTimeout, o.opts.DstSC, timeout, res.err)
+		}
+		return nil, res.err
+	case <-gctx.Done():
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("%w %s after %s", ErrStorageClassTimeout, o.opts.DstSC, timeout)
+	}
+}
@@ -0,0 +1,93 @@
+package clusterspace
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func Test_basePathStorageClassTimeout(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		delay   time.Duration
+		getErr  error
+		timeout error
+		err     string
+	}{
+		{
+			name: "should read the base path",
+		},
+		{
+			name:    "should time out when reading the storage class hangs",
+			delay:   time.Second,
+			timeout: ErrStorageClassTimeout,
+			err:     "failed to read destination storage class: timed out reading storage class openebs after 50ms",
+		},
+		{
+			name:   "should report api errors",
+			getErr: errors.New("connection refused"),
+			err:    "failed to read destination storage class: connection refused",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			kcli := fake.NewSimpleClientset(&storagev1.StorageClass{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "openebs",
+					Annotations: map[string]string{
+						"cas.openebs.io/config": "- name: BasePath\n  value: /var/local",
+					},
+				},
+				Provisioner: OpenEBSLocalProvisioner,
+			})
+			kcli.PrependReactor(
+				"get", "storageclasses",
+				func(action k8stesting.Action) (bool, runtime.Object, error) {
+					time.Sleep(tt.delay)
+					if tt.getErr != nil {
+						return true, nil, tt.getErr
+					}
+					return false, nil, nil
+				},
+			)
+
+			getter := OpenEBSFreeDiskSpaceGetter{
+				kcli: kcli,
+				opts: OpenEBSOptions{
+					DstSC:               "openebs",
+					StorageClassTimeout: 50 * time.Millisecond,
+				},
+				log: log.New(io.Discard, "", 0),
+			}
+
+			basePath, _, err := getter.basePath(context.Background())
+			if err != nil {
+				if tt.err == "" {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if err.Error() != tt.err {
+					t.Errorf("expecting %q, %q received instead", tt.err, err)
+				}
+				if tt.timeout != nil && !errors.Is(err, tt.timeout) {
+					t.Errorf("expecting %v to wrap %v", err, tt.timeout)
+				}
+				return
+			}
+
+			if tt.err != "" {
+				t.Fatalf("expecting error %q, nil received instead", tt.err)
+			}
+			if basePath != "/var/local" {
+				t.Errorf("unexpected base path %q", basePath)
+			}
+		})
+	}
+}
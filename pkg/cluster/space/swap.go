@@ -0,0 +1,210 @@
+package clusterspace
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// swapCommand prints the node swap devices. /proc/swaps is not namespaced so the node devices are
+// seen from inside the container, free is used where /proc/swaps is not available.
+const swapCommand = "cat /proc/swaps 2>/dev/null || free -b"
+
+// SwapPolicy defines whether swap must be enabled in the nodes.
+type SwapPolicy string
+
+const (
+	// SwapPolicyAny only reports the nodes swap, it never fails.
+	SwapPolicyAny SwapPolicy = "any"
+	// SwapPolicyRequireOff fails the nodes with swap enabled.
+	SwapPolicyRequireOff SwapPolicy = "require-off"
+	// SwapPolicyRequireOn fails the nodes without swap.
+	SwapPolicyRequireOn SwapPolicy = "require-on"
+)
+
+// SwapPolicies are all the supported swap policies.
+var SwapPolicies = []SwapPolicy{SwapPolicyAny, SwapPolicyRequireOff, SwapPolicyRequireOn}
+
+// NodeSwap holds the swap space of a node, in bytes. Devices holds the swap partitions and files
+// when they are known, they are not when the swap has been read from the free command output.
+type NodeSwap struct {
+	Total   int64    `json:"total"`
+	Used    int64    `json:"used"`
+	Devices []string `json:"devices,omitempty"`
+}
+
+// Enabled returns true if the node has any swap space.
+func (s NodeSwap) Enabled() bool {
+	return s.Total > 0
+}
+
+// Check returns an error if the node swap violates the provided policy.
+func (s NodeSwap) Check(policy SwapPolicy) error {
+	switch policy {
+	case SwapPolicyRequireOff:
+		if s.Enabled() {
+			return fmt.Errorf("swap is enabled but required to be off")
+		}
+	case SwapPolicyRequireOn:
+		if !s.Enabled() {
+			return fmt.Errorf("swap is disabled but required to be on")
+		}
+	case SwapPolicyAny:
+	default:
+		return fmt.Errorf("invalid swap policy %q", policy)
+	}
+	return nil
+}
+
+// parseSwapOutput parses the output of the swapCommand. it is either the content of /proc/swaps,
+// with sizes in KiB:
+//
+// Filename				Type		Size		Used		Priority
+// /dev/sda3                               partition	8388604		0		-2
+//
+// or, if /proc/swaps could not be read, the output of 'free -b':
+//
+// total        used        free      shared  buff/cache   available
+// Mem:      8246116352  1203372032  5311266816     1114112  1731477504  6769852416
+// Swap:     2147479552           0  2147479552
+//
+// lines that don't belong to either format are ignored. an empty /proc/swaps (header only) means
+// swap is disabled.
+func parseSwapOutput(output []byte) (NodeSwap, error) {
+	var swap NodeSwap
+	var found bool
+	scanner := bufio.NewScanner(bytes.NewBuffer(output))
+	for scanner.Scan() {
+		words := strings.Fields(scanner.Text())
+		switch {
+		case len(words) == 0:
+			continue
+
+		case words[0] == "Filename" && len(words) >= 4 && words[2] == "Size" && words[3] == "Used":
+			found = true
+
+		case words[0] == "Swap:":
+			if len(words) < 3 {
+				return NodeSwap{}, fmt.Errorf("expected total and used swap in free output, found %q", scanner.Text())
+			}
+			total, err := strconv.ParseInt(words[1], 10, 64)
+			if err != nil {
+				return NodeSwap{}, fmt.Errorf("failed to parse %q as total swap: %w", words[1], err)
+			}
+			used, err := strconv.ParseInt(words[2], 10, 64)
+			if err != nil {
+				return NodeSwap{}, fmt.Errorf("failed to parse %q as used swap: %w", words[2], err)
+			}
+			return NodeSwap{Total: total, Used: used}, nil
+
+		case found && strings.HasPrefix(words[0], "/"):
+			if len(words) < 4 {
+				return NodeSwap{}, fmt.Errorf("expected size and usage of swap device %s, found %q", words[0], scanner.Text())
+			}
+			size, err := strconv.ParseInt(words[2], 10, 64)
+			if err != nil {
+				return NodeSwap{}, fmt.Errorf("failed to parse %q as swap device %s size: %w", words[2], words[0], err)
+			}
+			used, err := strconv.ParseInt(words[3], 10, 64)
+			if err != nil {
+				return NodeSwap{}, fmt.Errorf("failed to parse %q as swap device %s usage: %w", words[3], words[0], err)
+			}
+			swap.Total += size * 1024
+			swap.Used += used * 1024
+			swap.Devices = append(swap.Devices, unescapeMountPath(words[0]))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return NodeSwap{}, fmt.Errorf("failed to process container log: %w", err)
+	}
+
+	if !found {
+		return NodeSwap{}, fmt.Errorf("failed to locate swap info in pod log: %s", string(output))
+	}
+	return swap, nil
+}
+
+// buildSwapJob returns a job that prints the swap devices of the provided node.
+func (o *OpenEBSFreeDiskSpaceGetter) buildSwapJob(node string) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        jobName(node),
+			Namespace:   o.opts.Namespace,
+			Labels:      o.jobLabels(node),
+			Annotations: o.jobAnnotations(),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          ptr.To(int32(1)),
+			ActiveDeadlineSeconds: activeDeadlineSeconds(o.opts.Job.Timeout),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      o.jobLabels(node),
+					Annotations: o.jobAnnotations(),
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:    corev1.RestartPolicyNever,
+					Tolerations:      o.tolerations,
+					Affinity:         nodeAffinity(node),
+					ImagePullSecrets: o.imagePullSecrets(),
+					Containers: []corev1.Container{
+						{
+							Name:    "swap",
+							Image:   o.opts.Image,
+							Command: []string{"/bin/sh", "-c"},
+							Args:    []string{encodeOutputCommand(swapCommand)},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// nodeSwap runs a swap job in the provided node and returns its swap space.
+func (o *OpenEBSFreeDiskSpaceGetter) nodeSwap(ctx context.Context, node string) (NodeSwap, error) {
+	job := o.buildSwapJob(node)
+	out, status, err := o.runJob(ctx, job)
+	if err != nil {
+		o.logContainersState(out, status)
+		return NodeSwap{}, fmt.Errorf(
+			"failed to run job %s/%s on node %s: %w", job.Namespace, job.Name, node, err,
+		)
+	}
+
+	swapOutput, err := decodeOutput(out["swap"])
+	if err != nil {
+		o.logContainersState(out, status)
+		return NodeSwap{}, fmt.Errorf("failed to read node %s swap output: %w", node, err)
+	}
+
+	swap, err := parseSwapOutput(swapOutput)
+	if err != nil {
+		o.logContainersState(out, status)
+		return NodeSwap{}, fmt.Errorf("failed to parse node %s swap output: %w", node, err)
+	}
+	return swap, nil
+}
+
+// NodesSwap measures the swap space of every node by running a job in each of them. returns the swap
+// of each node, indexed by node name. nodes that can't run linux jobs are not measured, they are
+// available through SkippedNodes.
+func (r *NodeJobRunner) NodesSwap(ctx context.Context) (map[string]NodeSwap, error) {
+	nodes, err := r.jobs.listNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return runNodeJobs(ctx, r, nodes.Items, func(ctx context.Context, node string) (NodeSwap, error) {
+		r.jobs.log.Printf("Measuring swap on node %s", node)
+		return r.jobs.nodeSwap(ctx, node)
+	})
+}
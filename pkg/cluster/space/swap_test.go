@@ -0,0 +1,146 @@
+package clusterspace
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_parseSwapOutput(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		content  string
+		expected NodeSwap
+		err      string
+	}{
+		{
+			name: "should sum all the devices in /proc/swaps",
+			content: `Filename				Type		Size		Used		Priority
+/dev/sda3                               partition	8388604		1024		-2
+/swapfile                               file		2097148		0		-3
+`,
+			expected: NodeSwap{
+				Total:   10485752 * 1024,
+				Used:    1024 * 1024,
+				Devices: []string{"/dev/sda3", "/swapfile"},
+			},
+		},
+		{
+			name:     "should report swap off when /proc/swaps only has the header",
+			content:  "Filename\t\t\t\tType\t\tSize\t\tUsed\t\tPriority\n",
+			expected: NodeSwap{},
+		},
+		{
+			name: "should unescape device paths",
+			content: `Filename				Type		Size		Used		Priority
+/var/swap\040files/swap0                file		1024		0		-2
+`,
+			expected: NodeSwap{Total: 1048576, Devices: []string{"/var/swap files/swap0"}},
+		},
+		{
+			name: "should ignore noise printed by the container",
+			content: `WARNING: image platform does not match the host
+Filename				Type		Size		Used		Priority
+/dev/dm-1                               partition	4194300		0		-2
+`,
+			expected: NodeSwap{Total: 4194300 * 1024, Devices: []string{"/dev/dm-1"}},
+		},
+		{
+			name: "should parse the free output",
+			content: `               total        used        free      shared  buff/cache   available
+Mem:      8246116352  1203372032  5311266816     1114112  1731477504  6769852416
+Swap:     2147479552    52428800  2095050752
+`,
+			expected: NodeSwap{Total: 2147479552, Used: 52428800},
+		},
+		{
+			name: "should report swap off from the free output",
+			content: `               total        used        free      shared  buff/cache   available
+Mem:      8246116352  1203372032  5311266816     1114112  1731477504  6769852416
+Swap:              0           0           0
+`,
+			expected: NodeSwap{},
+		},
+		{
+			name:    "should fail with empty output",
+			content: "",
+			err:     "failed to locate swap info in pod log",
+		},
+		{
+			name:    "should fail on unexpected output",
+			content: "cat: can't open '/proc/swaps': No such file or directory\nsh: free: not found\n",
+			err:     "failed to locate swap info in pod log",
+		},
+		{
+			name: "should fail on invalid device sizes",
+			content: `Filename				Type		Size		Used		Priority
+/dev/sda3                               partition	8G		0		-2
+`,
+			err: `failed to parse "8G" as swap device /dev/sda3 size`,
+		},
+		{
+			name: "should fail on truncated device lines",
+			content: `Filename				Type		Size		Used		Priority
+/dev/sda3                               partition
+`,
+			err: "expected size and usage of swap device /dev/sda3",
+		},
+		{
+			name:    "should fail on invalid free amounts",
+			content: "Swap:     2.0Gi           0  2.0Gi\n",
+			err:     `failed to parse "2.0Gi" as total swap`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			swap, err := parseSwapOutput([]byte(tt.content))
+			if err != nil {
+				if len(tt.err) == 0 {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expecting %q, %q received instead", tt.err, err)
+				}
+				return
+			}
+
+			if len(tt.err) > 0 {
+				t.Errorf("expecting error %q, nil received instead", tt.err)
+			}
+			if diff := cmp.Diff(tt.expected, swap); diff != "" {
+				t.Errorf("unexpected swap: %s", diff)
+			}
+		})
+	}
+}
+
+func TestNodeSwapCheck(t *testing.T) {
+	on := NodeSwap{Total: 1024}
+	off := NodeSwap{}
+	for _, tt := range []struct {
+		name   string
+		swap   NodeSwap
+		policy SwapPolicy
+		err    string
+	}{
+		{name: "any should accept swap on", swap: on, policy: SwapPolicyAny},
+		{name: "any should accept swap off", swap: off, policy: SwapPolicyAny},
+		{name: "require-off should accept swap off", swap: off, policy: SwapPolicyRequireOff},
+		{name: "require-off should reject swap on", swap: on, policy: SwapPolicyRequireOff, err: "swap is enabled but required to be off"},
+		{name: "require-on should accept swap on", swap: on, policy: SwapPolicyRequireOn},
+		{name: "require-on should reject swap off", swap: off, policy: SwapPolicyRequireOn, err: "swap is disabled but required to be on"},
+		{name: "should reject unknown policies", swap: off, policy: "sometimes", err: `invalid swap policy "sometimes"`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.swap.Check(tt.policy)
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("expected error %q, received %v", tt.err, err)
+			}
+		})
+	}
+}
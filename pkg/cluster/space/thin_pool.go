@@ -0,0 +1,106 @@
+package clusterspace
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// lvsCommand lists all logical volumes in the node using the host lvs binary. the output is parsed by
+// parseLVSOutput. if lvm is not installed in the node nothing is printed.
+const lvsCommand = "nsenter --target 1 --mount -- lvs --noheadings --units b --nosuffix --separator , " +
+	"-o vg_name,lv_name,lv_attr,lv_size,data_percent,pool_lv 2>/dev/null || true"
+
+// ThinPool represents an LVM thin pool in a node. Size is the physical size of the pool while
+// VirtualSize is the sum of the sizes of all thin volumes allocated from it. DataPercent is the
+// percentage of the pool physical space already in use.
+type ThinPool struct {
+	VG          string  `json:"vg"`
+	Name        string  `json:"name"`
+	Size        int64   `json:"size"`
+	VirtualSize int64   `json:"virtualSize"`
+	DataPercent float64 `json:"dataPercent"`
+}
+
+// Overcommit returns the ratio between the space promised to the thin volumes and the physical size
+// of the pool. values bigger than 1 mean the pool is overcommitted.
+func (t ThinPool) Overcommit() float64 {
+	if t.Size == 0 {
+		return 0
+	}
+	return float64(t.VirtualSize) / float64(t.Size)
+}
+
+// String returns the thin pool name in the vg/lv format.
+func (t ThinPool) String() string {
+	return fmt.Sprintf("%s/%s", t.VG, t.Name)
+}
+
+// parseLVSOutput parses the output of the lvsCommand and returns the thin pools found. the output is
+// expected to contain one logical volume per line as in the example below:
+//
+// vg0,pool0,twi-aotz--,107374182400,45.67,
+// vg0,thin0,Vwi-aotz--,161061273600,30.00,pool0
+// vg0,root,-wi-ao----,21474836480,,
+//
+// thin pools are identified by the 't' volume type in the lv_attr column while thin volumes are
+// identified by the 'V' volume type.
+func parseLVSOutput(output []byte) ([]ThinPool, error) {
+	var order []string
+	pools := map[string]*ThinPool{}
+	virtual := map[string]int64{}
+
+	buf := bytes.NewBuffer(output)
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Split(line, ",")
+		if len(fields) != 6 {
+			return nil, fmt.Errorf("unexpected number of fields in lvs line %q", line)
+		}
+
+		vg, name, attr := fields[0], fields[1], fields[2]
+		if attr == "" || (attr[0] != 't' && attr[0] != 'V') {
+			continue
+		}
+
+		size, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %q as logical volume size: %w", fields[3], err)
+		}
+
+		if attr[0] == 'V' {
+			virtual[fmt.Sprintf("%s/%s", vg, fields[5])] += size
+			continue
+		}
+
+		var percent float64
+		if fields[4] != "" {
+			if percent, err = strconv.ParseFloat(fields[4], 64); err != nil {
+				return nil, fmt.Errorf("failed to parse %q as thin pool data percent: %w", fields[4], err)
+			}
+		}
+
+		pool := &ThinPool{VG: vg, Name: name, Size: size, DataPercent: percent}
+		order = append(order, pool.String())
+		pools[pool.String()] = pool
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to process container log: %w", err)
+	}
+
+	var result []ThinPool
+	for _, key := range order {
+		pool := pools[key]
+		pool.VirtualSize = virtual[key]
+		result = append(result, *pool)
+	}
+	return result, nil
+}
@@ -0,0 +1,96 @@
+package clusterspace
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_parseLVSOutput(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		content  []byte
+		err      string
+		expected []ThinPool
+	}{
+		{
+			name:    "should return nothing when lvm is not installed",
+			content: []byte(``),
+		},
+		{
+			name: "should ignore regular logical volumes",
+			content: []byte(`  vg0,root,-wi-ao----,21474836480,,
+  vg0,swap,-wi-ao----,2147483648,,`),
+		},
+		{
+			name: "should parse thin pools and sum their thin volumes",
+			content: []byte(`  vg0,pool0,twi-aotz--,107374182400,45.67,
+  vg0,thin0,Vwi-aotz--,107374182400,30.00,pool0
+  vg0,thin1,Vwi-aotz--,53687091200,80.00,pool0
+  vg0,root,-wi-ao----,21474836480,,
+  vg1,pool0,twi-aotz--,10737418240,0.00,
+`),
+			expected: []ThinPool{
+				{
+					VG:          "vg0",
+					Name:        "pool0",
+					Size:        107374182400,
+					VirtualSize: 161061273600,
+					DataPercent: 45.67,
+				},
+				{
+					VG:   "vg1",
+					Name: "pool0",
+					Size: 10737418240,
+				},
+			},
+		},
+		{
+			name:    "should fail with unexpected number of fields",
+			content: []byte(`vg0 pool0 twi-aotz-- 107374182400 45.67`),
+			err:     "unexpected number of fields",
+		},
+		{
+			name:    "should fail with invalid size",
+			content: []byte(`vg0,pool0,twi-aotz--,100g,45.67,`),
+			err:     `failed to parse "100g" as logical volume size`,
+		},
+		{
+			name:    "should fail with invalid data percent",
+			content: []byte(`vg0,pool0,twi-aotz--,100,abc,`),
+			err:     `failed to parse "abc" as thin pool data percent`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			pools, err := parseLVSOutput(tt.content)
+			if err != nil {
+				if len(tt.err) == 0 {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expecting %q, %q received instead", tt.err, err)
+				}
+				return
+			}
+
+			if len(tt.err) > 0 {
+				t.Errorf("expecting error %q, nil received instead", tt.err)
+			}
+
+			if diff := cmp.Diff(tt.expected, pools); diff != "" {
+				t.Errorf("unexpected thin pools: %s", diff)
+			}
+		})
+	}
+}
+
+func TestThinPoolOvercommit(t *testing.T) {
+	pool := ThinPool{Size: 100, VirtualSize: 150}
+	if pool.Overcommit() != 1.5 {
+		t.Errorf("expected overcommit 1.5, %v received", pool.Overcommit())
+	}
+
+	if (ThinPool{}).Overcommit() != 0 {
+		t.Errorf("expected overcommit 0 for empty pool")
+	}
+}
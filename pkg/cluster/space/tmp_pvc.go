@@ -0,0 +1,92 @@
+package clusterspace
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// tmpPVCCleanupBackoff is how long we wait before retrying a temporary pvc deletion that failed
+// with a transient error, or before checking again if a temporary pv is gone. it is doubled after
+// every attempt up to tmpPVCCleanupMaxBackoff.
+var tmpPVCCleanupBackoff = time.Second
+
+// tmpPVCCleanupMaxBackoff caps the wait between temporary pvcs cleanup attempts.
+var tmpPVCCleanupMaxBackoff = 5 * time.Second
+
+// nextTmpPVCCleanupBackoff returns the wait following the provided one, see tmpPVCCleanupBackoff.
+func nextTmpPVCCleanupBackoff(backoff time.Duration) time.Duration {
+	return min(backoff*2, tmpPVCCleanupMaxBackoff)
+}
+
+// isTransientAPIError returns true if the provided api error is expected to go away by itself, e.g.
+// a conflict with a concurrent update or the api server throttling or timing out requests.
+func isTransientAPIError(err error) bool {
+	return errors.IsConflict(err) ||
+		errors.IsTooManyRequests(err) ||
+		errors.IsServerTimeout(err) ||
+		errors.IsTimeout(err) ||
+		errors.IsServiceUnavailable(err) ||
+		errors.IsInternalError(err)
+}
+
+// createTmpPVC creates the provided temporary pvc. if a pvc with the same name already exists, e.g.
+// left behind by a previous run when pvcs are reused, it is adopted as long as its spec matches the
+// expected one. an error is returned if the existing pvc conflicts with it.
+func (o *OpenEBSFreeDiskSpaceGetter) createTmpPVC(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (*corev1.PersistentVolumeClaim, error) {
+	created, err := o.kcli.CoreV1().PersistentVolumeClaims(pvc.Namespace).Create(ctx, pvc, metav1.CreateOptions{})
+	if err == nil {
+		return created, nil
+	}
+
+	if !errors.IsAlreadyExists(err) {
+		return nil, err
+	}
+
+	existing, err := o.kcli.CoreV1().PersistentVolumeClaims(pvc.Namespace).Get(ctx, pvc.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing pvc %s/%s: %w", pvc.Namespace, pvc.Name, err)
+	}
+
+	if conflicts := tmpPVCConflicts(pvc, existing); len(conflicts) > 0 {
+		return nil, fmt.Errorf(
+			"pvc %s/%s already exists and conflicts with the expected one: %s",
+			pvc.Namespace, pvc.Name, strings.Join(conflicts, ", "),
+		)
+	}
+
+	o.log.Printf("Adopting existing temporary pvc %s/%s", existing.Namespace, existing.Name)
+	return existing, nil
+}
+
+// tmpPVCConflicts returns the differences between the expected temporary pvc and an existing one
+// that prevent the existing one from being adopted.
+func tmpPVCConflicts(expected, existing *corev1.PersistentVolumeClaim) []string {
+	var conflicts []string
+	if existing.DeletionTimestamp != nil {
+		conflicts = append(conflicts, "pvc is being deleted")
+	}
+
+	expectedSC, existingSC := ptr.Deref(expected.Spec.StorageClassName, ""), ptr.Deref(existing.Spec.StorageClassName, "")
+	if expectedSC != existingSC {
+		conflicts = append(conflicts, fmt.Sprintf("storage class is %q, expected %q", existingSC, expectedSC))
+	}
+
+	if !slices.Equal(expected.Spec.AccessModes, existing.Spec.AccessModes) {
+		conflicts = append(conflicts, fmt.Sprintf("access modes are %v, expected %v", existing.Spec.AccessModes, expected.Spec.AccessModes))
+	}
+
+	expectedSize := expected.Spec.Resources.Requests[corev1.ResourceStorage]
+	existingSize := existing.Spec.Resources.Requests[corev1.ResourceStorage]
+	if expectedSize.Cmp(existingSize) != 0 {
+		conflicts = append(conflicts, fmt.Sprintf("storage request is %s, expected %s", existingSize.String(), expectedSize.String()))
+	}
+	return conflicts
+}
@@ -0,0 +1,253 @@
+package clusterspace
+
+import (
+	"context"
+	"io"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
+)
+
+func Test_createTmpPVC(t *testing.T) {
+	existing := func(mutate func(*corev1.PersistentVolumeClaim)) *corev1.PersistentVolumeClaim {
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "disk-free-node0",
+				Namespace: "default",
+				UID:       "previous-run",
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				StorageClassName: ptr.To("openebs"),
+				AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceStorage: resource.MustParse("1Mi"),
+					},
+				},
+			},
+		}
+		if mutate != nil {
+			mutate(pvc)
+		}
+		return pvc
+	}
+
+	for _, tt := range []struct {
+		name    string
+		objs    []runtime.Object
+		adopted bool
+		err     string
+	}{
+		{
+			name: "should create the pvc if it does not exist",
+		},
+		{
+			name:    "should adopt an existing pvc with a matching spec",
+			objs:    []runtime.Object{existing(nil)},
+			adopted: true,
+		},
+		{
+			name: "should adopt an existing pvc whose request uses a different notation",
+			objs: []runtime.Object{existing(func(pvc *corev1.PersistentVolumeClaim) {
+				pvc.Spec.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("1048576")
+			})},
+			adopted: true,
+		},
+		{
+			name: "should fail if the existing pvc uses a different storage class",
+			objs: []runtime.Object{existing(func(pvc *corev1.PersistentVolumeClaim) {
+				pvc.Spec.StorageClassName = ptr.To("longhorn")
+			})},
+			err: `pvc default/disk-free-node0 already exists and conflicts with the expected one: storage class is "longhorn", expected "openebs"`,
+		},
+		{
+			name: "should report all conflicts",
+			objs: []runtime.Object{existing(func(pvc *corev1.PersistentVolumeClaim) {
+				pvc.Spec.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}
+				pvc.Spec.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("10Gi")
+			})},
+			err: "access modes are [ReadWriteMany], expected [ReadWriteOnce], storage request is 10Gi, expected 1Mi",
+		},
+		{
+			name: "should fail if the existing pvc is being deleted",
+			objs: []runtime.Object{existing(func(pvc *corev1.PersistentVolumeClaim) {
+				pvc.DeletionTimestamp = &metav1.Time{}
+				pvc.Finalizers = []string{"kubernetes.io/pvc-protection"}
+			})},
+			err: "pvc is being deleted",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			getter := OpenEBSFreeDiskSpaceGetter{
+				kcli: fake.NewSimpleClientset(tt.objs...),
+				log:  log.New(io.Discard, "", 0),
+				opts: OpenEBSOptions{
+					Namespace: "default",
+					DstSC:     "openebs",
+					PVC: OpenEBSPVCOptions{
+						Reuse: true,
+					},
+				},
+			}
+
+			pvc, err := getter.createTmpPVC(context.Background(), getter.buildTmpPVC("node0"))
+			if err != nil {
+				if len(tt.err) == 0 {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expecting %q, %q received instead", tt.err, err)
+				}
+				return
+			}
+
+			if len(tt.err) > 0 {
+				t.Fatalf("expecting error %q, nil received instead", tt.err)
+			}
+
+			if pvc.Name != "disk-free-node0" {
+				t.Errorf("expected pvc named after the node, %s received", pvc.Name)
+			}
+			if adopted := pvc.UID == "previous-run"; adopted != tt.adopted {
+				t.Errorf("expected adopted to be %v, pvc %+v received", tt.adopted, pvc.ObjectMeta)
+			}
+		})
+	}
+}
+
+func Test_deleteTmpPVCsRetries(t *testing.T) {
+	backoff, maxBackoff := tmpPVCCleanupBackoff, tmpPVCCleanupMaxBackoff
+	tmpPVCCleanupBackoff, tmpPVCCleanupMaxBackoff = 10*time.Millisecond, 40*time.Millisecond
+	defer func() {
+		tmpPVCCleanupBackoff, tmpPVCCleanupMaxBackoff = backoff, maxBackoff
+	}()
+
+	gr := corev1.Resource("persistentvolumeclaims")
+	for _, tt := range []struct {
+		name     string
+		failures int
+		failWith error
+		attempts int
+		deleted  bool
+		err      string
+	}{
+		{
+			name:     "should delete the pvc at once",
+			attempts: 1,
+			deleted:  true,
+		},
+		{
+			name:     "should retry conflicts",
+			failures: 2,
+			failWith: apierrors.NewConflict(gr, "pvc", nil),
+			attempts: 3,
+			deleted:  true,
+		},
+		{
+			name:     "should retry throttled requests",
+			failures: 3,
+			failWith: apierrors.NewTooManyRequests("slow down", 0),
+			attempts: 4,
+			deleted:  true,
+		},
+		{
+			name:     "should not retry non transient errors",
+			failures: 100,
+			failWith: apierrors.NewForbidden(gr, "pvc", nil),
+			attempts: 1,
+		},
+		{
+			name:     "should timeout if the pvc can't be deleted",
+			failures: 1000,
+			failWith: apierrors.NewServerTimeout(gr, "delete", 0),
+			err:      "failed to delete pvc pvc: timeout",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "pvc", Namespace: "default"}}
+			kcli := fake.NewSimpleClientset(pvc)
+
+			var attempts int
+			kcli.PrependReactor("delete", "persistentvolumeclaims", func(k8stesting.Action) (bool, runtime.Object, error) {
+				attempts++
+				if attempts <= tt.failures {
+					return true, nil, tt.failWith
+				}
+				return false, nil, nil
+			})
+
+			getter := OpenEBSFreeDiskSpaceGetter{
+				opts: OpenEBSOptions{
+					PVC: OpenEBSPVCOptions{
+						DeletePVTimeout: 300 * time.Millisecond,
+					},
+					Namespace: "default",
+				},
+				kcli: kcli,
+				log:  log.New(io.Discard, "", 0),
+			}
+
+			err := getter.deleteTmpPVCs([]*corev1.PersistentVolumeClaim{pvc})
+			if err != nil {
+				if len(tt.err) == 0 {
+					t.Fatalf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expecting %q, %q received instead", tt.err, err)
+				}
+				return
+			}
+			if len(tt.err) > 0 {
+				t.Fatalf("expecting error %q, nil received instead", tt.err)
+			}
+
+			if attempts != tt.attempts {
+				t.Errorf("expected %d delete attempts, %d made", tt.attempts, attempts)
+			}
+
+			_, err = kcli.CoreV1().PersistentVolumeClaims("default").Get(context.Background(), "pvc", metav1.GetOptions{})
+			if deleted := apierrors.IsNotFound(err); deleted != tt.deleted {
+				t.Errorf("expected pvc deleted to be %v", tt.deleted)
+			}
+		})
+	}
+}
+
+func Test_deleteTmpPVCsCleanupClient(t *testing.T) {
+	pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "pvc", Namespace: "default"}}
+	kcli := fake.NewSimpleClientset()
+	kcli.PrependReactor("*", "*", func(k8stesting.Action) (bool, runtime.Object, error) {
+		t.Errorf("measuring client used during the cleanup")
+		return true, nil, apierrors.NewServiceUnavailable("unhealthy")
+	})
+	cleanupKcli := fake.NewSimpleClientset(pvc)
+
+	getter := OpenEBSFreeDiskSpaceGetter{
+		opts: OpenEBSOptions{
+			PVC: OpenEBSPVCOptions{
+				DeletePVTimeout: 300 * time.Millisecond,
+			},
+			CleanupClient: cleanupKcli,
+			Namespace:     "default",
+		},
+		kcli: kcli,
+		log:  log.New(io.Discard, "", 0),
+	}
+	if err := getter.deleteTmpPVCs([]*corev1.PersistentVolumeClaim{pvc}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := cleanupKcli.CoreV1().PersistentVolumeClaims("default").Get(
+		context.Background(), "pvc", metav1.GetOptions{},
+	); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the pvc to be deleted by the cleanup client, get returned: %v", err)
+	}
+}
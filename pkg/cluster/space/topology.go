@@ -0,0 +1,103 @@
+package clusterspace
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TopologySegment groups the nodes matching one of the allowed topologies of a storage class. Free is
+// the sum of the free space on all nodes in the segment while MaxNodeFree is the biggest free space
+// found in a single node (local volumes can't span multiple nodes).
+type TopologySegment struct {
+	Name        string   `json:"name"`
+	Nodes       []string `json:"nodes"`
+	Free        int64    `json:"free"`
+	MaxNodeFree int64    `json:"maxNodeFree"`
+}
+
+// topologyTermName returns a readable name for the provided topology selector term.
+func topologyTermName(term corev1.TopologySelectorTerm) string {
+	var parts []string
+	for _, expr := range term.MatchLabelExpressions {
+		parts = append(parts, fmt.Sprintf("%s in (%s)", expr.Key, strings.Join(expr.Values, ",")))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// nodeMatchesTopologyTerm returns true if the node labels satisfy all expressions in the term.
+func nodeMatchesTopologyTerm(node corev1.Node, term corev1.TopologySelectorTerm) bool {
+	for _, expr := range term.MatchLabelExpressions {
+		value, ok := node.Labels[expr.Key]
+		if !ok {
+			return false
+		}
+
+		var found bool
+		for _, allowed := range expr.Values {
+			if value == allowed {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// GroupByTopology groups the provided volumes by the allowed topologies of the storage class. nodes not
+// matching any of the allowed topologies are left out as no volume can be provisioned on them. returns
+// nil if the storage class does not restrict its topologies.
+func GroupByTopology(sclass *storagev1.StorageClass, nodes []corev1.Node, volumes map[string]OpenEBSVolume) []TopologySegment {
+	if len(sclass.AllowedTopologies) == 0 {
+		return nil
+	}
+
+	segments := []TopologySegment{}
+	for _, term := range sclass.AllowedTopologies {
+		segment := TopologySegment{Name: topologyTermName(term), Nodes: []string{}}
+		for _, node := range nodes {
+			vol, ok := volumes[node.Name]
+			if !ok || !nodeMatchesTopologyTerm(node, term) {
+				continue
+			}
+
+			segment.Nodes = append(segment.Nodes, node.Name)
+			segment.Free += vol.Free
+			if vol.Free > segment.MaxNodeFree {
+				segment.MaxNodeFree = vol.Free
+			}
+		}
+		sort.Strings(segment.Nodes)
+		segments = append(segments, segment)
+	}
+	return segments
+}
+
+// TopologySegments returns the provided volumes grouped by the storage class allowed topologies. capacity
+// is only meaningful where a pod would actually be scheduled so this is only done for storage classes with
+// WaitForFirstConsumer volume binding mode. returns nil if the volumes should be evaluated per node.
+func (o *OpenEBSFreeDiskSpaceGetter) TopologySegments(ctx context.Context, volumes map[string]OpenEBSVolume) ([]TopologySegment, error) {
+	sclass, err := o.kcli.StorageV1().StorageClasses().Get(ctx, o.opts.DstSC, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read storage class: %w", err)
+	}
+
+	mode := sclass.VolumeBindingMode
+	if mode == nil || *mode != storagev1.VolumeBindingWaitForFirstConsumer {
+		return nil, nil
+	}
+
+	nodes, err := o.listNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return GroupByTopology(sclass, nodes.Items, volumes), nil
+}
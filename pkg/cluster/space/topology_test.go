@@ -0,0 +1,109 @@
+package clusterspace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestTopologySegments(t *testing.T) {
+	node := func(name, zone string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{"topology.kubernetes.io/zone": zone},
+			},
+		}
+	}
+
+	volumes := map[string]OpenEBSVolume{
+		"node0": {Free: 100},
+		"node1": {Free: 200},
+		"node2": {Free: 50},
+		"node3": {Free: 1000},
+	}
+
+	waitForConsumer := storagev1.VolumeBindingWaitForFirstConsumer
+	immediate := storagev1.VolumeBindingImmediate
+	topologies := []corev1.TopologySelectorTerm{
+		{
+			MatchLabelExpressions: []corev1.TopologySelectorLabelRequirement{
+				{Key: "topology.kubernetes.io/zone", Values: []string{"zone-a"}},
+			},
+		},
+		{
+			MatchLabelExpressions: []corev1.TopologySelectorLabelRequirement{
+				{Key: "topology.kubernetes.io/zone", Values: []string{"zone-b", "zone-c"}},
+			},
+		},
+	}
+
+	for _, tt := range []struct {
+		name     string
+		sclass   *storagev1.StorageClass
+		expected []TopologySegment
+	}{
+		{
+			name: "should group nodes by allowed topologies",
+			sclass: &storagev1.StorageClass{
+				ObjectMeta:        metav1.ObjectMeta{Name: "openebs"},
+				VolumeBindingMode: &waitForConsumer,
+				AllowedTopologies: topologies,
+			},
+			expected: []TopologySegment{
+				{
+					Name:        "topology.kubernetes.io/zone in (zone-a)",
+					Nodes:       []string{"node0", "node1"},
+					Free:        300,
+					MaxNodeFree: 200,
+				},
+				{
+					Name:        "topology.kubernetes.io/zone in (zone-b,zone-c)",
+					Nodes:       []string{"node2"},
+					Free:        50,
+					MaxNodeFree: 50,
+				},
+			},
+		},
+		{
+			name: "should not group for immediate binding mode",
+			sclass: &storagev1.StorageClass{
+				ObjectMeta:        metav1.ObjectMeta{Name: "openebs"},
+				VolumeBindingMode: &immediate,
+				AllowedTopologies: topologies,
+			},
+		},
+		{
+			name: "should not group without allowed topologies",
+			sclass: &storagev1.StorageClass{
+				ObjectMeta:        metav1.ObjectMeta{Name: "openebs"},
+				VolumeBindingMode: &waitForConsumer,
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			kcli := fake.NewSimpleClientset(
+				tt.sclass,
+				node("node0", "zone-a"),
+				node("node1", "zone-a"),
+				node("node2", "zone-b"),
+				node("node3", "zone-d"),
+			)
+
+			getter := OpenEBSFreeDiskSpaceGetter{kcli: kcli, opts: OpenEBSOptions{DstSC: "openebs"}}
+			segments, err := getter.TopologySegments(context.Background(), volumes)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if diff := cmp.Diff(tt.expected, segments); diff != "" {
+				t.Errorf("unexpected segments: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package clusterspace
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// MinKubernetesVersion is the oldest kubernetes version the getter supports, the oldest one
+// packaged by kurl.
+const MinKubernetesVersion = "1.16.0"
+
+// requiredAPI is an api resource the getter depends on.
+type requiredAPI struct {
+	groupVersion string
+	resource     string
+}
+
+// String returns the resource and its group version, e.g. "jobs in batch/v1".
+func (r requiredAPI) String() string {
+	return fmt.Sprintf("%s in %s", r.resource, r.groupVersion)
+}
+
+// requiredAPIs returns the api resources the getter uses. jobs are not required when the df
+// workload runs as bare pods.
+func (o *OpenEBSFreeDiskSpaceGetter) requiredAPIs() []requiredAPI {
+	apis := []requiredAPI{
+		{groupVersion: "v1", resource: "nodes"},
+		{groupVersion: "v1", resource: "pods"},
+		{groupVersion: "v1", resource: "persistentvolumes"},
+		{groupVersion: "v1", resource: "persistentvolumeclaims"},
+		{groupVersion: "storage.k8s.io/v1", resource: "storageclasses"},
+	}
+	if !o.opts.Job.RunAsPod {
+		apis = append(apis, requiredAPI{groupVersion: "batch/v1", resource: "jobs"})
+	}
+	return apis
+}
+
+// Validate verifies that the cluster is compatible with the getter before anything is created in
+// it: the server version must be at least MinKubernetesVersion and all the api resources the getter
+// uses must be served. a descriptive error is returned otherwise.
+func (o *OpenEBSFreeDiskSpaceGetter) Validate(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	info, err := o.kcli.Discovery().ServerVersion()
+	if err != nil {
+		return fmt.Errorf("failed to read kubernetes server version: %w", err)
+	}
+
+	current, err := version.ParseGeneric(info.GitVersion)
+	if err != nil {
+		return fmt.Errorf("failed to parse kubernetes server version %q: %w", info.GitVersion, err)
+	}
+
+	if !current.AtLeast(version.MustParseGeneric(MinKubernetesVersion)) {
+		return fmt.Errorf(
+			"kubernetes version %s is not supported, the minimum supported version is %s",
+			info.GitVersion, MinKubernetesVersion,
+		)
+	}
+
+	served := map[string]map[string]bool{}
+	var missing []string
+	for _, api := range o.requiredAPIs() {
+		resources, ok := served[api.groupVersion]
+		if !ok {
+			list, err := o.kcli.Discovery().ServerResourcesForGroupVersion(api.groupVersion)
+			if err != nil && !errors.IsNotFound(err) {
+				return fmt.Errorf("failed to discover %s api resources: %w", api.groupVersion, err)
+			}
+
+			resources = map[string]bool{}
+			if list != nil {
+				for _, resource := range list.APIResources {
+					resources[resource.Name] = true
+				}
+			}
+			served[api.groupVersion] = resources
+		}
+
+		if !resources[api.resource] {
+			missing = append(missing, api.String())
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf(
+			"kubernetes %s does not serve the required api resources: %s",
+			info.GitVersion, strings.Join(missing, ", "),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package clusterspace
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestValidate(t *testing.T) {
+	resources := func(groupVersion string, names ...string) *metav1.APIResourceList {
+		list := &metav1.APIResourceList{GroupVersion: groupVersion}
+		for _, name := range names {
+			list.APIResources = append(list.APIResources, metav1.APIResource{Name: name})
+		}
+		return list
+	}
+
+	allResources := []*metav1.APIResourceList{
+		resources("v1", "nodes", "pods", "persistentvolumes", "persistentvolumeclaims"),
+		resources("storage.k8s.io/v1", "storageclasses", "csidrivers"),
+		resources("batch/v1", "jobs", "cronjobs"),
+	}
+
+	for _, tt := range []struct {
+		name      string
+		version   string
+		resources []*metav1.APIResourceList
+		runAsPod  bool
+		reactor   k8stesting.ReactionFunc
+		err       string
+	}{
+		{
+			name:      "should pass on a supported cluster",
+			version:   "v1.29.3",
+			resources: allResources,
+		},
+		{
+			name:      "should pass on distributions with version suffixes",
+			version:   "v1.16.15+k3s1",
+			resources: allResources,
+		},
+		{
+			name:      "should fail on clusters older than the minimum version",
+			version:   "v1.15.12",
+			resources: allResources,
+			err:       "kubernetes version v1.15.12 is not supported, the minimum supported version is 1.16.0",
+		},
+		{
+			name:      "should fail if the server version can't be parsed",
+			version:   "unknown",
+			resources: allResources,
+			err:       `failed to parse kubernetes server version "unknown"`,
+		},
+		{
+			name:    "should report all the missing api resources",
+			version: "v1.29.3",
+			resources: []*metav1.APIResourceList{
+				resources("v1", "nodes", "pods", "persistentvolumes", "persistentvolumeclaims"),
+				resources("storage.k8s.io/v1", "csidrivers"),
+			},
+			err: "kubernetes v1.29.3 does not serve the required api resources: storageclasses in storage.k8s.io/v1, jobs in batch/v1",
+		},
+		{
+			name:    "should not require jobs when running bare pods",
+			version: "v1.29.3",
+			resources: []*metav1.APIResourceList{
+				resources("v1", "nodes", "pods", "persistentvolumes", "persistentvolumeclaims"),
+				resources("storage.k8s.io/v1", "storageclasses"),
+			},
+			runAsPod: true,
+		},
+		{
+			name:      "should fail if the server version can't be read",
+			resources: allResources,
+			reactor: func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, nil, fmt.Errorf("connection refused")
+			},
+			err: "failed to read kubernetes server version: connection refused",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			kcli := fake.NewSimpleClientset()
+			if tt.reactor != nil {
+				kcli.PrependReactor("get", "version", tt.reactor)
+			}
+
+			discovery := kcli.Discovery().(*fakediscovery.FakeDiscovery)
+			discovery.FakedServerVersion = &version.Info{GitVersion: tt.version}
+			discovery.Resources = tt.resources
+
+			getter := OpenEBSFreeDiskSpaceGetter{kcli: kcli, opts: OpenEBSOptions{Job: OpenEBSJobOptions{RunAsPod: tt.runAsPod}}}
+			err := getter.Validate(context.Background())
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected error containing %q, received %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package clusterspace
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"code.cloudfoundry.org/bytefmt"
+)
+
+// VolumeRequest is a volume of a batch to be placed in the nodes, see PackVolumes. Node pins the
+// volume to a node, e.g. a local volume being migrated is recreated in the node holding it.
+type VolumeRequest struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+	Node string `json:"node,omitempty"`
+}
+
+// VolumePlacement is where a volume of a batch would be placed. PlacedOn is empty if the volume does
+// not fit in any node.
+type VolumePlacement struct {
+	VolumeRequest
+	PlacedOn string `json:"placedOn,omitempty"`
+}
+
+// VolumePacking is the result of packing a batch of volumes in the nodes. Placements follow the order
+// of the requests, Free holds the effective free space of each node before the batch is placed and
+// Remaining the space left once all placed volumes are accounted for.
+type VolumePacking struct {
+	Placements []VolumePlacement `json:"placements"`
+	Free       map[string]int64  `json:"free"`
+	Remaining  map[string]int64  `json:"remaining"`
+}
+
+// Fits returns true if all the volumes of the batch have been placed.
+func (p VolumePacking) Fits() bool {
+	return len(p.Unplaced()) == 0
+}
+
+// Unplaced returns the volumes of the batch that do not fit in any node.
+func (p VolumePacking) Unplaced() []VolumePlacement {
+	var unplaced []VolumePlacement
+	for _, placement := range p.Placements {
+		if placement.PlacedOn == "" {
+			unplaced = append(unplaced, placement)
+		}
+	}
+	return unplaced
+}
+
+// PackVolumes places a batch of volumes in the nodes given the effective free space of each of them.
+// as volumes can't span nodes each one must fit entirely in a single node and, as in the per node
+// checks, a node holds a volume only if its remaining space is bigger than the volume size. pinned
+// volumes are placed in their node first. the remaining volumes are then placed from the biggest to
+// the smallest, each one in the node with the least remaining space that still holds it (best fit),
+// so big volumes are not left without a node by smaller ones. ties are broken by name.
+func PackVolumes(free map[string]int64, requests []VolumeRequest) VolumePacking {
+	packing := VolumePacking{
+		Placements: make([]VolumePlacement, len(requests)),
+		Free:       map[string]int64{},
+		Remaining:  map[string]int64{},
+	}
+
+	var nodes []string
+	for node, bytes := range free {
+		nodes = append(nodes, node)
+		packing.Free[node] = bytes
+		packing.Remaining[node] = bytes
+	}
+	sort.Strings(nodes)
+
+	var order []int
+	for i, request := range requests {
+		packing.Placements[i] = VolumePlacement{VolumeRequest: request}
+		order = append(order, i)
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := requests[order[i]], requests[order[j]]
+		if (a.Node != "") != (b.Node != "") {
+			return a.Node != ""
+		}
+		if a.Size != b.Size {
+			return a.Size > b.Size
+		}
+		return a.Name < b.Name
+	})
+
+	for _, idx := range order {
+		request := requests[idx]
+		if request.Node != "" {
+			if remaining, ok := packing.Remaining[request.Node]; ok && remaining > request.Size {
+				packing.Placements[idx].PlacedOn = request.Node
+				packing.Remaining[request.Node] -= request.Size
+			}
+			continue
+		}
+
+		var best string
+		for _, node := range nodes {
+			remaining := packing.Remaining[node]
+			if remaining <= request.Size {
+				continue
+			}
+			if best == "" || remaining < packing.Remaining[best] {
+				best = node
+			}
+		}
+		if best != "" {
+			packing.Placements[idx].PlacedOn = best
+			packing.Remaining[best] -= request.Size
+		}
+	}
+	return packing
+}
+
+// PackVolumes measures the destination storage class in all nodes and places the provided batch of
+// volumes in them, see PackVolumes. the effective free space of each node excludes the space kept
+// free by its reserve calculator (by default 15% of the volume if it is part of the root filesystem,
+// so nodes whose base path lives in the root filesystem hold less) and the extra reserved bytes.
+// pending pvcs demand is subtracted as well if configured.
+func (o *OpenEBSDiskSpaceValidator) PackVolumes(ctx context.Context, requests []VolumeRequest) (VolumePacking, error) {
+	volumes, err := o.freeSpaceGetter.OpenEBSVolumes(ctx)
+	if err != nil {
+		return VolumePacking{}, fmt.Errorf("failed to calculate available disk space per node: %w", err)
+	}
+
+	if o.pendingPVCs {
+		if volumes, err = o.subtractPendingDemand(ctx, volumes); err != nil {
+			return VolumePacking{}, err
+		}
+	}
+
+	if err := o.loadNodeLabels(ctx); err != nil {
+		return VolumePacking{}, fmt.Errorf("failed to read node labels: %w", err)
+	}
+
+	free := map[string]int64{}
+	for node, vol := range volumes {
+		effective, _ := o.hasEnoughSpace(node, vol, 0)
+		free[node] = effective - o.reserved
+	}
+
+	packing := PackVolumes(free, requests)
+	for _, placement := range packing.Unplaced() {
+		o.log.Printf("Volume %s (%s) does not fit in any node", placement.Name, bytefmt.ByteSize(uint64(max(placement.Size, 0))))
+	}
+	return packing, nil
+}
@@ -0,0 +1,192 @@
+package clusterspace
+
+import (
+	"context"
+	"io"
+	"log"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPackVolumes(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		free      map[string]int64
+		requests  []VolumeRequest
+		placedOn  []string
+		remaining map[string]int64
+		fits      bool
+	}{
+		{
+			name:      "should fit an empty batch",
+			free:      map[string]int64{"node0": 100},
+			remaining: map[string]int64{"node0": 100},
+			fits:      true,
+		},
+		{
+			name: "should place the biggest volumes first",
+			free: map[string]int64{"node0": 100, "node1": 60},
+			requests: []VolumeRequest{
+				{Name: "small", Size: 40},
+				{Name: "big", Size: 90},
+				{Name: "medium", Size: 50},
+			},
+			placedOn:  []string{"", "node0", "node1"},
+			remaining: map[string]int64{"node0": 10, "node1": 10},
+		},
+		{
+			name: "should place volumes in the node with the least space that holds them",
+			free: map[string]int64{"node0": 100, "node1": 60, "node2": 60},
+			requests: []VolumeRequest{
+				{Name: "vol0", Size: 50},
+				{Name: "vol1", Size: 50},
+				{Name: "vol2", Size: 50},
+			},
+			placedOn:  []string{"node1", "node2", "node0"},
+			remaining: map[string]int64{"node0": 50, "node1": 10, "node2": 10},
+			fits:      true,
+		},
+		{
+			name: "should require more space than the volume size",
+			free: map[string]int64{"node0": 100},
+			requests: []VolumeRequest{
+				{Name: "vol0", Size: 60},
+				{Name: "vol1", Size: 40},
+			},
+			placedOn:  []string{"node0", ""},
+			remaining: map[string]int64{"node0": 40},
+		},
+		{
+			name: "should place pinned volumes in their node first",
+			free: map[string]int64{"node0": 100, "node1": 100},
+			requests: []VolumeRequest{
+				{Name: "free", Size: 90},
+				{Name: "pinned", Size: 30, Node: "node0"},
+				{Name: "other", Size: 30, Node: "node1"},
+			},
+			placedOn:  []string{"", "node0", "node1"},
+			remaining: map[string]int64{"node0": 70, "node1": 70},
+		},
+		{
+			name: "should not place volumes pinned to unknown nodes",
+			free: map[string]int64{"node0": 100},
+			requests: []VolumeRequest{
+				{Name: "pinned", Size: 10, Node: "node9"},
+			},
+			placedOn:  []string{""},
+			remaining: map[string]int64{"node0": 100},
+		},
+		{
+			name: "should not place volumes in nodes without space",
+			free: map[string]int64{"node0": -10},
+			requests: []VolumeRequest{
+				{Name: "vol0", Size: 0},
+			},
+			placedOn:  []string{""},
+			remaining: map[string]int64{"node0": -10},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			packing := PackVolumes(tt.free, tt.requests)
+
+			var placedOn []string
+			for i, placement := range packing.Placements {
+				if placement.VolumeRequest != tt.requests[i] {
+					t.Errorf("expected placement %d to be for %+v, %+v found", i, tt.requests[i], placement.VolumeRequest)
+				}
+				placedOn = append(placedOn, placement.PlacedOn)
+			}
+			if diff := cmp.Diff(tt.placedOn, placedOn); diff != "" {
+				t.Errorf("unexpected placements: %s", diff)
+			}
+			if diff := cmp.Diff(tt.remaining, packing.Remaining); diff != "" {
+				t.Errorf("unexpected remaining space: %s", diff)
+			}
+			if diff := cmp.Diff(tt.free, packing.Free); diff != "" {
+				t.Errorf("unexpected free space: %s", diff)
+			}
+			if packing.Fits() != tt.fits {
+				t.Errorf("expected fits to be %v, unplaced: %+v", tt.fits, packing.Unplaced())
+			}
+		})
+	}
+}
+
+func TestOpenEBSDiskSpaceValidatorPackVolumes(t *testing.T) {
+	kcli := fake.NewSimpleClientset(
+		kubeSystemNamespace(),
+		&storagev1.StorageClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "openebs",
+				Annotations: map[string]string{
+					"cas.openebs.io/config": "- name: BasePath\n  value: /var/local",
+				},
+			},
+			Provisioner: OpenEBSLocalProvisioner,
+		},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node0"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+	)
+
+	// the measurements are served from the cache so no job is executed.
+	cache, err := NewOpenEBSVolumeCache(filepath.Join(t.TempDir(), "cache.json"), time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error creating cache: %s", err)
+	}
+	for node, vol := range map[string]OpenEBSVolume{
+		"node0": {Free: 1000, Used: 1000, RootVolume: true},
+		"node1": {Free: 900, Used: 1100},
+	} {
+		if err := cache.Set(node, testCacheKey, vol); err != nil {
+			t.Fatalf("unexpected error caching volume: %s", err)
+		}
+	}
+
+	validator := OpenEBSDiskSpaceValidator{
+		kcli: kcli,
+		log:  log.New(io.Discard, "", 0),
+		freeSpaceGetter: &OpenEBSFreeDiskSpaceGetter{
+			kcli: kcli,
+			opts: OpenEBSOptions{
+				DstSC: "openebs",
+				Cache: cache,
+				PVC: OpenEBSPVCOptions{
+					DeletePVTimeout: time.Minute,
+				},
+			},
+			log: log.New(io.Discard, "", 0),
+		},
+		srcSC:    "longhorn",
+		reserved: 100,
+	}
+
+	// node0 keeps 15% of its volume free as it lives in the root filesystem.
+	packing, err := validator.PackVolumes(context.Background(), []VolumeRequest{
+		{Name: "vol0", Size: 700},
+		{Name: "vol1", Size: 550},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if diff := cmp.Diff(map[string]int64{"node0": 600, "node1": 800}, packing.Free); diff != "" {
+		t.Errorf("unexpected effective free space: %s", diff)
+	}
+	expected := []VolumePlacement{
+		{VolumeRequest: VolumeRequest{Name: "vol0", Size: 700}, PlacedOn: "node1"},
+		{VolumeRequest: VolumeRequest{Name: "vol1", Size: 550}, PlacedOn: "node0"},
+	}
+	if diff := cmp.Diff(expected, packing.Placements); diff != "" {
+		t.Errorf("unexpected placements: %s", diff)
+	}
+	if !packing.Fits() {
+		t.Errorf("expected the batch to fit")
+	}
+}
@@ -0,0 +1,202 @@
+package clusterspace
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/ptr"
+)
+
+// defaultWindowsDrive is the drive measured on windows nodes.
+const defaultWindowsDrive = "C"
+
+// nodeMeasurement is how the free space in a node is measured.
+type nodeMeasurement string
+
+const (
+	// measureDF measures the space with the df job, used for linux nodes.
+	measureDF nodeMeasurement = "df"
+	// measureWindows measures the space with a powershell job, used for windows nodes.
+	measureWindows nodeMeasurement = "windows"
+	// measureSkip means the node can't be measured.
+	measureSkip nodeMeasurement = "skip"
+)
+
+// SkipSpaceCheckAnnotation is the node annotation that opts the node out of the space checks when
+// set to "true". opted out nodes are not measured and are reported as skipped.
+const SkipSpaceCheckAnnotation = "kurl.sh/skip-space-check"
+
+// skipSpaceCheck returns true if the node opted out of the space checks through the
+// SkipSpaceCheckAnnotation annotation.
+func skipSpaceCheck(node corev1.Node) bool {
+	skip, _ := strconv.ParseBool(node.Annotations[SkipSpaceCheckAnnotation])
+	return skip
+}
+
+// measurementFor decides, based on the node operating system label, how the node free space is
+// measured. nodes without the label are assumed to be linux nodes, nodes that opted out through
+// the SkipSpaceCheckAnnotation annotation or that do not match the configured node selector are
+// skipped. if the node is skipped the reason is returned as well.
+func (o *OpenEBSFreeDiskSpaceGetter) measurementFor(node corev1.Node) (nodeMeasurement, string) {
+	if skipSpaceCheck(node) {
+		return measureSkip, fmt.Sprintf("opted out through the %s annotation", SkipSpaceCheckAnnotation)
+	}
+
+	if o.opts.NodeSelector != nil && !o.opts.NodeSelector.Matches(labels.Set(node.Labels)) {
+		return measureSkip, fmt.Sprintf("excluded by the node selector %q", o.opts.NodeSelector)
+	}
+
+	switch nodeOS := node.Labels[corev1.LabelOSStable]; nodeOS {
+	case "", "linux":
+		return measureDF, ""
+	case "windows":
+		if o.opts.WindowsImage == "" {
+			return measureSkip, "windows nodes are only measured when a windows image is provided"
+		}
+		return measureWindows, ""
+	default:
+		return measureSkip, fmt.Sprintf("unsupported operating system %q", nodeOS)
+	}
+}
+
+// windowsDriveCommand returns the powershell command that prints the used and free space of the
+// provided drive as csv:
+//
+// "Used","Free"
+// "53687091200","10737418240"
+func windowsDriveCommand(drive string) string {
+	return fmt.Sprintf("Get-PSDrive -Name %s | Select-Object Used,Free | ConvertTo-Csv -NoTypeInformation", drive)
+}
+
+// buildWindowsJob returns a job that measures the free space in the windows node drive. the job runs
+// a host process container as otherwise the container would see its own sandbox drive. no temporary
+// pvc is mounted as the openebs provisioner does not run on windows nodes.
+func (o *OpenEBSFreeDiskSpaceGetter) buildWindowsJob(node string) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        jobName(node),
+			Namespace:   o.opts.Namespace,
+			Labels:      o.jobLabels(node),
+			Annotations: o.jobAnnotations(),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          ptr.To(int32(1)),
+			ActiveDeadlineSeconds: activeDeadlineSeconds(o.opts.Job.Timeout),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      o.jobLabels(node),
+					Annotations: o.jobAnnotations(),
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Tolerations:   o.tolerations,
+					Affinity:      nodeAffinity(node),
+					HostNetwork:   true,
+					SecurityContext: &corev1.PodSecurityContext{
+						WindowsOptions: &corev1.WindowsSecurityContextOptions{
+							HostProcess:   ptr.To(true),
+							RunAsUserName: ptr.To(`NT AUTHORITY\SYSTEM`),
+						},
+					},
+					Containers: []corev1.Container{
+						{
+							Name:    "drive",
+							Image:   o.opts.WindowsImage,
+							Command: []string{"powershell.exe", "-NoProfile", "-NonInteractive", "-Command"},
+							Args:    []string{windowsDriveCommand(o.opts.WindowsDrive)},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// windowsVolume runs the windows job in the provided node and returns the measured drive as a volume.
+// the drive is flagged as root volume if it is the system drive.
+func (o *OpenEBSFreeDiskSpaceGetter) windowsVolume(ctx context.Context, node string) (OpenEBSVolume, error) {
+	job := o.buildWindowsJob(node)
+	out, status, err := o.runJob(ctx, job)
+	if err != nil {
+		o.logContainersState(out, status)
+		return OpenEBSVolume{}, fmt.Errorf(
+			"failed to run job %s/%s on node %s: %w", job.Namespace, job.Name, node, err,
+		)
+	}
+
+	free, used, err := parseWindowsDriveOutput(out["drive"])
+	if err != nil {
+		o.logContainersState(out, status)
+		return OpenEBSVolume{}, fmt.Errorf(
+			"failed to parse node %s drive output: %w", node, err,
+		)
+	}
+
+	return OpenEBSVolume{
+		Free:       free,
+		Used:       used,
+		RootVolume: strings.EqualFold(o.opts.WindowsDrive, defaultWindowsDrive),
+	}, nil
+}
+
+// isValidWindowsDrive returns true if the provided drive is a single letter drive name.
+func isValidWindowsDrive(drive string) bool {
+	if len(drive) != 1 {
+		return false
+	}
+	c := drive[0] | 0x20
+	return c >= 'a' && c <= 'z'
+}
+
+// parseWindowsDriveOutput parses the output of the windowsDriveCommand and returns the free and used
+// space in bytes.
+func parseWindowsDriveOutput(output []byte) (int64, int64, error) {
+	var header []string
+	scanner := bufio.NewScanner(bytes.NewBuffer(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Split(line, ",")
+		for i := range fields {
+			fields[i] = strings.Trim(strings.TrimSpace(fields[i]), `"`)
+		}
+
+		if header == nil {
+			if len(fields) == 2 && fields[0] == "Used" && fields[1] == "Free" {
+				header = fields
+			}
+			continue
+		}
+
+		if len(fields) != 2 {
+			return 0, 0, fmt.Errorf("unexpected number of fields in drive line %q", line)
+		}
+
+		used, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to parse %q as used space: %w", fields[0], err)
+		}
+
+		free, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to parse %q as available space: %w", fields[1], err)
+		}
+		return free, used, nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return 0, 0, fmt.Errorf("failed to process container log: %w", err)
+	}
+	return 0, 0, fmt.Errorf("failed to locate drive info in pod log: %s", string(output))
+}
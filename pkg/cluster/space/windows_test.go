@@ -0,0 +1,227 @@
+package clusterspace
+
+import (
+	"context"
+	"io"
+	"log"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_parseWindowsDriveOutput(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		content []byte
+		err     string
+		expfree int64
+		expused int64
+	}{
+		{
+			name:    "should parse the csv output",
+			content: []byte("\"Used\",\"Free\"\r\n\"53687091200\",\"10737418240\"\r\n"),
+			expfree: 10737418240,
+			expused: 53687091200,
+		},
+		{
+			name: "should ignore lines before the header",
+			content: []byte(`WARNING: something
+"Used","Free"
+"100","200"
+`),
+			expfree: 200,
+			expused: 100,
+		},
+		{
+			name: "should fail when the drive info is missing",
+			content: []byte(`Get-PSDrive : Cannot find drive. A drive with the name 'X' does not exist.
+`),
+			err: "failed to locate drive info in pod log",
+		},
+		{
+			name: "should fail on empty values",
+			content: []byte(`"Used","Free"
+"",""
+`),
+			err: `failed to parse "" as used space`,
+		},
+		{
+			name: "should fail on unexpected number of fields",
+			content: []byte(`"Used","Free"
+"100"
+`),
+			err: "unexpected number of fields",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			free, used, err := parseWindowsDriveOutput(tt.content)
+			if err != nil {
+				if len(tt.err) == 0 {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expecting %q, %q received instead", tt.err, err)
+				}
+				return
+			}
+
+			if len(tt.err) > 0 {
+				t.Errorf("expecting error %q, nil received instead", tt.err)
+			}
+			if free != tt.expfree {
+				t.Errorf("expecting free %d, received %d", tt.expfree, free)
+			}
+			if used != tt.expused {
+				t.Errorf("expecting used %d, received %d", tt.expused, used)
+			}
+		})
+	}
+}
+
+func Test_measurementFor(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		labels       map[string]string
+		annotations  map[string]string
+		windowsImage string
+		selector     string
+		expected     nodeMeasurement
+		reason       string
+	}{
+		{
+			name:     "should measure nodes without the os label with df",
+			expected: measureDF,
+		},
+		{
+			name:     "should measure linux nodes with df",
+			labels:   map[string]string{corev1.LabelOSStable: "linux"},
+			expected: measureDF,
+		},
+		{
+			name:     "should skip windows nodes without a windows image",
+			labels:   map[string]string{corev1.LabelOSStable: "windows"},
+			expected: measureSkip,
+			reason:   "windows image",
+		},
+		{
+			name:         "should measure windows nodes with powershell",
+			labels:       map[string]string{corev1.LabelOSStable: "windows"},
+			windowsImage: "mcr.microsoft.com/powershell:lts-nanoserver-ltsc2022",
+			expected:     measureWindows,
+		},
+		{
+			name:         "should skip nodes with unknown operating systems",
+			labels:       map[string]string{corev1.LabelOSStable: "plan9"},
+			windowsImage: "mcr.microsoft.com/powershell:lts-nanoserver-ltsc2022",
+			expected:     measureSkip,
+			reason:       `unsupported operating system "plan9"`,
+		},
+		{
+			name:        "should skip nodes that opted out of the space checks",
+			labels:      map[string]string{corev1.LabelOSStable: "linux"},
+			annotations: map[string]string{SkipSpaceCheckAnnotation: "true"},
+			expected:    measureSkip,
+			reason:      "opted out through the kurl.sh/skip-space-check annotation",
+		},
+		{
+			name:        "should measure nodes that did not opt out of the space checks",
+			annotations: map[string]string{SkipSpaceCheckAnnotation: "false"},
+			expected:    measureDF,
+		},
+		{
+			name:     "should skip nodes not matching the node selector",
+			labels:   map[string]string{"nvidia.com/gpu": "true"},
+			selector: "!nvidia.com/gpu",
+			expected: measureSkip,
+			reason:   `excluded by the node selector "!nvidia.com/gpu"`,
+		},
+		{
+			name:     "should measure nodes matching the node selector",
+			labels:   map[string]string{"storage": "openebs"},
+			selector: "storage=openebs",
+			expected: measureDF,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			getter := &OpenEBSFreeDiskSpaceGetter{opts: OpenEBSOptions{WindowsImage: tt.windowsImage}}
+			if tt.selector != "" {
+				selector, err := labels.Parse(tt.selector)
+				if err != nil {
+					t.Fatalf("failed to parse selector: %s", err)
+				}
+				getter.opts.NodeSelector = selector
+			}
+			node := corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", Labels: tt.labels, Annotations: tt.annotations}}
+			measurement, reason := getter.measurementFor(node)
+			if measurement != tt.expected {
+				t.Errorf("expecting %q, %q received instead", tt.expected, measurement)
+			}
+			if !strings.Contains(reason, tt.reason) {
+				t.Errorf("expecting reason %q, %q received instead", tt.reason, reason)
+			}
+			if tt.reason == "" && reason != "" {
+				t.Errorf("unexpected reason %q", reason)
+			}
+		})
+	}
+}
+
+func TestSkipSpaceCheckAnnotation(t *testing.T) {
+	kcli := fake.NewSimpleClientset(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "node0",
+			Annotations: map[string]string{SkipSpaceCheckAnnotation: "true"},
+		},
+	})
+	runner := NodeJobRunner{
+		jobs: &OpenEBSFreeDiskSpaceGetter{
+			kcli: kcli,
+			log:  log.New(io.Discard, "", 0),
+			opts: OpenEBSOptions{
+				Image:       "myimage:latest",
+				Namespace:   "default",
+				Parallelism: 1,
+			},
+		},
+	}
+
+	swaps, err := runner.NodesSwap(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(swaps) != 0 {
+		t.Errorf("expected no node to be measured, measured: %v", swaps)
+	}
+
+	expected := "opted out through the kurl.sh/skip-space-check annotation"
+	if reason := runner.SkippedNodes()["node0"]; reason != expected {
+		t.Errorf("expected skip reason %q, %q received instead", expected, reason)
+	}
+
+	jobs, err := kcli.BatchV1().Jobs("default").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("failed to list jobs: %s", err)
+	}
+	if len(jobs.Items) != 0 {
+		t.Errorf("expected no job to be created, %d found", len(jobs.Items))
+	}
+}
+
+func Test_isValidWindowsDrive(t *testing.T) {
+	for drive, expected := range map[string]bool{
+		"C":    true,
+		"d":    true,
+		"":     false,
+		"CD":   false,
+		"C:":   false,
+		"1":    false,
+		"C;ls": false,
+	} {
+		if got := isValidWindowsDrive(drive); got != expected {
+			t.Errorf("drive %q: expecting %v, %v received instead", drive, expected, got)
+		}
+	}
+}
@@ -1,8 +1,90 @@
 package host
 
-import "github.com/denisbrodbeck/machineid"
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/denisbrodbeck/machineid"
+)
+
+// protectedIDAppID is the application id hashed with the machine id into the protected id.
+const protectedIDAppID = "replicated"
+
+// machineIDPaths are the files the machine id is read from, the first readable one is used. this
+// mirrors what the machineid library does on linux.
+var machineIDPaths = []string{"/var/lib/dbus/machine-id", "/etc/machine-id"}
+
+// ProtectedIDInput is one of the values the protected id is computed from.
+type ProtectedIDInput struct {
+	// Name identifies the input, e.g. "machine id".
+	Name string
+	// Source is where the value has been read from.
+	Source string
+	// Value is the raw input value, it must be redacted before being shown, see Redacted.
+	Value string
+	// Secret is true for the values that must not be disclosed.
+	Secret bool
+}
+
+// Redacted returns the input value with all but its first four characters masked, values of eight
+// characters or fewer are masked entirely. values that are not secret are returned as they are.
+func (i ProtectedIDInput) Redacted() string {
+	if !i.Secret {
+		return i.Value
+	}
+	if len(i.Value) <= 8 {
+		return strings.Repeat("*", len(i.Value))
+	}
+	return i.Value[:4] + strings.Repeat("*", len(i.Value)-4)
+}
 
 // ProtectedID returns a hashed version of the machine ID in a cryptographically secure way,
 func ProtectedID() (string, error) {
-	return machineid.ProtectedID("replicated")
+	return machineid.ProtectedID(protectedIDAppID)
+}
+
+// ProtectedIDInputs returns the inputs the protected id is computed from, the protected id as returned
+// by ProtectedID and the protected id computed out of the returned inputs, so discrepancies between
+// expected and actual ids can be diagnosed. both ids differ if the machineid library no longer reads
+// or hashes the machine id the way protectedIDInputs does, the inputs can't be trusted in that case.
+func ProtectedIDInputs() ([]ProtectedIDInput, string, string, error) {
+	inputs, computed, err := protectedIDInputs(machineIDPaths)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	id, err := ProtectedID()
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to get protected id: %w", err)
+	}
+	return inputs, id, computed, nil
+}
+
+// protectedIDInputs reads the machine id from the first readable of the provided paths and returns
+// it together with the application id and the resulting protected id: the HMAC-SHA256 of the
+// application id keyed by the machine id, as computed by the machineid library.
+func protectedIDInputs(paths []string) ([]ProtectedIDInput, string, error) {
+	var errs []string
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+
+		machineID := strings.TrimSpace(string(data))
+		inputs := []ProtectedIDInput{
+			{Name: "app id", Source: "built-in", Value: protectedIDAppID},
+			{Name: "machine id", Source: path, Value: machineID, Secret: true},
+		}
+
+		mac := hmac.New(sha256.New, []byte(machineID))
+		mac.Write([]byte(protectedIDAppID))
+		return inputs, hex.EncodeToString(mac.Sum(nil)), nil
+	}
+	return nil, "", fmt.Errorf("failed to read machine id: %s", strings.Join(errs, ", "))
 }
@@ -0,0 +1,75 @@
+package host
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestProtectedIDInputRedacted(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		input    ProtectedIDInput
+		expected string
+	}{
+		{
+			name:     "should not redact values that are not secret",
+			input:    ProtectedIDInput{Value: "replicated"},
+			expected: "replicated",
+		},
+		{
+			name:     "should keep only the first four characters of secrets",
+			input:    ProtectedIDInput{Value: "0123456789abcdef", Secret: true},
+			expected: "0123************",
+		},
+		{
+			name:     "should mask short secrets entirely",
+			input:    ProtectedIDInput{Value: "0123", Secret: true},
+			expected: "****",
+		},
+		{
+			name:     "should handle empty secrets",
+			input:    ProtectedIDInput{Secret: true},
+			expected: "",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if redacted := tt.input.Redacted(); redacted != tt.expected {
+				t.Errorf("expected %q, %q received", tt.expected, redacted)
+			}
+		})
+	}
+}
+
+func Test_protectedIDInputs(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing")
+	present := filepath.Join(dir, "machine-id")
+	if err := os.WriteFile(present, []byte("d1f0a8e2b6c94c1e9f7a3b5c2d4e6f80\n"), 0644); err != nil {
+		t.Fatalf("failed to write machine id: %s", err)
+	}
+
+	inputs, id, err := protectedIDInputs([]string{missing, present})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []ProtectedIDInput{
+		{Name: "app id", Source: "built-in", Value: "replicated"},
+		{Name: "machine id", Source: present, Value: "d1f0a8e2b6c94c1e9f7a3b5c2d4e6f80", Secret: true},
+	}
+	if diff := cmp.Diff(expected, inputs); diff != "" {
+		t.Errorf("unexpected inputs: %s", diff)
+	}
+
+	// echo -n replicated | openssl dgst -sha256 -hmac d1f0a8e2b6c94c1e9f7a3b5c2d4e6f80
+	if expected := "a56a2cfbdc9a36014e73d8fd713a307ab60c76b11e9d7b5a3c88bd0906d532cf"; id != expected {
+		t.Errorf("expected protected id %q, %q received", expected, id)
+	}
+
+	if _, _, err := protectedIDInputs([]string{missing}); err == nil {
+		t.Errorf("expected an error when no machine id can be read")
+	}
+}
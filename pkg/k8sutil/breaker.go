@@ -0,0 +1,97 @@
+package k8sutil
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"k8s.io/client-go/rest"
+)
+
+// ErrAPIServerUnhealthy is returned by clients protected by a CircuitBreaker once the breaker has
+// been tripped.
+var ErrAPIServerUnhealthy = errors.New("API server appears unhealthy, aborting")
+
+// CircuitBreaker counts consecutive failed requests to the API server (transport errors and 5xx
+// responses). once the number of consecutive failures reaches the threshold the breaker trips and all
+// further requests fail immediately with ErrAPIServerUnhealthy so we stop hammering a struggling
+// API server. a successful request resets the failure counter. requests aborted by their own context
+// and 429 responses, which client-go already retries honoring the server provided delay, are not
+// accounted for.
+type CircuitBreaker struct {
+	mtx       sync.Mutex
+	threshold int
+	failures  int
+	tripped   bool
+}
+
+// Tripped returns true if the breaker has been tripped.
+func (c *CircuitBreaker) Tripped() bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.tripped
+}
+
+// record accounts for the result of a request.
+func (c *CircuitBreaker) record(failed bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if !failed {
+		c.failures = 0
+		return
+	}
+
+	c.failures++
+	if c.failures >= c.threshold {
+		c.tripped = true
+	}
+}
+
+// Wrap returns a round tripper that sends requests through the provided one while the breaker has
+// not been tripped.
+func (c *CircuitBreaker) Wrap(next http.RoundTripper) http.RoundTripper {
+	return &breakerRoundTripper{breaker: c, next: next}
+}
+
+// breakerRoundTripper is an http.RoundTripper protected by a CircuitBreaker.
+type breakerRoundTripper struct {
+	breaker *CircuitBreaker
+	next    http.RoundTripper
+}
+
+// RoundTrip executes the request unless the breaker has been tripped.
+func (b *breakerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if b.breaker.Tripped() {
+		return nil, ErrAPIServerUnhealthy
+	}
+
+	resp, err := b.next.RoundTrip(req)
+	if err != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || req.Context().Err() != nil) {
+		return resp, err
+	}
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		return resp, err
+	}
+
+	failed := err != nil || resp.StatusCode >= http.StatusInternalServerError
+	b.breaker.record(failed)
+	return resp, err
+}
+
+// NewCircuitBreaker returns a breaker that trips after threshold consecutive failures. thresholds
+// smaller than one are set to one.
+func NewCircuitBreaker(threshold int) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{threshold: threshold}
+}
+
+// WithCircuitBreaker returns a copy of the provided config whose clients are protected by the
+// provided circuit breaker. all clients created from the returned config share the breaker.
+func WithCircuitBreaker(cfg *rest.Config, breaker *CircuitBreaker) *rest.Config {
+	cfg = rest.CopyConfig(cfg)
+	cfg.Wrap(breaker.Wrap)
+	return cfg
+}
@@ -0,0 +1,120 @@
+package k8sutil
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var hits int32
+	var status int32 = http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+	}))
+	defer server.Close()
+
+	breaker := NewCircuitBreaker(3)
+	client := &http.Client{Transport: breaker.Wrap(http.DefaultTransport)}
+	get := func() error {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+
+	// two failures followed by a success must reset the counter.
+	for i := 0; i < 2; i++ {
+		if err := get(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	atomic.StoreInt32(&status, http.StatusOK)
+	if err := get(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if breaker.Tripped() {
+		t.Fatalf("breaker tripped after a successful request")
+	}
+
+	// sustained failures must trip the breaker.
+	atomic.StoreInt32(&status, http.StatusServiceUnavailable)
+	for i := 0; i < 3; i++ {
+		if err := get(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if !breaker.Tripped() {
+		t.Fatalf("breaker not tripped after sustained failures")
+	}
+
+	// once tripped requests must not reach the server anymore.
+	before := atomic.LoadInt32(&hits)
+	if err := get(); !errors.Is(err, ErrAPIServerUnhealthy) {
+		t.Errorf("expected ErrAPIServerUnhealthy, %v received", err)
+	}
+	if atomic.LoadInt32(&hits) != before {
+		t.Errorf("request reached the server after the breaker tripped")
+	}
+}
+
+func TestWithCircuitBreaker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	cfg := WithCircuitBreaker(&rest.Config{Host: server.URL}, NewCircuitBreaker(2))
+	kcli, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		_, err = kcli.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+		if errors.Is(err, ErrAPIServerUnhealthy) {
+			return
+		}
+	}
+	t.Errorf("expected ErrAPIServerUnhealthy after sustained failures, last error: %v", err)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestCircuitBreakerIgnoresThrottlingAndCancellation(t *testing.T) {
+	breaker := NewCircuitBreaker(2)
+	throttled := breaker.Wrap(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusTooManyRequests}, nil
+	}))
+	canceled := breaker.Wrap(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, context.Canceled
+	}))
+	expired := breaker.Wrap(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, context.DeadlineExceeded
+	}))
+
+	for _, rt := range []http.RoundTripper{throttled, canceled, expired, throttled, canceled, expired} {
+		req, err := http.NewRequest(http.MethodGet, "http://localhost", nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating request: %s", err)
+		}
+		_, _ = rt.RoundTrip(req)
+	}
+	if breaker.Tripped() {
+		t.Errorf("breaker tripped by throttled or canceled requests")
+	}
+}
@@ -0,0 +1,24 @@
+package k8sutil
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ConfigMapValue returns the value stored under the provided key in a config map. returns an error
+// if the config map does not exist or if the key is not present or empty.
+func ConfigMapValue(ctx context.Context, cli kubernetes.Interface, namespace, name, key string) (string, error) {
+	cm, err := cli.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get config map %s/%s: %w", namespace, name, err)
+	}
+
+	value, ok := cm.Data[key]
+	if !ok || value == "" {
+		return "", fmt.Errorf("key %s not found in config map %s/%s", key, namespace, name)
+	}
+	return value, nil
+}
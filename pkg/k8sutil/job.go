@@ -3,7 +3,6 @@ package k8sutil
 import (
 	"context"
 	"fmt"
-	"io"
 	"log"
 	"time"
 
@@ -38,9 +37,26 @@ func WaitForJob(ctx context.Context, cli kubernetes.Interface, job *batchv1.Job,
 	}
 }
 
+// deadlineExceeded returns true if the provided job has failed because it reached its active
+// deadline.
+func deadlineExceeded(ctx context.Context, cli kubernetes.Interface, job *batchv1.Job) bool {
+	gotJob, err := cli.BatchV1().Jobs(job.Namespace).Get(ctx, job.Name, metav1.GetOptions{})
+	if err != nil {
+		return false
+	}
+	for _, cond := range gotJob.Status.Conditions {
+		if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue && cond.Reason == batchv1.JobReasonDeadlineExceeded {
+			return true
+		}
+	}
+	return false
+}
+
 // RunJob runs the provided job and waits until it finishes or the timeout is reached.
 // returns the job's pod logs (indexed by container name) and the state of each of the
-// containers (also indexed by container name).
+// containers (also indexed by container name). a job that reached its active deadline
+// before its pod was scheduled fails with an error wrapping ErrPodNotScheduled, as if the
+// timeout had been reached.
 func RunJob(ctx context.Context, cli kubernetes.Interface, logger *log.Logger, job *batchv1.Job, timeout time.Duration) (map[string][]byte, map[string]corev1.ContainerState, error) {
 	job.ObjectMeta.Labels = AppendKurlLabels(job.ObjectMeta.Labels)
 	job, err := cli.BatchV1().Jobs(job.Namespace).Create(ctx, job, metav1.CreateOptions{})
@@ -61,6 +77,14 @@ func RunJob(ctx context.Context, cli kubernetes.Interface, logger *log.Logger, j
 
 	jobSucceeded, err := WaitForJob(ctx, cli, job, timeout)
 	if err != nil {
+		if ctx.Err() == nil && job.Spec.Selector != nil {
+			selector := labels.SelectorFromSet(job.Spec.Selector.MatchLabels).String()
+			if pods, lerr := cli.CoreV1().Pods(job.Namespace).List(
+				ctx, metav1.ListOptions{LabelSelector: selector},
+			); lerr == nil {
+				err = notScheduledError(pods.Items, err)
+			}
+		}
 		return nil, nil, err
 	}
 
@@ -71,43 +95,23 @@ func RunJob(ctx context.Context, cli kubernetes.Interface, logger *log.Logger, j
 	var pods *corev1.PodList
 	if pods, err = cli.CoreV1().Pods(job.Namespace).List(ctx, listOptions); err != nil {
 		return nil, nil, fmt.Errorf("failed to list pods for job: %w", err)
-	} else if len(pods.Items) == 0 {
-		return nil, nil, fmt.Errorf("pod for job not found")
-	}
-
-	jobPod := pods.Items[0]
-
-	lastContainerStatuses := map[string]corev1.ContainerState{}
-	for _, status := range jobPod.Status.ContainerStatuses {
-		lastContainerStatuses[status.Name] = status.State
 	}
 
-	logs := map[string][]byte{}
-	for _, container := range jobPod.Spec.Containers {
-		options := &corev1.PodLogOptions{Container: container.Name}
-		podLogs, err := cli.CoreV1().Pods(jobPod.Namespace).GetLogs(jobPod.Name, options).Stream(ctx)
-		if err != nil && jobSucceeded {
-			// if the job succeed to execute but there is an error to read the container logs we bail.
-			return nil, nil, fmt.Errorf("failed to read container %s logs: %w", container.Name, err)
-		} else if err != nil {
-			message := fmt.Sprintf("failed to get container %s logs: %s", container.Name, err)
-			logger.Print(message)
-			logs[container.Name] = []byte(message)
-			continue
+	// the job controller deletes the pods once the deadline is reached, no pod means it
+	// was not scheduled either.
+	if !jobSucceeded && deadlineExceeded(ctx, cli, job) {
+		if _, unscheduled := unscheduledReason(pods.Items); unscheduled {
+			return nil, nil, notScheduledError(pods.Items, fmt.Errorf("job deadline exceeded"))
 		}
+	}
 
-		defer func(stream io.ReadCloser) {
-			if err := stream.Close(); err != nil {
-				logger.Printf("failed to close pod log stream: %s", err)
-			}
-		}(podLogs)
-
-		output, err := io.ReadAll(podLogs)
-		if err != nil {
-			return nil, lastContainerStatuses, fmt.Errorf("failed to read pod logs: %w", err)
-		}
+	if len(pods.Items) == 0 {
+		return nil, nil, fmt.Errorf("pod for job not found")
+	}
 
-		logs[container.Name] = output
+	logs, lastContainerStatuses, err := podLogsAndStates(ctx, cli, logger, pods.Items[0], jobSucceeded)
+	if err != nil {
+		return nil, lastContainerStatuses, err
 	}
 
 	if !jobSucceeded {
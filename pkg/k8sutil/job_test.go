@@ -0,0 +1,96 @@
+package k8sutil
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRunJobDeadlineExceeded(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		pods          []corev1.Pod
+		unschedulable bool
+		err           string
+	}{
+		{
+			name: "pending pod",
+			pods: []corev1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "job-abcde", Namespace: "default", Labels: map[string]string{"job": "df"}},
+					Status: corev1.PodStatus{
+						Phase: corev1.PodPending,
+						Conditions: []corev1.PodCondition{
+							{
+								Type:    corev1.PodScheduled,
+								Status:  corev1.ConditionFalse,
+								Message: "0/3 nodes are available: 3 Insufficient cpu.",
+							},
+						},
+					},
+				},
+			},
+			unschedulable: true,
+			err:           "job deadline exceeded: pod not scheduled: 0/3 nodes are available: 3 Insufficient cpu.",
+		},
+		{
+			name:          "pod deleted by the job controller",
+			unschedulable: true,
+			err:           "job deadline exceeded: pod not scheduled",
+		},
+		{
+			name: "pod that ran",
+			pods: []corev1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "job-abcde", Namespace: "default", Labels: map[string]string{"job": "df"}},
+					Spec:       corev1.PodSpec{NodeName: "node0"},
+					Status:     corev1.PodStatus{Phase: corev1.PodFailed},
+				},
+			},
+			err: "job failed to execute",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := fake.NewSimpleClientset()
+			for _, pod := range tt.pods {
+				if _, err := cli.CoreV1().Pods(pod.Namespace).Create(context.Background(), &pod, metav1.CreateOptions{}); err != nil {
+					t.Fatalf("failed to create pod: %s", err)
+				}
+			}
+
+			job := &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: "job", Namespace: "default"},
+				Spec: batchv1.JobSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"job": "df"}},
+				},
+				Status: batchv1.JobStatus{
+					Failed: 1,
+					Conditions: []batchv1.JobCondition{
+						{
+							Type:   batchv1.JobFailed,
+							Status: corev1.ConditionTrue,
+							Reason: batchv1.JobReasonDeadlineExceeded,
+						},
+					},
+				},
+			}
+
+			_, _, err := RunJob(context.Background(), cli, log.New(io.Discard, "", 0), job, time.Minute)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected error %q, %v received", tt.err, err)
+			}
+			if unschedulable := errors.Is(err, ErrPodNotScheduled); unschedulable != tt.unschedulable {
+				t.Errorf("expected unschedulable to be %v, %v received: %s", tt.unschedulable, unschedulable, err)
+			}
+		})
+	}
+}
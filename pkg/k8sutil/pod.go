@@ -2,6 +2,10 @@ package k8sutil
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -67,3 +71,149 @@ func IsPodReady(pod corev1.Pod) bool {
 	}
 	return false
 }
+
+// ErrPodNotScheduled is returned, wrapped, by RunJob and RunPod when they time out, or the job
+// reaches its active deadline, before the pod has been scheduled to any node, as opposed to a pod
+// that ran and did not finish in time.
+var ErrPodNotScheduled = errors.New("pod not scheduled")
+
+// unscheduledReason returns true if none of the provided pods has been scheduled to a node, along
+// with the reason reported by the scheduler (e.g. "0/3 nodes are available: 3 Insufficient cpu.")
+// if any. returns true if no pod is provided as the pod was not even created.
+func unscheduledReason(pods []corev1.Pod) (string, bool) {
+	var reason string
+	for _, pod := range pods {
+		if pod.Spec.NodeName != "" {
+			return "", false
+		}
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type != corev1.PodScheduled {
+				continue
+			}
+			if cond.Status == corev1.ConditionTrue {
+				return "", false
+			}
+			if cond.Message != "" {
+				reason = cond.Message
+			}
+		}
+	}
+	return reason, true
+}
+
+// notScheduledError returns the error reported when the provided pods time out before being
+// scheduled, wrapping ErrPodNotScheduled, or the provided timeout error if any of them has been.
+func notScheduledError(pods []corev1.Pod, timeoutErr error) error {
+	reason, unscheduled := unscheduledReason(pods)
+	switch {
+	case !unscheduled:
+		return timeoutErr
+	case reason == "":
+		return fmt.Errorf("%w: %w", timeoutErr, ErrPodNotScheduled)
+	default:
+		return fmt.Errorf("%w: %w: %s", timeoutErr, ErrPodNotScheduled, reason)
+	}
+}
+
+// WaitForPod waits for a pod to finish. returns a boolean indicating if the pod succeeded.
+func WaitForPod(ctx context.Context, cli kubernetes.Interface, pod *corev1.Pod, timeout time.Duration) (bool, error) {
+	var endAt = time.Now().Add(timeout)
+	for {
+		gotPod, err := cli.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("failed getting pod: %w", err)
+		}
+
+		switch gotPod.Status.Phase {
+		case corev1.PodFailed:
+			return false, nil
+		case corev1.PodSucceeded:
+			return true, nil
+		default:
+			time.Sleep(time.Second)
+		}
+
+		if time.Now().After(endAt) {
+			return false, fmt.Errorf("timeout waiting for pod to finish")
+		}
+	}
+}
+
+// RunPod runs the provided pod and waits until it finishes or the timeout is reached. this is
+// an alternative to RunJob for places where jobs are not allowed. returns the pod logs (indexed
+// by container name) and the state of each of the containers (also indexed by container name).
+func RunPod(ctx context.Context, cli kubernetes.Interface, logger *log.Logger, pod *corev1.Pod, timeout time.Duration) (map[string][]byte, map[string]corev1.ContainerState, error) {
+	pod.ObjectMeta.Labels = AppendKurlLabels(pod.ObjectMeta.Labels)
+	pod, err := cli.CoreV1().Pods(pod.Namespace).Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create pod: %w", err)
+	}
+
+	defer func() {
+		// Cleanup should use background context so as not to fail if context has already been canceled
+		if err = cli.CoreV1().Pods(pod.Namespace).Delete(
+			context.Background(), pod.Name, metav1.DeleteOptions{},
+		); err != nil {
+			logger.Printf("failed to delete pod: %s", err)
+		}
+	}()
+
+	podSucceeded, err := WaitForPod(ctx, cli, pod, timeout)
+	if err != nil {
+		if ctx.Err() == nil {
+			if gotPod, gerr := cli.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{}); gerr == nil {
+				err = notScheduledError([]corev1.Pod{*gotPod}, err)
+			}
+		}
+		return nil, nil, err
+	}
+
+	if pod, err = cli.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{}); err != nil {
+		return nil, nil, fmt.Errorf("failed to get pod: %w", err)
+	}
+
+	logs, lastContainerStatuses, err := podLogsAndStates(ctx, cli, logger, *pod, podSucceeded)
+	if err != nil {
+		return nil, lastContainerStatuses, err
+	}
+
+	if !podSucceeded {
+		return logs, lastContainerStatuses, fmt.Errorf("pod failed to execute")
+	}
+	return logs, lastContainerStatuses, nil
+}
+
+// podLogsAndStates reads the logs and the last state of all containers in the provided pod. if
+// the pod has succeeded then failing to read the logs of any container is considered an error.
+func podLogsAndStates(ctx context.Context, cli kubernetes.Interface, logger *log.Logger, pod corev1.Pod, succeeded bool) (map[string][]byte, map[string]corev1.ContainerState, error) {
+	lastContainerStatuses := map[string]corev1.ContainerState{}
+	for _, status := range pod.Status.ContainerStatuses {
+		lastContainerStatuses[status.Name] = status.State
+	}
+
+	logs := map[string][]byte{}
+	for _, container := range pod.Spec.Containers {
+		options := &corev1.PodLogOptions{Container: container.Name}
+		podLogs, err := cli.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, options).Stream(ctx)
+		if err != nil && succeeded {
+			// if the pod succeed to execute but there is an error to read the container logs we bail.
+			return nil, nil, fmt.Errorf("failed to read container %s logs: %w", container.Name, err)
+		} else if err != nil {
+			message := fmt.Sprintf("failed to get container %s logs: %s", container.Name, err)
+			logger.Print(message)
+			logs[container.Name] = []byte(message)
+			continue
+		}
+
+		output, err := io.ReadAll(podLogs)
+		if closeErr := podLogs.Close(); closeErr != nil {
+			logger.Printf("failed to close pod log stream: %s", closeErr)
+		}
+		if err != nil {
+			return nil, lastContainerStatuses, fmt.Errorf("failed to read pod logs: %w", err)
+		}
+
+		logs[container.Name] = output
+	}
+	return logs, lastContainerStatuses, nil
+}
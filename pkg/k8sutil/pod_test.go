@@ -1,6 +1,8 @@
 package k8sutil
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
@@ -130,3 +132,76 @@ func TestPodUsesPVC(t *testing.T) {
 		})
 	}
 }
+
+func Test_notScheduledError(t *testing.T) {
+	timeout := fmt.Errorf("timeout waiting for job to finish")
+	for _, tt := range []struct {
+		name        string
+		pods        []corev1.Pod
+		unscheduled bool
+		expected    string
+	}{
+		{
+			name:        "should flag jobs without pods",
+			unscheduled: true,
+			expected:    "timeout waiting for job to finish: pod not scheduled",
+		},
+		{
+			name: "should flag pending pods with the scheduler message",
+			pods: []corev1.Pod{
+				{
+					Status: corev1.PodStatus{
+						Phase: corev1.PodPending,
+						Conditions: []corev1.PodCondition{
+							{
+								Type:    corev1.PodScheduled,
+								Status:  corev1.ConditionFalse,
+								Reason:  "Unschedulable",
+								Message: "0/3 nodes are available: 3 Insufficient cpu.",
+							},
+						},
+					},
+				},
+			},
+			unscheduled: true,
+			expected:    "timeout waiting for job to finish: pod not scheduled: 0/3 nodes are available: 3 Insufficient cpu.",
+		},
+		{
+			name: "should not flag pods bound to a node",
+			pods: []corev1.Pod{
+				{
+					Spec:   corev1.PodSpec{NodeName: "node0"},
+					Status: corev1.PodStatus{Phase: corev1.PodPending},
+				},
+			},
+			expected: "timeout waiting for job to finish",
+		},
+		{
+			name: "should not flag scheduled pods",
+			pods: []corev1.Pod{
+				{
+					Status: corev1.PodStatus{
+						Phase: corev1.PodRunning,
+						Conditions: []corev1.PodCondition{
+							{Type: corev1.PodScheduled, Status: corev1.ConditionTrue},
+						},
+					},
+				},
+			},
+			expected: "timeout waiting for job to finish",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := notScheduledError(tt.pods, timeout)
+			if err.Error() != tt.expected {
+				t.Errorf("expected %q, %q received instead", tt.expected, err)
+			}
+			if errors.Is(err, ErrPodNotScheduled) != tt.unscheduled {
+				t.Errorf("expected unscheduled %v, received %v", tt.unscheduled, !tt.unscheduled)
+			}
+			if !errors.Is(err, timeout) {
+				t.Errorf("expected the timeout error to be wrapped")
+			}
+		})
+	}
+}
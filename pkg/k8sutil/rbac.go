@@ -0,0 +1,63 @@
+package k8sutil
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Permission describes an action (verb) over a resource. Namespace is empty for cluster scoped
+// resources.
+type Permission struct {
+	Verb      string
+	Group     string
+	Resource  string
+	Namespace string
+}
+
+// String returns a kubectl like representation of the permission (e.g. "create jobs.batch in
+// namespace default").
+func (p Permission) String() string {
+	resource := p.Resource
+	if p.Group != "" {
+		resource = fmt.Sprintf("%s.%s", p.Resource, p.Group)
+	}
+	if p.Namespace == "" {
+		return fmt.Sprintf("%s %s", p.Verb, resource)
+	}
+	return fmt.Sprintf("%s %s in namespace %s", p.Verb, resource, p.Namespace)
+}
+
+// MissingPermissions issues a SelfSubjectAccessReview for each of the provided permissions and
+// returns the ones that are not granted to the current credentials.
+func MissingPermissions(ctx context.Context, cli kubernetes.Interface, perms []Permission) ([]Permission, error) {
+	var missing []Permission
+	for _, perm := range perms {
+		resource, subresource, _ := strings.Cut(perm.Resource, "/")
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace:   perm.Namespace,
+					Verb:        perm.Verb,
+					Group:       perm.Group,
+					Resource:    resource,
+					Subresource: subresource,
+				},
+			},
+		}
+
+		result, err := cli.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to review access to %s: %w", perm, err)
+		}
+
+		if !result.Status.Allowed {
+			missing = append(missing, perm)
+		}
+	}
+	return missing, nil
+}
@@ -111,3 +111,59 @@ func PVSReservationPerNode(ctx context.Context, cli kubernetes.Interface, scname
 
 	return attached, detached, nil
 }
+
+// PendingPVCSReservationPerNode returns the amount of storage requested by pvcs using the provided storage
+// class that are still pending provisioning. pvcs already scheduled to a node (through the selected-node
+// annotation, as for WaitForFirstConsumer storage classes) are accounted per node while the others are
+// returned as detached.
+func PendingPVCSReservationPerNode(ctx context.Context, cli kubernetes.Interface, scname string) (map[string]int64, int64, error) {
+	pvcs, err := cli.CoreV1().PersistentVolumeClaims("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list persistent volume claims: %w", err)
+	}
+
+	var detached int64
+	attached := map[string]int64{}
+	for _, pvc := range pvcs.Items {
+		if pvc.Status.Phase != corev1.ClaimPending {
+			continue
+		}
+
+		if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName != scname {
+			continue
+		}
+
+		bytes, ok := pvc.Spec.Resources.Requests.Storage().AsInt64()
+		if !ok {
+			return nil, 0, fmt.Errorf("failed to parse pvc %s/%s requested storage size", pvc.Namespace, pvc.Name)
+		}
+
+		if node := pvc.Annotations["volume.kubernetes.io/selected-node"]; node != "" {
+			attached[node] += bytes
+			continue
+		}
+		detached += bytes
+	}
+
+	return attached, detached, nil
+}
+
+// PersistentVolumeNode returns the node the persistent volume is bound to through its required node
+// affinity on the hostname label. returns an empty string if the volume is not bound to a node.
+func PersistentVolumeNode(pv corev1.PersistentVolume) string {
+	if pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+		return ""
+	}
+
+	for _, term := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms {
+		for _, expr := range term.MatchExpressions {
+			if expr.Key != corev1.LabelHostname || expr.Operator != corev1.NodeSelectorOpIn {
+				continue
+			}
+			if len(expr.Values) == 1 {
+				return expr.Values[0]
+			}
+		}
+	}
+	return ""
+}
@@ -407,3 +407,74 @@ func TestPVSByStorageClass(t *testing.T) {
 		})
 	}
 }
+
+func TestPendingPVCSReservationPerNode(t *testing.T) {
+	pvc := func(name, scname string, phase corev1.PersistentVolumeClaimPhase, size, node string) *corev1.PersistentVolumeClaim {
+		claim := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				StorageClassName: &scname,
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceStorage: resource.MustParse(size),
+					},
+				},
+			},
+			Status: corev1.PersistentVolumeClaimStatus{
+				Phase: phase,
+			},
+		}
+		if node != "" {
+			claim.Annotations = map[string]string{"volume.kubernetes.io/selected-node": node}
+		}
+		return claim
+	}
+
+	for _, tt := range []struct {
+		name             string
+		scname           string
+		expectedPerNode  map[string]int64
+		expectedDetached int64
+		objs             []runtime.Object
+	}{
+		{
+			name:            "should return nothing if there are no pvcs",
+			scname:          "openebs",
+			expectedPerNode: map[string]int64{},
+		},
+		{
+			name:   "should account only for pending pvcs in the storage class",
+			scname: "openebs",
+			expectedPerNode: map[string]int64{
+				"node-0": 300,
+			},
+			expectedDetached: 50,
+			objs: []runtime.Object{
+				pvc("bound", "openebs", corev1.ClaimBound, "1000", "node-0"),
+				pvc("pending-other-sc", "rook", corev1.ClaimPending, "1000", "node-0"),
+				pvc("pending-0", "openebs", corev1.ClaimPending, "100", "node-0"),
+				pvc("pending-1", "openebs", corev1.ClaimPending, "200", "node-0"),
+				pvc("pending-detached", "openebs", corev1.ClaimPending, "50", ""),
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			kcli := fake.NewSimpleClientset(tt.objs...)
+			perNode, detached, err := PendingPVCSReservationPerNode(context.Background(), kcli, tt.scname)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if diff := cmp.Diff(tt.expectedPerNode, perNode); diff != "" {
+				t.Errorf("unexpected return: %s", diff)
+			}
+
+			if tt.expectedDetached != detached {
+				t.Errorf("expecting detached to be %v, %v received", tt.expectedDetached, detached)
+			}
+		})
+	}
+}
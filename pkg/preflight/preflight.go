@@ -65,11 +65,11 @@ func Decode(data []byte) (*troubleshootv1beta2.Preflight, error) {
 }
 
 // CollectHostResults collects host preflights, and returns the CollectResult
-func CollectHostResults(_ context.Context, spec *troubleshootv1beta2.HostPreflight, progressChan chan interface{}) (preflight.CollectResult, error) {
+func CollectHostResults(ctx context.Context, spec *troubleshootv1beta2.HostPreflight, progressChan chan interface{}) (preflight.CollectResult, error) {
 	collectOpts := preflight.CollectOpts{
 		ProgressChan: progressChan,
 	}
-	collectResults, err := preflight.CollectHost(collectOpts, spec)
+	collectResults, err := preflight.CollectHostWithContext(ctx, collectOpts, spec)
 	if err != nil {
 		return nil, errors.Wrap(err, "collect host")
 	} else if collectResults == nil {
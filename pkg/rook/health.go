@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -28,6 +29,61 @@ func RookHealth(ctx context.Context, client kubernetes.Interface, ignoreChecks [
 	return health, msg, nil
 }
 
+// HealthReport is the rook-ceph health in a stable shape meant to be consumed by automation, it is
+// what 'kurl rook health --output json' prints. Warnings holds the message of every ceph health
+// check raised, but the ignored ones, prefixed by the check identifier. Message explains why the
+// cluster is considered unhealthy.
+type HealthReport struct {
+	Healthy     bool     `json:"healthy"`
+	CephStatus  string   `json:"ceph_status"`
+	OSDCount    int      `json:"osd_count"`
+	OSDsUp      int      `json:"osds_up"`
+	DegradedPGs int      `json:"degraded_pgs"`
+	Warnings    []string `json:"warnings"`
+	Message     string   `json:"message,omitempty"`
+}
+
+// RookHealthReport returns the current rook-ceph health. Individual checks can be ignored the same
+// way they are by RookHealth.
+func RookHealthReport(ctx context.Context, client kubernetes.Interface, ignoreChecks []string) (HealthReport, error) {
+	cephStatus, err := currentStatus(ctx, client)
+	if err != nil {
+		return HealthReport{}, err
+	}
+	return healthReport(cephStatus, ignoreChecks), nil
+}
+
+// healthReport builds the health report out of the provided ceph status.
+func healthReport(status cephtypes.CephStatus, ignoreChecks []string) HealthReport {
+	report := HealthReport{
+		CephStatus: status.Health.Status,
+		OSDCount:   status.Osdmap.Osdmap.NumOsds,
+		OSDsUp:     status.Osdmap.Osdmap.NumUpOsds,
+		Warnings:   []string{},
+	}
+
+	for _, pgs := range status.Pgmap.PgsByState {
+		for _, state := range strings.Split(pgs.StateName, "+") {
+			if state == "degraded" {
+				report.DegradedPGs += pgs.Count
+				break
+			}
+		}
+	}
+
+	for id, check := range status.Health.Checks {
+		if slices.Contains(ignoreChecks, id) {
+			continue
+		}
+		report.Warnings = append(report.Warnings, fmt.Sprintf("%s: %s", id, check.Summary.Message))
+	}
+	sort.Strings(report.Warnings)
+
+	// isStatusHealthy removes the checks it ignores from the status so the warnings are read first.
+	report.Healthy, report.Message = isStatusHealthy(status, ignoreChecks)
+	return report
+}
+
 // WaitForRookHealth waits for rook-ceph to report that it is healthy 5 times in a row. Individual
 // checks can be ignored by passing in a list of Ceph health check unique identifiers
 // (https://docs.ceph.com/en/quincy/rados/operations/health-checks/) to ignore.
@@ -110,7 +166,7 @@ func isStatusHealthy(status cephtypes.CephStatus, ignoreChecks []string) (bool,
 	}
 
 	if status.Pgmap.RecoveringBytesPerSec != 0 {
-		statusMessage = append(statusMessage, fmt.Sprintf("%d bytes are being recovered per second, 0 desired", status.Pgmap.RecoveringBytesPerSec))
+		statusMessage = append(statusMessage, fmt.Sprintf("%s are being recovered per second, 0 desired", formatBytes(int64(status.Pgmap.RecoveringBytesPerSec), " bytes")))
 	}
 
 	if status.Pgmap.InactivePgsRatio != 0 || status.Pgmap.DegradedRatio != 0 || status.Pgmap.MisplacedRatio != 0 {
@@ -188,7 +244,7 @@ func progressMessage(status cephtypes.CephStatus) string {
 	}
 
 	if status.Pgmap.InactivePgsRatio != 0 || status.Pgmap.DegradedRatio != 0 || status.Pgmap.MisplacedRatio != 0 {
-		return fmt.Sprintf("%f%% of PGs are inactive, %f%% are degraded, and %f%% are misplaced; recovering at %d B/sec", status.Pgmap.InactivePgsRatio*100, status.Pgmap.DegradedRatio*100, status.Pgmap.MisplacedRatio*100, status.Pgmap.RecoveringBytesPerSec)
+		return fmt.Sprintf("%f%% of PGs are inactive, %f%% are degraded, and %f%% are misplaced; recovering at %s/sec", status.Pgmap.InactivePgsRatio*100, status.Pgmap.DegradedRatio*100, status.Pgmap.MisplacedRatio*100, formatBytes(int64(status.Pgmap.RecoveringBytesPerSec), " B"))
 	}
 
 	return ""
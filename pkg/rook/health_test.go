@@ -3,6 +3,8 @@ package rook
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -115,6 +117,82 @@ func Test_isStatusHealthy(t *testing.T) {
 	}
 }
 
+func Test_healthReport(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       []byte
+		ignoreChecks []string
+		expected     HealthReport
+	}{
+		{
+			name:   "healthy ceph",
+			status: testfiles.HealthyCephStatus1,
+			expected: HealthReport{
+				Healthy:    true,
+				CephStatus: "HEALTH_OK",
+				OSDCount:   2,
+				OSDsUp:     2,
+				Warnings:   []string{},
+			},
+		},
+		{
+			name:   "ceph rebalancing multinode",
+			status: testfiles.RebalanceCephStatusMultinode,
+			expected: HealthReport{
+				CephStatus:  "HEALTH_WARN",
+				OSDCount:    6,
+				OSDsUp:      6,
+				DegradedPGs: 100,
+				Warnings:    []string{"PG_DEGRADED: Degraded data redundancy: 28841/67933 objects degraded (42.455%), 100 pgs degraded, 65 pgs undersized"},
+				Message:     "health is HEALTH_WARN because \"Degraded data redundancy: 28841/67933 objects degraded (42.455%), 100 pgs degraded, 65 pgs undersized\" and 18863356 bytes are being recovered per second, 0 desired and 0.000000% of PGs are inactive, 42.455066% are degraded, and 2.081463% are misplaced, 0 required for all and 1 tasks in progress, first task \"Rebalancing after osd.0 marked out\" is 64.823943% complete",
+			},
+		},
+		{
+			name:         "ignored checks are not reported as warnings",
+			status:       testfiles.RebalanceCephStatusMultinode,
+			ignoreChecks: []string{"PG_DEGRADED"},
+			expected: HealthReport{
+				CephStatus:  "HEALTH_WARN",
+				OSDCount:    6,
+				OSDsUp:      6,
+				DegradedPGs: 100,
+				Warnings:    []string{},
+				Message:     "18863356 bytes are being recovered per second, 0 desired and 0.000000% of PGs are inactive, 42.455066% are degraded, and 2.081463% are misplaced, 0 required for all and 1 tasks in progress, first task \"Rebalancing after osd.0 marked out\" is 64.823943% complete",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := require.New(t)
+			status := cephtypes.CephStatus{}
+			err := json.Unmarshal(tt.status, &status)
+			req.NoError(err)
+
+			report := healthReport(status, tt.ignoreChecks)
+			req.Equal(tt.expected, report)
+
+			data, err := json.Marshal(report)
+			req.NoError(err)
+			var decoded map[string]interface{}
+			req.NoError(json.Unmarshal(data, &decoded))
+			req.Equal(tt.expected.CephStatus, decoded["ceph_status"])
+			req.NotNil(decoded["warnings"])
+		})
+	}
+}
+
+func Test_isStatusHealthyBytesFormatter(t *testing.T) {
+	req := require.New(t)
+	status := cephtypes.CephStatus{}
+	req.NoError(json.Unmarshal(testfiles.AutoscalerInProgressCephStatus, &status))
+
+	InitBytesFormatter(func(b int64) string { return fmt.Sprintf("%dKB", b/1000) })
+	defer InitBytesFormatter(nil)
+
+	_, message := isStatusHealthy(status, nil)
+	req.True(strings.HasPrefix(message, "706KB are being recovered per second, 0 desired and "), message)
+}
+
 func Test_parseSafeToRemoveOSD(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -16,7 +16,10 @@ import (
 
 var loopSleep = time.Second * 1
 
-func HostpathToOsd(ctx context.Context, config *rest.Config) error {
+// HostpathToOsd replaces all hostpath based OSDs by block device based OSDs. returns a report with the
+// outcome of the migration of each hostpath OSD. the report is returned even if the migration fails
+// while removing the hostpath OSDs.
+func HostpathToOsd(ctx context.Context, config *rest.Config) (MigrationReport, error) {
 	client := kubernetes.NewForConfigOrDie(config)
 	cephClient := cephv1.NewForConfigOrDie(config)
 
@@ -24,7 +27,7 @@ func HostpathToOsd(ctx context.Context, config *rest.Config) error {
 	// start rook-ceph-tools deployment if not present
 	err := startToolbox(ctx, client)
 	if err != nil {
-		return fmt.Errorf("unable to start rook-ceph-tools before starting migration: %w", err)
+		return NewMigrationReport(nil), fmt.Errorf("unable to start rook-ceph-tools before starting migration: %w", err)
 	}
 
 	// ensure rook is healthy before starting
@@ -32,38 +35,38 @@ func HostpathToOsd(ctx context.Context, config *rest.Config) error {
 	defer minuteCancel()
 	err = WaitForRookHealth(minuteContext, client, nil)
 	if err != nil {
-		return fmt.Errorf("rook failed to become healthy within a minute, aborting migration: %w", err)
+		return NewMigrationReport(nil), fmt.Errorf("rook failed to become healthy within a minute, aborting migration: %w", err)
 	}
 
 	out("Rook is currently healthy, checking if a migration from directory-based storage is required")
 	dirOSDs, blockOSDs, err := countRookOSDs(ctx, client)
 	if err != nil {
-		return fmt.Errorf("failed to determine how many OSDs needed migration: %w", err)
+		return NewMigrationReport(nil), fmt.Errorf("failed to determine how many OSDs needed migration: %w", err)
 	}
 	if dirOSDs == 0 {
 		out("No directory OSDs exist, and so no migration is required.")
-		return nil
+		return NewMigrationReport(nil), nil
 	}
 	out(fmt.Sprintf("%d directory OSDs exist, and %d nodes with block-based OSDs. Continuing with migration.", dirOSDs, blockOSDs))
 
 	// change cephcluster to use OSDs not hostpath (if not already done)
 	err = enableBlockDevices(ctx, client, cephClient)
 	if err != nil {
-		return fmt.Errorf("unable to enable ceph block devices: %w", err)
+		return NewMigrationReport(nil), fmt.Errorf("unable to enable ceph block devices: %w", err)
 	}
 
 	out("Waiting for required block device OSDs to be added to the cluster")
 	// make sure there are at least min(num_nodes, 3) block device OSDs attached and available
 	err = waitForBlockOSDs(ctx, client)
 	if err != nil {
-		return fmt.Errorf("failed to wait for block device OSDs to be added: %w", err)
+		return NewMigrationReport(nil), fmt.Errorf("failed to wait for block device OSDs to be added: %w", err)
 	}
 
 	out("Determining the list of hostpath OSDs to migrate")
 	// determine the list of hostpath OSDs
 	allOSDs, err := getRookOSDs(ctx, client)
 	if err != nil {
-		return fmt.Errorf("failed to get the current list of OSDs: %w", err)
+		return NewMigrationReport(nil), fmt.Errorf("failed to get the current list of OSDs: %w", err)
 	}
 	hostPathOSDs := hostOSDs(allOSDs)
 
@@ -72,17 +75,48 @@ func HostpathToOsd(ctx context.Context, config *rest.Config) error {
 		osdListStrings = append(osdListStrings, fmt.Sprintf("osd.%d", osd))
 	}
 
+	nodes := map[int64]string{}
+	for _, osd := range allOSDs {
+		nodes[osd.Num] = osd.Node
+	}
+
 	out(fmt.Sprintf("Removing hostpath OSDs %s from the cluster", strings.Join(osdListStrings, ", ")))
+	var results []OSDMigrationResult
+	var migrationErr error
 	for _, osdNum := range hostPathOSDs {
-		err = safeRemoveOSD(ctx, client, osdNum)
+		result := OSDMigrationResult{OSD: osdNum, Node: nodes[osdNum]}
+		if migrationErr != nil {
+			result.Status = OSDMigrationSkipped
+			results = append(results, result)
+			continue
+		}
+
+		used, err := osdUsedBytes(ctx, client, osdNum)
 		if err != nil {
-			return fmt.Errorf("failed to safely remove OSD %d: %w", osdNum, err)
+			out(fmt.Sprintf("Unable to determine the amount of data stored in osd.%d: %s", osdNum, err))
+		}
+
+		if err := safeRemoveOSD(ctx, client, osdNum); err != nil {
+			migrationErr = fmt.Errorf("failed to safely remove OSD %d: %w", osdNum, err)
+			result.Status = OSDMigrationFailed
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
 		}
+
+		result.Status = OSDMigrationConverted
+		result.BytesMoved = used
+		results = append(results, result)
+	}
+
+	report := NewMigrationReport(results)
+	if migrationErr != nil {
+		return report, migrationErr
 	}
 
 	out("Migration completed successfully!")
 
-	return nil
+	return report, nil
 }
 
 // enableBlockDevices runs kubectl commands directly to edit the cephcluster object
@@ -0,0 +1,110 @@
+package rook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// OSDMigrationPlanned means the OSD would be migrated, it is only used by migration plans.
+const OSDMigrationPlanned OSDMigrationStatus = "planned"
+
+// osdSpace holds the used and available bytes of an OSD as reported by 'ceph osd df'.
+type osdSpace struct {
+	Used  int64
+	Avail int64
+}
+
+// MigrationPlan describes what a hostpath to block device migration would do without doing it.
+// OSDs holds the hostpath OSDs that would be removed, with the planned status and the estimated
+// amount of data to be moved out of each of them in BytesMoved. the data is moved to the block
+// device OSDs, Fits tells if they have room for it. SufficientBlockOSDs tells if enough block
+// device OSDs are already attached, the migration waits for them otherwise.
+type MigrationPlan struct {
+	OSDs                []OSDMigrationResult `json:"osds"`
+	BytesToMove         int64                `json:"bytesToMove"`
+	BlockOSDs           []int64              `json:"blockOSDs"`
+	BlockAvailableBytes int64                `json:"blockAvailableBytes"`
+	SufficientBlockOSDs bool                 `json:"sufficientBlockOSDs"`
+	Fits                bool                 `json:"fits"`
+}
+
+// parseOSDSpace returns the used and available bytes of each osd, indexed by osd number, according
+// to the output of 'ceph osd df --format json'.
+func parseOSDSpace(output string) (map[int64]osdSpace, error) {
+	var df osdDF
+	if err := json.Unmarshal([]byte(output), &df); err != nil {
+		return nil, fmt.Errorf("failed to parse ceph osd df output: %w", err)
+	}
+
+	space := map[int64]osdSpace{}
+	for _, node := range df.Nodes {
+		space[node.ID] = osdSpace{Used: node.KBUsed * 1024, Avail: node.KBAvail * 1024}
+	}
+	return space, nil
+}
+
+// planMigration builds the migration plan out of the cluster OSDs and their space. the migration
+// fits if the data stored in the hostpath OSDs is smaller than the space available in the block
+// device ones. OSDs missing from the space report are assumed to be empty.
+func planMigration(osds []RookOSD, space map[int64]osdSpace) MigrationPlan {
+	sorted := append([]RookOSD{}, osds...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Num < sorted[j].Num
+	})
+
+	plan := MigrationPlan{OSDs: []OSDMigrationResult{}, BlockOSDs: []int64{}}
+	for _, osd := range sorted {
+		if !osd.IsHostpath {
+			plan.BlockOSDs = append(plan.BlockOSDs, osd.Num)
+			plan.BlockAvailableBytes += space[osd.Num].Avail
+			continue
+		}
+
+		plan.OSDs = append(plan.OSDs, OSDMigrationResult{
+			OSD:        osd.Num,
+			Node:       osd.Node,
+			Status:     OSDMigrationPlanned,
+			BytesMoved: space[osd.Num].Used,
+		})
+		plan.BytesToMove += space[osd.Num].Used
+	}
+	plan.Fits = plan.BytesToMove < plan.BlockAvailableBytes || len(plan.OSDs) == 0
+	return plan
+}
+
+// HostpathToOsdPlan returns what HostpathToOsd would do without changing the cluster in any way. the
+// OSDs space is read through the rook-ceph-tools deployment, which is not started if absent: an error
+// is returned instead.
+func HostpathToOsdPlan(ctx context.Context, config *rest.Config) (MigrationPlan, error) {
+	client := kubernetes.NewForConfigOrDie(config)
+
+	if err := requireToolbox(ctx, client); err != nil {
+		return MigrationPlan{}, fmt.Errorf("%w, a running toolbox is required to plan the migration without changing the cluster", err)
+	}
+
+	osds, err := getRookOSDs(ctx, client)
+	if err != nil {
+		return MigrationPlan{}, fmt.Errorf("failed to get the current list of OSDs: %w", err)
+	}
+
+	stdout, _, err := runToolboxCommand(ctx, client, []string{"ceph", "osd", "df", "--format", "json"})
+	if err != nil {
+		return MigrationPlan{}, fmt.Errorf("failed to run 'ceph osd df --format json': %w", err)
+	}
+
+	space, err := parseOSDSpace(stdout)
+	if err != nil {
+		return MigrationPlan{}, err
+	}
+
+	plan := planMigration(osds, space)
+	if plan.SufficientBlockOSDs, err = HasSufficientBlockOSDs(ctx, client); err != nil {
+		return MigrationPlan{}, fmt.Errorf("failed to count block device OSDs: %w", err)
+	}
+	return plan, nil
+}
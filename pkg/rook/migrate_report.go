@@ -0,0 +1,97 @@
+package rook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// OSDMigrationStatus is the outcome of the migration of a single hostpath OSD.
+type OSDMigrationStatus string
+
+const (
+	// OSDMigrationConverted means the OSD data has been moved and the hostpath OSD removed.
+	OSDMigrationConverted OSDMigrationStatus = "converted"
+	// OSDMigrationFailed means the migration of the OSD failed.
+	OSDMigrationFailed OSDMigrationStatus = "failed"
+	// OSDMigrationSkipped means the OSD was not migrated because a previous one failed.
+	OSDMigrationSkipped OSDMigrationStatus = "skipped"
+)
+
+// OSDMigrationResult holds the outcome of the migration of a hostpath OSD. BytesMoved is the
+// amount of data stored in the OSD before it was reweighted (the data moved to other OSDs).
+type OSDMigrationResult struct {
+	OSD        int64              `json:"osd"`
+	Node       string             `json:"node"`
+	Status     OSDMigrationStatus `json:"status"`
+	BytesMoved int64              `json:"bytesMoved"`
+	Error      string             `json:"error,omitempty"`
+}
+
+// MigrationReport summarizes a hostpath to block device migration.
+type MigrationReport struct {
+	Converted  int                  `json:"converted"`
+	Failed     int                  `json:"failed"`
+	Skipped    int                  `json:"skipped"`
+	BytesMoved int64                `json:"bytesMoved"`
+	OSDs       []OSDMigrationResult `json:"osds"`
+}
+
+// Succeeded returns true if no OSD migration has failed or has been skipped.
+func (m MigrationReport) Succeeded() bool {
+	return m.Failed == 0 && m.Skipped == 0
+}
+
+// NewMigrationReport builds a migration report out of the provided per OSD results.
+func NewMigrationReport(results []OSDMigrationResult) MigrationReport {
+	report := MigrationReport{OSDs: []OSDMigrationResult{}}
+	for _, result := range results {
+		switch result.Status {
+		case OSDMigrationConverted:
+			report.Converted++
+			report.BytesMoved += result.BytesMoved
+		case OSDMigrationFailed:
+			report.Failed++
+		case OSDMigrationSkipped:
+			report.Skipped++
+		}
+		report.OSDs = append(report.OSDs, result)
+	}
+	return report
+}
+
+// osdDF is the subset of the 'ceph osd df --format json' output we care about.
+type osdDF struct {
+	Nodes []struct {
+		ID      int64 `json:"id"`
+		KBUsed  int64 `json:"kb_used"`
+		KBAvail int64 `json:"kb_avail"`
+	} `json:"nodes"`
+}
+
+// parseOSDUsedBytes returns the amount of bytes used by the provided osd according to the output
+// of 'ceph osd df --format json'.
+func parseOSDUsedBytes(output string, osdNum int64) (int64, error) {
+	var df osdDF
+	if err := json.Unmarshal([]byte(output), &df); err != nil {
+		return 0, fmt.Errorf("failed to parse ceph osd df output: %w", err)
+	}
+
+	for _, node := range df.Nodes {
+		if node.ID == osdNum {
+			return node.KBUsed * 1024, nil
+		}
+	}
+	return 0, fmt.Errorf("osd.%d not found in ceph osd df output", osdNum)
+}
+
+// osdUsedBytes returns the amount of bytes used by the provided osd.
+func osdUsedBytes(ctx context.Context, client kubernetes.Interface, osdNum int64) (int64, error) {
+	stdout, _, err := runToolboxCommand(ctx, client, []string{"ceph", "osd", "df", "--format", "json"})
+	if err != nil {
+		return 0, fmt.Errorf("failed to run 'ceph osd df --format json': %w", err)
+	}
+	return parseOSDUsedBytes(stdout, osdNum)
+}
@@ -125,3 +125,114 @@ func Test_getRookOSDs(t *testing.T) {
 		})
 	}
 }
+
+func TestNewMigrationReport(t *testing.T) {
+	tests := []struct {
+		name      string
+		results   []OSDMigrationResult
+		want      MigrationReport
+		succeeded bool
+	}{
+		{
+			name:      "no osds",
+			want:      MigrationReport{OSDs: []OSDMigrationResult{}},
+			succeeded: true,
+		},
+		{
+			name: "all osds converted",
+			results: []OSDMigrationResult{
+				{OSD: 0, Node: "10.0.0.1", Status: OSDMigrationConverted, BytesMoved: 100},
+				{OSD: 1, Node: "10.0.0.2", Status: OSDMigrationConverted, BytesMoved: 200},
+			},
+			want: MigrationReport{
+				Converted:  2,
+				BytesMoved: 300,
+				OSDs: []OSDMigrationResult{
+					{OSD: 0, Node: "10.0.0.1", Status: OSDMigrationConverted, BytesMoved: 100},
+					{OSD: 1, Node: "10.0.0.2", Status: OSDMigrationConverted, BytesMoved: 200},
+				},
+			},
+			succeeded: true,
+		},
+		{
+			name: "partial failure",
+			results: []OSDMigrationResult{
+				{OSD: 0, Node: "10.0.0.1", Status: OSDMigrationConverted, BytesMoved: 100},
+				{OSD: 1, Node: "10.0.0.2", Status: OSDMigrationFailed, Error: "timeout"},
+				{OSD: 2, Node: "10.0.0.3", Status: OSDMigrationSkipped},
+			},
+			want: MigrationReport{
+				Converted:  1,
+				Failed:     1,
+				Skipped:    1,
+				BytesMoved: 100,
+				OSDs: []OSDMigrationResult{
+					{OSD: 0, Node: "10.0.0.1", Status: OSDMigrationConverted, BytesMoved: 100},
+					{OSD: 1, Node: "10.0.0.2", Status: OSDMigrationFailed, Error: "timeout"},
+					{OSD: 2, Node: "10.0.0.3", Status: OSDMigrationSkipped},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := require.New(t)
+			got := NewMigrationReport(tt.results)
+			req.Equal(tt.want, got)
+			req.Equal(tt.succeeded, got.Succeeded())
+
+			_, err := json.Marshal(got)
+			req.NoError(err)
+		})
+	}
+}
+
+func Test_parseOSDUsedBytes(t *testing.T) {
+	output := `{"nodes":[{"id":0,"kb_used":1024},{"id":3,"kb_used":2048}],"summary":{}}`
+
+	req := require.New(t)
+	used, err := parseOSDUsedBytes(output, 3)
+	req.NoError(err)
+	req.Equal(int64(2048*1024), used)
+
+	_, err = parseOSDUsedBytes(output, 1)
+	req.Error(err)
+
+	_, err = parseOSDUsedBytes("not json", 0)
+	req.Error(err)
+}
+
+func Test_planMigration(t *testing.T) {
+	output := `{"nodes":[{"id":0,"kb_used":1024,"kb_avail":0},{"id":1,"kb_used":0,"kb_avail":4096},{"id":2,"kb_used":2048,"kb_avail":10}],"summary":{}}`
+	osds := []RookOSD{
+		{Num: 2, Node: "10.0.0.2", IsHostpath: true},
+		{Num: 1, Node: "10.0.0.1"},
+		{Num: 0, Node: "10.0.0.1", IsHostpath: true},
+	}
+
+	req := require.New(t)
+	space, err := parseOSDSpace(output)
+	req.NoError(err)
+
+	plan := planMigration(osds, space)
+	req.Equal(MigrationPlan{
+		OSDs: []OSDMigrationResult{
+			{OSD: 0, Node: "10.0.0.1", Status: OSDMigrationPlanned, BytesMoved: 1024 * 1024},
+			{OSD: 2, Node: "10.0.0.2", Status: OSDMigrationPlanned, BytesMoved: 2048 * 1024},
+		},
+		BytesToMove:         3072 * 1024,
+		BlockOSDs:           []int64{1},
+		BlockAvailableBytes: 4096 * 1024,
+		Fits:                true,
+	}, plan)
+
+	// the block device osd can't hold the data once it is smaller.
+	space[1] = osdSpace{Avail: 3072 * 1024}
+	req.False(planMigration(osds, space).Fits)
+
+	// nothing to migrate always fits, even without block device osds.
+	req.True(planMigration([]RookOSD{{Num: 1}}, map[int64]osdSpace{}).Fits)
+
+	_, err = parseOSDSpace("not json")
+	req.Error(err)
+}
@@ -19,6 +19,24 @@ func InitWriter(wr io.Writer) {
 	outputWriter = wr
 }
 
+// bytesFormatter formats the byte amounts in health and progress messages, nil keeps plain integers.
+var bytesFormatter func(int64) string
+
+// InitBytesFormatter sets the function used to format byte amounts in health and progress messages.
+// a nil formatter prints them as plain integers.
+func InitBytesFormatter(f func(int64) string) {
+	bytesFormatter = f
+}
+
+// formatBytes returns the provided amount of bytes using the configured formatter, or as an integer
+// followed by rawUnit if none is configured.
+func formatBytes(b int64, rawUnit string) string {
+	if bytesFormatter == nil {
+		return fmt.Sprintf("%d%s", b, rawUnit)
+	}
+	return bytesFormatter(b)
+}
+
 // writes a new line with the provided output
 func out(out string) {
 	if rewriteType != rewriteNone {
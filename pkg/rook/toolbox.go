@@ -24,6 +24,12 @@ var execFunction func(coreClient corev1client.CoreV1Interface, clientConfig *res
 // this is the config used to exec commands in a pod
 var conf *restclient.Config
 
+// InitConfig sets the config used to exec commands in the toolbox pod, the default config is loaded
+// when it is not set.
+func InitConfig(cfg *restclient.Config) {
+	conf = cfg
+}
+
 // determine if the rook-ceph-toolbox deployment exists; if it does ensure scale is proper; if it does not create it with the rook image used by the operator
 func startToolbox(ctx context.Context, client kubernetes.Interface) error {
 	existingToolbox, err := client.AppsV1().Deployments("rook-ceph").Get(ctx, "rook-ceph-tools", metav1.GetOptions{})
@@ -81,6 +87,22 @@ func startToolbox(ctx context.Context, client kubernetes.Interface) error {
 	return nil
 }
 
+// requireToolbox returns an error if the rook-ceph-tools deployment does not exist or has no ready
+// replicas. unlike startToolbox it never changes the cluster.
+func requireToolbox(ctx context.Context, client kubernetes.Interface) error {
+	toolbox, err := client.AppsV1().Deployments("rook-ceph").Get(ctx, "rook-ceph-tools", metav1.GetOptions{})
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return fmt.Errorf("the rook-ceph-tools deployment does not exist")
+		}
+		return fmt.Errorf("unable to determine rook-ceph-tools deployment status: %w", err)
+	}
+	if toolbox.Status.ReadyReplicas == 0 {
+		return fmt.Errorf("the rook-ceph-tools deployment has no ready replicas")
+	}
+	return nil
+}
+
 // determine the current rook image
 func operatorImage(ctx context.Context, client kubernetes.Interface) (string, error) {
 	existingOperator, err := client.AppsV1().Deployments("rook-ceph").Get(ctx, "rook-ceph-operator", metav1.GetOptions{})
@@ -209,3 +209,49 @@ func Test_startToolbox(t *testing.T) {
 		})
 	}
 }
+
+func Test_requireToolbox(t *testing.T) {
+	toolbox := func(ready int32) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-tools", Namespace: "rook-ceph"},
+			Status:     appsv1.DeploymentStatus{ReadyReplicas: ready},
+		}
+	}
+	tests := []struct {
+		name      string
+		resources []runtime.Object
+		wantErr   string
+	}{
+		{
+			name:      "tools deployment is running",
+			resources: []runtime.Object{toolbox(1)},
+		},
+		{
+			name:      "tools deployment is scaled down",
+			resources: []runtime.Object{toolbox(0)},
+			wantErr:   "the rook-ceph-tools deployment has no ready replicas",
+		},
+		{
+			name:    "tools deployment does not exist",
+			wantErr: "the rook-ceph-tools deployment does not exist",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := require.New(t)
+			clientset := fake.NewSimpleClientset(tt.resources...)
+
+			err := requireToolbox(context.Background(), clientset)
+			if tt.wantErr != "" {
+				req.EqualError(err, tt.wantErr)
+			} else {
+				req.NoError(err)
+			}
+
+			// the cluster must be left untouched.
+			deployments, err := clientset.AppsV1().Deployments("rook-ceph").List(context.Background(), metav1.ListOptions{})
+			req.NoError(err)
+			req.Len(deployments.Items, len(tt.resources))
+		})
+	}
+}